@@ -0,0 +1,252 @@
+package aggregate
+
+import (
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/your-org/k8s-ai-exporter/kube"
+)
+
+// Constraint kinds counted by Constraints.Violations.
+const (
+	ConstraintNodeSelector    = "node_selector"
+	ConstraintNodeAffinity    = "node_affinity"
+	ConstraintPodAffinity     = "pod_affinity"
+	ConstraintPodAntiAffinity = "pod_anti_affinity"
+)
+
+// ConstraintKinds lists every constraint kind in reporting order.
+var ConstraintKinds = []string{
+	ConstraintNodeSelector,
+	ConstraintNodeAffinity,
+	ConstraintPodAffinity,
+	ConstraintPodAntiAffinity,
+}
+
+// podViolation is the last evaluation of one pod, reused while neither its
+// node nor anything it depends on changed.
+type podViolation struct {
+	node  string
+	kinds []string
+}
+
+// Constraints evaluates the required scheduling constraints of running
+// pods and caches each pod's result between calls. It is not safe for
+// concurrent use.
+type Constraints struct {
+	// pods caches evaluations by namespace/name.
+	pods map[string]podViolation
+}
+
+// NewConstraints returns an evaluator with an empty cache.
+func NewConstraints() *Constraints {
+	return &Constraints{pods: make(map[string]podViolation)}
+}
+
+// Violations re-evaluates the required (hard) scheduling
+// constraints of active pods against the node they are bound to. The
+// scheduler only checks these at bind time, so label changes on nodes or
+// pods can leave pods running somewhere they would not be placed today.
+//
+// Pods on unchanged nodes keep their cached result, except pods with
+// required pod (anti-)affinity, which depend on pods elsewhere in their
+// topology domain and are always re-evaluated.
+//
+// The result counts violating pods per node and constraint kind; every node
+// has an entry for every kind.
+func (c *Constraints) Violations(nodes []corev1.Node, pods []corev1.Pod, changes kube.NodeChanges) map[string]map[string]float64 {
+	byName := make(map[string]*corev1.Node, len(nodes))
+	counts := make(map[string]map[string]float64, len(nodes))
+	for i := range nodes {
+		byName[nodes[i].Name] = &nodes[i]
+		counts[nodes[i].Name] = make(map[string]float64, len(ConstraintKinds))
+	}
+
+	seen := make(map[string]bool, len(pods))
+	for i := range pods {
+		pod := &pods[i]
+		node, ok := byName[pod.Spec.NodeName]
+		if !ok {
+			continue
+		}
+		key := pod.Namespace + "/" + pod.Name
+		seen[key] = true
+		cached, ok := c.pods[key]
+		if !ok || cached.node != node.Name || changes.Affects(node.Name) || hasRequiredPodAffinity(pod) {
+			cached = podViolation{node: node.Name, kinds: podConstraintViolations(pod, node, pods, byName)}
+			c.pods[key] = cached
+		}
+		for _, kind := range cached.kinds {
+			counts[node.Name][kind]++
+		}
+	}
+	for key := range c.pods {
+		if !seen[key] {
+			delete(c.pods, key)
+		}
+	}
+	return counts
+}
+
+// podConstraintViolations returns the constraint kinds the pod violates on
+// node. Each kind is reported at most once per pod.
+func podConstraintViolations(pod *corev1.Pod, node *corev1.Node, pods []corev1.Pod, nodes map[string]*corev1.Node) []string {
+	var out []string
+	if !labels.SelectorFromSet(pod.Spec.NodeSelector).Matches(labels.Set(node.Labels)) {
+		out = append(out, ConstraintNodeSelector)
+	}
+	aff := pod.Spec.Affinity
+	if aff == nil {
+		return out
+	}
+	if na := aff.NodeAffinity; na != nil && na.RequiredDuringSchedulingIgnoredDuringExecution != nil {
+		if !nodeSelectorMatches(na.RequiredDuringSchedulingIgnoredDuringExecution, node) {
+			out = append(out, ConstraintNodeAffinity)
+		}
+	}
+	if pa := aff.PodAffinity; pa != nil {
+		for _, term := range pa.RequiredDuringSchedulingIgnoredDuringExecution {
+			if countTermMatches(pod, node, term, pods, nodes) == 0 && !termSelectsSelf(pod, term) {
+				out = append(out, ConstraintPodAffinity)
+				break
+			}
+		}
+	}
+	if paa := aff.PodAntiAffinity; paa != nil {
+		for _, term := range paa.RequiredDuringSchedulingIgnoredDuringExecution {
+			if countTermMatches(pod, node, term, pods, nodes) > 0 {
+				out = append(out, ConstraintPodAntiAffinity)
+				break
+			}
+		}
+	}
+	return out
+}
+
+func hasRequiredPodAffinity(pod *corev1.Pod) bool {
+	aff := pod.Spec.Affinity
+	if aff == nil {
+		return false
+	}
+	return (aff.PodAffinity != nil && len(aff.PodAffinity.RequiredDuringSchedulingIgnoredDuringExecution) > 0) ||
+		(aff.PodAntiAffinity != nil && len(aff.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution) > 0)
+}
+
+// nodeSelectorMatches reports whether node satisfies any of the selector's
+// terms (terms are ORed, requirements within a term are ANDed).
+func nodeSelectorMatches(sel *corev1.NodeSelector, node *corev1.Node) bool {
+	for _, term := range sel.NodeSelectorTerms {
+		if nodeSelectorTermMatches(term, node) {
+			return true
+		}
+	}
+	return false
+}
+
+func nodeSelectorTermMatches(term corev1.NodeSelectorTerm, node *corev1.Node) bool {
+	if len(term.MatchExpressions) == 0 && len(term.MatchFields) == 0 {
+		return false
+	}
+	for _, req := range term.MatchExpressions {
+		v, has := node.Labels[req.Key]
+		if !nodeRequirementMatches(req, v, has) {
+			return false
+		}
+	}
+	for _, req := range term.MatchFields {
+		// metadata.name is the only supported node field.
+		if req.Key != "metadata.name" || !nodeRequirementMatches(req, node.Name, true) {
+			return false
+		}
+	}
+	return true
+}
+
+func nodeRequirementMatches(req corev1.NodeSelectorRequirement, value string, has bool) bool {
+	switch req.Operator {
+	case corev1.NodeSelectorOpIn:
+		return has && containsString(req.Values, value)
+	case corev1.NodeSelectorOpNotIn:
+		return !has || !containsString(req.Values, value)
+	case corev1.NodeSelectorOpExists:
+		return has
+	case corev1.NodeSelectorOpDoesNotExist:
+		return !has
+	case corev1.NodeSelectorOpGt, corev1.NodeSelectorOpLt:
+		if !has || len(req.Values) != 1 {
+			return false
+		}
+		got, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return false
+		}
+		want, err := strconv.ParseInt(req.Values[0], 10, 64)
+		if err != nil {
+			return false
+		}
+		if req.Operator == corev1.NodeSelectorOpGt {
+			return got > want
+		}
+		return got < want
+	}
+	return false
+}
+
+// countTermMatches counts pods (other than pod itself) selected by term that
+// run in the same topology domain as node. Terms using a namespaceSelector
+// are treated as spanning all namespaces.
+func countTermMatches(pod *corev1.Pod, node *corev1.Node, term corev1.PodAffinityTerm, pods []corev1.Pod, nodes map[string]*corev1.Node) int {
+	domain, ok := node.Labels[term.TopologyKey]
+	if !ok {
+		return 0
+	}
+	sel, err := metav1.LabelSelectorAsSelector(term.LabelSelector)
+	if err != nil {
+		return 0
+	}
+	namespaces := term.Namespaces
+	if len(namespaces) == 0 && term.NamespaceSelector == nil {
+		namespaces = []string{pod.Namespace}
+	}
+
+	n := 0
+	for i := range pods {
+		other := &pods[i]
+		if other.Namespace == pod.Namespace && other.Name == pod.Name {
+			continue
+		}
+		if len(namespaces) > 0 && !containsString(namespaces, other.Namespace) {
+			continue
+		}
+		otherNode, ok := nodes[other.Spec.NodeName]
+		if !ok || otherNode.Labels[term.TopologyKey] != domain {
+			continue
+		}
+		if sel.Matches(labels.Set(other.Labels)) {
+			n++
+		}
+	}
+	return n
+}
+
+// termSelectsSelf mirrors the scheduler exception that lets the first pod of
+// a self-affine group schedule when no matching pod exists yet.
+func termSelectsSelf(pod *corev1.Pod, term corev1.PodAffinityTerm) bool {
+	sel, err := metav1.LabelSelectorAsSelector(term.LabelSelector)
+	if err != nil {
+		return false
+	}
+	return sel.Matches(labels.Set(pod.Labels))
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,118 @@
+package aggregate
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/your-org/k8s-ai-exporter/kube"
+)
+
+func testNode(name string, lbls map[string]string) corev1.Node {
+	return corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: lbls}}
+}
+
+func testPod(ns, name, node string, lbls map[string]string) corev1.Pod {
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: ns, Name: name, Labels: lbls},
+		Spec:       corev1.PodSpec{NodeName: node},
+	}
+}
+
+func TestNodeRequirementMatches(t *testing.T) {
+	tests := []struct {
+		op     corev1.NodeSelectorOperator
+		values []string
+		value  string
+		has    bool
+		want   bool
+	}{
+		{corev1.NodeSelectorOpIn, []string{"a", "b"}, "b", true, true},
+		{corev1.NodeSelectorOpIn, []string{"a"}, "b", true, false},
+		{corev1.NodeSelectorOpIn, []string{"a"}, "", false, false},
+		{corev1.NodeSelectorOpNotIn, []string{"a"}, "", false, true},
+		{corev1.NodeSelectorOpExists, nil, "x", true, true},
+		{corev1.NodeSelectorOpDoesNotExist, nil, "x", true, false},
+		{corev1.NodeSelectorOpGt, []string{"4"}, "8", true, true},
+		{corev1.NodeSelectorOpLt, []string{"4"}, "8", true, false},
+		{corev1.NodeSelectorOpGt, []string{"4"}, "eight", true, false},
+	}
+	for _, tt := range tests {
+		req := corev1.NodeSelectorRequirement{Key: "k", Operator: tt.op, Values: tt.values}
+		if got := nodeRequirementMatches(req, tt.value, tt.has); got != tt.want {
+			t.Errorf("%s %v on %q (has=%v) = %v, want %v", tt.op, tt.values, tt.value, tt.has, got, tt.want)
+		}
+	}
+}
+
+func TestPodConstraintViolations(t *testing.T) {
+	nodeA := testNode("a", map[string]string{"zone": "z1", "disk": "hdd"})
+	nodeB := testNode("b", map[string]string{"zone": "z1"})
+	nodes := map[string]*corev1.Node{"a": &nodeA, "b": &nodeB}
+
+	web := testPod("default", "web-1", "a", map[string]string{"app": "web"})
+	web.Spec.NodeSelector = map[string]string{"disk": "ssd"}
+	web.Spec.Affinity = &corev1.Affinity{
+		NodeAffinity: &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+				NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+					MatchExpressions: []corev1.NodeSelectorRequirement{{Key: "zone", Operator: corev1.NodeSelectorOpIn, Values: []string{"z1"}}},
+				}},
+			},
+		},
+		PodAntiAffinity: &corev1.PodAntiAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{{
+				LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+				TopologyKey:   "zone",
+			}},
+		},
+	}
+	web2 := testPod("default", "web-2", "b", map[string]string{"app": "web"})
+	pods := []corev1.Pod{web, web2}
+
+	got := podConstraintViolations(&pods[0], &nodeA, pods, nodes)
+	want := []string{ConstraintNodeSelector, ConstraintPodAntiAffinity}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("podConstraintViolations = %v, want %v", got, want)
+	}
+
+	if got := podConstraintViolations(&pods[1], &nodeB, pods, nodes); len(got) != 0 {
+		t.Errorf("unconstrained pod: got %v, want none", got)
+	}
+}
+
+func TestConstraintsReusesCleanNodes(t *testing.T) {
+	c := NewConstraints()
+
+	nodes := []corev1.Node{testNode("a", map[string]string{"disk": "ssd"})}
+	pod := testPod("default", "db-0", "a", nil)
+	pod.Spec.NodeSelector = map[string]string{"disk": "ssd"}
+	pods := []corev1.Pod{pod}
+
+	c.Violations(nodes, pods, kube.AllNodesChanged)
+	if got := c.pods["default/db-0"].kinds; len(got) != 0 {
+		t.Fatalf("initial violations = %v, want none", got)
+	}
+
+	// The label change is not reported as a change, so the cached result stands.
+	nodes[0].Labels["disk"] = "hdd"
+	c.Violations(nodes, pods, kube.NodeChanges{Nodes: map[string]bool{"b": true}})
+	if got := c.pods["default/db-0"].kinds; len(got) != 0 {
+		t.Errorf("clean node re-evaluated: violations = %v", got)
+	}
+
+	counts := c.Violations(nodes, pods, kube.NodeChanges{Nodes: map[string]bool{"a": true}})
+	if got := c.pods["default/db-0"].kinds; !reflect.DeepEqual(got, []string{ConstraintNodeSelector}) {
+		t.Errorf("dirty node violations = %v, want [%s]", got, ConstraintNodeSelector)
+	}
+	if got := counts["a"][ConstraintNodeSelector]; got != 1 {
+		t.Errorf("counts[a][%s] = %v, want 1", ConstraintNodeSelector, got)
+	}
+
+	c.Violations(nodes, nil, kube.NodeChanges{})
+	if len(c.pods) != 0 {
+		t.Errorf("deleted pod still cached: %v", c.pods)
+	}
+}
@@ -0,0 +1,164 @@
+package aggregate
+
+import (
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/your-org/k8s-ai-exporter/collector"
+)
+
+// QueueKey names a batch queue. Namespace is set for Kueue LocalQueues
+// and empty for the cluster-scoped Volcano Queues.
+type QueueKey struct {
+	Scheduler, Namespace, Queue string
+}
+
+// QueueStatus is what waits in a batch queue and for how long it is
+// expected to.
+type QueueStatus struct {
+	Workloads, Pods       int
+	CPUCores, MemoryBytes float64
+	// Wait is the estimated time until the queue's requests fit; WaitKnown
+	// is false while they do not fit and nothing was admitted from the
+	// queue within the window yet.
+	Wait      time.Duration
+	WaitKnown bool
+}
+
+type admission struct {
+	at       time.Time
+	cpu, mem float64
+}
+
+// BatchQueues follows the workloads waiting in batch queues across cycles
+// to estimate how long each queue waits. A queue whose requests fit into
+// the cluster's headroom waits for nothing; otherwise it waits until the
+// missing capacity is admitted at the rate the queue drained within the
+// window. A workload that leaves the queue counts as admitted, including
+// one that was deleted while waiting. It is safe for concurrent use.
+type BatchQueues struct {
+	window time.Duration
+
+	mu       sync.Mutex
+	first    time.Time
+	waiting  map[string]collector.QueuedWorkload // by scheduler/namespace/name
+	admitted map[QueueKey][]admission
+}
+
+// NewBatchQueues returns a tracker that estimates drain rates over window.
+func NewBatchQueues(window time.Duration) *BatchQueues {
+	return &BatchQueues{window: window, waiting: make(map[string]collector.QueuedWorkload), admitted: make(map[QueueKey][]admission)}
+}
+
+// Observe records the workloads waiting at now and returns the status of
+// every queue with waiting workloads. headroomCPU and headroomMemory are
+// the cluster's free capacity (see Headroom); each queue is measured
+// against all of it.
+func (b *BatchQueues) Observe(waiting []collector.QueuedWorkload, headroomCPU, headroomMemory float64, now time.Time) map[QueueKey]QueueStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.first.IsZero() {
+		b.first = now
+	}
+	cur := make(map[string]collector.QueuedWorkload, len(waiting))
+	out := make(map[QueueKey]QueueStatus)
+	for _, w := range waiting {
+		cur[w.Scheduler+"/"+w.Namespace+"/"+w.Name] = w
+		k := queueKey(w)
+		s := out[k]
+		s.Workloads++
+		s.Pods += w.Pods
+		s.CPUCores += w.CPUCores
+		s.MemoryBytes += w.MemoryBytes
+		out[k] = s
+	}
+	for id, w := range b.waiting {
+		if _, ok := cur[id]; !ok {
+			k := queueKey(w)
+			b.admitted[k] = append(b.admitted[k], admission{at: now, cpu: w.CPUCores, mem: w.MemoryBytes})
+		}
+	}
+	b.waiting = cur
+
+	cutoff := now.Add(-b.window)
+	span := min(now.Sub(b.first), b.window).Seconds()
+	for k, as := range b.admitted {
+		i := 0
+		for i < len(as) && !as[i].at.After(cutoff) {
+			i++
+		}
+		if as = as[i:]; len(as) == 0 {
+			delete(b.admitted, k)
+			continue
+		}
+		b.admitted[k] = as
+	}
+	for k, s := range out {
+		missingCPU := max(s.CPUCores-headroomCPU, 0)
+		missingMem := max(s.MemoryBytes-headroomMemory, 0)
+		if missingCPU == 0 && missingMem == 0 {
+			s.WaitKnown = true
+			out[k] = s
+			continue
+		}
+		var cpu, mem float64
+		for _, a := range b.admitted[k] {
+			cpu += a.cpu
+			mem += a.mem
+		}
+		if span <= 0 || (missingCPU > 0 && cpu == 0) || (missingMem > 0 && mem == 0) {
+			continue
+		}
+		var secs float64
+		if missingCPU > 0 {
+			secs = missingCPU * span / cpu
+		}
+		if missingMem > 0 {
+			secs = max(secs, missingMem*span/mem)
+		}
+		s.Wait = time.Duration(secs * float64(time.Second))
+		s.WaitKnown = true
+		out[k] = s
+	}
+	return out
+}
+
+func queueKey(w collector.QueuedWorkload) QueueKey {
+	k := QueueKey{Scheduler: w.Scheduler, Queue: w.Queue}
+	if w.Scheduler == collector.SchedulerKueue {
+		k.Namespace = w.Namespace
+	}
+	return k
+}
+
+// Headroom returns the CPU and memory that is free for new pods: the
+// allocatable capacity of schedulable nodes minus the requests of the pods
+// on them.
+func Headroom(nodes []corev1.Node, pods []corev1.Pod) (cpu, mem float64) {
+	free := make(map[string]nodeFree, len(nodes))
+	for i := range nodes {
+		n := &nodes[i]
+		if n.Spec.Unschedulable {
+			continue
+		}
+		free[n.Name] = nodeFree{
+			cpu: n.Status.Allocatable.Cpu().AsApproximateFloat64(),
+			mem: n.Status.Allocatable.Memory().AsApproximateFloat64(),
+		}
+	}
+	for i := range pods {
+		if f, ok := free[pods[i].Spec.NodeName]; ok {
+			c, m := PodRequests(&pods[i])
+			f.cpu -= c
+			f.mem -= m
+			free[pods[i].Spec.NodeName] = f
+		}
+	}
+	for _, f := range free {
+		cpu += max(f.cpu, 0)
+		mem += max(f.mem, 0)
+	}
+	return cpu, mem
+}
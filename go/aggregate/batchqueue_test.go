@@ -0,0 +1,71 @@
+package aggregate
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/your-org/k8s-ai-exporter/collector"
+)
+
+func TestBatchQueues(t *testing.T) {
+	b := NewBatchQueues(time.Hour)
+	now := time.Unix(1700000000, 0)
+	job := func(name string, cpu float64) collector.QueuedWorkload {
+		return collector.QueuedWorkload{Scheduler: collector.SchedulerKueue, Namespace: "ml", Name: name, Queue: "train", Pods: 2, CPUCores: cpu, MemoryBytes: 1 << 30}
+	}
+	train := QueueKey{Scheduler: collector.SchedulerKueue, Namespace: "ml", Queue: "train"}
+	gang := QueueKey{Scheduler: collector.SchedulerVolcano, Queue: "default"}
+
+	got := b.Observe([]collector.QueuedWorkload{
+		job("a", 8), job("b", 8), job("c", 8),
+		{Scheduler: collector.SchedulerVolcano, Namespace: "hpc", Name: "mpi", Queue: "default", Pods: 4, CPUCores: 2},
+	}, 4, 64<<30, now)
+	if s := got[train]; s.Workloads != 3 || s.Pods != 6 || s.CPUCores != 24 || s.WaitKnown {
+		t.Errorf("first observation: %+v, want 3 workloads, 24 cores and an unknown wait", s)
+	}
+	if s := got[gang]; s.Workloads != 1 || !s.WaitKnown || s.Wait != 0 {
+		t.Errorf("volcano queue within the headroom: %+v, want no wait", s)
+	}
+
+	// 8 cores admitted in 10 minutes; 12 more missing take 15 minutes.
+	now = now.Add(10 * time.Minute)
+	got = b.Observe([]collector.QueuedWorkload{job("b", 8), job("c", 8)}, 4, 64<<30, now)
+	if s := got[train]; s.Workloads != 2 || !s.WaitKnown || s.Wait != 15*time.Minute {
+		t.Errorf("after an admission: %+v, want a 15m wait", s)
+	}
+	if _, ok := got[gang]; ok {
+		t.Error("empty queue still reported")
+	}
+
+	// The admission falls out of the window.
+	now = now.Add(time.Hour)
+	if s := b.Observe([]collector.QueuedWorkload{job("b", 8), job("c", 8)}, 4, 64<<30, now)[train]; s.WaitKnown {
+		t.Errorf("wait %v known without admissions in the window", s.Wait)
+	}
+}
+
+func TestHeadroom(t *testing.T) {
+	node := func(name string, unschedulable bool) corev1.Node {
+		return corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       corev1.NodeSpec{Unschedulable: unschedulable},
+			Status: corev1.NodeStatus{Allocatable: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("4"),
+				corev1.ResourceMemory: resource.MustParse("8Gi"),
+			}},
+		}
+	}
+	pod := func(node, cpu, mem string) corev1.Pod {
+		return corev1.Pod{Spec: corev1.PodSpec{NodeName: node, Containers: []corev1.Container{{Resources: requests(cpu, mem)}}}}
+	}
+	nodes := []corev1.Node{node("a", false), node("b", false), node("cordoned", true)}
+	pods := []corev1.Pod{pod("a", "1", "2Gi"), pod("b", "6", "1Gi")}
+	cpu, mem := Headroom(nodes, pods)
+	if cpu != 3 || mem != 13<<30 {
+		t.Errorf("Headroom = %v cores, %v bytes; want 3 cores, 13Gi", cpu, mem)
+	}
+}
@@ -0,0 +1,85 @@
+package aggregate
+
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	regionLabel       = "topology.kubernetes.io/region"
+	legacyRegionLabel = "failure-domain.beta.kubernetes.io/region"
+)
+
+// Lifecycle values of CloudInfo.
+const (
+	LifecycleOnDemand = "on-demand"
+	LifecycleSpot     = "spot"
+)
+
+// spotLabels are the node labels provisioners and managed node pools use
+// to mark spot or preemptible capacity, with the value meaning "spot".
+var spotLabels = []struct{ key, value string }{
+	{"karpenter.sh/capacity-type", "spot"},
+	{"eks.amazonaws.com/capacityType", "SPOT"},
+	{"node.kubernetes.io/lifecycle", "spot"},
+	{"cloud.google.com/gke-spot", "true"},
+	{"cloud.google.com/gke-preemptible", "true"},
+	{"kubernetes.azure.com/scalesetpriority", "spot"},
+}
+
+// CloudInfo is what the cloud provider's controllers record on a node.
+// Fields are empty when the node does not carry the information, e.g. on
+// bare metal.
+type CloudInfo struct {
+	Provider     string // scheme of spec.providerID: aws, gce, azure, ...
+	Region       string
+	Zone         string
+	InstanceType string
+	Lifecycle    string // LifecycleSpot or LifecycleOnDemand; empty without a provider
+	CPUCores     float64
+	MemoryBytes  float64
+}
+
+// NodeCloudInfo reads n's cloud metadata from its providerID, well-known
+// labels and capacity, which the cloud controller manager fills in from
+// the provider's APIs.
+func NodeCloudInfo(n *corev1.Node) CloudInfo {
+	info := CloudInfo{
+		Region:       n.Labels[regionLabel],
+		Zone:         nodeZoneName(n),
+		InstanceType: nodeInstanceType(n),
+		CPUCores:     n.Status.Capacity.Cpu().AsApproximateFloat64(),
+		MemoryBytes:  n.Status.Capacity.Memory().AsApproximateFloat64(),
+	}
+	if info.Region == "" {
+		info.Region = n.Labels[legacyRegionLabel]
+	}
+	if scheme, _, ok := strings.Cut(n.Spec.ProviderID, "://"); ok {
+		info.Provider = scheme
+		info.Lifecycle = LifecycleOnDemand
+	}
+	for _, l := range spotLabels {
+		if n.Labels[l.key] == l.value {
+			info.Lifecycle = LifecycleSpot
+			break
+		}
+	}
+	return info
+}
+
+// NodesCloudInfo returns NodeCloudInfo per node name.
+func NodesCloudInfo(nodes []corev1.Node) map[string]CloudInfo {
+	out := make(map[string]CloudInfo, len(nodes))
+	for i := range nodes {
+		out[nodes[i].Name] = NodeCloudInfo(&nodes[i])
+	}
+	return out
+}
+
+func nodeInstanceType(n *corev1.Node) string {
+	if t := n.Labels[instanceTypeLabel]; t != "" {
+		return t
+	}
+	return n.Labels[legacyInstanceTypeLabel]
+}
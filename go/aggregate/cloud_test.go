@@ -0,0 +1,58 @@
+package aggregate
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNodeCloudInfo(t *testing.T) {
+	tests := []struct {
+		name       string
+		providerID string
+		labels     map[string]string
+		want       CloudInfo
+	}{
+		{
+			name:       "eks on-demand",
+			providerID: "aws:///eu-west-1a/i-0abc",
+			labels: map[string]string{
+				regionLabel: "eu-west-1", zoneLabel: "eu-west-1a", instanceTypeLabel: "m5.large",
+				"eks.amazonaws.com/capacityType": "ON_DEMAND",
+			},
+			want: CloudInfo{Provider: "aws", Region: "eu-west-1", Zone: "eu-west-1a", InstanceType: "m5.large", Lifecycle: LifecycleOnDemand},
+		},
+		{
+			name:       "gke spot with legacy labels",
+			providerID: "gce://proj/us-central1-b/node-1",
+			labels: map[string]string{
+				legacyRegionLabel: "us-central1", legacyZoneLabel: "us-central1-b", legacyInstanceTypeLabel: "e2-standard-4",
+				"cloud.google.com/gke-spot": "true",
+			},
+			want: CloudInfo{Provider: "gce", Region: "us-central1", Zone: "us-central1-b", InstanceType: "e2-standard-4", Lifecycle: LifecycleSpot},
+		},
+		{
+			name:   "bare metal",
+			labels: map[string]string{"kubernetes.io/hostname": "rack1-3"},
+			want:   CloudInfo{},
+		},
+	}
+	for _, tt := range tests {
+		n := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Labels: tt.labels}, Spec: corev1.NodeSpec{ProviderID: tt.providerID}}
+		if got := NodeCloudInfo(n); got != tt.want {
+			t.Errorf("%s: NodeCloudInfo = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestNodeCloudInfoCapacity(t *testing.T) {
+	n := &corev1.Node{Status: corev1.NodeStatus{Capacity: corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("4"),
+		corev1.ResourceMemory: resource.MustParse("16Gi"),
+	}}}
+	if got := NodeCloudInfo(n); got.CPUCores != 4 || got.MemoryBytes != 16<<30 {
+		t.Errorf("capacity = %v cores, %v bytes; want 4, %d", got.CPUCores, got.MemoryBytes, 16<<30)
+	}
+}
@@ -0,0 +1,112 @@
+package aggregate
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/your-org/k8s-ai-exporter/collector"
+)
+
+// Container types of the k8s_namespace_container_* families.
+const (
+	ContainerApp       = "app"
+	ContainerInit      = "init"
+	ContainerEphemeral = "ephemeral"
+	ContainerSidecar   = "sidecar"
+)
+
+// SidecarPatterns are container name globs in path.Match syntax, such as
+// "istio-proxy" or "fluent-*", that mark regular containers as sidecars.
+type SidecarPatterns []string
+
+// ParseSidecarPatterns parses a comma-separated list of globs.
+func ParseSidecarPatterns(csv string) (SidecarPatterns, error) {
+	var patterns SidecarPatterns
+	for _, p := range strings.Split(csv, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		if _, err := path.Match(p, ""); err != nil {
+			return nil, fmt.Errorf("sidecar pattern %q: %v", p, err)
+		}
+		patterns = append(patterns, p)
+	}
+	return patterns, nil
+}
+
+// Match reports whether a container name matches one of the patterns.
+func (sp SidecarPatterns) Match(name string) bool {
+	for _, p := range sp {
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ContainerTypes maps the init, ephemeral (debug) and sidecar containers
+// of pods to their type; every other container is ContainerApp. Sidecars
+// are init containers with restartPolicy Always (native sidecars) and
+// regular containers whose name matches sidecars.
+func ContainerTypes(pods []corev1.Pod, sidecars SidecarPatterns) map[collector.ContainerRef]string {
+	types := make(map[collector.ContainerRef]string)
+	for i := range pods {
+		p := &pods[i]
+		ref := func(name string) collector.ContainerRef {
+			return collector.ContainerRef{Namespace: p.Namespace, Pod: p.Name, Name: name}
+		}
+		for _, c := range p.Spec.InitContainers {
+			if c.RestartPolicy != nil && *c.RestartPolicy == corev1.ContainerRestartPolicyAlways {
+				types[ref(c.Name)] = ContainerSidecar
+			} else {
+				types[ref(c.Name)] = ContainerInit
+			}
+		}
+		for _, c := range p.Spec.Containers {
+			if sidecars.Match(c.Name) {
+				types[ref(c.Name)] = ContainerSidecar
+			}
+		}
+		for _, c := range p.Spec.EphemeralContainers {
+			types[ref(c.Name)] = ContainerEphemeral
+		}
+	}
+	return types
+}
+
+// UsageByContainerType sums container usage per namespace and container
+// type, keyed by ContainerTypeKey. Pod-level cgroups are left out.
+func UsageByContainerType(types map[collector.ContainerRef]string, containers map[collector.ContainerRef]collector.NamespaceUsage) map[string]collector.NamespaceUsage {
+	out := make(map[string]collector.NamespaceUsage)
+	for ref, u := range containers {
+		if ref.Name == "" {
+			continue
+		}
+		typ, ok := types[ref]
+		if !ok {
+			typ = ContainerApp
+		}
+		key := ContainerTypeKey(ref.Namespace, typ)
+		sum := out[key]
+		sum.CPU += u.CPU
+		sum.Mem += u.Mem
+		out[key] = sum
+	}
+	return out
+}
+
+// ContainerTypeKey joins a namespace and a container type into one key, so
+// UsageCounters can track them; namespace names cannot contain '/'.
+func ContainerTypeKey(namespace, typ string) string {
+	return namespace + "/" + typ
+}
+
+// SplitContainerTypeKey is the inverse of ContainerTypeKey.
+func SplitContainerTypeKey(key string) (namespace, typ string) {
+	namespace, typ, _ = strings.Cut(key, "/")
+	return namespace, typ
+}
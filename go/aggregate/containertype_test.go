@@ -0,0 +1,56 @@
+package aggregate
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/your-org/k8s-ai-exporter/collector"
+)
+
+func TestUsageByContainerType(t *testing.T) {
+	always := corev1.ContainerRestartPolicyAlways
+	pods := []corev1.Pod{{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "a"},
+		Spec: corev1.PodSpec{
+			InitContainers: []corev1.Container{
+				{Name: "migrate"},
+				{Name: "proxy", RestartPolicy: &always},
+			},
+			Containers:          []corev1.Container{{Name: "app"}, {Name: "fluent-bit"}},
+			EphemeralContainers: []corev1.EphemeralContainer{{EphemeralContainerCommon: corev1.EphemeralContainerCommon{Name: "debugger-x"}}},
+		},
+	}}
+	sidecars, err := ParseSidecarPatterns("istio-proxy, fluent-*")
+	if err != nil {
+		t.Fatalf("ParseSidecarPatterns: %v", err)
+	}
+	types := ContainerTypes(pods, sidecars)
+	containers := map[collector.ContainerRef]collector.NamespaceUsage{
+		{Namespace: "shop", Pod: "a"}:                     {PodCPU: 40, PodMem: 900},
+		{Namespace: "shop", Pod: "a", Name: "migrate"}:    {CPU: 5, Mem: 100},
+		{Namespace: "shop", Pod: "a", Name: "proxy"}:      {CPU: 3, Mem: 50},
+		{Namespace: "shop", Pod: "a", Name: "app"}:        {CPU: 20, Mem: 500},
+		{Namespace: "shop", Pod: "a", Name: "fluent-bit"}: {CPU: 4, Mem: 60},
+		{Namespace: "shop", Pod: "a", Name: "debugger-x"}: {CPU: 2, Mem: 30},
+		{Namespace: "ci", Pod: "gone", Name: "build"}:     {CPU: 1},
+	}
+	want := map[string]collector.NamespaceUsage{
+		"shop/app":       {CPU: 20, Mem: 500},
+		"shop/sidecar":   {CPU: 7, Mem: 110},
+		"shop/init":      {CPU: 5, Mem: 100},
+		"shop/ephemeral": {CPU: 2, Mem: 30},
+		"ci/app":         {CPU: 1},
+	}
+	if got := UsageByContainerType(types, containers); !reflect.DeepEqual(got, want) {
+		t.Errorf("UsageByContainerType = %v, want %v", got, want)
+	}
+	if _, err := ParseSidecarPatterns("istio-proxy,[a-"); err == nil {
+		t.Error("ParseSidecarPatterns accepted a malformed glob")
+	}
+	if ns, typ := SplitContainerTypeKey(ContainerTypeKey("shop", ContainerInit)); ns != "shop" || typ != ContainerInit {
+		t.Errorf("SplitContainerTypeKey = %q, %q; want shop, init", ns, typ)
+	}
+}
@@ -0,0 +1,128 @@
+package aggregate
+
+import (
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/your-org/k8s-ai-exporter/collector"
+)
+
+// ControlPlaneStatus is the health of one control plane pod between two
+// scrapes.
+type ControlPlaneStatus struct {
+	Component string
+	// Up is false when the pod could not be scraped; the other fields are
+	// then unset.
+	Up bool
+	// SchedulingP99 is the 99th percentile latency of the pods the
+	// scheduler placed since the previous scrape, NaN when it placed none
+	// or on the first scrape.
+	SchedulingP99   float64
+	EtcdDBSizeBytes float64
+	EtcdHasLeader   bool
+	// EtcdLeaderChanges is the increase of the member's leader change
+	// counter since the previous scrape.
+	EtcdLeaderChanges float64
+}
+
+// ControlPlaneRates turns the cumulative histograms and counters of
+// control plane pods into ControlPlaneStatus using the previous
+// observation of each pod. It is safe for concurrent use.
+type ControlPlaneRates struct {
+	mu   sync.Mutex
+	prev map[string]collector.ControlPlaneSample
+}
+
+// NewControlPlaneRates returns a tracker without previous observations.
+func NewControlPlaneRates() *ControlPlaneRates {
+	return &ControlPlaneRates{prev: make(map[string]collector.ControlPlaneSample)}
+}
+
+// Observe records cur for pod of component and returns its status. A
+// counter or histogram that went down was reset and counts from zero.
+func (r *ControlPlaneRates) Observe(pod, component string, cur collector.ControlPlaneSample) ControlPlaneStatus {
+	r.mu.Lock()
+	prev, ok := r.prev[pod]
+	r.prev[pod] = cur
+	r.mu.Unlock()
+	s := ControlPlaneStatus{
+		Component:       component,
+		Up:              true,
+		SchedulingP99:   math.NaN(),
+		EtcdDBSizeBytes: cur.EtcdDBSizeBytes,
+		EtcdHasLeader:   cur.EtcdHasLeader,
+	}
+	if !ok {
+		return s
+	}
+	s.EtcdLeaderChanges = counterIncrease(prev.EtcdLeaderChanges, cur.EtcdLeaderChanges)
+	if len(cur.SchedulingBuckets) > 0 {
+		reset := false
+		for le, n := range cur.SchedulingBuckets {
+			if n < prev.SchedulingBuckets[le] {
+				reset = true
+			}
+		}
+		delta := make(map[float64]float64, len(cur.SchedulingBuckets))
+		for le, n := range cur.SchedulingBuckets {
+			if reset {
+				delta[le] = n
+			} else {
+				delta[le] = n - prev.SchedulingBuckets[le]
+			}
+		}
+		s.SchedulingP99 = HistogramQuantile(0.99, delta)
+	}
+	return s
+}
+
+// Retain forgets the state of pods not in pods.
+func (r *ControlPlaneRates) Retain(pods map[string]bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for pod := range r.prev {
+		if !pods[pod] {
+			delete(r.prev, pod)
+		}
+	}
+}
+
+func counterIncrease(prev, cur float64) float64 {
+	if cur < prev {
+		return cur
+	}
+	return cur - prev
+}
+
+// HistogramQuantile returns the q quantile of a histogram given as
+// cumulative counts by upper bound, interpolating linearly within the
+// bucket like PromQL's histogram_quantile. It returns NaN for an empty
+// histogram, and the highest finite bound when the quantile falls into the
+// +Inf bucket.
+func HistogramQuantile(q float64, buckets map[float64]float64) float64 {
+	bounds := make([]float64, 0, len(buckets))
+	for le := range buckets {
+		bounds = append(bounds, le)
+	}
+	sort.Float64s(bounds)
+	if len(bounds) == 0 || buckets[bounds[len(bounds)-1]] <= 0 {
+		return math.NaN()
+	}
+	rank := q * buckets[bounds[len(bounds)-1]]
+	prevBound, prevCount := 0.0, 0.0
+	for _, le := range bounds {
+		count := buckets[le]
+		if count >= rank {
+			if math.IsInf(le, 1) {
+				return prevBound
+			}
+			if count == prevCount {
+				return le
+			}
+			return prevBound + (le-prevBound)*(rank-prevCount)/(count-prevCount)
+		}
+		prevBound, prevCount = le, count
+	}
+	return prevBound
+}
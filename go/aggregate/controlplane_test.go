@@ -0,0 +1,54 @@
+package aggregate
+
+import (
+	"math"
+	"testing"
+
+	"github.com/your-org/k8s-ai-exporter/collector"
+)
+
+func TestHistogramQuantile(t *testing.T) {
+	inf := math.Inf(1)
+	tests := []struct {
+		buckets map[float64]float64
+		q, want float64
+	}{
+		{map[float64]float64{0.1: 50, 0.2: 100, inf: 100}, 0.5, 0.1},
+		{map[float64]float64{0.1: 50, 0.2: 100, inf: 100}, 0.75, 0.15},
+		{map[float64]float64{0.1: 0, 0.2: 0, inf: 10}, 0.99, 0.2},
+		{map[float64]float64{0.1: 0, inf: 0}, 0.99, math.NaN()},
+	}
+	for _, tt := range tests {
+		got := HistogramQuantile(tt.q, tt.buckets)
+		if !(math.Abs(got-tt.want) < 1e-9 || math.IsNaN(got) && math.IsNaN(tt.want)) {
+			t.Errorf("HistogramQuantile(%g, %v) = %g, want %g", tt.q, tt.buckets, got, tt.want)
+		}
+	}
+}
+
+func TestControlPlaneRates(t *testing.T) {
+	inf := math.Inf(1)
+	r := NewControlPlaneRates()
+	first := collector.ControlPlaneSample{SchedulingBuckets: map[float64]float64{0.01: 100, 1: 100, inf: 100}, EtcdLeaderChanges: 4}
+	s := r.Observe("sched", collector.ComponentScheduler, first)
+	if !s.Up || !math.IsNaN(s.SchedulingP99) {
+		t.Errorf("first Observe = %+v, want up without a p99", s)
+	}
+
+	// 100 more pods, all between 10ms and 1s.
+	second := collector.ControlPlaneSample{SchedulingBuckets: map[float64]float64{0.01: 100, 1: 200, inf: 200}, EtcdLeaderChanges: 6}
+	s = r.Observe("sched", collector.ComponentScheduler, second)
+	if math.Abs(s.SchedulingP99-0.9901) > 1e-9 || s.EtcdLeaderChanges != 2 {
+		t.Errorf("second Observe = %+v, want p99 0.9901 and 2 leader changes", s)
+	}
+
+	if s = r.Observe("sched", collector.ComponentScheduler, second); !math.IsNaN(s.SchedulingP99) {
+		t.Errorf("p99 without scheduled pods = %g, want NaN", s.SchedulingP99)
+	}
+
+	// A restart resets the histogram and counter.
+	restarted := collector.ControlPlaneSample{SchedulingBuckets: map[float64]float64{0.01: 10, 1: 10, inf: 10}, EtcdLeaderChanges: 1}
+	if s = r.Observe("sched", collector.ComponentScheduler, restarted); s.SchedulingP99 > 0.01 || s.EtcdLeaderChanges != 1 {
+		t.Errorf("Observe after a restart = %+v", s)
+	}
+}
@@ -0,0 +1,26 @@
+package aggregate
+
+import "github.com/your-org/k8s-ai-exporter/collector"
+
+// NamespaceCost is the scrape cost one namespace's pods add to a cycle,
+// summed over nodes.
+type NamespaceCost struct {
+	Series       float64
+	Bytes        float64
+	ParseSeconds float64
+}
+
+// AddNamespaceCosts adds the namespace shares of s to into. Parse time is
+// not measured per series, so each namespace is charged the fraction of the
+// node's parse time that matches its fraction of the exposition's bytes.
+func AddNamespaceCosts(into map[string]NamespaceCost, s collector.NodeSample) {
+	for ns, c := range s.Namespaces {
+		cost := into[ns]
+		cost.Series += float64(c.Series)
+		cost.Bytes += float64(c.Bytes)
+		if s.Bytes > 0 {
+			cost.ParseSeconds += s.ParseTime.Seconds() * float64(c.Bytes) / float64(s.Bytes)
+		}
+		into[ns] = cost
+	}
+}
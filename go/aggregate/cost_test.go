@@ -0,0 +1,42 @@
+package aggregate
+
+import (
+	"testing"
+	"time"
+
+	"github.com/your-org/k8s-ai-exporter/collector"
+)
+
+func TestAddNamespaceCosts(t *testing.T) {
+	costs := make(map[string]NamespaceCost)
+	AddNamespaceCosts(costs, collector.NodeSample{
+		Namespaces: map[string]collector.NamespaceCost{"shop": {Series: 3, Bytes: 500}, "batch": {Series: 1, Bytes: 250}},
+		Bytes:      1000,
+		ParseTime:  2 * time.Second,
+	})
+	AddNamespaceCosts(costs, collector.NodeSample{
+		Namespaces: map[string]collector.NamespaceCost{"shop": {Series: 2, Bytes: 200}},
+		Bytes:      400,
+		ParseTime:  time.Second,
+	})
+	want := map[string]NamespaceCost{
+		"shop":  {Series: 5, Bytes: 700, ParseSeconds: 1.5},
+		"batch": {Series: 1, Bytes: 250, ParseSeconds: 0.5},
+	}
+	for ns, w := range want {
+		if got := costs[ns]; got != w {
+			t.Errorf("costs[%q] = %+v, want %+v", ns, got, w)
+		}
+	}
+	if len(costs) != len(want) {
+		t.Errorf("costs = %v, want %d namespaces", costs, len(want))
+	}
+}
+
+func TestAddNamespaceCostsEmptyBody(t *testing.T) {
+	costs := make(map[string]NamespaceCost)
+	AddNamespaceCosts(costs, collector.NodeSample{Namespaces: map[string]collector.NamespaceCost{"a": {Series: 1, Bytes: 0}}})
+	if got := costs["a"]; got.ParseSeconds != 0 || got.Series != 1 {
+		t.Errorf("costs[a] = %+v", got)
+	}
+}
@@ -0,0 +1,65 @@
+package aggregate
+
+import (
+	"sync"
+	"time"
+)
+
+type cpuSnapshot struct {
+	coreSeconds float64
+	at          time.Time
+	cores       float64 // rate of the previous interval
+	hasRate     bool
+}
+
+// CPURates converts each node's cumulative CPU time in core-seconds, as
+// summed from container_cpu_usage_seconds_total or the Summary API, into
+// the cores it used on average since the previous observation. Node usage
+// is exported in cores whatever the source, so scraped nodes and nodes
+// taken from metrics-server agree. It is safe for concurrent use.
+//
+// The summed counter drops whenever a container goes away; such an
+// interval keeps the node's previous rate instead of reporting zero.
+type CPURates struct {
+	mu   sync.Mutex
+	prev map[string]cpuSnapshot
+}
+
+// NewCPURates returns a tracker without previous observations.
+func NewCPURates() *CPURates {
+	return &CPURates{prev: make(map[string]cpuSnapshot)}
+}
+
+// Observe records node's cumulative coreSeconds at now and returns its
+// average cores since the previous observation. The first observation of
+// a node only primes the state and returns ok=false; so does a decrease
+// before the node had a rate.
+func (r *CPURates) Observe(node string, coreSeconds float64, now time.Time) (cores float64, ok bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	prev, seen := r.prev[node]
+	cur := cpuSnapshot{coreSeconds: coreSeconds, at: now, cores: prev.cores, hasRate: prev.hasRate}
+	elapsed := now.Sub(prev.at).Seconds()
+	switch {
+	case !seen:
+	case elapsed <= 0:
+		cur.at = prev.at
+		cur.coreSeconds = prev.coreSeconds
+	case coreSeconds >= prev.coreSeconds:
+		cur.cores = (coreSeconds - prev.coreSeconds) / elapsed
+		cur.hasRate = true
+	}
+	r.prev[node] = cur
+	return cur.cores, cur.hasRate
+}
+
+// Retain forgets the state of nodes not in nodes.
+func (r *CPURates) Retain(nodes map[string]bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for node := range r.prev {
+		if !nodes[node] {
+			delete(r.prev, node)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package aggregate
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCPURates(t *testing.T) {
+	r := NewCPURates()
+	start := time.Unix(1700000000, 0)
+	// Realistic summed container_cpu_usage_seconds_total values of a node
+	// that has been up for a while.
+	if _, ok := r.Observe("a", 2823.4, start); ok {
+		t.Fatal("first observation returned a rate")
+	}
+	cores, ok := r.Observe("a", 2883.4, start.Add(30*time.Second))
+	if !ok || cores != 2 {
+		t.Errorf("Observe = %v, %v; want 2 cores", cores, ok)
+	}
+	// A container went away and took its counter with it.
+	cores, ok = r.Observe("a", 1500, start.Add(time.Minute))
+	if !ok || cores != 2 {
+		t.Errorf("Observe after a decrease = %v, %v; want the previous 2 cores", cores, ok)
+	}
+	cores, ok = r.Observe("a", 1530, start.Add(90*time.Second))
+	if !ok || cores != 1 {
+		t.Errorf("Observe after the decrease = %v, %v; want 1 core", cores, ok)
+	}
+
+	r.Retain(map[string]bool{"b": true})
+	if _, ok := r.Observe("a", 1600, start.Add(2*time.Minute)); ok {
+		t.Error("forgotten node still had a previous observation")
+	}
+}
@@ -0,0 +1,75 @@
+package aggregate
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/your-org/k8s-ai-exporter/collector"
+)
+
+// DNSStats are the cluster's DNS rates over the CoreDNS pods scraped twice
+// in a row.
+type DNSStats struct {
+	RequestsPerSecond  float64
+	ServFailsPerSecond float64
+	// CacheHitRatio is the share of cache lookups that hit, NaN when there
+	// were none.
+	CacheHitRatio float64
+	// Pods is how many CoreDNS pods the rates cover.
+	Pods int
+}
+
+type dnsSnapshot struct {
+	counters collector.DNSCounters
+	at       time.Time
+}
+
+// DNSRates converts the counters of CoreDNS pods into cluster-wide rates
+// using the previous observation of each pod. It is safe for concurrent
+// use.
+type DNSRates struct {
+	mu   sync.Mutex
+	prev map[string]dnsSnapshot
+}
+
+// NewDNSRates returns a tracker without previous observations.
+func NewDNSRates() *DNSRates {
+	return &DNSRates{prev: make(map[string]dnsSnapshot)}
+}
+
+// Observe records the counters of the pods in cur, replacing those of pods
+// not in cur, and returns the rates summed over the pods observed before.
+// It returns ok=false when no pod was.
+func (r *DNSRates) Observe(cur map[string]collector.DNSCounters, now time.Time) (s DNSStats, ok bool) {
+	r.mu.Lock()
+	prev := r.prev
+	r.prev = make(map[string]dnsSnapshot, len(cur))
+	for pod, c := range cur {
+		r.prev[pod] = dnsSnapshot{counters: c, at: now}
+	}
+	r.mu.Unlock()
+
+	var hits, lookups float64
+	for pod, c := range cur {
+		p, seen := prev[pod]
+		elapsed := now.Sub(p.at).Seconds()
+		if !seen || elapsed <= 0 {
+			continue
+		}
+		s.Pods++
+		s.RequestsPerSecond += CounterRate(p.counters.Requests, c.Requests, elapsed)
+		s.ServFailsPerSecond += CounterRate(p.counters.ServFails, c.ServFails, elapsed)
+		h := CounterRate(p.counters.CacheHits, c.CacheHits, elapsed)
+		hits += h
+		lookups += h + CounterRate(p.counters.CacheMisses, c.CacheMisses, elapsed)
+	}
+	if s.Pods == 0 {
+		return DNSStats{}, false
+	}
+	s.CacheHitRatio = math.NaN()
+	if lookups > 0 {
+		s.CacheHitRatio = hits / lookups
+	}
+	return s, true
+}
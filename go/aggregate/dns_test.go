@@ -0,0 +1,33 @@
+package aggregate
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/your-org/k8s-ai-exporter/collector"
+)
+
+func TestDNSRates(t *testing.T) {
+	r := NewDNSRates()
+	t0 := time.Unix(1000, 0)
+	if _, ok := r.Observe(map[string]collector.DNSCounters{"kube-system/coredns-a": {Requests: 100}}, t0); ok {
+		t.Fatal("first observation returned rates")
+	}
+	got, ok := r.Observe(map[string]collector.DNSCounters{
+		"kube-system/coredns-a": {Requests: 1100, ServFails: 20, CacheHits: 750, CacheMisses: 250},
+		"kube-system/coredns-b": {Requests: 5000}, // new, only primed
+	}, t0.Add(10*time.Second))
+	want := DNSStats{RequestsPerSecond: 100, ServFailsPerSecond: 2, CacheHitRatio: 0.75, Pods: 1}
+	if !ok || got != want {
+		t.Errorf("Observe = %+v, %v; want %+v", got, ok, want)
+	}
+
+	got, ok = r.Observe(map[string]collector.DNSCounters{
+		"kube-system/coredns-a": {Requests: 1100, ServFails: 20, CacheHits: 750, CacheMisses: 250},
+		"kube-system/coredns-b": {Requests: 5100},
+	}, t0.Add(20*time.Second))
+	if !ok || got.RequestsPerSecond != 10 || got.Pods != 2 || !math.IsNaN(got.CacheHitRatio) {
+		t.Errorf("Observe without cache lookups = %+v, %v", got, ok)
+	}
+}
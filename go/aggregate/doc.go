@@ -0,0 +1,5 @@
+// Package aggregate turns per-node samples and the cluster's nodes and pods
+// into the values the exporter publishes: network rates, scheduling
+// constraint violations, workload zone skew, usage and energy counters and
+// cloud metadata.
+package aggregate
@@ -0,0 +1,192 @@
+package aggregate
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	instanceTypeLabel       = "node.kubernetes.io/instance-type"
+	legacyInstanceTypeLabel = "beta.kubernetes.io/instance-type"
+
+	// DefaultPowerModel is the PowerModels key used for nodes whose
+	// instance type has no model of its own.
+	DefaultPowerModel = "default"
+
+	joulesPerKWh = 3.6e6
+)
+
+// PowerModel estimates a node's power draw from its CPU utilization by
+// linear interpolation between idle and full load, the model commonly used
+// for cloud carbon estimates.
+type PowerModel struct {
+	IdleWatts float64
+	MaxWatts  float64
+}
+
+// Watts returns the estimated draw at utilization, clamped to [0, 1].
+func (m PowerModel) Watts(utilization float64) float64 {
+	if utilization < 0 {
+		utilization = 0
+	} else if utilization > 1 {
+		utilization = 1
+	}
+	return m.IdleWatts + (m.MaxWatts-m.IdleWatts)*utilization
+}
+
+// PowerModels maps instance types to their power model.
+type PowerModels map[string]PowerModel
+
+// ParsePowerModels parses "<instance-type>=<idle>:<max>;..." with watts as
+// numbers, e.g. "m5.large=8:45;default=10:120".
+func ParsePowerModels(spec string) (PowerModels, error) {
+	models := make(PowerModels)
+	for _, entry := range strings.Split(spec, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		typ, watts, ok := strings.Cut(entry, "=")
+		idle, max, ok2 := strings.Cut(watts, ":")
+		if !ok || !ok2 || strings.TrimSpace(typ) == "" {
+			return nil, fmt.Errorf("power model %q: want <instance-type>=<idle-watts>:<max-watts>", entry)
+		}
+		var m PowerModel
+		var err error
+		if m.IdleWatts, err = strconv.ParseFloat(strings.TrimSpace(idle), 64); err != nil {
+			return nil, fmt.Errorf("power model %q: %v", entry, err)
+		}
+		if m.MaxWatts, err = strconv.ParseFloat(strings.TrimSpace(max), 64); err != nil {
+			return nil, fmt.Errorf("power model %q: %v", entry, err)
+		}
+		if m.IdleWatts < 0 || m.MaxWatts < m.IdleWatts {
+			return nil, fmt.Errorf("power model %q: want 0 <= idle <= max", entry)
+		}
+		models[strings.TrimSpace(typ)] = m
+	}
+	return models, nil
+}
+
+// ForNode returns the model of n's instance type, or the default model.
+func (pm PowerModels) ForNode(n *corev1.Node) (PowerModel, bool) {
+	typ := nodeInstanceType(n)
+	if m, ok := pm[typ]; ok && typ != "" {
+		return m, true
+	}
+	m, ok := pm[DefaultPowerModel]
+	return m, ok
+}
+
+// NodeEnergy is the energy of one node over one interval.
+type NodeEnergy struct {
+	Watts    float64
+	Joules   float64
+	Measured bool // Watts comes from a node agent, not a PowerModel
+	// Namespaces splits Joules by each namespace's share of the
+	// interval's container core-seconds. Idle power is shared the same
+	// way, so the parts add up to Joules whenever any container ran.
+	Namespaces map[string]float64
+}
+
+// EstimateEnergy applies m to a node with capacityCores CPUs whose
+// containers used inc over elapsed.
+func EstimateEnergy(m PowerModel, capacityCores float64, inc map[string]UsageIncrease, elapsed time.Duration) NodeEnergy {
+	secs := elapsed.Seconds()
+	var used float64
+	for _, u := range inc {
+		used += u.CoreSeconds
+	}
+	var utilization float64
+	if capacityCores > 0 && secs > 0 {
+		utilization = used / secs / capacityCores
+	}
+	return AttributeEnergy(m.Watts(utilization), inc, elapsed)
+}
+
+// AttributeEnergy integrates watts over elapsed and splits the energy
+// between namespaces by their share of the core-seconds in inc.
+func AttributeEnergy(watts float64, inc map[string]UsageIncrease, elapsed time.Duration) NodeEnergy {
+	var used float64
+	for _, u := range inc {
+		used += u.CoreSeconds
+	}
+	e := NodeEnergy{Watts: watts, Namespaces: make(map[string]float64, len(inc))}
+	e.Joules = watts * elapsed.Seconds()
+	if used > 0 {
+		for ns, u := range inc {
+			e.Namespaces[ns] = e.Joules * u.CoreSeconds / used
+		}
+	}
+	return e
+}
+
+// GramsCO2 converts joules to grams of CO2 at gramsPerKWh.
+func GramsCO2(joules, gramsPerKWh float64) float64 {
+	return joules / joulesPerKWh * gramsPerKWh
+}
+
+type joulesSnapshot struct {
+	joules float64
+	at     time.Time
+}
+
+// MeasuredPower turns cumulative energy counters reported by node agents
+// (RAPL via node_exporter, Kepler, ...) into watts per node. It is safe for
+// concurrent use.
+type MeasuredPower struct {
+	maxAge time.Duration
+
+	mu    sync.Mutex
+	prev  map[string]joulesSnapshot
+	watts map[string]joulesSnapshot // joules holds the last rate in watts
+}
+
+// NewMeasuredPower returns a tracker whose readings expire maxAge after
+// they were observed, so a node whose agent went away falls back to its
+// PowerModel.
+func NewMeasuredPower(maxAge time.Duration) *MeasuredPower {
+	return &MeasuredPower{maxAge: maxAge, prev: make(map[string]joulesSnapshot), watts: make(map[string]joulesSnapshot)}
+}
+
+// Observe records node's cumulative joules. A reading needs two
+// observations; a counter reset only primes the state again.
+func (m *MeasuredPower) Observe(node string, joules float64, now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	prev, ok := m.prev[node]
+	m.prev[node] = joulesSnapshot{joules: joules, at: now}
+	elapsed := now.Sub(prev.at).Seconds()
+	if !ok || joules < prev.joules || elapsed <= 0 {
+		return
+	}
+	m.watts[node] = joulesSnapshot{joules: (joules - prev.joules) / elapsed, at: now}
+}
+
+// Watts returns node's last measured power draw unless it is older than
+// the tracker's maxAge.
+func (m *MeasuredPower) Watts(node string, now time.Time) (float64, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	w, ok := m.watts[node]
+	if !ok || now.Sub(w.at) > m.maxAge {
+		return 0, false
+	}
+	return w.joules, true
+}
+
+// Retain forgets the state of nodes not in nodes.
+func (m *MeasuredPower) Retain(nodes map[string]bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for node := range m.prev {
+		if !nodes[node] {
+			delete(m.prev, node)
+			delete(m.watts, node)
+		}
+	}
+}
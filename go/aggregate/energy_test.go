@@ -0,0 +1,108 @@
+package aggregate
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParsePowerModels(t *testing.T) {
+	got, err := ParsePowerModels(" m5.large = 8:45 ;default=10:120;")
+	if err != nil {
+		t.Fatalf("ParsePowerModels: %v", err)
+	}
+	if len(got) != 2 || got["m5.large"] != (PowerModel{8, 45}) || got[DefaultPowerModel] != (PowerModel{10, 120}) {
+		t.Errorf("ParsePowerModels = %v", got)
+	}
+	for _, bad := range []string{"m5.large", "m5.large=8", "=1:2", "x=a:2", "x=50:10", "x=-1:10"} {
+		if _, err := ParsePowerModels(bad); err == nil {
+			t.Errorf("ParsePowerModels(%q) succeeded, want error", bad)
+		}
+	}
+}
+
+func TestPowerModelsForNode(t *testing.T) {
+	models := PowerModels{"m5.large": {8, 45}, DefaultPowerModel: {10, 120}}
+	node := func(labels map[string]string) *corev1.Node {
+		return &corev1.Node{ObjectMeta: metav1.ObjectMeta{Labels: labels}}
+	}
+	tests := []struct {
+		labels map[string]string
+		want   PowerModel
+	}{
+		{map[string]string{instanceTypeLabel: "m5.large"}, PowerModel{8, 45}},
+		{map[string]string{legacyInstanceTypeLabel: "m5.large"}, PowerModel{8, 45}},
+		{map[string]string{instanceTypeLabel: "c6i.xlarge"}, PowerModel{10, 120}},
+		{nil, PowerModel{10, 120}},
+	}
+	for _, tt := range tests {
+		if got, ok := models.ForNode(node(tt.labels)); !ok || got != tt.want {
+			t.Errorf("ForNode(%v) = %v, %v; want %v, true", tt.labels, got, ok, tt.want)
+		}
+	}
+	if _, ok := (PowerModels{"m5.large": {8, 45}}).ForNode(node(nil)); ok {
+		t.Error("ForNode without a default model matched an unlabeled node")
+	}
+}
+
+func TestEstimateEnergy(t *testing.T) {
+	m := PowerModel{IdleWatts: 10, MaxWatts: 110}
+	// 4 cores for 10s; 10 core-seconds used is 25% utilization: 35 W.
+	e := EstimateEnergy(m, 4, map[string]UsageIncrease{
+		"shop":  {CoreSeconds: 7.5},
+		"batch": {CoreSeconds: 2.5},
+		"idle":  {},
+	}, 10*time.Second)
+	if e.Watts != 35 || e.Joules != 350 {
+		t.Errorf("watts, joules = %v, %v; want 35, 350", e.Watts, e.Joules)
+	}
+	if e.Namespaces["shop"] != 262.5 || e.Namespaces["batch"] != 87.5 || e.Namespaces["idle"] != 0 {
+		t.Errorf("namespaces = %v", e.Namespaces)
+	}
+
+	e = EstimateEnergy(m, 4, nil, 10*time.Second)
+	if e.Watts != 10 || e.Joules != 100 || len(e.Namespaces) != 0 {
+		t.Errorf("idle node = %+v, want 10 W, 100 J, no namespaces", e)
+	}
+}
+
+func TestGramsCO2(t *testing.T) {
+	if got := GramsCO2(3.6e6, 400); got != 400 {
+		t.Errorf("GramsCO2(1 kWh, 400) = %v, want 400", got)
+	}
+}
+
+func TestMeasuredPower(t *testing.T) {
+	m := NewMeasuredPower(time.Minute)
+	start := time.Unix(1700000000, 0)
+	m.Observe("a", 1000, start)
+	if _, ok := m.Watts("a", start); ok {
+		t.Fatal("one observation returned watts")
+	}
+	m.Observe("a", 1900, start.Add(10*time.Second))
+	if w, ok := m.Watts("a", start.Add(10*time.Second)); !ok || w != 90 {
+		t.Errorf("Watts = %v, %v; want 90, true", w, ok)
+	}
+	if _, ok := m.Watts("a", start.Add(2*time.Minute)); ok {
+		t.Error("expired reading returned watts")
+	}
+
+	m.Observe("a", 50, start.Add(20*time.Second)) // agent restarted
+	if w, _ := m.Watts("a", start.Add(20*time.Second)); w != 90 {
+		t.Errorf("Watts after reset = %v, want the previous 90", w)
+	}
+
+	m.Retain(map[string]bool{"b": true})
+	if _, ok := m.Watts("a", start.Add(20*time.Second)); ok {
+		t.Error("forgotten node still had watts")
+	}
+}
+
+func TestAttributeEnergy(t *testing.T) {
+	e := AttributeEnergy(90, map[string]UsageIncrease{"shop": {CoreSeconds: 3}, "batch": {CoreSeconds: 1}}, 10*time.Second)
+	if e.Joules != 900 || e.Namespaces["shop"] != 675 || e.Namespaces["batch"] != 225 {
+		t.Errorf("AttributeEnergy = %+v", e)
+	}
+}
@@ -0,0 +1,220 @@
+package aggregate
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/your-org/k8s-ai-exporter/kube"
+)
+
+// PoolLabels are the node labels managed node pools and provisioners put
+// the pool name in, tried in order by NodePool.
+var PoolLabels = []string{
+	"karpenter.sh/nodepool",
+	"eks.amazonaws.com/nodegroup",
+	"cloud.google.com/gke-nodepool",
+	"kubernetes.azure.com/agentpool",
+	"node.kubernetes.io/pool",
+}
+
+// NodePool returns the pool of n: the value of label when it is set, of
+// the first of the PoolLabels n carries otherwise. It is empty for nodes
+// outside any pool.
+func NodePool(n *corev1.Node, label string) string {
+	if label != "" {
+		return n.Labels[label]
+	}
+	for _, l := range PoolLabels {
+		if v, ok := n.Labels[l]; ok {
+			return v
+		}
+	}
+	return ""
+}
+
+// Reasons why the free capacity of a node is stranded.
+const (
+	StrandedUnschedulable = "unschedulable" // the node is cordoned
+	StrandedMaxPods       = "max_pods"      // the node runs its max-pods
+	StrandedTaints        = "taints"        // no workload tolerates the node's taints
+	StrandedConstraints   = "constraints"   // node selectors, node or pod (anti-)affinity keep the workloads that would fit away
+	StrandedTooSmall      = "too_small"     // the free capacity is smaller than any workload's requests
+)
+
+// StrandedReasons lists every stranded reason in reporting order.
+var StrandedReasons = []string{
+	StrandedUnschedulable,
+	StrandedMaxPods,
+	StrandedTaints,
+	StrandedConstraints,
+	StrandedTooSmall,
+}
+
+// Fragmentation is the free capacity of a pool or of the cluster, and how
+// much of it is stranded, by reason.
+type Fragmentation struct {
+	FreeCPU, FreeMemory         float64
+	StrandedCPU, StrandedMemory map[string]float64
+}
+
+// CPURatio returns the share of the free CPU that is stranded.
+func (f Fragmentation) CPURatio() float64 {
+	return strandedRatio(f.StrandedCPU, f.FreeCPU)
+}
+
+// MemoryRatio returns the share of the free memory that is stranded.
+func (f Fragmentation) MemoryRatio() float64 {
+	return strandedRatio(f.StrandedMemory, f.FreeMemory)
+}
+
+func strandedRatio(stranded map[string]float64, free float64) float64 {
+	if free <= 0 {
+		return 0
+	}
+	var sum float64
+	for _, v := range stranded {
+		sum += v
+	}
+	return sum / free
+}
+
+func (f *Fragmentation) add(n nodeFree, reason string) {
+	f.FreeCPU += n.cpu
+	f.FreeMemory += n.mem
+	if reason == "" {
+		return
+	}
+	if f.StrandedCPU == nil {
+		f.StrandedCPU, f.StrandedMemory = make(map[string]float64), make(map[string]float64)
+	}
+	f.StrandedCPU[reason] += n.cpu
+	f.StrandedMemory[reason] += n.mem
+}
+
+type nodeFree struct {
+	cpu, mem float64
+	pods     int
+}
+
+// candidate is a pod standing in for another replica of its workload.
+type candidate struct {
+	pod      *corev1.Pod
+	cpu, mem float64
+}
+
+// CapacityFragmentation estimates how much free capacity no workload could
+// use. A node's free capacity is its allocatable CPU and memory minus the
+// requests of its pods. It counts as stranded when not one more replica of
+// any running workload could be placed there: the node is cordoned or at
+// its max-pods, or every workload is kept away by the node's taints, by its
+// placement constraints, or by requests larger than what is free. One pod
+// of each workload stands in for it; DaemonSets, static pods and pods
+// without requests are no candidates. This explains pods that stay Pending
+// on a cluster that looks half empty.
+//
+// The result is keyed by pool (see NodePool, with poolLabel); the cluster
+// sums every pool.
+func CapacityFragmentation(nodes []corev1.Node, pods []corev1.Pod, poolLabel string) (pools map[string]Fragmentation, cluster Fragmentation) {
+	free := make(map[string]nodeFree, len(nodes))
+	byName := make(map[string]*corev1.Node, len(nodes))
+	for i := range nodes {
+		n := &nodes[i]
+		byName[n.Name] = n
+		free[n.Name] = nodeFree{
+			cpu: n.Status.Allocatable.Cpu().AsApproximateFloat64(),
+			mem: n.Status.Allocatable.Memory().AsApproximateFloat64(),
+		}
+	}
+	var candidates []candidate
+	seen := make(map[kube.Workload]bool)
+	for i := range pods {
+		p := &pods[i]
+		cpu, mem := PodRequests(p)
+		if f, ok := free[p.Spec.NodeName]; ok {
+			f.cpu -= cpu
+			f.mem -= mem
+			f.pods++
+			free[p.Spec.NodeName] = f
+		}
+		w := kube.PodWorkload(p)
+		if seen[w] || PodKind(p) != "" || (cpu == 0 && mem == 0) {
+			continue
+		}
+		seen[w] = true
+		// Without a name the replica is not taken for p itself, so p counts
+		// against its own anti-affinity.
+		probe := *p
+		probe.Name = ""
+		candidates = append(candidates, candidate{&probe, cpu, mem})
+	}
+
+	pools = make(map[string]Fragmentation)
+	for i := range nodes {
+		n := &nodes[i]
+		f := free[n.Name]
+		f.cpu, f.mem = max(f.cpu, 0), max(f.mem, 0)
+		reason := strandedReason(n, f, candidates, pods, byName)
+		pool := NodePool(n, poolLabel)
+		pf := pools[pool]
+		pf.add(f, reason)
+		pools[pool] = pf
+		cluster.add(f, reason)
+	}
+	return pools, cluster
+}
+
+// strandedReason returns why no candidate fits the free capacity f of
+// node, or "" when one does. Of the reasons candidates fail for, the one
+// of the candidate that got furthest is reported: past the taints and the
+// requests, a constraint kept it away; past the taints only, it was too
+// large.
+func strandedReason(node *corev1.Node, f nodeFree, candidates []candidate, pods []corev1.Pod, nodes map[string]*corev1.Node) string {
+	if node.Spec.Unschedulable {
+		return StrandedUnschedulable
+	}
+	if q, ok := node.Status.Allocatable[corev1.ResourcePods]; ok && int64(f.pods) >= q.Value() {
+		return StrandedMaxPods
+	}
+	if len(candidates) == 0 {
+		return "" // nothing to judge the node by
+	}
+	reason := StrandedTaints
+	for _, c := range candidates {
+		if !toleratesNode(c.pod, node) {
+			continue
+		}
+		if c.cpu > f.cpu || c.mem > f.mem {
+			if reason == StrandedTaints {
+				reason = StrandedTooSmall
+			}
+			continue
+		}
+		if len(podConstraintViolations(c.pod, node, pods, nodes)) > 0 {
+			reason = StrandedConstraints
+			continue
+		}
+		return ""
+	}
+	return reason
+}
+
+// toleratesNode reports whether pod tolerates every taint of node that
+// keeps pods from being scheduled there.
+func toleratesNode(pod *corev1.Pod, node *corev1.Node) bool {
+	for i := range node.Spec.Taints {
+		taint := &node.Spec.Taints[i]
+		if taint.Effect == corev1.TaintEffectPreferNoSchedule {
+			continue
+		}
+		tolerated := false
+		for j := range pod.Spec.Tolerations {
+			if pod.Spec.Tolerations[j].ToleratesTaint(taint) {
+				tolerated = true
+				break
+			}
+		}
+		if !tolerated {
+			return false
+		}
+	}
+	return true
+}
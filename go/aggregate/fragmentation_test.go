@@ -0,0 +1,73 @@
+package aggregate
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCapacityFragmentation(t *testing.T) {
+	node := func(name, pool string, cpu string) corev1.Node {
+		return corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{
+				"karpenter.sh/nodepool":  pool,
+				"kubernetes.io/hostname": name,
+			}},
+			Status: corev1.NodeStatus{Allocatable: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse(cpu),
+				corev1.ResourceMemory: resource.MustParse("8Gi"),
+				corev1.ResourcePods:   resource.MustParse("110"),
+			}},
+		}
+	}
+	pod := func(name, node, cpu string) corev1.Pod {
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "web", Labels: map[string]string{"app": name}},
+			Spec:       corev1.PodSpec{NodeName: node, Containers: []corev1.Container{{Resources: requests(cpu, "1Gi")}}},
+		}
+	}
+	gpu := node("gpu-1", "gpu", "8")
+	gpu.Spec.Taints = []corev1.Taint{{Key: "nvidia.com/gpu", Effect: corev1.TaintEffectNoSchedule}}
+	cordoned := node("web-3", "web", "4")
+	cordoned.Spec.Unschedulable = true
+	nodes := []corev1.Node{node("web-1", "web", "4"), node("web-2", "web", "4"), cordoned, gpu}
+
+	// api needs 2 cores and keeps its replicas on separate nodes; it runs
+	// on web-1, leaving 1 core there. web-2 has 4 free.
+	api := pod("api", "web-1", "2")
+	api.Spec.Affinity = &corev1.Affinity{PodAntiAffinity: &corev1.PodAntiAffinity{
+		RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{{
+			LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "api"}},
+			TopologyKey:   "kubernetes.io/hostname",
+		}},
+	}}
+	pods := []corev1.Pod{api, pod("batch", "web-1", "1")}
+
+	pools, cluster := CapacityFragmentation(nodes, pods, "")
+	web := pools["web"]
+	if web.FreeCPU != 9 {
+		t.Errorf("web free CPU = %v, want 9 (1 + 4 + cordoned 4)", web.FreeCPU)
+	}
+	// web-1 has 1 core: batch fits, so nothing is stranded there. The
+	// cordoned node's 4 cores are.
+	if web.StrandedCPU[StrandedUnschedulable] != 4 || len(web.StrandedCPU) != 1 {
+		t.Errorf("web stranded CPU = %v, want 4 unschedulable", web.StrandedCPU)
+	}
+	if got := pools["gpu"].StrandedCPU[StrandedTaints]; got != 8 {
+		t.Errorf("gpu stranded CPU = %v, want 8 behind the taint", got)
+	}
+	if got, want := cluster.CPURatio(), 12.0/17; got != want {
+		t.Errorf("cluster CPU ratio = %v, want %v", got, want)
+	}
+
+	// Without batch, api would fit into web-1's 2 free cores, but its
+	// anti-affinity keeps it away. web-2, filled by big, has 1 core left:
+	// too small for either.
+	pods = []corev1.Pod{api, pod("big", "web-2", "3")}
+	pools, _ = CapacityFragmentation(nodes, pods, "")
+	if got := pools["web"].StrandedCPU; got[StrandedConstraints] != 2 || got[StrandedTooSmall] != 1 {
+		t.Errorf("web stranded CPU = %v, want 2 by constraints and 1 too small", got)
+	}
+}
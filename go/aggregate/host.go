@@ -0,0 +1,88 @@
+package aggregate
+
+import (
+	"sync"
+	"time"
+
+	"github.com/your-org/k8s-ai-exporter/collector"
+)
+
+// HostSaturation is how close one node's host resources run to their
+// limits, from its node_exporter.
+type HostSaturation struct {
+	// Load1, Load5 and Load15 are the load averages divided by the CPUs;
+	// above 1 runnable threads wait for a CPU.
+	Load1, Load5, Load15 float64
+	// DiskBusy is the share of time the busiest block device had IO in
+	// progress, from 0 to 1.
+	DiskBusy float64
+	// NetworkUtilization is the throughput of the busiest physical
+	// interface over its link speed, in its busier direction, from 0 to 1.
+	// Interfaces without a reported speed are left out.
+	NetworkUtilization float64
+}
+
+type hostSnapshot struct {
+	counters collector.HostCounters
+	at       time.Time
+}
+
+// HostRates converts node_exporter counters into HostSaturation using the
+// previous observation of each node. It is safe for concurrent use.
+type HostRates struct {
+	mu   sync.Mutex
+	prev map[string]hostSnapshot
+}
+
+// NewHostRates returns a tracker without previous observations.
+func NewHostRates() *HostRates {
+	return &HostRates{prev: make(map[string]hostSnapshot)}
+}
+
+// Observe records cur for node and returns its saturation since the
+// previous observation. The first observation only primes the state and
+// returns ok=false. A device whose counter was reset or that is new counts
+// as idle.
+func (r *HostRates) Observe(node string, cur collector.HostCounters, now time.Time) (s HostSaturation, ok bool) {
+	r.mu.Lock()
+	prev, ok := r.prev[node]
+	r.prev[node] = hostSnapshot{counters: cur, at: now}
+	r.mu.Unlock()
+	if !ok {
+		return HostSaturation{}, false
+	}
+	elapsed := now.Sub(prev.at).Seconds()
+	if elapsed <= 0 {
+		return HostSaturation{}, false
+	}
+	if cur.CPUs > 0 {
+		s.Load1 = cur.Load1 / float64(cur.CPUs)
+		s.Load5 = cur.Load5 / float64(cur.CPUs)
+		s.Load15 = cur.Load15 / float64(cur.CPUs)
+	}
+	for dev, busy := range cur.DiskIOSeconds {
+		if p, seen := prev.counters.DiskIOSeconds[dev]; seen {
+			s.DiskBusy = max(s.DiskBusy, min(CounterRate(p, busy, elapsed), 1))
+		}
+	}
+	for dev, i := range cur.Interfaces {
+		p, seen := prev.counters.Interfaces[dev]
+		if !seen || i.SpeedBytes <= 0 {
+			continue
+		}
+		bytes := max(CounterRate(p.RxBytes, i.RxBytes, elapsed), CounterRate(p.TxBytes, i.TxBytes, elapsed))
+		s.NetworkUtilization = max(s.NetworkUtilization, min(bytes/i.SpeedBytes, 1))
+	}
+	return s, true
+}
+
+// Retain forgets the state of nodes not in nodes.
+func (r *HostRates) Retain(nodes map[string]bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for node := range r.prev {
+		if !nodes[node] {
+			delete(r.prev, node)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package aggregate
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/your-org/k8s-ai-exporter/collector"
+)
+
+func TestHostRates(t *testing.T) {
+	r := NewHostRates()
+	t0 := time.Unix(1000, 0)
+	first := collector.HostCounters{
+		CPUs: 4, Load1: 2,
+		DiskIOSeconds: map[string]float64{"sda": 100, "sdb": 50},
+		Interfaces:    map[string]collector.InterfaceCounters{"eth0": {RxBytes: 0, TxBytes: 0, SpeedBytes: 1000}, "eth1": {}},
+	}
+	if _, ok := r.Observe("n1", first, t0); ok {
+		t.Fatal("first observation returned rates")
+	}
+	second := collector.HostCounters{
+		CPUs: 4, Load1: 6, Load5: 4, Load15: 2,
+		DiskIOSeconds: map[string]float64{"sda": 105, "sdb": 49, "sdc": 500},
+		Interfaces:    map[string]collector.InterfaceCounters{"eth0": {RxBytes: 2000, TxBytes: 8000, SpeedBytes: 1000}, "eth1": {RxBytes: 1e9}},
+	}
+	got, ok := r.Observe("n1", second, t0.Add(10*time.Second))
+	if !ok {
+		t.Fatal("second observation returned no rates")
+	}
+	// sdb was reset and sdc is new; eth1 has no speed.
+	want := HostSaturation{Load1: 1.5, Load5: 1, Load15: 0.5, DiskBusy: 0.5, NetworkUtilization: 0.8}
+	if math.Abs(got.DiskBusy-want.DiskBusy) > 1e-9 || math.Abs(got.NetworkUtilization-want.NetworkUtilization) > 1e-9 ||
+		got.Load1 != want.Load1 || got.Load5 != want.Load5 || got.Load15 != want.Load15 {
+		t.Errorf("Observe = %+v, want %+v", got, want)
+	}
+
+	r.Retain(map[string]bool{})
+	if _, ok := r.Observe("n1", second, t0.Add(20*time.Second)); ok {
+		t.Error("retained state of a removed node")
+	}
+}
@@ -0,0 +1,68 @@
+package aggregate
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+// InfraFootprint is what the infrastructure pods of one kind take from a
+// node: how many there are and the sum of their effective requests.
+type InfraFootprint struct {
+	Pods        float64
+	CPUCores    float64
+	MemoryBytes float64
+}
+
+// InfraPods returns, per node and PodKind, the footprint of the node's
+// infrastructure pods: mirror (static) pods in any namespace and DaemonSet
+// pods in one of dsNamespaces, such as the CNI, CSI and log shipper pods
+// of kube-system. That is the fixed overhead every node of a pool pays
+// before any workload lands on it.
+func InfraPods(pods []corev1.Pod, dsNamespaces map[string]bool) map[string]map[string]InfraFootprint {
+	out := make(map[string]map[string]InfraFootprint)
+	for i := range pods {
+		p := &pods[i]
+		kind := PodKind(p)
+		if kind == "" || (kind == PodKindDaemonSet && !dsNamespaces[p.Namespace]) {
+			continue
+		}
+		node := p.Spec.NodeName
+		if out[node] == nil {
+			out[node] = make(map[string]InfraFootprint)
+		}
+		cpu, mem := PodRequests(p)
+		f := out[node][kind]
+		f.Pods++
+		f.CPUCores += cpu
+		f.MemoryBytes += mem
+		out[node][kind] = f
+	}
+	return out
+}
+
+// PodRequests returns the CPU (cores) and memory (bytes) the scheduler
+// reserves for a pod: the larger of the sum over its containers and native
+// sidecars and the largest regular init container, plus the pod overhead
+// of its RuntimeClass.
+func PodRequests(pod *corev1.Pod) (cpu, mem float64) {
+	for _, c := range pod.Spec.Containers {
+		cpu += c.Resources.Requests.Cpu().AsApproximateFloat64()
+		mem += c.Resources.Requests.Memory().AsApproximateFloat64()
+	}
+	// Init containers run one after the other before the app containers,
+	// next to the native sidecars started before them.
+	var sidecarCPU, sidecarMem, initCPU, initMem float64
+	for _, c := range pod.Spec.InitContainers {
+		ccpu := c.Resources.Requests.Cpu().AsApproximateFloat64()
+		cmem := c.Resources.Requests.Memory().AsApproximateFloat64()
+		if c.RestartPolicy != nil && *c.RestartPolicy == corev1.ContainerRestartPolicyAlways {
+			sidecarCPU += ccpu
+			sidecarMem += cmem
+			continue
+		}
+		initCPU = max(initCPU, sidecarCPU+ccpu)
+		initMem = max(initMem, sidecarMem+cmem)
+	}
+	cpu = max(cpu+sidecarCPU, initCPU) + pod.Spec.Overhead.Cpu().AsApproximateFloat64()
+	mem = max(mem+sidecarMem, initMem) + pod.Spec.Overhead.Memory().AsApproximateFloat64()
+	return cpu, mem
+}
@@ -0,0 +1,68 @@
+package aggregate
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func requests(cpu, mem string) corev1.ResourceRequirements {
+	return corev1.ResourceRequirements{Requests: corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse(cpu),
+		corev1.ResourceMemory: resource.MustParse(mem),
+	}}
+}
+
+func TestPodRequests(t *testing.T) {
+	always := corev1.ContainerRestartPolicyAlways
+	pod := &corev1.Pod{Spec: corev1.PodSpec{
+		InitContainers: []corev1.Container{
+			{Name: "migrate", Resources: requests("2", "1Gi")},
+			{Name: "proxy", RestartPolicy: &always, Resources: requests("100m", "64Mi")},
+		},
+		Containers: []corev1.Container{
+			{Name: "app", Resources: requests("500m", "256Mi")},
+			{Name: "logs", Resources: requests("100m", "64Mi")},
+		},
+		Overhead: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("250m"), corev1.ResourceMemory: resource.MustParse("128Mi")},
+	}}
+	// The migrate init container outweighs app, logs and the proxy sidecar.
+	cpu, mem := PodRequests(pod)
+	if cpu != 2.25 || mem != (1024+128)<<20 {
+		t.Errorf("PodRequests = %v cores, %v bytes; want 2.25 and 1152Mi", cpu, mem)
+	}
+	pod.Spec.InitContainers = pod.Spec.InitContainers[1:]
+	if cpu, mem := PodRequests(pod); cpu != 0.95 || mem != (256+64+64+128)<<20 {
+		t.Errorf("PodRequests without migrate = %v cores, %v bytes; want 0.95 and 512Mi", cpu, mem)
+	}
+}
+
+func TestInfraPods(t *testing.T) {
+	controller := true
+	ds := func(ns, node string) corev1.Pod {
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: ns, OwnerReferences: []metav1.OwnerReference{{Kind: "DaemonSet", Name: "x", Controller: &controller}}},
+			Spec:       corev1.PodSpec{NodeName: node, Containers: []corev1.Container{{Resources: requests("100m", "128Mi")}}},
+		}
+	}
+	pods := []corev1.Pod{
+		ds("kube-system", "a"),
+		ds("kube-system", "a"),
+		ds("monitoring", "a"),
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system", Annotations: map[string]string{"kubernetes.io/config.mirror": "x"}},
+			Spec:       corev1.PodSpec{NodeName: "b", Containers: []corev1.Container{{Resources: requests("250m", "0")}}},
+		},
+		{Spec: corev1.PodSpec{NodeName: "b"}},
+	}
+	want := map[string]map[string]InfraFootprint{
+		"a": {PodKindDaemonSet: {Pods: 2, CPUCores: 0.2, MemoryBytes: 256 << 20}},
+		"b": {PodKindMirror: {Pods: 1, CPUCores: 0.25}},
+	}
+	if got := InfraPods(pods, map[string]bool{"kube-system": true}); !reflect.DeepEqual(got, want) {
+		t.Errorf("InfraPods = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,152 @@
+package aggregate
+
+import (
+	"fmt"
+	"math"
+	"net/netip"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// IP families of the per-node pod CIDR families.
+const (
+	FamilyIPv4 = "ipv4"
+	FamilyIPv6 = "ipv6"
+)
+
+// IPUsage is how many addresses of a CIDR are in use.
+type IPUsage struct {
+	Allocated float64
+	Capacity  float64
+}
+
+// Ratio is Allocated over Capacity, 0 for an empty range.
+func (u IPUsage) Ratio() float64 {
+	if u.Capacity <= 0 {
+		return 0
+	}
+	return u.Allocated / u.Capacity
+}
+
+// PodCIDRUsage returns, per node and IP family, the pod IPs in use against
+// the size of the node's spec.podCIDRs. Host-network pods use the node's
+// address and are not counted. Nodes without a pod CIDR (e.g. with a CNI
+// that does its own IPAM) are left out.
+func PodCIDRUsage(nodes []corev1.Node, pods []corev1.Pod) map[string]map[string]IPUsage {
+	out := make(map[string]map[string]IPUsage, len(nodes))
+	for _, n := range nodes {
+		cidrs := n.Spec.PodCIDRs
+		if len(cidrs) == 0 && n.Spec.PodCIDR != "" {
+			cidrs = []string{n.Spec.PodCIDR}
+		}
+		byFamily := make(map[string]IPUsage, len(cidrs))
+		for _, c := range cidrs {
+			p, err := netip.ParsePrefix(c)
+			if err != nil {
+				continue
+			}
+			u := byFamily[ipFamily(p.Addr())]
+			u.Capacity += cidrCapacity(p)
+			byFamily[ipFamily(p.Addr())] = u
+		}
+		if len(byFamily) > 0 {
+			out[n.Name] = byFamily
+		}
+	}
+	for _, p := range pods {
+		byFamily, ok := out[p.Spec.NodeName]
+		if !ok || p.Spec.HostNetwork {
+			continue
+		}
+		for _, ip := range podIPs(&p) {
+			addr, err := netip.ParseAddr(ip)
+			if err != nil {
+				continue
+			}
+			if u, ok := byFamily[ipFamily(addr)]; ok {
+				u.Allocated++
+				byFamily[ipFamily(addr)] = u
+			}
+		}
+	}
+	return out
+}
+
+// ServiceCIDRUsage returns, per CIDR, the Service ClusterIPs in use
+// against its size. Headless Services hold no address.
+func ServiceCIDRUsage(cidrs []netip.Prefix, services []corev1.Service) map[string]IPUsage {
+	out := make(map[string]IPUsage, len(cidrs))
+	for _, c := range cidrs {
+		out[c.String()] = IPUsage{Capacity: cidrCapacity(c)}
+	}
+	for _, svc := range services {
+		ips := svc.Spec.ClusterIPs
+		if len(ips) == 0 && svc.Spec.ClusterIP != "" {
+			ips = []string{svc.Spec.ClusterIP}
+		}
+		for _, ip := range ips {
+			addr, err := netip.ParseAddr(ip)
+			if err != nil {
+				continue // "None" for headless Services
+			}
+			for _, c := range cidrs {
+				if c.Contains(addr) {
+					u := out[c.String()]
+					u.Allocated++
+					out[c.String()] = u
+					break
+				}
+			}
+		}
+	}
+	return out
+}
+
+// ParseCIDRs parses a comma-separated list of CIDRs, e.g. the API server's
+// --service-cluster-ip-range.
+func ParseCIDRs(spec string) ([]netip.Prefix, error) {
+	var out []netip.Prefix
+	for _, s := range strings.Split(spec, ",") {
+		if s = strings.TrimSpace(s); s == "" {
+			continue
+		}
+		p, err := netip.ParsePrefix(s)
+		if err != nil {
+			return nil, fmt.Errorf("CIDR %q: %v", s, err)
+		}
+		out = append(out, p.Masked())
+	}
+	return out, nil
+}
+
+// cidrCapacity is the number of assignable addresses in p: its size less
+// the network address, and for IPv4 the broadcast address too.
+func cidrCapacity(p netip.Prefix) float64 {
+	size := math.Pow(2, float64(p.Addr().BitLen()-p.Bits()))
+	if p.Addr().Is4() {
+		return math.Max(size-2, 0)
+	}
+	return size - 1
+}
+
+func ipFamily(a netip.Addr) string {
+	if a.Is4() || a.Is4In6() {
+		return FamilyIPv4
+	}
+	return FamilyIPv6
+}
+
+func podIPs(p *corev1.Pod) []string {
+	if len(p.Status.PodIPs) == 0 {
+		if p.Status.PodIP == "" {
+			return nil
+		}
+		return []string{p.Status.PodIP}
+	}
+	ips := make([]string, len(p.Status.PodIPs))
+	for i, ip := range p.Status.PodIPs {
+		ips[i] = ip.IP
+	}
+	return ips
+}
@@ -0,0 +1,79 @@
+package aggregate
+
+import (
+	"net/netip"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPodCIDRUsage(t *testing.T) {
+	nodes := []corev1.Node{
+		{ObjectMeta: metav1.ObjectMeta{Name: "v4"}, Spec: corev1.NodeSpec{PodCIDR: "10.0.1.0/24"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "dual"}, Spec: corev1.NodeSpec{PodCIDRs: []string{"10.0.2.0/28", "fd00:1::/120"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "cni-ipam"}},
+	}
+	pod := func(node string, hostNetwork bool, ips ...string) corev1.Pod {
+		p := corev1.Pod{Spec: corev1.PodSpec{NodeName: node, HostNetwork: hostNetwork}}
+		for _, ip := range ips {
+			p.Status.PodIPs = append(p.Status.PodIPs, corev1.PodIP{IP: ip})
+		}
+		return p
+	}
+	pods := []corev1.Pod{
+		pod("v4", false, "10.0.1.5"),
+		pod("v4", false, "10.0.1.6"),
+		pod("v4", true, "192.168.0.10"),
+		pod("v4", false), // pending, no IP yet
+		pod("dual", false, "10.0.2.3", "fd00:1::3"),
+		pod("cni-ipam", false, "10.9.9.9"),
+	}
+	pods[1].Status = corev1.PodStatus{PodIP: "10.0.1.6"}
+
+	got := PodCIDRUsage(nodes, pods)
+	want := map[string]map[string]IPUsage{
+		"v4":   {FamilyIPv4: {Allocated: 2, Capacity: 254}},
+		"dual": {FamilyIPv4: {Allocated: 1, Capacity: 14}, FamilyIPv6: {Allocated: 1, Capacity: 255}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("PodCIDRUsage = %v, want %v", got, want)
+	}
+}
+
+func TestServiceCIDRUsage(t *testing.T) {
+	cidrs, err := ParseCIDRs("10.96.0.0/30, fd00:96::/126")
+	if err != nil {
+		t.Fatalf("ParseCIDRs: %v", err)
+	}
+	svc := func(ips ...string) corev1.Service {
+		return corev1.Service{Spec: corev1.ServiceSpec{ClusterIP: ips[0], ClusterIPs: ips}}
+	}
+	got := ServiceCIDRUsage(cidrs, []corev1.Service{
+		svc("10.96.0.1"),
+		svc("10.96.0.2", "fd00:96::2"),
+		svc("None"),
+		{Spec: corev1.ServiceSpec{ClusterIP: "fd00:96::1"}},
+	})
+	want := map[string]IPUsage{
+		"10.96.0.0/30":  {Allocated: 2, Capacity: 2},
+		"fd00:96::/126": {Allocated: 2, Capacity: 3},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ServiceCIDRUsage = %v, want %v", got, want)
+	}
+	if r := got["10.96.0.0/30"].Ratio(); r != 1 {
+		t.Errorf("Ratio = %v, want 1", r)
+	}
+}
+
+func TestParseCIDRs(t *testing.T) {
+	got, err := ParseCIDRs("10.96.0.1/12,")
+	if err != nil || len(got) != 1 || got[0] != netip.MustParsePrefix("10.96.0.0/12") {
+		t.Errorf("ParseCIDRs = %v, %v; want [10.96.0.0/12]", got, err)
+	}
+	if _, err := ParseCIDRs("10.96.0.0"); err == nil {
+		t.Error("ParseCIDRs without prefix length succeeded")
+	}
+}
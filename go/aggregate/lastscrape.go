@@ -0,0 +1,66 @@
+package aggregate
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// NodeScrape is the usage of a node from its last successful scrape.
+type NodeScrape struct {
+	CPU float64
+	Mem float64
+	At  time.Time
+}
+
+// LastScrapes keeps each node's last successful scrape, so a node that
+// cannot be scraped, for example while the API server proxy is down, keeps
+// its last known usage instead of dropping to zero. It is safe for
+// concurrent use.
+type LastScrapes struct {
+	mu    sync.Mutex
+	nodes map[string]NodeScrape
+}
+
+// NewLastScrapes returns an empty cache.
+func NewLastScrapes() *LastScrapes {
+	return &LastScrapes{nodes: make(map[string]NodeScrape)}
+}
+
+// Store records a successful scrape of node.
+func (l *LastScrapes) Store(node string, s NodeScrape) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.nodes[node] = s
+}
+
+// Load returns the last successful scrape of node.
+func (l *LastScrapes) Load(node string) (NodeScrape, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	s, ok := l.nodes[node]
+	return s, ok
+}
+
+// Nodes returns the cached node names, sorted.
+func (l *LastScrapes) Nodes() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	names := make([]string, 0, len(l.nodes))
+	for n := range l.nodes {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Retain forgets nodes not in nodes.
+func (l *LastScrapes) Retain(nodes map[string]bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for node := range l.nodes {
+		if !nodes[node] {
+			delete(l.nodes, node)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package aggregate
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLastScrapes(t *testing.T) {
+	at := time.Unix(1700000000, 0)
+	l := NewLastScrapes()
+	if _, ok := l.Load("a"); ok {
+		t.Fatal("empty cache returned a scrape")
+	}
+	l.Store("b", NodeScrape{CPU: 2, Mem: 20, At: at})
+	l.Store("a", NodeScrape{CPU: 1, Mem: 10, At: at})
+	l.Store("a", NodeScrape{CPU: 3, Mem: 30, At: at.Add(time.Minute)})
+	if got, want := l.Nodes(), []string{"a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Nodes = %v, want %v", got, want)
+	}
+	if got, ok := l.Load("a"); !ok || got != (NodeScrape{CPU: 3, Mem: 30, At: at.Add(time.Minute)}) {
+		t.Errorf("Load(a) = %+v, %v; want the latest scrape", got, ok)
+	}
+	l.Retain(map[string]bool{"a": true})
+	if _, ok := l.Load("b"); ok {
+		t.Error("Retain kept a node that left")
+	}
+}
@@ -0,0 +1,88 @@
+package aggregate
+
+import (
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/your-org/k8s-ai-exporter/alert"
+	"github.com/your-org/k8s-ai-exporter/collector"
+)
+
+// HoursPerMonth is the average month that monthly costs are projected
+// over.
+const HoursPerMonth = 730
+
+// Prices are what a core-hour of CPU and a GiB-hour of memory cost, in
+// the currency budgets are written in.
+type Prices struct {
+	CPUCoreHour   float64
+	MemoryGiBHour float64
+}
+
+// Set reports whether any price is known.
+func (p Prices) Set() bool {
+	return p.CPUCoreHour > 0 || p.MemoryGiBHour > 0
+}
+
+// Monthly returns what cores and bytes held for a month cost.
+func (p Prices) Monthly(cores, bytes float64) float64 {
+	return (cores*p.CPUCoreHour + bytes/(1<<30)*p.MemoryGiBHour) * HoursPerMonth
+}
+
+// NamespaceBudgetUses compares the usage and the pod requests of each
+// namespace with its budgets, per resource. Where several budgets of a
+// namespace cap the same resource, the lowest cap applies. The cost
+// compares the monthly cost of usage and of requests at prices, and is
+// left out without prices. The result is sorted by namespace and
+// resource.
+func NamespaceBudgetUses(budgets []collector.NamespaceBudget, usage map[string]UsageRate, pods []corev1.Pod, prices Prices) []alert.BudgetUse {
+	limits := make(map[[2]string]float64)
+	lower := func(ns, resource string, v float64) {
+		k := [2]string{ns, resource}
+		if cur, ok := limits[k]; v > 0 && (!ok || v < cur) {
+			limits[k] = v
+		}
+	}
+	for _, b := range budgets {
+		lower(b.Namespace, "cpu", b.CPUCores)
+		lower(b.Namespace, "memory", b.MemoryBytes)
+		if prices.Set() {
+			lower(b.Namespace, "cost", b.MonthlyCost)
+		}
+	}
+	if len(limits) == 0 {
+		return nil
+	}
+	requests := make(map[string]UsageRate)
+	for i := range pods {
+		cpu, mem := PodRequests(&pods[i])
+		r := requests[pods[i].Namespace]
+		r.CPUCores += cpu
+		r.MemoryBytes += mem
+		requests[pods[i].Namespace] = r
+	}
+	uses := make([]alert.BudgetUse, 0, len(limits))
+	for k, limit := range limits {
+		ns, resource := k[0], k[1]
+		u, r := usage[ns], requests[ns]
+		use := alert.BudgetUse{Namespace: ns, Resource: resource, Limit: limit}
+		switch resource {
+		case "cpu":
+			use.Usage, use.Requests = u.CPUCores, r.CPUCores
+		case "memory":
+			use.Usage, use.Requests = u.MemoryBytes, r.MemoryBytes
+		case "cost":
+			use.Usage = prices.Monthly(u.CPUCores, u.MemoryBytes)
+			use.Requests = prices.Monthly(r.CPUCores, r.MemoryBytes)
+		}
+		uses = append(uses, use)
+	}
+	sort.Slice(uses, func(i, j int) bool {
+		if uses[i].Namespace != uses[j].Namespace {
+			return uses[i].Namespace < uses[j].Namespace
+		}
+		return uses[i].Resource < uses[j].Resource
+	})
+	return uses
+}
@@ -0,0 +1,45 @@
+package aggregate
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/your-org/k8s-ai-exporter/alert"
+	"github.com/your-org/k8s-ai-exporter/collector"
+)
+
+func TestNamespaceBudgetUses(t *testing.T) {
+	budgets := []collector.NamespaceBudget{
+		{Namespace: "team-a", Name: "soft", CPUCores: 8, MonthlyCost: 100},
+		{Namespace: "team-a", Name: "tight", CPUCores: 4},
+		{Namespace: "team-b", Name: "mem", MemoryBytes: 4 << 30},
+	}
+	usage := map[string]UsageRate{"team-a": {CPUCores: 2, MemoryBytes: 2 << 30}}
+	pods := []corev1.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "team-a"}, Spec: corev1.PodSpec{Containers: []corev1.Container{{Resources: requests("3", "1Gi")}}}},
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "team-b"}, Spec: corev1.PodSpec{Containers: []corev1.Container{{Resources: requests("1", "6Gi")}}}},
+	}
+
+	got := NamespaceBudgetUses(budgets, usage, pods, Prices{})
+	want := []alert.BudgetUse{
+		{Namespace: "team-a", Resource: "cpu", Limit: 4, Usage: 2, Requests: 3},
+		{Namespace: "team-b", Resource: "memory", Limit: 4 << 30, Requests: 6 << 30},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("without prices: got %+v, want %+v", got, want)
+	}
+
+	// At 0.05 per core-hour and 0.01 per GiB-hour, team-a's requests of 3
+	// cores and 1 GiB cost 0.16 an hour.
+	got = NamespaceBudgetUses(budgets, usage, pods, Prices{CPUCoreHour: 0.05, MemoryGiBHour: 0.01})
+	if len(got) != 3 || got[0].Resource != "cost" || got[0].Limit != 100 {
+		t.Fatalf("with prices: got %+v, want a cost cap of team-a", got)
+	}
+	if c := got[0]; math.Abs(c.Requests-0.16*HoursPerMonth) > 1e-9 || math.Abs(c.Usage-0.12*HoursPerMonth) > 1e-9 || !c.Exceeded() {
+		t.Errorf("cost use %+v, want requests %v and usage %v a month", c, 0.16*HoursPerMonth, 0.12*HoursPerMonth)
+	}
+}
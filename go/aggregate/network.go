@@ -0,0 +1,75 @@
+package aggregate
+
+import (
+	"sync"
+	"time"
+
+	"github.com/your-org/k8s-ai-exporter/collector"
+)
+
+// NetRates are per-second host interface error and drop rates of one node.
+type NetRates struct {
+	RxErrors, TxErrors float64
+	RxDrops, TxDrops   float64
+}
+
+type netSnapshot struct {
+	counters collector.NetCounters
+	at       time.Time
+}
+
+// NetworkRates converts cumulative network counters into per-second rates
+// using the previous observation of each node. It is safe for concurrent
+// use.
+type NetworkRates struct {
+	mu   sync.Mutex
+	prev map[string]netSnapshot
+}
+
+// NewNetworkRates returns a tracker without previous observations.
+func NewNetworkRates() *NetworkRates {
+	return &NetworkRates{prev: make(map[string]netSnapshot)}
+}
+
+// Observe records cur for node and returns the rates since the previous
+// observation. The first observation, and any observation after a counter
+// reset, only primes the state and returns ok=false.
+func (r *NetworkRates) Observe(node string, cur collector.NetCounters, now time.Time) (rates NetRates, ok bool) {
+	r.mu.Lock()
+	prev, ok := r.prev[node]
+	r.prev[node] = netSnapshot{counters: cur, at: now}
+	r.mu.Unlock()
+	if !ok {
+		return NetRates{}, false
+	}
+	elapsed := now.Sub(prev.at).Seconds()
+	if elapsed <= 0 {
+		return NetRates{}, false
+	}
+	return NetRates{
+		RxErrors: CounterRate(prev.counters.RxErrors, cur.RxErrors, elapsed),
+		TxErrors: CounterRate(prev.counters.TxErrors, cur.TxErrors, elapsed),
+		RxDrops:  CounterRate(prev.counters.RxDrops, cur.RxDrops, elapsed),
+		TxDrops:  CounterRate(prev.counters.TxDrops, cur.TxDrops, elapsed),
+	}, true
+}
+
+// Retain forgets the state of nodes not in nodes.
+func (r *NetworkRates) Retain(nodes map[string]bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for node := range r.prev {
+		if !nodes[node] {
+			delete(r.prev, node)
+		}
+	}
+}
+
+// CounterRate returns the per-second increase between two counter values,
+// treating a decrease as a reset (rate 0).
+func CounterRate(prev, cur, elapsedSeconds float64) float64 {
+	if cur < prev || elapsedSeconds <= 0 {
+		return 0
+	}
+	return (cur - prev) / elapsedSeconds
+}
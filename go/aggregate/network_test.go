@@ -0,0 +1,42 @@
+package aggregate
+
+import (
+	"testing"
+	"time"
+
+	"github.com/your-org/k8s-ai-exporter/collector"
+)
+
+func TestCounterRate(t *testing.T) {
+	tests := []struct {
+		prev, cur, elapsed float64
+		want               float64
+	}{
+		{10, 40, 30, 1},
+		{10, 10, 30, 0},
+		{40, 10, 30, 0}, // counter reset
+		{10, 40, 0, 0},
+	}
+	for _, tt := range tests {
+		if got := CounterRate(tt.prev, tt.cur, tt.elapsed); got != tt.want {
+			t.Errorf("CounterRate(%v, %v, %v) = %v, want %v", tt.prev, tt.cur, tt.elapsed, got, tt.want)
+		}
+	}
+}
+
+func TestNetworkRates(t *testing.T) {
+	r := NewNetworkRates()
+	start := time.Unix(1700000000, 0)
+	if _, ok := r.Observe("a", collector.NetCounters{RxErrors: 10}, start); ok {
+		t.Fatal("first observation returned rates")
+	}
+	rates, ok := r.Observe("a", collector.NetCounters{RxErrors: 40, TxDrops: 6}, start.Add(30*time.Second))
+	if !ok || rates != (NetRates{RxErrors: 1, TxDrops: 0.2}) {
+		t.Errorf("Observe = %+v, %v; want {RxErrors:1 TxDrops:0.2}, true", rates, ok)
+	}
+
+	r.Retain(map[string]bool{"b": true})
+	if _, ok := r.Observe("a", collector.NetCounters{}, start.Add(time.Minute)); ok {
+		t.Error("forgotten node still had a previous observation")
+	}
+}
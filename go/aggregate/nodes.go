@@ -0,0 +1,157 @@
+package aggregate
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// PodsPerNode counts pods per node they are bound to.
+func PodsPerNode(pods []corev1.Pod) map[string]float64 {
+	counts := make(map[string]float64)
+	for _, p := range pods {
+		counts[p.Spec.NodeName]++
+	}
+	return counts
+}
+
+// Kinds of infrastructure pods that WorkloadPodsPerNode can leave out.
+const (
+	PodKindDaemonSet = "daemonset" // owned by a DaemonSet
+	PodKindMirror    = "mirror"    // mirror of a kubelet static pod
+)
+
+// mirrorAnnotation is set by the kubelet on the API objects of static pods.
+const mirrorAnnotation = "kubernetes.io/config.mirror"
+
+// ParsePodKinds parses a comma-separated list of pod kinds, e.g.
+// "daemonset,mirror", into a set.
+func ParsePodKinds(csv string) (map[string]bool, error) {
+	kinds := make(map[string]bool)
+	for _, k := range strings.Split(csv, ",") {
+		k = strings.ToLower(strings.TrimSpace(k))
+		switch k {
+		case "":
+		case PodKindDaemonSet, PodKindMirror:
+			kinds[k] = true
+		default:
+			return nil, fmt.Errorf("unknown pod kind %q, want %s or %s", k, PodKindDaemonSet, PodKindMirror)
+		}
+	}
+	return kinds, nil
+}
+
+// PodKind returns PodKindMirror or PodKindDaemonSet for infrastructure
+// pods and "" for every other pod.
+func PodKind(pod *corev1.Pod) string {
+	if _, ok := pod.Annotations[mirrorAnnotation]; ok {
+		return PodKindMirror
+	}
+	for _, ref := range pod.OwnerReferences {
+		if ref.Controller != nil && *ref.Controller && ref.Kind == "DaemonSet" {
+			return PodKindDaemonSet
+		}
+	}
+	return ""
+}
+
+// WorkloadPodsPerNode counts pods per node like PodsPerNode, leaving out
+// pods whose PodKind is in exclude. Nodes with only excluded pods count 0.
+func WorkloadPodsPerNode(pods []corev1.Pod, exclude map[string]bool) map[string]float64 {
+	counts := make(map[string]float64)
+	for i := range pods {
+		node := pods[i].Spec.NodeName
+		if kind := PodKind(&pods[i]); kind != "" && exclude[kind] {
+			if _, ok := counts[node]; !ok {
+				counts[node] = 0
+			}
+			continue
+		}
+		counts[node]++
+	}
+	return counts
+}
+
+// NodeConditions returns, per node and condition type (Ready,
+// MemoryPressure, ...), 1 when the condition is True and 0 otherwise.
+func NodeConditions(nodes []corev1.Node) map[string]map[string]float64 {
+	out := make(map[string]map[string]float64, len(nodes))
+	for _, n := range nodes {
+		conds := make(map[string]float64, len(n.Status.Conditions))
+		for _, c := range n.Status.Conditions {
+			v := 0.0
+			if c.Status == corev1.ConditionTrue {
+				v = 1
+			}
+			conds[string(c.Type)] = v
+		}
+		out[n.Name] = conds
+	}
+	return out
+}
+
+// Unreachable reports whether node's Ready condition is False or Unknown,
+// that is whether its kubelet is down or stopped posting status. A node
+// without a Ready condition yet counts as reachable.
+func Unreachable(node *corev1.Node) bool {
+	for _, c := range node.Status.Conditions {
+		if c.Type == corev1.NodeReady {
+			return c.Status != corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// Virtual reports whether node is a virtual node, such as one registered by
+// virtual-kubelet (Azure ACI, AWS Fargate via virtual-kubelet) or an EKS
+// Fargate node. Such nodes have no cAdvisor to scrape.
+func Virtual(node *corev1.Node) bool {
+	if node.Labels["type"] == "virtual-kubelet" || node.Labels["eks.amazonaws.com/compute-type"] == "fargate" {
+		return true
+	}
+	for _, t := range node.Spec.Taints {
+		if t.Key == "virtual-kubelet.io/provider" || t.Key == "eks.amazonaws.com/compute-type" {
+			return true
+		}
+	}
+	return false
+}
+
+// Windows reports whether node runs Windows, per its kubernetes.io/os
+// label or, without one, the OS its kubelet reports. Windows kubelets
+// expose other cAdvisor series than Linux ones, and some endpoints not at
+// all.
+func Windows(node *corev1.Node) bool {
+	if os, ok := node.Labels[corev1.LabelOSStable]; ok {
+		return os == "windows"
+	}
+	return node.Status.NodeInfo.OperatingSystem == "windows"
+}
+
+// PodDensityBuckets are the upper bounds of the pods-per-node histogram.
+// 110 is the kubelet's default max-pods; the larger ones cover nodes with a
+// raised limit such as EKS' ENI-based ones.
+var PodDensityBuckets = []float64{10, 20, 30, 50, 75, 100, 110, 150, 250}
+
+// MaxPods returns the pods a node can run, its allocatable "pods"
+// resource, per node that reports one.
+func MaxPods(nodes []corev1.Node) map[string]float64 {
+	out := make(map[string]float64, len(nodes))
+	for _, n := range nodes {
+		if q, ok := n.Status.Allocatable[corev1.ResourcePods]; ok && !q.IsZero() {
+			out[n.Name] = q.AsApproximateFloat64()
+		}
+	}
+	return out
+}
+
+// PodDensity returns the pod count of every node in nodes, 0 for nodes
+// missing from counts, e.g. for a histogram of pods per node.
+func PodDensity(nodes []corev1.Node, counts map[string]float64) []float64 {
+	out := make([]float64, len(nodes))
+	for i, n := range nodes {
+		out[i] = counts[n.Name]
+	}
+	return out
+}
@@ -0,0 +1,147 @@
+package aggregate
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNodeConditions(t *testing.T) {
+	nodes := []corev1.Node{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "a"},
+			Status: corev1.NodeStatus{Conditions: []corev1.NodeCondition{
+				{Type: corev1.NodeReady, Status: corev1.ConditionTrue},
+				{Type: corev1.NodeMemoryPressure, Status: corev1.ConditionFalse},
+			}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "b"},
+			Status: corev1.NodeStatus{Conditions: []corev1.NodeCondition{
+				{Type: corev1.NodeReady, Status: corev1.ConditionUnknown},
+			}},
+		},
+	}
+	want := map[string]map[string]float64{
+		"a": {"Ready": 1, "MemoryPressure": 0},
+		"b": {"Ready": 0},
+	}
+	if got := NodeConditions(nodes); !reflect.DeepEqual(got, want) {
+		t.Errorf("NodeConditions = %v, want %v", got, want)
+	}
+}
+
+func TestUnreachable(t *testing.T) {
+	for _, tc := range []struct {
+		status corev1.ConditionStatus
+		want   bool
+	}{
+		{corev1.ConditionTrue, false},
+		{corev1.ConditionFalse, true},
+		{corev1.ConditionUnknown, true},
+	} {
+		node := &corev1.Node{Status: corev1.NodeStatus{Conditions: []corev1.NodeCondition{
+			{Type: corev1.NodeMemoryPressure, Status: corev1.ConditionFalse},
+			{Type: corev1.NodeReady, Status: tc.status},
+		}}}
+		if got := Unreachable(node); got != tc.want {
+			t.Errorf("Ready=%s: Unreachable = %v, want %v", tc.status, got, tc.want)
+		}
+	}
+	if Unreachable(&corev1.Node{}) {
+		t.Error("node without conditions is unreachable")
+	}
+}
+
+func TestVirtual(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		node corev1.Node
+		want bool
+	}{
+		{"virtual-kubelet label", corev1.Node{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"type": "virtual-kubelet"}}}, true},
+		{"virtual-kubelet taint", corev1.Node{Spec: corev1.NodeSpec{Taints: []corev1.Taint{
+			{Key: "virtual-kubelet.io/provider", Value: "azure", Effect: corev1.TaintEffectNoSchedule},
+		}}}, true},
+		{"fargate", corev1.Node{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"eks.amazonaws.com/compute-type": "fargate"}}}, true},
+		{"managed node group", corev1.Node{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"eks.amazonaws.com/nodegroup": "ng-1"}}}, false},
+	} {
+		if got := Virtual(&tc.node); got != tc.want {
+			t.Errorf("%s: Virtual = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestWindows(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		node corev1.Node
+		want bool
+	}{
+		{"label", corev1.Node{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"kubernetes.io/os": "windows"}}}, true},
+		{"linux label", corev1.Node{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"kubernetes.io/os": "linux"}}}, false},
+		{"node info", corev1.Node{Status: corev1.NodeStatus{NodeInfo: corev1.NodeSystemInfo{OperatingSystem: "windows"}}}, true},
+		{"label wins", corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"kubernetes.io/os": "linux"}},
+			Status:     corev1.NodeStatus{NodeInfo: corev1.NodeSystemInfo{OperatingSystem: "windows"}},
+		}, false},
+		{"unlabelled", corev1.Node{}, false},
+	} {
+		if got := Windows(&tc.node); got != tc.want {
+			t.Errorf("%s: Windows = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestWorkloadPodsPerNode(t *testing.T) {
+	controller := true
+	pods := []corev1.Pod{
+		{Spec: corev1.PodSpec{NodeName: "a"}},
+		{
+			ObjectMeta: metav1.ObjectMeta{OwnerReferences: []metav1.OwnerReference{{Kind: "DaemonSet", Name: "fluent-bit", Controller: &controller}}},
+			Spec:       corev1.PodSpec{NodeName: "a"},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{"kubernetes.io/config.mirror": "abc"}},
+			Spec:       corev1.PodSpec{NodeName: "b"},
+		},
+	}
+	for _, tc := range []struct {
+		exclude string
+		want    map[string]float64
+	}{
+		{"daemonset,mirror", map[string]float64{"a": 1, "b": 0}},
+		{"mirror", map[string]float64{"a": 2, "b": 0}},
+		{"", map[string]float64{"a": 2, "b": 1}},
+	} {
+		exclude, err := ParsePodKinds(tc.exclude)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := WorkloadPodsPerNode(pods, exclude); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("exclude %q: WorkloadPodsPerNode = %v, want %v", tc.exclude, got, tc.want)
+		}
+	}
+	if _, err := ParsePodKinds("daemonset,job"); err == nil {
+		t.Error("ParsePodKinds accepted an unknown kind")
+	}
+}
+
+func TestMaxPods(t *testing.T) {
+	nodes := []corev1.Node{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "a"},
+			Status:     corev1.NodeStatus{Allocatable: corev1.ResourceList{corev1.ResourcePods: resource.MustParse("110")}},
+		},
+		{ObjectMeta: metav1.ObjectMeta{Name: "b"}},
+	}
+	if got, want := MaxPods(nodes), map[string]float64{"a": 110}; !reflect.DeepEqual(got, want) {
+		t.Errorf("MaxPods = %v, want %v", got, want)
+	}
+	if got, want := PodDensity(nodes, map[string]float64{"a": 98}), []float64{98, 0}; !reflect.DeepEqual(got, want) {
+		t.Errorf("PodDensity = %v, want %v", got, want)
+	}
+}
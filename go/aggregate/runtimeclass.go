@@ -0,0 +1,79 @@
+package aggregate
+
+import (
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/your-org/k8s-ai-exporter/collector"
+)
+
+// DefaultRuntimeClass labels pods without spec.runtimeClassName, which run
+// on the node's default handler (usually runc).
+const DefaultRuntimeClass = "default"
+
+// RuntimeClassOf returns the RuntimeClass a pod runs with.
+func RuntimeClassOf(pod *corev1.Pod) string {
+	if rc := pod.Spec.RuntimeClassName; rc != nil && *rc != "" {
+		return *rc
+	}
+	return DefaultRuntimeClass
+}
+
+// RuntimeClassPods counts pods per node and RuntimeClass.
+func RuntimeClassPods(pods []corev1.Pod) map[string]map[string]float64 {
+	counts := make(map[string]map[string]float64)
+	for i := range pods {
+		node := pods[i].Spec.NodeName
+		if counts[node] == nil {
+			counts[node] = make(map[string]float64)
+		}
+		counts[node][RuntimeClassOf(&pods[i])]++
+	}
+	return counts
+}
+
+// RuntimeClasses maps each pod to its RuntimeClass.
+func RuntimeClasses(pods []corev1.Pod) map[collector.PodRef]string {
+	classes := make(map[collector.PodRef]string, len(pods))
+	for i := range pods {
+		classes[collector.PodRef{Namespace: pods[i].Namespace, Name: pods[i].Name}] = RuntimeClassOf(&pods[i])
+	}
+	return classes
+}
+
+// UsageByRuntimeClass sums the usage of a node's pods per RuntimeClass.
+// Pods missing from classes (finished since the pod list) are left out.
+func UsageByRuntimeClass(classes map[collector.PodRef]string, pods map[collector.PodRef]collector.NamespaceUsage) map[string]collector.NamespaceUsage {
+	out := make(map[string]collector.NamespaceUsage)
+	for ref, u := range pods {
+		rc, ok := classes[ref]
+		if !ok {
+			continue
+		}
+		sum := out[rc]
+		sum.CPU += u.CPU
+		sum.Mem += u.Mem
+		sum.PodCPU += u.PodCPU
+		sum.PodMem += u.PodMem
+		out[rc] = sum
+	}
+	return out
+}
+
+// TotalUsageRates returns the average usage inc represents over elapsed,
+// including the pods' overhead.
+func TotalUsageRates(inc map[string]UsageIncrease, elapsed time.Duration) map[string]UsageRate {
+	secs := elapsed.Seconds()
+	rates := make(map[string]UsageRate, len(inc))
+	if secs <= 0 {
+		return rates
+	}
+	for k, u := range inc {
+		rates[k] = UsageRate{
+			CPUCores:    (u.CoreSeconds + u.OverheadCoreSeconds) / secs,
+			MemoryBytes: (u.ByteSeconds + u.OverheadByteSeconds) / secs,
+		}
+	}
+	return rates
+}
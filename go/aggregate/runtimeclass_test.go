@@ -0,0 +1,57 @@
+package aggregate
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/your-org/k8s-ai-exporter/collector"
+)
+
+func TestRuntimeClassPods(t *testing.T) {
+	kata, empty := "kata", ""
+	pods := []corev1.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "a"}, Spec: corev1.PodSpec{NodeName: "n1"}},
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "b"}, Spec: corev1.PodSpec{NodeName: "n1", RuntimeClassName: &kata}},
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "ci", Name: "c"}, Spec: corev1.PodSpec{NodeName: "n1", RuntimeClassName: &kata}},
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "ci", Name: "d"}, Spec: corev1.PodSpec{NodeName: "n2", RuntimeClassName: &empty}},
+	}
+	want := map[string]map[string]float64{
+		"n1": {"default": 1, "kata": 2},
+		"n2": {"default": 1},
+	}
+	if got := RuntimeClassPods(pods); !reflect.DeepEqual(got, want) {
+		t.Errorf("RuntimeClassPods = %v, want %v", got, want)
+	}
+
+	classes := RuntimeClasses(pods)
+	usage := map[collector.PodRef]collector.NamespaceUsage{
+		{Namespace: "shop", Name: "a"}:    {CPU: 10, Mem: 100},
+		{Namespace: "shop", Name: "b"}:    {CPU: 5, Mem: 50, PodCPU: 7, PodMem: 150},
+		{Namespace: "ci", Name: "c"}:      {CPU: 1, Mem: 10, PodCPU: 2, PodMem: 60},
+		{Namespace: "ci", Name: "gone-x"}: {CPU: 99},
+	}
+	wantUsage := map[string]collector.NamespaceUsage{
+		"default": {CPU: 10, Mem: 100},
+		"kata":    {CPU: 6, Mem: 60, PodCPU: 9, PodMem: 210},
+	}
+	if got := UsageByRuntimeClass(classes, usage); !reflect.DeepEqual(got, wantUsage) {
+		t.Errorf("UsageByRuntimeClass = %v, want %v", got, wantUsage)
+	}
+}
+
+func TestTotalUsageRates(t *testing.T) {
+	inc := map[string]UsageIncrease{
+		"kata": {CoreSeconds: 20, ByteSeconds: 1000, OverheadCoreSeconds: 10, OverheadByteSeconds: 500},
+	}
+	want := map[string]UsageRate{"kata": {CPUCores: 3, MemoryBytes: 150}}
+	if got := TotalUsageRates(inc, 10*time.Second); !reflect.DeepEqual(got, want) {
+		t.Errorf("TotalUsageRates = %v, want %v", got, want)
+	}
+	if got := TotalUsageRates(inc, 0); len(got) != 0 {
+		t.Errorf("TotalUsageRates over 0s = %v, want none", got)
+	}
+}
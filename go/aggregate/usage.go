@@ -0,0 +1,133 @@
+package aggregate
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/your-org/k8s-ai-exporter/collector"
+)
+
+// UsageIncrease is what a namespace consumed between two observations.
+// The Overhead fields are what its pods' sandboxes and RuntimeClass
+// overhead consumed on top of the containers.
+type UsageIncrease struct {
+	CoreSeconds float64
+	ByteSeconds float64
+
+	OverheadCoreSeconds float64
+	OverheadByteSeconds float64
+}
+
+type usageSnapshot struct {
+	usage map[string]collector.NamespaceUsage
+	at    time.Time
+}
+
+// UsageCounters turns per-node namespace usage into increases for monotonic
+// billing counters, using the previous observation of each node. It is
+// safe for concurrent use.
+//
+// Core-seconds are the increase of the namespace's summed CPU counters; a
+// decrease (a container went away or restarted) contributes nothing for
+// that interval, so totals err low by at most one interval of the churned
+// containers. Byte-seconds integrate the working set with the trapezoidal
+// rule.
+//
+// Overhead is the increase of the pod-level cgroups minus that of the
+// containers, and the pod-level working set minus the containers'; both
+// are clamped at zero. A restarted container adds its whole interval to
+// the overhead, as its own counter reset contributes nothing.
+type UsageCounters struct {
+	mu   sync.Mutex
+	prev map[string]usageSnapshot
+}
+
+// NewUsageCounters returns a tracker without previous observations.
+func NewUsageCounters() *UsageCounters {
+	return &UsageCounters{prev: make(map[string]usageSnapshot)}
+}
+
+// Observe records cur for node and returns each namespace's increase since
+// the previous observation, and the time between the two. The first
+// observation of a node only primes the state and returns ok=false;
+// namespaces new on the node are left out until their second observation.
+func (u *UsageCounters) Observe(node string, cur map[string]collector.NamespaceUsage, now time.Time) (inc map[string]UsageIncrease, elapsed time.Duration, ok bool) {
+	u.mu.Lock()
+	prev, ok := u.prev[node]
+	u.prev[node] = usageSnapshot{usage: cur, at: now}
+	u.mu.Unlock()
+	if !ok {
+		return nil, 0, false
+	}
+	elapsed = now.Sub(prev.at)
+	if elapsed <= 0 {
+		return nil, 0, false
+	}
+	secs := elapsed.Seconds()
+	inc = make(map[string]UsageIncrease, len(cur))
+	for ns, c := range cur {
+		p, ok := prev.usage[ns]
+		if !ok {
+			continue
+		}
+		core := CounterRate(p.CPU, c.CPU, secs) * secs
+		inc[ns] = UsageIncrease{
+			CoreSeconds:         core,
+			ByteSeconds:         (p.Mem + c.Mem) / 2 * secs,
+			OverheadCoreSeconds: math.Max(0, CounterRate(p.PodCPU, c.PodCPU, secs)*secs-core),
+			OverheadByteSeconds: (overheadMem(p) + overheadMem(c)) / 2 * secs,
+		}
+	}
+	return inc, elapsed, true
+}
+
+// overheadMem is the working set of u's pod-level cgroups beyond that of
+// its containers, or 0 when the pod-level cgroups were not reported.
+func overheadMem(u collector.NamespaceUsage) float64 {
+	return math.Max(0, u.PodMem-u.Mem)
+}
+
+// AddUsage adds the increases in inc to into.
+func AddUsage(into, inc map[string]UsageIncrease) {
+	for ns, u := range inc {
+		sum := into[ns]
+		sum.CoreSeconds += u.CoreSeconds
+		sum.ByteSeconds += u.ByteSeconds
+		sum.OverheadCoreSeconds += u.OverheadCoreSeconds
+		sum.OverheadByteSeconds += u.OverheadByteSeconds
+		into[ns] = sum
+	}
+}
+
+// Retain forgets the state of nodes not in nodes.
+func (u *UsageCounters) Retain(nodes map[string]bool) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	for node := range u.prev {
+		if !nodes[node] {
+			delete(u.prev, node)
+		}
+	}
+}
+
+// UsageRate is a namespace's average usage over an observation interval.
+type UsageRate struct {
+	CPUCores    float64
+	MemoryBytes float64
+}
+
+// AddUsageRates adds the average usage that inc represents over elapsed to
+// into, so rates of the namespace's pods on several nodes sum up.
+func AddUsageRates(into map[string]UsageRate, inc map[string]UsageIncrease, elapsed time.Duration) {
+	secs := elapsed.Seconds()
+	if secs <= 0 {
+		return
+	}
+	for ns, u := range inc {
+		r := into[ns]
+		r.CPUCores += u.CoreSeconds / secs
+		r.MemoryBytes += u.ByteSeconds / secs
+		into[ns] = r
+	}
+}
@@ -0,0 +1,81 @@
+package aggregate
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/your-org/k8s-ai-exporter/collector"
+)
+
+func TestUsageCounters(t *testing.T) {
+	u := NewUsageCounters()
+	start := time.Unix(1700000000, 0)
+	if _, _, ok := u.Observe("a", map[string]collector.NamespaceUsage{"shop": {CPU: 100, Mem: 1000}}, start); ok {
+		t.Fatal("first observation returned increases")
+	}
+
+	inc, elapsed, ok := u.Observe("a", map[string]collector.NamespaceUsage{
+		"shop":  {CPU: 130, Mem: 3000},
+		"batch": {CPU: 5, Mem: 10},
+	}, start.Add(10*time.Second))
+	want := map[string]UsageIncrease{"shop": {CoreSeconds: 30, ByteSeconds: 20000}}
+	if !ok || elapsed != 10*time.Second || !reflect.DeepEqual(inc, want) {
+		t.Errorf("Observe = %v, %v, %v; want %v, 10s, true", inc, elapsed, ok, want)
+	}
+
+	inc, _, _ = u.Observe("a", map[string]collector.NamespaceUsage{
+		"shop":  {CPU: 20, Mem: 1000}, // a container went away
+		"batch": {CPU: 9, Mem: 10},
+	}, start.Add(20*time.Second))
+	want = map[string]UsageIncrease{
+		"shop":  {CoreSeconds: 0, ByteSeconds: 20000},
+		"batch": {CoreSeconds: 4, ByteSeconds: 100},
+	}
+	if !reflect.DeepEqual(inc, want) {
+		t.Errorf("increase after churn = %v, want %v", inc, want)
+	}
+
+	u.Retain(map[string]bool{"b": true})
+	if _, _, ok := u.Observe("a", map[string]collector.NamespaceUsage{"shop": {CPU: 40}}, start.Add(30*time.Second)); ok {
+		t.Error("forgotten node still had a previous observation")
+	}
+}
+
+func TestUsageCountersOverhead(t *testing.T) {
+	u := NewUsageCounters()
+	start := time.Unix(1700000000, 0)
+	u.Observe("a", map[string]collector.NamespaceUsage{
+		"kata": {CPU: 100, Mem: 1000, PodCPU: 120, PodMem: 1500},
+		"runc": {CPU: 50, Mem: 800, PodCPU: 52, PodMem: 700},
+	}, start)
+	inc, _, _ := u.Observe("a", map[string]collector.NamespaceUsage{
+		"kata": {CPU: 130, Mem: 1000, PodCPU: 160, PodMem: 1700},
+		"runc": {CPU: 60, Mem: 800, PodCPU: 62, PodMem: 700},
+	}, start.Add(10*time.Second))
+	want := map[string]UsageIncrease{
+		"kata": {CoreSeconds: 30, ByteSeconds: 10000, OverheadCoreSeconds: 10, OverheadByteSeconds: 6000},
+		"runc": {CoreSeconds: 10, ByteSeconds: 8000},
+	}
+	if !reflect.DeepEqual(inc, want) {
+		t.Errorf("Observe = %v, want %v", inc, want)
+	}
+}
+
+func TestAddUsage(t *testing.T) {
+	sum := map[string]UsageIncrease{"a": {CoreSeconds: 1, ByteSeconds: 2}}
+	AddUsage(sum, map[string]UsageIncrease{"a": {CoreSeconds: 3, ByteSeconds: 4}, "b": {CoreSeconds: 5}})
+	want := map[string]UsageIncrease{"a": {CoreSeconds: 4, ByteSeconds: 6}, "b": {CoreSeconds: 5}}
+	if !reflect.DeepEqual(sum, want) {
+		t.Errorf("AddUsage = %v, want %v", sum, want)
+	}
+}
+
+func TestAddUsageRates(t *testing.T) {
+	rates := map[string]UsageRate{"a": {CPUCores: 1}}
+	AddUsageRates(rates, map[string]UsageIncrease{"a": {CoreSeconds: 10, ByteSeconds: 40}, "b": {CoreSeconds: 5}}, 10*time.Second)
+	want := map[string]UsageRate{"a": {CPUCores: 2, MemoryBytes: 4}, "b": {CPUCores: 0.5}}
+	if !reflect.DeepEqual(rates, want) {
+		t.Errorf("AddUsageRates = %v, want %v", rates, want)
+	}
+}
@@ -0,0 +1,97 @@
+package aggregate
+
+import (
+	"sync"
+	"time"
+)
+
+// Week is how far back WeekAgo compares.
+const Week = 7 * 24 * time.Hour
+
+// WeekAgo keeps a week of CPU and memory usage per key (node or
+// namespace), averaged over fixed time slots, to compare current usage
+// with the same time last week. Usage must be rates, e.g. from CPURates or
+// AddUsageRates: cumulative CPU counters would compare uptimes rather than
+// load. Memory is bounded by the number of keys times the slots in a week.
+// It is safe for concurrent use.
+type WeekAgo struct {
+	slot time.Duration
+
+	mu    sync.Mutex
+	slots []weekSlot // ring indexed by slot number
+}
+
+type weekSlot struct {
+	number int64 // slot number since the Unix epoch
+	sum    map[string]UsageRate
+	n      map[string]int
+}
+
+// UsageRatio is current usage divided by usage at the same time last week.
+type UsageRatio struct {
+	CPU    float64
+	Memory float64
+}
+
+// NewWeekAgo returns an empty history with slots of length slot, e.g. 5
+// minutes.
+func NewWeekAgo(slot time.Duration) *WeekAgo {
+	// One more slot than a week holds, so the current slot never reuses
+	// the one a week ago.
+	return &WeekAgo{slot: slot, slots: make([]weekSlot, Week/slot+1)}
+}
+
+func (w *WeekAgo) number(t time.Time) int64 {
+	return t.UnixNano() / int64(w.slot)
+}
+
+// Observe adds usage at now to its slot. It does nothing on a nil
+// receiver.
+func (w *WeekAgo) Observe(usage map[string]UsageRate, now time.Time) {
+	if w == nil {
+		return
+	}
+	num := w.number(now)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	s := &w.slots[num%int64(len(w.slots))]
+	if s.number != num || s.sum == nil {
+		*s = weekSlot{number: num, sum: make(map[string]UsageRate), n: make(map[string]int)}
+	}
+	for key, u := range usage {
+		sum := s.sum[key]
+		sum.CPUCores += u.CPUCores
+		sum.MemoryBytes += u.MemoryBytes
+		s.sum[key] = sum
+		s.n[key]++
+	}
+}
+
+// Ratios divides usage by the average of each key's slot a week before
+// now. Keys without usage then, or with none of CPU or memory, are left
+// out. A nil receiver returns nil.
+func (w *WeekAgo) Ratios(usage map[string]UsageRate, now time.Time) map[string]UsageRatio {
+	if w == nil {
+		return nil
+	}
+	num := w.number(now.Add(-Week))
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	s := w.slots[num%int64(len(w.slots))]
+	ratios := make(map[string]UsageRatio)
+	if s.number != num {
+		return ratios
+	}
+	for key, u := range usage {
+		n := float64(s.n[key])
+		past := s.sum[key]
+		if n == 0 || past.CPUCores <= 0 || past.MemoryBytes <= 0 {
+			continue
+		}
+		ratios[key] = UsageRatio{
+			CPU:    u.CPUCores / (past.CPUCores / n),
+			Memory: u.MemoryBytes / (past.MemoryBytes / n),
+		}
+	}
+	return ratios
+}
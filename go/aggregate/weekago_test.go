@@ -0,0 +1,65 @@
+package aggregate
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWeekAgo(t *testing.T) {
+	w := NewWeekAgo(5 * time.Minute)
+	monday := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	w.Observe(map[string]UsageRate{"a": {CPUCores: 1, MemoryBytes: 100}, "b": {CPUCores: 0, MemoryBytes: 50}}, monday)
+	w.Observe(map[string]UsageRate{"a": {CPUCores: 3, MemoryBytes: 300}}, monday.Add(time.Minute))
+
+	now := map[string]UsageRate{
+		"a": {CPUCores: 4, MemoryBytes: 100},
+		"b": {CPUCores: 1, MemoryBytes: 50},
+		"c": {CPUCores: 1, MemoryBytes: 50},
+	}
+	if got := w.Ratios(now, monday); len(got) != 0 {
+		t.Errorf("Ratios without a week of history = %v", got)
+	}
+
+	// The next Monday's slot compares with the average of the slot a week
+	// before; b had no CPU then and c did not exist.
+	nextMonday := monday.Add(Week + 2*time.Minute)
+	want := map[string]UsageRatio{"a": {CPU: 2, Memory: 0.5}}
+	if got := w.Ratios(now, nextMonday); !reflect.DeepEqual(got, want) {
+		t.Errorf("Ratios = %v, want %v", got, want)
+	}
+
+	// Observing the current slot does not overwrite the one a week ago.
+	w.Observe(now, nextMonday)
+	if got := w.Ratios(now, nextMonday); !reflect.DeepEqual(got, want) {
+		t.Errorf("Ratios after observing = %v, want %v", got, want)
+	}
+	if got := w.Ratios(now, nextMonday.Add(5*time.Minute)); len(got) != 0 {
+		t.Errorf("Ratios for a slot without history = %v", got)
+	}
+
+	var none *WeekAgo
+	none.Observe(now, monday)
+	if got := none.Ratios(now, nextMonday); got != nil {
+		t.Errorf("nil Ratios = %v", got)
+	}
+}
+
+func TestWeekAgoNodeCPURates(t *testing.T) {
+	// A node that was up for a week burns far more counter in total
+	// today, but uses 3 cores now against 2 a week ago.
+	w := NewWeekAgo(5 * time.Minute)
+	cpu := NewCPURates()
+	monday := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	cpu.Observe("a", 2823, monday)
+	cores, _ := cpu.Observe("a", 2883, monday.Add(30*time.Second))
+	w.Observe(map[string]UsageRate{"a": {CPUCores: cores, MemoryBytes: 100}}, monday.Add(30*time.Second))
+
+	nextMonday := monday.Add(Week)
+	cpu.Observe("a", 1.5e6, nextMonday)
+	cores, _ = cpu.Observe("a", 1.5e6+90, nextMonday.Add(30*time.Second))
+	got := w.Ratios(map[string]UsageRate{"a": {CPUCores: cores, MemoryBytes: 100}}, nextMonday.Add(30*time.Second))
+	if want := (UsageRatio{CPU: 1.5, Memory: 1}); got["a"] != want {
+		t.Errorf("Ratios = %v, want a: %v", got, want)
+	}
+}
@@ -0,0 +1,130 @@
+package aggregate
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/your-org/k8s-ai-exporter/kube"
+)
+
+const (
+	zoneLabel       = "topology.kubernetes.io/zone"
+	legacyZoneLabel = "failure-domain.beta.kubernetes.io/zone"
+)
+
+// ZoneSpread is the zone distribution of one workload.
+type ZoneSpread struct {
+	// Skew is the difference between the most and least populated zone.
+	Skew int
+	// Violation is set when Skew exceeds the maxSkew of the workload's zone
+	// topologySpreadConstraint.
+	Violation bool
+}
+
+type workloadSpread struct {
+	maxSkew int32 // 0 when only zone anti-affinity is declared
+	zones   map[string]int
+}
+
+// ZoneSkews returns the zone spread of workloads whose pods ask to be spread
+// across zones. Every zone that has at least one node counts as a domain,
+// so a workload packed into one zone of three shows its full skew.
+func ZoneSkews(nodes []corev1.Node, pods []corev1.Pod) map[kube.Workload]ZoneSpread {
+	nodeZone := make(map[string]string, len(nodes))
+	allZones := make(map[string]bool)
+	for _, n := range nodes {
+		if z := nodeZoneName(&n); z != "" {
+			nodeZone[n.Name] = z
+			allZones[z] = true
+		}
+	}
+
+	spreads := make(map[kube.Workload]*workloadSpread)
+	for i := range pods {
+		pod := &pods[i]
+		maxSkew, ok := zoneSpreadIntent(pod)
+		if !ok {
+			continue
+		}
+		key := kube.PodWorkload(pod)
+		ws, ok := spreads[key]
+		if !ok {
+			ws = &workloadSpread{zones: make(map[string]int)}
+			spreads[key] = ws
+		}
+		if maxSkew > ws.maxSkew {
+			ws.maxSkew = maxSkew
+		}
+		if z, ok := nodeZone[pod.Spec.NodeName]; ok {
+			ws.zones[z]++
+		}
+	}
+
+	out := make(map[kube.Workload]ZoneSpread, len(spreads))
+	for key, ws := range spreads {
+		skew := zoneSkew(ws.zones, allZones)
+		out[key] = ZoneSpread{Skew: skew, Violation: ws.maxSkew > 0 && skew > int(ws.maxSkew)}
+	}
+	return out
+}
+
+func nodeZoneName(node *corev1.Node) string {
+	if z := node.Labels[zoneLabel]; z != "" {
+		return z
+	}
+	return node.Labels[legacyZoneLabel]
+}
+
+func isZoneKey(key string) bool {
+	return key == zoneLabel || key == legacyZoneLabel
+}
+
+// zoneSpreadIntent reports whether the pod declares a zone
+// topologySpreadConstraint or required/preferred zone anti-affinity, and the
+// smallest maxSkew among its zone spread constraints.
+func zoneSpreadIntent(pod *corev1.Pod) (maxSkew int32, ok bool) {
+	for _, c := range pod.Spec.TopologySpreadConstraints {
+		if !isZoneKey(c.TopologyKey) {
+			continue
+		}
+		if !ok || c.MaxSkew < maxSkew {
+			maxSkew = c.MaxSkew
+		}
+		ok = true
+	}
+	if ok {
+		return maxSkew, true
+	}
+	if aff := pod.Spec.Affinity; aff != nil && aff.PodAntiAffinity != nil {
+		for _, term := range aff.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution {
+			if isZoneKey(term.TopologyKey) {
+				return 0, true
+			}
+		}
+		for _, wt := range aff.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution {
+			if isZoneKey(wt.PodAffinityTerm.TopologyKey) {
+				return 0, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// zoneSkew is max(count) - min(count) over all known zones.
+func zoneSkew(counts map[string]int, zones map[string]bool) int {
+	if len(zones) == 0 {
+		return 0
+	}
+	first := true
+	var lo, hi int
+	for z := range zones {
+		c := counts[z]
+		if first || c < lo {
+			lo = c
+		}
+		if first || c > hi {
+			hi = c
+		}
+		first = false
+	}
+	return hi - lo
+}
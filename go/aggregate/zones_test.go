@@ -0,0 +1,40 @@
+package aggregate
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestZoneSkew(t *testing.T) {
+	zones := map[string]bool{"a": true, "b": true, "c": true}
+	tests := []struct {
+		counts map[string]int
+		want   int
+	}{
+		{map[string]int{"a": 2, "b": 2, "c": 2}, 0},
+		{map[string]int{"a": 3, "b": 1, "c": 2}, 2},
+		{map[string]int{"a": 3}, 3},
+	}
+	for _, tt := range tests {
+		if got := zoneSkew(tt.counts, zones); got != tt.want {
+			t.Errorf("zoneSkew(%v) = %d, want %d", tt.counts, got, tt.want)
+		}
+	}
+	if got := zoneSkew(map[string]int{"a": 1}, nil); got != 0 {
+		t.Errorf("zoneSkew with no zones = %d, want 0", got)
+	}
+}
+
+func TestZoneSpreadIntent(t *testing.T) {
+	pod := corev1.Pod{Spec: corev1.PodSpec{TopologySpreadConstraints: []corev1.TopologySpreadConstraint{
+		{MaxSkew: 2, TopologyKey: zoneLabel},
+		{MaxSkew: 1, TopologyKey: "kubernetes.io/hostname"},
+	}}}
+	if skew, ok := zoneSpreadIntent(&pod); !ok || skew != 2 {
+		t.Errorf("zoneSpreadIntent = %d, %v; want 2, true", skew, ok)
+	}
+	if _, ok := zoneSpreadIntent(&corev1.Pod{}); ok {
+		t.Error("zoneSpreadIntent on plain pod = true, want false")
+	}
+}
@@ -0,0 +1,133 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/your-org/k8s-ai-exporter/tlspolicy"
+)
+
+// Alertmanager posts alerts to the v2 API of one or more Alertmanagers,
+// so the built-in rules go through the organization's routing, grouping
+// and silences. Like Prometheus, it sends to every URL: an HA cluster
+// deduplicates the copies itself.
+//
+// Alertmanager resolves an alert whose endsAt has passed, so firing alerts
+// must be re-sent, e.g. every cycle with Saturation.Firing, before Hold
+// runs out.
+type Alertmanager struct {
+	URLs   []string          // base URLs, e.g. http://alertmanager:9093
+	Labels map[string]string // added to every alert, e.g. cluster; cannot override the alert's own
+	Hold   time.Duration     // how long a firing alert lasts without a re-send
+	Client *http.Client      // tlspolicy.Default.HTTPClient() when nil
+}
+
+// alertmanagerAlert is an alert in the Alertmanager v2 API.
+type alertmanagerAlert struct {
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+	StartsAt    time.Time         `json:"startsAt"`
+	EndsAt      time.Time         `json:"endsAt"`
+}
+
+// Notify sends alerts in one request per Alertmanager and returns the
+// errors of those that failed.
+func (am *Alertmanager) Notify(ctx context.Context, alerts []Alert) error {
+	now := time.Now()
+	payload := make([]alertmanagerAlert, len(alerts))
+	for i, a := range alerts {
+		labels := make(map[string]string, len(am.Labels)+4)
+		for k, v := range am.Labels {
+			labels[k] = v
+		}
+		labels["alertname"] = a.Name
+		if a.Namespace != "" {
+			labels["namespace"] = a.Namespace
+		} else {
+			labels["node"] = a.Node
+		}
+		labels["resource"] = a.Resource
+		labels["severity"] = a.Severity
+		ends := a.EndsAt
+		if a.Firing {
+			ends = now.Add(am.Hold)
+		}
+		payload[i] = alertmanagerAlert{
+			Labels: labels,
+			Annotations: map[string]string{
+				"summary":         a.Summary(),
+				"fingerprint":     a.Fingerprint(),
+				"threshold":       fmt.Sprint(a.Threshold),
+				"target":          fmt.Sprint(a.Target),
+				"short_burn_rate": fmt.Sprintf("%.2f", a.ShortBurnRate),
+				"long_burn_rate":  fmt.Sprintf("%.2f", a.LongBurnRate),
+			},
+			StartsAt: a.StartsAt.UTC(),
+			EndsAt:   ends.UTC(),
+		}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, u := range am.URLs {
+		if err := postJSON(ctx, am.Client, strings.TrimSuffix(strings.TrimSpace(u), "/")+"/api/v2/alerts", nil, body); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Check fails unless every Alertmanager answers its /-/healthy endpoint
+// with 200 OK.
+func (am *Alertmanager) Check(ctx context.Context) error {
+	client := am.Client
+	if client == nil {
+		client = tlspolicy.Default.HTTPClient()
+	}
+	var errs []error
+	for _, u := range am.URLs {
+		u = strings.TrimSuffix(strings.TrimSpace(u), "/") + "/-/healthy"
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			errs = append(errs, fmt.Errorf("GET %s: %s", u, resp.Status))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ParseLabels parses "<name>=<value>,..." into labels, e.g. the
+// -alertmanager-labels flag.
+func ParseLabels(spec string) (map[string]string, error) {
+	labels := make(map[string]string)
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(pair, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("label %q: want <name>=<value>", pair)
+		}
+		labels[name] = strings.TrimSpace(value)
+	}
+	return labels, nil
+}
@@ -0,0 +1,80 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAlertmanager(t *testing.T) {
+	srv, reqs := recordRequests(t, http.StatusOK)
+	am := &Alertmanager{
+		URLs:   []string{srv.URL + "/", srv.URL + "/am2"},
+		Labels: map[string]string{"cluster": "prod", "node": "not-this"},
+		Hold:   4 * time.Minute,
+		Client: srv.Client(),
+	}
+	before := time.Now()
+	resolved := testAlerts[1]
+	resolved.StartsAt = time.Date(2024, 5, 1, 11, 0, 0, 0, time.UTC)
+	resolved.EndsAt = resolved.StartsAt.Add(time.Hour)
+	if err := am.Notify(context.Background(), []Alert{testAlerts[0], resolved}); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	if len(*reqs) != 2 || (*reqs)[0].path != "/api/v2/alerts" || (*reqs)[1].path != "/am2/api/v2/alerts" {
+		t.Fatalf("requests = %+v, want one per Alertmanager", *reqs)
+	}
+	var got []alertmanagerAlert
+	if err := json.Unmarshal((*reqs)[0].raw, &got); err != nil {
+		t.Fatalf("body %s: %v", (*reqs)[0].raw, err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("alerts = %+v", got)
+	}
+	firing := got[0]
+	if firing.Labels["alertname"] != SaturationAlert || firing.Labels["node"] != "node-a" || firing.Labels["cluster"] != "prod" || firing.Labels["severity"] != "critical" {
+		t.Errorf("labels = %v", firing.Labels)
+	}
+	if firing.Annotations["fingerprint"] != testAlerts[0].Fingerprint() || firing.Annotations["summary"] != testAlerts[0].Summary() {
+		t.Errorf("annotations = %v", firing.Annotations)
+	}
+	if firing.EndsAt.Before(before.Add(4*time.Minute)) || firing.EndsAt.After(time.Now().Add(4*time.Minute)) {
+		t.Errorf("firing endsAt = %v, want Hold from now", firing.EndsAt)
+	}
+	if !got[1].EndsAt.Equal(resolved.EndsAt) {
+		t.Errorf("resolved endsAt = %v, want %v", got[1].EndsAt, resolved.EndsAt)
+	}
+}
+
+func TestParseLabels(t *testing.T) {
+	labels, err := ParseLabels(" cluster=prod, team = sre ,")
+	if err != nil || len(labels) != 2 || labels["cluster"] != "prod" || labels["team"] != "sre" {
+		t.Errorf("ParseLabels = %v, %v", labels, err)
+	}
+	for _, bad := range []string{"cluster", "=prod"} {
+		if _, err := ParseLabels(bad); err == nil {
+			t.Errorf("ParseLabels(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestAlertmanagerCheck(t *testing.T) {
+	healthy := true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/-/healthy" || !healthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+	am := &Alertmanager{URLs: []string{srv.URL, srv.URL + "/"}, Client: srv.Client()}
+	if err := am.Check(context.Background()); err != nil {
+		t.Errorf("Check: %v", err)
+	}
+	healthy = false
+	if err := am.Check(context.Background()); err == nil {
+		t.Error("Check succeeded on a 503")
+	}
+}
@@ -0,0 +1,291 @@
+package alert
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Windows of the multi-window burn rate evaluation. The long window keeps a
+// brief spike from paging; the short one resolves the alert soon after
+// saturation ends.
+const (
+	ShortWindow = 5 * time.Minute
+	LongWindow  = time.Hour
+
+	// Window names as in PromQL ranges, for labels.
+	ShortWindowName = "5m"
+	LongWindowName  = "1h"
+)
+
+// MinCoverage is the share of a window its samples must span before the
+// window's burn rate can fire an alert. Without it the first sample of a
+// node, after a restart or when it joins, would be the whole window and
+// page on its own.
+const MinCoverage = 0.75
+
+// SaturationAlert is the name of the alerts Saturation raises.
+const SaturationAlert = "NodeSaturationBudgetBurn"
+
+// Objective is the saturation SLO of one resource: at least Target of the
+// samples are at or below Threshold.
+type Objective struct {
+	Threshold float64 // fraction of allocatable, e.g. 0.9
+	Target    float64 // fraction of good samples, e.g. 0.99
+}
+
+// Budget is the fraction of samples allowed above the threshold.
+func (o Objective) Budget() float64 {
+	return 1 - o.Target
+}
+
+// Objectives maps resources ("cpu", "memory") to their objective.
+type Objectives map[string]Objective
+
+// ParseObjectives parses "<resource>=<threshold>/<target>;...", e.g.
+// "cpu=0.9/0.99;memory=0.95/0.99".
+func ParseObjectives(spec string) (Objectives, error) {
+	objectives := make(Objectives)
+	for _, entry := range strings.Split(spec, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		resource, values, ok := strings.Cut(entry, "=")
+		threshold, target, ok2 := strings.Cut(values, "/")
+		resource = strings.TrimSpace(resource)
+		if !ok || !ok2 || (resource != "cpu" && resource != "memory") {
+			return nil, fmt.Errorf("saturation objective %q: want cpu|memory=<threshold>/<target>", entry)
+		}
+		var o Objective
+		var err error
+		if o.Threshold, err = strconv.ParseFloat(strings.TrimSpace(threshold), 64); err != nil {
+			return nil, fmt.Errorf("saturation objective %q: %v", entry, err)
+		}
+		if o.Target, err = strconv.ParseFloat(strings.TrimSpace(target), 64); err != nil {
+			return nil, fmt.Errorf("saturation objective %q: %v", entry, err)
+		}
+		if o.Threshold <= 0 || o.Target <= 0 || o.Target >= 1 {
+			return nil, fmt.Errorf("saturation objective %q: want threshold > 0 and 0 < target < 1", entry)
+		}
+		objectives[resource] = o
+	}
+	return objectives, nil
+}
+
+// Alert is a state change of one alert. It concerns a Node, or a
+// Namespace for budget alerts.
+type Alert struct {
+	Name      string
+	Node      string
+	Namespace string
+	Resource  string
+	Severity  string
+	Firing    bool // false when the alert resolved
+	StartsAt  time.Time
+	EndsAt    time.Time // zero while firing
+	Threshold float64
+	Target    float64
+
+	// Value is the figure that crossed Threshold, for budget alerts.
+	Value float64
+
+	// ShortBurnRate and LongBurnRate are the burn rates over ShortWindow
+	// and LongWindow when the state changed.
+	ShortBurnRate float64
+	LongBurnRate  float64
+}
+
+// BurnRate is the budget burn rate of one node and resource over both
+// windows, and whether its alert is firing.
+type BurnRate struct {
+	Node     string
+	Resource string
+	Short    float64
+	Long     float64
+	Firing   bool
+}
+
+// Saturation evaluates Objectives on node saturation samples. An alert
+// fires when the burn rate over both windows is at least Factor, e.g. 14.4,
+// which spends 2% of a 30-day budget in an hour, and samples cover
+// MinCoverage of both windows. It is safe for concurrent use.
+type Saturation struct {
+	objectives Objectives
+	factor     float64
+	severity   string
+
+	mu      sync.Mutex
+	samples map[key][]sample
+	firing  map[key]Alert // with the burn rates of the last Observe
+}
+
+type key struct {
+	node, resource string
+}
+
+type sample struct {
+	at  time.Time
+	bad bool
+}
+
+// NewSaturation returns an evaluator of objectives that raises alerts of
+// severity when both burn rates reach factor.
+func NewSaturation(objectives Objectives, factor float64, severity string) *Saturation {
+	return &Saturation{
+		objectives: objectives,
+		factor:     factor,
+		severity:   severity,
+		samples:    make(map[key][]sample),
+		firing:     make(map[key]Alert),
+	}
+}
+
+// Observe records the saturation (usage over allocatable) of each node per
+// resource at now and returns the burn rates of all nodes, sorted by node
+// and resource, and the alerts that started or resolved. Nodes missing from
+// saturation keep their samples until Retain drops them. A nil receiver
+// returns nothing.
+func (s *Saturation) Observe(saturation map[string]map[string]float64, now time.Time) ([]BurnRate, []Alert) {
+	if s == nil {
+		return nil, nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for resource, nodes := range saturation {
+		o, ok := s.objectives[resource]
+		if !ok {
+			continue
+		}
+		for node, v := range nodes {
+			k := key{node, resource}
+			s.samples[k] = append(s.samples[k], sample{at: now, bad: v > o.Threshold})
+		}
+	}
+
+	var rates []BurnRate
+	var changes []Alert
+	for k, samples := range s.samples {
+		samples = trim(samples, now.Add(-LongWindow))
+		s.samples[k] = samples
+		o := s.objectives[k.resource]
+		r := BurnRate{
+			Node:     k.node,
+			Resource: k.resource,
+			Short:    badFraction(samples, now.Add(-ShortWindow)) / o.Budget(),
+			Long:     badFraction(samples, now.Add(-LongWindow)) / o.Budget(),
+		}
+		r.Firing = r.Short >= s.factor && r.Long >= s.factor &&
+			covers(samples, now, ShortWindow) && covers(samples, now, LongWindow)
+		firing, wasFiring := s.firing[k]
+		switch {
+		case r.Firing && !wasFiring:
+			s.firing[k] = s.alert(k, o, r, now, time.Time{})
+			changes = append(changes, s.firing[k])
+		case r.Firing:
+			s.firing[k] = s.alert(k, o, r, firing.StartsAt, time.Time{})
+		case wasFiring:
+			delete(s.firing, k)
+			changes = append(changes, s.alert(k, o, r, firing.StartsAt, now))
+		}
+		rates = append(rates, r)
+	}
+	sort.Slice(rates, func(i, j int) bool {
+		if rates[i].Node != rates[j].Node {
+			return rates[i].Node < rates[j].Node
+		}
+		return rates[i].Resource < rates[j].Resource
+	})
+	sortAlerts(changes)
+	return rates, changes
+}
+
+// Firing returns the alerts firing as of the last Observe, with its burn
+// rates, sorted by node and resource. Receivers that expire alerts, like
+// Alertmanager, need them re-sent while they fire. A nil receiver returns
+// nothing.
+func (s *Saturation) Firing() []Alert {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	alerts := make([]Alert, 0, len(s.firing))
+	for _, a := range s.firing {
+		alerts = append(alerts, a)
+	}
+	sortAlerts(alerts)
+	return alerts
+}
+
+func sortAlerts(alerts []Alert) {
+	sort.Slice(alerts, func(i, j int) bool {
+		if alerts[i].Node != alerts[j].Node {
+			return alerts[i].Node < alerts[j].Node
+		}
+		return alerts[i].Resource < alerts[j].Resource
+	})
+}
+
+func (s *Saturation) alert(k key, o Objective, r BurnRate, starts, ends time.Time) Alert {
+	return Alert{
+		Name:          SaturationAlert,
+		Node:          k.node,
+		Resource:      k.resource,
+		Severity:      s.severity,
+		Firing:        ends.IsZero(),
+		StartsAt:      starts,
+		EndsAt:        ends,
+		Threshold:     o.Threshold,
+		Target:        o.Target,
+		ShortBurnRate: r.Short,
+		LongBurnRate:  r.Long,
+	}
+}
+
+// Retain forgets nodes not in current, without resolving their alerts. It
+// does nothing on a nil receiver.
+func (s *Saturation) Retain(current map[string]bool) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for k := range s.samples {
+		if !current[k.node] {
+			delete(s.samples, k)
+			delete(s.firing, k)
+		}
+	}
+}
+
+// trim drops the samples before since; samples are in time order.
+func trim(samples []sample, since time.Time) []sample {
+	i := sort.Search(len(samples), func(i int) bool { return !samples[i].at.Before(since) })
+	return samples[i:]
+}
+
+// covers reports whether the samples in the window before now span at
+// least MinCoverage of it.
+func covers(samples []sample, now time.Time, window time.Duration) bool {
+	samples = trim(samples, now.Add(-window))
+	return len(samples) > 0 && now.Sub(samples[0].at) >= time.Duration(MinCoverage*float64(window))
+}
+
+// badFraction is the fraction of samples since since above the threshold.
+func badFraction(samples []sample, since time.Time) float64 {
+	samples = trim(samples, since)
+	if len(samples) == 0 {
+		return 0
+	}
+	bad := 0
+	for _, s := range samples {
+		if s.bad {
+			bad++
+		}
+	}
+	return float64(bad) / float64(len(samples))
+}
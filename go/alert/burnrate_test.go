@@ -0,0 +1,111 @@
+package alert
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseObjectives(t *testing.T) {
+	got, err := ParseObjectives(" cpu=0.9/0.99; memory = 0.95/0.995 ;")
+	if err != nil {
+		t.Fatalf("ParseObjectives: %v", err)
+	}
+	if got["cpu"] != (Objective{0.9, 0.99}) || got["memory"] != (Objective{0.95, 0.995}) || len(got) != 2 {
+		t.Errorf("ParseObjectives = %v", got)
+	}
+	for _, spec := range []string{"cpu=0.9", "disk=0.9/0.99", "cpu=x/0.99", "cpu=0.9/1", "cpu=0/0.99"} {
+		if _, err := ParseObjectives(spec); err == nil {
+			t.Errorf("ParseObjectives(%q) succeeded", spec)
+		}
+	}
+}
+
+func TestSaturation(t *testing.T) {
+	s := NewSaturation(Objectives{"cpu": {Threshold: 0.9, Target: 0.9}}, 2, "critical")
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	observe := func(at time.Duration, cpu float64) ([]BurnRate, []Alert) {
+		return s.Observe(map[string]map[string]float64{
+			"cpu":    {"node-a": cpu},
+			"memory": {"node-a": 1}, // no objective
+		}, start.Add(at))
+	}
+
+	// 40 minutes at 50%, then saturated every minute.
+	for m := 0; m < 40; m++ {
+		if _, alerts := observe(time.Duration(m)*time.Minute, 0.5); len(alerts) != 0 {
+			t.Fatalf("minute %d: alerts %v", m, alerts)
+		}
+	}
+	var fired time.Duration
+	for m := 40; m < 60 && fired == 0; m++ {
+		rates, alerts := observe(time.Duration(m)*time.Minute, 0.95)
+		if len(rates) != 1 || rates[0].Resource != "cpu" {
+			t.Fatalf("rates = %v, want node-a cpu only", rates)
+		}
+		if len(alerts) > 0 {
+			fired = time.Duration(m) * time.Minute
+			a := alerts[0]
+			if !a.Firing || a.Node != "node-a" || a.Severity != "critical" || a.ShortBurnRate < 9.99 || a.LongBurnRate < 1.99 {
+				t.Errorf("alert = %+v", a)
+			}
+		}
+	}
+	// The long window needs 20% bad samples for a burn rate of 2: 10
+	// saturated samples out of 50.
+	if fired != 49*time.Minute {
+		t.Fatalf("fired at %v, want 49m", fired)
+	}
+
+	// Still firing: Firing keeps the start and has the new burn rates.
+	_, alerts := observe(50*time.Minute, 0.95)
+	if firing := s.Firing(); len(alerts) != 0 || len(firing) != 1 || !firing[0].StartsAt.Equal(start.Add(fired)) || firing[0].LongBurnRate < 2.1 {
+		t.Errorf("at 50m: changes %v, Firing = %+v", alerts, firing)
+	}
+
+	// Saturation ends: the short window resolves the alert once its burn
+	// rate drops below 2, i.e. 20% of the last 5 minutes.
+	var resolved []Alert
+	for m := 51; m < 60 && resolved == nil; m++ {
+		_, resolved = observe(time.Duration(m)*time.Minute, 0.5)
+	}
+	if len(resolved) != 1 || resolved[0].Firing || !resolved[0].StartsAt.Equal(start.Add(fired)) || resolved[0].EndsAt.Before(resolved[0].StartsAt) {
+		t.Errorf("resolved = %+v", resolved)
+	}
+	if firing := s.Firing(); len(firing) != 0 {
+		t.Errorf("Firing after resolving = %+v", firing)
+	}
+
+	s.Retain(map[string]bool{})
+	if rates, _ := s.Observe(nil, start.Add(time.Hour)); len(rates) != 0 {
+		t.Errorf("after Retain: rates = %v", rates)
+	}
+
+	var none *Saturation
+	if rates, alerts := none.Observe(map[string]map[string]float64{"cpu": {"n": 1}}, start); rates != nil || alerts != nil {
+		t.Error("nil Saturation returned results")
+	}
+	none.Retain(nil)
+	if none.Firing() != nil {
+		t.Error("nil Saturation has firing alerts")
+	}
+}
+
+func TestSaturationNeedsCoverage(t *testing.T) {
+	s := NewSaturation(Objectives{"cpu": {Threshold: 0.9, Target: 0.99}}, 14.4, "critical")
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	// A node saturated from its first sample: both burn rates are 100 at
+	// once, but the long window is only covered after 45 minutes.
+	var fired time.Duration
+	for m := 0; m <= 60 && fired == 0; m++ {
+		rates, alerts := s.Observe(map[string]map[string]float64{"cpu": {"node-a": 1}}, start.Add(time.Duration(m)*time.Minute))
+		if rates[0].Short < 99.9 || rates[0].Long < 99.9 {
+			t.Fatalf("minute %d: rates = %+v, want 100 over both windows", m, rates[0])
+		}
+		if len(alerts) > 0 {
+			fired = time.Duration(m) * time.Minute
+		}
+	}
+	if fired != 45*time.Minute {
+		t.Errorf("fired at %v, want 45m", fired)
+	}
+}
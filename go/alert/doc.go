@@ -0,0 +1,6 @@
+// Package alert evaluates the exporter's built-in alert rules and delivers
+// their state changes. Saturation alerts are SLO style: a node burns its
+// error budget while its usage is above a threshold of its allocatable
+// resources, and an alert fires when the budget burns too fast over both a
+// short and a long window.
+package alert
@@ -0,0 +1,252 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Default endpoints of the paging services.
+const (
+	PagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+	OpsgenieURL        = "https://api.opsgenie.com" // https://api.eu.opsgenie.com for EU accounts
+)
+
+// Summary is a one-line description of the alert for chat and paging
+// services, e.g. "NodeSaturationBudgetBurn firing: cpu of node a burns its
+// budget 20.0x over 5m and 15.0x over 1h".
+func (a Alert) Summary() string {
+	if !a.Firing {
+		return fmt.Sprintf("%s resolved: %s of %s", a.Name, a.Resource, a.subject())
+	}
+	if a.Namespace != "" {
+		return fmt.Sprintf("%s firing: %s of namespace %s at %.4g over its budget of %.4g",
+			a.Name, a.Resource, a.Namespace, a.Value, a.Threshold)
+	}
+	return fmt.Sprintf("%s firing: %s of node %s burns its budget %.1fx over %s and %.1fx over %s",
+		a.Name, a.Resource, a.Node, a.ShortBurnRate, ShortWindowName, a.LongBurnRate, LongWindowName)
+}
+
+// subject is what the alert is about, e.g. "node a" or "namespace b".
+func (a Alert) subject() string {
+	if a.Namespace != "" {
+		return "namespace " + a.Namespace
+	}
+	return "node " + a.Node
+}
+
+// entity is the node or namespace the alert is about.
+func (a Alert) entity() string {
+	if a.Namespace != "" {
+		return a.Namespace
+	}
+	return a.Node
+}
+
+// PagerDutySeverity maps an alert rule's severity to a PagerDuty Events v2
+// severity: critical, warning and info are kept, anything else is error.
+func PagerDutySeverity(severity string) string {
+	switch severity {
+	case "critical", "warning", "info":
+		return severity
+	}
+	return "error"
+}
+
+// OpsgeniePriority maps an alert rule's severity to an Opsgenie priority:
+// critical is P1, warning P3, info P5 and anything else P2.
+func OpsgeniePriority(severity string) string {
+	switch severity {
+	case "critical":
+		return "P1"
+	case "warning":
+		return "P3"
+	case "info":
+		return "P5"
+	}
+	return "P2"
+}
+
+// details are the fields of an alert every integration attaches.
+func (a Alert) details() map[string]any {
+	if a.Namespace != "" {
+		return map[string]any{
+			"namespace": a.Namespace,
+			"resource":  a.Resource,
+			"threshold": a.Threshold,
+			"value":     a.Value,
+		}
+	}
+	return map[string]any{
+		"node":          a.Node,
+		"resource":      a.Resource,
+		"threshold":     a.Threshold,
+		"target":        a.Target,
+		"shortBurnRate": a.ShortBurnRate,
+		"longBurnRate":  a.LongBurnRate,
+	}
+}
+
+// Teams posts alerts as an Adaptive Card to a Microsoft Teams incoming
+// webhook or Workflows URL, one card per state change batch.
+type Teams struct {
+	URL    string
+	Client *http.Client // tlspolicy.Default.HTTPClient() when nil
+}
+
+// Notify sends alerts as one card.
+func (t *Teams) Notify(ctx context.Context, alerts []Alert) error {
+	body := []map[string]any{{
+		"type": "TextBlock", "size": "Medium", "weight": "Bolder",
+		"text": fmt.Sprintf("%d alert(s) changed state", len(alerts)),
+	}}
+	for _, a := range alerts {
+		color := "Good"
+		if a.Firing {
+			color = "Warning"
+			if a.Severity == "critical" {
+				color = "Attention"
+			}
+		}
+		body = append(body, map[string]any{
+			"type": "TextBlock", "wrap": true, "color": color,
+			"text": fmt.Sprintf("**[%s]** %s", strings.ToUpper(a.Severity), a.Summary()),
+		})
+	}
+	card := map[string]any{
+		"type": "message",
+		"attachments": []map[string]any{{
+			"contentType": "application/vnd.microsoft.card.adaptive",
+			"content": map[string]any{
+				"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+				"type":    "AdaptiveCard",
+				"version": "1.4",
+				"body":    body,
+			},
+		}},
+	}
+	payload, err := json.Marshal(card)
+	if err != nil {
+		return err
+	}
+	return postJSON(ctx, t.Client, t.URL, nil, payload)
+}
+
+// Check reports whether the Teams URL answers; see Reachable.
+func (t *Teams) Check(ctx context.Context) error {
+	return Reachable(ctx, t.Client, t.URL)
+}
+
+// PagerDuty sends alerts as PagerDuty Events v2: a trigger when an alert
+// fires and a resolve when it resolves, deduplicated on its fingerprint so
+// every replica's event lands on the same incident.
+type PagerDuty struct {
+	RoutingKey string
+	URL        string       // PagerDutyEventsURL when empty
+	Client     *http.Client // tlspolicy.Default.HTTPClient() when nil
+}
+
+// Notify sends one event per alert and returns the errors of those that
+// failed.
+func (p *PagerDuty) Notify(ctx context.Context, alerts []Alert) error {
+	u := p.URL
+	if u == "" {
+		u = PagerDutyEventsURL
+	}
+	var errs []error
+	for _, a := range alerts {
+		event := map[string]any{
+			"routing_key":  p.RoutingKey,
+			"event_action": "trigger",
+			"dedup_key":    a.Fingerprint(),
+		}
+		if a.Firing {
+			event["payload"] = map[string]any{
+				"summary":        a.Summary(),
+				"source":         a.entity(),
+				"severity":       PagerDutySeverity(a.Severity),
+				"component":      a.Resource,
+				"class":          a.Name,
+				"timestamp":      a.StartsAt.UTC(),
+				"custom_details": a.details(),
+			}
+		} else {
+			event["event_action"] = "resolve"
+		}
+		body, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		if err := postJSON(ctx, p.Client, u, nil, body); err != nil {
+			errs = append(errs, fmt.Errorf("%s %s: %w", a.Name, a.entity(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Check reports whether the Events API answers; see Reachable.
+func (p *PagerDuty) Check(ctx context.Context) error {
+	u := p.URL
+	if u == "" {
+		u = PagerDutyEventsURL
+	}
+	return Reachable(ctx, p.Client, u)
+}
+
+// Opsgenie creates an Opsgenie alert when an alert fires and closes it when
+// it resolves, using the fingerprint as the Opsgenie alias.
+type Opsgenie struct {
+	APIKey string
+	URL    string       // OpsgenieURL when empty
+	Client *http.Client // tlspolicy.Default.HTTPClient() when nil
+}
+
+// Notify sends one request per alert and returns the errors of those that
+// failed.
+func (o *Opsgenie) Notify(ctx context.Context, alerts []Alert) error {
+	base := strings.TrimSuffix(o.URL, "/")
+	if base == "" {
+		base = OpsgenieURL
+	}
+	header := http.Header{"Authorization": {"GenieKey " + o.APIKey}}
+	var errs []error
+	for _, a := range alerts {
+		u := base + "/v2/alerts"
+		req := map[string]any{"source": "k8s-ai-exporter"}
+		if a.Firing {
+			req["message"] = a.Summary()
+			req["alias"] = a.Fingerprint()
+			req["priority"] = OpsgeniePriority(a.Severity)
+			req["entity"] = a.entity()
+			req["tags"] = []string{a.Name, a.Resource}
+			details := make(map[string]string)
+			for k, v := range a.details() {
+				details[k] = fmt.Sprint(v)
+			}
+			req["details"] = details
+		} else {
+			u += "/" + url.PathEscape(a.Fingerprint()) + "/close?identifierType=alias"
+		}
+		body, err := json.Marshal(req)
+		if err != nil {
+			return err
+		}
+		if err := postJSON(ctx, o.Client, u, header, body); err != nil {
+			errs = append(errs, fmt.Errorf("%s %s: %w", a.Name, a.entity(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Check reports whether the Opsgenie API answers; see Reachable.
+func (o *Opsgenie) Check(ctx context.Context) error {
+	base := strings.TrimSuffix(o.URL, "/")
+	if base == "" {
+		base = OpsgenieURL
+	}
+	return Reachable(ctx, o.Client, base+"/v2/alerts")
+}
@@ -0,0 +1,119 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type request struct {
+	path, auth string
+	raw        []byte
+	body       map[string]any // raw, when it is an object
+}
+
+func recordRequests(t *testing.T, status int) (*httptest.Server, *[]request) {
+	var reqs []request
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		req := request{path: r.URL.RequestURI(), auth: r.Header.Get("Authorization"), raw: body}
+		if err := json.Unmarshal(body, &req.body); err != nil && body[0] == '{' {
+			t.Errorf("body %s: %v", body, err)
+		}
+		reqs = append(reqs, req)
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &reqs
+}
+
+var testAlerts = []Alert{
+	{Name: SaturationAlert, Node: "node-a", Resource: "cpu", Severity: "critical", Firing: true, StartsAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC), ShortBurnRate: 20, LongBurnRate: 15},
+	{Name: SaturationAlert, Node: "node-b", Resource: "memory", Severity: "warning"},
+}
+
+func TestPagerDuty(t *testing.T) {
+	srv, reqs := recordRequests(t, http.StatusAccepted)
+	p := &PagerDuty{RoutingKey: "rk", URL: srv.URL, Client: srv.Client()}
+	if err := p.Notify(context.Background(), testAlerts); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	if len(*reqs) != 2 {
+		t.Fatalf("sent %d events, want 2", len(*reqs))
+	}
+	trigger, resolve := (*reqs)[0].body, (*reqs)[1].body
+	payload, _ := trigger["payload"].(map[string]any)
+	if trigger["event_action"] != "trigger" || trigger["routing_key"] != "rk" || trigger["dedup_key"] != testAlerts[0].Fingerprint() ||
+		payload["severity"] != "critical" || payload["source"] != "node-a" || !strings.Contains(payload["summary"].(string), "20.0x over 5m") {
+		t.Errorf("trigger = %v", trigger)
+	}
+	if resolve["event_action"] != "resolve" || resolve["dedup_key"] != testAlerts[1].Fingerprint() || resolve["payload"] != nil {
+		t.Errorf("resolve = %v", resolve)
+	}
+}
+
+func TestOpsgenie(t *testing.T) {
+	srv, reqs := recordRequests(t, http.StatusAccepted)
+	o := &Opsgenie{APIKey: "key", URL: srv.URL + "/", Client: srv.Client()}
+	if err := o.Notify(context.Background(), testAlerts); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	if len(*reqs) != 2 {
+		t.Fatalf("sent %d requests, want 2", len(*reqs))
+	}
+	create, resolve := (*reqs)[0], (*reqs)[1]
+	if create.path != "/v2/alerts" || create.auth != "GenieKey key" || create.body["priority"] != "P1" || create.body["alias"] != testAlerts[0].Fingerprint() {
+		t.Errorf("create = %+v", create)
+	}
+	if want := "/v2/alerts/" + testAlerts[1].Fingerprint() + "/close?identifierType=alias"; resolve.path != want {
+		t.Errorf("close path = %s, want %s", resolve.path, want)
+	}
+}
+
+func TestTeams(t *testing.T) {
+	srv, reqs := recordRequests(t, http.StatusOK)
+	tm := &Teams{URL: srv.URL, Client: srv.Client()}
+	if err := tm.Notify(context.Background(), testAlerts); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	card, _ := json.Marshal((*reqs)[0].body)
+	for _, want := range []string{"application/vnd.microsoft.card.adaptive", `"color":"Attention"`, "node-a", "resolved: memory of node node-b"} {
+		if !strings.Contains(string(card), want) {
+			t.Errorf("card %s lacks %s", card, want)
+		}
+	}
+}
+
+func TestIntegrationErrors(t *testing.T) {
+	srv, _ := recordRequests(t, http.StatusBadRequest)
+	for _, n := range []Notifier{
+		&Teams{URL: srv.URL, Client: srv.Client()},
+		&PagerDuty{URL: srv.URL, Client: srv.Client()},
+		&Opsgenie{URL: srv.URL, Client: srv.Client()},
+	} {
+		if err := n.Notify(context.Background(), testAlerts); err == nil {
+			t.Errorf("%T.Notify succeeded on a 400", n)
+		}
+	}
+}
+
+func TestSeverityMapping(t *testing.T) {
+	for _, c := range []struct{ severity, pd, og string }{
+		{"critical", "critical", "P1"},
+		{"warning", "warning", "P3"},
+		{"info", "info", "P5"},
+		{"page", "error", "P2"},
+	} {
+		if got := PagerDutySeverity(c.severity); got != c.pd {
+			t.Errorf("PagerDutySeverity(%s) = %s, want %s", c.severity, got, c.pd)
+		}
+		if got := OpsgeniePriority(c.severity); got != c.og {
+			t.Errorf("OpsgeniePriority(%s) = %s, want %s", c.severity, got, c.og)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package alert
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// NamespaceBudgetAlert is the name of the alerts NamespaceBudgets raises.
+const NamespaceBudgetAlert = "NamespaceBudgetExceeded"
+
+// BudgetUse is where a namespace stands against its budget for one
+// resource: "cpu" in cores, "memory" in bytes or "cost" per month.
+type BudgetUse struct {
+	Namespace string
+	Resource  string
+	Limit     float64
+	Usage     float64
+	Requests  float64
+}
+
+// Exceeded reports whether usage or requests are above the limit.
+func (u BudgetUse) Exceeded() bool {
+	return u.Usage > u.Limit || u.Requests > u.Limit
+}
+
+// NamespaceBudgets raises an alert while a namespace exceeds its budget
+// for a resource and resolves it when the namespace is back within, or its
+// budget is gone. Unlike saturation there is no burn rate: budgets are
+// soft caps set by hand, and crossing one is the event. It is safe for
+// concurrent use.
+type NamespaceBudgets struct {
+	severity string
+
+	mu     sync.Mutex
+	firing map[[2]string]Alert // by namespace and resource
+}
+
+// NewNamespaceBudgets returns an evaluator that raises alerts of severity.
+func NewNamespaceBudgets(severity string) *NamespaceBudgets {
+	return &NamespaceBudgets{severity: severity, firing: make(map[[2]string]Alert)}
+}
+
+// Observe evaluates uses, every budget there is, at now and returns the
+// alerts that started or resolved.
+func (b *NamespaceBudgets) Observe(uses []BudgetUse, now time.Time) []Alert {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var changes []Alert
+	seen := make(map[[2]string]bool, len(uses))
+	for _, u := range uses {
+		k := [2]string{u.Namespace, u.Resource}
+		seen[k] = true
+		a, wasFiring := b.firing[k]
+		switch {
+		case u.Exceeded():
+			starts := now
+			if wasFiring {
+				starts = a.StartsAt
+			}
+			b.firing[k] = b.alert(u, starts, time.Time{})
+			if !wasFiring {
+				changes = append(changes, b.firing[k])
+			}
+		case wasFiring:
+			delete(b.firing, k)
+			changes = append(changes, b.alert(u, a.StartsAt, now))
+		}
+	}
+	for k, a := range b.firing {
+		if !seen[k] {
+			delete(b.firing, k)
+			a.Firing, a.EndsAt = false, now
+			changes = append(changes, a)
+		}
+	}
+	sortBudgetAlerts(changes)
+	return changes
+}
+
+// Firing returns the alerts firing as of the last Observe, sorted by
+// namespace and resource. A nil receiver returns nothing.
+func (b *NamespaceBudgets) Firing() []Alert {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	alerts := make([]Alert, 0, len(b.firing))
+	for _, a := range b.firing {
+		alerts = append(alerts, a)
+	}
+	sortBudgetAlerts(alerts)
+	return alerts
+}
+
+func (b *NamespaceBudgets) alert(u BudgetUse, starts, ends time.Time) Alert {
+	return Alert{
+		Name:      NamespaceBudgetAlert,
+		Namespace: u.Namespace,
+		Resource:  u.Resource,
+		Severity:  b.severity,
+		Firing:    ends.IsZero(),
+		StartsAt:  starts,
+		EndsAt:    ends,
+		Threshold: u.Limit,
+		Value:     max(u.Usage, u.Requests),
+	}
+}
+
+func sortBudgetAlerts(alerts []Alert) {
+	sort.Slice(alerts, func(i, j int) bool {
+		if alerts[i].Namespace != alerts[j].Namespace {
+			return alerts[i].Namespace < alerts[j].Namespace
+		}
+		return alerts[i].Resource < alerts[j].Resource
+	})
+}
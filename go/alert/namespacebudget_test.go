@@ -0,0 +1,47 @@
+package alert
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNamespaceBudgets(t *testing.T) {
+	b := NewNamespaceBudgets("warning")
+	now := time.Unix(1700000000, 0)
+	cpu := BudgetUse{Namespace: "team-a", Resource: "cpu", Limit: 8, Usage: 6, Requests: 9}
+	mem := BudgetUse{Namespace: "team-a", Resource: "memory", Limit: 1 << 30, Usage: 1 << 29, Requests: 1 << 29}
+
+	changes := b.Observe([]BudgetUse{cpu, mem}, now)
+	if len(changes) != 1 || !changes[0].Firing || changes[0].Resource != "cpu" || changes[0].Value != 9 || changes[0].Threshold != 8 {
+		t.Fatalf("requests over the cap: changes %+v, want cpu firing at 9 of 8", changes)
+	}
+	if changes[0].Summary() != "NamespaceBudgetExceeded firing: cpu of namespace team-a at 9 over its budget of 8" {
+		t.Errorf("Summary = %q", changes[0].Summary())
+	}
+
+	// Still exceeded: no change, but still firing from the first time.
+	later := now.Add(time.Minute)
+	if changes := b.Observe([]BudgetUse{cpu, mem}, later); len(changes) != 0 {
+		t.Errorf("unchanged budget: changes %+v", changes)
+	}
+	if f := b.Firing(); len(f) != 1 || !f[0].StartsAt.Equal(now) {
+		t.Errorf("Firing = %+v, want cpu since the first observation", f)
+	}
+
+	// Back within: resolved.
+	cpu.Requests = 7
+	changes = b.Observe([]BudgetUse{cpu, mem}, later)
+	if len(changes) != 1 || changes[0].Firing || !changes[0].EndsAt.Equal(later) {
+		t.Fatalf("within the cap again: changes %+v, want cpu resolved", changes)
+	}
+
+	// A deleted budget resolves its alert.
+	mem.Usage = 2 << 30
+	b.Observe([]BudgetUse{mem}, later)
+	if changes := b.Observe(nil, later.Add(time.Minute)); len(changes) != 1 || changes[0].Firing || changes[0].Resource != "memory" {
+		t.Errorf("budget deleted: changes %+v, want memory resolved", changes)
+	}
+	if f := b.Firing(); len(f) != 0 {
+		t.Errorf("Firing after every budget is gone = %+v", f)
+	}
+}
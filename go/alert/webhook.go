@@ -0,0 +1,182 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/your-org/k8s-ai-exporter/tlspolicy"
+)
+
+// Signature headers, shared with the agent's report webhook.
+const (
+	TimestampHeader = "X-Binbots-Timestamp"
+	SignatureHeader = "X-Binbots-Signature"
+)
+
+// Notifier delivers alert state changes.
+type Notifier interface {
+	Notify(ctx context.Context, alerts []Alert) error
+}
+
+// Webhook POSTs alerts as JSON to URL, signed with Secret when it is set.
+type Webhook struct {
+	URL    string
+	Secret string
+	Client *http.Client // tlspolicy.Default.HTTPClient() when nil
+}
+
+// webhookAlert is the JSON form of an Alert.
+type webhookAlert struct {
+	Fingerprint   string     `json:"fingerprint"`
+	Name          string     `json:"name"`
+	Status        string     `json:"status"` // firing or resolved
+	Node          string     `json:"node"`
+	Namespace     string     `json:"namespace,omitempty"`
+	Resource      string     `json:"resource"`
+	Severity      string     `json:"severity"`
+	StartsAt      time.Time  `json:"startsAt"`
+	EndsAt        *time.Time `json:"endsAt,omitempty"`
+	Threshold     float64    `json:"threshold"`
+	Target        float64    `json:"target"`
+	ShortBurnRate float64    `json:"shortBurnRate"`
+	LongBurnRate  float64    `json:"longBurnRate"`
+	Value         float64    `json:"value,omitempty"`
+}
+
+// Status is "firing" or "resolved".
+func (a Alert) Status() string {
+	if a.Firing {
+		return "firing"
+	}
+	return "resolved"
+}
+
+// Fingerprint identifies the alert independently of which exporter
+// replica raised it and when: the first 16 hex digits of the SHA-256 of
+// its name, node or namespace, and resource. Every replica evaluates
+// every node, so receivers see one notification per replica and should
+// deduplicate on it, as they would on an Alertmanager fingerprint.
+func (a Alert) Fingerprint() string {
+	sum := sha256.Sum256([]byte(a.Name + "\x00" + a.entity() + "\x00" + a.Resource))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Notify sends alerts in one request and fails unless the receiver answers
+// with a 2xx status.
+func (w *Webhook) Notify(ctx context.Context, alerts []Alert) error {
+	payload := struct {
+		Alerts []webhookAlert `json:"alerts"`
+	}{Alerts: make([]webhookAlert, len(alerts))}
+	for i, a := range alerts {
+		wa := webhookAlert{
+			Fingerprint:   a.Fingerprint(),
+			Name:          a.Name,
+			Status:        a.Status(),
+			Node:          a.Node,
+			Namespace:     a.Namespace,
+			Resource:      a.Resource,
+			Severity:      a.Severity,
+			StartsAt:      a.StartsAt.UTC(),
+			Threshold:     a.Threshold,
+			Target:        a.Target,
+			ShortBurnRate: a.ShortBurnRate,
+			LongBurnRate:  a.LongBurnRate,
+			Value:         a.Value,
+		}
+		if !a.Firing {
+			ends := a.EndsAt.UTC()
+			wa.EndsAt = &ends
+		}
+		payload.Alerts[i] = wa
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	header := make(http.Header)
+	if w.Secret != "" {
+		ts := time.Now().Unix()
+		header.Set(TimestampHeader, strconv.FormatInt(ts, 10))
+		header.Set(SignatureHeader, Sign(w.Secret, ts, body))
+	}
+	return postJSON(ctx, w.Client, w.URL, header, body)
+}
+
+// postJSON POSTs the JSON body with header to url and fails unless the
+// receiver answers with a 2xx status. A nil client is
+// tlspolicy.Default.HTTPClient().
+func postJSON(ctx context.Context, client *http.Client, url string, header http.Header, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if client == nil {
+		client = tlspolicy.Default.HTTPClient()
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("POST %s: %s", url, resp.Status)
+	}
+	return nil
+}
+
+// Sign returns the signature header value of body sent at Unix time ts:
+// "sha256=" and the hex HMAC-SHA256 of "<ts>.<body>" keyed with secret.
+func Sign(secret string, ts int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", ts)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Checker is implemented by notifiers that can tell whether their receiver
+// is reachable without sending it an alert.
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// Check reports whether the webhook URL answers; see Reachable.
+func (w *Webhook) Check(ctx context.Context) error {
+	return Reachable(ctx, w.Client, w.URL)
+}
+
+// Reachable sends a HEAD request to url and fails on transport errors and
+// 5xx statuses. Receivers that only accept POST answer 4xx, e.g. 405,
+// which still proves DNS, the network path and TLS work. A nil client is
+// tlspolicy.Default.HTTPClient().
+func Reachable(ctx context.Context, client *http.Client, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return err
+	}
+	if client == nil {
+		client = tlspolicy.Default.HTTPClient()
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 500 {
+		return fmt.Errorf("HEAD %s: %s", url, resp.Status)
+	}
+	return nil
+}
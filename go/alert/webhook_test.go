@@ -0,0 +1,117 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// Cross-checked with sign_payload in python/ai_agent.py.
+func TestSign(t *testing.T) {
+	got := Sign("s3cret", 1700000000, []byte(`{"alerts":[]}`))
+	if want := "sha256=7a2d8306d3a05552e5f067dd2452742d93d8a8e9dfa3d57e894f97b69a2da8b1"; got != want {
+		t.Errorf("Sign = %s, want %s", got, want)
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	var body []byte
+	var header http.Header
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		header = r.Header
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	alerts := []Alert{
+		{Name: SaturationAlert, Node: "node-a", Resource: "cpu", Severity: "critical", Firing: true, StartsAt: start, ShortBurnRate: 20, LongBurnRate: 15},
+		{Name: SaturationAlert, Node: "node-b", Resource: "memory", Severity: "critical", StartsAt: start, EndsAt: start.Add(time.Hour)},
+	}
+	w := &Webhook{URL: srv.URL, Secret: "s3cret", Client: srv.Client()}
+	if err := w.Notify(context.Background(), alerts); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+
+	ts, err := strconv.ParseInt(header.Get(TimestampHeader), 10, 64)
+	if err != nil {
+		t.Fatalf("timestamp header: %v", err)
+	}
+	if got, want := header.Get(SignatureHeader), Sign("s3cret", ts, body); got != want {
+		t.Errorf("signature = %s, want %s", got, want)
+	}
+	var got struct {
+		Alerts []map[string]any `json:"alerts"`
+	}
+	if err := json.Unmarshal(body, &got); err != nil {
+		t.Fatalf("body %s: %v", body, err)
+	}
+	if len(got.Alerts) != 2 {
+		t.Fatalf("alerts = %v", got.Alerts)
+	}
+	if a := got.Alerts[0]; a["status"] != "firing" || a["node"] != "node-a" || a["endsAt"] != nil || a["shortBurnRate"] != 20.0 || a["fingerprint"] != alerts[0].Fingerprint() {
+		t.Errorf("firing alert = %v", a)
+	}
+	if a := got.Alerts[1]; a["status"] != "resolved" || a["endsAt"] != "2024-05-01T13:00:00Z" {
+		t.Errorf("resolved alert = %v", a)
+	}
+
+	status = http.StatusBadGateway
+	w.Secret = ""
+	if err := w.Notify(context.Background(), alerts); err == nil {
+		t.Error("Notify succeeded on a 502")
+	}
+	if header.Get(SignatureHeader) != "" {
+		t.Error("unsigned webhook sent a signature")
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	a := Alert{Name: SaturationAlert, Node: "node-a", Resource: "cpu", Firing: true, StartsAt: time.Unix(1, 0)}
+	// Another replica raises the same alert a few seconds later.
+	b := a
+	b.StartsAt = time.Unix(7, 0)
+	if a.Fingerprint() != b.Fingerprint() || len(a.Fingerprint()) != 16 {
+		t.Errorf("Fingerprint = %s and %s, want the same 16 digits", a.Fingerprint(), b.Fingerprint())
+	}
+	b.Resource = "memory"
+	if a.Fingerprint() == b.Fingerprint() {
+		t.Error("cpu and memory alerts share a fingerprint")
+	}
+	ns := Alert{Name: NamespaceBudgetAlert, Namespace: "team-a", Resource: "cpu"}
+	other := ns
+	other.Namespace = "team-b"
+	if ns.Fingerprint() == other.Fingerprint() {
+		t.Error("budget alerts of two namespaces share a fingerprint")
+	}
+}
+
+func TestReachable(t *testing.T) {
+	status := http.StatusMethodNotAllowed
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodHead {
+			t.Errorf("method = %s, want HEAD", r.Method)
+		}
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+	w := &Webhook{URL: srv.URL, Client: srv.Client()}
+	if err := w.Check(context.Background()); err != nil {
+		t.Errorf("Check on a 405: %v", err)
+	}
+	status = http.StatusBadGateway
+	if err := w.Check(context.Background()); err == nil {
+		t.Error("Check succeeded on a 502")
+	}
+	srv.Close()
+	if err := w.Check(context.Background()); err == nil {
+		t.Error("Check succeeded on a closed server")
+	}
+}
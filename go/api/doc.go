@@ -0,0 +1,5 @@
+// Package api serves the exporter's versioned JSON API under /api/v1 and
+// its OpenAPI document at /api/openapi.json. The document is generated from
+// the route table and response types in this package, so it cannot drift
+// from what the handlers return.
+package api
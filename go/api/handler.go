@@ -0,0 +1,462 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/your-org/k8s-ai-exporter/selfcheck"
+	"github.com/your-org/k8s-ai-exporter/timeline"
+)
+
+// Version is the API version served under /api/<Version>.
+const Version = "v1"
+
+// maxBodyBytes bounds request bodies.
+const maxBodyBytes = 64 << 10
+
+// Store holds the latest snapshot and, optionally, the snapshots of a
+// trailing window. It is safe for concurrent use.
+type Store struct {
+	mu        sync.RWMutex
+	snapshot  *Snapshot
+	retention time.Duration
+	history   []Snapshot // oldest first
+}
+
+// NewStore returns an empty store without history; the snapshot endpoint
+// answers 503 until the first Set.
+func NewStore() *Store {
+	return &Store{}
+}
+
+// NewStoreWithHistory returns an empty store that also keeps every
+// snapshot of the last retention for History.
+func NewStoreWithHistory(retention time.Duration) *Store {
+	return &Store{retention: retention}
+}
+
+// Set replaces the latest snapshot and drops history older than the
+// retention, measured from snap.
+func (s *Store) Set(snap Snapshot) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.snapshot = &snap
+	if s.retention <= 0 {
+		return
+	}
+	s.history = append(s.history, snap)
+	cutoff := snap.Timestamp.Add(-s.retention)
+	drop := sort.Search(len(s.history), func(i int) bool { return !s.history[i].Timestamp.Before(cutoff) })
+	if drop > 0 {
+		s.history = append(s.history[:0], s.history[drop:]...)
+	}
+}
+
+// History returns the retained snapshots at or after since, oldest first.
+func (s *Store) History(since time.Time) []Snapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	i := sort.Search(len(s.history), func(i int) bool { return !s.history[i].Timestamp.Before(since) })
+	return append([]Snapshot(nil), s.history[i:]...)
+}
+
+// UpdateNode replaces the usage of node name in the latest snapshot, e.g.
+// after an out-of-band scrape, and clears its stale flag. History keeps the
+// values of the cycle that recorded it. It returns the updated node, or
+// false when the latest snapshot does not have it.
+func (s *Store) UpdateNode(name string, cpu, mem float64) (NodeSnapshot, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.snapshot == nil {
+		return NodeSnapshot{}, false
+	}
+	for i, n := range s.snapshot.Nodes {
+		if n.Name != name {
+			continue
+		}
+		// The node slice is shared with the history.
+		nodes := append([]NodeSnapshot(nil), s.snapshot.Nodes...)
+		nodes[i].CPUCores, nodes[i].MemoryBytes, nodes[i].Stale = cpu, mem, false
+		s.snapshot.Nodes = nodes
+		return nodes[i], true
+	}
+	return NodeSnapshot{}, false
+}
+
+// Get returns the latest snapshot, if any.
+func (s *Store) Get() (Snapshot, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.snapshot == nil {
+		return Snapshot{}, false
+	}
+	return *s.snapshot, true
+}
+
+// Config wires the handler to the exporter's state.
+type Config struct {
+	Snapshots   *Store
+	Events      *timeline.Timeline
+	Maintenance *timeline.Schedule
+	SelfCheck   *selfcheck.Checker // nil answers 503
+	Scrape      ScrapeTrigger      // nil answers 503
+
+	// Token must be sent as a bearer token to create events and
+	// maintenance windows and to trigger scrapes. Without one those
+	// endpoints answer 403.
+	Token string
+}
+
+// ScrapeTrigger runs collection cycles, or the scrape of one node, out of
+// band.
+type ScrapeTrigger interface {
+	// TriggerScrape queues an immediate run of the named cycles, or of
+	// all cycles when names is empty, and returns the queued names. It
+	// fails for unknown names, and queues nothing but returns how long to
+	// wait when the previous request was too recent.
+	TriggerScrape(names []string) (queued []string, retryAfter time.Duration, err error)
+
+	// ScrapeNode scrapes one node now, leaving the cycles alone, and
+	// returns its refreshed usage. Errors wrap ErrUnknownNode for nodes
+	// that do not exist. Like TriggerScrape it returns how long to wait
+	// when the node was scraped on request too recently.
+	ScrapeNode(ctx context.Context, node string) (n NodeSnapshot, retryAfter time.Duration, err error)
+}
+
+// ErrUnknownNode is wrapped by ScrapeTrigger.ScrapeNode errors for nodes
+// that do not exist.
+var ErrUnknownNode = errors.New("unknown node")
+
+// param is a query parameter of a route.
+type param struct {
+	name, description string
+	typ               reflect.Type
+}
+
+// route is one endpoint. handle receives the response headers and the
+// decoded request body (a pointer to a new request value, or nil) and
+// returns the response body or an HTTP status and message. operationID
+// names the call in generated clients.
+type route struct {
+	method      string
+	path        string
+	operationID string
+	summary     string
+	query       []param
+	request     reflect.Type
+	response    reflect.Type
+	status      int
+	auth        bool
+	handle      func(header http.Header, r *http.Request, body interface{}) (interface{}, int, string)
+}
+
+// Handler serves /api/v1/* and /api/openapi.json.
+type Handler struct {
+	routes map[string]map[string]route // path -> method -> route
+	token  string
+	spec   []byte
+}
+
+func routes(cfg Config) []route {
+	return []route{
+		{
+			method:      http.MethodGet,
+			path:        "/api/" + Version + "/snapshot",
+			operationID: "getSnapshot",
+			summary:     "Per-node usage from the last completed collection cycle.",
+			response:    reflect.TypeOf(Snapshot{}),
+			handle: func(http.Header, *http.Request, interface{}) (interface{}, int, string) {
+				snap, ok := cfg.Snapshots.Get()
+				if !ok {
+					return nil, http.StatusServiceUnavailable, "no collection cycle has completed yet"
+				}
+				return snap, http.StatusOK, ""
+			},
+		},
+		{
+			method:      http.MethodGet,
+			path:        "/api/" + Version + "/history",
+			operationID: "getHistory",
+			summary:     "Snapshots of the retained history (-history-retention), oldest first.",
+			query: []param{
+				{name: "window", description: "Only snapshots of this trailing window, as a duration such as 30m or 6h; default all retained.", typ: reflect.TypeOf("")},
+			},
+			response: reflect.TypeOf(History{}),
+			handle: func(_ http.Header, r *http.Request, _ interface{}) (interface{}, int, string) {
+				since, ok := windowStart(r)
+				if !ok {
+					return nil, http.StatusBadRequest, "window must be a positive duration such as 6h"
+				}
+				return History{Snapshots: cfg.Snapshots.History(since)}, http.StatusOK, ""
+			},
+		},
+		{
+			method:      http.MethodGet,
+			path:        "/api/" + Version + "/heatmap",
+			operationID: "getHeatmap",
+			summary:     "Node utilization over the retained history as a node by time matrix.",
+			query: []param{
+				{name: "resource", description: "cpu (default) or memory.", typ: reflect.TypeOf("")},
+				{name: "window", description: "Only the trailing window, as a duration such as 30m or 6h; default all retained.", typ: reflect.TypeOf("")},
+			},
+			response: reflect.TypeOf(Heatmap{}),
+			handle: func(_ http.Header, r *http.Request, _ interface{}) (interface{}, int, string) {
+				resource := r.URL.Query().Get("resource")
+				if resource == "" {
+					resource = ResourceCPU
+				}
+				if resource != ResourceCPU && resource != ResourceMemory {
+					return nil, http.StatusBadRequest, "resource must be cpu or memory"
+				}
+				since, ok := windowStart(r)
+				if !ok {
+					return nil, http.StatusBadRequest, "window must be a positive duration such as 6h"
+				}
+				return NewHeatmap(resource, cfg.Snapshots.History(since)), http.StatusOK, ""
+			},
+		},
+		{
+			method:      http.MethodGet,
+			path:        "/api/" + Version + "/events",
+			operationID: "listEvents",
+			summary:     "Retained external events, oldest first.",
+			query: []param{
+				{name: "since", description: "Only events at or after this RFC 3339 time.", typ: reflect.TypeOf(time.Time{})},
+			},
+			response: reflect.TypeOf(EventList{}),
+			handle: func(_ http.Header, r *http.Request, _ interface{}) (interface{}, int, string) {
+				var since time.Time
+				if v := r.URL.Query().Get("since"); v != "" {
+					t, err := time.Parse(time.RFC3339, v)
+					if err != nil {
+						return nil, http.StatusBadRequest, "since must be an RFC 3339 time"
+					}
+					since = t
+				}
+				return EventList{Events: cfg.Events.Between(since, time.Time{})}, http.StatusOK, ""
+			},
+		},
+		{
+			method:      http.MethodPost,
+			path:        "/api/" + Version + "/events",
+			operationID: "createEvent",
+			summary:     "Record an external event such as a deploy marker or incident start/end.",
+			request:     reflect.TypeOf(timeline.Event{}),
+			response:    reflect.TypeOf(timeline.Event{}),
+			status:      http.StatusCreated,
+			auth:        true,
+			handle: func(_ http.Header, _ *http.Request, body interface{}) (interface{}, int, string) {
+				e, err := cfg.Events.Add(*body.(*timeline.Event), time.Now())
+				if err != nil {
+					return nil, http.StatusBadRequest, err.Error()
+				}
+				return e, http.StatusCreated, ""
+			},
+		},
+		{
+			method:      http.MethodGet,
+			path:        "/api/" + Version + "/maintenance",
+			operationID: "listMaintenanceWindows",
+			summary:     "Declared maintenance windows, ordered by start.",
+			response:    reflect.TypeOf(MaintenanceList{}),
+			handle: func(http.Header, *http.Request, interface{}) (interface{}, int, string) {
+				return MaintenanceList{Windows: cfg.Maintenance.All()}, http.StatusOK, ""
+			},
+		},
+		{
+			method:      http.MethodPost,
+			path:        "/api/" + Version + "/maintenance",
+			operationID: "createMaintenanceWindow",
+			summary:     "Declare a maintenance window during which alerts and forecasts are suppressed.",
+			request:     reflect.TypeOf(timeline.Window{}),
+			response:    reflect.TypeOf(timeline.Window{}),
+			status:      http.StatusCreated,
+			auth:        true,
+			handle: func(_ http.Header, _ *http.Request, body interface{}) (interface{}, int, string) {
+				w, err := cfg.Maintenance.Add(*body.(*timeline.Window))
+				if err != nil {
+					return nil, http.StatusBadRequest, err.Error()
+				}
+				return w, http.StatusCreated, ""
+			},
+		},
+		{
+			method:      http.MethodPost,
+			path:        "/api/" + Version + "/scrape",
+			operationID: "triggerScrape",
+			summary:     "Run collection cycles now instead of at their next interval, or scrape a single node; rate-limited by -scrape-trigger-min-interval.",
+			query: []param{
+				{name: "cycle", description: "Comma-separated cycles to run (pods, main, detail); default all.", typ: reflect.TypeOf("")},
+				{name: "node", description: "Scrape only this node, before responding, without touching the cycles; excludes cycle.", typ: reflect.TypeOf("")},
+			},
+			response: reflect.TypeOf(ScrapeResponse{}),
+			status:   http.StatusAccepted,
+			auth:     true,
+			handle: func(header http.Header, r *http.Request, _ interface{}) (interface{}, int, string) {
+				if cfg.Scrape == nil {
+					return nil, http.StatusServiceUnavailable, "out-of-band scrapes are disabled"
+				}
+				if node := r.URL.Query().Get("node"); node != "" {
+					if r.URL.Query().Has("cycle") {
+						return nil, http.StatusBadRequest, "node and cycle cannot be combined"
+					}
+					n, retryAfter, err := cfg.Scrape.ScrapeNode(r.Context(), node)
+					switch {
+					case errors.Is(err, ErrUnknownNode):
+						return nil, http.StatusNotFound, err.Error()
+					case err != nil:
+						return nil, http.StatusBadGateway, err.Error()
+					case retryAfter > 0:
+						return nil, http.StatusTooManyRequests, retryMessage(header, "node "+node, retryAfter)
+					}
+					return ScrapeResponse{Node: &n}, http.StatusAccepted, ""
+				}
+				var names []string
+				if v := r.URL.Query().Get("cycle"); v != "" {
+					names = strings.Split(v, ",")
+				}
+				queued, retryAfter, err := cfg.Scrape.TriggerScrape(names)
+				if err != nil {
+					return nil, http.StatusBadRequest, err.Error()
+				}
+				if retryAfter > 0 {
+					return nil, http.StatusTooManyRequests, retryMessage(header, "a scrape", retryAfter)
+				}
+				return ScrapeResponse{Cycles: queued}, http.StatusAccepted, ""
+			},
+		},
+		{
+			method:      http.MethodGet,
+			path:        "/api/" + Version + "/selfcheck",
+			operationID: "getSelfCheck",
+			summary:     "Privileges the exporter runs with, writable paths and egress reachability, checked on request.",
+			response:    reflect.TypeOf(selfcheck.Report{}),
+			handle: func(_ http.Header, r *http.Request, _ interface{}) (interface{}, int, string) {
+				if cfg.SelfCheck == nil {
+					return nil, http.StatusServiceUnavailable, "self-check is not configured"
+				}
+				return cfg.SelfCheck.Run(r.Context()), http.StatusOK, ""
+			},
+		},
+	}
+}
+
+// NewHandler returns the API handler. Mount it at /api/.
+func NewHandler(cfg Config) *Handler {
+	rts := routes(cfg)
+	h := &Handler{routes: make(map[string]map[string]route), token: cfg.Token}
+	for _, rt := range rts {
+		if rt.status == 0 {
+			rt.status = http.StatusOK
+		}
+		if h.routes[rt.path] == nil {
+			h.routes[rt.path] = make(map[string]route)
+		}
+		h.routes[rt.path][rt.method] = rt
+	}
+	spec, err := json.Marshal(openAPIDocument(rts))
+	if err != nil {
+		panic("api: cannot encode OpenAPI document: " + err.Error())
+	}
+	h.spec = spec
+	return h
+}
+
+// OpenAPI returns the OpenAPI document served at /api/openapi.json.
+func OpenAPI() []byte {
+	return NewHandler(Config{}).spec
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/api/openapi.json" && r.Method == http.MethodGet {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(h.spec)
+		return
+	}
+	methods, ok := h.routes[r.URL.Path]
+	if !ok {
+		writeJSON(w, http.StatusNotFound, Error{Error: "not found"})
+		return
+	}
+	rt, ok := methods[r.Method]
+	if !ok {
+		allowed := make([]string, 0, len(methods))
+		for m := range methods {
+			allowed = append(allowed, m)
+		}
+		sort.Strings(allowed)
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		writeJSON(w, http.StatusMethodNotAllowed, Error{Error: "method not allowed"})
+		return
+	}
+	if rt.auth && h.token == "" {
+		writeJSON(w, http.StatusForbidden, Error{Error: "write endpoints are disabled: no API token is configured"})
+		return
+	}
+	if rt.auth && !validBearer(r, h.token) {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		writeJSON(w, http.StatusUnauthorized, Error{Error: "missing or invalid bearer token"})
+		return
+	}
+
+	var body interface{}
+	if rt.request != nil {
+		body = reflect.New(rt.request).Interface()
+		dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(body); err != nil {
+			writeJSON(w, http.StatusBadRequest, Error{Error: "invalid request body: " + err.Error()})
+			return
+		}
+	}
+	resp, status, msg := rt.handle(w.Header(), r, body)
+	if status != rt.status {
+		writeJSON(w, status, Error{Error: msg})
+		return
+	}
+	writeJSON(w, status, resp)
+}
+
+// windowStart returns the start of the trailing window query parameter,
+// zero without one, and false when it is not a positive duration.
+func windowStart(r *http.Request) (time.Time, bool) {
+	v := r.URL.Query().Get("window")
+	if v == "" {
+		return time.Time{}, true
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		return time.Time{}, false
+	}
+	return time.Now().Add(-d), true
+}
+
+func validBearer(r *http.Request, token string) bool {
+	got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// retryMessage tells a rate-limited client when what was triggered
+// recently can be requested again, in whole seconds rounded up, both in
+// the Retry-After header and in the message it returns.
+func retryMessage(header http.Header, what string, retryAfter time.Duration) string {
+	secs := int((retryAfter + time.Second - 1) / time.Second)
+	header.Set("Retry-After", strconv.Itoa(secs))
+	return fmt.Sprintf("%s was triggered recently; retry in %ds", what, secs)
+}
@@ -0,0 +1,422 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/your-org/k8s-ai-exporter/selfcheck"
+	"github.com/your-org/k8s-ai-exporter/timeline"
+)
+
+func TestSnapshotEndpoint(t *testing.T) {
+	store := NewStore()
+	h := NewHandler(Config{Snapshots: store, Events: timeline.New(0), Maintenance: timeline.NewSchedule(0)})
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/snapshot", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("before first cycle: status = %d, want 503", rec.Code)
+	}
+
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	store.Set(NewSnapshot(at, map[string]float64{"b": 3}, map[string]float64{"a": 1.5, "b": 0.5}, map[string]float64{"a": 1024}))
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/snapshot", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	var got Snapshot
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	want := Snapshot{Timestamp: at, Nodes: []NodeSnapshot{
+		{Name: "a", CPUCores: 1.5, MemoryBytes: 1024},
+		{Name: "b", CPUCores: 0.5, ActivePods: 3},
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("snapshot = %+v, want %+v", got, want)
+	}
+}
+
+func TestStoreHistory(t *testing.T) {
+	store := NewStoreWithHistory(time.Hour)
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		store.Set(Snapshot{Timestamp: start.Add(time.Duration(i) * 30 * time.Minute)})
+	}
+	got := store.History(time.Time{})
+	if len(got) != 3 || !got[0].Timestamp.Equal(start.Add(time.Hour)) || !got[2].Timestamp.Equal(start.Add(2*time.Hour)) {
+		t.Errorf("History = %+v, want 13:00 to 14:00", got)
+	}
+	if got := store.History(start.Add(100 * time.Minute)); len(got) != 1 {
+		t.Errorf("History since 13:40 = %+v, want one snapshot", got)
+	}
+	if got := NewStore(); len(got.History(time.Time{})) != 0 {
+		t.Error("store without retention kept history")
+	}
+}
+
+func TestHistoryEndpoint(t *testing.T) {
+	store := NewStoreWithHistory(time.Hour)
+	now := time.Now()
+	store.Set(Snapshot{Timestamp: now.Add(-50 * time.Minute)})
+	store.Set(Snapshot{Timestamp: now.Add(-5 * time.Minute)})
+	h := NewHandler(Config{Snapshots: store, Events: timeline.New(0), Maintenance: timeline.NewSchedule(0)})
+
+	for path, want := range map[string]int{"/api/v1/history": 2, "/api/v1/history?window=30m": 1} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		var got History
+		if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+			t.Fatalf("GET %s: decode: %v", path, err)
+		}
+		if len(got.Snapshots) != want {
+			t.Errorf("GET %s = %d snapshots, want %d", path, len(got.Snapshots), want)
+		}
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/history?window=-1h", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("GET with negative window = %d, want 400", rec.Code)
+	}
+}
+
+func TestHandlerErrors(t *testing.T) {
+	h := NewHandler(Config{Snapshots: NewStore(), Events: timeline.New(0), Maintenance: timeline.NewSchedule(0)})
+	tests := []struct {
+		method, path string
+		want         int
+	}{
+		{http.MethodGet, "/api/v1/nope", http.StatusNotFound},
+		{http.MethodPost, "/api/v1/snapshot", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+		if rec.Code != tt.want {
+			t.Errorf("%s %s = %d, want %d", tt.method, tt.path, rec.Code, tt.want)
+		}
+		var e Error
+		if err := json.Unmarshal(rec.Body.Bytes(), &e); err != nil || e.Error == "" {
+			t.Errorf("%s %s: body %q is not an Error", tt.method, tt.path, rec.Body.String())
+		}
+	}
+}
+
+func TestEventsEndpoints(t *testing.T) {
+	h := NewHandler(Config{Snapshots: NewStore(), Events: timeline.New(10), Maintenance: timeline.NewSchedule(0), Token: "s3cret"})
+	post := func(body, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/events", strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	deploy := `{"type":"deploy","title":"checkout v42","source":"argocd","namespace":"shop","time":"2024-05-01T12:00:00Z"}`
+	if rec := post(deploy, ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("POST without token = %d, want 401", rec.Code)
+	}
+	if rec := post(deploy, "wrong"); rec.Code != http.StatusUnauthorized {
+		t.Errorf("POST with wrong token = %d, want 401", rec.Code)
+	}
+	for _, bad := range []string{`{"type":"deploy"}`, `{"type":"deploy","title":"x","color":"red"}`, `not json`} {
+		if rec := post(bad, "s3cret"); rec.Code != http.StatusBadRequest {
+			t.Errorf("POST %s = %d, want 400", bad, rec.Code)
+		}
+	}
+	rec := post(deploy, "s3cret")
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("POST = %d %s, want 201", rec.Code, rec.Body)
+	}
+	var created timeline.Event
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil || created.ID == 0 || created.Source != "argocd" {
+		t.Errorf("created = %+v, %v", created, err)
+	}
+	post(`{"type":"incident_start","title":"5xx spike","time":"2024-05-01T13:00:00Z"}`, "s3cret")
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/events?since=2024-05-01T12:30:00Z", nil))
+	var list EventList
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(list.Events) != 1 || list.Events[0].Type != timeline.TypeIncidentStart {
+		t.Errorf("events since 12:30 = %+v", list.Events)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/events?since=yesterday", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("GET with bad since = %d, want 400", rec.Code)
+	}
+}
+
+func TestWritesNeedToken(t *testing.T) {
+	h := NewHandler(Config{Snapshots: NewStore(), Events: timeline.New(10), Maintenance: timeline.NewSchedule(0)})
+	for _, target := range []string{"/api/v1/events", "/api/v1/maintenance", "/api/v1/scrape"} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, target, strings.NewReader(`{}`)))
+		if rec.Code != http.StatusForbidden {
+			t.Errorf("POST %s without a configured token = %d, want 403", target, rec.Code)
+		}
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/maintenance", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("GET without a configured token = %d, want 200", rec.Code)
+	}
+}
+
+func TestMaintenanceEndpoints(t *testing.T) {
+	h := NewHandler(Config{Snapshots: NewStore(), Events: timeline.New(0), Maintenance: timeline.NewSchedule(0), Token: "s3cret"})
+	for body, want := range map[string]int{
+		`{"start":"2024-05-01T02:00:00Z","end":"2024-05-01T04:00:00Z","reason":"chaos drill"}`: http.StatusCreated,
+		`{"start":"2024-05-01T04:00:00Z","end":"2024-05-01T02:00:00Z"}`:                        http.StatusBadRequest,
+		`{"start":"2024-05-01T02:00:00Z"}`:                                                     http.StatusBadRequest,
+	} {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/maintenance", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer s3cret")
+		h.ServeHTTP(rec, req)
+		if rec.Code != want {
+			t.Errorf("POST %s = %d %s, want %d", body, rec.Code, rec.Body, want)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/maintenance", nil))
+	var list MaintenanceList
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(list.Windows) != 1 || list.Windows[0].ID == 0 || list.Windows[0].Reason != "chaos drill" {
+		t.Errorf("windows = %+v", list.Windows)
+	}
+}
+
+func TestOpenAPIDocument(t *testing.T) {
+	rec := httptest.NewRecorder()
+	NewHandler(Config{Snapshots: NewStore(), Events: timeline.New(0), Maintenance: timeline.NewSchedule(0)}).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/openapi.json", nil))
+	var doc struct {
+		Paths      map[string]json.RawMessage `json:"paths"`
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]map[string]interface{} `json:"properties"`
+				Required   []string                          `json:"required"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if _, ok := doc.Paths["/api/v1/snapshot"]; !ok {
+		t.Errorf("paths = %v, want /api/v1/snapshot", doc.Paths)
+	}
+	node := doc.Components.Schemas["NodeSnapshot"]
+	if node.Properties["activePods"]["type"] != "integer" || node.Properties["cpuCores"]["type"] != "number" {
+		t.Errorf("NodeSnapshot properties = %v", node.Properties)
+	}
+	snap := doc.Components.Schemas["Snapshot"]
+	if snap.Properties["timestamp"]["format"] != "date-time" || len(snap.Required) != 2 {
+		t.Errorf("Snapshot schema = %+v", snap)
+	}
+}
+
+func TestNewNamespaceSnapshots(t *testing.T) {
+	got := NewNamespaceSnapshots(map[string]float64{"shop": 1.5, "batch": 0.25}, map[string]float64{"shop": 1024, "kube-system": 2048})
+	want := []NamespaceSnapshot{
+		{Name: "batch", CPUCores: 0.25},
+		{Name: "kube-system", MemoryBytes: 2048},
+		{Name: "shop", CPUCores: 1.5, MemoryBytes: 1024},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("NewNamespaceSnapshots = %+v, want %+v", got, want)
+	}
+}
+
+func TestNewHeatmap(t *testing.T) {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	snaps := []Snapshot{
+		{Timestamp: start, Nodes: []NodeSnapshot{{Name: "b", CPUCores: 1, CPUAllocatable: 4, MemoryBytes: 512, MemoryAllocatable: 1024}}},
+		{Timestamp: start.Add(time.Minute), Nodes: []NodeSnapshot{
+			{Name: "a", CPUCores: 1},
+			{Name: "b", CPUCores: 3, CPUAllocatable: 4},
+		}},
+	}
+	h := NewHeatmap(ResourceCPU, snaps)
+	if !reflect.DeepEqual(h.Nodes, []string{"a", "b"}) || len(h.Timestamps) != 2 || !h.Timestamps[1].Equal(start.Add(time.Minute)) {
+		t.Fatalf("heatmap axes = %v, %v", h.Nodes, h.Timestamps)
+	}
+	if h.Values[0][0] != nil || h.Values[0][1] != nil {
+		t.Errorf("row a = %v, want nulls (absent, then no allocatable)", h.Values[0])
+	}
+	if *h.Values[1][0] != 0.25 || *h.Values[1][1] != 0.75 {
+		t.Errorf("row b = %v, %v, want 0.25, 0.75", *h.Values[1][0], *h.Values[1][1])
+	}
+	if mem := NewHeatmap(ResourceMemory, snaps); *mem.Values[1][0] != 0.5 || mem.Values[1][1] != nil {
+		t.Errorf("memory row b = %v", mem.Values[1])
+	}
+}
+
+func TestHeatmapEndpoint(t *testing.T) {
+	store := NewStoreWithHistory(time.Hour)
+	store.Set(Snapshot{Timestamp: time.Now(), Nodes: []NodeSnapshot{{Name: "a", CPUCores: 1, CPUAllocatable: 2}}})
+	h := NewHandler(Config{Snapshots: store, Events: timeline.New(0), Maintenance: timeline.NewSchedule(0)})
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/heatmap?window=6h", nil))
+	if want := `"values":[[0.5]]`; rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), want) || !strings.Contains(rec.Body.String(), `"resource":"cpu"`) {
+		t.Errorf("GET heatmap = %d %s, want cpu with %s", rec.Code, rec.Body, want)
+	}
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/heatmap?resource=disk", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("GET heatmap for disk = %d, want 400", rec.Code)
+	}
+}
+
+func TestSelfCheckEndpoint(t *testing.T) {
+	get := func(h http.Handler) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/selfcheck", nil))
+		return rec
+	}
+	if rec := get(NewHandler(Config{Snapshots: NewStore(), Events: timeline.New(0), Maintenance: timeline.NewSchedule(0)})); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("without a checker = %d, want 503", rec.Code)
+	}
+
+	dir := t.TempDir()
+	rec := get(NewHandler(Config{Snapshots: NewStore(), Events: timeline.New(0), Maintenance: timeline.NewSchedule(0), SelfCheck: &selfcheck.Checker{Paths: []string{dir}}}))
+	var got selfcheck.Report
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if rec.Code != http.StatusOK || len(got.Filesystem) != 1 || !got.Filesystem[0].Writable {
+		t.Errorf("selfcheck = %d %+v, want %s writable", rec.Code, got, dir)
+	}
+}
+
+type fakeTrigger struct {
+	retryAfter time.Duration
+	got        []string
+}
+
+func (f *fakeTrigger) TriggerScrape(names []string) ([]string, time.Duration, error) {
+	for _, n := range names {
+		if n != "main" && n != "pods" {
+			return nil, 0, fmt.Errorf("unknown cycle %q", n)
+		}
+	}
+	if f.retryAfter > 0 {
+		return nil, f.retryAfter, nil
+	}
+	if len(names) == 0 {
+		names = []string{"pods", "main"}
+	}
+	f.got = names
+	return names, 0, nil
+}
+
+func (f *fakeTrigger) ScrapeNode(_ context.Context, node string) (NodeSnapshot, time.Duration, error) {
+	switch {
+	case node == "down":
+		return NodeSnapshot{}, 0, fmt.Errorf("cadvisor %s: status 502", node)
+	case node != "node-a":
+		return NodeSnapshot{}, 0, fmt.Errorf("%w %s", ErrUnknownNode, node)
+	case f.retryAfter > 0:
+		return NodeSnapshot{}, f.retryAfter, nil
+	}
+	return NodeSnapshot{Name: node, CPUCores: 1.5}, 0, nil
+}
+
+func TestScrapeEndpoint(t *testing.T) {
+	post := func(h http.Handler, target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, target, nil)
+		req.Header.Set("Authorization", "Bearer s3cret")
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+	cfg := Config{Snapshots: NewStore(), Events: timeline.New(0), Maintenance: timeline.NewSchedule(0), Token: "s3cret"}
+	if rec := post(NewHandler(cfg), "/api/v1/scrape"); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("without a trigger = %d, want 503", rec.Code)
+	}
+
+	trigger := &fakeTrigger{}
+	cfg.Scrape = trigger
+	h := NewHandler(cfg)
+	rec := post(h, "/api/v1/scrape")
+	var got ScrapeResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if rec.Code != http.StatusAccepted || len(got.Cycles) != 2 {
+		t.Errorf("scrape = %d %+v, want 202 with both cycles", rec.Code, got)
+	}
+	if rec := post(h, "/api/v1/scrape?cycle=main"); rec.Code != http.StatusAccepted || len(trigger.got) != 1 || trigger.got[0] != "main" {
+		t.Errorf("scrape of main = %d, queued %v", rec.Code, trigger.got)
+	}
+	if rec := post(h, "/api/v1/scrape?cycle=detail"); rec.Code != http.StatusBadRequest {
+		t.Errorf("unknown cycle = %d, want 400", rec.Code)
+	}
+
+	rec = post(h, "/api/v1/scrape?node=node-a")
+	got = ScrapeResponse{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if rec.Code != http.StatusAccepted || got.Node == nil || got.Node.CPUCores != 1.5 || len(got.Cycles) != 0 {
+		t.Errorf("scrape of node-a = %d %s, want 202 with the node", rec.Code, rec.Body)
+	}
+	for target, want := range map[string]int{
+		"/api/v1/scrape?node=nope":              http.StatusNotFound,
+		"/api/v1/scrape?node=down":              http.StatusBadGateway,
+		"/api/v1/scrape?node=node-a&cycle=main": http.StatusBadRequest,
+	} {
+		if rec := post(h, target); rec.Code != want {
+			t.Errorf("POST %s = %d, want %d", target, rec.Code, want)
+		}
+	}
+
+	trigger.retryAfter = 2500 * time.Millisecond
+	rec = post(h, "/api/v1/scrape")
+	if rec.Code != http.StatusTooManyRequests || !strings.Contains(rec.Body.String(), "retry in 3s") || rec.Header().Get("Retry-After") != "3" {
+		t.Errorf("rate-limited scrape = %d %s, Retry-After %q; want 429 retry in 3s", rec.Code, rec.Body, rec.Header().Get("Retry-After"))
+	}
+	if rec := post(h, "/api/v1/scrape?node=node-a"); rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "3" {
+		t.Errorf("rate-limited node scrape = %d, Retry-After %q; want 429 and 3", rec.Code, rec.Header().Get("Retry-After"))
+	}
+}
+
+func TestStoreUpdateNode(t *testing.T) {
+	s := NewStoreWithHistory(time.Hour)
+	if _, ok := s.UpdateNode("a", 1, 2); ok {
+		t.Error("UpdateNode on an empty store succeeded")
+	}
+	snap := NewSnapshot(time.Now(), map[string]float64{"a": 3}, map[string]float64{"a": 0.5}, map[string]float64{"a": 100})
+	snap.SetStale(map[string]bool{"a": true})
+	s.Set(snap)
+	n, ok := s.UpdateNode("a", 1, 200)
+	if !ok || n.CPUCores != 1 || n.MemoryBytes != 200 || n.ActivePods != 3 || n.Stale {
+		t.Errorf("UpdateNode(a) = %+v, %v", n, ok)
+	}
+	if latest, _ := s.Get(); latest.Nodes[0] != n {
+		t.Errorf("latest snapshot has %+v, want %+v", latest.Nodes[0], n)
+	}
+	if old := s.History(time.Time{}); old[0].Nodes[0].CPUCores != 0.5 {
+		t.Errorf("history was changed to %+v", old[0].Nodes[0])
+	}
+	if _, ok := s.UpdateNode("b", 1, 2); ok {
+		t.Error("UpdateNode(b) succeeded for a node not in the snapshot")
+	}
+}
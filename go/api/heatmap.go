@@ -0,0 +1,62 @@
+package api
+
+import (
+	"sort"
+	"time"
+)
+
+// Resources of a Heatmap.
+const (
+	ResourceCPU    = "cpu"
+	ResourceMemory = "memory"
+)
+
+// Heatmap is node utilization over time: one row per node and one column
+// per snapshot, so a window of history costs one request instead of a
+// range query per node.
+type Heatmap struct {
+	Resource   string       `json:"resource" doc:"cpu or memory."`
+	Timestamps []time.Time  `json:"timestamps" doc:"Column times, oldest first."`
+	Nodes      []string     `json:"nodes" doc:"Row names, sorted."`
+	Values     [][]*float64 `json:"values" doc:"Usage divided by allocatable, values[node][timestamp]; null where the node was absent or its allocatable unknown."`
+}
+
+// NewHeatmap builds the heatmap of resource from snapshots ordered oldest
+// first. Every node present in any snapshot gets a row.
+func NewHeatmap(resource string, snapshots []Snapshot) Heatmap {
+	rows := make(map[string][]*float64)
+	for col, snap := range snapshots {
+		for _, n := range snap.Nodes {
+			used, allocatable := n.CPUCores, n.CPUAllocatable
+			if resource == ResourceMemory {
+				used, allocatable = n.MemoryBytes, n.MemoryAllocatable
+			}
+			row, ok := rows[n.Name]
+			if !ok {
+				row = make([]*float64, len(snapshots))
+				rows[n.Name] = row
+			}
+			if allocatable > 0 {
+				v := used / allocatable
+				row[col] = &v
+			}
+		}
+	}
+	h := Heatmap{
+		Resource:   resource,
+		Timestamps: make([]time.Time, len(snapshots)),
+		Nodes:      make([]string, 0, len(rows)),
+		Values:     make([][]*float64, 0, len(rows)),
+	}
+	for i, snap := range snapshots {
+		h.Timestamps[i] = snap.Timestamp
+	}
+	for name := range rows {
+		h.Nodes = append(h.Nodes, name)
+	}
+	sort.Strings(h.Nodes)
+	for _, name := range h.Nodes {
+		h.Values = append(h.Values, rows[name])
+	}
+	return h
+}
@@ -0,0 +1,152 @@
+package api
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// openAPIDocument builds an OpenAPI 3.0 document for routes. Request and
+// response types become component schemas named after the Go type; field
+// descriptions come from `doc` struct tags.
+func openAPIDocument(routes []route) map[string]interface{} {
+	schemas := make(map[string]interface{})
+	errorRef := schemaRef(reflect.TypeOf(Error{}), schemas)
+	paths := make(map[string]interface{})
+	for _, rt := range routes {
+		status := rt.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		op := map[string]interface{}{
+			"operationId": rt.operationID,
+			"summary":     rt.summary,
+			"responses": map[string]interface{}{
+				strconv.Itoa(status): jsonResponse(http.StatusText(status), schemaRef(rt.response, schemas)),
+				"default":            jsonResponse("Error", errorRef),
+			},
+		}
+		if len(rt.query) > 0 {
+			var params []interface{}
+			for _, p := range rt.query {
+				params = append(params, map[string]interface{}{
+					"name":        p.name,
+					"in":          "query",
+					"description": p.description,
+					"schema":      schemaRef(p.typ, schemas),
+				})
+			}
+			op["parameters"] = params
+		}
+		if rt.request != nil {
+			op["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{"schema": schemaRef(rt.request, schemas)},
+				},
+			}
+		}
+		if rt.auth {
+			op["security"] = []interface{}{map[string]interface{}{"bearerAuth": []interface{}{}}}
+		}
+		if paths[rt.path] == nil {
+			paths[rt.path] = make(map[string]interface{})
+		}
+		paths[rt.path].(map[string]interface{})[strings.ToLower(rt.method)] = op
+	}
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":   "k8s-ai-exporter API",
+			"version": Version,
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": schemas,
+			"securitySchemes": map[string]interface{}{
+				"bearerAuth": map[string]interface{}{
+					"type":        "http",
+					"scheme":      "bearer",
+					"description": "Required only when the exporter runs with -api-token.",
+				},
+			},
+		},
+	}
+}
+
+func jsonResponse(description string, schema map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"description": description,
+		"content": map[string]interface{}{
+			"application/json": map[string]interface{}{"schema": schema},
+		},
+	}
+}
+
+// schemaRef returns the schema of t, registering named structs in schemas
+// and referring to them by $ref.
+func schemaRef(t reflect.Type, schemas map[string]interface{}) map[string]interface{} {
+	switch {
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case t.Kind() == reflect.Ptr:
+		return schemaRef(t.Elem(), schemas)
+	case t.Kind() == reflect.Struct:
+		if _, ok := schemas[t.Name()]; !ok {
+			schemas[t.Name()] = nil // placeholder for recursive types
+			schemas[t.Name()] = structSchema(t, schemas)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + t.Name()}
+	case t.Kind() == reflect.Slice || t.Kind() == reflect.Array:
+		return map[string]interface{}{"type": "array", "items": schemaRef(t.Elem(), schemas)}
+	case t.Kind() == reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": schemaRef(t.Elem(), schemas)}
+	case t.Kind() == reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	}
+	return map[string]interface{}{"type": "string"}
+}
+
+func structSchema(t reflect.Type, schemas map[string]interface{}) map[string]interface{} {
+	props := make(map[string]interface{})
+	var required []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		prop := schemaRef(f.Type, schemas)
+		if doc := f.Tag.Get("doc"); doc != "" {
+			if _, isRef := prop["$ref"]; isRef {
+				// Siblings of $ref are ignored in OpenAPI 3.0.
+				prop = map[string]interface{}{"allOf": []interface{}{prop}, "description": doc}
+			} else {
+				prop["description"] = doc
+			}
+		}
+		props[name] = prop
+		if !strings.Contains(opts, "omitempty") {
+			required = append(required, name)
+		}
+	}
+	s := map[string]interface{}{"type": "object", "properties": props}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s
+}
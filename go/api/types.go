@@ -0,0 +1,113 @@
+package api
+
+import (
+	"sort"
+	"time"
+
+	"github.com/your-org/k8s-ai-exporter/timeline"
+)
+
+// Snapshot is the result of the last completed collection cycle.
+type Snapshot struct {
+	Timestamp  time.Time           `json:"timestamp" doc:"End of the collection cycle."`
+	Nodes      []NodeSnapshot      `json:"nodes" doc:"Nodes sorted by name."`
+	Namespaces []NamespaceSnapshot `json:"namespaces,omitempty" doc:"Namespaces sorted by name; empty until each node was scraped twice."`
+}
+
+// NodeSnapshot is the aggregated usage of one node.
+type NodeSnapshot struct {
+	Name        string  `json:"name"`
+	CPUCores    float64 `json:"cpuCores" doc:"CPU usage in cores averaged since the node's previous scrape, as k8s_node_cpu_usage_cores; 0 until the node was scraped twice."`
+	MemoryBytes float64 `json:"memoryBytes" doc:"Memory working set in bytes, as k8s_node_memory_usage_bytes."`
+	ActivePods  int     `json:"activePods" doc:"Non-terminal pods bound to the node, as k8s_node_active_pods."`
+
+	CPUAllocatable    float64 `json:"cpuAllocatableCores,omitempty" doc:"The node's allocatable CPU in cores."`
+	MemoryAllocatable float64 `json:"memoryAllocatableBytes,omitempty" doc:"The node's allocatable memory in bytes."`
+
+	Stale bool `json:"stale,omitempty" doc:"The node's last scrape failed; CPU and memory are from its last successful one, as k8s_node_scrape_stale."`
+}
+
+// NamespaceSnapshot is the average usage of one namespace's containers
+// over the collection cycle.
+type NamespaceSnapshot struct {
+	Name        string  `json:"name"`
+	CPUCores    float64 `json:"cpuCores" doc:"Average CPU usage in cores, from the rate of k8s_namespace_cpu_core_seconds_total."`
+	MemoryBytes float64 `json:"memoryBytes" doc:"Average working set in bytes."`
+}
+
+// History is the response of the history endpoint.
+type History struct {
+	Snapshots []Snapshot `json:"snapshots"`
+}
+
+// EventList is the response of the event listing.
+type EventList struct {
+	Events []timeline.Event `json:"events"`
+}
+
+// MaintenanceList is the response of the maintenance window listing.
+type MaintenanceList struct {
+	Windows []timeline.Window `json:"windows"`
+}
+
+// ScrapeResponse is the response of an out-of-band scrape request.
+type ScrapeResponse struct {
+	Cycles []string      `json:"cycles,omitempty" doc:"Cycles queued to run now; a cycle still running starts again as soon as it finishes."`
+	Node   *NodeSnapshot `json:"node,omitempty" doc:"The node scraped on a node request, with its fresh usage."`
+}
+
+// Error is the body of every non-2xx response.
+type Error struct {
+	Error string `json:"error"`
+}
+
+// NewSnapshot builds a Snapshot from per-node maps keyed by node name. Every
+// node present in any map is included.
+func NewSnapshot(at time.Time, pods, cpu, mem map[string]float64) Snapshot {
+	names := make(map[string]bool)
+	for _, m := range []map[string]float64{pods, cpu, mem} {
+		for n := range m {
+			names[n] = true
+		}
+	}
+	s := Snapshot{Timestamp: at, Nodes: make([]NodeSnapshot, 0, len(names))}
+	for n := range names {
+		s.Nodes = append(s.Nodes, NodeSnapshot{Name: n, CPUCores: cpu[n], MemoryBytes: mem[n], ActivePods: int(pods[n])})
+	}
+	sort.Slice(s.Nodes, func(i, j int) bool { return s.Nodes[i].Name < s.Nodes[j].Name })
+	return s
+}
+
+// SetAllocatable fills in the allocatable resources of the snapshot's nodes
+// from per-node maps keyed by node name.
+func (s *Snapshot) SetAllocatable(cpu, mem map[string]float64) {
+	for i := range s.Nodes {
+		s.Nodes[i].CPUAllocatable = cpu[s.Nodes[i].Name]
+		s.Nodes[i].MemoryAllocatable = mem[s.Nodes[i].Name]
+	}
+}
+
+// SetStale flags the snapshot's nodes whose usage is from an earlier
+// successful scrape.
+func (s *Snapshot) SetStale(stale map[string]bool) {
+	for i := range s.Nodes {
+		s.Nodes[i].Stale = stale[s.Nodes[i].Name]
+	}
+}
+
+// NewNamespaceSnapshots builds the namespace list of a Snapshot from
+// per-namespace maps. Every namespace present in either map is included.
+func NewNamespaceSnapshots(cpu, mem map[string]float64) []NamespaceSnapshot {
+	names := make(map[string]bool)
+	for _, m := range []map[string]float64{cpu, mem} {
+		for n := range m {
+			names[n] = true
+		}
+	}
+	out := make([]NamespaceSnapshot, 0, len(names))
+	for n := range names {
+		out = append(out, NamespaceSnapshot{Name: n, CPUCores: cpu[n], MemoryBytes: mem[n]})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
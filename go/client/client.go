@@ -0,0 +1,193 @@
+// Package client is the Go client for the exporter's /api/v1 JSON API. It
+// shares its request and response types with package api.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/your-org/k8s-ai-exporter/api"
+	"github.com/your-org/k8s-ai-exporter/selfcheck"
+	"github.com/your-org/k8s-ai-exporter/timeline"
+)
+
+// Client calls the API of one exporter, e.g. http://k8s-ai-exporter.monitoring.svc:9100.
+type Client struct {
+	baseURL string
+	http    *http.Client
+
+	// Token is sent as a bearer token when set, as required for creating
+	// events and maintenance windows on exporters run with -api-token.
+	Token string
+}
+
+// New returns a client for baseURL. A nil httpClient uses
+// http.DefaultClient.
+func New(baseURL string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{baseURL: strings.TrimSuffix(baseURL, "/"), http: httpClient}
+}
+
+// Error is a non-2xx API response.
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("api: status %d: %s", e.StatusCode, e.Message)
+}
+
+// GetSnapshot returns per-node usage from the last completed collection
+// cycle. Before the first cycle it fails with an *Error with status 503.
+func (c *Client) GetSnapshot(ctx context.Context) (api.Snapshot, error) {
+	var snap api.Snapshot
+	err := c.get(ctx, "/api/"+api.Version+"/snapshot", &snap)
+	return snap, err
+}
+
+// GetHistory returns the exporter's retained snapshots of the trailing
+// window, oldest first; a zero window returns all of them.
+func (c *Client) GetHistory(ctx context.Context, window time.Duration) ([]api.Snapshot, error) {
+	path := "/api/" + api.Version + "/history"
+	if window > 0 {
+		path += "?window=" + url.QueryEscape(window.String())
+	}
+	var h api.History
+	err := c.get(ctx, path, &h)
+	return h.Snapshots, err
+}
+
+// GetHeatmap returns the utilization of resource (api.ResourceCPU or
+// api.ResourceMemory) per node over the trailing window; a zero window
+// covers all retained history.
+func (c *Client) GetHeatmap(ctx context.Context, resource string, window time.Duration) (api.Heatmap, error) {
+	q := url.Values{"resource": {resource}}
+	if window > 0 {
+		q.Set("window", window.String())
+	}
+	var h api.Heatmap
+	err := c.get(ctx, "/api/"+api.Version+"/heatmap?"+q.Encode(), &h)
+	return h, err
+}
+
+// GetSelfCheck runs the exporter's privilege self-check and returns its
+// report.
+func (c *Client) GetSelfCheck(ctx context.Context) (selfcheck.Report, error) {
+	var r selfcheck.Report
+	err := c.get(ctx, "/api/"+api.Version+"/selfcheck", &r)
+	return r, err
+}
+
+// ListEvents returns the retained external events at or after since (all
+// of them when since is zero), oldest first.
+func (c *Client) ListEvents(ctx context.Context, since time.Time) ([]timeline.Event, error) {
+	path := "/api/" + api.Version + "/events"
+	if !since.IsZero() {
+		path += "?since=" + url.QueryEscape(since.Format(time.RFC3339))
+	}
+	var list api.EventList
+	err := c.get(ctx, path, &list)
+	return list.Events, err
+}
+
+// CreateEvent records an external event and returns it with its ID and
+// time filled in.
+func (c *Client) CreateEvent(ctx context.Context, e timeline.Event) (timeline.Event, error) {
+	var created timeline.Event
+	err := c.do(ctx, http.MethodPost, "/api/"+api.Version+"/events", e, &created)
+	return created, err
+}
+
+// ListMaintenanceWindows returns the declared maintenance windows ordered
+// by start.
+func (c *Client) ListMaintenanceWindows(ctx context.Context) ([]timeline.Window, error) {
+	var list api.MaintenanceList
+	err := c.get(ctx, "/api/"+api.Version+"/maintenance", &list)
+	return list.Windows, err
+}
+
+// CreateMaintenanceWindow declares a maintenance window and returns it with
+// its ID filled in.
+func (c *Client) CreateMaintenanceWindow(ctx context.Context, w timeline.Window) (timeline.Window, error) {
+	var created timeline.Window
+	err := c.do(ctx, http.MethodPost, "/api/"+api.Version+"/maintenance", w, &created)
+	return created, err
+}
+
+// TriggerScrape runs the named cycles now, or all cycles when none are
+// named, and returns the queued cycle names. A rate-limited request fails
+// with an *Error of status 429.
+func (c *Client) TriggerScrape(ctx context.Context, cycles ...string) ([]string, error) {
+	path := "/api/" + api.Version + "/scrape"
+	if len(cycles) > 0 {
+		path += "?cycle=" + url.QueryEscape(strings.Join(cycles, ","))
+	}
+	var resp api.ScrapeResponse
+	err := c.do(ctx, http.MethodPost, path, nil, &resp)
+	return resp.Cycles, err
+}
+
+// ScrapeNode scrapes one node now, without running any cycle, and returns
+// its refreshed usage. An unknown node fails with an *Error of status 404.
+func (c *Client) ScrapeNode(ctx context.Context, node string) (api.NodeSnapshot, error) {
+	var resp api.ScrapeResponse
+	err := c.do(ctx, http.MethodPost, "/api/"+api.Version+"/scrape?node="+url.QueryEscape(node), nil, &resp)
+	if err != nil {
+		return api.NodeSnapshot{}, err
+	}
+	if resp.Node == nil {
+		return api.NodeSnapshot{}, fmt.Errorf("scrape of node %s returned no node", node)
+	}
+	return *resp.Node, nil
+}
+
+func (c *Client) get(ctx context.Context, path string, out interface{}) error {
+	return c.do(ctx, http.MethodGet, path, nil, out)
+}
+
+func (c *Client) do(ctx context.Context, method, path string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		apiErr := &Error{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(body))}
+		var e api.Error
+		if json.Unmarshal(body, &e) == nil && e.Error != "" {
+			apiErr.Message = e.Error
+		}
+		return apiErr
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
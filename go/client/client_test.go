@@ -0,0 +1,167 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/your-org/k8s-ai-exporter/api"
+	"github.com/your-org/k8s-ai-exporter/selfcheck"
+	"github.com/your-org/k8s-ai-exporter/timeline"
+)
+
+func TestGetSnapshot(t *testing.T) {
+	store := api.NewStore()
+	srv := httptest.NewServer(api.NewHandler(api.Config{Snapshots: store, Events: timeline.New(0), Maintenance: timeline.NewSchedule(0)}))
+	defer srv.Close()
+	c := New(srv.URL+"/", nil)
+
+	_, err := c.GetSnapshot(context.Background())
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusServiceUnavailable || apiErr.Message == "" {
+		t.Fatalf("GetSnapshot before first cycle: err = %v, want 503 *Error", err)
+	}
+
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	store.Set(api.NewSnapshot(at, map[string]float64{"a": 2}, map[string]float64{"a": 0.5}, nil))
+	got, err := c.GetSnapshot(context.Background())
+	if err != nil {
+		t.Fatalf("GetSnapshot: %v", err)
+	}
+	if !got.Timestamp.Equal(at) || len(got.Nodes) != 1 || got.Nodes[0] != (api.NodeSnapshot{Name: "a", CPUCores: 0.5, ActivePods: 2}) {
+		t.Errorf("GetSnapshot = %+v", got)
+	}
+}
+
+func TestGetHistory(t *testing.T) {
+	store := api.NewStoreWithHistory(time.Hour)
+	srv := httptest.NewServer(api.NewHandler(api.Config{Snapshots: store, Events: timeline.New(0), Maintenance: timeline.NewSchedule(0)}))
+	defer srv.Close()
+	c := New(srv.URL, nil)
+
+	now := time.Now()
+	store.Set(api.Snapshot{Timestamp: now.Add(-40 * time.Minute)})
+	store.Set(api.Snapshot{Timestamp: now.Add(-time.Minute), Namespaces: []api.NamespaceSnapshot{{Name: "shop", CPUCores: 1.5}}})
+	got, err := c.GetHistory(context.Background(), 0)
+	if err != nil || len(got) != 2 {
+		t.Fatalf("GetHistory(0) = %+v, %v; want 2 snapshots", got, err)
+	}
+	got, err = c.GetHistory(context.Background(), 10*time.Minute)
+	if err != nil || len(got) != 1 || got[0].Namespaces[0] != (api.NamespaceSnapshot{Name: "shop", CPUCores: 1.5}) {
+		t.Errorf("GetHistory(10m) = %+v, %v", got, err)
+	}
+}
+
+func TestGetHeatmap(t *testing.T) {
+	store := api.NewStoreWithHistory(time.Hour)
+	srv := httptest.NewServer(api.NewHandler(api.Config{Snapshots: store, Events: timeline.New(0), Maintenance: timeline.NewSchedule(0)}))
+	defer srv.Close()
+	c := New(srv.URL, nil)
+
+	store.Set(api.Snapshot{Timestamp: time.Now(), Nodes: []api.NodeSnapshot{{Name: "a", MemoryBytes: 256, MemoryAllocatable: 1024}}})
+	h, err := c.GetHeatmap(context.Background(), api.ResourceMemory, 10*time.Minute)
+	if err != nil || h.Resource != api.ResourceMemory || len(h.Values) != 1 || *h.Values[0][0] != 0.25 {
+		t.Fatalf("GetHeatmap = %+v, %v", h, err)
+	}
+	var apiErr *Error
+	if _, err := c.GetHeatmap(context.Background(), "disk", 0); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("GetHeatmap(disk): err = %v, want 400", err)
+	}
+}
+
+func TestGetSelfCheck(t *testing.T) {
+	dir := t.TempDir()
+	srv := httptest.NewServer(api.NewHandler(api.Config{Snapshots: api.NewStore(), Events: timeline.New(0), Maintenance: timeline.NewSchedule(0), SelfCheck: &selfcheck.Checker{Paths: []string{dir}}}))
+	defer srv.Close()
+
+	r, err := New(srv.URL, nil).GetSelfCheck(context.Background())
+	if err != nil || len(r.Filesystem) != 1 || r.Filesystem[0].Path != dir || !r.Filesystem[0].Writable {
+		t.Errorf("GetSelfCheck = %+v, %v", r, err)
+	}
+}
+
+func TestEvents(t *testing.T) {
+	srv := httptest.NewServer(api.NewHandler(api.Config{Snapshots: api.NewStore(), Events: timeline.New(10), Maintenance: timeline.NewSchedule(0), Token: "s3cret"}))
+	defer srv.Close()
+	c := New(srv.URL, nil)
+
+	e := timeline.Event{Type: timeline.TypeDeploy, Title: "checkout v42", Time: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+	var apiErr *Error
+	if _, err := c.CreateEvent(context.Background(), e); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("CreateEvent without token: err = %v, want 401", err)
+	}
+
+	c.Token = "s3cret"
+	created, err := c.CreateEvent(context.Background(), e)
+	if err != nil || created.ID == 0 {
+		t.Fatalf("CreateEvent = %+v, %v", created, err)
+	}
+	events, err := c.ListEvents(context.Background(), e.Time.Add(-time.Minute))
+	if err != nil || len(events) != 1 || events[0].Title != e.Title {
+		t.Errorf("ListEvents = %+v, %v", events, err)
+	}
+	if events, _ := c.ListEvents(context.Background(), e.Time.Add(time.Minute)); len(events) != 0 {
+		t.Errorf("ListEvents after the event = %+v, want none", events)
+	}
+}
+
+func TestMaintenanceWindows(t *testing.T) {
+	srv := httptest.NewServer(api.NewHandler(api.Config{Snapshots: api.NewStore(), Events: timeline.New(0), Maintenance: timeline.NewSchedule(0), Token: "s3cret"}))
+	defer srv.Close()
+	c := New(srv.URL, nil)
+	c.Token = "s3cret"
+
+	start := time.Date(2024, 5, 1, 2, 0, 0, 0, time.UTC)
+	created, err := c.CreateMaintenanceWindow(context.Background(), timeline.Window{Start: start, End: start.Add(2 * time.Hour), Reason: "chaos drill"})
+	if err != nil || created.ID == 0 {
+		t.Fatalf("CreateMaintenanceWindow = %+v, %v", created, err)
+	}
+	windows, err := c.ListMaintenanceWindows(context.Background())
+	if err != nil || len(windows) != 1 || !windows[0].Start.Equal(start) {
+		t.Errorf("ListMaintenanceWindows = %+v, %v", windows, err)
+	}
+}
+
+type onceTrigger struct{ done bool }
+
+func (o *onceTrigger) TriggerScrape(names []string) ([]string, time.Duration, error) {
+	if o.done {
+		return nil, time.Second, nil
+	}
+	o.done = true
+	return names, 0, nil
+}
+
+func (o *onceTrigger) ScrapeNode(_ context.Context, node string) (api.NodeSnapshot, time.Duration, error) {
+	if node != "node-a" {
+		return api.NodeSnapshot{}, 0, fmt.Errorf("%w %s", api.ErrUnknownNode, node)
+	}
+	return api.NodeSnapshot{Name: node, CPUCores: 2}, 0, nil
+}
+
+func TestTriggerScrape(t *testing.T) {
+	srv := httptest.NewServer(api.NewHandler(api.Config{Snapshots: api.NewStore(), Events: timeline.New(0), Maintenance: timeline.NewSchedule(0), Scrape: &onceTrigger{}, Token: "s3cret"}))
+	defer srv.Close()
+	c := New(srv.URL, nil)
+	c.Token = "s3cret"
+
+	cycles, err := c.TriggerScrape(context.Background(), "pods", "main")
+	if err != nil || len(cycles) != 2 || cycles[1] != "main" {
+		t.Fatalf("TriggerScrape = %v, %v; want [pods main]", cycles, err)
+	}
+	var apiErr *Error
+	if _, err := c.TriggerScrape(context.Background()); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("second TriggerScrape = %v, want a 429 error", err)
+	}
+
+	if n, err := c.ScrapeNode(context.Background(), "node-a"); err != nil || n.CPUCores != 2 {
+		t.Errorf("ScrapeNode(node-a) = %+v, %v", n, err)
+	}
+	if _, err := c.ScrapeNode(context.Background(), "nope"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("ScrapeNode(nope) = %v, want a 404 error", err)
+	}
+}
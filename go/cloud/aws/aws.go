@@ -0,0 +1,89 @@
+// Package aws reads EC2 instance metadata. Importing it registers the
+// "aws" cloud.Provider.
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/your-org/k8s-ai-exporter/cloud"
+)
+
+// DefaultEndpoint is the EC2 instance metadata service.
+const DefaultEndpoint = "http://169.254.169.254"
+
+func init() { cloud.Register(&Provider{Endpoint: DefaultEndpoint}) }
+
+// Provider talks to the instance metadata service at Endpoint with IMDSv2
+// session tokens. Pods reach it only when the instances' metadata hop
+// limit is at least 2.
+type Provider struct {
+	Endpoint string
+}
+
+// Name implements cloud.Provider.
+func (p *Provider) Name() string { return "aws" }
+
+// Interruption implements cloud.Provider with the spot instance-action
+// notice, which EC2 posts two minutes before it stops or terminates a spot
+// instance.
+func (p *Provider) Interruption(ctx context.Context, client *http.Client) (cloud.Interruption, error) {
+	token, err := p.token(ctx, client)
+	if err != nil {
+		return cloud.Interruption{}, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.Endpoint+"/latest/meta-data/spot/instance-action", nil)
+	if err != nil {
+		return cloud.Interruption{}, err
+	}
+	req.Header.Set("X-aws-ec2-metadata-token", token)
+	resp, err := client.Do(req)
+	if err != nil {
+		return cloud.Interruption{}, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		return cloud.Interruption{}, nil
+	case http.StatusOK:
+	default:
+		return cloud.Interruption{}, fmt.Errorf("spot instance-action: HTTP %d", resp.StatusCode)
+	}
+	var notice struct {
+		Action string `json:"action"`
+		Time   string `json:"time"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&notice); err != nil {
+		return cloud.Interruption{}, fmt.Errorf("spot instance-action: %v", err)
+	}
+	in := cloud.Interruption{Pending: true, Action: notice.Action}
+	if err := in.At.UnmarshalText([]byte(notice.Time)); err != nil {
+		return cloud.Interruption{}, fmt.Errorf("spot instance-action time %q: %v", notice.Time, err)
+	}
+	return in, nil
+}
+
+func (p *Provider) token(ctx context.Context, client *http.Client) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, p.Endpoint+"/latest/api/token", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", "60")
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("IMDSv2 token: HTTP %d", resp.StatusCode)
+	}
+	b, err := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(b)), nil
+}
@@ -0,0 +1,39 @@
+package aws
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestInterruption(t *testing.T) {
+	notice := ""
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPut && r.URL.Path == "/latest/api/token":
+			w.Write([]byte("tok"))
+		case r.URL.Path == "/latest/meta-data/spot/instance-action" && r.Header.Get("X-aws-ec2-metadata-token") == "tok":
+			if notice == "" {
+				http.NotFound(w, r)
+				return
+			}
+			w.Write([]byte(notice))
+		default:
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+		}
+	}))
+	defer srv.Close()
+	p := &Provider{Endpoint: srv.URL}
+
+	in, err := p.Interruption(context.Background(), srv.Client())
+	if err != nil || in.Pending {
+		t.Errorf("without a notice: %+v, %v", in, err)
+	}
+	notice = `{"action": "terminate", "time": "2026-10-16T08:22:00Z"}`
+	in, err = p.Interruption(context.Background(), srv.Client())
+	if err != nil || !in.Pending || in.Action != "terminate" || !in.At.Equal(time.Date(2026, 10, 16, 8, 22, 0, 0, time.UTC)) {
+		t.Errorf("with a notice: %+v, %v", in, err)
+	}
+}
@@ -0,0 +1,92 @@
+// Package azure reads Azure Instance Metadata Service data. Importing it
+// registers the "azure" cloud.Provider.
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/your-org/k8s-ai-exporter/cloud"
+)
+
+// DefaultEndpoint is the Azure Instance Metadata Service.
+const DefaultEndpoint = "http://169.254.169.254"
+
+func init() { cloud.Register(&Provider{Endpoint: DefaultEndpoint}) }
+
+// Provider talks to the Instance Metadata Service at Endpoint.
+type Provider struct {
+	Endpoint string
+}
+
+// Name implements cloud.Provider.
+func (p *Provider) Name() string { return "azure" }
+
+// Interruption implements cloud.Provider with Scheduled Events: a Preempt
+// event of a spot VM (30 seconds ahead) or a Terminate event (at least 5
+// minutes ahead) that names this VM. Events for other VMs of the same
+// scale set, and Reboot, Redeploy and Freeze events, are ignored.
+func (p *Provider) Interruption(ctx context.Context, client *http.Client) (cloud.Interruption, error) {
+	name, err := p.get(ctx, client, "/metadata/instance/compute/name?api-version=2021-02-01&format=text")
+	if err != nil {
+		return cloud.Interruption{}, err
+	}
+	body, err := p.get(ctx, client, "/metadata/scheduledevents?api-version=2020-07-01")
+	if err != nil {
+		return cloud.Interruption{}, err
+	}
+	var doc struct {
+		Events []struct {
+			EventType string
+			Resources []string
+			NotBefore string
+		}
+	}
+	if err := json.Unmarshal([]byte(body), &doc); err != nil {
+		return cloud.Interruption{}, fmt.Errorf("scheduled events: %v", err)
+	}
+	for _, e := range doc.Events {
+		if e.EventType != "Preempt" && e.EventType != "Terminate" {
+			continue
+		}
+		for _, r := range e.Resources {
+			if r != name {
+				continue
+			}
+			in := cloud.Interruption{Pending: true, Action: strings.ToLower(e.EventType)}
+			if e.NotBefore != "" {
+				if in.At, err = time.Parse(time.RFC1123, e.NotBefore); err != nil {
+					return cloud.Interruption{}, fmt.Errorf("scheduled event NotBefore %q: %v", e.NotBefore, err)
+				}
+			}
+			return in, nil
+		}
+	}
+	return cloud.Interruption{}, nil
+}
+
+func (p *Provider) get(ctx context.Context, client *http.Client, path string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.Endpoint+path, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata", "true")
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s: HTTP %d", path, resp.StatusCode)
+	}
+	b, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(b)), nil
+}
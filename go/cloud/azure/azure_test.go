@@ -0,0 +1,42 @@
+package azure
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestInterruption(t *testing.T) {
+	events := `{"DocumentIncarnation": 1, "Events": []}`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata") != "true" {
+			http.Error(w, "missing Metadata header", http.StatusBadRequest)
+			return
+		}
+		switch r.URL.Path {
+		case "/metadata/instance/compute/name":
+			w.Write([]byte("aks-spot-1"))
+		case "/metadata/scheduledevents":
+			w.Write([]byte(events))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	p := &Provider{Endpoint: srv.URL}
+
+	if in, err := p.Interruption(context.Background(), srv.Client()); err != nil || in.Pending {
+		t.Errorf("without events: %+v, %v", in, err)
+	}
+	events = `{"DocumentIncarnation": 2, "Events": [
+		{"EventId": "a", "EventType": "Freeze", "Resources": ["aks-spot-1"], "NotBefore": "Fri, 16 Oct 2026 08:00:00 GMT"},
+		{"EventId": "b", "EventType": "Preempt", "Resources": ["aks-spot-2"], "NotBefore": "Fri, 16 Oct 2026 08:00:00 GMT"},
+		{"EventId": "c", "EventType": "Preempt", "Resources": ["aks-spot-1"], "NotBefore": "Fri, 16 Oct 2026 08:00:30 GMT"}
+	]}`
+	in, err := p.Interruption(context.Background(), srv.Client())
+	if err != nil || !in.Pending || in.Action != "preempt" || !in.At.Equal(time.Date(2026, 10, 16, 8, 0, 30, 0, time.UTC)) {
+		t.Errorf("with a preempt event: %+v, %v", in, err)
+	}
+}
@@ -0,0 +1,79 @@
+// Package cloud holds the integrations that talk to a cloud provider
+// rather than to Kubernetes. Each provider lives in a subpackage that
+// registers itself from an init function; the exporter links them through
+// files behind build tags, so `-tags nocloud` (or noaws, nogcp, noazure)
+// builds a binary without them for air-gapped clusters. Metadata that the
+// cloud controllers already put on Nodes is read by package aggregate and
+// is available in every build.
+package cloud
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Interruption is a notice that the instance is about to be reclaimed.
+type Interruption struct {
+	Pending bool
+	// Action is what the provider will do, e.g. "terminate", "stop" or
+	// "preempt"; empty when nothing is pending.
+	Action string
+	// At is when the provider acts, zero when it does not say.
+	At time.Time
+}
+
+// Provider is the integration of one cloud.
+type Provider interface {
+	// Name is the scheme of the spec.providerID of the provider's nodes,
+	// e.g. "aws".
+	Name() string
+	// Interruption asks the instance metadata service of the instance the
+	// exporter runs on whether it is about to be reclaimed.
+	Interruption(ctx context.Context, client *http.Client) (Interruption, error)
+}
+
+var (
+	mu        sync.RWMutex
+	providers = make(map[string]Provider)
+)
+
+// Register makes p available under p.Name(). It panics when the name is
+// taken.
+func Register(p Provider) {
+	mu.Lock()
+	defer mu.Unlock()
+	if _, dup := providers[p.Name()]; dup {
+		panic("cloud: provider " + p.Name() + " registered twice")
+	}
+	providers[p.Name()] = p
+}
+
+// Lookup returns the provider of the providerID scheme name.
+func Lookup(name string) (Provider, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	p, ok := providers[name]
+	return p, ok
+}
+
+// Names returns the names of the registered providers, sorted.
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// MetadataClient returns an HTTP client for instance metadata services.
+// They answer on link-local addresses, so it never uses a proxy, and
+// quickly, so it gives up after timeout.
+func MetadataClient(timeout time.Duration) *http.Client {
+	return &http.Client{Transport: &http.Transport{Proxy: nil}, Timeout: timeout}
+}
@@ -0,0 +1,69 @@
+// Package gcp reads Compute Engine instance metadata. Importing it
+// registers the "gce" cloud.Provider.
+package gcp
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/your-org/k8s-ai-exporter/cloud"
+)
+
+// DefaultEndpoint is the Compute Engine metadata server.
+const DefaultEndpoint = "http://metadata.google.internal"
+
+func init() { cloud.Register(&Provider{Endpoint: DefaultEndpoint}) }
+
+// Provider talks to the metadata server at Endpoint.
+type Provider struct {
+	Endpoint string
+}
+
+// Name implements cloud.Provider.
+func (p *Provider) Name() string { return "gce" }
+
+// Interruption implements cloud.Provider. A spot or preemptible VM is
+// "preempt"ed about 30 seconds after instance/preempted turns TRUE; any VM
+// is "terminate"d for host maintenance when instance/maintenance-event
+// says TERMINATE_ON_HOST_MAINTENANCE. Neither says when.
+func (p *Provider) Interruption(ctx context.Context, client *http.Client) (cloud.Interruption, error) {
+	preempted, err := p.get(ctx, client, "/computeMetadata/v1/instance/preempted")
+	if err != nil {
+		return cloud.Interruption{}, err
+	}
+	if preempted == "TRUE" {
+		return cloud.Interruption{Pending: true, Action: "preempt"}, nil
+	}
+	event, err := p.get(ctx, client, "/computeMetadata/v1/instance/maintenance-event")
+	if err != nil {
+		return cloud.Interruption{}, err
+	}
+	if event == "TERMINATE_ON_HOST_MAINTENANCE" {
+		return cloud.Interruption{Pending: true, Action: "terminate"}, nil
+	}
+	return cloud.Interruption{}, nil
+}
+
+func (p *Provider) get(ctx context.Context, client *http.Client, path string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.Endpoint+path, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s: HTTP %d", path, resp.StatusCode)
+	}
+	b, err := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(b)), nil
+}
@@ -0,0 +1,38 @@
+package gcp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestInterruption(t *testing.T) {
+	values := map[string]string{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata-Flavor") != "Google" {
+			http.Error(w, "missing Metadata-Flavor", http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(values[r.URL.Path] + "\n"))
+	}))
+	defer srv.Close()
+	p := &Provider{Endpoint: srv.URL}
+
+	tests := []struct {
+		preempted, event, want string
+	}{
+		{"FALSE", "NONE", ""},
+		{"FALSE", "MIGRATE_ON_HOST_MAINTENANCE", ""},
+		{"FALSE", "TERMINATE_ON_HOST_MAINTENANCE", "terminate"},
+		{"TRUE", "NONE", "preempt"},
+	}
+	for _, tt := range tests {
+		values["/computeMetadata/v1/instance/preempted"] = tt.preempted
+		values["/computeMetadata/v1/instance/maintenance-event"] = tt.event
+		in, err := p.Interruption(context.Background(), srv.Client())
+		if err != nil || in.Action != tt.want || in.Pending != (tt.want != "") {
+			t.Errorf("preempted=%s event=%s: %+v, %v; want action %q", tt.preempted, tt.event, in, err, tt.want)
+		}
+	}
+}
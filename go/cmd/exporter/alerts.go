@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/your-org/k8s-ai-exporter/aggregate"
+	"github.com/your-org/k8s-ai-exporter/alert"
+)
+
+// observeSaturation evaluates the saturation objectives at now on the
+// usage rates of freshly scraped nodes, exports the burn rates and sends
+// the alerts that fired or resolved. Nodes without allocatable resources
+// are left out.
+func observeSaturation(fresh map[string]aggregate.UsageRate, allocCPU, allocMem map[string]float64, now time.Time) {
+	sat := map[string]map[string]float64{"cpu": {}, "memory": {}}
+	for node, u := range fresh {
+		if a := allocCPU[node]; a > 0 {
+			sat["cpu"][node] = u.CPUCores / a
+		}
+		if a := allocMem[node]; a > 0 {
+			sat["memory"][node] = u.MemoryBytes / a
+		}
+	}
+	rates, changes := saturation.Observe(sat, now)
+	metrics.SetSaturationBurnRates(rates)
+	for _, a := range changes {
+		log.Printf("alert %s %s: node %s %s (burn rate %.1f over %s, %.1f over %s)", a.Name, a.Status(), a.Node, a.Resource,
+			a.ShortBurnRate, alert.ShortWindowName, a.LongBurnRate, alert.LongWindowName)
+	}
+	sendAlerts(changes, saturation.Firing(), now)
+}
+
+// sendAlerts delivers the alerts that changed state to the notifiers and
+// Alertmanager, and re-sends the firing ones to Alertmanager. Nothing is
+// sent during a maintenance window.
+func sendAlerts(changes, firing []alert.Alert, now time.Time) {
+	if alertmanager == nil {
+		firing = nil
+	}
+	if len(changes) == 0 && len(firing) == 0 {
+		return
+	}
+	if len(notifiers) == 0 && alertmanager == nil {
+		return
+	}
+	if len(maintenance.Active(now)) > 0 {
+		if len(changes) > 0 {
+			log.Printf("maintenance window in effect; not sending %d alert(s)", len(changes))
+		}
+		return
+	}
+	if len(changes) > 0 {
+		for name, n := range notifiers {
+			go notifyAlerts(name, n, changes)
+		}
+	}
+	if alertmanager != nil {
+		// Firing alerts are re-sent every cycle, or Alertmanager would
+		// resolve them once their endsAt passes.
+		for _, a := range changes {
+			if !a.Firing {
+				firing = append(firing, a)
+			}
+		}
+		go notifyAlerts("alertmanager", alertmanager, firing)
+	}
+}
+
+// notifyAlerts delivers alerts with the notifier name, counting failures.
+func notifyAlerts(name string, n alert.Notifier, alerts []alert.Alert) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := n.Notify(ctx, alerts); err != nil {
+		metrics.NotificationError(name)
+		log.Printf("alert %s: %v", name, err)
+	}
+}
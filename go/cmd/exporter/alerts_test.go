@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/your-org/k8s-ai-exporter/aggregate"
+	"github.com/your-org/k8s-ai-exporter/alert"
+	"github.com/your-org/k8s-ai-exporter/timeline"
+)
+
+type chanNotifier chan []alert.Alert
+
+func (c chanNotifier) Notify(_ context.Context, alerts []alert.Alert) error {
+	c <- alerts
+	return nil
+}
+
+func TestObserveSaturation(t *testing.T) {
+	sent := make(chanNotifier, 1)
+	defer func(s *alert.Saturation, n map[string]alert.Notifier, m *timeline.Schedule) {
+		saturation, notifiers, maintenance = s, n, m
+	}(saturation, notifiers, maintenance)
+	saturation = alert.NewSaturation(alert.Objectives{"cpu": {Threshold: 0.9, Target: 0.99}}, 14.4, "critical")
+	notifiers = map[string]alert.Notifier{"test": sent}
+	maintenance = timeline.NewSchedule(10)
+
+	fresh := map[string]aggregate.UsageRate{"node-a": {CPUCores: 3.8, MemoryBytes: 1}, "no-alloc": {CPUCores: 1}}
+	alloc := map[string]float64{"node-a": 4}
+	start := time.Now().Add(-time.Hour)
+	// A saturated node pages once its samples cover the long window.
+	for m := 0; m < 45; m++ {
+		observeSaturation(fresh, alloc, alloc, start.Add(time.Duration(m)*time.Minute))
+	}
+	select {
+	case alerts := <-sent:
+		t.Fatalf("sent %+v before the windows were covered", alerts)
+	case <-time.After(50 * time.Millisecond):
+	}
+	observeSaturation(fresh, alloc, alloc, start.Add(45*time.Minute))
+	select {
+	case alerts := <-sent:
+		if len(alerts) != 1 || alerts[0].Node != "node-a" || alerts[0].Resource != "cpu" || !alerts[0].Firing {
+			t.Errorf("sent %+v, want node-a cpu firing", alerts)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no alert sent")
+	}
+
+	// Alerts firing inside a maintenance window are not sent.
+	now := time.Now()
+	if _, err := maintenance.Add(timeline.Window{Start: now.Add(-2 * time.Hour), End: now.Add(time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+	saturation.Retain(map[string]bool{})
+	for m := 0; m <= 45; m++ {
+		observeSaturation(fresh, alloc, alloc, start.Add(time.Duration(m)*time.Minute))
+	}
+	select {
+	case alerts := <-sent:
+		t.Errorf("sent %+v during maintenance", alerts)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestObserveSaturationFromCounters(t *testing.T) {
+	sent := make(chanNotifier, 1)
+	defer func(s *alert.Saturation, n map[string]alert.Notifier) {
+		saturation, notifiers = s, n
+	}(saturation, notifiers)
+	saturation = alert.NewSaturation(alert.Objectives{"cpu": {Threshold: 0.9, Target: 0.99}}, 14.4, "critical")
+	notifiers = map[string]alert.Notifier{"test": sent}
+
+	// A node that has been up for a while uses 1 of its 4 cores: its
+	// summed CPU counter is in the thousands, its saturation 0.25.
+	rates := aggregate.NewCPURates()
+	alloc := map[string]float64{"node-a": 4}
+	start := time.Now().Add(-time.Hour)
+	for i := 0; i <= 120; i++ {
+		at := start.Add(time.Duration(i) * 30 * time.Second)
+		cores, ok := rates.Observe("node-a", 2823+float64(i)*30, at)
+		if !ok {
+			continue
+		}
+		observeSaturation(map[string]aggregate.UsageRate{"node-a": {CPUCores: cores}}, alloc, alloc, at)
+	}
+	select {
+	case alerts := <-sent:
+		t.Errorf("sent %+v for a node at 25%% CPU", alerts)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestObserveSaturationResendsToAlertmanager(t *testing.T) {
+	posts := make(chan []byte, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		posts <- body
+	}))
+	defer srv.Close()
+	defer func(s *alert.Saturation, am *alert.Alertmanager, n map[string]alert.Notifier, m *timeline.Schedule) {
+		saturation, alertmanager, notifiers, maintenance = s, am, n, m
+	}(saturation, alertmanager, notifiers, maintenance)
+	saturation = alert.NewSaturation(alert.Objectives{"cpu": {Threshold: 0.9, Target: 0.99}}, 14.4, "critical")
+	alertmanager = &alert.Alertmanager{URLs: []string{srv.URL}, Hold: time.Minute, Client: srv.Client()}
+	notifiers = nil
+	maintenance = timeline.NewSchedule(10)
+
+	fresh := map[string]aggregate.UsageRate{"node-a": {CPUCores: 3.8}}
+	alloc := map[string]float64{"node-a": 4}
+	start := time.Now().Add(-time.Hour)
+	for m := 0; m <= 47; m++ {
+		observeSaturation(fresh, alloc, alloc, start.Add(time.Duration(m)*time.Minute))
+	}
+	// Fired at minute 45 and re-sent on each of the two cycles since.
+	for i := 0; i < 3; i++ {
+		select {
+		case body := <-posts:
+			if !strings.Contains(string(body), `"node":"node-a"`) {
+				t.Errorf("post %d = %s, want the node-a alert", i, body)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("got %d posts, want 3", i)
+		}
+	}
+}
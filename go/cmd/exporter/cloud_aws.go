@@ -0,0 +1,6 @@
+//go:build !nocloud && !noaws
+
+package main
+
+// The aws integration; build with -tags nocloud or noaws to leave it out.
+import _ "github.com/your-org/k8s-ai-exporter/cloud/aws"
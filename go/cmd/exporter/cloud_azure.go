@@ -0,0 +1,6 @@
+//go:build !nocloud && !noazure
+
+package main
+
+// The azure integration; build with -tags nocloud or noazure to leave it out.
+import _ "github.com/your-org/k8s-ai-exporter/cloud/azure"
@@ -0,0 +1,6 @@
+//go:build !nocloud && !nogcp
+
+package main
+
+// The gcp integration; build with -tags nocloud or nogcp to leave it out.
+import _ "github.com/your-org/k8s-ai-exporter/cloud/gcp"
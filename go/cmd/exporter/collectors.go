@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/your-org/k8s-ai-exporter/aggregate"
+	"github.com/your-org/k8s-ai-exporter/collector"
+	"github.com/your-org/k8s-ai-exporter/kube"
+)
+
+// cycleState is what the collectors of one cycle run see: the exporter's
+// clients and the nodes and pods listed at the start of the run.
+type cycleState struct {
+	lister    *kube.Lister
+	scraper   *collector.Scraper
+	clientset kubernetes.Interface
+	budget    *collector.Budget
+	nodes     []corev1.Node
+	pods      []corev1.Pod
+	changes   kube.NodeChanges // set for the detail collectors
+
+	// nsUsage is the usage per namespace of the freshly scraped nodes,
+	// set by the node-usage collector for the ones after it.
+	nsUsage map[string]aggregate.UsageRate
+}
+
+// builtin is a collector of the exporter. Each one registers itself from
+// an init function, so adding a collector does not touch main.
+type builtin struct {
+	cycle   string // "pods", "main" or "detail"
+	name    string
+	enabled *bool
+	collect func(ctx context.Context, s *cycleState) error
+}
+
+var builtins []builtin
+
+// registerBuiltin adds a collector to the runs of cycle, in registration
+// order, with a -collector.<name> flag that turns it off.
+func registerBuiltin(cycle, name, help string, collect func(ctx context.Context, s *cycleState) error) {
+	builtins = append(builtins, builtin{
+		cycle:   cycle,
+		name:    name,
+		enabled: flag.Bool("collector."+name, true, help),
+		collect: collect,
+	})
+}
+
+// newRegistry returns the collectors of cycle bound to s, with the ones
+// turned off by their flag disabled.
+func newRegistry(cycle string, s *cycleState) *collector.Registry {
+	reg := &collector.Registry{}
+	for _, b := range builtins {
+		if b.cycle != cycle {
+			continue
+		}
+		collect := b.collect
+		reg.MustRegister(collector.NewCollector(b.name, func(ctx context.Context) error {
+			return collect(ctx, s)
+		}))
+		if !*b.enabled {
+			reg.Disable(b.name)
+		}
+	}
+	return reg
+}
+
+// runCollectors runs the enabled collectors of reg one after the other,
+// each isolated, and exports how long each took and whether it failed. A
+// failing collector does not stop the others.
+func runCollectors(ctx context.Context, reg *collector.Registry) {
+	for _, c := range reg.Enabled() {
+		start := time.Now()
+		err := isolate(c.Name(), func() error { return c.Collect(ctx) })
+		metrics.ObserveCollector(c.Name(), time.Since(start), err)
+		if err != nil {
+			log.Printf("collector %s: %v", c.Name(), err)
+		}
+	}
+}
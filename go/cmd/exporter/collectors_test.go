@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestRunCollectors(t *testing.T) {
+	saved := builtins
+	defer func() { builtins = saved }()
+	on, off := true, false
+	var ran []string
+	record := func(name string, err error) func(context.Context, *cycleState) error {
+		return func(context.Context, *cycleState) error {
+			ran = append(ran, name)
+			return err
+		}
+	}
+	builtins = []builtin{
+		{cycle: "main", name: "failing", enabled: &on, collect: record("failing", errors.New("no GPUs"))},
+		{cycle: "main", name: "ok", enabled: &on, collect: record("ok", nil)},
+		{cycle: "main", name: "off", enabled: &off, collect: record("off", nil)},
+		{cycle: "detail", name: "detail", enabled: &on, collect: record("detail", nil)},
+	}
+	runCollectors(context.Background(), newRegistry("main", &cycleState{}))
+	if want := []string{"failing", "ok"}; !reflect.DeepEqual(ran, want) {
+		t.Errorf("ran %v, want %v: a failing collector must not stop the next, a disabled one or one of another cycle must not run", ran, want)
+	}
+}
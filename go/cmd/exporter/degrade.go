@@ -0,0 +1,80 @@
+package main
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/your-org/k8s-ai-exporter/kube"
+)
+
+// degradation backs the exporter off the API server while it is
+// throttled. At level n every cycle interval is stretched by 2^n, and from
+// level 1 the expensive collectors (detail collectors and the Service
+// listing) are skipped. Each check that saw throttling raises the level up
+// to max; each quiet period without throttling lowers it by one, so
+// intervals recover by halving. A nil degradation never degrades.
+type degradation struct {
+	max   int
+	quiet time.Duration
+
+	mu    sync.Mutex
+	level int
+	since time.Time // last change of level, or last throttling
+}
+
+func newDegradation(max int, quiet time.Duration) *degradation {
+	return &degradation{max: max, quiet: quiet}
+}
+
+// observe updates the level after a check at now and returns it.
+func (d *degradation) observe(throttled bool, now time.Time) int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	switch {
+	case throttled:
+		if d.level < d.max {
+			d.level++
+		}
+		d.since = now
+	case d.level > 0 && now.Sub(d.since) >= d.quiet:
+		d.level--
+		d.since = now
+	}
+	return d.level
+}
+
+// current returns the degradation level.
+func (d *degradation) current() int {
+	if d == nil {
+		return 0
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.level
+}
+
+// stretch returns interval scaled for the current level.
+func (d *degradation) stretch(interval time.Duration) time.Duration {
+	return interval << d.current()
+}
+
+// shedding reports whether expensive collectors are skipped.
+func (d *degradation) shedding() bool {
+	return d.current() > 0
+}
+
+// run checks monitor every tick, exports the level and logs changes.
+func (d *degradation) run(monitor *kube.ThrottleMonitor, tick time.Duration) {
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+	prev := 0
+	for now := range ticker.C {
+		level := d.observe(monitor.Throttled(), now)
+		metrics.SetDegradationLevel(level)
+		if level != prev {
+			log.Printf("API server throttling: degradation level %d -> %d (intervals x%d, expensive collectors skipped: %v)", prev, level, 1<<level, level > 0)
+			prev = level
+		}
+	}
+}
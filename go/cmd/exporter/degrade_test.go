@@ -0,0 +1,48 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDegradation(t *testing.T) {
+	start := time.Date(2024, 1, 2, 15, 0, 0, 0, time.UTC)
+	d := newDegradation(2, time.Minute)
+	steps := []struct {
+		after     time.Duration
+		throttled bool
+		want      int
+	}{
+		{0, false, 0},
+		{10 * time.Second, true, 1},
+		{20 * time.Second, true, 2},
+		{30 * time.Second, true, 2},
+		{80 * time.Second, false, 2},
+		{90 * time.Second, false, 1},
+		{120 * time.Second, true, 2},
+		{180 * time.Second, false, 1},
+		{240 * time.Second, false, 0},
+		{400 * time.Second, false, 0},
+	}
+	for _, s := range steps {
+		if got := d.observe(s.throttled, start.Add(s.after)); got != s.want {
+			t.Errorf("level at +%v (throttled %v) = %d, want %d", s.after, s.throttled, got, s.want)
+		}
+	}
+
+	d.observe(true, start)
+	if got, want := d.stretch(10*time.Second), 20*time.Second; got != want {
+		t.Errorf("stretch at level 1 = %v, want %v", got, want)
+	}
+	if !d.shedding() {
+		t.Errorf("shedding at level 1 = false, want true")
+	}
+
+	var disabled *degradation
+	if got, want := disabled.stretch(10*time.Second), 10*time.Second; got != want {
+		t.Errorf("stretch without degradation = %v, want %v", got, want)
+	}
+	if disabled.shedding() {
+		t.Errorf("shedding without degradation = true, want false")
+	}
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/your-org/k8s-ai-exporter/aggregate"
+	"github.com/your-org/k8s-ai-exporter/collector"
+	"github.com/your-org/k8s-ai-exporter/kube"
+)
+
+func init() {
+	registerBuiltin("detail", "constraints", "Export the scheduling constraint violations of pods", func(ctx context.Context, s *cycleState) error {
+		if !s.changes.Empty() {
+			metrics.SetConstraintViolations(constraints.Violations(s.nodes, s.pods, s.changes))
+		}
+		return nil
+	})
+	registerBuiltin("detail", "zone-skew", "Export the zone skew of workloads", func(ctx context.Context, s *cycleState) error {
+		if !s.changes.Empty() {
+			metrics.SetZoneSkews(aggregate.ZoneSkews(s.nodes, s.pods))
+		}
+		return nil
+	})
+	registerBuiltin("detail", "fragmentation", "Export the free capacity no workload could use, per pool", func(ctx context.Context, s *cycleState) error {
+		if !s.changes.Empty() {
+			metrics.SetFragmentation(aggregate.CapacityFragmentation(s.nodes, s.pods, *poolLabel))
+		}
+		return nil
+	})
+	registerBuiltin("detail", "batch-queues", "Export the workloads waiting in the queues of -batch-queues", collectBatchQueues)
+	registerBuiltin("detail", "gpu", "Scrape the DCGM exporter pods of -dcgm-selector", collectGPUUsage)
+	registerBuiltin("detail", "host-saturation", "Scrape the node_exporter pods of -node-exporter-selector", collectHostSaturation)
+	registerBuiltin("detail", "coredns", "Scrape the CoreDNS pods of -coredns-selector", collectDNS)
+	registerBuiltin("detail", "control-plane", "Scrape the control plane pods of -control-plane-targets", collectControlPlane)
+	registerBuiltin("detail", "annotated-pods", "Scrape the pods annotated prometheus.io/scrape for the series of -annotated-series", collectAnnotated)
+	registerBuiltin("detail", "custom-targets", "Scrape the targets of -enable-custom-targets", collectCustomTargets)
+}
+
+// collectDetail runs the collectors that evaluate individual pods and
+// workloads. They are the expensive part of a cycle on large clusters.
+// Pod-derived collectors are skipped when nothing changed since their last
+// run; custom targets, annotated pods, GPU usage, host saturation, DNS, the
+// control plane, batch queues and exec collectors are live data and are
+// always read.
+func collectDetail(ctx context.Context, s *cycleState) {
+	s.changes = s.lister.Changes()
+	runCollectors(ctx, newRegistry("detail", s))
+}
+
+// collectCustomTargets scrapes the annotated pods and Services of
+// -enable-custom-targets and feeds the series that other families use.
+func collectCustomTargets(ctx context.Context, s *cycleState) error {
+	if !*enableCustomTargets {
+		return nil
+	}
+	sums, errs := collector.ScrapeCustomTargets(ctx, s.clientset, s.scraper.Client, s.scraper.BaseURL, s.pods)
+	for _, err := range errs {
+		metrics.ScrapeError(err.Target)
+		log.Printf("%v", err)
+	}
+	metrics.SetCustomSeries(sums)
+	observeEnergySeries(sums)
+	if *cpuCreditsSeries != "" {
+		metrics.SetCPUCredits(nodeSeries(sums, *cpuCreditsSeries))
+	}
+	return nil
+}
+
+// collectBatchQueues exports what waits in the Kueue and Volcano queues of
+// -batch-queues. Like custom targets they are read every run: admissions
+// do not show as node changes.
+func collectBatchQueues(ctx context.Context, s *cycleState) error {
+	if !*batchQueueFlag {
+		return nil
+	}
+	waiting, err := s.scraper.QueuedWorkloads(ctx)
+	if err != nil {
+		metrics.ScrapeError("api:batch-queues")
+		return err
+	}
+	cpu, mem := aggregate.Headroom(s.nodes, s.pods)
+	metrics.SetBatchQueues(batchQueues.Observe(waiting, cpu, mem, time.Now()))
+	return nil
+}
+
+// collectGPUUsage scrapes the DCGM exporter pods of -dcgm-selector and
+// exports the usage of each node's GPUs.
+func collectGPUUsage(ctx context.Context, s *cycleState) error {
+	if dcgmPods == nil {
+		return nil
+	}
+	usage, errs := s.scraper.GPUUsage(ctx, s.pods, dcgmPods, *dcgmPort)
+	for _, err := range errs {
+		metrics.ScrapeError(err.Target)
+		log.Printf("%v", err)
+	}
+	metrics.SetGPUUsage(usage)
+	return nil
+}
+
+// collectHostSaturation scrapes the node_exporter pods of
+// -node-exporter-selector and exports the load, disk and network
+// saturation of each node. A node's families appear from its second
+// successful scrape on.
+func collectHostSaturation(ctx context.Context, s *cycleState) error {
+	if nodeExpPods == nil {
+		return nil
+	}
+	counters, errs := s.scraper.HostCounters(ctx, s.pods, nodeExpPods, *nodeExpPort)
+	for _, err := range errs {
+		metrics.ScrapeError(err.Target)
+		log.Printf("%v", err)
+	}
+	now := time.Now()
+	saturation := make(map[string]aggregate.HostSaturation, len(counters))
+	for node, c := range counters {
+		if sat, ok := hostRates.Observe(node, c, now); ok {
+			saturation[node] = sat
+		}
+	}
+	current := make(map[string]bool, len(s.nodes))
+	for i := range s.nodes {
+		current[s.nodes[i].Name] = true
+	}
+	hostRates.Retain(current)
+	metrics.SetHostSaturation(saturation)
+	return nil
+}
+
+// collectDNS scrapes the CoreDNS pods of -coredns-selector and exports
+// the cluster's DNS rates. They appear from the second cycle on.
+func collectDNS(ctx context.Context, s *cycleState) error {
+	if corednsPods == nil {
+		return nil
+	}
+	counters, errs := s.scraper.DNSCounters(ctx, s.pods, corednsPods, *corednsPort)
+	for _, err := range errs {
+		metrics.ScrapeError(err.Target)
+		log.Printf("%v", err)
+	}
+	metrics.SetDNSStats(dnsRates.Observe(counters, time.Now()))
+	return nil
+}
+
+// collectAnnotated scrapes the pods annotated prometheus.io/scrape and
+// re-exports the series of -annotated-series.
+func collectAnnotated(ctx context.Context, s *cycleState) error {
+	if annotated == nil {
+		return nil
+	}
+	series, errs := s.scraper.AnnotatedSeries(ctx, s.pods, annotated)
+	for _, err := range errs {
+		metrics.ScrapeError(err.Target)
+		log.Printf("%v", err)
+	}
+	metrics.SetAnnotatedSeries(series)
+	return nil
+}
+
+// collectControlPlane scrapes the control plane pods of
+// -control-plane-targets and exports their health.
+func collectControlPlane(ctx context.Context, s *cycleState) error {
+	if len(controlPlane) == 0 {
+		return nil
+	}
+	targets := collector.ControlPlaneTargets(s.pods, controlPlane)
+	samples, errs := s.scraper.ControlPlane(ctx, cpClient, cpPlain, targets)
+	for _, err := range errs {
+		metrics.ScrapeError(err.Target)
+		log.Printf("%v", err)
+	}
+	status := make(map[string]aggregate.ControlPlaneStatus, len(targets))
+	current := make(map[string]bool, len(targets))
+	for _, t := range targets {
+		current[t.Pod] = true
+		if sample, ok := samples[t.Pod]; ok {
+			status[t.Pod] = cpRates.Observe(t.Pod, t.Component.Name, sample)
+		} else {
+			status[t.Pod] = aggregate.ControlPlaneStatus{Component: t.Component.Name}
+		}
+	}
+	cpRates.Retain(current)
+	metrics.SetControlPlane(status)
+	return nil
+}
+
+// observeEnergySeries feeds the -energy-series counters of each node's
+// custom targets to the measured power tracker.
+func observeEnergySeries(sums map[collector.CustomSeries]float64) {
+	var names []string
+	for _, name := range strings.Split(*energySeries, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	now := time.Now()
+	for node, j := range nodeSeries(sums, names...) {
+		measured.Observe(node, j, now)
+	}
+}
+
+// nodeSeries sums the custom target series named metrics per node.
+func nodeSeries(sums map[collector.CustomSeries]float64, metrics ...string) map[string]float64 {
+	out := make(map[string]float64)
+	for k, v := range sums {
+		for _, m := range metrics {
+			if k.Metric == m {
+				out[k.Node] += v
+			}
+		}
+	}
+	return out
+}
+
+// scrapeDetail is the standalone detail cycle used when
+// -detail-scrape-interval is set. It does nothing while degraded.
+func scrapeDetail(ctx context.Context, budget *collector.Budget, lister *kube.Lister, scraper *collector.Scraper, clientset kubernetes.Interface) error {
+	if degrade.shedding() {
+		return nil
+	}
+	start := time.Now()
+	nodes, pods, err := lister.List(ctx)
+	if err != nil {
+		return err
+	}
+	budget.Since(collector.PhaseAPIList, start)
+	start = time.Now()
+	collectDetail(ctx, &cycleState{lister: lister, scraper: scraper, clientset: clientset, budget: budget, nodes: nodes, pods: pods})
+	budget.Since(collector.PhaseDetail, start)
+	return nil
+}
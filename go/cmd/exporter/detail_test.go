@@ -0,0 +1,25 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/your-org/k8s-ai-exporter/collector"
+)
+
+func TestNodeSeries(t *testing.T) {
+	sums := map[collector.CustomSeries]float64{
+		{Node: "a", Job: "node-exporter", Metric: "node_rapl_package_joules_total"}: 100,
+		{Node: "a", Job: "node-exporter", Metric: "node_rapl_dram_joules_total"}:    20,
+		{Node: "a", Job: "node-exporter", Metric: "node_load1"}:                     3,
+		{Node: "b", Job: "node-exporter", Metric: "node_rapl_package_joules_total"}: 50,
+	}
+	got := nodeSeries(sums, "node_rapl_package_joules_total", "node_rapl_dram_joules_total")
+	want := map[string]float64{"a": 120, "b": 50}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("nodeSeries = %v, want %v", got, want)
+	}
+	if got := nodeSeries(sums); len(got) != 0 {
+		t.Errorf("nodeSeries without metrics = %v, want empty", got)
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/your-org/k8s-ai-exporter/api"
+)
+
+// writeSnapshotTable prints s as the console table of -dev mode.
+func writeSnapshotTable(w io.Writer, s api.Snapshot) error {
+	if _, err := fmt.Fprintf(w, "\n%s\n", s.Timestamp.Format(time.TimeOnly)); err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(tw, "NODE\tCPU(cores)\tMEMORY(MiB)\tPODS")
+	var cpu, mem float64
+	var pods int
+	for _, n := range s.Nodes {
+		fmt.Fprintf(tw, "%s\t%.2f\t%.0f\t%d\n", n.Name, n.CPUCores, n.MemoryBytes/(1<<20), n.ActivePods)
+		cpu += n.CPUCores
+		mem += n.MemoryBytes
+		pods += n.ActivePods
+	}
+	fmt.Fprintf(tw, "TOTAL (%d nodes)\t%.2f\t%.0f\t%d\n", len(s.Nodes), cpu, mem/(1<<20), pods)
+	return tw.Flush()
+}
+
+// flagSet reports whether the flag name was given on the command line.
+func flagSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
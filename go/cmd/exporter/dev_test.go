@@ -0,0 +1,33 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/your-org/k8s-ai-exporter/api"
+)
+
+func TestWriteSnapshotTable(t *testing.T) {
+	s := api.Snapshot{
+		Timestamp: time.Date(2024, 5, 1, 12, 0, 30, 0, time.UTC),
+		Nodes: []api.NodeSnapshot{
+			{Name: "node-a", CPUCores: 1.5, MemoryBytes: 512 << 20, ActivePods: 12},
+			{Name: "b", CPUCores: 0.25, MemoryBytes: 2048 << 20, ActivePods: 3},
+		},
+	}
+	var got strings.Builder
+	if err := writeSnapshotTable(&got, s); err != nil {
+		t.Fatal(err)
+	}
+	want := `
+12:00:30
+NODE              CPU(cores)   MEMORY(MiB)   PODS
+node-a            1.50         512           12
+b                 0.25         2048          3
+TOTAL (2 nodes)   1.75         2560          15
+`
+	if got.String() != want {
+		t.Errorf("table =\n%s\nwant\n%s", got.String(), want)
+	}
+}
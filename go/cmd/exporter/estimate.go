@@ -0,0 +1,274 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"github.com/your-org/k8s-ai-exporter/aggregate"
+	"github.com/your-org/k8s-ai-exporter/collector"
+	"github.com/your-org/k8s-ai-exporter/kube"
+)
+
+// familyEstimate is the predicted size of one metric family.
+type familyEstimate struct {
+	name   string
+	series float64
+}
+
+// runEstimate implements the estimate subcommand: it parses args like the
+// exporter's own flags, including -config-file, and predicts the series,
+// samples per second and Prometheus storage the node usage families will
+// take with them. The families are measured by running the parsers and the
+// aggregation over real payloads: the cycles of a -record-dir recording
+// given as the only argument, or without one, two scrapes of
+// -estimate-sample nodes of the live cluster. Families labelled by node
+// are scaled from the measured nodes to -estimate-nodes or the cluster's
+// node count, families labelled by namespace to the cluster's namespaces.
+// Families computed from the node and pod listing are not included.
+func runEstimate(w io.Writer, fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *configFile != "" {
+		if err := loadConfig(fs, *configFile); err != nil {
+			return fmt.Errorf("-config-file: %w", err)
+		}
+	}
+	if fs.NArg() > 1 {
+		return fmt.Errorf("usage: estimate [flags] [<record-dir>]")
+	}
+	if *estimateBytes <= 0 || *estimateRetention <= 0 {
+		return fmt.Errorf("-estimate-bytes-per-sample and -estimate-retention must be positive")
+	}
+	rules, err := collector.ParsePassthroughSpec(*passthroughSeries)
+	if err != nil {
+		return fmt.Errorf("invalid -passthrough-series: %w", err)
+	}
+
+	var cycles []collector.RecordedCycle
+	nodes, namespaces := *estimateNodes, 0
+	if fs.NArg() == 1 {
+		if cycles, err = collector.ReadRecording(fs.Arg(0)); err != nil {
+			return err
+		}
+	} else {
+		var cluster int
+		cycles, cluster, namespaces, err = sampleCluster(context.Background(), rules)
+		if err != nil {
+			return err
+		}
+		if nodes == 0 {
+			nodes = cluster
+		}
+	}
+	if len(cycles) == 0 {
+		return fmt.Errorf("no cycles to measure")
+	}
+
+	r := newReplayer(rules)
+	for _, c := range cycles {
+		_, errs := r.cycle(c)
+		for _, err := range errs {
+			log.Printf("%s %v", c.Time.Format(time.RFC3339), err)
+		}
+	}
+	reg := prometheus.NewRegistry()
+	if err := r.metrics.Register(reg, reg); err != nil {
+		return err
+	}
+	mfs, err := reg.Gather()
+	if err != nil {
+		return err
+	}
+	interval := *scrapeInterval
+	if *fastScrapeInterval > 0 {
+		interval = *fastScrapeInterval
+	}
+	return writeEstimate(w, estimateFamilies(mfs, nodes, namespaces), interval)
+}
+
+// sampleCluster lists the cluster the exporter would see and scrapes up to
+// -estimate-sample of its nodes twice, -estimate-sample-interval apart, as
+// recorded cycles. It also returns the number of nodes and of namespaces
+// with pods. Scrapes go through the API server proxy whatever
+// -scrape-mode is.
+func sampleCluster(ctx context.Context, rules collector.Rules) (cycles []collector.RecordedCycle, nodes, namespaces int, err error) {
+	var cfg *rest.Config
+	if *devMode {
+		cfg, _, err = kube.DevConfig(*kubeContext)
+	} else {
+		cfg, err = kube.Config()
+	}
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("kube config: %w", err)
+	}
+	clientset, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	lister := &kube.Lister{Clientset: clientset, ExcludePhases: kube.ParsePhases(*excludePhases)}
+	if *nodeSelector != "" {
+		if lister.NodeSelector, err = labels.Parse(*nodeSelector); err != nil {
+			return nil, 0, 0, fmt.Errorf("-node-selector: %w", err)
+		}
+	}
+	if *excludeCP {
+		lister.NodeSelector = kube.WithoutControlPlane(lister.NodeSelector)
+	}
+	listed, pods, err := lister.List(ctx)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	ns := make(map[string]bool)
+	for i := range pods {
+		ns[pods[i].Namespace] = true
+	}
+
+	client, baseURL, err := kube.ProxyClient(cfg)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	var cycle *collector.RecordedCycle
+	scraper := &collector.Scraper{
+		Client:      client,
+		BaseURL:     baseURL,
+		Protobuf:    *scrapeProtobuf,
+		Gzip:        *scrapeGzip,
+		Passthrough: rules,
+		Record: func(node, source, format string, body []byte) {
+			cycle.Payloads = append(cycle.Payloads, collector.Payload{Node: node, Source: source, Format: format, Body: body})
+		},
+	}
+	sample := sampleNodes(listed, *estimateSample)
+	for i := 0; i < 2; i++ {
+		if i > 0 {
+			time.Sleep(*estimateInterval)
+		}
+		cycles = append(cycles, collector.RecordedCycle{Time: time.Now()})
+		cycle = &cycles[len(cycles)-1]
+		for j := range sample {
+			if r := scrapeNode(ctx, scraper, sample[j].Name, nodeSourceFor(&sample[j]), nil); r.err != nil {
+				log.Printf("%s %s: %v", r.source, sample[j].Name, r.err)
+			}
+		}
+	}
+	return cycles, len(listed), len(ns), nil
+}
+
+// sampleNodes returns n of nodes spread evenly over the list, skipping
+// virtual ones, which have nothing to scrape.
+func sampleNodes(nodes []corev1.Node, n int) []corev1.Node {
+	var scrapable []corev1.Node
+	for i := range nodes {
+		if !aggregate.Virtual(&nodes[i]) {
+			scrapable = append(scrapable, nodes[i])
+		}
+	}
+	if n >= len(scrapable) {
+		return scrapable
+	}
+	out := make([]corev1.Node, 0, n)
+	for i := 0; i < n; i++ {
+		out = append(out, scrapable[i*len(scrapable)/n])
+	}
+	return out
+}
+
+// estimateFamilies counts the series of each family in mfs. A family with a
+// node label is scaled from the nodes it holds to nodes, one with a
+// namespace label from its namespaces to namespaces; 0 keeps the measured
+// count.
+func estimateFamilies(mfs []*dto.MetricFamily, nodes, namespaces int) []familyEstimate {
+	seenNodes, seenNS := make(map[string]bool), make(map[string]bool)
+	for _, mf := range mfs {
+		for _, m := range mf.GetMetric() {
+			for _, l := range m.GetLabel() {
+				switch l.GetName() {
+				case "node":
+					seenNodes[l.GetValue()] = true
+				case "namespace":
+					seenNS[l.GetValue()] = true
+				}
+			}
+		}
+	}
+	var out []familyEstimate
+	for _, mf := range mfs {
+		series := float64(len(mf.GetMetric()))
+		if series == 0 {
+			continue
+		}
+		switch {
+		case hasLabel(mf, "node") && nodes > 0 && len(seenNodes) > 0:
+			series *= float64(nodes) / float64(len(seenNodes))
+		case hasLabel(mf, "namespace") && namespaces > 0 && len(seenNS) > 0:
+			series *= float64(max(namespaces, len(seenNS))) / float64(len(seenNS))
+		}
+		out = append(out, familyEstimate{mf.GetName(), series})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].series != out[j].series {
+			return out[i].series > out[j].series
+		}
+		return out[i].name < out[j].name
+	})
+	return out
+}
+
+func hasLabel(mf *dto.MetricFamily, name string) bool {
+	for _, m := range mf.GetMetric() {
+		for _, l := range m.GetLabel() {
+			if l.GetName() == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// writeEstimate prints the series per family and the totals: samples per
+// second when Prometheus scrapes every interval, and the storage they take
+// per day and over -estimate-retention at -estimate-bytes-per-sample.
+func writeEstimate(w io.Writer, families []familyEstimate, interval time.Duration) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(tw, "FAMILY\tSERIES")
+	var total float64
+	for _, f := range families {
+		fmt.Fprintf(tw, "%s\t%.0f\n", f.name, f.series)
+		total += f.series
+	}
+	perSecond := total / interval.Seconds()
+	day := perSecond * (24 * time.Hour).Seconds() * *estimateBytes
+	retention := perSecond * estimateRetention.Seconds() * *estimateBytes
+	fmt.Fprintf(tw, "TOTAL (%d families)\t%.0f\n", len(families), total)
+	fmt.Fprintf(tw, "\nsamples/s at %s\t%.1f\n", interval, perSecond)
+	fmt.Fprintf(tw, "storage per day\t%s\n", humanBytes(day))
+	fmt.Fprintf(tw, "storage over %s\t%s\n", *estimateRetention, humanBytes(retention))
+	return tw.Flush()
+}
+
+func humanBytes(b float64) string {
+	const unit = 1024
+	if b < unit {
+		return fmt.Sprintf("%.0f B", b)
+	}
+	exp, div := 0, float64(unit)
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", b/div, "KMGTPE"[exp])
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestEstimateRecording(t *testing.T) {
+	var out bytes.Buffer
+	err := runEstimate(&out, exporterFlags(t), []string{"-estimate-nodes=2000", "-scrape-interval=1m", "testdata/golden/cadvisor-text/payloads"})
+	if err != nil {
+		t.Fatalf("runEstimate: %v", err)
+	}
+	got := make(map[string]string)
+	for _, line := range strings.Split(out.String(), "\n") {
+		if f := strings.Fields(line); len(f) == 2 {
+			got[f[0]] = f[1]
+		}
+	}
+	// The recording holds two nodes: node families scale by 1000, the
+	// namespace families stay as recorded.
+	for family, want := range map[string]string{
+		"k8s_node_cpu_usage_cores":             "2000",
+		"k8s_namespace_cpu_core_seconds_total": "2",
+	} {
+		if got[family] != want {
+			t.Errorf("%s: %s series, want %s\n%s", family, got[family], want, out.String())
+		}
+	}
+	if !strings.Contains(out.String(), "samples/s at 1m0s") {
+		t.Errorf("no samples/s at the scrape interval:\n%s", out.String())
+	}
+}
+
+func TestHumanBytes(t *testing.T) {
+	for b, want := range map[float64]string{512: "512 B", 1536: "1.5 KiB", 3 << 30: "3.0 GiB"} {
+		if got := humanBytes(b); got != want {
+			t.Errorf("humanBytes(%g) = %s, want %s", b, got, want)
+		}
+	}
+}
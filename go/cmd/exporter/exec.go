@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/your-org/k8s-ai-exporter/collector"
+)
+
+var execCollectors []collector.ExecCollector // empty without -exec-collectors
+
+func init() {
+	registerBuiltin("detail", "exec", "Run the programs of -exec-collectors", collectExec)
+}
+
+// collectExec runs the programs of -exec-collectors one after the other,
+// each within -exec-collector-timeout, and exports what they printed. A
+// program that fails is counted as a scrape error and its series are
+// dropped until its next successful run.
+func collectExec(ctx context.Context, s *cycleState) error {
+	if len(execCollectors) == 0 {
+		return nil
+	}
+	env := execEnv()
+	var series []collector.RawSeries
+	for _, c := range execCollectors {
+		runCtx, cancel := context.WithTimeout(ctx, *execTimeout)
+		out, err := c.Run(runCtx, env)
+		cancel()
+		if err != nil {
+			metrics.ScrapeError("exec:" + c.Name)
+			log.Printf("exec collector %s: %v", c.Name, err)
+			continue
+		}
+		series = append(series, out...)
+	}
+	metrics.SetExecSeries(series)
+	return nil
+}
+
+// execEnv returns the exporter's environment without the variables that
+// hold credentials (see secretFlags), which the programs have no use for.
+func execEnv() []string {
+	var env []string
+	for _, kv := range os.Environ() {
+		name, _, _ := strings.Cut(kv, "=")
+		secret := false
+		for _, s := range secretFlags {
+			secret = secret || name == s.env
+		}
+		if !secret {
+			env = append(env, kv)
+		}
+	}
+	return env
+}
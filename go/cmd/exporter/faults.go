@@ -0,0 +1,44 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/your-org/k8s-ai-exporter/collector"
+)
+
+// hiddenFlagPrefix marks flags left out of -help and the migrated config:
+// the -fault.* flags break scrapes on purpose, to test alerting and error
+// handling in staging, and must not end up in a production config.
+const hiddenFlagPrefix = "fault."
+
+func isHiddenFlag(name string) bool {
+	return strings.HasPrefix(name, hiddenFlagPrefix)
+}
+
+// faultInjection returns the faults requested by the -fault.* flags.
+func faultInjection() *collector.Faults {
+	return &collector.Faults{
+		FailureRatio:  *faultFailureRatio,
+		Delay:         *faultDelay,
+		DelayRatio:    *faultDelayRatio,
+		TruncateRatio: *faultTruncateRatio,
+		OnFault:       metrics.FaultInjected,
+	}
+}
+
+// usageWithoutHidden is flag.Usage with the hidden flags left out.
+func usageWithoutHidden() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage of %s:\n", os.Args[0])
+	visible := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	visible.SetOutput(out)
+	flag.VisitAll(func(f *flag.Flag) {
+		if !isHiddenFlag(f.Name) {
+			visible.Var(f.Value, f.Name, f.Usage)
+		}
+	})
+	visible.PrintDefaults()
+}
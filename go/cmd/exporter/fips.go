@@ -0,0 +1,10 @@
+//go:build boringcrypto
+
+package main
+
+// Built with GOEXPERIMENT=boringcrypto: fipsonly restricts crypto/tls to
+// FIPS 140-2 approved versions, cipher suites and curves, overriding
+// -tls-min-version and -tls-cipher-suites where they are broader.
+import _ "crypto/tls/fipsonly"
+
+const fipsBuild = true
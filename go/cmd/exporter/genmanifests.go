@@ -0,0 +1,310 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/your-org/k8s-ai-exporter/kube"
+)
+
+// secretFlags are the flags that hold credentials, with the environment
+// variable each defaults to.
+var secretFlags = []struct{ name, env string }{
+	{"api-token", "API_TOKEN"},
+	{"alert-webhook-secret", "ALERT_WEBHOOK_SECRET"},
+	{"alert-teams-url", "ALERT_TEAMS_URL"},
+	{"alert-pagerduty-routing-key", "PAGERDUTY_ROUTING_KEY"},
+	{"alert-opsgenie-api-key", "OPSGENIE_API_KEY"},
+}
+
+// manifestOnlyFlags only shape the output of the gen-rbac, gen-manifests
+// and estimate subcommands and are not passed on to the exporter.
+var manifestOnlyFlags = map[string]bool{
+	"estimate-nodes":            true,
+	"estimate-sample":           true,
+	"estimate-sample-interval":  true,
+	"estimate-retention":        true,
+	"estimate-bytes-per-sample": true,
+	"rbac-namespace":            true,
+	"manifest-image":            true,
+	"manifest-release-label":    true,
+	"config-file":               true,
+	"print-migrated-config":     true,
+}
+
+// genManifests implements the gen-manifests subcommand: it parses args
+// like the exporter's own flags, including -config-file, and prints a
+// deployment bundle that runs the exporter with them: ServiceAccount,
+// ClusterRole and ClusterRoleBinding (as gen-rbac), a Deployment with
+// -mode=cluster or a DaemonSet with -mode=daemonset, Service,
+// ServiceMonitor and a NetworkPolicy that admits traffic to the listeners
+// only. Credentials are refused as arguments; the workload reads them from
+// the optional Secret k8s-ai-exporter instead.
+func genManifests(w io.Writer, fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *configFile != "" {
+		if err := loadConfig(fs, *configFile); err != nil {
+			return fmt.Errorf("-config-file: %w", err)
+		}
+	}
+	for _, s := range secretFlags {
+		if f := fs.Lookup(s.name); f != nil && f.Value.String() != "" {
+			return fmt.Errorf("-%s is a credential: put it in the Secret %s under key %s instead", s.name, rbacName, s.env)
+		}
+	}
+	var exporterArgs []string
+	fs.Visit(func(f *flag.Flag) {
+		if manifestOnlyFlags[f.Name] || isDeprecatedFlag(f) {
+			return
+		}
+		exporterArgs = append(exporterArgs, "--"+f.Name+"="+f.Value.String())
+	})
+	ports, err := manifestPorts()
+	if err != nil {
+		return err
+	}
+
+	ns := *rbacNamespace
+	docs := []func(io.Writer) error{
+		func(w io.Writer) error { return writeServiceAccount(w, rbacName, ns) },
+		func(w io.Writer) error {
+			return kube.WriteClusterRole(w, rbacName, kube.RequiredPermissions(enabledFeatures()))
+		},
+		func(w io.Writer) error { return kube.WriteClusterRoleBinding(w, rbacName, ns) },
+		func(w io.Writer) error { return writeWorkload(w, ns, exporterArgs, ports) },
+		func(w io.Writer) error { return writeService(w, ns, ports) },
+		func(w io.Writer) error { return writeServiceMonitor(w, ns, ports) },
+		func(w io.Writer) error { return writeNetworkPolicy(w, ns, ports) },
+	}
+	for i, doc := range docs {
+		if i > 0 {
+			if _, err := io.WriteString(w, "---\n"); err != nil {
+				return err
+			}
+		}
+		if err := doc(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// manifestPort is a listener of the exporter as the manifests expose it.
+type manifestPort struct {
+	name     string
+	port     int
+	interval string // ServiceMonitor scrape interval
+}
+
+// manifestPorts returns the main listener and, with
+// -detail-listen-address, the detail one.
+func manifestPorts() ([]manifestPort, error) {
+	port, err := listenPort(*listenAddr)
+	if err != nil {
+		return nil, fmt.Errorf("-listen-address: %w", err)
+	}
+	interval := *scrapeInterval
+	if *fastScrapeInterval > 0 {
+		interval = *fastScrapeInterval
+	}
+	ports := []manifestPort{{name: "http", port: port, interval: interval.String()}}
+	if *detailListenAddr != "" {
+		detail, err := listenPort(*detailListenAddr)
+		if err != nil {
+			return nil, fmt.Errorf("-detail-listen-address: %w", err)
+		}
+		interval := *scrapeInterval
+		if *detailScrapeInterval > 0 {
+			interval = *detailScrapeInterval
+		}
+		ports = append(ports, manifestPort{name: "detail", port: detail, interval: interval.String()})
+	}
+	return ports, nil
+}
+
+func listenPort(addr string) (int, error) {
+	_, p, err := net.SplitHostPort(addr)
+	if err != nil {
+		return 0, err
+	}
+	port, err := strconv.Atoi(p)
+	if err != nil || port <= 0 {
+		return 0, fmt.Errorf("%q has no fixed port", addr)
+	}
+	return port, nil
+}
+
+func writeServiceAccount(w io.Writer, name, namespace string) error {
+	_, err := fmt.Fprintf(w, `apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: %s
+  namespace: %s
+`, name, namespace)
+	return err
+}
+
+// writeWorkload writes the Deployment or DaemonSet, with the pod security
+// settings of deploy/daemonset-exporter.yaml.
+func writeWorkload(w io.Writer, namespace string, args []string, ports []manifestPort) error {
+	var b strings.Builder
+	kind, replicas := "Deployment", "  replicas: 1\n"
+	if *mode == "daemonset" {
+		kind, replicas = "DaemonSet", ""
+	}
+	fmt.Fprintf(&b, `apiVersion: apps/v1
+kind: %s
+metadata:
+  name: %s
+  namespace: %s
+  labels:
+    app: %[2]s
+spec:
+%[4]s  selector:
+    matchLabels:
+      app: %[2]s
+  template:
+    metadata:
+      labels:
+        app: %[2]s
+    spec:
+      serviceAccountName: %[2]s
+      automountServiceAccountToken: true
+      securityContext:
+        runAsNonRoot: true
+        runAsUser: 65532
+        runAsGroup: 65532
+        seccompProfile:
+          type: RuntimeDefault
+`, kind, rbacName, namespace, replicas)
+	if *mode == "daemonset" {
+		b.WriteString("      tolerations:\n        - operator: \"Exists\"\n")
+	}
+	fmt.Fprintf(&b, `      containers:
+        - name: exporter
+          image: %s
+          imagePullPolicy: IfNotPresent
+          args:
+`, *manifestImage)
+	for _, a := range args {
+		fmt.Fprintf(&b, "            - %q\n", a)
+	}
+	b.WriteString("          env:\n")
+	if *mode == "daemonset" {
+		b.WriteString("            - name: NODE_NAME\n              valueFrom:\n                fieldRef:\n                  fieldPath: spec.nodeName\n")
+	}
+	for _, s := range secretFlags {
+		fmt.Fprintf(&b, "            - name: %s\n              valueFrom:\n                secretKeyRef:\n                  name: %s\n                  key: %[1]s\n                  optional: true\n", s.env, rbacName)
+	}
+	b.WriteString(`          securityContext:
+            allowPrivilegeEscalation: false
+            readOnlyRootFilesystem: true
+            capabilities:
+              drop: ["ALL"]
+          ports:
+`)
+	for _, p := range ports {
+		fmt.Fprintf(&b, "            - name: %s\n              containerPort: %d\n", p.name, p.port)
+	}
+	b.WriteString(`          readinessProbe:
+            httpGet:
+              path: /-/ready
+              port: http
+`)
+	if *tlsCertFile != "" {
+		b.WriteString("              scheme: HTTPS\n")
+	}
+	b.WriteString(`            initialDelaySeconds: 5
+            periodSeconds: 10
+          resources:
+            requests:
+              cpu: 50m
+              memory: 64Mi
+            limits:
+              cpu: 500m
+              memory: 256Mi
+`)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeService(w io.Writer, namespace string, ports []manifestPort) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, `apiVersion: v1
+kind: Service
+metadata:
+  name: %s
+  namespace: %s
+  labels:
+    app: %[1]s
+spec:
+  selector:
+    app: %[1]s
+  ports:
+`, rbacName, namespace)
+	for _, p := range ports {
+		fmt.Fprintf(&b, "    - name: %s\n      port: %d\n      targetPort: %[1]s\n", p.name, p.port)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeServiceMonitor writes a ServiceMonitor that scrapes each listener
+// at the interval its families change at.
+func writeServiceMonitor(w io.Writer, namespace string, ports []manifestPort) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, `apiVersion: monitoring.coreos.com/v1
+kind: ServiceMonitor
+metadata:
+  name: %s
+  namespace: %s
+  labels:
+    release: %s
+spec:
+  selector:
+    matchLabels:
+      app: %[1]s
+  namespaceSelector:
+    matchNames: [%[2]q]
+  endpoints:
+`, rbacName, namespace, *manifestRelease)
+	for _, p := range ports {
+		fmt.Fprintf(&b, "    - port: %s\n      path: /metrics\n      interval: %s\n", p.name, p.interval)
+		if *tlsCertFile != "" {
+			fmt.Fprintf(&b, "      scheme: https\n      tlsConfig:\n        serverName: %s.%s.svc\n", rbacName, namespace)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeNetworkPolicy writes a NetworkPolicy that admits traffic to the
+// exporter's listeners only. Egress is left open: the API server and
+// kubelet addresses differ per cluster.
+func writeNetworkPolicy(w io.Writer, namespace string, ports []manifestPort) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, `apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: %s
+  namespace: %s
+spec:
+  podSelector:
+    matchLabels:
+      app: %[1]s
+  policyTypes: ["Ingress"]
+  ingress:
+    - ports:
+`, rbacName, namespace)
+	for _, p := range ports {
+		fmt.Fprintf(&b, "        - protocol: TCP\n          port: %d\n", p.port)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// exporterFlags returns a FlagSet over the exporter's own flag values; the
+// values it sets are reset when the test ends.
+func exporterFlags(t *testing.T) *flag.FlagSet {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	flag.VisitAll(func(f *flag.Flag) { fs.Var(f.Value, f.Name, f.Usage) })
+	t.Cleanup(func() {
+		fs.Visit(func(f *flag.Flag) { f.Value.Set(f.DefValue) })
+	})
+	return fs
+}
+
+func TestGenManifests(t *testing.T) {
+	var out bytes.Buffer
+	err := genManifests(&out, exporterFlags(t), []string{
+		"-mode=daemonset", "-rbac-namespace=obs", "-manifest-image=registry.example/exporter:v1",
+		"-detail-listen-address=:9101", "-detail-scrape-interval=2m", "-enable-custom-targets",
+	})
+	if err != nil {
+		t.Fatalf("genManifests: %v", err)
+	}
+
+	var kinds []string
+	dec := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(out.Bytes()), 4096)
+	for {
+		var doc struct {
+			Kind     string `json:"kind"`
+			Metadata struct {
+				Namespace string `json:"namespace"`
+			} `json:"metadata"`
+		}
+		if err := dec.Decode(&doc); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("invalid YAML: %v\n%s", err, out.String())
+		}
+		kinds = append(kinds, doc.Kind)
+		if doc.Kind != "ClusterRole" && doc.Kind != "ClusterRoleBinding" && doc.Metadata.Namespace != "obs" {
+			t.Errorf("%s in namespace %q, want obs", doc.Kind, doc.Metadata.Namespace)
+		}
+	}
+	want := "ServiceAccount ClusterRole ClusterRoleBinding DaemonSet Service ServiceMonitor NetworkPolicy"
+	if got := strings.Join(kinds, " "); got != want {
+		t.Errorf("kinds = %s, want %s", got, want)
+	}
+	for _, s := range []string{
+		`- "--mode=daemonset"`, `- "--enable-custom-targets=true"`, "image: registry.example/exporter:v1",
+		"fieldPath: spec.nodeName", `"pods/proxy"`, "containerPort: 9101", "interval: 2m0s",
+	} {
+		if !strings.Contains(out.String(), s) {
+			t.Errorf("output lacks %q", s)
+		}
+	}
+	for _, s := range []string{"--rbac-namespace", "--manifest-image"} {
+		if strings.Contains(out.String(), s) {
+			t.Errorf("output passes %s on to the exporter", s)
+		}
+	}
+}
+
+func TestGenManifestsRefusesSecrets(t *testing.T) {
+	err := genManifests(io.Discard, exporterFlags(t), []string{"-api-token=hunter2"})
+	if err == nil || !strings.Contains(err.Error(), "API_TOKEN") {
+		t.Errorf("err = %v, want a pointer to the API_TOKEN Secret key", err)
+	}
+}
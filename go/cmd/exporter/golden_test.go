@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/your-org/k8s-ai-exporter/collector"
+	"github.com/your-org/k8s-ai-exporter/golden"
+)
+
+var updateGolden = flag.Bool("update", false, "Rewrite the metrics.golden files of TestGolden from the current output")
+
+// TestGolden replays every recording under testdata/golden through the
+// parsers, the aggregation and the sink, and compares the exposition with
+// the case's metrics.golden within the tolerances of its tolerances file.
+// A case may also hold the -passthrough-series spec in a passthrough file.
+func TestGolden(t *testing.T) {
+	cases, err := filepath.Glob("testdata/golden/*")
+	if err != nil || len(cases) == 0 {
+		t.Fatalf("no golden cases: %v", err)
+	}
+	for _, dir := range cases {
+		t.Run(filepath.Base(dir), func(t *testing.T) {
+			spec, err := readOptional(filepath.Join(dir, "passthrough"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			rules, err := collector.ParsePassthroughSpec(strings.TrimSpace(spec))
+			if err != nil {
+				t.Fatalf("passthrough: %v", err)
+			}
+			cycles, err := collector.ReadRecording(filepath.Join(dir, "payloads"))
+			if err != nil || len(cycles) == 0 {
+				t.Fatalf("no recorded cycles: %v", err)
+			}
+
+			r := newReplayer(rules)
+			for _, c := range cycles {
+				if _, errs := r.cycle(c); len(errs) > 0 {
+					t.Errorf("cycle %s: %v", c.Time, errors.Join(errs...))
+				}
+			}
+			reg := prometheus.NewRegistry()
+			if err := r.metrics.Register(reg, reg); err != nil {
+				t.Fatalf("Register: %v", err)
+			}
+			var got bytes.Buffer
+			if err := golden.Write(&got, reg); err != nil {
+				t.Fatalf("Write: %v", err)
+			}
+
+			goldenFile := filepath.Join(dir, "metrics.golden")
+			if *updateGolden {
+				if err := os.WriteFile(goldenFile, got.Bytes(), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(goldenFile)
+			if err != nil {
+				t.Fatalf("%v; run go test ./cmd/exporter -run TestGolden -update to create it", err)
+			}
+			tolSpec, err := readOptional(filepath.Join(dir, "tolerances"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			tolerances, err := golden.ParseTolerances(strings.NewReader(tolSpec))
+			if err != nil {
+				t.Fatalf("tolerances: %v", err)
+			}
+			diffs, err := golden.Diff(&got, bytes.NewReader(want), tolerances)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, d := range diffs {
+				t.Error(d)
+			}
+			if len(diffs) > 0 {
+				t.Log("if the changes are intended, run go test ./cmd/exporter -run TestGolden -update and review the golden file diff")
+			}
+		})
+	}
+}
+
+// readOptional returns the contents of name, or "" if it does not exist.
+func readOptional(name string) (string, error) {
+	b, err := os.ReadFile(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	return string(b), err
+}
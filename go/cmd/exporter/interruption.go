@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/your-org/k8s-ai-exporter/cloud"
+)
+
+var (
+	interruptionNode   string       // $NODE_NAME with -cloud-interruptions
+	interruptionClient *http.Client // nil without -cloud-interruptions
+)
+
+func init() {
+	registerBuiltin("pods", "interruption", "Export the interruption notices of the node the exporter runs on", collectInterruption)
+}
+
+// collectInterruption asks the instance metadata service of the node the
+// exporter runs on, through the cloud provider of its providerID, whether
+// the instance is about to be reclaimed.
+func collectInterruption(ctx context.Context, s *cycleState) error {
+	if interruptionClient == nil {
+		return nil
+	}
+	for i := range s.nodes {
+		n := &s.nodes[i]
+		if n.Name != interruptionNode {
+			continue
+		}
+		scheme, _, _ := strings.Cut(n.Spec.ProviderID, "://")
+		p, ok := cloud.Lookup(scheme)
+		if !ok {
+			return fmt.Errorf("node %s: no cloud provider %q in this build (have %s)", n.Name, scheme, strings.Join(cloud.Names(), ", "))
+		}
+		in, err := p.Interruption(ctx, interruptionClient)
+		if err != nil {
+			return fmt.Errorf("%s interruption notice: %w", p.Name(), err)
+		}
+		metrics.SetInterruption(n.Name, in)
+		return nil
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"runtime/debug"
+)
+
+// isolate runs one collector and returns its error. A panic in f, e.g. on
+// an input shape no one anticipated, is recovered: it is logged with its
+// stack, counted in k8s_ai_exporter_collector_panics_total and returned as
+// an error, so only that collector's results are missing from the cycle.
+func isolate(collector string, f func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			metrics.CollectorPanic(collector)
+			log.Printf("collector %s panicked, skipping it this cycle: %v\n%s", collector, r, debug.Stack())
+			err = fmt.Errorf("collector %s panicked: %v", collector, r)
+		}
+	}()
+	return f()
+}
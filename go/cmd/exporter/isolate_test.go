@@ -0,0 +1,22 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestIsolate(t *testing.T) {
+	want := errors.New("list failed")
+	if err := isolate("ok", func() error { return want }); err != want {
+		t.Errorf("isolate = %v, want the collector's error", err)
+	}
+	var nodes map[string]float64
+	err := isolate("zone-skew", func() error {
+		nodes["a"] = 1 // assignment to a nil map
+		return nil
+	})
+	if err == nil || !strings.Contains(err.Error(), "collector zone-skew panicked") {
+		t.Errorf("isolate after a panic = %v, want a panic error", err)
+	}
+}
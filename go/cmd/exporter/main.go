@@ -0,0 +1,1147 @@
+// Command exporter aggregates kubelet/cAdvisor metrics per node and serves
+// them for Prometheus. The collection logic lives in the kube, collector,
+// aggregate and sink packages.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"net/netip"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/your-org/k8s-ai-exporter/aggregate"
+	"github.com/your-org/k8s-ai-exporter/alert"
+	"github.com/your-org/k8s-ai-exporter/api"
+	"github.com/your-org/k8s-ai-exporter/cloud"
+	"github.com/your-org/k8s-ai-exporter/collector"
+	"github.com/your-org/k8s-ai-exporter/kube"
+	"github.com/your-org/k8s-ai-exporter/sink"
+	"github.com/your-org/k8s-ai-exporter/timeline"
+	"github.com/your-org/k8s-ai-exporter/tlspolicy"
+	"github.com/your-org/k8s-ai-exporter/ui"
+)
+
+var (
+	scrapeInterval = flag.Duration("scrape-interval", 30*time.Second, "Interval of the kubelet/cAdvisor scrape cycle")
+	listenAddr     = flag.String("listen-address", ":9100", "HTTP listen address")
+	enableKubelet  = flag.Bool("enable-kubelet", true, "Scrape kubelet metrics")
+	enableCadvisor = flag.Bool("enable-cadvisor", true, "Scrape cAdvisor metrics")
+	excludePhases  = flag.String("exclude-phases", "Succeeded,Failed", "Comma-separated pod phases to exclude from aggregation")
+	nodeSelector   = flag.String("node-selector", "", "Label selector of the nodes to scrape and report, e.g. 'pool=gpu' or 'topology.kubernetes.io/zone in (a,b)'; pods on other nodes are left out too")
+	mode           = flag.String("mode", "cluster", "cluster: every instance scrapes and reports all nodes; daemonset: each instance scrapes and reports only the node named by $NODE_NAME (set it from spec.nodeName), and -scrape-mode defaults to direct")
+	excludeCP      = flag.Bool("exclude-control-plane", false, "Leave out nodes labelled node-role.kubernetes.io/control-plane (or master), and their pods, to report worker capacity only")
+	useInformers   = flag.Bool("informers", false, "Watch nodes and pods with informers instead of listing them every cycle; detail collectors then only recompute changed nodes")
+	scrapeProtobuf = flag.Bool("scrape-protobuf", true, "Ask kubelet/cAdvisor for the protobuf exposition format and fall back to text when not offered")
+	scrapeGzip     = flag.Bool("scrape-gzip", true, "Ask kubelet/cAdvisor for gzip-compressed responses, which cuts the bandwidth through the API server proxy")
+	scrapeTimeout  = flag.Duration("scrape-timeout", 0, "Deadline for the node scrapes of a cycle, counted from their start; scrapes still outstanding are cancelled and their nodes keep their last usage, flagged stale. At most -scrape-interval, 0 disables it")
+	scrapeSpread   = flag.Duration("scrape-spread", 0, "Spread the node scrapes of a cycle over this window, each node at a fixed offset derived from its name, instead of hitting all at the tick; must be shorter than -scrape-interval, 0 disables it")
+	scrapeRetries  = flag.Int("scrape-retries", 1, "Times a node scrape is retried after a transport error, 429 or 5xx; 0 disables retries")
+	retryBackoff   = flag.Duration("scrape-retry-backoff", 250*time.Millisecond, "Backoff before the first retry of a node scrape, doubled per retry with random jitter")
+	breakerFails   = flag.Int("circuit-breaker-failures", 3, "Consecutive failed scrapes after which a node is skipped for -circuit-breaker-cooldown; 0 disables the breaker")
+	breakerCool    = flag.Duration("circuit-breaker-cooldown", 5*time.Minute, "How long a node is skipped once its circuit breaker opened; the next scrape after it probes the node")
+	skipVirtual    = flag.Bool("skip-virtual-nodes", true, "Do not scrape virtual nodes (virtual-kubelet, EKS Fargate), which have no cAdvisor; they are counted in k8s_ai_exporter_virtual_nodes")
+	windowsSummary = flag.Bool("windows-summary", false, "Scrape nodes labelled kubernetes.io/os=windows through the kubelet Summary API, since their cAdvisor and kubelet /metrics series differ from Linux nodes'")
+	skipUnready    = flag.Bool("skip-unready-nodes", false, "Do not scrape nodes whose Ready condition is False or Unknown; they keep their last usage, flagged stale, and k8s_node_unreachable is 1")
+	recordDir      = flag.String("record-dir", "", "Save every raw kubelet/cAdvisor payload under this directory, one subdirectory per cycle, for the replay subcommand")
+
+	source          = flag.String("source", "metrics", "Where node usage comes from: metrics (cAdvisor, or kubelet /metrics, per -enable-cadvisor/-enable-kubelet) or summary (the kubelet Summary API, for clusters that restrict cAdvisor)")
+	scrapeMode      = flag.String("scrape-mode", "proxy", "How kubelet/cAdvisor are reached: proxy (through the API server), direct (each node's InternalIP with the ServiceAccount token) or readonly (the kubelets' unauthenticated read-only HTTP port; needs -allow-insecure-kubelet)")
+	kubeletPort     = flag.Int("kubelet-port", kube.DefaultKubeletPort, "Kubelet port for -scrape-mode=direct, or readonly where it defaults to 10255")
+	insecureKubelet = flag.Bool("allow-insecure-kubelet", false, "Allow -scrape-mode=readonly, which scrapes kubelets over plain HTTP without authentication")
+	kubeletCAFile   = flag.String("kubelet-ca-file", "", "CA bundle that signs the kubelets' serving certificates for -scrape-mode=direct; empty uses the API server CA")
+	kubeletInsecure = flag.Bool("kubelet-insecure-skip-verify", false, "Do not verify the kubelets' serving certificates in -scrape-mode=direct, e.g. when they are self-signed")
+	metricsFallback = flag.Bool("metrics-server-fallback", false, "Take node CPU and memory from metrics-server (metrics.k8s.io) for nodes whose kubelet scrape failed; needs list on nodes.metrics.k8s.io")
+
+	passthroughSeries   = flag.String("passthrough-series", "", "Semicolon-separated allowlist of raw kubelet/cAdvisor series to re-export with a node label, e.g. 'kubelet_running_pods;container_cpu_cfs_throttled_periods_total{namespace=\"prod\"}'")
+	labelValueCaps      = flag.String("label-value-caps", "", "Semicolon-separated caps on distinct passthrough label values as <label>=<max>[/<scope-label>], e.g. 'pod=500/namespace'; values beyond a cap are folded into \"other\"")
+	enableCustomTargets = flag.Bool("enable-custom-targets", false, "Scrape pods and Services annotated with binbots.io/scrape and re-export selected series per node")
+
+	scrapeConcurrency   = flag.Int("scrape-concurrency", 16, "Number of nodes whose kubelet/cAdvisor are scraped in parallel in the main cycle")
+	fastScrapeInterval  = flag.Duration("fast-scrape-interval", 0, "Interval of a separate cycle that lists nodes and pods for pod counts and node conditions; 0 computes them in the main cycle from its own listing. Without -informers each run adds a node and a pod LIST per replica")
+	stuckAfterIntervals = flag.Float64("stuck-after-intervals", 5, "Report a cycle as stuck (k8s_ai_exporter_stuck, failing /-/ready) when it has not completed for this many of its intervals; 0 disables the watchdog")
+	exitWhenStuck       = flag.Bool("exit-when-stuck", false, "Exit when a cycle is stuck, so Kubernetes restarts the container")
+	adaptiveInterval    = flag.Bool("adaptive-interval", false, "Stretch a cycle's interval while its runs take more than 80% of it, and shrink it back to the configured interval when they get faster")
+	adaptiveMaxFactor   = flag.Float64("adaptive-interval-max-factor", 4, "With -adaptive-interval, the longest interval a cycle stretches to, as a multiple of its configured interval")
+	onDemandMode        = flag.Bool("on-demand", false, "Run the cycles when /metrics is requested instead of every interval, unless the last run started within -cache-max-age, so Prometheus' scrape interval drives collection")
+	cacheMaxAge         = flag.Duration("cache-max-age", 15*time.Second, "With -on-demand, how long the metrics of a run are served before a /metrics request runs the cycles again")
+	throttleMaxLevel    = flag.Int("throttle-max-level", 3, "Highest degradation level while the API server throttles the exporter; level n stretches intervals by 2^n and skips expensive collectors. 0 disables degradation")
+	throttleRecovery    = flag.Duration("throttle-recovery", 2*time.Minute, "Time without throttling before the degradation level drops by one")
+
+	detailListenAddr     = flag.String("detail-listen-address", "", "Serve per-pod/per-workload families on this separate address instead of -listen-address")
+	detailScrapeInterval = flag.Duration("detail-scrape-interval", 0, "Interval for per-pod/per-workload collectors; 0 runs them on every -scrape-interval cycle")
+
+	eventsMax          = flag.Int("events-max", 1000, "Number of external events kept for /api/v1/events; older ones are dropped first")
+	maintenanceWindows = flag.String("maintenance-windows", "", "Semicolon-separated maintenance windows as <start>/<end>[=<reason>] with RFC 3339 times; more can be added with POST /api/v1/maintenance")
+	historyRetention   = flag.Duration("history-retention", 6*time.Hour, "How long snapshots are kept in memory for /api/v1/history and the web UI; 0 disables the history")
+	weekOverWeekSlot   = flag.Duration("week-over-week-slot", 0, "Keep a week of node and namespace usage averaged over slots of this length and export usage vs the same time last week; 0 disables")
+	apiToken           = flag.String("api-token", "", "Bearer token required to POST /api/v1/events, /api/v1/maintenance and /api/v1/scrape; empty disables those endpoints (403). Defaults to $API_TOKEN, which keeps it out of the process arguments")
+	scrapeTriggerMin   = flag.Duration("scrape-trigger-min-interval", 10*time.Second, "Minimum time between out-of-band scrapes requested with POST /api/v1/scrape; 0 disables the endpoint")
+
+	powerModels     = flag.String("power-models", "", "Semicolon-separated power models as <instance-type>=<idle-watts>:<max-watts>, with 'default' for other nodes; enables the energy and carbon estimates")
+	energySeries    = flag.String("energy-series", "node_rapl_package_joules_total,node_rapl_dram_joules_total", "Comma-separated cumulative joule counters that custom targets on a node report; when present they replace the power model for that node")
+	carbonIntensity = flag.Float64("carbon-intensity", 0, "Grid carbon intensity in grams of CO2 per kWh for the carbon estimates; 0 exports energy only")
+
+	saturationObjectives = flag.String("saturation-objectives", "cpu=0.9/0.99;memory=0.9/0.99", "Semicolon-separated saturation SLOs as <cpu|memory>=<threshold>/<target>: at least target of the samples are at or below threshold times allocatable; empty disables the saturation alerts")
+	saturationBurnRate   = flag.Float64("saturation-burn-rate", 14.4, "Burn rate of a saturation SLO's error budget over both 5m and 1h that fires its alert")
+	alertWebhookURL      = flag.String("alert-webhook-url", "", "POST saturation alerts as JSON to this URL when they fire or resolve; held back during maintenance windows")
+	alertWebhookSecret   = flag.String("alert-webhook-secret", "", "Sign -alert-webhook-url requests with HMAC-SHA256 like the agent's report webhook. Defaults to $ALERT_WEBHOOK_SECRET, which keeps it out of the process arguments")
+	alertTeamsURL        = flag.String("alert-teams-url", "", "Post saturation alerts as an Adaptive Card to this Microsoft Teams incoming webhook or Workflows URL. Defaults to $ALERT_TEAMS_URL, since the URL is a credential")
+	pagerDutyRoutingKey  = flag.String("alert-pagerduty-routing-key", "", "Send saturation alerts as PagerDuty Events v2 with this integration routing key; critical alerts page with severity critical. Defaults to $PAGERDUTY_ROUTING_KEY")
+	opsgenieAPIKey       = flag.String("alert-opsgenie-api-key", "", "Create and close Opsgenie alerts for saturation alerts with this API integration key; critical alerts are P1. Defaults to $OPSGENIE_API_KEY")
+	opsgenieURL          = flag.String("alert-opsgenie-url", alert.OpsgenieURL, "Opsgenie API URL, e.g. https://api.eu.opsgenie.com for EU accounts")
+	alertmanagerURLs     = flag.String("alertmanager-url", "", "Comma-separated Alertmanager base URLs that saturation alerts are sent to with the v2 API, re-sent every cycle while they fire, e.g. http://alertmanager.monitoring:9093")
+	alertmanagerLabels   = flag.String("alertmanager-labels", "", "Comma-separated <name>=<value> labels added to every alert sent to -alertmanager-url, e.g. cluster=prod, for routing")
+	sinkCheckInterval    = flag.Duration("sink-check-interval", time.Minute, "Interval of the health checks of the configured alert receivers, exported as k8s_ai_exporter_sink_healthy; 0 disables them")
+
+	serviceCIDR      = flag.String("service-cidr", "", "The API server's --service-cluster-ip-range (comma-separated for dual-stack); enables Service ClusterIP utilization metrics and needs list on services")
+	batchQueueFlag   = flag.Bool("batch-queues", false, "Export the workloads waiting in Kueue and Volcano queues and their estimated wait in the detail cycle; needs list on workloads.kueue.x-k8s.io and podgroups.scheduling.volcano.sh")
+	batchQueueWindow = flag.Duration("batch-queue-window", time.Hour, "Window of admissions from a batch queue that its drain rate, and so its estimated wait, is taken from")
+	nsBudgetFlag     = flag.Bool("namespace-budgets", false, "Compare each namespace's usage and requests with its NamespaceBudgets (binbots.io/v1alpha1) every main cycle, export k8s_namespace_budget_* and alert while a cap is exceeded; needs list on namespacebudgets.binbots.io")
+	costCoreHour     = flag.Float64("cost-cpu-core-hour", 0, "Price of a CPU core-hour that the monthlyCost caps of NamespaceBudgets are compared at; without prices cost caps are ignored")
+	costGiBHour      = flag.Float64("cost-memory-gib-hour", 0, "Price of a GiB-hour of memory for the monthlyCost caps of NamespaceBudgets")
+	poolLabel        = flag.String("pool-label", "", "Node label naming a node's pool in the k8s_pool_* families; empty tries the labels of Karpenter, EKS, GKE and AKS node pools")
+	infraNamespaces  = flag.String("infra-daemonset-namespaces", "kube-system", "Comma-separated namespaces whose DaemonSet pods count as per-node infrastructure in the k8s_node_infra_* families, besides static pods")
+	workloadExclude  = flag.String("workload-pods-exclude", "daemonset,mirror", "Comma-separated infrastructure pod kinds left out of k8s_node_workload_pods: daemonset, mirror (static pods)")
+	sidecarNames     = flag.String("sidecar-containers", "istio-proxy,linkerd-proxy,envoy,fluent-bit,fluentd,promtail,filebeat,vector", "Comma-separated container name globs counted as sidecars (container_type=\"sidecar\") besides native sidecar init containers")
+	cpuCreditsSeries = flag.String("cpu-credits-series", "", "Series that custom targets on burstable nodes report their CPU credit balance in, re-exported as k8s_node_cpu_credits_remaining")
+	dcgmSelector     = flag.String("dcgm-selector", "", "Label selector of the NVIDIA DCGM exporter pods, e.g. app=nvidia-dcgm-exporter; enables k8s_node_gpu_utilization and k8s_node_gpu_memory_used_bytes in the detail cycle and needs get on pods/proxy")
+	dcgmPort         = flag.Int("dcgm-port", 9400, "Port the DCGM exporter pods of -dcgm-selector serve /metrics on")
+	nodeExpSelector  = flag.String("node-exporter-selector", "", "Label selector of the Prometheus node_exporter pods, e.g. app.kubernetes.io/name=prometheus-node-exporter; enables the k8s_node_load_per_cpu, k8s_node_disk_io_utilization and k8s_node_network_utilization families in the detail cycle and needs get on pods/proxy")
+	nodeExpPort      = flag.Int("node-exporter-port", 9100, "Port the node_exporter pods of -node-exporter-selector serve /metrics on")
+	corednsSelector  = flag.String("coredns-selector", "", "Label selector of the CoreDNS pods, e.g. k8s-app=kube-dns; enables the k8s_dns_* families in the detail cycle and needs get on pods/proxy")
+	corednsPort      = flag.Int("coredns-port", 9153, "Port the CoreDNS pods of -coredns-selector serve /metrics on")
+	controlPlaneSpec = flag.String("control-plane-targets", "", "Comma-separated control plane components to scrape from their kube-system static pods, each <component>[=<port>]: scheduler (default port 10259), controller-manager (10257) and etcd (metrics listener, 2381); enables the k8s_control_plane_up, k8s_scheduler_* and k8s_etcd_* families in the detail cycle")
+	controlPlaneTLS  = flag.Bool("control-plane-insecure-skip-verify", false, "Do not verify the serving certificates of the scheduler and controller-manager, e.g. the self-signed ones kubeadm sets up; otherwise they are verified against the API server CA")
+	cloudInterrupt   = flag.Bool("cloud-interruptions", false, "Poll the instance metadata service of the node named by $NODE_NAME for spot preemption and maintenance notices, exported as k8s_node_interruption_pending; needs a build with the node's cloud provider")
+	annotatedSpec    = flag.String("annotated-series", "", "Semicolon-separated allowlist, in the syntax of -passthrough-series, of series to re-export with namespace and pod labels from the pods annotated prometheus.io/scrape: \"true\"; enables the annotated-pods collector in the detail cycle and needs get on pods/proxy")
+	podFilter        = flag.String("pod-filter", "", "Expression over a pod's fields that leaves the pods it is true for out of every collector, e.g. 'namespace == \"ci\" && labels[\"job-type\"] == \"load-test\"'; see the README for the syntax and variables")
+	nodeScore        = flag.String("node-score", "", "Expression over a node's usage, requests and allocatable exported as k8s_node_custom_score every main cycle, e.g. 'max(cpu_usage / cpu_allocatable, memory_usage / memory_allocatable)'")
+	execSpec         = flag.String("exec-collectors", "", "Semicolon-separated programs to run every detail cycle, each <name>=<path> [<arg>...], whose JSON output on stdout is re-exported with a collector=<name> label, e.g. 'san=/opt/collectors/san-stats --pool=a'")
+	execTimeout      = flag.Duration("exec-collector-timeout", 10*time.Second, "Time each program of -exec-collectors may run before it is killed")
+
+	permissionCheck   = flag.Bool("check-permissions", true, "Verify at startup that the ServiceAccount holds every permission the enabled collectors need")
+	requireReadOnly   = flag.Bool("require-read-only", false, "Refuse to start when the ServiceAccount can write to the API or read Secrets")
+	requireNonRoot    = flag.Bool("require-non-root", false, "Refuse to start when running as root or with effective Linux capabilities")
+	selfCheckEgress   = flag.String("selfcheck-egress", "", "Comma-separated host:port targets that /api/v1/selfcheck dials besides the API server, e.g. hosts a NetworkPolicy should block")
+	rbacNamespace     = flag.String("rbac-namespace", "monitoring", "Namespace of the ServiceAccount in gen-rbac output, and of every object in gen-manifests output")
+	manifestImage     = flag.String("manifest-image", "your-registry/k8s-ai-exporter:latest", "Exporter image in gen-manifests output")
+	manifestRelease   = flag.String("manifest-release-label", "prometheus-stack", "release label of the ServiceMonitor in gen-manifests output, which kube-prometheus-stack selects ServiceMonitors by")
+	estimateNodes     = flag.Int("estimate-nodes", 0, "Node count estimate scales the node families to; 0 uses the live cluster's, or the recording's nodes")
+	estimateSample    = flag.Int("estimate-sample", 3, "Nodes of the live cluster estimate scrapes to measure the node families")
+	estimateInterval  = flag.Duration("estimate-sample-interval", 10*time.Second, "Time between the two scrapes of each node estimate samples, which rates and counters need")
+	estimateRetention = flag.Duration("estimate-retention", 15*24*time.Hour, "Prometheus retention estimate computes storage for")
+	estimateBytes     = flag.Float64("estimate-bytes-per-sample", 2, "Compressed Prometheus storage per sample that estimate assumes; Prometheus typically needs 1 to 2 bytes")
+
+	tlsCertFile     = flag.String("web.tls-cert-file", "", "Serve HTTPS with this PEM certificate on all listeners; needs -web.tls-key-file")
+	tlsKeyFile      = flag.String("web.tls-key-file", "", "PEM private key for -web.tls-cert-file")
+	tlsMinVersion   = flag.String("tls-min-version", "1.2", "Minimum TLS version of the HTTPS listeners and the API server client: 1.2 or 1.3 (1.0 and 1.1 are accepted but not recommended)")
+	tlsCipherSuites = flag.String("tls-cipher-suites", "", "Comma-separated TLS 1.2 cipher suites (Go names, e.g. TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256) for the listeners and the API server client; default Go's secure suites")
+
+	devMode     = flag.Bool("dev", false, "Run from a workstation against a remote cluster: use a kubeconfig context, lower client QPS and print a node table every cycle instead of serving HTTP unless -listen-address is given")
+	kubeContext = flag.String("kube-context", "", "Kubeconfig context for -dev; default is the current context")
+
+	printMigratedConfig = flag.Bool("print-migrated-config", false, "Print the equivalent YAML config for the given flags (including deprecated ones) and exit")
+	configFile          = flag.String("config-file", "", "YAML file of flag values, keyed as -print-migrated-config prints them; flags on the command line take precedence")
+
+	// Hidden from -help, see faults.go.
+	faultFailureRatio  = flag.Float64("fault.failure-ratio", 0, "Fraction of kubelet/cAdvisor scrapes to fail without sending them")
+	faultDelay         = flag.Duration("fault.delay", 0, "Delay added to the scrapes picked by -fault.delay-ratio")
+	faultDelayRatio    = flag.Float64("fault.delay-ratio", 0, "Fraction of kubelet/cAdvisor scrapes to delay by -fault.delay")
+	faultTruncateRatio = flag.Float64("fault.truncate-ratio", 0, "Fraction of kubelet/cAdvisor scrape bodies to cut in half")
+)
+
+// State shared by the main and detail cycles.
+var (
+	metrics      = sink.NewPrometheus()
+	netRates     = aggregate.NewNetworkRates()
+	cpuRates     = aggregate.NewCPURates()
+	usage        = aggregate.NewUsageCounters()
+	classUsage   = aggregate.NewUsageCounters() // keyed by RuntimeClass
+	lastScrapes  = aggregate.NewLastScrapes()
+	typeUsage    = aggregate.NewUsageCounters() // keyed by aggregate.ContainerTypeKey
+	power        aggregate.PowerModels
+	sidecars     aggregate.SidecarPatterns
+	infraPods    map[string]bool // pod kinds left out of the workload pod count
+	infraNS      map[string]bool // namespaces of infrastructure DaemonSets
+	serviceNets  []netip.Prefix
+	labelGuard   *collector.CardinalityGuard
+	measured     *aggregate.MeasuredPower
+	batchQueues  *aggregate.BatchQueues
+	dcgmPods     labels.Selector // nil without -dcgm-selector
+	nodeExpPods  labels.Selector // nil without -node-exporter-selector
+	hostRates    = aggregate.NewHostRates()
+	corednsPods  labels.Selector // nil without -coredns-selector
+	annotated    collector.Rules // nil without -annotated-series
+	dnsRates     = aggregate.NewDNSRates()
+	controlPlane []collector.ControlPlaneComponent // empty without -control-plane-targets
+	cpRates      = aggregate.NewControlPlaneRates()
+	cpClient     *http.Client // ServiceAccount token, for the scheduler and controller-manager
+	cpPlain      *http.Client // no credentials, for etcd's HTTP metrics listener
+	constraints  = aggregate.NewConstraints()
+	snapshots    *api.Store
+	maintenance  = timeline.NewSchedule(1000)
+	recorder     *collector.Recorder
+	degrade      *degradation
+	kubelets     *kube.KubeletEndpoints // nil with -scrape-mode=proxy
+	usageMu      sync.Mutex             // serializes usage updates of the main cycle and single-node scrapes
+	weekNodes    *aggregate.WeekAgo
+	weekSpaces   *aggregate.WeekAgo
+	saturation   *alert.Saturation         // nil when -saturation-objectives is empty
+	notifiers    map[string]alert.Notifier // by name, empty without -alert-* receivers
+	alertmanager *alert.Alertmanager       // nil without -alertmanager-url
+	nsBudgets    *alert.NamespaceBudgets   // nil without -namespace-budgets
+	breakers     *collector.Breakers       // nil with -circuit-breaker-failures=0
+)
+
+func init() {
+	flag.Usage = usageWithoutHidden
+}
+
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "tui" {
+		if err := runTUI(os.Args[2:]); err != nil {
+			log.Fatalf("tui: %v", err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "replay" {
+		if err := runReplay(os.Stdout, os.Args[2:]); err != nil {
+			log.Fatalf("replay: %v", err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "gen-rbac" {
+		if err := genRBAC(os.Stdout, flag.CommandLine, os.Args[2:]); err != nil {
+			log.Fatalf("gen-rbac: %v", err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "estimate" {
+		if err := runEstimate(os.Stdout, flag.CommandLine, os.Args[2:]); err != nil {
+			log.Fatalf("estimate: %v", err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "gen-manifests" {
+		if err := genManifests(os.Stdout, flag.CommandLine, os.Args[2:]); err != nil {
+			log.Fatalf("gen-manifests: %v", err)
+		}
+		return
+	}
+	flag.Parse()
+	if *configFile != "" {
+		if err := loadConfig(flag.CommandLine, *configFile); err != nil {
+			log.Fatalf("-config-file: %v", err)
+		}
+	}
+
+	if *printMigratedConfig {
+		if err := writeMigratedConfig(os.Stdout, flag.CommandLine); err != nil {
+			log.Fatalf("cannot print migrated config: %v", err)
+		}
+		return
+	}
+
+	for _, s := range secretFlags {
+		secretFromEnv(flag.Lookup(s.name), s.env)
+	}
+
+	rules, err := collector.ParsePassthroughSpec(*passthroughSeries)
+	if err != nil {
+		log.Fatalf("invalid -passthrough-series: %v", err)
+	}
+
+	caps, err := collector.ParseLabelCaps(*labelValueCaps)
+	if err != nil {
+		log.Fatalf("invalid -label-value-caps: %v", err)
+	}
+	labelGuard = collector.NewCardinalityGuard(caps)
+
+	if sidecars, err = aggregate.ParseSidecarPatterns(*sidecarNames); err != nil {
+		log.Fatalf("invalid -sidecar-containers: %v", err)
+	}
+	if infraPods, err = aggregate.ParsePodKinds(*workloadExclude); err != nil {
+		log.Fatalf("invalid -workload-pods-exclude: %v", err)
+	}
+	infraNS = make(map[string]bool)
+	for _, ns := range strings.Split(*infraNamespaces, ",") {
+		if ns = strings.TrimSpace(ns); ns != "" {
+			infraNS[ns] = true
+		}
+	}
+	if power, err = aggregate.ParsePowerModels(*powerModels); err != nil {
+		log.Fatalf("invalid -power-models: %v", err)
+	}
+	// Readings older than three detail cycles are stale.
+	measured = aggregate.NewMeasuredPower(3 * max(*detailScrapeInterval, *scrapeInterval))
+	if *batchQueueWindow <= 0 {
+		log.Fatalf("-batch-queue-window must be positive")
+	}
+	batchQueues = aggregate.NewBatchQueues(*batchQueueWindow)
+	if *dcgmSelector != "" {
+		if dcgmPods, err = labels.Parse(*dcgmSelector); err != nil {
+			log.Fatalf("invalid -dcgm-selector: %v", err)
+		}
+		if *dcgmPort <= 0 || *dcgmPort > 65535 {
+			log.Fatalf("-dcgm-port must be a port number")
+		}
+	}
+	if *nodeExpSelector != "" {
+		if nodeExpPods, err = labels.Parse(*nodeExpSelector); err != nil {
+			log.Fatalf("invalid -node-exporter-selector: %v", err)
+		}
+		if *nodeExpPort <= 0 || *nodeExpPort > 65535 {
+			log.Fatalf("-node-exporter-port must be a port number")
+		}
+	}
+	if *corednsSelector != "" {
+		if corednsPods, err = labels.Parse(*corednsSelector); err != nil {
+			log.Fatalf("invalid -coredns-selector: %v", err)
+		}
+		if *corednsPort <= 0 || *corednsPort > 65535 {
+			log.Fatalf("-coredns-port must be a port number")
+		}
+	}
+	if controlPlane, err = collector.ParseControlPlaneTargets(*controlPlaneSpec); err != nil {
+		log.Fatalf("invalid -control-plane-targets: %v", err)
+	}
+	if annotated, err = collector.ParsePassthroughSpec(*annotatedSpec); err != nil {
+		log.Fatalf("invalid -annotated-series: %v", err)
+	}
+	if podExclude, err = compilePodFilter(*podFilter); err != nil {
+		log.Fatalf("invalid -pod-filter: %v", err)
+	}
+	if nodeScorer, err = compileNodeScore(*nodeScore); err != nil {
+		log.Fatalf("invalid -node-score: %v", err)
+	}
+	if execCollectors, err = collector.ParseExecCollectors(*execSpec); err != nil {
+		log.Fatalf("invalid -exec-collectors: %v", err)
+	}
+	if *execTimeout <= 0 {
+		log.Fatalf("-exec-collector-timeout must be positive")
+	}
+	if serviceNets, err = aggregate.ParseCIDRs(*serviceCIDR); err != nil {
+		log.Fatalf("invalid -service-cidr: %v", err)
+	}
+	if *carbonIntensity < 0 {
+		log.Fatalf("-carbon-intensity must not be negative")
+	}
+	objectives, err := alert.ParseObjectives(*saturationObjectives)
+	if err != nil {
+		log.Fatalf("invalid -saturation-objectives: %v", err)
+	}
+	if *saturationBurnRate <= 0 {
+		log.Fatalf("-saturation-burn-rate must be positive")
+	}
+	if len(objectives) > 0 {
+		saturation = alert.NewSaturation(objectives, *saturationBurnRate, "critical")
+	}
+	if *costCoreHour < 0 || *costGiBHour < 0 {
+		log.Fatalf("-cost-cpu-core-hour and -cost-memory-gib-hour must not be negative")
+	}
+	if *nsBudgetFlag {
+		// Budgets are soft caps: exceeding one warns, it does not page.
+		nsBudgets = alert.NewNamespaceBudgets("warning")
+	}
+
+	snapshots = api.NewStoreWithHistory(*historyRetention)
+	if *weekOverWeekSlot > 0 {
+		weekNodes = aggregate.NewWeekAgo(*weekOverWeekSlot)
+		weekSpaces = aggregate.NewWeekAgo(*weekOverWeekSlot)
+	}
+
+	windows, err := timeline.ParseWindows(*maintenanceWindows)
+	if err != nil {
+		log.Fatalf("invalid -maintenance-windows: %v", err)
+	}
+	for _, w := range windows {
+		w.Source = "config"
+		maintenance.Add(w)
+	}
+
+	if *scrapeInterval <= 0 {
+		log.Fatalf("-scrape-interval must be positive")
+	}
+	if *fastScrapeInterval < 0 {
+		log.Fatalf("-fast-scrape-interval must not be negative")
+	}
+	if *stuckAfterIntervals != 0 && *stuckAfterIntervals <= 1 {
+		log.Fatalf("-stuck-after-intervals must be 0 or greater than 1")
+	}
+	if *throttleMaxLevel < 0 || *throttleMaxLevel > 6 {
+		log.Fatalf("-throttle-max-level must be between 0 and 6")
+	}
+	if *weekOverWeekSlot < 0 || (*weekOverWeekSlot > 0 && *weekOverWeekSlot < time.Minute) || *weekOverWeekSlot > 24*time.Hour {
+		log.Fatalf("-week-over-week-slot must be 0 or between 1m and 24h")
+	}
+	if *scrapeConcurrency < 1 {
+		log.Fatalf("-scrape-concurrency must be at least 1")
+	}
+	if *scrapeSpread < 0 || *scrapeSpread >= *scrapeInterval {
+		log.Fatalf("-scrape-spread must not be negative and must be shorter than -scrape-interval")
+	}
+	if *scrapeTimeout < 0 || *scrapeTimeout > *scrapeInterval {
+		log.Fatalf("-scrape-timeout must not be negative or longer than -scrape-interval")
+	}
+	if *scrapeTimeout > 0 && *scrapeSpread >= *scrapeTimeout {
+		log.Fatalf("-scrape-spread must be shorter than -scrape-timeout")
+	}
+	if *scrapeRetries < 0 || *retryBackoff < 0 {
+		log.Fatalf("-scrape-retries and -scrape-retry-backoff must not be negative")
+	}
+	if *breakerFails < 0 || (*breakerFails > 0 && *breakerCool <= 0) {
+		log.Fatalf("-circuit-breaker-failures must not be negative and -circuit-breaker-cooldown must be positive")
+	}
+	if *scrapeTriggerMin < 0 {
+		log.Fatalf("-scrape-trigger-min-interval must not be negative")
+	}
+	if *adaptiveMaxFactor < 1 {
+		log.Fatalf("-adaptive-interval-max-factor must be at least 1")
+	}
+	if *onDemandMode && *cacheMaxAge <= 0 {
+		log.Fatalf("-cache-max-age must be positive")
+	}
+	if *onDemandMode && *adaptiveInterval {
+		log.Fatalf("-adaptive-interval has no intervals to adapt with -on-demand")
+	}
+	if *onDemandMode && *devMode && !flagSet(flag.CommandLine, "listen-address") {
+		log.Fatalf("-on-demand needs -listen-address in dev mode: nothing requests /metrics otherwise")
+	}
+	if *throttleRecovery <= 0 {
+		log.Fatalf("-throttle-recovery must be positive")
+	}
+	if *mode != "cluster" && *mode != "daemonset" {
+		log.Fatalf("-mode must be cluster or daemonset")
+	}
+	localNode := os.Getenv("NODE_NAME")
+	if *mode == "daemonset" {
+		if localNode == "" {
+			log.Fatalf("-mode=daemonset needs $NODE_NAME, set from the downward API field spec.nodeName")
+		}
+		// The local kubelet is reached directly: metrics traffic no longer
+		// goes through the API server.
+		if !flagSet(flag.CommandLine, "scrape-mode") {
+			*scrapeMode = "direct"
+		}
+	}
+	if *cloudInterrupt {
+		if localNode == "" {
+			log.Fatalf("-cloud-interruptions needs $NODE_NAME, set from the downward API field spec.nodeName")
+		}
+		if len(cloud.Names()) == 0 {
+			log.Fatalf("-cloud-interruptions: this binary was built without cloud providers")
+		}
+		interruptionNode, interruptionClient = localNode, cloud.MetadataClient(2*time.Second)
+	}
+	if *scrapeMode != "proxy" && *scrapeMode != "direct" && *scrapeMode != "readonly" {
+		log.Fatalf("-scrape-mode must be proxy, direct or readonly")
+	}
+	if *scrapeMode == "readonly" && !*insecureKubelet {
+		log.Fatalf("-scrape-mode=readonly scrapes kubelets over plain HTTP without authentication; set -allow-insecure-kubelet to accept that")
+	}
+	if *scrapeMode == "readonly" && !flagSet(flag.CommandLine, "kubelet-port") {
+		*kubeletPort = kube.DefaultReadOnlyKubeletPort
+	}
+	if *source != "metrics" && *source != "summary" {
+		log.Fatalf("-source must be metrics or summary")
+	}
+	if *kubeletPort <= 0 || *kubeletPort > 65535 {
+		log.Fatalf("-kubelet-port must be between 1 and 65535")
+	}
+
+	var cfg *rest.Config
+	if *devMode {
+		var context string
+		cfg, context, err = kube.DevConfig(*kubeContext)
+		if err != nil {
+			log.Fatalf("cannot load kubeconfig: %v", err)
+		}
+		log.Printf("dev mode: using kubeconfig context %q", context)
+	} else {
+		cfg, err = kube.Config()
+		if err != nil {
+			log.Fatalf("cannot create kube config: %v", err)
+		}
+	}
+
+	tlsPolicy, err := tlspolicy.Parse(*tlsMinVersion, *tlsCipherSuites)
+	if err != nil {
+		log.Fatalf("invalid TLS policy: %v", err)
+	}
+	if (*tlsCertFile == "") != (*tlsKeyFile == "") {
+		log.Fatalf("-web.tls-cert-file and -web.tls-key-file must be given together")
+	}
+	var kubeletCfg *rest.Config
+	if *scrapeMode == "direct" {
+		kubeletCfg = kube.KubeletConfig(cfg, *kubeletCAFile, *kubeletInsecure)
+		if *kubeletInsecure {
+			log.Printf("WARNING: kubelet serving certificates are not verified")
+		}
+	}
+	var controlPlaneCfg *rest.Config
+	if len(controlPlane) > 0 {
+		controlPlaneCfg = kube.KubeletConfig(cfg, "", *controlPlaneTLS)
+		if *controlPlaneTLS {
+			log.Printf("WARNING: control plane serving certificates are not verified")
+		}
+	}
+	if flagSet(flag.CommandLine, "tls-min-version") || *tlsCipherSuites != "" {
+		if err := kube.WithTLSPolicy(cfg, tlsPolicy); err != nil {
+			log.Fatalf("cannot apply TLS policy to the API server client: %v", err)
+		}
+		if kubeletCfg != nil {
+			if err := kube.WithTLSPolicy(kubeletCfg, tlsPolicy); err != nil {
+				log.Fatalf("cannot apply TLS policy to the kubelet client: %v", err)
+			}
+		}
+		if controlPlaneCfg != nil {
+			if err := kube.WithTLSPolicy(controlPlaneCfg, tlsPolicy); err != nil {
+				log.Fatalf("cannot apply TLS policy to the control plane client: %v", err)
+			}
+		}
+	}
+	if controlPlaneCfg != nil {
+		if cpClient, err = kube.KubeletClient(controlPlaneCfg); err != nil {
+			log.Fatalf("cannot create control plane client: %v", err)
+		}
+		cpPlain = kube.ReadOnlyKubeletClient()
+	}
+	notifiers = make(map[string]alert.Notifier)
+	if *alertWebhookURL != "" {
+		notifiers["webhook"] = &alert.Webhook{URL: *alertWebhookURL, Secret: *alertWebhookSecret, Client: tlsPolicy.HTTPClient()}
+	}
+	if *alertTeamsURL != "" {
+		notifiers["teams"] = &alert.Teams{URL: *alertTeamsURL, Client: tlsPolicy.HTTPClient()}
+	}
+	if *pagerDutyRoutingKey != "" {
+		notifiers["pagerduty"] = &alert.PagerDuty{RoutingKey: *pagerDutyRoutingKey, Client: tlsPolicy.HTTPClient()}
+	}
+	if *opsgenieAPIKey != "" {
+		notifiers["opsgenie"] = &alert.Opsgenie{APIKey: *opsgenieAPIKey, URL: *opsgenieURL, Client: tlsPolicy.HTTPClient()}
+	}
+	if *alertmanagerURLs != "" {
+		labels, err := alert.ParseLabels(*alertmanagerLabels)
+		if err != nil {
+			log.Fatalf("invalid -alertmanager-labels: %v", err)
+		}
+		alertmanager = &alert.Alertmanager{
+			URLs:   strings.Split(*alertmanagerURLs, ","),
+			Labels: labels,
+			// Outlasts four cycles at the longest interval degradation allows.
+			Hold:   4 * max(time.Minute, *scrapeInterval<<*throttleMaxLevel),
+			Client: tlsPolicy.HTTPClient(),
+		}
+	}
+	if *sinkCheckInterval < 0 {
+		log.Fatalf("-sink-check-interval must not be negative")
+	}
+	if checkers := sinkCheckers(); len(checkers) > 0 && *sinkCheckInterval > 0 {
+		go runSinkChecks(checkers, *sinkCheckInterval)
+	}
+	if fipsBuild {
+		log.Printf("FIPS mode: TLS is restricted to BoringCrypto-approved versions and cipher suites")
+	}
+
+	checker := newSelfChecker(cfg)
+	if !*devMode {
+		if err := checkPrivileges(checker); err != nil {
+			log.Fatalf("%v", err)
+		}
+	}
+
+	throttle := &kube.ThrottleMonitor{MaxWait: time.Second, OnThrottle: metrics.APIThrottled}
+	throttle.Instrument(cfg)
+
+	clientset, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		log.Fatalf("cannot create clientset: %v", err)
+	}
+	if *permissionCheck {
+		if err := checkPermissions(context.Background(), clientset); err != nil {
+			log.Fatalf("%v", err)
+		}
+	}
+
+	lister := &kube.Lister{Clientset: clientset, ExcludePhases: kube.ParsePhases(*excludePhases), Exclude: podExclude}
+	if *nodeSelector != "" {
+		lister.NodeSelector, err = labels.Parse(*nodeSelector)
+		if err != nil {
+			log.Fatalf("-node-selector: %v", err)
+		}
+	}
+	if *excludeCP {
+		lister.NodeSelector = kube.WithoutControlPlane(lister.NodeSelector)
+	}
+	if *mode == "daemonset" {
+		lister.NodeName = localNode
+		log.Printf("daemonset mode: scraping node %s only", localNode)
+	}
+	if *useInformers {
+		lister.Cache, err = kube.NewCache(clientset, make(chan struct{}))
+		if err != nil {
+			log.Fatalf("cannot start informers: %v", err)
+		}
+	}
+
+	client, baseURL, err := kube.ProxyClient(cfg)
+	if err != nil {
+		log.Fatalf("cannot create proxy client: %v", err)
+	}
+	if *breakerFails > 0 {
+		breakers = collector.NewBreakers(*breakerFails, *breakerCool)
+	}
+	scraper := &collector.Scraper{
+		Client:       client,
+		BaseURL:      baseURL,
+		Protobuf:     *scrapeProtobuf,
+		Gzip:         *scrapeGzip,
+		Passthrough:  rules,
+		Retries:      *scrapeRetries,
+		RetryBackoff: *retryBackoff,
+		OnRetry:      func(node, source string, err error) { metrics.ScrapeRetry(source + ":" + node) },
+	}
+	if kubeletCfg != nil {
+		scraper.DirectClient, err = kube.KubeletClient(kubeletCfg)
+		if err != nil {
+			log.Fatalf("cannot create kubelet client: %v", err)
+		}
+		kubelets = &kube.KubeletEndpoints{Port: *kubeletPort}
+		scraper.Direct = kubelets.URL
+		log.Printf("Scraping kubelets directly on port %d", *kubeletPort)
+	}
+	if *scrapeMode == "readonly" {
+		scraper.DirectClient = kube.ReadOnlyKubeletClient()
+		kubelets = &kube.KubeletEndpoints{Port: *kubeletPort, Scheme: "http"}
+		scraper.Direct = kubelets.URL
+		log.Printf("WARNING: scraping kubelets over plain HTTP without authentication on read-only port %d", *kubeletPort)
+	}
+	if faults := faultInjection(); faults.Enabled() {
+		log.Printf("WARNING: injecting faults into scrapes (failure %g, delay %s at %g, truncate %g); for testing only",
+			faults.FailureRatio, faults.Delay, faults.DelayRatio, faults.TruncateRatio)
+		client.Transport = faults.Wrap(client.Transport)
+		if scraper.DirectClient != nil {
+			scraper.DirectClient.Transport = faults.Wrap(scraper.DirectClient.Transport)
+		}
+	}
+	if *recordDir != "" {
+		recorder = &collector.Recorder{Dir: *recordDir}
+		scraper.Record = func(node, source, format string, body []byte) {
+			if err := recorder.Save(node, source, format, body); err != nil {
+				log.Printf("record %s %s: %v", source, node, err)
+			}
+		}
+		log.Printf("Recording scrape payloads to %s", *recordDir)
+	}
+
+	var detailReg *prometheus.Registry
+	detailRegisterer := prometheus.DefaultRegisterer
+	if *detailListenAddr != "" {
+		detailReg = prometheus.NewRegistry()
+		detailRegisterer = detailReg
+	}
+	if err := metrics.Register(prometheus.DefaultRegisterer, detailRegisterer); err != nil {
+		log.Fatalf("cannot register metrics: %v", err)
+	}
+
+	var cycles []cycle
+	if *fastScrapeInterval > 0 {
+		if !*useInformers {
+			log.Printf("-fast-scrape-interval without -informers lists all nodes and pods every %s on every replica", *fastScrapeInterval)
+		}
+		cycles = append(cycles, cycle{name: "pods", interval: *fastScrapeInterval, run: func(ctx context.Context, b *collector.Budget) error {
+			return collectPods(ctx, b, lister)
+		}})
+	}
+	cycles = append(cycles, cycle{name: "main", interval: *scrapeInterval, run: func(ctx context.Context, b *collector.Budget) error {
+		return scrapeAndAggregate(ctx, b, lister, scraper, clientset)
+	}})
+	if *detailScrapeInterval > 0 {
+		cycles = append(cycles, cycle{name: "detail", interval: *detailScrapeInterval, run: func(ctx context.Context, b *collector.Budget) error {
+			return scrapeDetail(ctx, b, lister, scraper, clientset)
+		}})
+	}
+	if *adaptiveInterval {
+		for i := range cycles {
+			cycles[i].maxInterval = time.Duration(*adaptiveMaxFactor * float64(cycles[i].interval))
+		}
+	}
+	if *throttleMaxLevel > 0 {
+		degrade = newDegradation(*throttleMaxLevel, *throttleRecovery)
+		tick := *fastScrapeInterval
+		if tick == 0 {
+			tick = *scrapeInterval
+		}
+		go degrade.run(throttle, tick)
+	}
+	var trigger api.ScrapeTrigger
+	if *scrapeTriggerMin > 0 {
+		st := newScrapeTrigger(cycles, *scrapeTriggerMin)
+		st.scrapeNode = func(ctx context.Context, node string) (api.NodeSnapshot, error) {
+			return rescrapeNode(ctx, lister, scraper, node)
+		}
+		trigger = st
+	}
+	var wd *watchdog
+	var od *onDemand
+	if *onDemandMode {
+		// Nothing runs between requests, so there is nothing to watch.
+		od = newOnDemand(cycles, *cacheMaxAge)
+		od.listen(context.Background())
+		log.Printf("Collecting on demand; /metrics reuses runs younger than %s", *cacheMaxAge)
+	} else {
+		if *stuckAfterIntervals > 0 {
+			wd = newWatchdog(*stuckAfterIntervals, cycles, time.Now())
+			go wd.run(time.Second, *exitWhenStuck, nil)
+		}
+		runCycles(context.Background(), cycles, wd)
+	}
+
+	if *devMode && !flagSet(flag.CommandLine, "listen-address") {
+		log.Printf("dev mode: printing a node table every %s; pass -listen-address to also serve /metrics", *scrapeInterval)
+		select {}
+	}
+
+	if detailReg != nil {
+		mux := http.NewServeMux()
+		var h http.Handler = promhttp.HandlerFor(detailReg, promhttp.HandlerOpts{})
+		if od != nil {
+			h = od.handler(h)
+		}
+		mux.Handle("/metrics", h)
+		go func() {
+			log.Printf("Serving detail metrics on %s", *detailListenAddr)
+			log.Fatal(listenAndServe(*detailListenAddr, mux, tlsPolicy))
+		}()
+	}
+
+	metricsHandler := promhttp.Handler()
+	if od != nil {
+		metricsHandler = od.handler(metricsHandler)
+	}
+	http.Handle("/metrics", metricsHandler)
+	http.Handle("/-/ready", wd)
+	http.Handle("/api/", api.NewHandler(api.Config{
+		Snapshots:   snapshots,
+		Events:      timeline.New(*eventsMax),
+		Maintenance: maintenance,
+		SelfCheck:   checker,
+		Scrape:      trigger,
+		Token:       *apiToken,
+	}))
+	http.Handle("/ui/", http.StripPrefix("/ui", ui.Handler()))
+	log.Printf("Starting exporter on %s (source=%s kubelet=%v cadvisor=%v)", *listenAddr, *source, *enableKubelet, *enableCadvisor)
+	log.Fatal(listenAndServe(*listenAddr, http.DefaultServeMux, tlsPolicy))
+}
+
+// secretFromEnv sets an empty secret flag from the environment variable
+// env. Secrets passed as arguments are visible to anyone who can read
+// /proc/<pid>/cmdline or run ps on the node.
+func secretFromEnv(f *flag.Flag, env string) {
+	if f.Value.String() == "" {
+		f.Value.Set(os.Getenv(env))
+	}
+}
+
+// listenAndServe serves h on addr, with HTTPS under policy when
+// -web.tls-cert-file is set.
+func listenAndServe(addr string, h http.Handler, policy tlspolicy.Policy) error {
+	srv := &http.Server{Addr: addr, Handler: h}
+	if *tlsCertFile == "" {
+		return srv.ListenAndServe()
+	}
+	srv.TLSConfig = policy.Config()
+	return srv.ListenAndServeTLS(*tlsCertFile, *tlsKeyFile)
+}
+
+// collectPods is the fast cycle of -fast-scrape-interval: pod counts and
+// node conditions only need the node and pod lists, which are free with
+// -informers but cost two LISTs per run without.
+func collectPods(ctx context.Context, budget *collector.Budget, lister *kube.Lister) error {
+	// Set before listing so a failing API server cannot hide a window.
+	metrics.SetMaintenanceWindows(len(maintenance.Active(time.Now())))
+
+	start := time.Now()
+	nodes, activePods, err := lister.List(ctx)
+	if err != nil {
+		return err
+	}
+	budget.Since(collector.PhaseAPIList, start)
+
+	start = time.Now()
+	observePods(ctx, &cycleState{lister: lister, budget: budget, nodes: nodes, pods: activePods})
+	budget.Since(collector.PhaseAggregate, start)
+	return nil
+}
+
+func init() {
+	registerBuiltin("pods", "pod-counts", "Export k8s_node_active_pods, k8s_node_workload_pods and the max-pods families", func(ctx context.Context, s *cycleState) error {
+		counts := aggregate.PodsPerNode(s.pods)
+		metrics.SetNodePods(counts)
+		metrics.SetNodeMaxPods(aggregate.MaxPods(s.nodes), counts, aggregate.PodDensity(s.nodes, counts))
+		metrics.SetNodeWorkloadPods(aggregate.WorkloadPodsPerNode(s.pods, infraPods))
+		return nil
+	})
+	registerBuiltin("pods", "runtimeclass-pods", "Export k8s_node_runtimeclass_pods", func(ctx context.Context, s *cycleState) error {
+		metrics.SetRuntimeClassPods(aggregate.RuntimeClassPods(s.pods))
+		return nil
+	})
+	registerBuiltin("pods", "node-conditions", "Export k8s_node_condition", func(ctx context.Context, s *cycleState) error {
+		metrics.SetNodeConditions(aggregate.NodeConditions(s.nodes))
+		return nil
+	})
+	registerBuiltin("pods", "cloud-info", "Export k8s_node_cloud_info", func(ctx context.Context, s *cycleState) error {
+		metrics.SetNodeCloudInfo(aggregate.NodesCloudInfo(s.nodes))
+		return nil
+	})
+	registerBuiltin("pods", "infra-pods", "Export the count and requests of static pods and -infra-daemonset-namespaces DaemonSet pods per node", func(ctx context.Context, s *cycleState) error {
+		metrics.SetInfraPods(aggregate.InfraPods(s.pods, infraNS))
+		return nil
+	})
+	registerBuiltin("pods", "pod-cidr", "Export the pod IP utilization of each node's podCIDR", func(ctx context.Context, s *cycleState) error {
+		metrics.SetPodCIDRUsage(aggregate.PodCIDRUsage(s.nodes, s.pods))
+		return nil
+	})
+
+	registerBuiltin("main", "service-cidr", "List Services for the ClusterIP utilization of -service-cidr", collectServiceCIDR)
+	registerBuiltin("main", "node-usage", "Scrape every node's kubelet or cAdvisor for usage, network rates, passthrough series and the API snapshot", collectNodeUsage)
+	registerBuiltin("main", "namespace-budgets", "Compare namespaces with the NamespaceBudgets of -namespace-budgets", collectNamespaceBudgets)
+}
+
+// observePods runs the collectors that only need the node and pod lists.
+func observePods(ctx context.Context, s *cycleState) {
+	current := make(map[string]bool, len(s.nodes))
+	for _, node := range s.nodes {
+		current[node.Name] = true
+	}
+	runCollectors(ctx, newRegistry("pods", s))
+	metrics.RetainNodes(current)
+}
+
+// scrapeAndAggregate is the main cycle: it scrapes every node's kubelet or
+// cAdvisor and, without -detail-scrape-interval, runs the detail collectors.
+// Without -fast-scrape-interval it also exports the pod counts and node
+// conditions of the fast cycle from its own listing.
+func scrapeAndAggregate(ctx context.Context, budget *collector.Budget, lister *kube.Lister, scraper *collector.Scraper, clientset kubernetes.Interface) error {
+	start := time.Now()
+	if recorder != nil {
+		recorder.StartCycle(start)
+	}
+	if *fastScrapeInterval == 0 {
+		metrics.SetMaintenanceWindows(len(maintenance.Active(start)))
+	}
+	nodes, activePods, err := lister.List(ctx)
+	if err != nil {
+		// Keep serving the last known usage, flagged stale.
+		for _, node := range lastScrapes.Nodes() {
+			metrics.MarkNodeStale(node)
+		}
+		return err
+	}
+	budget.Since(collector.PhaseAPIList, start)
+	kubelets.Update(nodes)
+	s := &cycleState{lister: lister, scraper: scraper, clientset: clientset, budget: budget, nodes: nodes, pods: activePods}
+	if *fastScrapeInterval == 0 {
+		observePods(ctx, s)
+	}
+	if *detailScrapeInterval == 0 && !degrade.shedding() {
+		start := time.Now()
+		collectDetail(ctx, s)
+		budget.Since(collector.PhaseDetail, start)
+	}
+	runCollectors(ctx, newRegistry("main", s))
+	return nil
+}
+
+// collectServiceCIDR exports the ClusterIP utilization of -service-cidr.
+// Listing every Service is skipped while degraded.
+func collectServiceCIDR(ctx context.Context, s *cycleState) error {
+	if len(serviceNets) == 0 || degrade.shedding() {
+		return nil
+	}
+	start := time.Now()
+	defer s.budget.Since(collector.PhaseAPIList, start)
+	svcs, err := s.clientset.CoreV1().Services("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		metrics.ScrapeError("api:services")
+		return fmt.Errorf("list services: %w", err)
+	}
+	metrics.SetServiceCIDRUsage(aggregate.ServiceCIDRUsage(serviceNets, svcs.Items))
+	return nil
+}
+
+// collectNodeUsage scrapes every node and exports its usage, the
+// namespace usage and costs derived from the samples, and the API
+// snapshot. Nodes whose scrape failed keep their last usage, flagged stale.
+func collectNodeUsage(ctx context.Context, s *cycleState) error {
+	nodes, budget := s.nodes, s.budget
+	nodeCounts := aggregate.PodsPerNode(s.pods)
+	classes := aggregate.RuntimeClasses(s.pods)
+	types := aggregate.ContainerTypes(s.pods, sidecars)
+
+	nodeCPU := make(map[string]float64)
+	nodeMem := make(map[string]float64)
+	current := make(map[string]bool, len(nodes))
+	costs := make(map[string]aggregate.NamespaceCost)
+	usageInc := make(map[string]aggregate.UsageIncrease)
+	usageRates := make(map[string]aggregate.UsageRate)
+	typeInc := make(map[string]aggregate.UsageIncrease)
+
+	stale := make(map[string]bool)
+	fresh := make(map[string]aggregate.UsageRate)
+
+	var passthrough []collector.RawSeries
+	var sources []collector.ScrapeSource
+	unreachable, virtual := make(map[string]bool), make(map[string]bool)
+	nodeSources := make(map[string]string, len(nodes))
+	for i := range nodes {
+		nodeSources[nodes[i].Name] = nodeSourceFor(&nodes[i])
+		if *skipVirtual && aggregate.Virtual(&nodes[i]) {
+			virtual[nodes[i].Name] = true
+			continue
+		}
+		if *skipUnready {
+			u := aggregate.Unreachable(&nodes[i])
+			unreachable[nodes[i].Name] = u
+			metrics.SetNodeUnreachable(nodes[i].Name, u)
+		}
+	}
+	metrics.SetVirtualNodes(len(virtual))
+	scrapeCtx, cancel := ctx, context.CancelFunc(func() {})
+	if *scrapeTimeout > 0 {
+		scrapeCtx, cancel = context.WithTimeout(ctx, *scrapeTimeout)
+	}
+	results := scrapeNodes(scrapeCtx, nodes, *scrapeConcurrency, *scrapeSpread, func(name string) nodeScrape {
+		if virtual[name] {
+			// No source: neither scraped nor failed.
+			return nodeScrape{}
+		}
+		if unreachable[name] {
+			return nodeScrape{source: nodeSources[name], err: errNodeUnreachable}
+		}
+		if scrapeCtx.Err() != nil {
+			// Not started before the deadline: skip rather than fail the
+			// node, which would count against its circuit breaker.
+			return nodeScrape{source: nodeSources[name], err: errScrapeTimeout}
+		}
+		return scrapeNodeGuarded(scrapeCtx, s.scraper, name, nodeSources[name], budget)
+	})
+	timedOut := timedOutNodes(ctx, scrapeCtx, nodes, results)
+	cancel()
+	start := time.Now()
+	fallback := fallbackUsage(ctx, s.scraper, results)
+	budget.Since(collector.PhaseAPIList, start)
+	usageMu.Lock()
+	defer usageMu.Unlock()
+	for i, node := range nodes {
+		name := node.Name
+		current[name] = true
+		if virtual[name] {
+			// Not scraped: leave it out of the usage rather than export 0.
+			continue
+		}
+		nodeCPU[name] = 0
+		nodeMem[name] = 0
+		scraped, hasCPU := false, false
+
+		r := results[i]
+		err := r.err
+		if err == nil && r.source != "" {
+			err = isolate(r.source, func() error {
+				sample := r.sample
+				nodeCPU[name], hasCPU = cpuRates.Observe(name, sample.CPU, time.Now())
+				nodeMem[name] += sample.Mem
+				observeNodeSample(&node, r.source, sample, usageInc, usageRates)
+				observeRuntimeClassUsage(name, sample, classes)
+				observeContainerTypeUsage(name, sample, types, typeInc)
+				passthrough = append(passthrough, collector.WithNode(sample.Raw, name)...)
+				aggregate.AddNamespaceCosts(costs, sample)
+				sources = append(sources, scrapeSource(&node, r.source, sample))
+				scraped = true
+				return nil
+			})
+		}
+		if *scrapeTimeout > 0 {
+			metrics.SetNodeScrapeTimedOut(name, timedOut[name])
+		}
+		if err != nil {
+			if !errors.Is(err, collector.ErrCircuitOpen) && !errors.Is(err, errNodeUnreachable) && !timedOut[name] {
+				metrics.ScrapeError(r.source + ":" + name)
+				log.Printf("%s %s: %v", r.source, name, err)
+			}
+			if u, ok := fallback[name]; ok {
+				nodeCPU[name], nodeMem[name] = u.CPU, u.Mem
+				sources = append(sources, scrapeSource(&node, collector.SourceMetricsServer, collector.NodeSample{Format: collector.FormatJSON}))
+				scraped, hasCPU = true, true
+			}
+		}
+		if scraped && !hasCPU {
+			// The node's first scrape only primes its CPU counter; CPU is
+			// exported from the next one.
+			delete(nodeCPU, name)
+			metrics.SetNodeFreshness(name, time.Now(), false)
+		} else if scraped {
+			fresh[name] = aggregate.UsageRate{CPUCores: nodeCPU[name], MemoryBytes: nodeMem[name]}
+			last := aggregate.NodeScrape{CPU: nodeCPU[name], Mem: nodeMem[name], At: time.Now()}
+			lastScrapes.Store(name, last)
+			metrics.SetNodeFreshness(name, last.At, false)
+		} else if last, ok := lastScrapes.Load(name); ok {
+			nodeCPU[name], nodeMem[name] = last.CPU, last.Mem
+			metrics.SetNodeFreshness(name, last.At, true)
+			stale[name] = true
+		}
+	}
+
+	start = time.Now()
+	passthrough, overflowed := labelGuard.Apply(passthrough)
+	metrics.SetPassthrough(passthrough)
+	metrics.SetLabelOverflow(overflowed)
+	metrics.SetNodeUsage(nodeCPU, nodeMem)
+	metrics.SetNamespaceCosts(costs)
+	metrics.SetScrapeSources(sources)
+	metrics.AddNamespaceUsage(usageInc)
+	metrics.AddContainerTypeUsage(typeInc)
+	observeWeekOverWeek(fresh, usageRates)
+	for name := range virtual {
+		delete(nodeCounts, name)
+	}
+	snap := api.NewSnapshot(time.Now(), nodeCounts, nodeCPU, nodeMem)
+	snap.Namespaces = namespaceSnapshots(usageRates)
+	allocCPU := make(map[string]float64, len(nodes))
+	allocMem := make(map[string]float64, len(nodes))
+	for _, node := range nodes {
+		if virtual[node.Name] {
+			continue
+		}
+		allocCPU[node.Name] = node.Status.Allocatable.Cpu().AsApproximateFloat64()
+		allocMem[node.Name] = node.Status.Allocatable.Memory().AsApproximateFloat64()
+	}
+	snap.SetAllocatable(allocCPU, allocMem)
+	observeSaturation(fresh, allocCPU, allocMem, time.Now())
+	s.nsUsage = usageRates
+	snap.SetStale(stale)
+	snapshots.Set(snap)
+	if *devMode {
+		if err := writeSnapshotTable(os.Stdout, snap); err != nil {
+			log.Printf("print node table: %v", err)
+		}
+	}
+	metrics.RetainNodes(current)
+	netRates.Retain(current)
+	cpuRates.Retain(current)
+	usage.Retain(current)
+	classUsage.Retain(current)
+	lastScrapes.Retain(current)
+	breakers.Retain(current)
+	typeUsage.Retain(current)
+	measured.Retain(current)
+	saturation.Retain(current)
+	budget.Since(collector.PhaseAggregate, start)
+
+	return nil
+}
+
+// scrapeSource describes a successful scrape of node for
+// k8s_node_scrape_source_info.
+func scrapeSource(node *corev1.Node, source string, sample collector.NodeSample) collector.ScrapeSource {
+	return collector.ScrapeSource{
+		Node:             node.Name,
+		Source:           source,
+		Format:           sample.Format,
+		KubeletVersion:   node.Status.NodeInfo.KubeletVersion,
+		ContainerRuntime: node.Status.NodeInfo.ContainerRuntimeVersion,
+	}
+}
+
+// namespaceSnapshots builds the namespace list of a snapshot from rates.
+func namespaceSnapshots(rates map[string]aggregate.UsageRate) []api.NamespaceSnapshot {
+	cpu := make(map[string]float64, len(rates))
+	mem := make(map[string]float64, len(rates))
+	for ns, r := range rates {
+		cpu[ns] = r.CPUCores
+		mem[ns] = r.MemoryBytes
+	}
+	return api.NewNamespaceSnapshots(cpu, mem)
+}
+
+// observeWeekOverWeek exports the usage of freshly scraped nodes and of
+// namespaces relative to the same time last week, then records it for next
+// week.
+func observeWeekOverWeek(nodes, namespaces map[string]aggregate.UsageRate) {
+	now := time.Now()
+	metrics.SetWeekOverWeek(weekNodes.Ratios(nodes, now), weekSpaces.Ratios(namespaces, now))
+	weekNodes.Observe(nodes, now)
+	weekSpaces.Observe(namespaces, now)
+}
+
+// observeNodeSample feeds node's sample from source to the collectors that
+// work on one node at a time.
+func observeNodeSample(node *corev1.Node, source string, sample collector.NodeSample, totals map[string]aggregate.UsageIncrease, rates map[string]aggregate.UsageRate) {
+	if source == collector.SourceCadvisor || source == collector.SourceSummary {
+		if r, ok := netRates.Observe(node.Name, sample.Net, time.Now()); ok {
+			metrics.SetNetworkRates(node.Name, r)
+		}
+	}
+	metrics.SetScrapeBytes(node.Name, sample.Bytes, sample.Transferred)
+	switch source {
+	case collector.SourceCadvisor:
+		metrics.SetCadvisorSeries(node.Name, sample.Series())
+	case collector.SourceSummary:
+		metrics.SetNodeFs(node.Name, sample.Fs)
+	}
+	observeUsage(node, sample, totals, rates)
+}
+
+// observeRuntimeClassUsage exports node's usage per RuntimeClass over the
+// interval since its previous scrape, using classes to map its pods.
+func observeRuntimeClassUsage(node string, sample collector.NodeSample, classes map[collector.PodRef]string) {
+	inc, elapsed, ok := classUsage.Observe(node, aggregate.UsageByRuntimeClass(classes, sample.PodUsage()), time.Now())
+	if ok {
+		metrics.SetRuntimeClassUsage(node, aggregate.TotalUsageRates(inc, elapsed))
+	}
+}
+
+// observeContainerTypeUsage adds node's usage increase per namespace and
+// container type since its previous scrape to totals, using types to
+// classify its containers.
+func observeContainerTypeUsage(node string, sample collector.NodeSample, types map[collector.ContainerRef]string, totals map[string]aggregate.UsageIncrease) {
+	if inc, _, ok := typeUsage.Observe(node, aggregate.UsageByContainerType(types, sample.Containers), time.Now()); ok {
+		aggregate.AddUsage(totals, inc)
+	}
+}
+
+// observeUsage adds node's usage increase since its previous scrape to
+// totals, its average namespace usage over that interval to rates, and
+// exports its energy: measured by a node agent when available, otherwise
+// estimated with -power-models.
+func observeUsage(node *corev1.Node, sample collector.NodeSample, totals map[string]aggregate.UsageIncrease, rates map[string]aggregate.UsageRate) {
+	inc, elapsed, ok := usage.Observe(node.Name, sample.Usage, time.Now())
+	if !ok {
+		return
+	}
+	aggregate.AddUsage(totals, inc)
+	aggregate.AddUsageRates(rates, inc, elapsed)
+	if watts, ok := measured.Watts(node.Name, time.Now()); ok {
+		e := aggregate.AttributeEnergy(watts, inc, elapsed)
+		e.Measured = true
+		metrics.AddNodeEnergy(node.Name, e, *carbonIntensity)
+	} else if model, ok := power.ForNode(node); ok {
+		capacity := node.Status.Capacity.Cpu().AsApproximateFloat64()
+		metrics.AddNodeEnergy(node.Name, aggregate.EstimateEnergy(model, capacity, inc, elapsed), *carbonIntensity)
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/your-org/k8s-ai-exporter/aggregate"
+	"github.com/your-org/k8s-ai-exporter/api"
+	"github.com/your-org/k8s-ai-exporter/collector"
+	"github.com/your-org/k8s-ai-exporter/sink"
+)
+
+func TestCollectNodeUsageSkipsVirtualNodes(t *testing.T) {
+	savedMetrics, savedSnapshots, savedGuard, savedMeasured := metrics, snapshots, labelGuard, measured
+	defer func() {
+		metrics, snapshots = savedMetrics, savedSnapshots
+		labelGuard, measured = savedGuard, savedMeasured
+	}()
+	metrics, snapshots = sink.NewPrometheus(), api.NewStore()
+	labelGuard, measured = collector.NewCardinalityGuard(nil), aggregate.NewMeasuredPower(time.Minute)
+
+	fargate := corev1.Node{ObjectMeta: metav1.ObjectMeta{
+		Name:   "fargate-ip-10-0-1-7",
+		Labels: map[string]string{"eks.amazonaws.com/compute-type": "fargate"},
+	}}
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "api-0"},
+		Spec:       corev1.PodSpec{NodeName: fargate.Name},
+	}
+	s := &cycleState{nodes: []corev1.Node{fargate}, pods: []corev1.Pod{pod}}
+	if err := collectNodeUsage(context.Background(), s); err != nil {
+		t.Fatal(err)
+	}
+
+	reg := prometheus.NewRegistry()
+	if err := metrics.Register(reg, reg); err != nil {
+		t.Fatal(err)
+	}
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range families {
+		switch f.GetName() {
+		case "k8s_node_cpu_usage_cores", "k8s_node_memory_usage_bytes":
+			t.Errorf("%s exported for a virtual node: %v", f.GetName(), f.GetMetric())
+		}
+	}
+	snap, ok := snapshots.Get()
+	if !ok {
+		t.Fatal("no snapshot")
+	}
+	if len(snap.Nodes) != 0 {
+		t.Errorf("snapshot nodes = %+v, want none", snap.Nodes)
+	}
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/yaml"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// flagMigration maps a deprecated flag to its replacement. The old name
+// keeps working: its value is forwarded to the new flag and the use is
+// reported as a log line and a metric.
+type flagMigration struct {
+	old, new string
+}
+
+// flagMigrations lists the renamed flags; none at the moment.
+var flagMigrations []flagMigration
+
+var deprecatedFlagUsed = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "k8s_ai_exporter_deprecated_flag_used",
+		Help: "1 for each deprecated command-line flag that was used at startup.",
+	},
+	[]string{"flag", "replacement"},
+)
+
+func init() {
+	prometheus.MustRegister(deprecatedFlagUsed)
+	registerFlagMigrations(flag.CommandLine, flagMigrations)
+}
+
+// registerFlagMigrations defines each deprecated flag on fs as an alias of
+// its replacement, which must already be defined.
+func registerFlagMigrations(fs *flag.FlagSet, migrations []flagMigration) {
+	for _, m := range migrations {
+		m := m
+		target := fs.Lookup(m.new)
+		if target == nil {
+			panic("flag migration to undefined flag -" + m.new)
+		}
+		fs.Var(&aliasValue{fs: fs, migration: m}, m.old, fmt.Sprintf("DEPRECATED: use -%s. %s", m.new, target.Usage))
+	}
+}
+
+// aliasValue is the value of a deprecated flag: it forwards to the
+// replacement.
+type aliasValue struct {
+	fs        *flag.FlagSet
+	migration flagMigration
+}
+
+func (a *aliasValue) String() string { return "" }
+
+func (a *aliasValue) Set(v string) error {
+	log.Printf("flag -%s is deprecated, use -%s instead", a.migration.old, a.migration.new)
+	deprecatedFlagUsed.WithLabelValues(a.migration.old, a.migration.new).Set(1)
+	return a.fs.Set(a.migration.new, v)
+}
+
+func isDeprecatedFlag(f *flag.Flag) bool {
+	_, ok := f.Value.(*aliasValue)
+	return ok
+}
+
+// configKey derives the config file path for a flag: dots separate
+// sections and dashed words become lowerCamelCase, so
+// -fault.failure-ratio maps to fault.failureRatio.
+func configKey(flagName string) []string {
+	parts := strings.Split(flagName, ".")
+	for i, p := range parts {
+		words := strings.Split(p, "-")
+		for j := 1; j < len(words); j++ {
+			if words[j] != "" {
+				words[j] = strings.ToUpper(words[j][:1]) + words[j][1:]
+			}
+		}
+		parts[i] = strings.Join(words, "")
+	}
+	return parts
+}
+
+// writeMigratedConfig prints the flags explicitly set on fs (after alias
+// forwarding) as the equivalent YAML config document.
+func writeMigratedConfig(w io.Writer, fs *flag.FlagSet) error {
+	type entry struct {
+		key   []string
+		value string
+	}
+	var entries []entry
+	fs.Visit(func(f *flag.Flag) {
+		if isDeprecatedFlag(f) || isHiddenFlag(f.Name) || f.Name == "print-migrated-config" || f.Name == "config-file" {
+			return
+		}
+		entries = append(entries, entry{configKey(f.Name), yamlScalar(f.Value)})
+	})
+	sort.Slice(entries, func(i, j int) bool {
+		return strings.Join(entries[i].key, ".") < strings.Join(entries[j].key, ".")
+	})
+
+	var open []string
+	for _, e := range entries {
+		// Close sections that differ from the previous key, then open new ones.
+		common := 0
+		for common < len(open) && common < len(e.key)-1 && open[common] == e.key[common] {
+			common++
+		}
+		open = open[:common]
+		for _, section := range e.key[common : len(e.key)-1] {
+			if _, err := fmt.Fprintf(w, "%s%s:\n", strings.Repeat("  ", len(open)), section); err != nil {
+				return err
+			}
+			open = append(open, section)
+		}
+		if _, err := fmt.Fprintf(w, "%s%s: %s\n", strings.Repeat("  ", len(open)), e.key[len(e.key)-1], e.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// yamlScalar renders a flag value: booleans and numbers as plain scalars,
+// everything else (including durations) double-quoted.
+func yamlScalar(v flag.Value) string {
+	if g, ok := v.(flag.Getter); ok {
+		switch g.Get().(type) {
+		case bool, int, int64, uint, uint64, float64:
+			return v.String()
+		}
+	}
+	return strconv.Quote(v.String())
+}
+
+// loadConfig sets the flags of fs that were not given on the command line
+// from the YAML config file at path, keyed as writeMigratedConfig prints
+// them. Unknown keys are errors so typos do not pass silently.
+func loadConfig(fs *flag.FlagSet, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var doc map[string]interface{}
+	if err := yaml.NewYAMLOrJSONDecoder(f, 4096).Decode(&doc); err != nil && err != io.EOF {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	byKey := make(map[string]*flag.Flag)
+	fs.VisitAll(func(f *flag.Flag) {
+		if !isDeprecatedFlag(f) && !isHiddenFlag(f.Name) {
+			byKey[strings.Join(configKey(f.Name), ".")] = f
+		}
+	})
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	values := make(map[string]string)
+	if err := flattenConfig(doc, "", values); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		f, ok := byKey[key]
+		if !ok {
+			return fmt.Errorf("%s: unknown key %s", path, key)
+		}
+		if set[f.Name] {
+			continue
+		}
+		if err := fs.Set(f.Name, values[key]); err != nil {
+			return fmt.Errorf("%s: %s: %w", path, key, err)
+		}
+	}
+	return nil
+}
+
+// flattenConfig collects the scalars of a decoded config document by their
+// dotted key.
+func flattenConfig(doc map[string]interface{}, prefix string, out map[string]string) error {
+	for k, v := range doc {
+		key := prefix + k
+		switch v := v.(type) {
+		case map[string]interface{}:
+			if err := flattenConfig(v, key+".", out); err != nil {
+				return err
+			}
+		case string:
+			out[key] = v
+		case bool:
+			out[key] = strconv.FormatBool(v)
+		case float64:
+			out[key] = strconv.FormatFloat(v, 'g', -1, 64)
+		case int64:
+			out[key] = strconv.FormatInt(v, 10)
+		default:
+			return fmt.Errorf("%s: want a string, number or boolean", key)
+		}
+	}
+	return nil
+}
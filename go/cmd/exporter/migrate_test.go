@@ -0,0 +1,107 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConfigKey(t *testing.T) {
+	tests := map[string][]string{
+		"web.telemetry-path":     {"web", "telemetryPath"},
+		"scrape-interval":        {"scrapeInterval"},
+		"detail-scrape-interval": {"detailScrapeInterval"},
+		"enable-kubelet":         {"enableKubelet"},
+	}
+	for name, want := range tests {
+		if got := configKey(name); !reflect.DeepEqual(got, want) {
+			t.Errorf("configKey(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestFlagMigrationAndConfig(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.String("listen-address", ":9100", "")
+	path := fs.String("web.telemetry-path", "/metrics", "")
+	fs.Duration("scrape-interval", 30*time.Second, "")
+	fs.Bool("enable-kubelet", true, "")
+	fs.Float64("fault.failure-ratio", 0, "")
+	registerFlagMigrations(fs, []flagMigration{{old: "telemetry-path", new: "web.telemetry-path"}})
+
+	if err := fs.Parse([]string{"-listen-address=:9200", "-scrape-interval=15s", "-enable-kubelet=false", "-telemetry-path=/m", "-fault.failure-ratio=0.5"}); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if *path != "/m" {
+		t.Errorf("deprecated alias not forwarded: web.telemetry-path = %q", *path)
+	}
+
+	var b strings.Builder
+	if err := writeMigratedConfig(&b, fs); err != nil {
+		t.Fatalf("writeMigratedConfig: %v", err)
+	}
+	want := `enableKubelet: false
+listenAddress: ":9200"
+scrapeInterval: "15s"
+web:
+  telemetryPath: "/m"
+`
+	if b.String() != want {
+		t.Errorf("writeMigratedConfig =\n%s\nwant\n%s", b.String(), want)
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	newFlags := func() (*flag.FlagSet, *string, *time.Duration, *int, *bool, *string) {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		return fs,
+			fs.String("listen-address", ":9100", ""),
+			fs.Duration("scrape-interval", 30*time.Second, ""),
+			fs.Int("scrape-concurrency", 16, ""),
+			fs.Bool("enable-kubelet", true, ""),
+			fs.String("web.telemetry-path", "/metrics", "")
+	}
+
+	// What -print-migrated-config writes loads back to the same values.
+	src, _, _, _, _, _ := newFlags()
+	if err := src.Parse([]string{"-scrape-interval=15s", "-scrape-concurrency=4", "-enable-kubelet=false", "-web.telemetry-path=/m"}); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	var b strings.Builder
+	if err := writeMigratedConfig(&b, src); err != nil {
+		t.Fatalf("writeMigratedConfig: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "exporter.yaml")
+	if err := os.WriteFile(path, []byte(b.String()+"listenAddress: \":9300\"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	fs, addr, interval, concurrency, kubelet, telemetry := newFlags()
+	if err := fs.Parse([]string{"-listen-address=:9200"}); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if err := loadConfig(fs, path); err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+	if *interval != 15*time.Second || *concurrency != 4 || *kubelet || *telemetry != "/m" {
+		t.Errorf("loaded %v %d %v %q, want 15s 4 false /m", *interval, *concurrency, *kubelet, *telemetry)
+	}
+	if *addr != ":9200" {
+		t.Errorf("listen-address = %q, want the command line's :9200", *addr)
+	}
+
+	if err := os.WriteFile(path, []byte("scrapeIntervall: 10s\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	fs, _, _, _, _, _ = newFlags()
+	if err := loadConfig(fs, path); err == nil || !strings.Contains(err.Error(), "unknown key scrapeIntervall") {
+		t.Errorf("loadConfig with a typo = %v, want an unknown key error", err)
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/your-org/k8s-ai-exporter/aggregate"
+)
+
+// collectNamespaceBudgets lists the NamespaceBudgets, compares each
+// namespace's usage from the node-usage collector and its pod requests
+// with them, and sends the budget alerts that fired or resolved. It runs
+// after node-usage, whose namespace usage it needs.
+func collectNamespaceBudgets(ctx context.Context, s *cycleState) error {
+	if nsBudgets == nil {
+		return nil
+	}
+	budgets, err := s.scraper.NamespaceBudgets(ctx)
+	if err != nil {
+		metrics.ScrapeError("api:namespace-budgets")
+		return err
+	}
+	prices := aggregate.Prices{CPUCoreHour: *costCoreHour, MemoryGiBHour: *costGiBHour}
+	uses := aggregate.NamespaceBudgetUses(budgets, s.nsUsage, s.pods, prices)
+	metrics.SetNamespaceBudgets(uses)
+	now := time.Now()
+	changes := nsBudgets.Observe(uses, now)
+	for _, a := range changes {
+		log.Printf("alert %s %s: namespace %s %s (%.4g of %.4g)", a.Name, a.Status(), a.Namespace, a.Resource, a.Value, a.Threshold)
+	}
+	sendAlerts(changes, nsBudgets.Firing(), now)
+	return nil
+}
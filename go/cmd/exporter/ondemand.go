@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// onDemand runs the cycles of -on-demand when /metrics is requested
+// instead of on tickers, so Prometheus' scrape interval drives collection.
+// A request within maxAge of the previous run is served from what that run
+// exported. Requests that arrive during a run wait for it and share it.
+type onDemand struct {
+	cycles []cycle
+	maxAge time.Duration
+	now    func() time.Time
+
+	mu   sync.Mutex // held for a run
+	last time.Time  // start of the last run
+}
+
+func newOnDemand(cycles []cycle, maxAge time.Duration) *onDemand {
+	return &onDemand{cycles: cycles, maxAge: maxAge, now: time.Now}
+}
+
+// refresh runs every cycle, in order, unless the last run started within
+// maxAge, stretched while degraded. It reports whether it ran them.
+func (o *onDemand) refresh(ctx context.Context) bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	start := o.now()
+	if !o.last.IsZero() && start.Sub(o.last) < degrade.stretch(o.maxAge) {
+		return false
+	}
+	for _, c := range o.cycles {
+		runCycle(ctx, c, o.maxAge)
+	}
+	o.last = start
+	return true
+}
+
+// handler refreshes the metrics before h serves them. The run is not tied
+// to the request: one that Prometheus gives up on still completes and
+// serves the next request.
+func (o *onDemand) handler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		o.refresh(context.WithoutCancel(r.Context()))
+		h.ServeHTTP(w, r)
+	})
+}
+
+// listen runs a cycle whenever it is triggered through POST
+// /api/v1/scrape; see newScrapeTrigger. It returns when ctx is done.
+func (o *onDemand) listen(ctx context.Context) {
+	for _, c := range o.cycles {
+		if c.trigger == nil {
+			continue
+		}
+		c := c
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-c.trigger:
+					o.mu.Lock()
+					runCycle(ctx, c, o.maxAge)
+					o.mu.Unlock()
+				}
+			}
+		}()
+	}
+}
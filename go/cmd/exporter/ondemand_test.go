@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/your-org/k8s-ai-exporter/collector"
+)
+
+func TestOnDemand(t *testing.T) {
+	var mu sync.Mutex
+	runs := map[string]int{}
+	count := func(name string) cycle {
+		return cycle{name: name, run: func(ctx context.Context, b *collector.Budget) error {
+			mu.Lock()
+			defer mu.Unlock()
+			runs[name]++
+			return nil
+		}}
+	}
+	o := newOnDemand([]cycle{count("main"), count("detail")}, 15*time.Second)
+	now := time.Unix(1700000000, 0)
+	o.now = func() time.Time { return now }
+
+	if !o.refresh(context.Background()) {
+		t.Fatal("first request did not run the cycles")
+	}
+	now = now.Add(10 * time.Second)
+	if o.refresh(context.Background()) {
+		t.Error("request within -cache-max-age ran the cycles again")
+	}
+	now = now.Add(5 * time.Second)
+
+	// Concurrent requests share one run.
+	h := o.handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		}()
+	}
+	wg.Wait()
+	if runs["main"] != 2 || runs["detail"] != 2 {
+		t.Errorf("runs = %v, want 2 of each cycle", runs)
+	}
+}
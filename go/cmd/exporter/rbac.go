@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/your-org/k8s-ai-exporter/kube"
+)
+
+const rbacName = "k8s-ai-exporter"
+
+// enabledFeatures returns the permission-relevant settings from the flags.
+func enabledFeatures() kube.Features {
+	summary := *source == "summary"
+	scrapes := summary || *enableKubelet || *enableCadvisor
+	return kube.Features{
+		Informers:     *useInformers,
+		NodeProxy:     scrapes && *scrapeMode == "proxy",
+		NodeMetrics:   scrapes && !summary && *scrapeMode == "direct",
+		NodeStats:     scrapes && (summary || *windowsSummary) && *scrapeMode == "direct",
+		MetricsServer: scrapes && *metricsFallback,
+		CustomTargets: *enableCustomTargets,
+		GPU:           *dcgmSelector != "",
+		NodeExporter:  *nodeExpSelector != "",
+		CoreDNS:       *corednsSelector != "",
+		AnnotatedPods: *annotatedSpec != "",
+		ControlPlane:  *controlPlaneSpec != "",
+		ServiceCIDR:   *serviceCIDR != "",
+		BatchQueues:   *batchQueueFlag,
+		NSBudgets:     *nsBudgetFlag,
+	}
+}
+
+// genRBAC implements the gen-rbac subcommand: it parses args like the
+// exporter's own flags and prints the ClusterRole and ClusterRoleBinding
+// those settings need.
+func genRBAC(w io.Writer, fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := kube.WriteClusterRole(w, rbacName, kube.RequiredPermissions(enabledFeatures())); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, "---\n"); err != nil {
+		return err
+	}
+	return kube.WriteClusterRoleBinding(w, rbacName, *rbacNamespace)
+}
+
+// checkPermissions fails when the ServiceAccount lacks a permission the
+// enabled collectors need, and reports permissions beyond read-only access.
+func checkPermissions(ctx context.Context, clientset kubernetes.Interface) error {
+	_, missing, err := kube.AllowedPermissions(ctx, clientset, kube.RequiredPermissions(enabledFeatures()))
+	if err != nil {
+		return fmt.Errorf("permission self-check: %w (skip it with -check-permissions=false)", err)
+	}
+	if len(missing) > 0 {
+		var b strings.Builder
+		for _, p := range missing {
+			fmt.Fprintf(&b, "\n  - %s (%s)", p, p.Reason)
+		}
+		return fmt.Errorf("missing RBAC permissions for the enabled collectors:%s\nApply the output of `exporter gen-rbac` with the same flags, or disable the collectors that need them", b.String())
+	}
+
+	excess, _, err := kube.AllowedPermissions(ctx, clientset, kube.ReadOnlyProbes)
+	if err != nil {
+		return fmt.Errorf("permission self-check: %w (skip it with -check-permissions=false)", err)
+	}
+	if len(excess) > 0 {
+		names := make([]string, len(excess))
+		for i, p := range excess {
+			names[i] = p.String()
+		}
+		msg := "ServiceAccount is not read-only, it can " + strings.Join(names, ", ")
+		if *requireReadOnly {
+			return errors.New(msg + "; bind it to the ClusterRole from `exporter gen-rbac` only")
+		}
+		log.Printf("warning: %s; the exporter only needs the permissions printed by gen-rbac", msg)
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/your-org/k8s-ai-exporter/aggregate"
+	"github.com/your-org/k8s-ai-exporter/api"
+	"github.com/your-org/k8s-ai-exporter/collector"
+	"github.com/your-org/k8s-ai-exporter/sink"
+)
+
+// runReplay implements the replay subcommand: it runs the parsers and the
+// node and namespace aggregation over payloads saved with -record-dir and
+// prints a snapshot per recorded cycle, to check parser and aggregation
+// changes against production data offline.
+func runReplay(w io.Writer, args []string) error {
+	fs := flag.NewFlagSet("replay", flag.ContinueOnError)
+	passthrough := fs.String("passthrough-series", "", "Raw series allowlist to parse with, as for the exporter")
+	asJSON := fs.Bool("json", false, "Print each cycle's snapshot as a line of JSON, including namespace usage, instead of a node table")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: replay [flags] <record-dir>")
+	}
+	rules, err := collector.ParsePassthroughSpec(*passthrough)
+	if err != nil {
+		return fmt.Errorf("invalid -passthrough-series: %w", err)
+	}
+	cycles, err := collector.ReadRecording(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if len(cycles) == 0 {
+		return fmt.Errorf("no recorded cycles in %s", fs.Arg(0))
+	}
+	return replay(w, newReplayer(rules), cycles, *asJSON)
+}
+
+// replay aggregates cycles with r and writes a snapshot of each. Payloads
+// that fail to parse are logged and skipped, and make it return an error
+// once all cycles are written.
+func replay(w io.Writer, r *replayer, cycles []collector.RecordedCycle, asJSON bool) error {
+	enc := json.NewEncoder(w)
+	var failed, total int
+	for _, c := range cycles {
+		snap, errs := r.cycle(c)
+		for _, err := range errs {
+			log.Printf("%s %v", c.Time.Format(time.RFC3339), err)
+		}
+		failed += len(errs)
+		total += len(c.Payloads)
+		var err error
+		if asJSON {
+			err = enc.Encode(snap)
+		} else {
+			err = writeSnapshotTable(w, snap)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d payloads failed to parse", failed, total)
+	}
+	return nil
+}
+
+// replayer runs recorded cycles through the parsers and the aggregation
+// of the main cycle into a sink of its own. Node objects are not
+// recorded, so pod counts, allocatable resources, energy and the node
+// info labels of k8s_node_scrape_source_info are left out.
+type replayer struct {
+	rules    collector.Rules
+	metrics  *sink.Prometheus
+	netRates *aggregate.NetworkRates
+	cpuRates *aggregate.CPURates
+	usage    *aggregate.UsageCounters
+}
+
+func newReplayer(rules collector.Rules) *replayer {
+	return &replayer{
+		rules:    rules,
+		metrics:  sink.NewPrometheus(),
+		netRates: aggregate.NewNetworkRates(),
+		cpuRates: aggregate.NewCPURates(),
+		usage:    aggregate.NewUsageCounters(),
+	}
+}
+
+// cycle aggregates one recorded cycle and returns its snapshot and an
+// error for each payload that did not parse.
+func (r *replayer) cycle(c collector.RecordedCycle) (api.Snapshot, []error) {
+	nodeCPU := make(map[string]float64)
+	nodeMem := make(map[string]float64)
+	current := make(map[string]bool)
+	costs := make(map[string]aggregate.NamespaceCost)
+	usageInc := make(map[string]aggregate.UsageIncrease)
+	usageRates := make(map[string]aggregate.UsageRate)
+
+	var errs []error
+	var passthrough []collector.RawSeries
+	var sources []collector.ScrapeSource
+	for _, p := range c.Payloads {
+		sample, err := p.Parse(r.rules)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s %s: %w", p.Source, p.Node, err))
+			continue
+		}
+		current[p.Node] = true
+		if cores, ok := r.cpuRates.Observe(p.Node, sample.CPU, c.Time); ok {
+			nodeCPU[p.Node] = cores
+		}
+		nodeMem[p.Node] += sample.Mem
+		if p.Source == collector.SourceCadvisor || p.Source == collector.SourceSummary {
+			if rates, ok := r.netRates.Observe(p.Node, sample.Net, c.Time); ok {
+				r.metrics.SetNetworkRates(p.Node, rates)
+			}
+		}
+		switch p.Source {
+		case collector.SourceCadvisor:
+			r.metrics.SetCadvisorSeries(p.Node, sample.Series())
+		case collector.SourceSummary:
+			r.metrics.SetNodeFs(p.Node, sample.Fs)
+		}
+		if inc, elapsed, ok := r.usage.Observe(p.Node, sample.Usage, c.Time); ok {
+			aggregate.AddUsage(usageInc, inc)
+			aggregate.AddUsageRates(usageRates, inc, elapsed)
+		}
+		passthrough = append(passthrough, collector.WithNode(sample.Raw, p.Node)...)
+		aggregate.AddNamespaceCosts(costs, sample)
+		sources = append(sources, collector.ScrapeSource{Node: p.Node, Source: p.Source, Format: sample.Format})
+	}
+
+	r.metrics.SetPassthrough(passthrough)
+	r.metrics.SetNodeUsage(nodeCPU, nodeMem)
+	r.metrics.SetNamespaceCosts(costs)
+	r.metrics.SetScrapeSources(sources)
+	r.metrics.AddNamespaceUsage(usageInc)
+	r.metrics.RetainNodes(current)
+	r.netRates.Retain(current)
+	r.cpuRates.Retain(current)
+	r.usage.Retain(current)
+
+	snap := api.NewSnapshot(c.Time, nil, nodeCPU, nodeMem)
+	snap.Namespaces = namespaceSnapshots(usageRates)
+	return snap, errs
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/your-org/k8s-ai-exporter/api"
+	"github.com/your-org/k8s-ai-exporter/collector"
+)
+
+func TestReplay(t *testing.T) {
+	payload := func(node, cpu string) collector.Payload {
+		body := `container_cpu_usage_seconds_total{container="app",namespace="shop",pod="p"} ` + cpu + "\n" +
+			`container_memory_working_set_bytes{container="app",namespace="shop",pod="p"} 1024` + "\n"
+		return collector.Payload{Node: node, Source: collector.SourceCadvisor, Format: collector.FormatText, Body: []byte(body)}
+	}
+	start := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	cycles := []collector.RecordedCycle{
+		{Time: start, Payloads: []collector.Payload{payload("node-a", "10")}},
+		{Time: start.Add(time.Minute), Payloads: []collector.Payload{payload("node-a", "70")}},
+	}
+
+	var out strings.Builder
+	if err := replay(&out, newReplayer(nil), cycles, true); err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	dec := json.NewDecoder(strings.NewReader(out.String()))
+	var snaps []api.Snapshot
+	for dec.More() {
+		var s api.Snapshot
+		if err := dec.Decode(&s); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		snaps = append(snaps, s)
+	}
+	if len(snaps) != 2 || len(snaps[1].Nodes) != 1 || snaps[1].Nodes[0].CPUCores != 1 {
+		t.Fatalf("snapshots = %+v, want two with node-a at 1 core", snaps)
+	}
+	if ns := snaps[1].Namespaces; len(ns) != 1 || ns[0].Name != "shop" || ns[0].CPUCores != 1 || ns[0].MemoryBytes != 1024 {
+		t.Errorf("namespaces = %+v, want shop at 1 core and 1024 bytes", ns)
+	}
+
+	cycles[1].Payloads = append(cycles[1].Payloads, collector.Payload{Node: "node-b", Format: collector.FormatProtobuf, Body: []byte("garbage")})
+	out.Reset()
+	if err := replay(&out, newReplayer(nil), cycles, false); err == nil || !strings.Contains(err.Error(), "1 of 3") {
+		t.Errorf("replay with a corrupt payload: err = %v, want 1 of 3 failed", err)
+	}
+	if !strings.Contains(out.String(), "TOTAL (1 nodes)") {
+		t.Errorf("table output missing the replayed cycles:\n%s", out.String())
+	}
+}
+
+func TestReplayHeatmapUsesCPURate(t *testing.T) {
+	// Summed counters of a node that has been up for an hour: 60 core-
+	// seconds over a minute is 1 core, half of its allocatable CPU.
+	payload := func(cpu string) collector.Payload {
+		body := `container_cpu_usage_seconds_total{container="app",namespace="shop",pod="p"} ` + cpu + "\n"
+		return collector.Payload{Node: "node-a", Source: collector.SourceCadvisor, Format: collector.FormatText, Body: []byte(body)}
+	}
+	start := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	r := newReplayer(nil)
+	var snaps []api.Snapshot
+	for i, cpu := range []string{"2823", "2883"} {
+		snap, errs := r.cycle(collector.RecordedCycle{Time: start.Add(time.Duration(i) * time.Minute), Payloads: []collector.Payload{payload(cpu)}})
+		if len(errs) > 0 {
+			t.Fatal(errs)
+		}
+		snap.SetAllocatable(map[string]float64{"node-a": 2}, nil)
+		snaps = append(snaps, snap)
+	}
+	h := api.NewHeatmap(api.ResourceCPU, snaps)
+	if got := h.Values[0][1]; got == nil || *got != 0.5 {
+		t.Errorf("CPU utilization after the second cycle = %v, want 0.5", got)
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+
+	"github.com/your-org/k8s-ai-exporter/aggregate"
+	"github.com/your-org/k8s-ai-exporter/api"
+	"github.com/your-org/k8s-ai-exporter/collector"
+	"github.com/your-org/k8s-ai-exporter/kube"
+)
+
+// rescrapeNode scrapes node name out of band for POST
+// /api/v1/scrape?node=. It updates the node's usage and freshness, the
+// namespace usage counters and the node's entry in the latest API
+// snapshot. Families that need the pod list (RuntimeClass and container
+// type breakdowns) or every node (costs, passthrough) wait for the next
+// main cycle.
+func rescrapeNode(ctx context.Context, lister *kube.Lister, scraper *collector.Scraper, name string) (api.NodeSnapshot, error) {
+	node, err := lister.Node(ctx, name)
+	if apierrors.IsNotFound(err) {
+		return api.NodeSnapshot{}, fmt.Errorf("%w %s", api.ErrUnknownNode, name)
+	}
+	if err != nil {
+		return api.NodeSnapshot{}, fmt.Errorf("get node %s: %w", name, err)
+	}
+	r := scrapeNode(ctx, scraper, name, nodeSourceFor(node), nil)
+	if r.source == "" {
+		return api.NodeSnapshot{}, errors.New("kubelet and cAdvisor scraping are disabled")
+	}
+	// An explicit request bypasses the breaker, and its outcome counts.
+	metrics.SetCircuitOpen(name, breakers.Record(name, r.err, time.Now()))
+	if r.err != nil {
+		metrics.ScrapeError(r.source + ":" + name)
+		return api.NodeSnapshot{}, fmt.Errorf("%s %s: %w", r.source, name, r.err)
+	}
+
+	usageMu.Lock()
+	defer usageMu.Unlock()
+	var cpu float64
+	err = isolate(r.source, func() error {
+		inc := make(map[string]aggregate.UsageIncrease)
+		observeNodeSample(node, r.source, r.sample, inc, make(map[string]aggregate.UsageRate))
+		metrics.AddNamespaceUsage(inc)
+		cores, ok := cpuRates.Observe(name, r.sample.CPU, time.Now())
+		if !ok {
+			// First scrape of the node: memory only, like the main cycle.
+			metrics.SetNodeUsage(nil, map[string]float64{name: r.sample.Mem})
+			metrics.SetNodeFreshness(name, time.Now(), false)
+			return nil
+		}
+		cpu = cores
+		metrics.SetNodeUsage(map[string]float64{name: cpu}, map[string]float64{name: r.sample.Mem})
+		last := aggregate.NodeScrape{CPU: cpu, Mem: r.sample.Mem, At: time.Now()}
+		lastScrapes.Store(name, last)
+		metrics.SetNodeFreshness(name, last.At, false)
+		return nil
+	})
+	if err != nil {
+		return api.NodeSnapshot{}, err
+	}
+	if n, ok := snapshots.UpdateNode(name, cpu, r.sample.Mem); ok {
+		return n, nil
+	}
+	// A node that joined since the last main cycle.
+	return api.NodeSnapshot{Name: name, CPUCores: cpu, MemoryBytes: r.sample.Mem}, nil
+}
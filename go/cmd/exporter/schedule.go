@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/your-org/k8s-ai-exporter/collector"
+)
+
+// cycle is one collection loop. Cycles run on independent tickers, so cheap
+// collectors keep their resolution while an expensive one is still busy.
+type cycle struct {
+	name     string // cycle label of the k8s_ai_exporter_cycle_* families
+	interval time.Duration
+	run      func(ctx context.Context, b *collector.Budget) error
+	trigger  chan struct{} // runs the cycle early; see newScrapeTrigger
+
+	// maxInterval, when set, lets the interval adapt to how long runs
+	// take, between interval and maxInterval; see adaptInterval.
+	maxInterval time.Duration
+}
+
+// Thresholds of adaptInterval: a run may take this share of the interval
+// before the interval grows, and the interval shrinks by at most
+// adaptShrink per run.
+const (
+	adaptBusy   = 0.8
+	adaptShrink = 0.75
+)
+
+// adaptInterval returns the interval after a run that took took at the
+// current interval cur. When the run took more than adaptBusy of cur, the
+// interval grows to where it would have taken adaptBusy, up to limit. When
+// the run was faster, the interval shrinks towards that point by at most a
+// quarter per run, down to base, so one fast run after a slow one does not
+// snap it back.
+func adaptInterval(cur, base, limit, took time.Duration) time.Duration {
+	target := time.Duration(float64(took) / adaptBusy)
+	if target > cur {
+		return min(target, limit)
+	}
+	return max(target, time.Duration(float64(cur)*adaptShrink), base)
+}
+
+// runCycles starts each cycle in its own goroutine. A cycle runs
+// immediately, then every interval (adapted to its runs when maxInterval
+// is set, and stretched while degraded) from the start of its previous
+// run, or right away when that run took longer or the cycle was
+// triggered. A panic that escapes the collectors of a cycle is recovered
+// and fails that run only. Completions are reported to wd, which may be
+// nil. The goroutines stop when ctx is done.
+func runCycles(ctx context.Context, cycles []cycle, wd *watchdog) {
+	for _, c := range cycles {
+		c := c
+		go func() {
+			interval := c.interval
+			for {
+				start := time.Now()
+				runCycle(ctx, c, interval)
+				if c.maxInterval > 0 {
+					if next := adaptInterval(interval, c.interval, c.maxInterval, time.Since(start)); next != interval {
+						log.Printf("%s cycle: interval %s -> %s", c.name, interval, next.Round(time.Millisecond))
+						interval = next
+						wd.setInterval(c.name, interval)
+					}
+				}
+				metrics.SetCycleInterval(c.name, degrade.stretch(interval))
+				wd.done(c.name, time.Now())
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(time.Until(start.Add(degrade.stretch(interval)))):
+				case <-c.trigger:
+				}
+			}
+		}()
+	}
+}
+
+// runCycle runs c once and exports how it went. interval is what the run
+// is measured against in the cycle families.
+func runCycle(ctx context.Context, c cycle, interval time.Duration) {
+	budget := collector.NewBudget()
+	err := isolate("cycle:"+c.name, func() error { return c.run(ctx, budget) })
+	if err != nil {
+		log.Printf("%s cycle: %v", c.name, err)
+	}
+	metrics.ObserveCycle(c.name, budget, interval)
+}
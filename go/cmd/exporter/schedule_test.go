@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/your-org/k8s-ai-exporter/collector"
+)
+
+func TestRunCyclesIndependent(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var fast, slow int32
+	release := make(chan struct{})
+	runCycles(ctx, []cycle{
+		{name: "fast", interval: time.Millisecond, run: func(context.Context, *collector.Budget) error {
+			atomic.AddInt32(&fast, 1)
+			return nil
+		}},
+		{name: "slow", interval: time.Millisecond, run: func(context.Context, *collector.Budget) error {
+			atomic.AddInt32(&slow, 1)
+			<-release
+			return nil
+		}},
+	}, nil)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for atomic.LoadInt32(&fast) < 5 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if n := atomic.LoadInt32(&fast); n < 5 {
+		t.Fatalf("fast cycle ran %d times while the slow one was blocked, want >= 5", n)
+	}
+	if n := atomic.LoadInt32(&slow); n != 1 {
+		t.Errorf("slow cycle ran %d times while blocked, want 1", n)
+	}
+	close(release)
+}
+
+func TestAdaptInterval(t *testing.T) {
+	const base, limit = 30 * time.Second, 2 * time.Minute
+	for _, tc := range []struct {
+		cur, took, want time.Duration
+	}{
+		{base, 10 * time.Second, base},                    // fast at the base
+		{base, 24 * time.Second, base},                    // exactly 80%
+		{base, 36 * time.Second, 45 * time.Second},        // slow: 36s is 80% of 45s
+		{base, 5 * time.Minute, limit},                    // capped
+		{time.Minute, 40 * time.Second, 50 * time.Second}, // shrinks to where 40s is 80%
+		{time.Minute, time.Second, 45 * time.Second},      // at most a quarter per run
+		{35 * time.Second, time.Second, base},             // never below the base
+	} {
+		if got := adaptInterval(tc.cur, base, limit, tc.took); got != tc.want {
+			t.Errorf("adaptInterval(%s, took %s) = %s, want %s", tc.cur, tc.took, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/your-org/k8s-ai-exporter/aggregate"
+	"github.com/your-org/k8s-ai-exporter/collector"
+)
+
+// errNodeUnreachable is the result of a node skipped by
+// -skip-unready-nodes.
+var errNodeUnreachable = errors.New("node not ready")
+
+// errScrapeTimeout is the result of a node whose scrape had not started
+// when the cycle hit -scrape-timeout.
+var errScrapeTimeout = errors.New("scrape timeout of the cycle exceeded")
+
+// nodeScrape is the outcome of scraping one node. Source is empty when
+// no source is enabled.
+type nodeScrape struct {
+	source string
+	sample collector.NodeSample
+	err    error
+}
+
+// scrapeNodes runs scrape for every node with at most concurrency calls in
+// flight. Workers hand their results back through a channel; they are
+// returned in the order of nodes so that aggregation stays deterministic.
+// With a positive spread, each node's scrape starts at its spreadOffset
+// into the window instead of all at once; the wait ends early when ctx is
+// done.
+func scrapeNodes(ctx context.Context, nodes []corev1.Node, concurrency int, spread time.Duration, scrape func(name string) nodeScrape) []nodeScrape {
+	if concurrency > len(nodes) {
+		concurrency = len(nodes)
+	}
+	type result struct {
+		i int
+		nodeScrape
+	}
+	start := time.Now()
+	order := make([]int, len(nodes))
+	offsets := make([]time.Duration, len(nodes))
+	for i := range nodes {
+		order[i] = i
+		offsets[i] = spreadOffset(nodes[i].Name, spread)
+	}
+	// Hand out jobs by offset, so no worker waits for a late node while an
+	// earlier one is due.
+	sort.SliceStable(order, func(a, b int) bool { return offsets[order[a]] < offsets[order[b]] })
+
+	jobs := make(chan int)
+	results := make(chan result)
+	for w := 0; w < concurrency; w++ {
+		go func() {
+			for i := range jobs {
+				if wait := time.Until(start.Add(offsets[i])); wait > 0 {
+					t := time.NewTimer(wait)
+					select {
+					case <-t.C:
+					case <-ctx.Done():
+						t.Stop()
+					}
+				}
+				results <- result{i, scrape(nodes[i].Name)}
+			}
+		}()
+	}
+	go func() {
+		for _, i := range order {
+			jobs <- i
+		}
+		close(jobs)
+	}()
+
+	out := make([]nodeScrape, len(nodes))
+	for range nodes {
+		r := <-results
+		out[r.i] = r.nodeScrape
+	}
+	return out
+}
+
+// spreadOffset returns where in a window of spread the scrape of node
+// starts. It is derived from a hash of the name, so a node keeps its slot
+// from cycle to cycle and the nodes are spread evenly over the window.
+func spreadOffset(node string, spread time.Duration) time.Duration {
+	if spread <= 0 {
+		return 0
+	}
+	h := fnv.New64a()
+	h.Write([]byte(node))
+	return time.Duration(h.Sum64() % uint64(spread))
+}
+
+// nodeSource returns the source nodes are scraped from: the Summary API
+// with -source=summary, otherwise cAdvisor, or the kubelet when cAdvisor
+// scraping is disabled. It is empty when no source is enabled.
+func nodeSource() string {
+	switch {
+	case *source == "summary":
+		return collector.SourceSummary
+	case *enableCadvisor:
+		return collector.SourceCadvisor
+	case *enableKubelet:
+		return collector.SourceKubelet
+	}
+	return ""
+}
+
+// nodeSourceFor returns the source node is scraped from: the Summary API
+// for Windows nodes with -windows-summary, whose cAdvisor and kubelet
+// series differ from Linux ones, the nodeSource otherwise.
+func nodeSourceFor(node *corev1.Node) string {
+	src := nodeSource()
+	if src != "" && *windowsSummary && aggregate.Windows(node) {
+		return collector.SourceSummary
+	}
+	return src
+}
+
+// scrapeNode fetches name's sample from source, as returned by
+// nodeSourceFor. It is safe for concurrent use.
+func scrapeNode(ctx context.Context, scraper *collector.Scraper, name, source string, budget *collector.Budget) nodeScrape {
+	r := nodeScrape{source: source}
+	if r.source == "" {
+		return r
+	}
+	r.err = isolate(r.source, func() (err error) {
+		switch r.source {
+		case collector.SourceSummary:
+			r.sample, err = scraper.Summary(ctx, name, budget)
+		case collector.SourceCadvisor:
+			r.sample, err = scraper.Cadvisor(ctx, name, budget)
+		default:
+			r.sample, err = scraper.Kubelet(ctx, name, budget)
+		}
+		return err
+	})
+	return r
+}
+
+// scrapeNodeGuarded is scrapeNode behind name's circuit breaker. While
+// the circuit is open the node is not scraped and the result carries
+// collector.ErrCircuitOpen; otherwise the outcome is recorded.
+func scrapeNodeGuarded(ctx context.Context, scraper *collector.Scraper, name, source string, budget *collector.Budget) nodeScrape {
+	if !breakers.Allow(name, time.Now()) {
+		return nodeScrape{source: source, err: collector.ErrCircuitOpen}
+	}
+	r := scrapeNode(ctx, scraper, name, source, budget)
+	if r.source != "" {
+		metrics.SetCircuitOpen(name, breakers.Record(name, r.err, time.Now()))
+	}
+	return r
+}
+
+// timedOutNodes returns the nodes of results whose scrape was cut short or
+// skipped because scrapeCtx, derived from ctx, hit its deadline. When there
+// are any, the overrun is counted and logged. Cancelling ctx itself, on
+// shutdown, is no overrun.
+func timedOutNodes(ctx, scrapeCtx context.Context, nodes []corev1.Node, results []nodeScrape) map[string]bool {
+	if ctx.Err() != nil || !errors.Is(scrapeCtx.Err(), context.DeadlineExceeded) {
+		return nil
+	}
+	timedOut := make(map[string]bool)
+	var names []string
+	for i, r := range results {
+		if errors.Is(r.err, errScrapeTimeout) || errors.Is(r.err, context.DeadlineExceeded) {
+			timedOut[nodes[i].Name] = true
+			names = append(names, nodes[i].Name)
+		}
+	}
+	if len(names) > 0 {
+		metrics.CycleOverrun("main")
+		log.Printf("scrape timeout of %s exceeded, %d nodes not scraped: %s", *scrapeTimeout, len(names), strings.Join(names, ", "))
+	}
+	return timedOut
+}
+
+// fallbackUsage lists node usage from metrics-server when
+// -metrics-server-fallback is set and a scrape in results failed. It
+// returns nil otherwise, or when metrics-server fails too.
+func fallbackUsage(ctx context.Context, scraper *collector.Scraper, results []nodeScrape) map[string]collector.NodeUsage {
+	if !*metricsFallback {
+		return nil
+	}
+	failed := false
+	for _, r := range results {
+		failed = failed || r.err != nil
+	}
+	if !failed {
+		return nil
+	}
+	var usage map[string]collector.NodeUsage
+	err := isolate(collector.SourceMetricsServer, func() (err error) {
+		usage, err = scraper.NodeMetrics(ctx)
+		return err
+	})
+	if err != nil {
+		metrics.ScrapeError("api:" + collector.SourceMetricsServer)
+		log.Printf("metrics-server fallback: %v", err)
+	}
+	return usage
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/your-org/k8s-ai-exporter/collector"
+)
+
+func TestScrapeNodes(t *testing.T) {
+	var nodes []corev1.Node
+	for i := 0; i < 20; i++ {
+		nodes = append(nodes, corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("node-%d", i)}})
+	}
+	for _, concurrency := range []int{1, 4, 50} {
+		var inFlight, peak int32
+		results := scrapeNodes(context.Background(), nodes, concurrency, 0, func(name string) nodeScrape {
+			n := atomic.AddInt32(&inFlight, 1)
+			for {
+				p := atomic.LoadInt32(&peak)
+				if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			atomic.AddInt32(&inFlight, -1)
+			return nodeScrape{source: name, sample: collector.NodeSample{CPU: 1}}
+		})
+		if len(results) != len(nodes) {
+			t.Fatalf("concurrency %d: %d results, want %d", concurrency, len(results), len(nodes))
+		}
+		for i, r := range results {
+			if r.source != nodes[i].Name {
+				t.Errorf("concurrency %d: results[%d] is from %s, want %s", concurrency, i, r.source, nodes[i].Name)
+			}
+		}
+		if max := int32(min(concurrency, len(nodes))); peak > max {
+			t.Errorf("concurrency %d: %d scrapes in flight", concurrency, peak)
+		}
+	}
+
+	if got := scrapeNodes(context.Background(), nil, 4, 0, nil); len(got) != 0 {
+		t.Errorf("scrapeNodes(nil) = %v", got)
+	}
+}
+
+func TestScrapeNodesSpread(t *testing.T) {
+	var nodes []corev1.Node
+	for i := 0; i < 10; i++ {
+		nodes = append(nodes, corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("node-%d", i)}})
+	}
+	const spread = 200 * time.Millisecond
+	start := time.Now()
+	results := scrapeNodes(context.Background(), nodes, 2, spread, func(name string) nodeScrape {
+		return nodeScrape{source: name, sample: collector.NodeSample{CPU: time.Since(start).Seconds()}}
+	})
+	for i, r := range results {
+		offset := spreadOffset(nodes[i].Name, spread)
+		if offset < 0 || offset >= spread {
+			t.Fatalf("offset of %s = %v, outside the %v window", nodes[i].Name, offset, spread)
+		}
+		if started := time.Duration(r.sample.CPU * float64(time.Second)); started < offset {
+			t.Errorf("%s scraped after %v, before its offset %v", nodes[i].Name, started, offset)
+		}
+	}
+	if spreadOffset("node-1", spread) != spreadOffset("node-1", spread) {
+		t.Error("offset not deterministic")
+	}
+
+	// A cancelled context ends the waits.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	start = time.Now()
+	scrapeNodes(ctx, nodes, 2, time.Hour, func(name string) nodeScrape { return nodeScrape{} })
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("cancelled spread scrape took %v", d)
+	}
+}
+
+func TestFallbackUsage(t *testing.T) {
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Write([]byte(`{"items": [{"metadata": {"name": "node-b"}, "usage": {"cpu": "500m", "memory": "1Gi"}}]}`))
+	}))
+	defer srv.Close()
+	scraper := &collector.Scraper{Client: srv.Client(), BaseURL: srv.URL}
+	ok := []nodeScrape{{source: collector.SourceCadvisor}, {source: collector.SourceCadvisor}}
+	failed := []nodeScrape{{source: collector.SourceCadvisor}, {source: collector.SourceCadvisor, err: errors.New("403")}}
+
+	defer func(v bool) { *metricsFallback = v }(*metricsFallback)
+	*metricsFallback = false
+	if got := fallbackUsage(context.Background(), scraper, failed); got != nil || requests != 0 {
+		t.Errorf("disabled: fallbackUsage = %v after %d requests, want nil after none", got, requests)
+	}
+	*metricsFallback = true
+	if got := fallbackUsage(context.Background(), scraper, ok); got != nil || requests != 0 {
+		t.Errorf("no failures: fallbackUsage = %v after %d requests, want nil after none", got, requests)
+	}
+	got := fallbackUsage(context.Background(), scraper, failed)
+	if want := (collector.NodeUsage{CPU: 0.5, Mem: 1 << 30}); got["node-b"] != want || requests != 1 {
+		t.Errorf("fallbackUsage = %v after %d requests, want node-b %v after 1", got, requests, want)
+	}
+}
+
+func TestScrapeNodeGuarded(t *testing.T) {
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		http.Error(w, "kubelet down", http.StatusBadGateway)
+	}))
+	defer srv.Close()
+	scraper := &collector.Scraper{Client: srv.Client(), BaseURL: srv.URL}
+
+	defer func(b *collector.Breakers) { breakers = b }(breakers)
+	breakers = collector.NewBreakers(2, time.Hour)
+	for i := 0; i < 2; i++ {
+		if r := scrapeNodeGuarded(context.Background(), scraper, "node-a", nodeSource(), nil); r.err == nil || errors.Is(r.err, collector.ErrCircuitOpen) {
+			t.Fatalf("scrape %d: err = %v, want the 502", i+1, r.err)
+		}
+	}
+	r := scrapeNodeGuarded(context.Background(), scraper, "node-a", nodeSource(), nil)
+	if !errors.Is(r.err, collector.ErrCircuitOpen) || r.source != collector.SourceCadvisor || requests != 2 {
+		t.Errorf("open circuit: %s %v after %d requests, want cadvisor ErrCircuitOpen after 2", r.source, r.err, requests)
+	}
+}
+
+func TestNodeSourceFor(t *testing.T) {
+	windows := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"kubernetes.io/os": "windows"}}}
+	linux := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"kubernetes.io/os": "linux"}}}
+
+	defer func(v bool) { *windowsSummary = v }(*windowsSummary)
+	*windowsSummary = false
+	if got := nodeSourceFor(windows); got != collector.SourceCadvisor {
+		t.Errorf("disabled: windows source = %q, want cadvisor", got)
+	}
+	*windowsSummary = true
+	if got := nodeSourceFor(windows); got != collector.SourceSummary {
+		t.Errorf("windows source = %q, want summary", got)
+	}
+	if got := nodeSourceFor(linux); got != collector.SourceCadvisor {
+		t.Errorf("linux source = %q, want cadvisor", got)
+	}
+
+	defer func(c, k bool) { *enableCadvisor, *enableKubelet = c, k }(*enableCadvisor, *enableKubelet)
+	*enableCadvisor, *enableKubelet = false, false
+	if got := nodeSourceFor(windows); got != "" {
+		t.Errorf("scraping disabled: windows source = %q, want none", got)
+	}
+}
+
+func TestTimedOutNodes(t *testing.T) {
+	var nodes []corev1.Node
+	for _, name := range []string{"fast", "slow", "queued"} {
+		nodes = append(nodes, corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name}})
+	}
+	ctx := context.Background()
+	scrapeCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	results := scrapeNodes(scrapeCtx, nodes, 1, 0, func(name string) nodeScrape {
+		if scrapeCtx.Err() != nil {
+			return nodeScrape{err: errScrapeTimeout}
+		}
+		if name == "slow" {
+			<-scrapeCtx.Done()
+			return nodeScrape{err: fmt.Errorf("get /metrics/cadvisor: %w", scrapeCtx.Err())}
+		}
+		return nodeScrape{}
+	})
+	got := timedOutNodes(ctx, scrapeCtx, nodes, results)
+	if want := map[string]bool{"slow": true, "queued": true}; !reflect.DeepEqual(got, want) {
+		t.Errorf("timedOutNodes = %v, want %v", got, want)
+	}
+
+	// Shutting down is no overrun.
+	parent, stop := context.WithCancel(ctx)
+	stop()
+	if got := timedOutNodes(parent, parent, nodes, results); got != nil {
+		t.Errorf("timedOutNodes after cancel = %v, want nil", got)
+	}
+}
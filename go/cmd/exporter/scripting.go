@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/your-org/k8s-ai-exporter/aggregate"
+	"github.com/your-org/k8s-ai-exporter/expr"
+	"github.com/your-org/k8s-ai-exporter/kube"
+)
+
+var (
+	podExclude func(*corev1.Pod) bool // nil without -pod-filter
+	nodeScorer *expr.Program          // nil without -node-score
+)
+
+func init() {
+	registerBuiltin("main", "node-score", "Export the -node-score expression as k8s_node_custom_score", collectNodeScores)
+}
+
+// podVars are the variables of -pod-filter.
+var podVars = expr.Vars{
+	"namespace":       expr.String,
+	"name":            expr.String,
+	"node":            expr.String,
+	"phase":           expr.String,
+	"qos":             expr.String,
+	"workload_kind":   expr.String,
+	"workload":        expr.String,
+	"labels":          expr.Labels,
+	"annotations":     expr.Labels,
+	"containers":      expr.Number,
+	"restarts":        expr.Number,
+	"priority":        expr.Number,
+	"age_seconds":     expr.Number,
+	"cpu_requests":    expr.Number, // cores
+	"memory_requests": expr.Number, // bytes
+}
+
+// nodeVars are the variables of -node-score.
+var nodeVars = expr.Vars{
+	"node":               expr.String,
+	"labels":             expr.Labels,
+	"unschedulable":      expr.Bool,
+	"pods":               expr.Number,
+	"cpu_usage":          expr.Number, // cores
+	"memory_usage":       expr.Number, // bytes
+	"cpu_allocatable":    expr.Number,
+	"memory_allocatable": expr.Number,
+	"cpu_requests":       expr.Number,
+	"memory_requests":    expr.Number,
+}
+
+// compilePodFilter returns the Lister.Exclude function of the -pod-filter
+// expression src, or nil when src is empty.
+func compilePodFilter(src string) (func(*corev1.Pod) bool, error) {
+	if src == "" {
+		return nil, nil
+	}
+	p, err := expr.Compile(src, podVars)
+	if err != nil {
+		return nil, err
+	}
+	if p.Type() != expr.Bool {
+		return nil, fmt.Errorf("is a %s, want a bool", p.Type())
+	}
+	return func(pod *corev1.Pod) bool { return p.Eval(podEnv(pod, time.Now())).(bool) }, nil
+}
+
+// compileNodeScore compiles the -node-score expression src, or returns nil
+// when src is empty.
+func compileNodeScore(src string) (*expr.Program, error) {
+	if src == "" {
+		return nil, nil
+	}
+	p, err := expr.Compile(src, nodeVars)
+	if err != nil {
+		return nil, err
+	}
+	if p.Type() != expr.Number {
+		return nil, fmt.Errorf("is a %s, want a number", p.Type())
+	}
+	return p, nil
+}
+
+func podEnv(p *corev1.Pod, now time.Time) expr.Env {
+	w := kube.PodWorkload(p)
+	cpu, mem := aggregate.PodRequests(p)
+	var restarts int32
+	for _, s := range p.Status.ContainerStatuses {
+		restarts += s.RestartCount
+	}
+	var priority float64
+	if p.Spec.Priority != nil {
+		priority = float64(*p.Spec.Priority)
+	}
+	return expr.Env{
+		"namespace":       p.Namespace,
+		"name":            p.Name,
+		"node":            p.Spec.NodeName,
+		"phase":           string(p.Status.Phase),
+		"qos":             string(p.Status.QOSClass),
+		"workload_kind":   w.Kind,
+		"workload":        w.Name,
+		"labels":          p.Labels,
+		"annotations":     p.Annotations,
+		"containers":      float64(len(p.Spec.Containers)),
+		"restarts":        float64(restarts),
+		"priority":        priority,
+		"age_seconds":     now.Sub(p.CreationTimestamp.Time).Seconds(),
+		"cpu_requests":    cpu,
+		"memory_requests": mem,
+	}
+}
+
+// collectNodeScores evaluates -node-score for every node whose usage is
+// known. This file sorts after main.go, so the collector is registered, and
+// runs, after node-usage and sees the usage of the same run.
+func collectNodeScores(ctx context.Context, s *cycleState) error {
+	if nodeScorer == nil {
+		return nil
+	}
+	pods := aggregate.PodsPerNode(s.pods)
+	reqCPU, reqMem := make(map[string]float64), make(map[string]float64)
+	for i := range s.pods {
+		cpu, mem := aggregate.PodRequests(&s.pods[i])
+		reqCPU[s.pods[i].Spec.NodeName] += cpu
+		reqMem[s.pods[i].Spec.NodeName] += mem
+	}
+	scores := make(map[string]float64, len(s.nodes))
+	for i := range s.nodes {
+		n := &s.nodes[i]
+		last, ok := lastScrapes.Load(n.Name)
+		if !ok {
+			continue
+		}
+		scores[n.Name] = nodeScorer.Eval(expr.Env{
+			"node":               n.Name,
+			"labels":             n.Labels,
+			"unschedulable":      n.Spec.Unschedulable,
+			"pods":               pods[n.Name],
+			"cpu_usage":          last.CPU,
+			"memory_usage":       last.Mem,
+			"cpu_allocatable":    n.Status.Allocatable.Cpu().AsApproximateFloat64(),
+			"memory_allocatable": n.Status.Allocatable.Memory().AsApproximateFloat64(),
+			"cpu_requests":       reqCPU[n.Name],
+			"memory_requests":    reqMem[n.Name],
+		}).(float64)
+	}
+	metrics.SetCustomScores(scores)
+	return nil
+}
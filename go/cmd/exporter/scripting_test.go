@@ -0,0 +1,45 @@
+package main
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCompilePodFilter(t *testing.T) {
+	exclude, err := compilePodFilter(`namespace == "ci" && labels["job-type"] == "load-test" || restarts > 10`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pod := func(ns string, labels map[string]string, restarts int32) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: ns, Name: "p", Labels: labels},
+			Status:     corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{RestartCount: restarts}}},
+		}
+	}
+	tests := []struct {
+		pod  *corev1.Pod
+		want bool
+	}{
+		{pod("ci", map[string]string{"job-type": "load-test"}, 0), true},
+		{pod("ci", map[string]string{"job-type": "unit"}, 0), false},
+		{pod("prod", nil, 11), true},
+		{pod("prod", nil, 0), false},
+	}
+	for _, tt := range tests {
+		if got := exclude(tt.pod); got != tt.want {
+			t.Errorf("exclude(%s %v restarts %d) = %v, want %v", tt.pod.Namespace, tt.pod.Labels, tt.pod.Status.ContainerStatuses[0].RestartCount, got, tt.want)
+		}
+	}
+
+	if f, err := compilePodFilter(""); f != nil || err != nil {
+		t.Errorf("compilePodFilter(\"\") = %v, %v, want nil, nil", f != nil, err)
+	}
+	if _, err := compilePodFilter(`cpu_requests * 2`); err == nil {
+		t.Error("compilePodFilter accepted a number expression")
+	}
+	if _, err := compileNodeScore(`unschedulable`); err == nil {
+		t.Error("compileNodeScore accepted a bool expression")
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"k8s.io/client-go/rest"
+
+	"github.com/your-org/k8s-ai-exporter/selfcheck"
+)
+
+// newSelfChecker probes the root filesystem and temp dir, the API server
+// the exporter depends on and the -selfcheck-egress targets.
+func newSelfChecker(cfg *rest.Config) *selfcheck.Checker {
+	c := &selfcheck.Checker{Paths: []string{"/", os.TempDir()}}
+	if target := apiServerAddr(cfg.Host); target != "" {
+		c.Egress = append(c.Egress, target)
+	}
+	for _, t := range strings.Split(*selfCheckEgress, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			c.Egress = append(c.Egress, t)
+		}
+	}
+	return c
+}
+
+// apiServerAddr returns the host:port of a rest.Config host URL.
+func apiServerAddr(host string) string {
+	u, err := url.Parse(host)
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	if u.Port() != "" {
+		return u.Host
+	}
+	port := "443"
+	if u.Scheme == "http" {
+		port = "80"
+	}
+	return net.JoinHostPort(u.Hostname(), port)
+}
+
+// checkPrivileges runs the self-check at startup and logs its findings;
+// with -require-non-root it fails when the exporter runs as root or holds
+// capabilities. Nothing the exporter does needs either.
+func checkPrivileges(c *selfcheck.Checker) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	r := c.Run(ctx)
+	for _, f := range r.Findings {
+		log.Printf("self-check: %s", f)
+	}
+	for _, e := range r.Egress {
+		if !e.Reachable {
+			log.Printf("self-check: cannot reach %s: %s", e.Target, e.Error)
+		}
+	}
+	if *requireNonRoot && !r.Hardened() {
+		return errors.New("running as root or with capabilities; set runAsNonRoot and drop ALL capabilities, or start without -require-non-root")
+	}
+	return nil
+}
@@ -0,0 +1,17 @@
+package main
+
+import "testing"
+
+func TestAPIServerAddr(t *testing.T) {
+	for host, want := range map[string]string{
+		"https://10.96.0.1:443":          "10.96.0.1:443",
+		"https://api.example.com":        "api.example.com:443",
+		"http://localhost":               "localhost:80",
+		"https://[fd00::1]:6443/prefix/": "[fd00::1]:6443",
+		"":                               "",
+	} {
+		if got := apiServerAddr(host); got != want {
+			t.Errorf("apiServerAddr(%q) = %q, want %q", host, got, want)
+		}
+	}
+}
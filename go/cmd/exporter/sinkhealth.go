@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/your-org/k8s-ai-exporter/alert"
+)
+
+// sinkCheckers returns the configured alert receivers that can be health
+// checked, by name.
+func sinkCheckers() map[string]alert.Checker {
+	checkers := make(map[string]alert.Checker)
+	for name, n := range notifiers {
+		if c, ok := n.(alert.Checker); ok {
+			checkers[name] = c
+		}
+	}
+	if alertmanager != nil {
+		checkers["alertmanager"] = alertmanager
+	}
+	return checkers
+}
+
+// checkSinks checks every receiver once, exports the results and logs the
+// ones whose health changed since prev, which it updates.
+func checkSinks(ctx context.Context, checkers map[string]alert.Checker, prev map[string]bool) {
+	names := make([]string, 0, len(checkers))
+	for name := range checkers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		err := checkers[name].Check(ctx)
+		cancel()
+		healthy := err == nil
+		metrics.SetSinkHealthy(name, healthy)
+		if was, seen := prev[name]; !healthy && (!seen || was) {
+			log.Printf("alert receiver %s is unhealthy: %v", name, err)
+		} else if healthy && seen && !was {
+			log.Printf("alert receiver %s is healthy again", name)
+		}
+		prev[name] = healthy
+	}
+}
+
+// runSinkChecks checks the receivers at startup and then every interval,
+// so a broken delivery path shows before an alert is lost on it.
+func runSinkChecks(checkers map[string]alert.Checker, interval time.Duration) {
+	prev := make(map[string]bool)
+	checkSinks(context.Background(), checkers, prev)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		checkSinks(context.Background(), checkers, prev)
+	}
+}
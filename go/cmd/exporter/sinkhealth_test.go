@@ -0,0 +1,29 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/your-org/k8s-ai-exporter/alert"
+)
+
+type fakeChecker struct{ err error }
+
+func (f *fakeChecker) Check(context.Context) error { return f.err }
+
+func TestCheckSinks(t *testing.T) {
+	webhook := &fakeChecker{}
+	teams := &fakeChecker{err: errors.New("dial tcp: i/o timeout")}
+	checkers := map[string]alert.Checker{"webhook": webhook, "teams": teams}
+	prev := make(map[string]bool)
+	checkSinks(context.Background(), checkers, prev)
+	if !prev["webhook"] || prev["teams"] {
+		t.Errorf("health = %v, want webhook healthy and teams not", prev)
+	}
+	teams.err = nil
+	checkSinks(context.Background(), checkers, prev)
+	if !prev["teams"] {
+		t.Error("teams still unhealthy after a successful check")
+	}
+}
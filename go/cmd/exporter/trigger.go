@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/your-org/k8s-ai-exporter/api"
+)
+
+// scrapeTrigger serves POST /api/v1/scrape: it runs cycles out of band, at
+// most once per minInterval across all cycles so the endpoint cannot be
+// used to hammer the API server. Single nodes are scraped with scrapeNode,
+// at most once per minInterval per node.
+type scrapeTrigger struct {
+	minInterval time.Duration
+	names       []string // in cycle order
+	channels    map[string]chan struct{}
+	scrapeNode  func(ctx context.Context, node string) (api.NodeSnapshot, error)
+
+	mu       sync.Mutex
+	last     time.Time
+	nodeLast map[string]time.Time
+}
+
+// newScrapeTrigger gives every cycle a trigger channel that runCycles
+// listens on. It must be called before runCycles copies the cycles.
+func newScrapeTrigger(cycles []cycle, minInterval time.Duration) *scrapeTrigger {
+	t := &scrapeTrigger{
+		minInterval: minInterval,
+		channels:    make(map[string]chan struct{}, len(cycles)),
+		nodeLast:    make(map[string]time.Time),
+	}
+	for i := range cycles {
+		ch := make(chan struct{}, 1)
+		cycles[i].trigger = ch
+		t.names = append(t.names, cycles[i].name)
+		t.channels[cycles[i].name] = ch
+	}
+	return t
+}
+
+// TriggerScrape implements api.ScrapeTrigger.
+func (t *scrapeTrigger) TriggerScrape(names []string) ([]string, time.Duration, error) {
+	return t.trigger(names, time.Now())
+}
+
+func (t *scrapeTrigger) trigger(names []string, now time.Time) ([]string, time.Duration, error) {
+	if len(names) == 0 {
+		names = t.names
+	}
+	for _, n := range names {
+		if _, ok := t.channels[n]; !ok {
+			return nil, 0, fmt.Errorf("unknown cycle %q, want one of %s", n, strings.Join(t.names, ", "))
+		}
+	}
+
+	t.mu.Lock()
+	if wait := t.last.Add(t.minInterval).Sub(now); !t.last.IsZero() && wait > 0 {
+		t.mu.Unlock()
+		return nil, wait, nil
+	}
+	t.last = now
+	t.mu.Unlock()
+
+	for _, n := range names {
+		// A run already queued covers this request too.
+		select {
+		case t.channels[n] <- struct{}{}:
+		default:
+		}
+	}
+	log.Printf("out-of-band scrape of %s requested", strings.Join(names, ", "))
+	return names, 0, nil
+}
+
+// ScrapeNode implements api.ScrapeTrigger.
+func (t *scrapeTrigger) ScrapeNode(ctx context.Context, node string) (api.NodeSnapshot, time.Duration, error) {
+	return t.triggerNode(ctx, node, time.Now())
+}
+
+func (t *scrapeTrigger) triggerNode(ctx context.Context, node string, now time.Time) (api.NodeSnapshot, time.Duration, error) {
+	t.mu.Lock()
+	if wait := t.nodeLast[node].Add(t.minInterval).Sub(now); !t.nodeLast[node].IsZero() && wait > 0 {
+		t.mu.Unlock()
+		return api.NodeSnapshot{}, wait, nil
+	}
+	t.nodeLast[node] = now
+	t.mu.Unlock()
+
+	log.Printf("out-of-band scrape of node %s requested", node)
+	n, err := t.scrapeNode(ctx, node)
+	if errors.Is(err, api.ErrUnknownNode) {
+		// Do not remember names that are not nodes.
+		t.mu.Lock()
+		delete(t.nodeLast, node)
+		t.mu.Unlock()
+	}
+	return n, 0, err
+}
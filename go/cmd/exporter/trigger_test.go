@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/your-org/k8s-ai-exporter/api"
+)
+
+func TestScrapeTrigger(t *testing.T) {
+	cycles := []cycle{{name: "pods"}, {name: "main"}}
+	st := newScrapeTrigger(cycles, 10*time.Second)
+	now := time.Date(2024, 1, 2, 15, 0, 0, 0, time.UTC)
+
+	got, wait, err := st.trigger(nil, now)
+	if err != nil || wait != 0 || !reflect.DeepEqual(got, []string{"pods", "main"}) {
+		t.Fatalf("trigger all = %v, %v, %v; want both cycles", got, wait, err)
+	}
+	for _, c := range cycles {
+		select {
+		case <-c.trigger:
+		default:
+			t.Errorf("cycle %s was not triggered", c.name)
+		}
+	}
+
+	if _, wait, _ := st.trigger([]string{"main"}, now.Add(4*time.Second)); wait != 6*time.Second {
+		t.Errorf("retry after = %v, want 6s", wait)
+	}
+	if _, _, err := st.trigger([]string{"detail"}, now.Add(time.Minute)); err == nil {
+		t.Error("unknown cycle was accepted")
+	}
+
+	// Triggers while a run is still queued collapse into it.
+	st.trigger([]string{"main"}, now.Add(time.Minute))
+	if _, _, err := st.trigger([]string{"main"}, now.Add(2*time.Minute)); err != nil {
+		t.Fatalf("trigger: %v", err)
+	}
+	if n := len(cycles[1].trigger); n != 1 {
+		t.Errorf("queued runs of main = %d, want 1", n)
+	}
+}
+
+func TestScrapeTriggerNode(t *testing.T) {
+	st := newScrapeTrigger(nil, 10*time.Second)
+	var scraped []string
+	st.scrapeNode = func(_ context.Context, node string) (api.NodeSnapshot, error) {
+		if node != "node-a" && node != "node-b" {
+			return api.NodeSnapshot{}, fmt.Errorf("%w: %s", api.ErrUnknownNode, node)
+		}
+		scraped = append(scraped, node)
+		return api.NodeSnapshot{Name: node, CPUCores: 1}, nil
+	}
+	ctx := context.Background()
+	now := time.Date(2024, 1, 2, 15, 0, 0, 0, time.UTC)
+
+	if n, wait, err := st.triggerNode(ctx, "node-a", now); err != nil || wait != 0 || n.Name != "node-a" {
+		t.Fatalf("triggerNode(node-a) = %+v, %v, %v", n, wait, err)
+	}
+	if _, wait, _ := st.triggerNode(ctx, "node-a", now.Add(4*time.Second)); wait != 6*time.Second {
+		t.Errorf("retry after = %v, want 6s", wait)
+	}
+	// Other nodes and the cycles have their own limits.
+	if _, wait, err := st.triggerNode(ctx, "node-b", now.Add(4*time.Second)); err != nil || wait != 0 {
+		t.Errorf("triggerNode(node-b) = %v, %v; want a scrape", wait, err)
+	}
+	if _, wait, _ := st.trigger(nil, now.Add(4*time.Second)); wait != 0 {
+		t.Errorf("cycles were rate-limited by a node scrape")
+	}
+	if !reflect.DeepEqual(scraped, []string{"node-a", "node-b"}) {
+		t.Errorf("scraped %v, want [node-a node-b]", scraped)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, wait, err := st.triggerNode(ctx, "nope", now); wait != 0 || err == nil {
+			t.Errorf("triggerNode(nope) = %v, %v; want an unknown node error", wait, err)
+		}
+	}
+	if _, ok := st.nodeLast["nope"]; ok {
+		t.Error("unknown node was remembered")
+	}
+}
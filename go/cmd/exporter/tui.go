@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/your-org/k8s-ai-exporter/api"
+	"github.com/your-org/k8s-ai-exporter/client"
+	"github.com/your-org/k8s-ai-exporter/timeline"
+)
+
+const (
+	tuiBarWidth  = 20
+	tuiMaxNodes  = 20
+	tuiMaxEvents = 8
+	tuiEventSpan = time.Hour
+
+	ansiHome  = "\x1b[H\x1b[2J"
+	ansiBold  = "\x1b[1m"
+	ansiReset = "\x1b[0m"
+)
+
+// runTUI implements the tui subcommand: a live terminal dashboard of a
+// running exporter, read from its /api/v1 snapshot and event timeline.
+func runTUI(args []string) error {
+	fs := flag.NewFlagSet("tui", flag.ContinueOnError)
+	url := fs.String("url", "http://localhost:9100", "Base URL of the exporter")
+	refresh := fs.Duration("refresh", 5*time.Second, "Redraw interval")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *refresh <= 0 {
+		return fmt.Errorf("-refresh must be positive")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	c := client.New(*url, &http.Client{Timeout: *refresh})
+	ticker := time.NewTicker(*refresh)
+	defer ticker.Stop()
+	for {
+		var b strings.Builder
+		b.WriteString(ansiHome)
+		snap, err := c.GetSnapshot(ctx)
+		if err != nil {
+			fmt.Fprintf(&b, "%s: %v\n", *url, err)
+		} else {
+			// Events are optional decoration; a failure only hides them.
+			events, _ := c.ListEvents(ctx, time.Now().Add(-tuiEventSpan))
+			renderDashboard(&b, snap, events)
+		}
+		fmt.Fprintf(&b, "\nRefreshing every %s from %s. Ctrl-C quits.\n", *refresh, *url)
+		io.WriteString(os.Stdout, b.String())
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// renderDashboard writes one frame: cluster totals, the busiest nodes with
+// bars relative to the busiest one, and the most recent events.
+func renderDashboard(w io.Writer, s api.Snapshot, events []timeline.Event) {
+	var cpu, mem, maxCPU, maxMem float64
+	var pods int
+	for _, n := range s.Nodes {
+		cpu += n.CPUCores
+		mem += n.MemoryBytes
+		pods += n.ActivePods
+		maxCPU = math.Max(maxCPU, n.CPUCores)
+		maxMem = math.Max(maxMem, n.MemoryBytes)
+	}
+	fmt.Fprintf(w, "%sBinbots k8s%s  %s\n\n", ansiBold, ansiReset, s.Timestamp.Format(time.TimeOnly))
+	fmt.Fprintf(w, "Cluster  CPU %.2f cores   Memory %.1f GiB   Pods %d   Nodes %d\n\n", cpu, mem/(1<<30), pods, len(s.Nodes))
+
+	nodes := append([]api.NodeSnapshot(nil), s.Nodes...)
+	sort.SliceStable(nodes, func(i, j int) bool { return nodes[i].CPUCores > nodes[j].CPUCores })
+	width := len("NODE")
+	for _, n := range nodes {
+		width = max(width, len(n.Name))
+	}
+	fmt.Fprintf(w, "%s%-*s  %-*s  %-*s  %s%s\n", ansiBold, width, "NODE", tuiBarWidth+7, "CPU (cores)", tuiBarWidth+11, "MEMORY", "PODS", ansiReset)
+	for i, n := range nodes {
+		if i == tuiMaxNodes {
+			fmt.Fprintf(w, "... %d more nodes\n", len(nodes)-tuiMaxNodes)
+			break
+		}
+		fmt.Fprintf(w, "%-*s  %s %6.2f  %s %6.0f MiB  %d\n", width, n.Name,
+			bar(n.CPUCores, maxCPU), n.CPUCores, bar(n.MemoryBytes, maxMem), n.MemoryBytes/(1<<20), n.ActivePods)
+	}
+
+	fmt.Fprintf(w, "\n%sRecent events%s\n", ansiBold, ansiReset)
+	if len(events) == 0 {
+		fmt.Fprintf(w, "none in the last %s\n", tuiEventSpan)
+	}
+	if len(events) > tuiMaxEvents {
+		events = events[len(events)-tuiMaxEvents:]
+	}
+	for i := len(events) - 1; i >= 0; i-- {
+		e := events[i]
+		fmt.Fprintf(w, "%s  %-14s %s", e.Time.Format(time.TimeOnly), e.Type, e.Title)
+		if e.Namespace != "" {
+			fmt.Fprintf(w, " (%s)", e.Namespace)
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+// bar draws v relative to top as a fixed-width bar.
+func bar(v, top float64) string {
+	filled := 0
+	if top > 0 {
+		filled = int(math.Round(v / top * tuiBarWidth))
+	}
+	return strings.Repeat("█", filled) + strings.Repeat("░", tuiBarWidth-filled)
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/your-org/k8s-ai-exporter/api"
+	"github.com/your-org/k8s-ai-exporter/timeline"
+)
+
+func TestRenderDashboard(t *testing.T) {
+	at := time.Date(2024, 5, 1, 12, 0, 30, 0, time.UTC)
+	s := api.Snapshot{Timestamp: at, Nodes: []api.NodeSnapshot{
+		{Name: "small", CPUCores: 0.5, MemoryBytes: 1 << 30, ActivePods: 4},
+		{Name: "busy", CPUCores: 2, MemoryBytes: 2 << 30, ActivePods: 20},
+	}}
+	events := []timeline.Event{
+		{Time: at.Add(-20 * time.Minute), Type: timeline.TypeDeploy, Title: "checkout v42", Namespace: "shop"},
+		{Time: at.Add(-5 * time.Minute), Type: timeline.TypeIncidentStart, Title: "5xx spike"},
+	}
+	var b strings.Builder
+	renderDashboard(&b, s, events)
+	out := b.String()
+
+	for _, want := range []string{
+		"CPU 2.50 cores   Memory 3.0 GiB   Pods 24   Nodes 2",
+		"busy   " + strings.Repeat("█", tuiBarWidth) + "   2.00",
+		"small  " + strings.Repeat("█", 5) + strings.Repeat("░", 15) + "   0.50",
+		"11:40:30  deploy         checkout v42 (shop)",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("dashboard lacks %q:\n%s", want, out)
+		}
+	}
+	if strings.Index(out, "busy") > strings.Index(out, "small") {
+		t.Errorf("nodes not sorted by CPU:\n%s", out)
+	}
+	if strings.Index(out, "5xx spike") > strings.Index(out, "checkout v42") {
+		t.Errorf("events not newest first:\n%s", out)
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// watchdog notices cycles that stopped completing, e.g. on a call that
+// hangs without a timeout. A cycle is stuck when it has not completed
+// within factor times its interval, stretched while degraded, since its
+// previous completion, or since the watchdog started before its first one.
+type watchdog struct {
+	factor float64
+
+	mu        sync.Mutex
+	intervals map[string]time.Duration
+	last      map[string]time.Time
+	stuck     []string
+}
+
+func newWatchdog(factor float64, cycles []cycle, start time.Time) *watchdog {
+	w := &watchdog{
+		factor:    factor,
+		intervals: make(map[string]time.Duration, len(cycles)),
+		last:      make(map[string]time.Time, len(cycles)),
+	}
+	for _, c := range cycles {
+		w.intervals[c.name] = c.interval
+		w.last[c.name] = start
+	}
+	return w
+}
+
+// done records that cycle completed at t.
+func (w *watchdog) done(cycle string, t time.Time) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	w.last[cycle] = t
+	w.mu.Unlock()
+}
+
+// setInterval records that cycle now runs every interval.
+func (w *watchdog) setInterval(cycle string, interval time.Duration) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	w.intervals[cycle] = interval
+	w.mu.Unlock()
+}
+
+// check updates and returns the sorted names of the cycles stuck at now,
+// and exports k8s_ai_exporter_stuck for every cycle.
+func (w *watchdog) check(now time.Time) []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.stuck = w.stuck[:0]
+	for name, interval := range w.intervals {
+		limit := time.Duration(w.factor * float64(degrade.stretch(interval)))
+		stuck := now.Sub(w.last[name]) > limit
+		if stuck {
+			w.stuck = append(w.stuck, name)
+		}
+		metrics.SetStuck(name, stuck)
+	}
+	sort.Strings(w.stuck)
+	return append([]string(nil), w.stuck...)
+}
+
+// run checks every tick until stop is closed. Stuck cycles are logged, and
+// with exit the process ends so Kubernetes restarts the container.
+func (w *watchdog) run(tick time.Duration, exit bool, stop <-chan struct{}) {
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			stuck := w.check(now)
+			if len(stuck) == 0 {
+				continue
+			}
+			if exit {
+				log.Fatalf("watchdog: cycles %s have not completed within %g intervals; exiting", strings.Join(stuck, ", "), w.factor)
+			}
+			log.Printf("watchdog: cycles %s have not completed within %g intervals", strings.Join(stuck, ", "), w.factor)
+		}
+	}
+}
+
+// ServeHTTP implements the readiness endpoint: 503 while a cycle is stuck
+// as of the last check. A nil watchdog is always ready.
+func (w *watchdog) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	var stuck string
+	if w != nil {
+		w.mu.Lock()
+		stuck = strings.Join(w.stuck, ", ")
+		w.mu.Unlock()
+	}
+	if stuck != "" {
+		http.Error(rw, "stuck cycles: "+stuck, http.StatusServiceUnavailable)
+		return
+	}
+	rw.Write([]byte("ok\n"))
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWatchdog(t *testing.T) {
+	start := time.Date(2024, 1, 2, 15, 0, 0, 0, time.UTC)
+	wd := newWatchdog(3, []cycle{
+		{name: "pods", interval: 10 * time.Second},
+		{name: "main", interval: time.Minute},
+	}, start)
+	ready := func() int {
+		rec := httptest.NewRecorder()
+		wd.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/-/ready", nil))
+		return rec.Code
+	}
+
+	if got := wd.check(start.Add(30 * time.Second)); len(got) != 0 {
+		t.Errorf("stuck after 3 pods intervals = %v, want none", got)
+	}
+	if got, want := wd.check(start.Add(31*time.Second)), []string{"pods"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("stuck = %v, want %v", got, want)
+	}
+	if code := ready(); code != http.StatusServiceUnavailable {
+		t.Errorf("ready with a stuck cycle = %d, want 503", code)
+	}
+
+	wd.done("pods", start.Add(35*time.Second))
+	if got, want := wd.check(start.Add(3*time.Minute+time.Second)), []string{"main", "pods"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("stuck = %v, want %v", got, want)
+	}
+	wd.done("pods", start.Add(3*time.Minute))
+	wd.done("main", start.Add(3*time.Minute))
+	if got := wd.check(start.Add(3*time.Minute + time.Second)); len(got) != 0 {
+		t.Errorf("stuck after completions = %v, want none", got)
+	}
+	if code := ready(); code != http.StatusOK {
+		t.Errorf("ready = %d, want 200", code)
+	}
+
+	var disabled *watchdog
+	disabled.done("main", start)
+	rec := httptest.NewRecorder()
+	disabled.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/-/ready", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("ready without a watchdog = %d, want 200", rec.Code)
+	}
+}
@@ -0,0 +1,394 @@
+// Command gen-pyclient generates the Python API client from the exporter's
+// OpenAPI document. Run from go/:
+//
+//	go run ./cmd/gen-pyclient -o ../python/binbots_client.py
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/your-org/k8s-ai-exporter/api"
+)
+
+var output = flag.String("o", "", "Write the client to this file instead of stdout")
+
+func main() {
+	flag.Parse()
+	src, err := generate(api.OpenAPI())
+	if err != nil {
+		log.Fatalf("gen-pyclient: %v", err)
+	}
+	if *output == "" {
+		os.Stdout.Write(src)
+		return
+	}
+	if err := os.WriteFile(*output, src, 0o644); err != nil {
+		log.Fatalf("gen-pyclient: %v", err)
+	}
+}
+
+// schema is the subset of OpenAPI schema objects emitted by package api.
+type schema struct {
+	Ref                  string             `json:"$ref"`
+	Type                 string             `json:"type"`
+	Format               string             `json:"format"`
+	Description          string             `json:"description"`
+	Items                *schema            `json:"items"`
+	AdditionalProperties *schema            `json:"additionalProperties"`
+	AllOf                []*schema          `json:"allOf"`
+	Properties           map[string]*schema `json:"properties"`
+	Required             []string           `json:"required"`
+}
+
+type content map[string]struct {
+	Schema *schema `json:"schema"`
+}
+
+type operation struct {
+	OperationID string `json:"operationId"`
+	Summary     string `json:"summary"`
+	Parameters  []struct {
+		Name   string  `json:"name"`
+		In     string  `json:"in"`
+		Schema *schema `json:"schema"`
+	} `json:"parameters"`
+	RequestBody *struct {
+		Content content `json:"content"`
+	} `json:"requestBody"`
+	Responses map[string]struct {
+		Content content `json:"content"`
+	} `json:"responses"`
+}
+
+type document struct {
+	Info struct {
+		Version string `json:"version"`
+	} `json:"info"`
+	Paths      map[string]map[string]*operation `json:"paths"`
+	Components struct {
+		Schemas map[string]*schema `json:"schemas"`
+	} `json:"components"`
+}
+
+type pyField struct {
+	Name, JSONName, Type, Convert, ToJSON, Doc string
+	Required                                   bool
+}
+
+type pyClass struct {
+	Name   string
+	Doc    string
+	Fields []pyField
+}
+
+type pyMethod struct {
+	Name, Args, Summary, Result, Convert string
+}
+
+func generate(spec []byte) ([]byte, error) {
+	var doc document
+	if err := json.Unmarshal(spec, &doc); err != nil {
+		return nil, err
+	}
+
+	var classes []pyClass
+	for _, name := range sortedKeys(doc.Components.Schemas) {
+		if name == "Error" {
+			continue // raised as APIError
+		}
+		s := doc.Components.Schemas[name]
+		c := pyClass{Name: name, Doc: s.Description}
+		required := make(map[string]bool)
+		for _, r := range s.Required {
+			required[r] = true
+		}
+		for _, prop := range sortedKeys(s.Properties) {
+			p := s.Properties[prop]
+			f := pyField{
+				Name:     snakeCase(prop),
+				JSONName: prop,
+				Type:     pyType(p),
+				Doc:      p.Description,
+				Required: required[prop],
+			}
+			value := fmt.Sprintf("d[%q]", prop)
+			if !f.Required {
+				value = fmt.Sprintf("d.get(%q)", prop)
+				f.Type = "Optional[" + f.Type + "]"
+			}
+			f.Convert = convert(p, value)
+			if !f.Required && f.Convert != value {
+				f.Convert = fmt.Sprintf("%s if %s is not None else None", f.Convert, value)
+			}
+			f.ToJSON = toJSON(p, "self."+f.Name)
+			c.Fields = append(c.Fields, f)
+		}
+		// Fields with defaults must follow those without.
+		sort.SliceStable(c.Fields, func(i, j int) bool { return c.Fields[i].Required && !c.Fields[j].Required })
+		classes = append(classes, c)
+	}
+
+	var methods []pyMethod
+	for _, path := range sortedKeys(doc.Paths) {
+		for _, method := range sortedKeys(doc.Paths[path]) {
+			m, err := pyOperation(strings.ToUpper(method), path, doc.Paths[path][method])
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %v", strings.ToUpper(method), path, err)
+			}
+			methods = append(methods, m)
+		}
+	}
+	sort.SliceStable(methods, func(i, j int) bool { return methods[i].Name < methods[j].Name })
+
+	var buf bytes.Buffer
+	err := pyTemplate.Execute(&buf, map[string]interface{}{
+		"Version": doc.Info.Version,
+		"Classes": classes,
+		"Methods": methods,
+	})
+	return buf.Bytes(), err
+}
+
+// pyOperation renders one operation as a Client method. Query parameters
+// become optional keyword arguments and a request body becomes a required
+// argument named after its schema.
+func pyOperation(method, path string, op *operation) (pyMethod, error) {
+	var result *schema
+	for _, status := range sortedKeys(op.Responses) {
+		if strings.HasPrefix(status, "2") {
+			result = op.Responses[status].Content["application/json"].Schema
+			break
+		}
+	}
+	if result == nil {
+		return pyMethod{}, fmt.Errorf("no JSON success response")
+	}
+
+	var args, query []string
+	call := fmt.Sprintf("self._request(%q, %q", method, path)
+	if op.RequestBody != nil {
+		s := op.RequestBody.Content["application/json"].Schema
+		if s == nil || refName(s) == "" {
+			return pyMethod{}, fmt.Errorf("request body is not a component schema")
+		}
+		name := snakeCase(refName(s))
+		args = append(args, name+": "+refName(s))
+		call += ", body=" + toJSON(s, name)
+	}
+	for _, p := range op.Parameters {
+		if p.In != "query" {
+			return pyMethod{}, fmt.Errorf("unsupported %s parameter %q", p.In, p.Name)
+		}
+		name := snakeCase(p.Name)
+		args = append(args, name+": Optional["+pyType(p.Schema)+"] = None")
+		query = append(query, fmt.Sprintf("%q: %s", p.Name, name))
+	}
+	if len(query) > 0 {
+		call += ", query={" + strings.Join(query, ", ") + "}"
+	}
+	call += ")"
+
+	m := pyMethod{
+		Name:    snakeCase(op.OperationID),
+		Summary: op.Summary,
+		Result:  pyType(result),
+		Convert: convert(result, call),
+	}
+	if len(args) > 0 {
+		m.Args = ", " + strings.Join(args, ", ")
+	}
+	return m, nil
+}
+
+func refName(s *schema) string {
+	if len(s.AllOf) == 1 {
+		s = s.AllOf[0]
+	}
+	return strings.TrimPrefix(s.Ref, "#/components/schemas/")
+}
+
+func pyType(s *schema) string {
+	if name := refName(s); name != "" {
+		return name
+	}
+	switch s.Type {
+	case "integer":
+		return "int"
+	case "number":
+		return "float"
+	case "boolean":
+		return "bool"
+	case "array":
+		return "List[" + pyType(s.Items) + "]"
+	case "object":
+		if s.AdditionalProperties != nil {
+			return "Dict[str, " + pyType(s.AdditionalProperties) + "]"
+		}
+		return "Dict[str, Any]"
+	}
+	return "str"
+}
+
+// convert returns the Python expression turning the decoded JSON value
+// expr into pyType(s).
+func convert(s *schema, expr string) string {
+	if name := refName(s); name != "" {
+		return name + ".from_dict(" + expr + ")"
+	}
+	switch {
+	case s.Type == "array" && refName(s.Items) != "":
+		return "[" + convert(s.Items, "v") + " for v in " + expr + "]"
+	case s.Type == "object" && s.AdditionalProperties != nil && refName(s.AdditionalProperties) != "":
+		return "{k: " + convert(s.AdditionalProperties, "v") + " for k, v in " + expr + ".items()}"
+	}
+	return expr
+}
+
+// toJSON is the inverse of convert: it returns the Python expression
+// turning expr, a pyType(s) value, into something json.dumps accepts.
+func toJSON(s *schema, expr string) string {
+	if refName(s) != "" {
+		return expr + ".to_dict()"
+	}
+	switch {
+	case s.Type == "array" && refName(s.Items) != "":
+		return "[" + toJSON(s.Items, "v") + " for v in " + expr + "]"
+	case s.Type == "object" && s.AdditionalProperties != nil && refName(s.AdditionalProperties) != "":
+		return "{k: " + toJSON(s.AdditionalProperties, "v") + " for k, v in " + expr + ".items()}"
+	}
+	return expr
+}
+
+func snakeCase(s string) string {
+	var b strings.Builder
+	for i, r := range s {
+		if unicode.IsUpper(r) {
+			if i > 0 && !unicode.IsUpper(rune(s[i-1])) {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+var pyTemplate = template.Must(template.New("client").Parse(`# Code generated by go run ./cmd/gen-pyclient; DO NOT EDIT.
+"""Python client for the k8s-ai-exporter API {{.Version}}.
+
+Usage:
+    client = Client("http://k8s-ai-exporter.monitoring.svc:9100")
+    snapshot = client.get_snapshot()
+
+Pass token= for operations that require a bearer token.
+"""
+from __future__ import annotations
+
+import json
+import urllib.error
+import urllib.parse
+import urllib.request
+from dataclasses import dataclass
+from typing import Any, Dict, List, Optional
+
+
+class APIError(Exception):
+    """A non-2xx response from the exporter."""
+
+    def __init__(self, status: int, message: str):
+        super().__init__(f"status {status}: {message}")
+        self.status = status
+        self.message = message
+{{range .Classes}}
+
+@dataclass
+class {{.Name}}:
+{{- if .Doc}}
+    """{{.Doc}}"""
+{{end}}
+{{- range .Fields}}
+    {{.Name}}: {{.Type}}{{if not .Required}} = None{{end}}{{if .Doc}}  # {{.Doc}}{{end}}
+{{- end}}
+
+    @classmethod
+    def from_dict(cls, d: Dict[str, Any]) -> {{.Name}}:
+        return cls(
+{{- range .Fields}}
+            {{.Name}}={{.Convert}},
+{{- end}}
+        )
+
+    def to_dict(self) -> Dict[str, Any]:
+        d: Dict[str, Any] = {}
+{{- range .Fields}}
+{{- if .Required}}
+        d["{{.JSONName}}"] = {{.ToJSON}}
+{{- else}}
+        if self.{{.Name}} is not None:
+            d["{{.JSONName}}"] = {{.ToJSON}}
+{{- end}}
+{{- end}}
+        return d
+{{end}}
+
+class Client:
+    """Client for one exporter's /api/{{.Version}} endpoints."""
+
+    def __init__(self, base_url: str, timeout: float = 10.0, token: Optional[str] = None):
+        self.base_url = base_url.rstrip("/")
+        self.timeout = timeout
+        self.token = token
+
+    def _request(
+        self,
+        method: str,
+        path: str,
+        body: Optional[Dict[str, Any]] = None,
+        query: Optional[Dict[str, Any]] = None,
+    ) -> Any:
+        url = self.base_url + path
+        params = {k: v for k, v in (query or {}).items() if v is not None}
+        if params:
+            url += "?" + urllib.parse.urlencode(params)
+        headers = {"Accept": "application/json"}
+        data = None
+        if body is not None:
+            data = json.dumps(body).encode("utf-8")
+            headers["Content-Type"] = "application/json"
+        if self.token:
+            headers["Authorization"] = "Bearer " + self.token
+        req = urllib.request.Request(url, data=data, headers=headers, method=method)
+        try:
+            with urllib.request.urlopen(req, timeout=self.timeout) as resp:
+                return json.load(resp)
+        except urllib.error.HTTPError as e:
+            text = e.read().decode("utf-8", "replace")
+            try:
+                message = json.loads(text)["error"]
+            except (ValueError, KeyError, TypeError):
+                message = text.strip()
+            raise APIError(e.code, message) from None
+{{range .Methods}}
+    def {{.Name}}(self{{.Args}}) -> {{.Result}}:
+        """{{.Summary}}"""
+        return {{.Convert}}
+{{end -}}
+`))
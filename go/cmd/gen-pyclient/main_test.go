@@ -0,0 +1,39 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/your-org/k8s-ai-exporter/api"
+)
+
+// TestPythonClientUpToDate fails when the checked-in Python client no
+// longer matches the OpenAPI document.
+func TestPythonClientUpToDate(t *testing.T) {
+	want, err := generate(api.OpenAPI())
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	got, err := os.ReadFile("../../../python/binbots_client.py")
+	if err != nil {
+		t.Fatalf("read client: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Error("python/binbots_client.py is stale; run `go run ./cmd/gen-pyclient -o ../python/binbots_client.py` from go/")
+	}
+}
+
+func TestSnakeCase(t *testing.T) {
+	tests := map[string]string{
+		"getSnapshot": "get_snapshot",
+		"cpuCores":    "cpu_cores",
+		"name":        "name",
+		"nodeID":      "node_id",
+	}
+	for in, want := range tests {
+		if got := snakeCase(in); got != want {
+			t.Errorf("snakeCase(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
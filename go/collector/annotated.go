@@ -0,0 +1,134 @@
+package collector
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Annotations of the prometheus.io convention that the Prometheus Helm
+// chart's kubernetes-pods job reads.
+const (
+	PromAnnotationScrape = "prometheus.io/scrape"
+	PromAnnotationPort   = "prometheus.io/port"
+	PromAnnotationPath   = "prometheus.io/path"
+	PromAnnotationScheme = "prometheus.io/scheme"
+)
+
+// AnnotatedTargets returns the running pods among pods annotated
+// prometheus.io/scrape: "true", on the port of prometheus.io/port or else
+// the first port their containers declare, at prometheus.io/path (default
+// /metrics) and over prometheus.io/scheme (default http). Pods without a
+// port or with an invalid one are left out.
+func AnnotatedTargets(pods []corev1.Pod) []Target {
+	var out []Target
+	for _, p := range pods {
+		ann := p.Annotations
+		if ann[PromAnnotationScrape] != "true" || p.Status.Phase != corev1.PodRunning || p.Spec.NodeName == "" {
+			continue
+		}
+		port := 0
+		if s, ok := ann[PromAnnotationPort]; ok {
+			if n, err := strconv.Atoi(s); err == nil && n > 0 && n <= 65535 {
+				port = n
+			}
+		} else {
+			port = firstContainerPort(&p)
+		}
+		if port == 0 {
+			continue
+		}
+		path := ann[PromAnnotationPath]
+		if path == "" {
+			path = "/metrics"
+		}
+		if !strings.HasPrefix(path, "/") {
+			path = "/" + path
+		}
+		out = append(out, Target{
+			Job:       "annotated",
+			Node:      p.Spec.NodeName,
+			Namespace: p.Namespace,
+			Pod:       p.Name,
+			Port:      port,
+			Path:      path,
+			HTTPS:     ann[PromAnnotationScheme] == "https",
+		})
+	}
+	return out
+}
+
+func firstContainerPort(p *corev1.Pod) int {
+	for _, c := range p.Spec.Containers {
+		for _, cp := range c.Ports {
+			if cp.Protocol == "" || cp.Protocol == corev1.ProtocolTCP {
+				return int(cp.ContainerPort)
+			}
+		}
+	}
+	return 0
+}
+
+// AnnotatedSeries scrapes the annotated pods among pods (see
+// AnnotatedTargets) through the API server and returns their series that
+// rules select, labelled with the pod's namespace and name. Failed pods are
+// skipped and reported in errs.
+func (s *Scraper) AnnotatedSeries(ctx context.Context, pods []corev1.Pod, rules Rules) (series []RawSeries, errs []*TargetError) {
+	for _, t := range AnnotatedTargets(pods) {
+		body, _, _, err := s.fetch(ctx, s.Client, t.URL(s.BaseURL), "text/plain")
+		var got []RawSeries
+		if err == nil {
+			got, err = ParseSelected(bytes.NewReader(body), rules)
+		}
+		if err != nil {
+			errs = append(errs, &TargetError{Target: "annotated:" + t.Namespace + "/" + t.Pod, Err: err})
+			continue
+		}
+		series = append(series, WithPod(got, t.Namespace, t.Pod)...)
+	}
+	return series, errs
+}
+
+// ParseSelected returns the samples of a text exposition that rules
+// select.
+func ParseSelected(body io.Reader, rules Rules) ([]RawSeries, error) {
+	var out []RawSeries
+	r := bufio.NewReader(body)
+	for {
+		line, err := r.ReadBytes('\n')
+		line = bytes.TrimRight(line, "\r\n")
+		if len(line) > 0 && line[0] != '#' {
+			if name, _, _, ok := splitSampleLine(line); ok && rules.Wants(string(name)) {
+				n, l, v, perr := parseSampleLine(string(line))
+				if perr == nil && rules.Match(n, l) {
+					out = append(out, RawSeries{Name: n, Labels: l, Value: v})
+				}
+			}
+		}
+		if err == io.EOF {
+			return out, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// WithPod adds the namespace and pod labels to each series. Pre-existing
+// ones are kept as exported_namespace and exported_pod, like WithNode.
+func WithPod(series []RawSeries, namespace, pod string) []RawSeries {
+	for i := range series {
+		for k, v := range map[string]string{"namespace": namespace, "pod": pod} {
+			if old, ok := series[i].Labels[k]; ok {
+				series[i].Labels["exported_"+k] = old
+			}
+			series[i].Labels[k] = v
+		}
+	}
+	return series
+}
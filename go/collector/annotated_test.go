@@ -0,0 +1,70 @@
+package collector
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestAnnotatedTargets(t *testing.T) {
+	pod := func(name string, ann map[string]string, ports ...int32) corev1.Pod {
+		p := corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: name, Annotations: ann},
+			Spec:       corev1.PodSpec{NodeName: "n1", Containers: []corev1.Container{{Name: "app"}}},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+		}
+		for _, port := range ports {
+			p.Spec.Containers[0].Ports = append(p.Spec.Containers[0].Ports, corev1.ContainerPort{ContainerPort: port})
+		}
+		return p
+	}
+	pending := pod("pending", map[string]string{PromAnnotationScrape: "true"}, 8080)
+	pending.Status.Phase = corev1.PodPending
+	pods := []corev1.Pod{
+		pod("api", map[string]string{PromAnnotationScrape: "true", PromAnnotationPort: "9090", PromAnnotationPath: "stats"}, 8080),
+		pod("web", map[string]string{PromAnnotationScrape: "true", PromAnnotationScheme: "https"}, 8443, 9000),
+		pod("bad-port", map[string]string{PromAnnotationScrape: "true", PromAnnotationPort: "http"}, 8080),
+		pod("no-port", map[string]string{PromAnnotationScrape: "true"}),
+		pod("off", map[string]string{PromAnnotationScrape: "false"}, 8080),
+		pending,
+	}
+	var got []string
+	for _, tg := range AnnotatedTargets(pods) {
+		got = append(got, tg.URL("https://api"))
+	}
+	want := []string{
+		"https://api/api/v1/namespaces/shop/pods/api:9090/proxy/stats",
+		"https://api/api/v1/namespaces/shop/pods/https:web:8443/proxy/metrics",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("AnnotatedTargets = %q, want %q", got, want)
+	}
+}
+
+func TestParseSelected(t *testing.T) {
+	body := `# TYPE http_requests_total counter
+http_requests_total{code="200",pod="api-0"} 1027
+http_requests_total{code="500",pod="api-0"} 3
+queue_depth 7
+go_goroutines 41
+`
+	rules, err := ParsePassthroughSpec(`http_requests_total{code="500"};queue_depth`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ParseSelected(strings.NewReader(body), rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = WithPod(got, "shop", "api-7d9f")
+	want := []RawSeries{
+		{Name: "http_requests_total", Labels: map[string]string{"code": "500", "exported_pod": "api-0", "namespace": "shop", "pod": "api-7d9f"}, Value: 3},
+		{Name: "queue_depth", Labels: map[string]string{"namespace": "shop", "pod": "api-7d9f"}, Value: 7},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseSelected = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,200 @@
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Paths of the batch workload objects of Kueue and Volcano.
+const (
+	KueueWorkloadsPath   = "/apis/kueue.x-k8s.io/v1beta1/workloads"
+	VolcanoPodGroupsPath = "/apis/scheduling.volcano.sh/v1beta1/podgroups"
+)
+
+// Batch schedulers whose queues QueuedWorkloads reads.
+const (
+	SchedulerKueue   = "kueue"
+	SchedulerVolcano = "volcano"
+)
+
+// QueuedWorkload is a batch workload waiting in a queue for admission: a
+// Kueue Workload without an admission, or a Volcano PodGroup that is
+// Pending or Inqueue.
+type QueuedWorkload struct {
+	Scheduler string
+	Namespace string
+	Name      string
+	// Queue is the Kueue LocalQueue, which lives in Namespace, or the
+	// cluster-scoped Volcano Queue.
+	Queue       string
+	Pods        int
+	CPUCores    float64
+	MemoryBytes float64
+}
+
+// QueuedWorkloads lists the batch workloads waiting in Kueue and Volcano
+// queues. A scheduler whose API is not served, because it is not
+// installed, is skipped.
+func (s *Scraper) QueuedWorkloads(ctx context.Context) ([]QueuedWorkload, error) {
+	var out []QueuedWorkload
+	for _, src := range []struct {
+		path  string
+		parse func([]byte) ([]QueuedWorkload, error)
+	}{
+		{KueueWorkloadsPath, ParseKueueWorkloads},
+		{VolcanoPodGroupsPath, ParseVolcanoPodGroups},
+	} {
+		body, err := s.fetchOptional(ctx, src.path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", src.path, err)
+		}
+		if body == nil {
+			continue
+		}
+		ws, err := src.parse(body)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", src.path, err)
+		}
+		out = append(out, ws...)
+	}
+	return out, nil
+}
+
+// ParseKueueWorkloads returns the Workloads of a kueue.x-k8s.io
+// WorkloadList that are neither admitted nor finished. Their requests are
+// the pod template requests of each pod set times its count.
+func ParseKueueWorkloads(body []byte) ([]QueuedWorkload, error) {
+	var list struct {
+		Items []struct {
+			Metadata struct {
+				Namespace string `json:"namespace"`
+				Name      string `json:"name"`
+			} `json:"metadata"`
+			Spec struct {
+				QueueName string `json:"queueName"`
+				PodSets   []struct {
+					Count    int                    `json:"count"`
+					Template corev1.PodTemplateSpec `json:"template"`
+				} `json:"podSets"`
+			} `json:"spec"`
+			Status struct {
+				Admission  json.RawMessage `json:"admission"`
+				Conditions []struct {
+					Type   string `json:"type"`
+					Status string `json:"status"`
+				} `json:"conditions"`
+			} `json:"status"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(body, &list); err != nil {
+		return nil, err
+	}
+	var out []QueuedWorkload
+	for _, it := range list.Items {
+		if len(it.Status.Admission) > 0 && string(it.Status.Admission) != "null" {
+			continue
+		}
+		finished := false
+		for _, c := range it.Status.Conditions {
+			if c.Type == "Finished" && c.Status == "True" {
+				finished = true
+			}
+		}
+		if finished {
+			continue
+		}
+		w := QueuedWorkload{Scheduler: SchedulerKueue, Namespace: it.Metadata.Namespace, Name: it.Metadata.Name, Queue: it.Spec.QueueName}
+		for _, ps := range it.Spec.PodSets {
+			cpu, mem := templateRequests(&ps.Template.Spec)
+			w.Pods += ps.Count
+			w.CPUCores += float64(ps.Count) * cpu
+			w.MemoryBytes += float64(ps.Count) * mem
+		}
+		out = append(out, w)
+	}
+	return out, nil
+}
+
+// ParseVolcanoPodGroups returns the PodGroups of a scheduling.volcano.sh
+// PodGroupList that wait for their gang: Pending, or Inqueue without
+// running pods yet. Their requests are the group's minResources.
+func ParseVolcanoPodGroups(body []byte) ([]QueuedWorkload, error) {
+	var list struct {
+		Items []struct {
+			Metadata struct {
+				Namespace string `json:"namespace"`
+				Name      string `json:"name"`
+			} `json:"metadata"`
+			Spec struct {
+				MinMember    int                 `json:"minMember"`
+				Queue        string              `json:"queue"`
+				MinResources corev1.ResourceList `json:"minResources"`
+			} `json:"spec"`
+			Status struct {
+				Phase string `json:"phase"`
+			} `json:"status"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(body, &list); err != nil {
+		return nil, err
+	}
+	var out []QueuedWorkload
+	for _, it := range list.Items {
+		if it.Status.Phase != "Pending" && it.Status.Phase != "Inqueue" {
+			continue
+		}
+		queue := it.Spec.Queue
+		if queue == "" {
+			queue = "default" // Volcano's default queue
+		}
+		out = append(out, QueuedWorkload{
+			Scheduler:   SchedulerVolcano,
+			Namespace:   it.Metadata.Namespace,
+			Name:        it.Metadata.Name,
+			Queue:       queue,
+			Pods:        it.Spec.MinMember,
+			CPUCores:    quantity(it.Spec.MinResources, corev1.ResourceCPU),
+			MemoryBytes: quantity(it.Spec.MinResources, corev1.ResourceMemory),
+		})
+	}
+	return out, nil
+}
+
+// fetchOptional GETs the JSON list at path from the API server. The body is
+// nil when the API is not served, e.g. because the CRD behind it is not
+// installed.
+func (s *Scraper) fetchOptional(ctx context.Context, path string) ([]byte, error) {
+	body, _, _, err := s.fetch(ctx, s.Client, s.BaseURL+path, "application/json")
+	var status *StatusError
+	if errors.As(err, &status) && status.Code == http.StatusNotFound {
+		return nil, nil
+	}
+	return body, err
+}
+
+// templateRequests returns the CPU and memory one pod of spec requests:
+// the larger of its containers' sum and its largest init container.
+func templateRequests(spec *corev1.PodSpec) (cpu, mem float64) {
+	for _, c := range spec.Containers {
+		cpu += quantity(c.Resources.Requests, corev1.ResourceCPU)
+		mem += quantity(c.Resources.Requests, corev1.ResourceMemory)
+	}
+	for _, c := range spec.InitContainers {
+		cpu = max(cpu, quantity(c.Resources.Requests, corev1.ResourceCPU))
+		mem = max(mem, quantity(c.Resources.Requests, corev1.ResourceMemory))
+	}
+	return cpu, mem
+}
+
+func quantity(l corev1.ResourceList, name corev1.ResourceName) float64 {
+	q, ok := l[name]
+	if !ok {
+		return 0
+	}
+	return q.AsApproximateFloat64()
+}
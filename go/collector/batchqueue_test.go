@@ -0,0 +1,65 @@
+package collector
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+const kueueWorkloadsJSON = `{
+  "kind": "WorkloadList",
+  "apiVersion": "kueue.x-k8s.io/v1beta1",
+  "items": [
+    {"metadata": {"namespace": "ml", "name": "job-train"},
+     "spec": {"queueName": "gpu", "podSets": [
+       {"name": "main", "count": 4, "template": {"spec": {"containers": [{"name": "c", "resources": {"requests": {"cpu": "2", "memory": "1Gi"}}}]}}}
+     ]}},
+    {"metadata": {"namespace": "ml", "name": "job-admitted"},
+     "spec": {"queueName": "gpu", "podSets": [{"name": "main", "count": 1, "template": {"spec": {"containers": [{"name": "c"}]}}}]},
+     "status": {"admission": {"clusterQueue": "cq"}}},
+    {"metadata": {"namespace": "ml", "name": "job-done"},
+     "spec": {"queueName": "gpu", "podSets": [{"name": "main", "count": 1, "template": {"spec": {"containers": [{"name": "c"}]}}}]},
+     "status": {"conditions": [{"type": "Finished", "status": "True"}]}}
+  ]
+}`
+
+const volcanoPodGroupsJSON = `{
+  "kind": "PodGroupList",
+  "apiVersion": "scheduling.volcano.sh/v1beta1",
+  "items": [
+    {"metadata": {"namespace": "hpc", "name": "mpi"}, "spec": {"minMember": 8, "minResources": {"cpu": "16", "memory": "32Gi"}}, "status": {"phase": "Inqueue"}},
+    {"metadata": {"namespace": "hpc", "name": "done"}, "spec": {"minMember": 1, "queue": "batch"}, "status": {"phase": "Running"}}
+  ]
+}`
+
+func TestQueuedWorkloads(t *testing.T) {
+	for _, volcano := range []bool{true, false} {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case r.URL.Path == KueueWorkloadsPath:
+				w.Write([]byte(kueueWorkloadsJSON))
+			case r.URL.Path == VolcanoPodGroupsPath && volcano:
+				w.Write([]byte(volcanoPodGroupsJSON))
+			default:
+				http.NotFound(w, r)
+			}
+		}))
+		s := &Scraper{Client: srv.Client(), BaseURL: srv.URL}
+		got, err := s.QueuedWorkloads(context.Background())
+		srv.Close()
+		if err != nil {
+			t.Fatalf("QueuedWorkloads: %v", err)
+		}
+		want := []QueuedWorkload{
+			{Scheduler: SchedulerKueue, Namespace: "ml", Name: "job-train", Queue: "gpu", Pods: 4, CPUCores: 8, MemoryBytes: 4 << 30},
+		}
+		if volcano {
+			want = append(want, QueuedWorkload{Scheduler: SchedulerVolcano, Namespace: "hpc", Name: "mpi", Queue: "default", Pods: 8, CPUCores: 16, MemoryBytes: 32 << 30})
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("volcano installed %v: got %+v, want %+v", volcano, got, want)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package collector
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned instead of scraping a node whose circuit is
+// open.
+var ErrCircuitOpen = errors.New("circuit open")
+
+type circuit struct {
+	failures  int
+	openUntil time.Time
+}
+
+// Breakers keeps a circuit breaker per node. After threshold consecutive
+// failed scrapes a node's circuit opens and the node is skipped for the
+// cooldown, so a NotReady or firewalled kubelet does not cost a client
+// timeout every cycle. The first scrape after the cooldown is a probe: a
+// success closes the circuit, a failure opens it for another cooldown. A
+// nil *Breakers, or one with a threshold of 0, never opens. It is safe for
+// concurrent use.
+type Breakers struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu    sync.Mutex
+	nodes map[string]*circuit
+}
+
+// NewBreakers returns breakers that open after threshold consecutive
+// failures for cooldown.
+func NewBreakers(threshold int, cooldown time.Duration) *Breakers {
+	return &Breakers{threshold: threshold, cooldown: cooldown, nodes: make(map[string]*circuit)}
+}
+
+// Allow reports whether node may be scraped at now, that is whether its
+// circuit is closed or its cooldown is over.
+func (b *Breakers) Allow(node string, now time.Time) bool {
+	return !b.Open(node, now)
+}
+
+// Open reports whether node's circuit is open at now.
+func (b *Breakers) Open(node string, now time.Time) bool {
+	if b == nil || b.threshold <= 0 {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	c, ok := b.nodes[node]
+	return ok && now.Before(c.openUntil)
+}
+
+// Record counts the outcome of a scrape of node at now; err nil closes the
+// circuit. It reports whether the circuit is open afterwards.
+func (b *Breakers) Record(node string, err error, now time.Time) bool {
+	if b == nil || b.threshold <= 0 {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err == nil {
+		delete(b.nodes, node)
+		return false
+	}
+	c := b.nodes[node]
+	if c == nil {
+		c = &circuit{}
+		b.nodes[node] = c
+	}
+	c.failures++
+	if c.failures >= b.threshold {
+		c.openUntil = now.Add(b.cooldown)
+	}
+	return now.Before(c.openUntil)
+}
+
+// Retain forgets the circuits of nodes not in nodes.
+func (b *Breakers) Retain(nodes map[string]bool) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for node := range b.nodes {
+		if !nodes[node] {
+			delete(b.nodes, node)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package collector
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBreakers(t *testing.T) {
+	b := NewBreakers(3, time.Minute)
+	now := time.Unix(1700000000, 0)
+	timeout := errors.New("context deadline exceeded")
+
+	for i := 0; i < 2; i++ {
+		if b.Record("a", timeout, now) {
+			t.Fatalf("circuit open after %d failures, want 3", i+1)
+		}
+	}
+	// A success in between starts the count again.
+	b.Record("a", nil, now)
+	for i := 0; i < 2; i++ {
+		b.Record("a", timeout, now)
+	}
+	if !b.Allow("a", now) {
+		t.Fatal("circuit open after a success and 2 failures")
+	}
+	if !b.Record("a", timeout, now) || b.Allow("a", now.Add(59*time.Second)) {
+		t.Fatal("circuit not open for the cooldown after 3 consecutive failures")
+	}
+	if !b.Allow("b", now) {
+		t.Error("other node skipped")
+	}
+
+	// The probe after the cooldown fails: open for another cooldown.
+	probe := now.Add(time.Minute)
+	if !b.Allow("a", probe) {
+		t.Fatal("no probe after the cooldown")
+	}
+	if !b.Record("a", timeout, probe) || b.Allow("a", probe.Add(30*time.Second)) {
+		t.Error("failed probe did not reopen the circuit")
+	}
+	// A successful probe closes it.
+	probe = probe.Add(time.Minute)
+	if b.Record("a", nil, probe) || !b.Allow("a", probe) || b.Record("a", timeout, probe) {
+		t.Error("circuit not closed by a successful probe")
+	}
+
+	b.Retain(map[string]bool{"b": true})
+	b.Record("a", timeout, probe)
+	b.Record("a", timeout, probe)
+	if !b.Allow("a", probe) {
+		t.Error("forgotten node kept its failures")
+	}
+}
+
+func TestBreakersDisabled(t *testing.T) {
+	now := time.Now()
+	for _, b := range []*Breakers{nil, NewBreakers(0, time.Minute)} {
+		for i := 0; i < 5; i++ {
+			b.Record("a", errors.New("refused"), now)
+		}
+		if !b.Allow("a", now) {
+			t.Errorf("%v: node skipped with the breaker off", b)
+		}
+	}
+}
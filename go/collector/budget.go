@@ -0,0 +1,78 @@
+package collector
+
+import (
+	"sync"
+	"time"
+)
+
+// Cycle phases tracked by Budget.
+const (
+	PhaseAPIList    = "api_list"
+	PhaseProxyFetch = "proxy_fetch"
+	PhaseParse      = "parse"
+	PhaseDetail     = "detail"
+	PhaseAggregate  = "aggregate"
+)
+
+// Budget accumulates time per phase for one collection cycle. A nil
+// *Budget discards everything, so helpers can be called without one.
+type Budget struct {
+	mu     sync.Mutex
+	start  time.Time
+	phases map[string]time.Duration
+}
+
+// NewBudget starts timing a cycle.
+func NewBudget() *Budget {
+	return &Budget{start: time.Now(), phases: make(map[string]time.Duration)}
+}
+
+// Since adds the time elapsed since start to phase.
+func (b *Budget) Since(phase string, start time.Time) {
+	if b == nil {
+		return
+	}
+	d := time.Since(start)
+	b.mu.Lock()
+	b.phases[phase] += d
+	b.mu.Unlock()
+}
+
+// Elapsed returns the wall-clock time since the cycle started.
+func (b *Budget) Elapsed() time.Duration {
+	if b == nil {
+		return 0
+	}
+	return time.Since(b.start)
+}
+
+// Phases returns a copy of the time accumulated per phase.
+func (b *Budget) Phases() map[string]time.Duration {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	out := make(map[string]time.Duration, len(b.phases))
+	for phase, d := range b.phases {
+		out[phase] = d
+	}
+	return out
+}
+
+// Summary renders the phase breakdown for log lines.
+func (b *Budget) Summary() string {
+	phases := b.Phases()
+	s := ""
+	for _, phase := range []string{PhaseAPIList, PhaseProxyFetch, PhaseParse, PhaseDetail, PhaseAggregate} {
+		d, ok := phases[phase]
+		if !ok {
+			continue
+		}
+		if s != "" {
+			s += " "
+		}
+		s += phase + "=" + d.Round(time.Millisecond).String()
+	}
+	return s
+}
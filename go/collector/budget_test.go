@@ -0,0 +1,24 @@
+package collector
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBudgetSummary(t *testing.T) {
+	b := NewBudget()
+	b.phases[PhaseParse] = 250 * time.Millisecond
+	b.phases[PhaseAPIList] = 1200 * time.Millisecond
+	b.phases[PhaseParse] += 250 * time.Millisecond
+	if got, want := b.Summary(), "api_list=1.2s parse=500ms"; got != want {
+		t.Errorf("Summary = %q, want %q", got, want)
+	}
+}
+
+func TestNilBudget(t *testing.T) {
+	var b *Budget
+	b.Since(PhaseParse, time.Now())
+	if b.Elapsed() != 0 || b.Phases() != nil || b.Summary() != "" {
+		t.Error("nil Budget should report nothing")
+	}
+}
@@ -0,0 +1,179 @@
+package collector
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// OverflowValue replaces label values beyond a LabelCap.
+const OverflowValue = "other"
+
+// LabelCap limits the distinct values of one label per metric family and,
+// with a scope, per value of the scope label: pod=500/namespace allows 500
+// pods per namespace in each family.
+type LabelCap struct {
+	Label string
+	Max   int
+	Scope string // optional
+}
+
+func (c LabelCap) String() string {
+	s := c.Label + "=" + strconv.Itoa(c.Max)
+	if c.Scope != "" {
+		s += "/" + c.Scope
+	}
+	return s
+}
+
+// ParseLabelCaps parses a ';'-separated list such as
+// `pod=500/namespace;container=50`.
+func ParseLabelCaps(spec string) ([]LabelCap, error) {
+	var caps []LabelCap
+	for _, entry := range strings.Split(spec, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		label, limit, ok := strings.Cut(entry, "=")
+		if !ok || label == "" {
+			return nil, fmt.Errorf("label cap %q: want <label>=<max>[/<scope-label>]", entry)
+		}
+		limit, scope, _ := strings.Cut(limit, "/")
+		max, err := strconv.Atoi(limit)
+		if err != nil || max <= 0 {
+			return nil, fmt.Errorf("label cap %q: max must be a positive integer", entry)
+		}
+		if scope == label {
+			return nil, fmt.Errorf("label cap %q: a label cannot be its own scope", entry)
+		}
+		caps = append(caps, LabelCap{Label: label, Max: max, Scope: scope})
+	}
+	return caps, nil
+}
+
+type capKey struct {
+	cap    int // index into CardinalityGuard.caps
+	family string
+	scope  string
+}
+
+// CardinalityGuard enforces LabelCaps on raw series, folding values beyond
+// a cap into OverflowValue. Values admitted in the previous Apply keep
+// their place while they are still present, so a churn storm of new pods
+// lands in the overflow bucket instead of displacing established series.
+// It is safe for concurrent use.
+type CardinalityGuard struct {
+	caps []LabelCap
+
+	mu       sync.Mutex
+	admitted map[capKey]map[string]bool
+}
+
+// NewCardinalityGuard returns a guard for caps. A guard without caps
+// returns series unchanged.
+func NewCardinalityGuard(caps []LabelCap) *CardinalityGuard {
+	return &CardinalityGuard{caps: caps, admitted: make(map[capKey]map[string]bool)}
+}
+
+// Apply returns series with the caps enforced, and for every capped label
+// the number of distinct values folded into OverflowValue. Series that end up
+// with identical labels are merged by summing their values, which is
+// right for the counters and usage gauges passthrough is meant for.
+func (g *CardinalityGuard) Apply(series []RawSeries) ([]RawSeries, map[string]int) {
+	overflowed := make(map[string]int)
+	if len(g.caps) == 0 {
+		return series, overflowed
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	series = append([]RawSeries(nil), series...)
+	admitted := make(map[capKey]map[string]bool)
+	for i, c := range g.caps {
+		overflowed[c.Label] += 0
+		present := make(map[capKey]map[string]bool)
+		for _, s := range series {
+			v, ok := s.Labels[c.Label]
+			if !ok || v == "" || v == OverflowValue {
+				continue
+			}
+			key := capKey{cap: i, family: s.Name, scope: s.Labels[c.Scope]}
+			if present[key] == nil {
+				present[key] = make(map[string]bool)
+			}
+			present[key][v] = true
+		}
+
+		for key, values := range present {
+			keep := make(map[string]bool, c.Max)
+			var fresh []string
+			for v := range values {
+				if g.admitted[key][v] {
+					keep[v] = true
+				} else {
+					fresh = append(fresh, v)
+				}
+			}
+			sort.Strings(fresh)
+			for _, v := range fresh {
+				if len(keep) == c.Max {
+					break
+				}
+				keep[v] = true
+			}
+			admitted[key] = keep
+			overflowed[c.Label] += len(values) - len(keep)
+		}
+
+		for j, s := range series {
+			v, ok := s.Labels[c.Label]
+			if !ok || v == "" || v == OverflowValue {
+				continue
+			}
+			if !admitted[capKey{cap: i, family: s.Name, scope: s.Labels[c.Scope]}][v] {
+				lbls := make(map[string]string, len(s.Labels))
+				for k, v := range s.Labels {
+					lbls[k] = v
+				}
+				lbls[c.Label] = OverflowValue
+				series[j].Labels = lbls
+			}
+		}
+	}
+	g.admitted = admitted
+	return mergeSeries(series), overflowed
+}
+
+// mergeSeries sums series with the same name and labels, keeping the order
+// of first occurrence.
+func mergeSeries(series []RawSeries) []RawSeries {
+	index := make(map[string]int, len(series))
+	out := series[:0:0]
+	for _, s := range series {
+		key := seriesKey(s)
+		if i, ok := index[key]; ok {
+			out[i].Value += s.Value
+			continue
+		}
+		index[key] = len(out)
+		out = append(out, s)
+	}
+	return out
+}
+
+func seriesKey(s RawSeries) string {
+	keys := make([]string, 0, len(s.Labels))
+	for k := range s.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	b.WriteString(s.Name)
+	for _, k := range keys {
+		b.WriteString("\xff" + k + "\xfe" + s.Labels[k])
+	}
+	return b.String()
+}
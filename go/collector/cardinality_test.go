@@ -0,0 +1,62 @@
+package collector
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseLabelCaps(t *testing.T) {
+	caps, err := ParseLabelCaps(" pod=500/namespace; container=50 ;")
+	if err != nil {
+		t.Fatalf("ParseLabelCaps: %v", err)
+	}
+	want := []LabelCap{{Label: "pod", Max: 500, Scope: "namespace"}, {Label: "container", Max: 50}}
+	if !reflect.DeepEqual(caps, want) {
+		t.Errorf("ParseLabelCaps = %+v, want %+v", caps, want)
+	}
+	for _, bad := range []string{"pod", "=5", "pod=0", "pod=x", "pod=5/pod"} {
+		if _, err := ParseLabelCaps(bad); err == nil {
+			t.Errorf("ParseLabelCaps(%q): expected error", bad)
+		}
+	}
+}
+
+func TestCardinalityGuard(t *testing.T) {
+	g := NewCardinalityGuard([]LabelCap{{Label: "pod", Max: 2, Scope: "namespace"}})
+	series := func(pods ...string) []RawSeries {
+		var out []RawSeries
+		for _, p := range pods {
+			out = append(out, RawSeries{Name: "m", Labels: map[string]string{"namespace": "shop", "pod": p}, Value: 1})
+		}
+		return append(out, RawSeries{Name: "m", Labels: map[string]string{"namespace": "batch", "pod": "z"}, Value: 1})
+	}
+
+	got, overflowed := g.Apply(series("c", "b"))
+	if len(got) != 3 || !reflect.DeepEqual(overflowed, map[string]int{"pod": 0}) {
+		t.Fatalf("within the cap: %+v, %v", got, overflowed)
+	}
+
+	// a and d sort before c, but b and c were admitted first and keep their
+	// series; the newcomers share the overflow bucket.
+	got, overflowed = g.Apply(series("a", "b", "c", "d"))
+	want := []RawSeries{
+		{Name: "m", Labels: map[string]string{"namespace": "shop", "pod": OverflowValue}, Value: 2},
+		{Name: "m", Labels: map[string]string{"namespace": "shop", "pod": "b"}, Value: 1},
+		{Name: "m", Labels: map[string]string{"namespace": "shop", "pod": "c"}, Value: 1},
+		{Name: "m", Labels: map[string]string{"namespace": "batch", "pod": "z"}, Value: 1},
+	}
+	if !reflect.DeepEqual(got, want) || overflowed["pod"] != 2 {
+		t.Errorf("over the cap = %+v, %v; want %+v, 2", got, overflowed, want)
+	}
+
+	// Once b is gone its slot goes to the first newcomer.
+	got, _ = g.Apply(series("a", "c", "d"))
+	if got[0].Labels["pod"] != "a" || got[2].Labels["pod"] != OverflowValue {
+		t.Errorf("after churn = %+v, want a admitted and d overflowed", got)
+	}
+
+	in := series("e")
+	if out, _ := NewCardinalityGuard(nil).Apply(in); !reflect.DeepEqual(out, in) {
+		t.Errorf("guard without caps changed series: %+v", out)
+	}
+}
@@ -0,0 +1,202 @@
+package collector
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Control plane components of -control-plane-targets.
+const (
+	ComponentScheduler         = "scheduler"
+	ComponentControllerManager = "controller-manager"
+	ComponentEtcd              = "etcd"
+)
+
+// Series the control plane aggregates are read from.
+const (
+	SchedulerAttemptDuration = "scheduler_scheduling_attempt_duration_seconds_bucket"
+	// SchedulerE2EDuration is the histogram of Kubernetes releases before
+	// 1.23, read when SchedulerAttemptDuration is missing.
+	SchedulerE2EDuration = "scheduler_e2e_scheduling_duration_seconds_bucket"
+	EtcdDBSize           = "etcd_mvcc_db_total_size_in_bytes"
+	EtcdLeaderChanges    = "etcd_server_leader_changes_seen_total"
+	EtcdHasLeader        = "etcd_server_has_leader"
+)
+
+// ControlPlaneComponent is where the pods of one control plane component
+// serve their metrics.
+type ControlPlaneComponent struct {
+	Name string
+	// PodLabel is the value of the component label of its static pods
+	// in kube-system, as kubeadm, kops and Kubespray set it.
+	PodLabel string
+	Port     int
+	// Scheme is https, scraped with the ServiceAccount token, or http,
+	// scraped without credentials.
+	Scheme string
+}
+
+var controlPlaneDefaults = map[string]ControlPlaneComponent{
+	ComponentScheduler:         {Name: ComponentScheduler, PodLabel: "kube-scheduler", Port: 10259, Scheme: "https"},
+	ComponentControllerManager: {Name: ComponentControllerManager, PodLabel: "kube-controller-manager", Port: 10257, Scheme: "https"},
+	ComponentEtcd:              {Name: ComponentEtcd, PodLabel: "etcd", Port: 2381, Scheme: "http"},
+}
+
+// ParseControlPlaneTargets parses "<component>[=<port>],...", e.g.
+// "scheduler,controller-manager,etcd=2382". A component without a port
+// keeps its default: scheduler 10259 and controller-manager 10257 over
+// HTTPS, etcd's metrics listener 2381 over HTTP. etcd's client port needs
+// client certificates and is not supported.
+func ParseControlPlaneTargets(spec string) ([]ControlPlaneComponent, error) {
+	var out []ControlPlaneComponent
+	seen := make(map[string]bool)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, port, hasPort := strings.Cut(entry, "=")
+		c, ok := controlPlaneDefaults[name]
+		if !ok {
+			return nil, fmt.Errorf("control plane target %q: component must be %s, %s or %s", entry, ComponentScheduler, ComponentControllerManager, ComponentEtcd)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("control plane target %q: %s given twice", entry, name)
+		}
+		seen[name] = true
+		if hasPort {
+			n, err := strconv.Atoi(port)
+			if err != nil || n <= 0 || n > 65535 {
+				return nil, fmt.Errorf("control plane target %q: invalid port", entry)
+			}
+			c.Port = n
+		}
+		out = append(out, c)
+	}
+	return out, nil
+}
+
+// ControlPlaneTarget is one control plane pod to scrape.
+type ControlPlaneTarget struct {
+	Component ControlPlaneComponent
+	Pod       string
+	URL       string
+}
+
+// ControlPlaneTargets returns the running kube-system pods among pods that
+// are labelled component=<PodLabel> of one of components. They are
+// scraped at their pod IP, which for static pods on the host network is
+// the node's.
+func ControlPlaneTargets(pods []corev1.Pod, components []ControlPlaneComponent) []ControlPlaneTarget {
+	var out []ControlPlaneTarget
+	for _, p := range pods {
+		if p.Namespace != "kube-system" || p.Status.Phase != corev1.PodRunning || p.Status.PodIP == "" {
+			continue
+		}
+		for _, c := range components {
+			if p.Labels["component"] == c.PodLabel {
+				url := c.Scheme + "://" + net.JoinHostPort(p.Status.PodIP, strconv.Itoa(c.Port)) + "/metrics"
+				out = append(out, ControlPlaneTarget{Component: c, Pod: p.Name, URL: url})
+			}
+		}
+	}
+	return out
+}
+
+// ControlPlaneSample is what one control plane pod reports. Fields of
+// other components stay zero.
+type ControlPlaneSample struct {
+	// SchedulingBuckets is the cumulative scheduling latency histogram of
+	// the pods the scheduler placed, by upper bound.
+	SchedulingBuckets map[float64]float64
+	EtcdDBSizeBytes   float64
+	EtcdLeaderChanges float64
+	EtcdHasLeader     bool
+}
+
+// ControlPlane scrapes targets, the HTTPS ones with client and the HTTP
+// ones with plain, which must not send credentials. Samples are keyed by
+// pod name. Failed pods are skipped and reported in errs.
+func (s *Scraper) ControlPlane(ctx context.Context, client, plain *http.Client, targets []ControlPlaneTarget) (samples map[string]ControlPlaneSample, errs []*TargetError) {
+	samples = make(map[string]ControlPlaneSample)
+	for _, t := range targets {
+		c := client
+		if t.Component.Scheme == "http" {
+			c = plain
+		}
+		body, _, _, err := s.fetch(ctx, c, t.URL, "text/plain")
+		if err == nil {
+			samples[t.Pod], err = ParseControlPlane(bytes.NewReader(body))
+		}
+		if err != nil {
+			errs = append(errs, &TargetError{Target: t.Component.Name + ":" + t.Pod, Err: err})
+			delete(samples, t.Pod)
+		}
+	}
+	return samples, errs
+}
+
+var (
+	lePrefix     = []byte(`le="`)
+	resultPrefix = []byte(`result="`)
+)
+
+// ParseControlPlane reads the scheduling latency histogram and the etcd
+// series from the exposition of a control plane pod. Scheduling attempts
+// with a result other than "scheduled" are left out.
+func ParseControlPlane(body io.Reader) (ControlPlaneSample, error) {
+	var c ControlPlaneSample
+	attempts, e2e := make(map[float64]float64), make(map[float64]float64)
+	r := bufio.NewReader(body)
+	for {
+		line, err := r.ReadBytes('\n')
+		line = bytes.TrimRight(line, "\r\n")
+		if len(line) > 0 && line[0] != '#' {
+			if name, lbls, value, ok := splitSampleLine(line); ok {
+				switch string(name) {
+				case SchedulerAttemptDuration, SchedulerE2EDuration:
+					if res := labelValue(lbls, resultPrefix); len(res) > 0 && string(res) != "scheduled" {
+						break
+					}
+					le, err := strconv.ParseFloat(string(labelValue(lbls, lePrefix)), 64)
+					if err != nil {
+						break
+					}
+					if string(name) == SchedulerAttemptDuration {
+						attempts[le] += parseValue(value)
+					} else {
+						e2e[le] += parseValue(value)
+					}
+				case EtcdDBSize:
+					c.EtcdDBSizeBytes = parseValue(value)
+				case EtcdLeaderChanges:
+					c.EtcdLeaderChanges = parseValue(value)
+				case EtcdHasLeader:
+					c.EtcdHasLeader = parseValue(value) == 1
+				}
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return ControlPlaneSample{}, err
+		}
+	}
+	switch {
+	case len(attempts) > 0:
+		c.SchedulingBuckets = attempts
+	case len(e2e) > 0:
+		c.SchedulingBuckets = e2e
+	}
+	return c, nil
+}
@@ -0,0 +1,84 @@
+package collector
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParseControlPlaneTargets(t *testing.T) {
+	got, err := ParseControlPlaneTargets("scheduler, etcd=2382")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ControlPlaneComponent{
+		{Name: "scheduler", PodLabel: "kube-scheduler", Port: 10259, Scheme: "https"},
+		{Name: "etcd", PodLabel: "etcd", Port: 2382, Scheme: "http"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseControlPlaneTargets = %+v, want %+v", got, want)
+	}
+	for _, bad := range []string{"apiserver", "etcd=0", "etcd=x", "scheduler,scheduler"} {
+		if _, err := ParseControlPlaneTargets(bad); err == nil {
+			t.Errorf("ParseControlPlaneTargets(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestControlPlaneTargets(t *testing.T) {
+	comps, _ := ParseControlPlaneTargets("scheduler,etcd")
+	pod := func(ns, name, component, ip string) corev1.Pod {
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: ns, Name: name, Labels: map[string]string{"component": component}},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning, PodIP: ip},
+		}
+	}
+	pods := []corev1.Pod{
+		pod("kube-system", "kube-scheduler-cp1", "kube-scheduler", "10.0.0.1"),
+		pod("kube-system", "etcd-cp1", "etcd", "fd00::1"),
+		pod("kube-system", "kube-controller-manager-cp1", "kube-controller-manager", "10.0.0.1"),
+		pod("default", "etcd-imposter", "etcd", "10.1.0.9"),
+	}
+	var got []string
+	for _, tg := range ControlPlaneTargets(pods, comps) {
+		got = append(got, tg.Component.Name+" "+tg.Pod+" "+tg.URL)
+	}
+	want := []string{
+		"scheduler kube-scheduler-cp1 https://10.0.0.1:10259/metrics",
+		"etcd etcd-cp1 http://[fd00::1]:2381/metrics",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ControlPlaneTargets = %q, want %q", got, want)
+	}
+}
+
+func TestParseControlPlane(t *testing.T) {
+	body := `# TYPE scheduler_scheduling_attempt_duration_seconds histogram
+scheduler_scheduling_attempt_duration_seconds_bucket{profile="default-scheduler",result="scheduled",le="0.01"} 5
+scheduler_scheduling_attempt_duration_seconds_bucket{profile="default-scheduler",result="scheduled",le="+Inf"} 7
+scheduler_scheduling_attempt_duration_seconds_bucket{profile="default-scheduler",result="unschedulable",le="0.01"} 100
+scheduler_scheduling_attempt_duration_seconds_bucket{profile="batch",result="scheduled",le="0.01"} 1
+scheduler_scheduling_attempt_duration_seconds_bucket{profile="batch",result="scheduled",le="+Inf"} 1
+scheduler_e2e_scheduling_duration_seconds_bucket{le="0.01"} 50
+etcd_mvcc_db_total_size_in_bytes 2.097152e+07
+etcd_server_leader_changes_seen_total 3
+etcd_server_has_leader 1
+`
+	got, err := ParseControlPlane(strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := ControlPlaneSample{
+		SchedulingBuckets: map[float64]float64{0.01: 6, math.Inf(1): 8},
+		EtcdDBSizeBytes:   20971520,
+		EtcdLeaderChanges: 3,
+		EtcdHasLeader:     true,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseControlPlane = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,92 @@
+package collector
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// Counters of CoreDNS that the DNS families are computed from. The names
+// before CoreDNS 1.7 are read as well.
+const (
+	CoreDNSRequests       = "coredns_dns_requests_total"
+	CoreDNSResponses      = "coredns_dns_responses_total" // by rcode
+	CoreDNSCacheHits      = "coredns_cache_hits_total"
+	CoreDNSCacheMisses    = "coredns_cache_misses_total"
+	coreDNSLegacyRequests = "coredns_dns_request_count_total"
+	coreDNSLegacyRcodes   = "coredns_dns_response_rcode_count_total"
+)
+
+var rcodePrefix = []byte(`rcode="`)
+
+// DNSCounters are the cumulative counters of one CoreDNS pod, summed over
+// its servers, zones and protocols.
+type DNSCounters struct {
+	Requests    float64
+	ServFails   float64
+	CacheHits   float64
+	CacheMisses float64
+}
+
+// CoreDNSTargets returns the CoreDNS endpoints among pods: the running pods
+// matching selector, scraped on port at /metrics.
+func CoreDNSTargets(pods []corev1.Pod, selector labels.Selector, port int) []Target {
+	return agentTargets("coredns", pods, selector, port)
+}
+
+// DNSCounters scrapes the CoreDNS pods among pods (see CoreDNSTargets)
+// through the API server and returns their counters by namespace/name.
+// Failed pods are skipped and reported in errs.
+func (s *Scraper) DNSCounters(ctx context.Context, pods []corev1.Pod, selector labels.Selector, port int) (counters map[string]DNSCounters, errs []*TargetError) {
+	counters = make(map[string]DNSCounters)
+	for _, t := range CoreDNSTargets(pods, selector, port) {
+		key := t.Namespace + "/" + t.Pod
+		body, _, _, err := s.fetch(ctx, s.Client, t.URL(s.BaseURL), "text/plain")
+		if err == nil {
+			counters[key], err = ParseCoreDNS(bytes.NewReader(body))
+		}
+		if err != nil {
+			errs = append(errs, &TargetError{Target: "coredns:" + key, Err: err})
+			delete(counters, key)
+		}
+	}
+	return counters, errs
+}
+
+// ParseCoreDNS reads the request, SERVFAIL and cache counters from the
+// exposition of a CoreDNS pod.
+func ParseCoreDNS(body io.Reader) (DNSCounters, error) {
+	var c DNSCounters
+	r := bufio.NewReader(body)
+	for {
+		line, err := r.ReadBytes('\n')
+		line = bytes.TrimRight(line, "\r\n")
+		if len(line) > 0 && line[0] != '#' {
+			if name, lbls, value, ok := splitSampleLine(line); ok {
+				switch string(name) {
+				case CoreDNSRequests, coreDNSLegacyRequests:
+					c.Requests += parseValue(value)
+				case CoreDNSResponses, coreDNSLegacyRcodes:
+					if string(labelValue(lbls, rcodePrefix)) == "SERVFAIL" {
+						c.ServFails += parseValue(value)
+					}
+				case CoreDNSCacheHits:
+					c.CacheHits += parseValue(value)
+				case CoreDNSCacheMisses:
+					c.CacheMisses += parseValue(value)
+				}
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return DNSCounters{}, err
+		}
+	}
+	return c, nil
+}
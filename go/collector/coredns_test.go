@@ -0,0 +1,35 @@
+package collector
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseCoreDNS(t *testing.T) {
+	body := `# HELP coredns_dns_requests_total Counter of DNS requests made per zone, protocol and family.
+# TYPE coredns_dns_requests_total counter
+coredns_dns_requests_total{family="1",proto="udp",server="dns://:53",type="A",zone="."} 900
+coredns_dns_requests_total{family="1",proto="tcp",server="dns://:53",type="AAAA",zone="."} 100
+coredns_dns_responses_total{plugin="",rcode="NOERROR",server="dns://:53",zone="."} 950
+coredns_dns_responses_total{plugin="",rcode="SERVFAIL",server="dns://:53",zone="."} 30
+coredns_dns_responses_total{plugin="",rcode="NXDOMAIN",server="dns://:53",zone="."} 20
+coredns_cache_hits_total{server="dns://:53",type="success",zones="."} 600
+coredns_cache_hits_total{server="dns://:53",type="denial",zones="."} 100
+coredns_cache_misses_total{server="dns://:53",zones="."} 300
+`
+	got, err := ParseCoreDNS(strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (DNSCounters{Requests: 1000, ServFails: 30, CacheHits: 700, CacheMisses: 300}); got != want {
+		t.Errorf("ParseCoreDNS = %+v, want %+v", got, want)
+	}
+
+	legacy := `coredns_dns_request_count_total{proto="udp",server="dns://:53",zone="."} 50
+coredns_dns_response_rcode_count_total{rcode="SERVFAIL",server="dns://:53",zone="."} 5
+`
+	got, _ = ParseCoreDNS(strings.NewReader(legacy))
+	if want := (DNSCounters{Requests: 50, ServFails: 5}); got != want {
+		t.Errorf("ParseCoreDNS(pre-1.7 names) = %+v, want %+v", got, want)
+	}
+}
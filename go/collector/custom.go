@@ -0,0 +1,267 @@
+package collector
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/your-org/k8s-ai-exporter/kube"
+)
+
+// Annotations that opt a pod or Service into custom target scraping. On a
+// Service, the endpoints are resolved through its EndpointSlices and each
+// backing pod is scraped on its own node.
+const (
+	AnnotationScrape = "binbots.io/scrape"
+	AnnotationPort   = "binbots.io/port"
+	AnnotationPath   = "binbots.io/path"
+	AnnotationSeries = "binbots.io/series"
+	AnnotationJob    = "binbots.io/job"
+)
+
+// Target is a single pod endpoint to scrape through the API server
+// pod proxy.
+type Target struct {
+	Job       string
+	Node      string
+	Namespace string
+	Pod       string
+	Port      int
+	Path      string
+	Series    map[string]bool
+	// HTTPS makes the API server reach the pod over HTTPS, without
+	// verifying its certificate.
+	HTTPS bool
+}
+
+// URL returns the pod proxy URL of the target.
+func (t Target) URL(baseURL string) string {
+	pod := t.Pod
+	if t.HTTPS {
+		pod = "https:" + pod
+	}
+	return fmt.Sprintf("%s/api/v1/namespaces/%s/pods/%s:%d/proxy%s", baseURL, t.Namespace, pod, t.Port, t.Path)
+}
+
+// TargetFromAnnotations builds the scrape settings shared by pod and Service
+// annotations. The node, namespace and pod are filled in by the caller.
+func TargetFromAnnotations(ann map[string]string, defaultJob string) (Target, bool) {
+	if ann[AnnotationScrape] != "true" {
+		return Target{}, false
+	}
+	series := make(map[string]bool)
+	for _, s := range strings.Split(ann[AnnotationSeries], ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			series[s] = true
+		}
+	}
+	if len(series) == 0 {
+		return Target{}, false
+	}
+	t := Target{Job: ann[AnnotationJob], Path: ann[AnnotationPath], Series: series}
+	if t.Job == "" {
+		t.Job = defaultJob
+	}
+	if t.Path == "" {
+		t.Path = "/metrics"
+	}
+	if !strings.HasPrefix(t.Path, "/") {
+		t.Path = "/" + t.Path
+	}
+	if p := ann[AnnotationPort]; p != "" {
+		port, err := strconv.Atoi(p)
+		if err != nil || port <= 0 {
+			return Target{}, false
+		}
+		t.Port = port
+	}
+	return t, true
+}
+
+// DiscoverPodTargets returns targets for annotated pods. Pods must set the
+// port annotation since there is no Service to fall back on.
+func DiscoverPodTargets(pods []corev1.Pod) []Target {
+	var out []Target
+	for _, p := range pods {
+		t, ok := TargetFromAnnotations(p.Annotations, kube.PodWorkload(&p).Name)
+		if !ok || t.Port == 0 {
+			continue
+		}
+		t.Node, t.Namespace, t.Pod = p.Spec.NodeName, p.Namespace, p.Name
+		out = append(out, t)
+	}
+	return out
+}
+
+// DiscoverServiceTargets resolves annotated Services to their ready pod
+// endpoints via EndpointSlices, with one List per namespace that has
+// annotated Services. Without a port annotation the first slice port is
+// used.
+func DiscoverServiceTargets(ctx context.Context, clientset kubernetes.Interface) ([]Target, error) {
+	svcs, err := clientset.CoreV1().Services("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	bases := make(map[string]map[string]Target) // namespace -> service -> target
+	var namespaces []string
+	for _, svc := range svcs.Items {
+		base, ok := TargetFromAnnotations(svc.Annotations, svc.Name)
+		if !ok {
+			continue
+		}
+		if bases[svc.Namespace] == nil {
+			bases[svc.Namespace] = make(map[string]Target)
+			namespaces = append(namespaces, svc.Namespace)
+		}
+		bases[svc.Namespace][svc.Name] = base
+	}
+
+	var out []Target
+	for _, ns := range namespaces {
+		names := make([]string, 0, len(bases[ns]))
+		for name := range bases[ns] {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		slices, err := clientset.DiscoveryV1().EndpointSlices(ns).List(ctx, metav1.ListOptions{
+			LabelSelector: discoveryv1.LabelServiceName + " in (" + strings.Join(names, ",") + ")",
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, slice := range slices.Items {
+			base, ok := bases[ns][slice.Labels[discoveryv1.LabelServiceName]]
+			if !ok {
+				continue
+			}
+			out = append(out, endpointSliceTargets(base, &slice)...)
+		}
+	}
+	return out, nil
+}
+
+func endpointSliceTargets(base Target, slice *discoveryv1.EndpointSlice) []Target {
+	port := base.Port
+	if port == 0 {
+		if len(slice.Ports) == 0 || slice.Ports[0].Port == nil {
+			return nil
+		}
+		port = int(*slice.Ports[0].Port)
+	}
+	var out []Target
+	for _, ep := range slice.Endpoints {
+		if ep.TargetRef == nil || ep.TargetRef.Kind != "Pod" || ep.NodeName == nil {
+			continue
+		}
+		if ep.Conditions.Ready != nil && !*ep.Conditions.Ready {
+			continue
+		}
+		t := base
+		t.Port = port
+		t.Node, t.Namespace, t.Pod = *ep.NodeName, ep.TargetRef.Namespace, ep.TargetRef.Name
+		if t.Namespace == "" {
+			t.Namespace = slice.Namespace
+		}
+		out = append(out, t)
+	}
+	return out
+}
+
+// CustomSeries identifies one re-exported custom target series.
+type CustomSeries struct {
+	Node, Job, Metric string
+}
+
+// TargetError is a failed custom target discovery or scrape. Target is
+// "custom:discovery" or "custom:<namespace>/<pod>".
+type TargetError struct {
+	Target string
+	Err    error
+}
+
+func (e *TargetError) Error() string { return e.Target + ": " + e.Err.Error() }
+
+func (e *TargetError) Unwrap() error { return e.Err }
+
+// ScrapeCustomTargets discovers annotated pods (among pods) and Services,
+// scrapes each target once and returns the selected series summed per node
+// and job. Failed targets are skipped and reported in errs.
+func ScrapeCustomTargets(ctx context.Context, clientset kubernetes.Interface, client *http.Client, baseURL string, pods []corev1.Pod) (sums map[CustomSeries]float64, errs []*TargetError) {
+	targets := DiscoverPodTargets(pods)
+	svcTargets, err := DiscoverServiceTargets(ctx, clientset)
+	if err != nil {
+		errs = append(errs, &TargetError{Target: "custom:discovery", Err: err})
+	}
+	targets = append(targets, svcTargets...)
+
+	sums = make(map[CustomSeries]float64)
+	seen := make(map[string]bool)
+	for _, t := range targets {
+		// A pod may be both annotated itself and behind an annotated Service.
+		id := t.Job + "/" + t.Namespace + "/" + t.Pod
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		values, err := scrapeSeries(ctx, client, t.URL(baseURL), t.Series)
+		if err != nil {
+			errs = append(errs, &TargetError{Target: "custom:" + t.Namespace + "/" + t.Pod, Err: err})
+			continue
+		}
+		for metric, v := range values {
+			sums[CustomSeries{t.Node, t.Job, metric}] += v
+		}
+	}
+	return sums, errs
+}
+
+func scrapeSeries(ctx context.Context, client *http.Client, url string, series map[string]bool) (map[string]float64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return sumSeries(resp.Body, series)
+}
+
+// sumSeries sums all samples of the named metric families, across label
+// sets. Lines are read whole whatever their length, since a bufio.Scanner
+// would stop at its 64KiB token limit.
+func sumSeries(body io.Reader, series map[string]bool) (map[string]float64, error) {
+	out := make(map[string]float64, len(series))
+	r := bufio.NewReader(body)
+	for {
+		line, err := r.ReadBytes('\n')
+		line = bytes.TrimRight(line, "\r\n")
+		if len(line) > 0 && line[0] != '#' {
+			if name, _, value, ok := splitSampleLine(line); ok && series[string(name)] {
+				out[string(name)] += parseValue(value)
+			}
+		}
+		if err == io.EOF {
+			return out, nil
+		}
+		if err != nil {
+			return out, err
+		}
+	}
+}
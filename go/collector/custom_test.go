@@ -0,0 +1,116 @@
+package collector
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestTargetFromAnnotations(t *testing.T) {
+	got, ok := TargetFromAnnotations(map[string]string{
+		AnnotationScrape: "true",
+		AnnotationPort:   "9400",
+		AnnotationPath:   "stats",
+		AnnotationSeries: "gpu_util, gpu_mem_used",
+	}, "dcgm")
+	if !ok {
+		t.Fatal("targetFromAnnotations: ok = false")
+	}
+	if got.Job != "dcgm" || got.Port != 9400 || got.Path != "/stats" || len(got.Series) != 2 || !got.Series["gpu_mem_used"] {
+		t.Errorf("targetFromAnnotations = %+v", got)
+	}
+
+	for _, ann := range []map[string]string{
+		{AnnotationScrape: "false", AnnotationSeries: "x"},
+		{AnnotationScrape: "true"},
+		{AnnotationScrape: "true", AnnotationSeries: "x", AnnotationPort: "http"},
+	} {
+		if _, ok := TargetFromAnnotations(ann, "job"); ok {
+			t.Errorf("targetFromAnnotations(%v) ok = true, want false", ann)
+		}
+	}
+}
+
+func TestEndpointSliceTargets(t *testing.T) {
+	port := int32(8080)
+	node := "node-a"
+	notReady := false
+	slice := discoveryv1.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "agents"},
+		Ports:      []discoveryv1.EndpointPort{{Port: &port}},
+		Endpoints: []discoveryv1.Endpoint{
+			{NodeName: &node, TargetRef: &corev1.ObjectReference{Kind: "Pod", Name: "agent-1"}},
+			{NodeName: &node, TargetRef: &corev1.ObjectReference{Kind: "Pod", Name: "agent-2"}, Conditions: discoveryv1.EndpointConditions{Ready: &notReady}},
+			{TargetRef: &corev1.ObjectReference{Kind: "Pod", Name: "agent-3"}},
+		},
+	}
+	got := endpointSliceTargets(Target{Job: "agent", Path: "/metrics"}, &slice)
+	if len(got) != 1 {
+		t.Fatalf("endpointSliceTargets returned %d targets, want 1", len(got))
+	}
+	if u := got[0].URL("https://api"); u != "https://api/api/v1/namespaces/agents/pods/agent-1:8080/proxy/metrics" {
+		t.Errorf("url = %q", u)
+	}
+}
+
+func TestSumSeries(t *testing.T) {
+	body := strings.NewReader(`# TYPE gpu_util gauge
+gpu_util{gpu="0"} 40 1700000000000
+gpu_util{gpu="1"} 60
+gpu_util_max 100
+other{note="` + strings.Repeat("x", 100<<10) + `"} 5
+gpu_util{gpu="2"} 0`)
+	got, err := sumSeries(body, map[string]bool{"gpu_util": true, "missing": true})
+	if err != nil {
+		t.Fatalf("sumSeries: %v", err)
+	}
+	if len(got) != 1 || got["gpu_util"] != 100 {
+		t.Errorf("sumSeries = %v, want map[gpu_util:100]", got)
+	}
+}
+
+func TestDiscoverServiceTargets(t *testing.T) {
+	port := int32(9400)
+	node := "node-a"
+	ann := map[string]string{AnnotationScrape: "true", AnnotationSeries: "gpu_util"}
+	service := func(ns, name string, ann map[string]string) *corev1.Service {
+		return &corev1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: ns, Name: name, Annotations: ann}}
+	}
+	slice := func(ns, svc, pod string) *discoveryv1.EndpointSlice {
+		return &discoveryv1.EndpointSlice{
+			ObjectMeta: metav1.ObjectMeta{Namespace: ns, Name: svc + "-abc", Labels: map[string]string{discoveryv1.LabelServiceName: svc}},
+			Ports:      []discoveryv1.EndpointPort{{Port: &port}},
+			Endpoints:  []discoveryv1.Endpoint{{NodeName: &node, TargetRef: &corev1.ObjectReference{Kind: "Pod", Name: pod}}},
+		}
+	}
+	clientset := fake.NewSimpleClientset(
+		service("gpu", "dcgm", ann), service("gpu", "dcgm-2", ann), service("gpu", "web", nil), service("ml", "trainer", ann),
+		slice("gpu", "dcgm", "dcgm-0"), slice("gpu", "dcgm-2", "dcgm-1"), slice("gpu", "web", "web-0"), slice("ml", "trainer", "trainer-0"),
+	)
+
+	got, err := DiscoverServiceTargets(context.Background(), clientset)
+	if err != nil {
+		t.Fatalf("DiscoverServiceTargets: %v", err)
+	}
+	pods := make(map[string]string)
+	for _, target := range got {
+		pods[target.Pod] = target.Job
+	}
+	if len(got) != 3 || pods["dcgm-0"] != "dcgm" || pods["dcgm-1"] != "dcgm-2" || pods["trainer-0"] != "trainer" {
+		t.Errorf("targets = %+v, want dcgm-0, dcgm-1 and trainer-0", got)
+	}
+	lists := 0
+	for _, a := range clientset.Actions() {
+		if a.GetVerb() == "list" && a.GetResource().Resource == "endpointslices" {
+			lists++
+		}
+	}
+	if lists != 2 {
+		t.Errorf("EndpointSlice lists = %d, want one per namespace (2)", lists)
+	}
+}
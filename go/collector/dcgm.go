@@ -0,0 +1,103 @@
+package collector
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// Series of the NVIDIA DCGM exporter that GPU usage is read from, one
+// sample per GPU.
+const (
+	DCGMGPUUtil = "DCGM_FI_DEV_GPU_UTIL" // percent
+	DCGMFBUsed  = "DCGM_FI_DEV_FB_USED"  // framebuffer memory in MiB
+)
+
+// GPUUsage is what the GPUs of a node use.
+type GPUUsage struct {
+	GPUs int
+	// Utilization is the mean utilization of the GPUs, from 0 to 1.
+	Utilization     float64
+	MemoryUsedBytes float64
+}
+
+// DCGMTargets returns the DCGM exporter endpoints among pods: the running
+// pods matching selector, scraped on port at /metrics.
+func DCGMTargets(pods []corev1.Pod, selector labels.Selector, port int) []Target {
+	return agentTargets("dcgm", pods, selector, port)
+}
+
+// agentTargets returns a target of job for each running, scheduled pod
+// among pods that matches selector, scraped on port at /metrics.
+func agentTargets(job string, pods []corev1.Pod, selector labels.Selector, port int) []Target {
+	var out []Target
+	for _, p := range pods {
+		if p.Status.Phase != corev1.PodRunning || p.Spec.NodeName == "" || !selector.Matches(labels.Set(p.Labels)) {
+			continue
+		}
+		out = append(out, Target{Job: job, Node: p.Spec.NodeName, Namespace: p.Namespace, Pod: p.Name, Port: port, Path: "/metrics"})
+	}
+	return out
+}
+
+// GPUUsage scrapes the DCGM exporter pods among pods (see DCGMTargets)
+// through the API server and returns the usage of each node's GPUs. A
+// failed pod is skipped and reported in errs; so is a node with more than
+// one exporter pod, whose GPUs would count twice.
+func (s *Scraper) GPUUsage(ctx context.Context, pods []corev1.Pod, selector labels.Selector, port int) (usage map[string]GPUUsage, errs []*TargetError) {
+	usage = make(map[string]GPUUsage)
+	for _, t := range DCGMTargets(pods, selector, port) {
+		name := "dcgm:" + t.Namespace + "/" + t.Pod
+		if _, ok := usage[t.Node]; ok {
+			errs = append(errs, &TargetError{Target: name, Err: fmt.Errorf("another DCGM exporter pod runs on node %s", t.Node)})
+			continue
+		}
+		body, _, _, err := s.fetch(ctx, s.Client, t.URL(s.BaseURL), "text/plain")
+		if err == nil {
+			usage[t.Node], err = ParseDCGM(bytes.NewReader(body))
+		}
+		if err != nil {
+			errs = append(errs, &TargetError{Target: name, Err: err})
+			delete(usage, t.Node)
+		}
+	}
+	return usage, errs
+}
+
+// ParseDCGM reads the GPU usage of one node from the exposition of its
+// DCGM exporter. GPUs are counted by their DCGMGPUUtil samples.
+func ParseDCGM(body io.Reader) (GPUUsage, error) {
+	var u GPUUsage
+	var util float64
+	r := bufio.NewReader(body)
+	for {
+		line, err := r.ReadBytes('\n')
+		line = bytes.TrimRight(line, "\r\n")
+		if len(line) > 0 && line[0] != '#' {
+			if name, _, value, ok := splitSampleLine(line); ok {
+				switch string(name) {
+				case DCGMGPUUtil:
+					u.GPUs++
+					util += parseValue(value)
+				case DCGMFBUsed:
+					u.MemoryUsedBytes += parseValue(value) * (1 << 20)
+				}
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return GPUUsage{}, err
+		}
+	}
+	if u.GPUs > 0 {
+		u.Utilization = util / 100 / float64(u.GPUs)
+	}
+	return u, nil
+}
@@ -0,0 +1,78 @@
+package collector
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const dcgmBody = `# HELP DCGM_FI_DEV_GPU_UTIL GPU utilization (in %).
+# TYPE DCGM_FI_DEV_GPU_UTIL gauge
+DCGM_FI_DEV_GPU_UTIL{gpu="0",UUID="GPU-a",Hostname="gpu-1"} 90
+DCGM_FI_DEV_GPU_UTIL{gpu="1",UUID="GPU-b",Hostname="gpu-1"} 30
+# TYPE DCGM_FI_DEV_FB_USED gauge
+DCGM_FI_DEV_FB_USED{gpu="0",UUID="GPU-a",Hostname="gpu-1"} 1024
+DCGM_FI_DEV_FB_USED{gpu="1",UUID="GPU-b",Hostname="gpu-1"} 512
+DCGM_FI_DEV_FB_FREE{gpu="0",UUID="GPU-a",Hostname="gpu-1"} 39936
+`
+
+func TestParseDCGM(t *testing.T) {
+	got, err := ParseDCGM(strings.NewReader(dcgmBody))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (GPUUsage{GPUs: 2, Utilization: 0.6, MemoryUsedBytes: 1536 << 20}); got != want {
+		t.Errorf("ParseDCGM = %+v, want %+v", got, want)
+	}
+	if got, _ := ParseDCGM(strings.NewReader("# no GPUs\n")); got != (GPUUsage{}) {
+		t.Errorf("empty exposition: %+v", got)
+	}
+}
+
+func TestGPUUsage(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/namespaces/gpu-operator/pods/dcgm-a:9400/proxy/metrics", "/api/v1/namespaces/gpu-operator/pods/dcgm-c:9400/proxy/metrics":
+			w.Write([]byte(dcgmBody))
+		default:
+			http.Error(w, "no endpoints", http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+	s := &Scraper{Client: srv.Client(), BaseURL: srv.URL}
+
+	pod := func(name, node, app string, phase corev1.PodPhase) corev1.Pod {
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "gpu-operator", Name: name, Labels: map[string]string{"app": app}},
+			Spec:       corev1.PodSpec{NodeName: node},
+			Status:     corev1.PodStatus{Phase: phase},
+		}
+	}
+	pods := []corev1.Pod{
+		pod("dcgm-a", "gpu-1", "nvidia-dcgm-exporter", corev1.PodRunning),
+		pod("dcgm-b", "gpu-2", "nvidia-dcgm-exporter", corev1.PodRunning),
+		pod("dcgm-c", "gpu-1", "nvidia-dcgm-exporter", corev1.PodRunning),
+		pod("dcgm-d", "gpu-3", "nvidia-dcgm-exporter", corev1.PodPending),
+		pod("other", "gpu-3", "web", corev1.PodRunning),
+	}
+	usage, errs := s.GPUUsage(context.Background(), pods, labels.SelectorFromSet(labels.Set{"app": "nvidia-dcgm-exporter"}), 9400)
+	want := map[string]GPUUsage{"gpu-1": {GPUs: 2, Utilization: 0.6, MemoryUsedBytes: 1536 << 20}}
+	if !reflect.DeepEqual(usage, want) {
+		t.Errorf("usage = %+v, want %+v", usage, want)
+	}
+	var failed []string
+	for _, err := range errs {
+		failed = append(failed, err.Target)
+	}
+	// dcgm-b is down; dcgm-c is a second exporter on gpu-1.
+	if want := []string{"dcgm:gpu-operator/dcgm-b", "dcgm:gpu-operator/dcgm-c"}; !reflect.DeepEqual(failed, want) {
+		t.Errorf("failed targets = %v, want %v", failed, want)
+	}
+}
@@ -0,0 +1,10 @@
+// Package collector scrapes kubelet and cAdvisor expositions through the API
+// server proxy or directly from the kubelets and parses them into per-node
+// samples. It also discovers and scrapes annotated custom targets and times
+// collection cycles.
+//
+// A typical cycle, given a proxy client from kube.ProxyClient:
+//
+//	s := &collector.Scraper{Client: client, BaseURL: baseURL, Protobuf: true}
+//	sample, err := s.Cadvisor(ctx, nodeName, nil)
+package collector
@@ -0,0 +1,177 @@
+package collector
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MaxExecOutput is the most an exec collector may print to stdout in one
+// run.
+const MaxExecOutput = 4 << 20
+
+// execWaitDelay is how long Run waits for the output of a killed program
+// to close.
+const execWaitDelay = time.Second
+
+// ExecLabel is the label every series of an exec collector gets, holding
+// the collector's name. Collectors must not set it themselves.
+const ExecLabel = "collector"
+
+var (
+	execNameRE   = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+	metricNameRE = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+	labelNameRE  = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+// ExecCollector is an external program run every detail cycle whose
+// output is re-exported.
+type ExecCollector struct {
+	Name string
+	Path string
+	Args []string
+}
+
+// ParseExecCollectors parses a ';'-separated list of
+// "<name>=<path> [<arg>...]" entries, e.g.
+// "san=/opt/collectors/san-stats --pool=a;ups=/opt/collectors/ups".
+// Names are lower-case DNS labels; arguments are split on white space and
+// not interpreted by a shell.
+func ParseExecCollectors(spec string) ([]ExecCollector, error) {
+	var out []ExecCollector
+	seen := make(map[string]bool)
+	for _, entry := range strings.Split(spec, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, cmd, ok := strings.Cut(entry, "=")
+		fields := strings.Fields(cmd)
+		if !ok || len(fields) == 0 {
+			return nil, fmt.Errorf("exec collector %q: want <name>=<path> [<arg>...]", entry)
+		}
+		if !execNameRE.MatchString(name) {
+			return nil, fmt.Errorf("exec collector %q: name must be lower-case letters, digits and '-'", entry)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("exec collector %q: %s given twice", entry, name)
+		}
+		seen[name] = true
+		out = append(out, ExecCollector{Name: name, Path: fields[0], Args: fields[1:]})
+	}
+	return out, nil
+}
+
+// execOutput is what an exec collector prints to stdout.
+type execOutput struct {
+	Metrics []struct {
+		Name   string            `json:"name"`
+		Labels map[string]string `json:"labels"`
+		Value  *float64          `json:"value"`
+	} `json:"metrics"`
+}
+
+// Run runs c with env as its whole environment and returns the series it
+// printed, each labelled collector=<name>. The program is killed when ctx
+// ends. A non-zero exit status, output over MaxExecOutput or output that is
+// not a valid document fails the run, and none of its series are returned.
+func (c ExecCollector) Run(ctx context.Context, env []string) ([]RawSeries, error) {
+	cmd := exec.CommandContext(ctx, c.Path, c.Args...)
+	cmd.Env = env
+	// Children the program started may hold stdout open after it was
+	// killed; stop waiting for them.
+	cmd.WaitDelay = execWaitDelay
+	stdout, stderr := &limitedBuffer{max: MaxExecOutput}, &limitedBuffer{max: 1024}
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%w: %s", err, msg)
+		}
+		return nil, err
+	}
+	if stdout.overflow {
+		return nil, fmt.Errorf("output exceeds %d bytes", MaxExecOutput)
+	}
+	return ParseExecOutput(c.Name, stdout.Bytes())
+}
+
+// ParseExecOutput reads the document an exec collector printed:
+//
+//	{"metrics": [{"name": "san_pool_used_bytes", "labels": {"pool": "a"}, "value": 1.2e12}]}
+//
+// Names and label names follow the Prometheus rules. Names starting with
+// k8s_ belong to the exporter and are refused, as are the label
+// collector and two series with the same name and labels.
+func ParseExecOutput(name string, body []byte) ([]RawSeries, error) {
+	var doc execOutput
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid output: %w", err)
+	}
+	out := make([]RawSeries, 0, len(doc.Metrics))
+	seen := make(map[string]bool, len(doc.Metrics))
+	for _, m := range doc.Metrics {
+		if !metricNameRE.MatchString(m.Name) {
+			return nil, fmt.Errorf("invalid metric name %q", m.Name)
+		}
+		if strings.HasPrefix(m.Name, "k8s_") {
+			return nil, fmt.Errorf("metric %s: the k8s_ prefix is reserved for the exporter", m.Name)
+		}
+		if m.Value == nil {
+			return nil, fmt.Errorf("metric %s: no value", m.Name)
+		}
+		lbls := make(map[string]string, len(m.Labels)+1)
+		keys := make([]string, 0, len(m.Labels))
+		for k, v := range m.Labels {
+			if !labelNameRE.MatchString(k) || strings.HasPrefix(k, "__") {
+				return nil, fmt.Errorf("metric %s: invalid label name %q", m.Name, k)
+			}
+			if k == ExecLabel {
+				return nil, fmt.Errorf("metric %s: the label %s is set by the exporter", m.Name, ExecLabel)
+			}
+			lbls[k] = v
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		id := m.Name
+		for _, k := range keys {
+			id += "\xff" + k + "\xff" + lbls[k]
+		}
+		if seen[id] {
+			return nil, fmt.Errorf("metric %s: duplicate series %v", m.Name, m.Labels)
+		}
+		seen[id] = true
+		lbls[ExecLabel] = name
+		out = append(out, RawSeries{Name: m.Name, Labels: lbls, Value: *m.Value})
+	}
+	return out, nil
+}
+
+// limitedBuffer keeps the first max bytes written to it and drops the rest,
+// so a runaway program cannot exhaust the exporter's memory.
+type limitedBuffer struct {
+	bytes.Buffer
+	max      int
+	overflow bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.max - b.Len(); len(p) > room {
+		b.overflow = true
+		if room > 0 {
+			b.Buffer.Write(p[:room])
+		}
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
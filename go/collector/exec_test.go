@@ -0,0 +1,98 @@
+package collector
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseExecCollectors(t *testing.T) {
+	got, err := ParseExecCollectors(" san=/opt/san-stats --pool=a  -v ; ups=/opt/ups;")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ExecCollector{
+		{Name: "san", Path: "/opt/san-stats", Args: []string{"--pool=a", "-v"}},
+		{Name: "ups", Path: "/opt/ups", Args: []string{}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseExecCollectors = %+v, want %+v", got, want)
+	}
+	for _, spec := range []string{"san", "san=", "SAN=/opt/san", "a=/x;a=/y"} {
+		if _, err := ParseExecCollectors(spec); err == nil {
+			t.Errorf("ParseExecCollectors(%q) succeeded", spec)
+		}
+	}
+}
+
+func TestParseExecOutput(t *testing.T) {
+	got, err := ParseExecOutput("san", []byte(`{"metrics": [
+		{"name": "san_pool_used_bytes", "labels": {"pool": "a"}, "value": 1.5e12},
+		{"name": "san_up", "value": 1}
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []RawSeries{
+		{Name: "san_pool_used_bytes", Labels: map[string]string{"pool": "a", "collector": "san"}, Value: 1.5e12},
+		{Name: "san_up", Labels: map[string]string{"collector": "san"}, Value: 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseExecOutput = %+v, want %+v", got, want)
+	}
+
+	for _, body := range []string{
+		`not json`,
+		`{"metrics": [{"name": "san up", "value": 1}]}`,
+		`{"metrics": [{"name": "k8s_node_san", "value": 1}]}`,
+		`{"metrics": [{"name": "san_up"}]}`,
+		`{"metrics": [{"name": "san_up", "labels": {"collector": "x"}, "value": 1}]}`,
+		`{"metrics": [{"name": "san_up", "labels": {"__name__": "x"}, "value": 1}]}`,
+		`{"metrics": [{"name": "san_up", "value": 1}, {"name": "san_up", "value": 0}]}`,
+		`{"metrics": [], "extra": true}`,
+	} {
+		if _, err := ParseExecOutput("san", []byte(body)); err == nil {
+			t.Errorf("ParseExecOutput(%s) succeeded", body)
+		}
+	}
+}
+
+func TestExecCollectorRun(t *testing.T) {
+	if _, err := os.Stat("/bin/sh"); err != nil {
+		t.Skip("no /bin/sh")
+	}
+	script := filepath.Join(t.TempDir(), "collector.sh")
+	body := `#!/bin/sh
+case "$1" in
+ok) echo '{"metrics": [{"name": "san_up", "labels": {"pool": "'"$POOL"'"}, "value": 1}]}' ;;
+fail) echo "appliance unreachable" >&2; exit 1 ;;
+hang) sleep 10 ;;
+esac
+`
+	if err := os.WriteFile(script, []byte(body), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ExecCollector{Name: "san", Path: script, Args: []string{"ok"}}.Run(context.Background(), []string{"POOL=a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].Labels["pool"] != "a" || got[0].Labels["collector"] != "san" {
+		t.Errorf("Run = %+v, want san_up{pool=a,collector=san}", got)
+	}
+
+	_, err = ExecCollector{Name: "san", Path: script, Args: []string{"fail"}}.Run(context.Background(), nil)
+	if err == nil || !strings.Contains(err.Error(), "appliance unreachable") {
+		t.Errorf("Run(fail) error = %v, want the program's stderr", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := (ExecCollector{Name: "san", Path: script, Args: []string{"hang"}}).Run(ctx, nil); err != context.DeadlineExceeded {
+		t.Errorf("Run(hang) error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
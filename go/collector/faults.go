@@ -0,0 +1,110 @@
+package collector
+
+import (
+	"errors"
+	"io"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// Kinds of injected faults, passed to Faults.OnFault.
+const (
+	FaultFailure  = "failure"
+	FaultDelay    = "delay"
+	FaultTruncate = "truncate"
+)
+
+// ErrInjectedFault is returned for requests failed by Faults.
+var ErrInjectedFault = errors.New("injected fault: target failure")
+
+// Faults injects failures into scrapes, for testing alerts and error
+// handling in staging without breaking real kubelets. Each ratio is the
+// probability, per request, of that fault.
+type Faults struct {
+	FailureRatio  float64 // fail the request with ErrInjectedFault
+	DelayRatio    float64 // wait Delay before sending it
+	Delay         time.Duration
+	TruncateRatio float64 // return only the first half of the body
+
+	// OnFault, when set, is called for every injected fault.
+	OnFault func(kind string)
+	// Rand returns numbers in [0, 1); default math/rand.
+	Rand func() float64
+}
+
+// Enabled reports whether f injects anything.
+func (f *Faults) Enabled() bool {
+	return f.FailureRatio > 0 || (f.DelayRatio > 0 && f.Delay > 0) || f.TruncateRatio > 0
+}
+
+// Wrap returns rt with the faults injected into its requests.
+func (f *Faults) Wrap(rt http.RoundTripper) http.RoundTripper {
+	return &faultTransport{faults: f, next: rt}
+}
+
+type faultTransport struct {
+	faults *Faults
+	next   http.RoundTripper
+}
+
+func (t *faultTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	f := t.faults
+	if t.hit(f.FailureRatio, FaultFailure) {
+		return nil, ErrInjectedFault
+	}
+	if f.Delay > 0 && t.hit(f.DelayRatio, FaultDelay) {
+		timer := time.NewTimer(f.Delay)
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		}
+	}
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || !t.hit(f.TruncateRatio, FaultTruncate) {
+		return resp, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(&truncatedReader{data: body[:len(body)/2]})
+	resp.ContentLength = -1
+	resp.Header.Del("Content-Length")
+	return resp, nil
+}
+
+func (t *faultTransport) hit(ratio float64, kind string) bool {
+	if ratio <= 0 {
+		return false
+	}
+	r := rand.Float64
+	if t.faults.Rand != nil {
+		r = t.faults.Rand
+	}
+	if r() >= ratio {
+		return false
+	}
+	if t.faults.OnFault != nil {
+		t.faults.OnFault(kind)
+	}
+	return true
+}
+
+// truncatedReader serves data and then fails like a connection dropped
+// mid-body.
+type truncatedReader struct {
+	data []byte
+}
+
+func (r *truncatedReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, io.ErrUnexpectedEOF
+	}
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
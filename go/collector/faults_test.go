@@ -0,0 +1,62 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestFaults(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("0123456789"))
+	}))
+	defer srv.Close()
+
+	var injected []string
+	get := func(f *Faults) (string, error) {
+		f.OnFault = func(kind string) { injected = append(injected, kind) }
+		c := &http.Client{Transport: f.Wrap(http.DefaultTransport)}
+		resp, err := c.Get(srv.URL)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		return string(body), err
+	}
+	always := func() float64 { return 0 }
+	never := func() float64 { return 0.99 }
+
+	if _, err := get(&Faults{FailureRatio: 0.5, Rand: always}); !errors.Is(err, ErrInjectedFault) {
+		t.Errorf("failure: err = %v, want ErrInjectedFault", err)
+	}
+	body, err := get(&Faults{TruncateRatio: 0.5, Rand: always})
+	if body != "01234" || !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("truncate: body %q, err %v; want 01234 and ErrUnexpectedEOF", body, err)
+	}
+	start := time.Now()
+	if body, err := get(&Faults{DelayRatio: 1, Delay: 50 * time.Millisecond, Rand: always}); err != nil || body != "0123456789" || time.Since(start) < 50*time.Millisecond {
+		t.Errorf("delay: body %q, err %v after %s", body, err, time.Since(start))
+	}
+	if body, err := get(&Faults{FailureRatio: 0.5, TruncateRatio: 0.5, Rand: never}); err != nil || body != "0123456789" {
+		t.Errorf("no hit: body %q, err %v", body, err)
+	}
+	if want := []string{FaultFailure, FaultTruncate, FaultDelay}; len(injected) != 3 || injected[0] != want[0] || injected[1] != want[1] || injected[2] != want[2] {
+		t.Errorf("OnFault calls = %v, want %v", injected, want)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	f := &Faults{DelayRatio: 1, Delay: time.Minute, Rand: always}
+	if _, err := f.Wrap(http.DefaultTransport).RoundTrip(req); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("delay past the deadline: err = %v", err)
+	}
+	if (&Faults{DelayRatio: 1}).Enabled() || !(&Faults{TruncateRatio: 0.1}).Enabled() {
+		t.Error("Enabled: a delay ratio without a delay injects nothing")
+	}
+}
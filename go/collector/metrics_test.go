@@ -1,4 +1,4 @@
-package main
+package collector
 
 import (
 	"strings"
@@ -14,13 +14,14 @@ func TestParsePrometheusValue(t *testing.T) {
 		{"container_memory_working_set_bytes{id=\"/\"} 1073741824", 1073741824},
 		{"metric_name 0", 0},
 		{"metric_name 1.5e2", 150},
+		{"container_cpu_usage_seconds_total{id=\"/\"} 123.45 1700000000000", 123.45},
 		{"no_value", 0},
 		{"", 0},
 	}
 	for _, tt := range tests {
-		got := parsePrometheusValue(tt.line)
+		got := ParsePrometheusValue(tt.line)
 		if got != tt.want {
-			t.Errorf("parsePrometheusValue(%q) = %v, want %v", tt.line, got, tt.want)
+			t.Errorf("ParsePrometheusValue(%q) = %v, want %v", tt.line, got, tt.want)
 		}
 	}
 }
@@ -34,9 +35,9 @@ container_cpu_usage_seconds_total{id="/system"} 0.2
 container_memory_working_set_bytes{id="/"} 536870912
 container_memory_working_set_bytes{id="/system"} 268435456
 `)
-	cpu, mem, err := parseContainerMetrics(body, "container_cpu_usage_seconds_total", "container_memory_working_set_bytes")
+	cpu, mem, err := ParseContainerMetrics(body, "container_cpu_usage_seconds_total", "container_memory_working_set_bytes")
 	if err != nil {
-		t.Fatalf("parseContainerMetrics: %v", err)
+		t.Fatalf("ParseContainerMetrics: %v", err)
 	}
 	if cpu != 1.7 {
 		t.Errorf("cpu = %v, want 1.7", cpu)
@@ -48,9 +49,9 @@ container_memory_working_set_bytes{id="/system"} 268435456
 
 func TestParseContainerMetricsEmpty(t *testing.T) {
 	body := strings.NewReader("")
-	cpu, mem, err := parseContainerMetrics(body, "container_cpu_usage_seconds_total", "container_memory_working_set_bytes")
+	cpu, mem, err := ParseContainerMetrics(body, "container_cpu_usage_seconds_total", "container_memory_working_set_bytes")
 	if err != nil {
-		t.Fatalf("parseContainerMetrics: %v", err)
+		t.Fatalf("ParseContainerMetrics: %v", err)
 	}
 	if cpu != 0 || mem != 0 {
 		t.Errorf("empty body: cpu=%v mem=%v, want 0,0", cpu, mem)
@@ -0,0 +1,58 @@
+package collector
+
+import (
+	"context"
+	"encoding/json"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// NodeMetricsPath is the metrics-server (metrics.k8s.io) list of node
+// usage on the API server.
+const NodeMetricsPath = "/apis/metrics.k8s.io/v1beta1/nodes"
+
+// NodeUsage is a node's CPU and memory usage as reported by metrics-server.
+type NodeUsage struct {
+	CPU float64 // cores
+	Mem float64 // working set bytes
+}
+
+// nodeMetricsList is the subset of a metrics.k8s.io NodeMetricsList that
+// NodeMetrics reads.
+type nodeMetricsList struct {
+	Items []struct {
+		Metadata struct {
+			Name string `json:"name"`
+		} `json:"metadata"`
+		Usage corev1.ResourceList `json:"usage"`
+	} `json:"items"`
+}
+
+// NodeMetrics lists the usage of every node from metrics-server. It always
+// goes through the API server, even when Direct is set, so it still works
+// when the kubelets cannot be reached; metrics-server only reports node
+// totals, averaged over its own resolution.
+func (s *Scraper) NodeMetrics(ctx context.Context) (map[string]NodeUsage, error) {
+	body, _, _, err := s.fetch(ctx, s.Client, s.BaseURL+NodeMetricsPath, "application/json")
+	if err != nil {
+		return nil, err
+	}
+	return ParseNodeMetrics(body)
+}
+
+// ParseNodeMetrics parses a metrics.k8s.io NodeMetricsList into usage per
+// node.
+func ParseNodeMetrics(body []byte) (map[string]NodeUsage, error) {
+	var list nodeMetricsList
+	if err := json.Unmarshal(body, &list); err != nil {
+		return nil, err
+	}
+	usage := make(map[string]NodeUsage, len(list.Items))
+	for _, item := range list.Items {
+		usage[item.Metadata.Name] = NodeUsage{
+			CPU: item.Usage.Cpu().AsApproximateFloat64(),
+			Mem: item.Usage.Memory().AsApproximateFloat64(),
+		}
+	}
+	return usage, nil
+}
@@ -0,0 +1,53 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+const nodeMetricsJSON = `{
+  "kind": "NodeMetricsList",
+  "apiVersion": "metrics.k8s.io/v1beta1",
+  "items": [
+    {"metadata": {"name": "node-a"}, "window": "20s", "usage": {"cpu": "1500m", "memory": "2Gi"}},
+    {"metadata": {"name": "node-b"}, "window": "20s", "usage": {"cpu": "250m", "memory": "512Mi"}}
+  ]
+}`
+
+func TestNodeMetrics(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != NodeMetricsPath {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(nodeMetricsJSON))
+	}))
+	defer srv.Close()
+
+	// metrics-server is reached through the API server even in direct mode.
+	s := &Scraper{Client: srv.Client(), BaseURL: srv.URL, Direct: func(node, path string) (string, error) {
+		return "", errors.New("unreachable")
+	}}
+	got, err := s.NodeMetrics(context.Background())
+	if err != nil {
+		t.Fatalf("NodeMetrics: %v", err)
+	}
+	want := map[string]NodeUsage{
+		"node-a": {CPU: 1.5, Mem: 2 << 30},
+		"node-b": {CPU: 0.25, Mem: 512 << 20},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("NodeMetrics = %v, want %v", got, want)
+	}
+}
+
+func TestParseNodeMetricsInvalid(t *testing.T) {
+	if _, err := ParseNodeMetrics([]byte(`{"items": [`)); err == nil {
+		t.Error("ParseNodeMetrics accepted truncated JSON")
+	}
+}
@@ -0,0 +1,68 @@
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// NamespaceBudgetsPath lists the NamespaceBudgets of all namespaces; see
+// helm/crds/namespacebudgets.yaml.
+const NamespaceBudgetsPath = "/apis/binbots.io/v1alpha1/namespacebudgets"
+
+// NamespaceBudget is a soft cap on what the namespace it lives in may use
+// or request. A zero cap is not set.
+type NamespaceBudget struct {
+	Namespace   string
+	Name        string
+	CPUCores    float64
+	MemoryBytes float64
+	MonthlyCost float64
+}
+
+// NamespaceBudgets lists the NamespaceBudgets of all namespaces. It
+// returns none when the CRD is not installed.
+func (s *Scraper) NamespaceBudgets(ctx context.Context) ([]NamespaceBudget, error) {
+	body, err := s.fetchOptional(ctx, NamespaceBudgetsPath)
+	if err != nil || body == nil {
+		return nil, err
+	}
+	return ParseNamespaceBudgets(body)
+}
+
+// ParseNamespaceBudgets decodes a binbots.io NamespaceBudgetList.
+func ParseNamespaceBudgets(body []byte) ([]NamespaceBudget, error) {
+	var list struct {
+		Items []struct {
+			Metadata struct {
+				Namespace string `json:"namespace"`
+				Name      string `json:"name"`
+			} `json:"metadata"`
+			Spec struct {
+				CPU         *resource.Quantity `json:"cpu"`
+				Memory      *resource.Quantity `json:"memory"`
+				MonthlyCost float64            `json:"monthlyCost"`
+			} `json:"spec"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(body, &list); err != nil {
+		return nil, err
+	}
+	out := make([]NamespaceBudget, 0, len(list.Items))
+	for _, it := range list.Items {
+		b := NamespaceBudget{Namespace: it.Metadata.Namespace, Name: it.Metadata.Name, MonthlyCost: it.Spec.MonthlyCost}
+		if it.Spec.CPU != nil {
+			b.CPUCores = it.Spec.CPU.AsApproximateFloat64()
+		}
+		if it.Spec.Memory != nil {
+			b.MemoryBytes = it.Spec.Memory.AsApproximateFloat64()
+		}
+		if b.CPUCores < 0 || b.MemoryBytes < 0 || b.MonthlyCost < 0 {
+			return nil, fmt.Errorf("NamespaceBudget %s/%s: negative cap", b.Namespace, b.Name)
+		}
+		out = append(out, b)
+	}
+	return out, nil
+}
@@ -0,0 +1,41 @@
+package collector
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestNamespaceBudgets(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != NamespaceBudgetsPath {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"kind": "NamespaceBudgetList", "items": [
+  {"metadata": {"namespace": "team-a", "name": "default"}, "spec": {"cpu": "8", "memory": "32Gi", "monthlyCost": 500}},
+  {"metadata": {"namespace": "team-b", "name": "cost-only"}, "spec": {"monthlyCost": 120.5}}
+]}`))
+	}))
+	defer srv.Close()
+	s := &Scraper{Client: srv.Client(), BaseURL: srv.URL}
+	got, err := s.NamespaceBudgets(context.Background())
+	if err != nil {
+		t.Fatalf("NamespaceBudgets: %v", err)
+	}
+	want := []NamespaceBudget{
+		{Namespace: "team-a", Name: "default", CPUCores: 8, MemoryBytes: 32 << 30, MonthlyCost: 500},
+		{Namespace: "team-b", Name: "cost-only", MonthlyCost: 120.5},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	// Without the CRD there are no budgets, and no error.
+	s.BaseURL = srv.URL + "/missing"
+	if got, err := s.NamespaceBudgets(context.Background()); err != nil || got != nil {
+		t.Errorf("CRD not installed: %v, %v", got, err)
+	}
+}
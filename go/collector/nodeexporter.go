@@ -0,0 +1,160 @@
+package collector
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// Series of the Prometheus node_exporter that host saturation is read
+// from.
+const (
+	NodeLoad1       = "node_load1"
+	NodeLoad5       = "node_load5"
+	NodeLoad15      = "node_load15"
+	NodeCPUSeconds  = "node_cpu_seconds_total"           // CPUs are counted by their mode="idle" samples
+	NodeDiskIOTime  = "node_disk_io_time_seconds_total"  // per device
+	NodeNetRxBytes  = "node_network_receive_bytes_total" // per device
+	NodeNetTxBytes  = "node_network_transmit_bytes_total"
+	NodeNetSpeed    = "node_network_speed_bytes" // link speed, per device
+	nodeIdleMode    = "idle"
+	nodeLoopbackDev = "lo"
+)
+
+// virtualInterfaces are name prefixes of the container, bridge and tunnel
+// interfaces node_exporter reports besides the host's NICs. Their traffic
+// also crosses a physical interface, and most report no link speed.
+var virtualInterfaces = []string{
+	"veth", "cali", "cilium", "lxc", "cni", "flannel", "docker", "br-", "vxlan", "tunl", "genev", "kube-ipvs", "weave", "virbr", "tap", "gke", "eni",
+}
+
+var (
+	devicePrefix = []byte(`device="`)
+	modePrefix   = []byte(`mode="`)
+)
+
+// InterfaceCounters are the cumulative byte counters and link speed of one
+// network interface.
+type InterfaceCounters struct {
+	RxBytes, TxBytes float64
+	SpeedBytes       float64 // per second; 0 when not reported
+}
+
+// HostCounters is what node_exporter reports about one node's saturation.
+// Load is a gauge; the disk and network counters are cumulative and turn
+// into rates between two scrapes.
+type HostCounters struct {
+	CPUs                 int
+	Load1, Load5, Load15 float64
+	// DiskIOSeconds is the time each block device spent with IO in
+	// progress.
+	DiskIOSeconds map[string]float64
+	// Interfaces holds the physical interfaces, by device.
+	Interfaces map[string]InterfaceCounters
+}
+
+// NodeExporterTargets returns the node_exporter endpoints among pods: the
+// running pods matching selector, scraped on port at /metrics.
+func NodeExporterTargets(pods []corev1.Pod, selector labels.Selector, port int) []Target {
+	return agentTargets("node-exporter", pods, selector, port)
+}
+
+// HostCounters scrapes the node_exporter pods among pods (see
+// NodeExporterTargets) through the API server and returns the counters of
+// each node. A failed pod is skipped and reported in errs; so is a node
+// with more than one node_exporter pod.
+func (s *Scraper) HostCounters(ctx context.Context, pods []corev1.Pod, selector labels.Selector, port int) (counters map[string]HostCounters, errs []*TargetError) {
+	counters = make(map[string]HostCounters)
+	for _, t := range NodeExporterTargets(pods, selector, port) {
+		name := "node-exporter:" + t.Namespace + "/" + t.Pod
+		if _, ok := counters[t.Node]; ok {
+			errs = append(errs, &TargetError{Target: name, Err: fmt.Errorf("another node_exporter pod runs on node %s", t.Node)})
+			continue
+		}
+		body, _, _, err := s.fetch(ctx, s.Client, t.URL(s.BaseURL), "text/plain")
+		if err == nil {
+			counters[t.Node], err = ParseNodeExporter(bytes.NewReader(body))
+		}
+		if err != nil {
+			errs = append(errs, &TargetError{Target: name, Err: err})
+			delete(counters, t.Node)
+		}
+	}
+	return counters, errs
+}
+
+// ParseNodeExporter reads the load, disk and network counters of one node
+// from the exposition of its node_exporter. Loopback and virtual
+// interfaces (see virtualInterfaces) are left out.
+func ParseNodeExporter(body io.Reader) (HostCounters, error) {
+	c := HostCounters{DiskIOSeconds: make(map[string]float64), Interfaces: make(map[string]InterfaceCounters)}
+	r := bufio.NewReader(body)
+	for {
+		line, err := r.ReadBytes('\n')
+		line = bytes.TrimRight(line, "\r\n")
+		if len(line) > 0 && line[0] != '#' {
+			if name, lbls, value, ok := splitSampleLine(line); ok {
+				c.add(string(name), lbls, parseValue(value))
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return HostCounters{}, err
+		}
+	}
+	return c, nil
+}
+
+func (c *HostCounters) add(name string, lbls []byte, v float64) {
+	switch name {
+	case NodeLoad1:
+		c.Load1 = v
+	case NodeLoad5:
+		c.Load5 = v
+	case NodeLoad15:
+		c.Load15 = v
+	case NodeCPUSeconds:
+		if string(labelValue(lbls, modePrefix)) == nodeIdleMode {
+			c.CPUs++
+		}
+	case NodeDiskIOTime:
+		if dev := labelValue(lbls, devicePrefix); len(dev) > 0 {
+			c.DiskIOSeconds[string(dev)] = v
+		}
+	case NodeNetRxBytes, NodeNetTxBytes, NodeNetSpeed:
+		dev := string(labelValue(lbls, devicePrefix))
+		if !physicalInterface(dev) {
+			return
+		}
+		i := c.Interfaces[dev]
+		switch name {
+		case NodeNetRxBytes:
+			i.RxBytes = v
+		case NodeNetTxBytes:
+			i.TxBytes = v
+		default:
+			i.SpeedBytes = v
+		}
+		c.Interfaces[dev] = i
+	}
+}
+
+func physicalInterface(dev string) bool {
+	if dev == "" || dev == nodeLoopbackDev {
+		return false
+	}
+	for _, p := range virtualInterfaces {
+		if strings.HasPrefix(dev, p) {
+			return false
+		}
+	}
+	return true
+}
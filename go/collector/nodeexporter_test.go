@@ -0,0 +1,77 @@
+package collector
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const nodeExporterBody = `# HELP node_load1 1m load average.
+# TYPE node_load1 gauge
+node_load1 3
+node_load5 2
+node_load15 1
+node_cpu_seconds_total{cpu="0",mode="idle"} 1000
+node_cpu_seconds_total{cpu="0",mode="user"} 200
+node_cpu_seconds_total{cpu="1",mode="idle"} 900
+node_disk_io_time_seconds_total{device="nvme0n1"} 120.5
+node_disk_io_time_seconds_total{device="nvme1n1"} 30
+node_network_receive_bytes_total{device="eth0"} 5e+09
+node_network_transmit_bytes_total{device="eth0"} 1e+09
+node_network_speed_bytes{device="eth0"} 1.25e+09
+node_network_receive_bytes_total{device="lo"} 9e+09
+node_network_receive_bytes_total{device="veth1a2b"} 7e+09
+node_network_receive_bytes_total{device="cali0f3"} 7e+09
+node_filesystem_avail_bytes{device="/dev/nvme0n1p1",mountpoint="/"} 1e+10
+`
+
+func TestParseNodeExporter(t *testing.T) {
+	got, err := ParseNodeExporter(strings.NewReader(nodeExporterBody))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := HostCounters{
+		CPUs:  2,
+		Load1: 3, Load5: 2, Load15: 1,
+		DiskIOSeconds: map[string]float64{"nvme0n1": 120.5, "nvme1n1": 30},
+		Interfaces:    map[string]InterfaceCounters{"eth0": {RxBytes: 5e9, TxBytes: 1e9, SpeedBytes: 1.25e9}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseNodeExporter = %+v, want %+v", got, want)
+	}
+}
+
+func TestHostCounters(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/namespaces/monitoring/pods/ne-a:9100/proxy/metrics" {
+			w.Write([]byte(nodeExporterBody))
+			return
+		}
+		http.Error(w, "no endpoints", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+	s := &Scraper{Client: srv.Client(), BaseURL: srv.URL}
+
+	pod := func(name, node string) corev1.Pod {
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "monitoring", Name: name, Labels: map[string]string{"app": "node-exporter"}},
+			Spec:       corev1.PodSpec{NodeName: node},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning},
+		}
+	}
+	pods := []corev1.Pod{pod("ne-a", "node-1"), pod("ne-b", "node-2")}
+	counters, errs := s.HostCounters(context.Background(), pods, labels.SelectorFromSet(labels.Set{"app": "node-exporter"}), 9100)
+	if len(counters) != 1 || counters["node-1"].CPUs != 2 {
+		t.Errorf("counters = %+v, want node-1 with 2 CPUs", counters)
+	}
+	if len(errs) != 1 || errs[0].Target != "node-exporter:monitoring/ne-b" {
+		t.Errorf("errs = %v, want ne-b", errs)
+	}
+}
@@ -0,0 +1,155 @@
+package collector
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// rule selects raw series by exact metric name and, optionally,
+// exact label values.
+type rule struct {
+	name   string
+	labels map[string]string
+}
+
+// Rules is a passthrough allowlist. A nil Rules selects nothing.
+type Rules []rule
+
+// RawSeries is one sample from a kubelet/cAdvisor exposition.
+type RawSeries struct {
+	Name   string
+	Labels map[string]string
+	Value  float64
+}
+
+// ParsePassthroughSpec parses a ';'-separated allowlist such as
+// `container_cpu_cfs_throttled_periods_total{namespace="prod"};kubelet_running_pods`.
+func ParsePassthroughSpec(spec string) (Rules, error) {
+	var rules Rules
+	for _, entry := range strings.Split(spec, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, lbls, _, err := parseSampleLine(entry + " 0")
+		if err != nil {
+			return nil, fmt.Errorf("passthrough entry %q: %w", entry, err)
+		}
+		rules = append(rules, rule{name: name, labels: lbls})
+	}
+	return rules, nil
+}
+
+func (r rule) matches(name string, lbls map[string]string) bool {
+	if name != r.name {
+		return false
+	}
+	for k, v := range r.labels {
+		if lbls[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// Wants reports whether any rule selects the metric family name.
+func (rules Rules) Wants(name string) bool {
+	for _, r := range rules {
+		if r.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Match reports whether any rule selects the sample.
+func (rules Rules) Match(name string, lbls map[string]string) bool {
+	for _, r := range rules {
+		if r.matches(name, lbls) {
+			return true
+		}
+	}
+	return false
+}
+
+// WithNode adds the node label to each series. A pre-existing node label is
+// kept as exported_node, following Prometheus' honor_labels=false convention.
+func WithNode(series []RawSeries, node string) []RawSeries {
+	for i := range series {
+		if v, ok := series[i].Labels["node"]; ok {
+			series[i].Labels["exported_node"] = v
+		}
+		series[i].Labels["node"] = node
+	}
+	return series
+}
+
+// parseSampleLine parses `name{k="v",...} value [timestamp]`.
+func parseSampleLine(line string) (name string, lbls map[string]string, value float64, err error) {
+	lbls = make(map[string]string)
+	rest := line
+	if idx := strings.IndexByte(line, '{'); idx != -1 {
+		name = line[:idx]
+		rest = line[idx+1:]
+		for {
+			rest = strings.TrimLeft(rest, ", ")
+			if strings.HasPrefix(rest, "}") {
+				rest = rest[1:]
+				break
+			}
+			eq := strings.IndexByte(rest, '=')
+			if eq == -1 || len(rest) < eq+2 || rest[eq+1] != '"' {
+				return "", nil, 0, fmt.Errorf("malformed labels")
+			}
+			key := strings.TrimSpace(rest[:eq])
+			val, n, err := unquoteLabelValue(rest[eq+1:])
+			if err != nil {
+				return "", nil, 0, err
+			}
+			lbls[key] = val
+			rest = rest[eq+1+n:]
+		}
+	} else {
+		idx := strings.IndexByte(line, ' ')
+		if idx == -1 {
+			return "", nil, 0, fmt.Errorf("missing value")
+		}
+		name, rest = line[:idx], line[idx:]
+	}
+	fields := strings.Fields(rest)
+	if name == "" || len(fields) == 0 {
+		return "", nil, 0, fmt.Errorf("missing name or value")
+	}
+	value, err = strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return "", nil, 0, err
+	}
+	return name, lbls, value, nil
+}
+
+// unquoteLabelValue reads a double-quoted label value from the start of s
+// and returns it with the number of bytes consumed.
+func unquoteLabelValue(s string) (string, int, error) {
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if i+1 >= len(s) {
+				return "", 0, fmt.Errorf("unterminated escape")
+			}
+			i++
+			switch s[i] {
+			case 'n':
+				b.WriteByte('\n')
+			default:
+				b.WriteByte(s[i])
+			}
+		case '"':
+			return b.String(), i + 1, nil
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated label value")
+}
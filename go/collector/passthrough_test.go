@@ -0,0 +1,66 @@
+package collector
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSampleLine(t *testing.T) {
+	tests := []struct {
+		line   string
+		name   string
+		labels map[string]string
+		value  float64
+	}{
+		{`up 1`, "up", map[string]string{}, 1},
+		{`m{a="x",b="y, z"} 2.5`, "m", map[string]string{"a": "x", "b": "y, z"}, 2.5},
+		{`m{a="q\"uote"} 3 1700000000000`, "m", map[string]string{"a": `q"uote`}, 3},
+		{`m{} 4`, "m", map[string]string{}, 4},
+	}
+	for _, tt := range tests {
+		name, lbls, value, err := parseSampleLine(tt.line)
+		if err != nil {
+			t.Errorf("parseSampleLine(%q): %v", tt.line, err)
+			continue
+		}
+		if name != tt.name || value != tt.value || !reflect.DeepEqual(lbls, tt.labels) {
+			t.Errorf("parseSampleLine(%q) = %q %v %v, want %q %v %v", tt.line, name, lbls, value, tt.name, tt.labels, tt.value)
+		}
+	}
+	for _, bad := range []string{"", "novalue", `m{a="x} 1`, `m{a=x} 1`, "m abc"} {
+		if _, _, _, err := parseSampleLine(bad); err == nil {
+			t.Errorf("parseSampleLine(%q): expected error", bad)
+		}
+	}
+}
+
+func TestPassthroughFilter(t *testing.T) {
+	rules, err := ParsePassthroughSpec(`kubelet_running_pods; container_cpu_cfs_throttled_periods_total{namespace="prod"}`)
+	if err != nil {
+		t.Fatalf("parsePassthroughSpec: %v", err)
+	}
+	body := []byte(`# TYPE kubelet_running_pods gauge
+kubelet_running_pods 12
+container_cpu_cfs_throttled_periods_total{namespace="prod",pod="a"} 5
+container_cpu_cfs_throttled_periods_total{namespace="dev",pod="b"} 7
+container_cpu_usage_seconds_total{namespace="prod"} 1
+`)
+	sample, err := NewTextParser(rules).Parse(body)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	got := WithNode(sample.Raw, "node-a")
+	want := []RawSeries{
+		{Name: "kubelet_running_pods", Labels: map[string]string{"node": "node-a"}, Value: 12},
+		{Name: "container_cpu_cfs_throttled_periods_total", Labels: map[string]string{"namespace": "prod", "pod": "a", "node": "node-a"}, Value: 5},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("passthrough = %+v, want %+v", got, want)
+	}
+}
+
+func TestParsePassthroughSpecInvalid(t *testing.T) {
+	if _, err := ParsePassthroughSpec(`m{a=unquoted}`); err == nil {
+		t.Error("expected error for unquoted label value")
+	}
+}
@@ -0,0 +1,130 @@
+package collector
+
+import (
+	"errors"
+	"io"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"google.golang.org/protobuf/proto"
+)
+
+// ProtobufAccept prefers the delimited protobuf exposition and accepts text
+// as a fallback, matching what Prometheus itself sends.
+const ProtobufAccept = `application/vnd.google.protobuf;proto=io.prometheus.client.MetricFamily;encoding=delimited;q=0.7,text/plain;version=0.0.4;q=0.3`
+
+// TextAccept asks for the text exposition only, so an endpoint that would
+// default to another format still answers in one the text parser reads.
+const TextAccept = `text/plain;version=0.0.4`
+
+// ParseProto builds a NodeSample from a delimited protobuf exposition
+// in a single pass over the decoded families, collecting series selected by
+// rules.
+func ParseProto(body io.Reader, rules Rules) (NodeSample, error) {
+	sample := NodeSample{Families: make(map[string]int), Namespaces: make(map[string]NamespaceCost), Usage: make(map[string]NamespaceUsage), Containers: make(map[ContainerRef]NamespaceUsage)}
+	dec := expfmt.NewDecoder(body, expfmt.NewFormat(expfmt.TypeProtoDelim))
+	for {
+		var mf dto.MetricFamily
+		if err := dec.Decode(&mf); err != nil {
+			if errors.Is(err, io.EOF) {
+				return sample, nil
+			}
+			return NodeSample{}, err
+		}
+		name := mf.GetName()
+		netField := networkCounterMetrics[name]
+		passthrough := rules.Wants(name)
+		for _, m := range mf.GetMetric() {
+			series := protoSeriesCount(mf.GetType(), m)
+			sample.Families[name] += series
+			ns := protoLabel(m, "namespace")
+			if ns != "" {
+				c := sample.Namespaces[ns]
+				c.Series += series
+				c.Bytes += proto.Size(m)
+				sample.Namespaces[ns] = c
+			}
+			v, ok := protoValue(mf.GetType(), m)
+			if !ok {
+				continue
+			}
+			if cpu := name == CPUUsageMetric; cpu || name == MemWorkingSetMetric {
+				if cpu {
+					sample.CPU += v
+				} else {
+					sample.Mem += v
+				}
+				if container := isContainer(protoLabel(m, "container")); ns != "" && (container || protoPodCgroup(m)) {
+					u := sample.Usage[ns]
+					u.add(cpu, container, v)
+					sample.Usage[ns] = u
+					if pod := protoLabel(m, "pod"); pod != "" {
+						ref := ContainerRef{Namespace: ns, Pod: pod, Name: protoLabel(m, "container")}
+						u := sample.Containers[ref]
+						u.add(cpu, container, v)
+						sample.Containers[ref] = u
+					}
+				}
+			}
+			if netField == nil && !passthrough {
+				continue
+			}
+			lbls := protoLabels(m)
+			if netField != nil && lbls["id"] == "/" {
+				*netField(&sample.Net) += v
+			}
+			if passthrough && rules.Match(name, lbls) {
+				sample.Raw = append(sample.Raw, RawSeries{Name: name, Labels: lbls, Value: v})
+			}
+		}
+	}
+}
+
+// protoPodCgroup is inPodCgroup for a protobuf metric.
+func protoPodCgroup(m *dto.Metric) bool {
+	id := protoLabel(m, "id")
+	return protoLabel(m, "container") == "" && id != "" && isPodCgroup(id)
+}
+
+// protoValue returns the sample value of a counter, gauge or untyped metric.
+func protoValue(t dto.MetricType, m *dto.Metric) (float64, bool) {
+	switch t {
+	case dto.MetricType_COUNTER:
+		return m.GetCounter().GetValue(), true
+	case dto.MetricType_GAUGE:
+		return m.GetGauge().GetValue(), true
+	case dto.MetricType_UNTYPED:
+		return m.GetUntyped().GetValue(), true
+	}
+	return 0, false
+}
+
+// protoSeriesCount is the number of text-format series the metric expands
+// to, so cardinality is comparable across formats.
+func protoSeriesCount(t dto.MetricType, m *dto.Metric) int {
+	switch t {
+	case dto.MetricType_SUMMARY:
+		return len(m.GetSummary().GetQuantile()) + 2
+	case dto.MetricType_HISTOGRAM, dto.MetricType_GAUGE_HISTOGRAM:
+		return len(m.GetHistogram().GetBucket()) + 2
+	}
+	return 1
+}
+
+// protoLabel returns the value of label name, or "" when m has none.
+func protoLabel(m *dto.Metric, name string) string {
+	for _, lp := range m.GetLabel() {
+		if lp.GetName() == name {
+			return lp.GetValue()
+		}
+	}
+	return ""
+}
+
+func protoLabels(m *dto.Metric) map[string]string {
+	lbls := make(map[string]string, len(m.GetLabel()))
+	for _, lp := range m.GetLabel() {
+		lbls[lp.GetName()] = lp.GetValue()
+	}
+	return lbls
+}
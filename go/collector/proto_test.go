@@ -0,0 +1,114 @@
+package collector
+
+import (
+	"bytes"
+	"testing"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+func protoFamily(name string, t dto.MetricType, metrics ...*dto.Metric) *dto.MetricFamily {
+	return &dto.MetricFamily{Name: &name, Type: t.Enum(), Metric: metrics}
+}
+
+func protoCounter(v float64, kv ...string) *dto.Metric {
+	m := &dto.Metric{Counter: &dto.Counter{Value: &v}}
+	for i := 0; i+1 < len(kv); i += 2 {
+		name, value := kv[i], kv[i+1]
+		m.Label = append(m.Label, &dto.LabelPair{Name: &name, Value: &value})
+	}
+	return m
+}
+
+func protoGauge(v float64, kv ...string) *dto.Metric {
+	m := protoCounter(0, kv...)
+	m.Counter = nil
+	m.Gauge = &dto.Gauge{Value: &v}
+	return m
+}
+
+func TestParseProtoSample(t *testing.T) {
+	var buf bytes.Buffer
+	enc := expfmt.NewEncoder(&buf, expfmt.NewFormat(expfmt.TypeProtoDelim))
+	for _, mf := range []*dto.MetricFamily{
+		protoFamily("container_cpu_usage_seconds_total", dto.MetricType_COUNTER,
+			protoCounter(1.5, "id", "/"), protoCounter(0.2, "id", "/system")),
+		protoFamily("container_memory_working_set_bytes", dto.MetricType_GAUGE,
+			protoGauge(536870912, "id", "/"), protoGauge(268435456, "id", "/system")),
+		protoFamily("container_network_receive_errors_total", dto.MetricType_COUNTER,
+			protoCounter(3, "id", "/", "interface", "eth0"), protoCounter(100, "id", "/kubepods", "interface", "eth0")),
+	} {
+		if err := enc.Encode(mf); err != nil {
+			t.Fatalf("Encode: %v", err)
+		}
+	}
+
+	got, err := ParseProto(&buf, nil)
+	if err != nil {
+		t.Fatalf("ParseProto: %v", err)
+	}
+	if got.CPU != 1.7 || got.Mem != 805306368 {
+		t.Errorf("cpu, mem = %v, %v; want 1.7, 805306368", got.CPU, got.Mem)
+	}
+	if got.Net.RxErrors != 3 {
+		t.Errorf("rxErrors = %v, want 3", got.Net.RxErrors)
+	}
+	if n := got.Series(); n != 6 {
+		t.Errorf("series = %d, want 6", n)
+	}
+}
+
+func TestParseProtoNamespaceCost(t *testing.T) {
+	var buf bytes.Buffer
+	enc := expfmt.NewEncoder(&buf, expfmt.NewFormat(expfmt.TypeProtoDelim))
+	mf := protoFamily("container_cpu_usage_seconds_total", dto.MetricType_COUNTER,
+		protoCounter(1, "id", "/"),
+		protoCounter(1, "id", "/kubepods/p1", "namespace", "a"),
+		protoCounter(2, "id", "/kubepods/p2", "namespace", "a"),
+		protoCounter(3, "id", "/kubepods/p3", "namespace", "b"))
+	if err := enc.Encode(mf); err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+
+	got, err := ParseProto(&buf, nil)
+	if err != nil {
+		t.Fatalf("ParseProto: %v", err)
+	}
+	a, b := got.Namespaces["a"], got.Namespaces["b"]
+	if a.Series != 2 || b.Series != 1 || len(got.Namespaces) != 2 {
+		t.Errorf("namespaces = %v, want a with 2 series and b with 1", got.Namespaces)
+	}
+	if b.Bytes <= 0 || a.Bytes != 2*b.Bytes {
+		t.Errorf("bytes a, b = %d, %d; want a = 2b > 0", a.Bytes, b.Bytes)
+	}
+}
+
+func TestParseProtoNamespaceUsage(t *testing.T) {
+	var buf bytes.Buffer
+	enc := expfmt.NewEncoder(&buf, expfmt.NewFormat(expfmt.TypeProtoDelim))
+	for _, mf := range []*dto.MetricFamily{
+		protoFamily("container_cpu_usage_seconds_total", dto.MetricType_COUNTER,
+			protoCounter(12, "container", "", "id", "/kubepods.slice/kubepods-burstable.slice/kubepods-burstable-pod1.slice", "namespace", "shop"),
+			protoCounter(1, "container", "POD", "namespace", "shop"),
+			protoCounter(8, "container", "app", "namespace", "shop", "pod", "a"),
+			protoCounter(3, "container", "sidecar", "namespace", "shop")),
+		protoFamily("container_memory_working_set_bytes", dto.MetricType_GAUGE,
+			protoGauge(2048, "container", "app", "namespace", "shop")),
+	} {
+		if err := enc.Encode(mf); err != nil {
+			t.Fatalf("Encode: %v", err)
+		}
+	}
+
+	got, err := ParseProto(&buf, nil)
+	if err != nil {
+		t.Fatalf("ParseProto: %v", err)
+	}
+	if u := got.Usage["shop"]; u != (NamespaceUsage{CPU: 11, Mem: 2048, PodCPU: 12}) {
+		t.Errorf("usage[shop] = %+v, want {CPU:11 Mem:2048 PodCPU:12}", u)
+	}
+	if u := got.Containers[ContainerRef{Namespace: "shop", Pod: "a", Name: "app"}]; u != (NamespaceUsage{CPU: 8}) || len(got.Containers) != 1 {
+		t.Errorf("containers = %+v, want only shop/a/app {CPU:8}", got.Containers)
+	}
+}
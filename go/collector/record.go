@@ -0,0 +1,145 @@
+package collector
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// recordCycleLayout names the directory of one recorded cycle.
+const recordCycleLayout = "20060102T150405.000Z"
+
+// recordExt maps exposition formats to payload file extensions.
+var recordExt = map[string]string{FormatProtobuf: ".pb", FormatText: ".txt", FormatJSON: ".json"}
+
+// Recorder writes raw scrape payloads to Dir for offline replay, one
+// directory per cycle with a file per node and source:
+//
+//	<Dir>/20240102T150405.000Z/cadvisor_node-1.pb
+type Recorder struct {
+	Dir string
+
+	mu    sync.Mutex
+	cycle string
+}
+
+// StartCycle puts the payloads saved from now on into a new cycle
+// directory named after t.
+func (r *Recorder) StartCycle(t time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cycle = t.UTC().Format(recordCycleLayout)
+}
+
+// Save writes the payload of one scrape of node from source, in format
+// (FormatProtobuf, FormatText or FormatJSON), to the current cycle.
+func (r *Recorder) Save(node, source, format string, body []byte) error {
+	r.mu.Lock()
+	cycle := r.cycle
+	r.mu.Unlock()
+	if cycle == "" {
+		return fmt.Errorf("recorder: no cycle started")
+	}
+	ext, ok := recordExt[format]
+	if !ok {
+		return fmt.Errorf("recorder: unknown format %q", format)
+	}
+	dir := filepath.Join(r.Dir, cycle)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, source+"_"+node+ext), body, 0o644)
+}
+
+// RecordedCycle is the payloads of one cycle read back by ReadRecording.
+type RecordedCycle struct {
+	Time     time.Time
+	Payloads []Payload
+}
+
+// Payload is one recorded scrape.
+type Payload struct {
+	Node   string
+	Source string // SourceCadvisor, SourceKubelet or SourceSummary
+	Format string // FormatProtobuf, FormatText or FormatJSON
+	Body   []byte
+}
+
+// Parse parses the payload the way Scraper parses a live scrape.
+func (p Payload) Parse(rules Rules) (NodeSample, error) {
+	return parsePayload(p.Format, p.Body, rules)
+}
+
+// ReadRecording reads the cycles a Recorder wrote to dir, oldest first.
+// Files and directories it did not write are ignored.
+func ReadRecording(dir string) ([]RecordedCycle, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var cycles []RecordedCycle
+	for _, e := range entries {
+		t, err := time.Parse(recordCycleLayout, e.Name())
+		if !e.IsDir() || err != nil {
+			continue
+		}
+		c := RecordedCycle{Time: t}
+		files, err := os.ReadDir(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			p, ok := parsePayloadName(f.Name())
+			if !ok || f.IsDir() {
+				continue
+			}
+			if p.Body, err = os.ReadFile(filepath.Join(dir, e.Name(), f.Name())); err != nil {
+				return nil, err
+			}
+			c.Payloads = append(c.Payloads, p)
+		}
+		cycles = append(cycles, c)
+	}
+	sort.Slice(cycles, func(i, j int) bool { return cycles[i].Time.Before(cycles[j].Time) })
+	return cycles, nil
+}
+
+// parsePayloadName splits a payload file name written by Recorder.Save.
+// Node names cannot contain '_', so the first one ends the source.
+func parsePayloadName(name string) (Payload, bool) {
+	for format, ext := range recordExt {
+		base, ok := strings.CutSuffix(name, ext)
+		if !ok {
+			continue
+		}
+		source, node, ok := strings.Cut(base, "_")
+		if !ok || node == "" || (source != SourceCadvisor && source != SourceKubelet && source != SourceSummary) {
+			return Payload{}, false
+		}
+		return Payload{Node: node, Source: source, Format: format}, true
+	}
+	return Payload{}, false
+}
+
+// parsePayload parses a payload body in format, collecting series
+// selected by rules from expositions.
+func parsePayload(format string, body []byte, rules Rules) (NodeSample, error) {
+	var sample NodeSample
+	var err error
+	switch format {
+	case FormatProtobuf:
+		sample, err = ParseProto(bytes.NewReader(body), rules)
+	case FormatJSON:
+		sample, err = ParseSummary(body)
+	default:
+		sample, err = NewTextParser(rules).Parse(body)
+	}
+	sample.Format = format
+	sample.Bytes = len(body)
+	return sample, err
+}
@@ -0,0 +1,65 @@
+package collector
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		w.Write([]byte("container_cpu_usage_seconds_total{id=\"/\"} 5\ncontainer_memory_working_set_bytes{id=\"/\"} 1024\n"))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	rec := &Recorder{Dir: dir}
+	s := &Scraper{Client: srv.Client(), BaseURL: srv.URL, Record: func(node, source, format string, body []byte) {
+		if err := rec.Save(node, source, format, body); err != nil {
+			t.Errorf("Save: %v", err)
+		}
+	}}
+	start := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	var live []NodeSample
+	for i, node := range []string{"node-a", "node-b.example.com"} {
+		rec.StartCycle(start.Add(time.Duration(1-i) * time.Minute))
+		sample, err := s.Cadvisor(context.Background(), node, nil)
+		if err != nil {
+			t.Fatalf("Cadvisor: %v", err)
+		}
+		live = append(live, sample)
+	}
+	// Not written by the Recorder.
+	os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("x"), 0o644)
+
+	cycles, err := ReadRecording(dir)
+	if err != nil {
+		t.Fatalf("ReadRecording: %v", err)
+	}
+	if len(cycles) != 2 || !cycles[0].Time.Equal(start) || !cycles[1].Time.Equal(start.Add(time.Minute)) {
+		t.Fatalf("cycles = %+v, want two, oldest first", cycles)
+	}
+	p := cycles[0].Payloads
+	if len(p) != 1 || p[0].Node != "node-b.example.com" || p[0].Source != SourceCadvisor || p[0].Format != FormatText {
+		t.Fatalf("payloads = %+v, want the cAdvisor text payload of node-b.example.com", p)
+	}
+	replayed, err := p[0].Parse(nil)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if replayed.CPU != live[1].CPU || replayed.Mem != live[1].Mem || replayed.Bytes != live[1].Bytes {
+		t.Errorf("replayed sample = %+v, want the live one %+v", replayed, live[1])
+	}
+}
+
+func TestRecorderNeedsCycle(t *testing.T) {
+	rec := &Recorder{Dir: t.TempDir()}
+	if err := rec.Save("node-a", SourceKubelet, FormatText, nil); err == nil {
+		t.Error("Save before StartCycle: expected an error")
+	}
+}
@@ -0,0 +1,85 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+)
+
+// Collector is one named unit of work of a collection cycle, e.g. the node
+// scrape or the zone skew of workloads. Collectors registered in a Registry
+// can be turned off one by one and are timed and counted by name.
+type Collector interface {
+	Name() string
+	Collect(ctx context.Context) error
+}
+
+// NewCollector returns a Collector named name that calls collect.
+func NewCollector(name string, collect func(ctx context.Context) error) Collector {
+	return funcCollector{name: name, collect: collect}
+}
+
+type funcCollector struct {
+	name    string
+	collect func(ctx context.Context) error
+}
+
+func (f funcCollector) Name() string                      { return f.name }
+func (f funcCollector) Collect(ctx context.Context) error { return f.collect(ctx) }
+
+// Registry holds the collectors of a cycle in the order they run. The zero
+// value is an empty registry. It is not safe for concurrent registration;
+// register everything before the cycle starts.
+type Registry struct {
+	collectors []Collector
+	disabled   map[string]bool
+}
+
+// Register appends c. Names must be unique within a registry.
+func (r *Registry) Register(c Collector) error {
+	for _, have := range r.collectors {
+		if have.Name() == c.Name() {
+			return fmt.Errorf("collector %q registered twice", c.Name())
+		}
+	}
+	r.collectors = append(r.collectors, c)
+	return nil
+}
+
+// MustRegister is Register for built-in collectors, whose names are fixed;
+// it panics on a duplicate.
+func (r *Registry) MustRegister(cs ...Collector) {
+	for _, c := range cs {
+		if err := r.Register(c); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// Disable keeps the collector name from running. Names nothing registered
+// under are remembered too, so Disable may be called before Register.
+func (r *Registry) Disable(name string) {
+	if r.disabled == nil {
+		r.disabled = make(map[string]bool)
+	}
+	r.disabled[name] = true
+}
+
+// Names returns the names of all registered collectors in order.
+func (r *Registry) Names() []string {
+	names := make([]string, len(r.collectors))
+	for i, c := range r.collectors {
+		names[i] = c.Name()
+	}
+	return names
+}
+
+// Enabled returns the collectors that were not disabled, in order.
+func (r *Registry) Enabled() []Collector {
+	var out []Collector
+	for _, c := range r.collectors {
+		if !r.disabled[c.Name()] {
+			out = append(out, c)
+		}
+	}
+	return out
+}
@@ -0,0 +1,31 @@
+package collector
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	nop := func(context.Context) error { return nil }
+	var r Registry
+	r.Disable("zone-skew")
+	for _, name := range []string{"node-usage", "zone-skew", "gpu"} {
+		if err := r.Register(NewCollector(name, nop)); err != nil {
+			t.Fatalf("Register(%s): %v", name, err)
+		}
+	}
+	if err := r.Register(NewCollector("gpu", nop)); err == nil {
+		t.Error("registering gpu twice succeeded")
+	}
+	if got, want := r.Names(), []string{"node-usage", "zone-skew", "gpu"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Names = %v, want %v", got, want)
+	}
+	var enabled []string
+	for _, c := range r.Enabled() {
+		enabled = append(enabled, c.Name())
+	}
+	if want := []string{"node-usage", "gpu"}; !reflect.DeepEqual(enabled, want) {
+		t.Errorf("Enabled = %v, want %v", enabled, want)
+	}
+}
@@ -0,0 +1,70 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// StatusError is returned for a scrape answered with a status other than
+// 200 OK.
+type StatusError struct {
+	Code int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("status %d", e.Code)
+}
+
+// Retryable reports whether a failed fetch may succeed when repeated: on
+// transport errors, such as a reset connection, and on 429 and 5xx
+// statuses, e.g. a 502 from the API server proxy. Other statuses, errors
+// before the request was sent and a cancelled or expired context are
+// final.
+func Retryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var status *StatusError
+	if errors.As(err, &status) {
+		return status.Code == http.StatusTooManyRequests || status.Code >= 500
+	}
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
+
+// Backoff returns how long to wait before retry attempt, counted from 0:
+// base doubled per attempt, of which a random half is waited, so the
+// retries of nodes that failed together spread out.
+func Backoff(base time.Duration, attempt int) time.Duration {
+	d := base << attempt
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// fetchRetrying is fetch with up to s.Retries more attempts after
+// failures that Retryable accepts.
+func (s *Scraper) fetchRetrying(ctx context.Context, client *http.Client, node, source, url, accept string) ([]byte, string, int64, error) {
+	for attempt := 0; ; attempt++ {
+		body, contentType, n, err := s.fetch(ctx, client, url, accept)
+		if err == nil || attempt >= s.Retries || !Retryable(err) {
+			return body, contentType, n, err
+		}
+		if s.OnRetry != nil {
+			s.OnRetry(node, source, err)
+		}
+		timer := time.NewTimer(Backoff(s.RetryBackoff, attempt))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, "", 0, err
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package collector
+
+import (
+	"strings"
+	"time"
+)
+
+// NodeSample holds everything extracted from a single kubelet or cAdvisor
+// scrape of one node.
+type NodeSample struct {
+	CPU float64
+	Mem float64
+	Net NetCounters
+	Raw []RawSeries
+
+	// Fs is the node's root filesystem (Summary API only).
+	Fs NodeFs
+
+	// Families is the series count per metric family (cAdvisor only).
+	Families map[string]int
+
+	// Namespaces is the share of the exposition per namespace label.
+	// Series without one (node and system cgroups) are not included.
+	Namespaces map[string]NamespaceCost
+
+	// Usage is the CPU and memory of each namespace's containers.
+	Usage map[string]NamespaceUsage
+
+	// Containers is the same per container, for series that carry a pod
+	// label. The pod-level cgroup is the entry with an empty Name and only
+	// PodCPU and PodMem set.
+	Containers map[ContainerRef]NamespaceUsage
+
+	// Bytes is the size of the scraped exposition, Transferred the bytes
+	// read off the wire for it (fewer when it came gzipped), ParseTime how
+	// long parsing it took and Format the exposition format the endpoint
+	// answered with (FormatProtobuf or FormatText); all are set by Scraper.
+	Bytes       int
+	Transferred int64
+	ParseTime   time.Duration
+	Format      string
+}
+
+// Exposition formats of NodeSample.Format.
+const (
+	FormatProtobuf = "protobuf"
+	FormatText     = "text"
+	FormatJSON     = "json" // the kubelet Summary API
+)
+
+// NamespaceCost is what one namespace's series add to an exposition.
+// Bytes are in the scraped format (text lines or protobuf messages).
+type NamespaceCost struct {
+	Series int
+	Bytes  int
+}
+
+// NamespaceUsage sums the CPUUsageMetric and MemWorkingSetMetric series of
+// a namespace's containers on one node. Pod-level cgroups and pause
+// containers (container "" or "POD") are skipped so nothing is counted
+// twice.
+//
+// PodCPU and PodMem sum the pod-level cgroups instead, which also hold the
+// sandbox (pause container) and any RuntimeClass overhead such as a Kata
+// VM or gVisor sentry; the difference to CPU and Mem is the pods' overhead.
+type NamespaceUsage struct {
+	CPU float64 // cumulative core-seconds
+	Mem float64 // bytes
+
+	PodCPU float64 // cumulative core-seconds
+	PodMem float64 // bytes
+}
+
+// PodRef names a pod by the namespace and pod labels of its series.
+type PodRef struct {
+	Namespace, Name string
+}
+
+// ContainerRef names a container by the namespace, pod and container
+// labels of its series.
+type ContainerRef struct {
+	Namespace, Pod, Name string
+}
+
+// PodUsage sums Containers per pod.
+func (s NodeSample) PodUsage() map[PodRef]NamespaceUsage {
+	pods := make(map[PodRef]NamespaceUsage)
+	for ref, u := range s.Containers {
+		pod := PodRef{Namespace: ref.Namespace, Name: ref.Pod}
+		sum := pods[pod]
+		sum.CPU += u.CPU
+		sum.Mem += u.Mem
+		sum.PodCPU += u.PodCPU
+		sum.PodMem += u.PodMem
+		pods[pod] = sum
+	}
+	return pods
+}
+
+// add adds the value of a CPUUsageMetric (cpu) or MemWorkingSetMetric
+// series of a workload container or, when container is false, of a
+// pod-level cgroup.
+func (u *NamespaceUsage) add(cpu, container bool, v float64) {
+	switch {
+	case cpu && container:
+		u.CPU += v
+	case cpu:
+		u.PodCPU += v
+	case container:
+		u.Mem += v
+	default:
+		u.PodMem += v
+	}
+}
+
+// isContainer reports whether a container label value names a workload
+// container rather than a pod cgroup or pause container.
+func isContainer(name string) bool {
+	return name != "" && name != "POD"
+}
+
+// isPodCgroup reports whether a cgroup id names a pod-level cgroup, like
+// /kubepods/burstable/pod<uid> or, with the systemd driver,
+// .../kubepods-burstable-pod<uid>.slice, rather than a container in it.
+func isPodCgroup(id string) bool {
+	last := id[strings.LastIndexByte(id, '/')+1:]
+	return strings.HasPrefix(last, "pod") || strings.Contains(last, "-pod")
+}
+
+// NodeFs is the usage of a node's root filesystem.
+type NodeFs struct {
+	CapacityBytes, UsedBytes float64
+}
+
+// NetCounters are cumulative host interface counters for one node.
+type NetCounters struct {
+	RxErrors, TxErrors float64
+	RxDrops, TxDrops   float64
+}
+
+// cAdvisor reports network counters per cgroup; the root cgroup (id="/")
+// carries the host interfaces, so only those series are summed per node.
+const RootCgroupLabel = `id="/"`
+
+var networkCounterMetrics = map[string]func(*NetCounters) *float64{
+	"container_network_receive_errors_total":           func(c *NetCounters) *float64 { return &c.RxErrors },
+	"container_network_transmit_errors_total":          func(c *NetCounters) *float64 { return &c.TxErrors },
+	"container_network_receive_packets_dropped_total":  func(c *NetCounters) *float64 { return &c.RxDrops },
+	"container_network_transmit_packets_dropped_total": func(c *NetCounters) *float64 { return &c.TxDrops },
+}
+
+// Series is the total number of series in the scraped exposition.
+func (s NodeSample) Series() int {
+	n := 0
+	for _, c := range s.Families {
+		n += c
+	}
+	return n
+}
@@ -0,0 +1,117 @@
+package collector
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTextParserSeriesCount(t *testing.T) {
+	body := []byte(`# HELP container_cpu_usage_seconds_total CPU usage
+# TYPE container_cpu_usage_seconds_total counter
+container_cpu_usage_seconds_total{id="/"} 1.5
+container_cpu_usage_seconds_total{id="/system"} 0.2
+
+machine_cpu_cores 8
+`)
+	sample, err := NewTextParser(nil).Parse(body)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	got := sample.Families
+	if got["container_cpu_usage_seconds_total"] != 2 || got["machine_cpu_cores"] != 1 || len(got) != 2 {
+		t.Errorf("families = %v", got)
+	}
+	if n := sample.Series(); n != 3 {
+		t.Errorf("Series = %d, want 3", n)
+	}
+}
+
+func TestTextParserNetwork(t *testing.T) {
+	body := []byte(`# HELP container_network_receive_errors_total Cumulative count of errors encountered while receiving
+# TYPE container_network_receive_errors_total counter
+container_network_receive_errors_total{id="/",interface="eth0"} 3
+container_network_receive_errors_total{id="/",interface="eth1"} 2
+container_network_receive_errors_total{id="/kubepods/pod1",interface="eth0"} 100
+container_network_receive_errors_total{container_id="/",interface="eth0"} 50
+container_network_transmit_errors_total{id="/",interface="eth0"} 1
+container_network_receive_packets_dropped_total{id="/",interface="eth0"} 7
+container_network_transmit_packets_dropped_total{id="/",interface="eth0"} 4
+container_cpu_usage_seconds_total{id="/"} 1.5
+`)
+	sample, err := NewTextParser(nil).Parse(body)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	want := NetCounters{RxErrors: 5, TxErrors: 1, RxDrops: 7, TxDrops: 4}
+	if sample.Net != want {
+		t.Errorf("net = %+v, want %+v", sample.Net, want)
+	}
+}
+
+func TestTextParserNamespaceCost(t *testing.T) {
+	shop1 := `container_cpu_usage_seconds_total{id="/kubepods/pod1",namespace="shop",pod="a"} 1`
+	shop2 := `container_memory_working_set_bytes{id="/kubepods/pod1",namespace="shop",pod="a"} 2`
+	batch := `container_cpu_usage_seconds_total{id="/kubepods/pod2",kube_namespace="x",namespace="batch"} 3`
+	body := []byte("# TYPE container_cpu_usage_seconds_total counter\n" +
+		`container_cpu_usage_seconds_total{id="/"} 4` + "\n" +
+		shop1 + "\n" + batch + "\n" + shop2 + "\n")
+	sample, err := NewTextParser(nil).Parse(body)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	want := map[string]NamespaceCost{
+		"shop":  {Series: 2, Bytes: len(shop1) + len(shop2) + 2},
+		"batch": {Series: 1, Bytes: len(batch) + 1},
+	}
+	if len(sample.Namespaces) != len(want) {
+		t.Errorf("namespaces = %v, want %v", sample.Namespaces, want)
+	}
+	for ns, w := range want {
+		if got := sample.Namespaces[ns]; got != w {
+			t.Errorf("namespaces[%q] = %+v, want %+v", ns, got, w)
+		}
+	}
+}
+
+func TestTextParserNamespaceUsage(t *testing.T) {
+	body := []byte(`container_cpu_usage_seconds_total{id="/"} 100
+container_cpu_usage_seconds_total{container="",id="/kubepods/pod1",namespace="shop",pod="a"} 12
+container_cpu_usage_seconds_total{container="POD",id="/kubepods/pod1/pause",namespace="shop",pod="a"} 1
+container_cpu_usage_seconds_total{container="app",id="/kubepods/pod1/app",namespace="shop",pod="a"} 8
+container_cpu_usage_seconds_total{container="sidecar",id="/kubepods/pod1/sc",namespace="shop",pod="a"} 3
+container_memory_working_set_bytes{container="",id="/kubepods/pod1",namespace="shop",pod="a"} 4096
+container_memory_working_set_bytes{container="app",id="/kubepods/pod1/app",namespace="shop",pod="a"} 2048
+container_memory_working_set_bytes{container="",id="/kubepods/pod2/5f2c",namespace="batch",pod="b"} 64
+container_memory_working_set_bytes{container="job",id="/kubepods/pod2/job",namespace="batch",pod="b"} 512
+container_network_receive_bytes_total{id="/kubepods/pod3",interface="eth0",namespace="idle",pod="c"} 1
+`)
+	sample, err := NewTextParser(nil).Parse(body)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	want := map[string]NamespaceUsage{"shop": {CPU: 11, Mem: 2048, PodCPU: 12, PodMem: 4096}, "batch": {Mem: 512}}
+	if len(sample.Usage) != len(want) {
+		t.Errorf("usage = %v, want %v", sample.Usage, want)
+	}
+	for ns, w := range want {
+		if got := sample.Usage[ns]; got != w {
+			t.Errorf("usage[%q] = %+v, want %+v", ns, got, w)
+		}
+	}
+	wantContainers := map[ContainerRef]NamespaceUsage{
+		{Namespace: "shop", Pod: "a"}:                  {PodCPU: 12, PodMem: 4096},
+		{Namespace: "shop", Pod: "a", Name: "app"}:     {CPU: 8, Mem: 2048},
+		{Namespace: "shop", Pod: "a", Name: "sidecar"}: {CPU: 3},
+		{Namespace: "batch", Pod: "b", Name: "job"}:    {Mem: 512},
+	}
+	if !reflect.DeepEqual(sample.Containers, wantContainers) {
+		t.Errorf("containers = %v, want %v", sample.Containers, wantContainers)
+	}
+	wantPods := map[PodRef]NamespaceUsage{
+		{Namespace: "shop", Name: "a"}:  {CPU: 11, Mem: 2048, PodCPU: 12, PodMem: 4096},
+		{Namespace: "batch", Name: "b"}: {Mem: 512},
+	}
+	if got := sample.PodUsage(); !reflect.DeepEqual(got, wantPods) {
+		t.Errorf("pod usage = %v, want %v", got, wantPods)
+	}
+}
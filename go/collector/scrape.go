@@ -0,0 +1,230 @@
+package collector
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/common/expfmt"
+)
+
+// Scraper fetches kubelet and cAdvisor metrics of nodes through the API
+// server proxy at BaseURL, or directly from the kubelets when Direct is set.
+type Scraper struct {
+	Client  *http.Client
+	BaseURL string
+
+	// Direct, when set, returns the URL of a kubelet path such as
+	// "/metrics/cadvisor" on a node; it is fetched with DirectClient
+	// instead of through the proxy.
+	Direct       func(node, path string) (string, error)
+	DirectClient *http.Client
+
+	// Protobuf asks for the delimited protobuf exposition format, falling
+	// back to text when the endpoint does not offer it.
+	Protobuf bool
+
+	// Gzip asks for gzip-compressed responses and decompresses them. A
+	// cAdvisor exposition shrinks about tenfold, which is what the API
+	// server proxy has to carry.
+	Gzip bool
+
+	// Retries is how many times a fetch that failed with a Retryable
+	// error is repeated, after Backoff(RetryBackoff, attempt). OnRetry,
+	// when set, is called before each retry.
+	Retries      int
+	RetryBackoff time.Duration
+	OnRetry      func(node, source string, err error)
+
+	// Passthrough selects raw series to return in NodeSample.Raw.
+	Passthrough Rules
+
+	// Record, when set, is called with the payload of every successful
+	// fetch, e.g. to save it with a Recorder.
+	Record func(node, source, format string, body []byte)
+}
+
+// Sources of a ScrapeSource.
+const (
+	SourceCadvisor = "cadvisor"
+	SourceKubelet  = "kubelet"
+	SourceSummary  = "summary" // the kubelet Summary API
+
+	// SourceMetricsServer marks node totals taken from metrics-server
+	// after the node's own scrape failed.
+	SourceMetricsServer = "metrics-server"
+)
+
+// ScrapeSource records where one node's data came from in a cycle, for
+// auditing the provenance of the exported figures across a fleet.
+type ScrapeSource struct {
+	Node             string
+	Source           string // SourceCadvisor, SourceKubelet, SourceSummary or SourceMetricsServer
+	Format           string // NodeSample.Format
+	KubeletVersion   string
+	ContainerRuntime string // e.g. containerd://1.7.2, the CRI implementation behind cAdvisor's data
+}
+
+// Cadvisor scrapes /metrics/cadvisor of node. Fetch and parse time is added
+// to b, which may be nil.
+func (s *Scraper) Cadvisor(ctx context.Context, node string, b *Budget) (NodeSample, error) {
+	return s.scrape(ctx, node, SourceCadvisor, "/metrics/cadvisor", b)
+}
+
+// Kubelet scrapes /metrics of node. Fetch and parse time is added to b,
+// which may be nil.
+func (s *Scraper) Kubelet(ctx context.Context, node string, b *Budget) (NodeSample, error) {
+	return s.scrape(ctx, node, SourceKubelet, "/metrics", b)
+}
+
+// Summary fetches the kubelet Summary API, /stats/summary, of node. Fetch
+// and parse time is added to b, which may be nil.
+func (s *Scraper) Summary(ctx context.Context, node string, b *Budget) (NodeSample, error) {
+	return s.scrape(ctx, node, SourceSummary, "/stats/summary", b)
+}
+
+func (s *Scraper) scrape(ctx context.Context, node, source, path string, b *Budget) (NodeSample, error) {
+	client, url := s.Client, fmt.Sprintf("%s/api/v1/nodes/%s/proxy%s", s.BaseURL, node, path)
+	if s.Direct != nil {
+		var err error
+		if url, err = s.Direct(node, path); err != nil {
+			return NodeSample{}, err
+		}
+		client = s.DirectClient
+	}
+	start := time.Now()
+	accept := TextAccept
+	if s.Protobuf {
+		accept = ProtobufAccept
+	}
+	if source == SourceSummary {
+		accept = "application/json"
+	}
+	body, contentType, transferred, err := s.fetchRetrying(ctx, client, node, source, url, accept)
+	if err != nil {
+		return NodeSample{}, err
+	}
+	b.Since(PhaseProxyFetch, start)
+
+	format := FormatJSON
+	if source != SourceSummary {
+		if format, err = expositionFormat(contentType); err != nil {
+			return NodeSample{}, err
+		}
+	}
+	if s.Record != nil {
+		s.Record(node, source, format, body)
+	}
+
+	start = time.Now()
+	sample, err := parsePayload(format, body, s.Passthrough)
+	b.Since(PhaseParse, start)
+	sample.ParseTime = time.Since(start)
+	sample.Transferred = transferred
+	return sample, err
+}
+
+// fetch GETs a metrics endpoint with client, asking for accept when it is
+// set, and returns the full body, its Content-Type and the bytes read off
+// the wire. The encoding is always set explicitly: left alone, the
+// transport would ask for gzip itself and hide the compressed size.
+func (s *Scraper) fetch(ctx context.Context, client *http.Client, url, accept string) ([]byte, string, int64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	if s.Gzip {
+		req.Header.Set("Accept-Encoding", "gzip")
+	} else {
+		req.Header.Set("Accept-Encoding", "identity")
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", 0, &StatusError{Code: resp.StatusCode}
+	}
+	wire := &countingReader{r: resp.Body}
+	var r io.Reader = wire
+	if resp.Header.Get("Content-Encoding") == "gzip" {
+		zr, err := gzip.NewReader(wire)
+		if err != nil {
+			return nil, "", 0, fmt.Errorf("gzip: %v", err)
+		}
+		defer zr.Close()
+		r = zr
+	}
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	return body, resp.Header.Get("Content-Type"), wire.n, nil
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// expositionFormat returns the NodeSample format of an exposition served
+// with contentType. A response without one is taken as text, like
+// Prometheus does; OpenMetrics text is read by the text parser, whose
+// sample lines it shares. Protobuf is only parsed in its delimited
+// encoding.
+func expositionFormat(contentType string) (string, error) {
+	if contentType == "" {
+		return FormatText, nil
+	}
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", fmt.Errorf("content type %q: %v", contentType, err)
+	}
+	switch mediaType {
+	case expfmt.ProtoType:
+		if enc := params["encoding"]; enc != "" && enc != "delimited" {
+			return "", fmt.Errorf("unsupported protobuf encoding %q", enc)
+		}
+		return FormatProtobuf, nil
+	case "text/plain", "application/openmetrics-text":
+		return FormatText, nil
+	}
+	return "", fmt.Errorf("unsupported exposition format %q", contentType)
+}
+
+// ParseContainerMetrics sums the given CPU and memory families across all
+// series of a text exposition.
+func ParseContainerMetrics(body io.Reader, cpuMetric, memMetric string) (cpuTotal, memTotal float64, err error) {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return 0, 0, err
+	}
+	p := &TextParser{cpuMetric: cpuMetric, memMetric: memMetric}
+	sample, err := p.Parse(data)
+	return sample.CPU, sample.Mem, err
+}
+
+// ParsePrometheusValue returns the value of a text exposition sample line,
+// ignoring a trailing timestamp, or 0 when it has none.
+func ParsePrometheusValue(line string) float64 {
+	_, _, value, ok := splitSampleLine([]byte(line))
+	if !ok {
+		return 0
+	}
+	return parseValue(value)
+}
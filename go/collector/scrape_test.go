@@ -0,0 +1,244 @@
+package collector
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestScraperRecordsFormat(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/nodes/node-a/proxy/metrics/cadvisor" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		w.Write([]byte("container_cpu_usage_seconds_total{id=\"/\"} 5\n"))
+	}))
+	defer srv.Close()
+
+	s := &Scraper{Client: srv.Client(), BaseURL: srv.URL, Protobuf: true}
+	sample, err := s.Cadvisor(context.Background(), "node-a", nil)
+	if err != nil {
+		t.Fatalf("Cadvisor: %v", err)
+	}
+	if sample.Format != FormatText || sample.Bytes == 0 {
+		t.Errorf("Format = %q, Bytes = %d; want %q and the body size", sample.Format, sample.Bytes, FormatText)
+	}
+	if _, err := s.Kubelet(context.Background(), "node-a", nil); err == nil {
+		t.Error("Kubelet: expected an error for status 404")
+	}
+}
+
+func TestScraperDirect(t *testing.T) {
+	kubelet := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/metrics/cadvisor" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		w.Write([]byte("container_cpu_usage_seconds_total{id=\"/\"} 5\n"))
+	}))
+	defer kubelet.Close()
+
+	s := &Scraper{
+		BaseURL: "http://proxy.invalid",
+		Direct: func(node, path string) (string, error) {
+			if node != "node-a" {
+				return "", fmt.Errorf("node %s has no InternalIP", node)
+			}
+			return kubelet.URL + path, nil
+		},
+		DirectClient: kubelet.Client(),
+	}
+	sample, err := s.Cadvisor(context.Background(), "node-a", nil)
+	if err != nil {
+		t.Fatalf("Cadvisor: %v", err)
+	}
+	if sample.CPU != 5 {
+		t.Errorf("CPU = %g, want 5", sample.CPU)
+	}
+	if _, err := s.Cadvisor(context.Background(), "node-b", nil); err == nil {
+		t.Error("Cadvisor(node-b): expected an error for an unresolved node")
+	}
+}
+
+func TestScraperSummary(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/nodes/node-a/proxy/stats/summary" || r.Header.Get("Accept") != "application/json" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(summaryJSON))
+	}))
+	defer srv.Close()
+
+	var recorded string
+	s := &Scraper{Client: srv.Client(), BaseURL: srv.URL, Protobuf: true, Record: func(_, source, format string, _ []byte) {
+		recorded = source + "/" + format
+	}}
+	sample, err := s.Summary(context.Background(), "node-a", nil)
+	if err != nil {
+		t.Fatalf("Summary: %v", err)
+	}
+	if sample.Format != FormatJSON || sample.CPU != 120 || recorded != "summary/json" {
+		t.Errorf("Format = %q, CPU = %g, recorded %q; want json, 120, summary/json", sample.Format, sample.CPU, recorded)
+	}
+}
+
+func TestScraperNegotiatesFormat(t *testing.T) {
+	contentType, accept := "", ""
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accept = r.Header.Get("Accept")
+		w.Header().Set("Content-Type", contentType)
+		w.Write([]byte("# HELP container_cpu_usage_seconds_total help\n# TYPE container_cpu_usage_seconds_total counter\ncontainer_cpu_usage_seconds_total{id=\"/\"} 5\n"))
+	}))
+	defer srv.Close()
+
+	s := &Scraper{Client: srv.Client(), BaseURL: srv.URL}
+	contentType = "text/plain; version=0.0.4; charset=utf-8"
+	sample, err := s.Cadvisor(context.Background(), "node-a", nil)
+	if err != nil || sample.CPU != 5 {
+		t.Errorf("Cadvisor = %v, %v; want CPU 5", sample.CPU, err)
+	}
+	if accept != TextAccept {
+		t.Errorf("Accept = %q without -scrape-protobuf, want %q", accept, TextAccept)
+	}
+	s.Protobuf = true
+	if s.Cadvisor(context.Background(), "node-a", nil); accept != ProtobufAccept {
+		t.Errorf("Accept = %q, want %q", accept, ProtobufAccept)
+	}
+	contentType = "application/vnd.google.protobuf; proto=io.prometheus.client.MetricFamily; encoding=text"
+	if _, err := s.Cadvisor(context.Background(), "node-a", nil); err == nil {
+		t.Error("Cadvisor parsed a protobuf text encoding")
+	}
+}
+
+func TestExpositionFormat(t *testing.T) {
+	tests := []struct {
+		contentType, format string
+	}{
+		{"", FormatText},
+		{"text/plain; version=0.0.4", FormatText},
+		{"text/plain; version=1.0.0; charset=utf-8", FormatText},
+		{"application/openmetrics-text; version=1.0.0; charset=utf-8", FormatText},
+		{"application/vnd.google.protobuf; proto=io.prometheus.client.MetricFamily; encoding=delimited", FormatProtobuf},
+		{"application/vnd.google.protobuf; proto=io.prometheus.client.MetricFamily; encoding=compact-text", ""},
+		{"text/html", ""},
+		{"text/plain; version", ""},
+	}
+	for _, tt := range tests {
+		format, err := expositionFormat(tt.contentType)
+		if format != tt.format || (err != nil) != (tt.format == "") {
+			t.Errorf("expositionFormat(%q) = %q, %v; want %q", tt.contentType, format, err, tt.format)
+		}
+	}
+}
+
+func TestScraperGzip(t *testing.T) {
+	var exposition strings.Builder
+	for i := 0; i < 500; i++ {
+		fmt.Fprintf(&exposition, "container_cpu_usage_seconds_total{namespace=\"ns\",pod=\"p%d\",container=\"c\"} 1\n", i)
+	}
+	var encoding string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encoding = r.Header.Get("Accept-Encoding")
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		if encoding != "gzip" {
+			io.WriteString(w, exposition.String())
+			return
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		zw := gzip.NewWriter(w)
+		io.WriteString(zw, exposition.String())
+		zw.Close()
+	}))
+	defer srv.Close()
+
+	s := &Scraper{Client: srv.Client(), BaseURL: srv.URL, Gzip: true}
+	sample, err := s.Cadvisor(context.Background(), "node-a", nil)
+	if err != nil {
+		t.Fatalf("Cadvisor: %v", err)
+	}
+	if sample.CPU != 500 || sample.Bytes != exposition.Len() {
+		t.Errorf("CPU = %g, Bytes = %d; want 500 and %d", sample.CPU, sample.Bytes, exposition.Len())
+	}
+	if sample.Transferred <= 0 || sample.Transferred*5 > int64(sample.Bytes) {
+		t.Errorf("Transferred = %d of %d bytes, want it compressed", sample.Transferred, sample.Bytes)
+	}
+
+	s.Gzip = false
+	if sample, err = s.Cadvisor(context.Background(), "node-a", nil); err != nil || encoding != "identity" {
+		t.Fatalf("Cadvisor without gzip: %v, Accept-Encoding %q", err, encoding)
+	}
+	if sample.Transferred != int64(sample.Bytes) {
+		t.Errorf("Transferred = %d, want all %d bytes", sample.Transferred, sample.Bytes)
+	}
+}
+
+func TestScraperRetries(t *testing.T) {
+	statuses := []int{http.StatusBadGateway, http.StatusOK}
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := statuses[min(requests, len(statuses)-1)]
+		requests++
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		io.WriteString(w, "container_cpu_usage_seconds_total{id=\"/\"} 5\n")
+	}))
+	defer srv.Close()
+
+	var retried []string
+	s := &Scraper{Client: srv.Client(), BaseURL: srv.URL, Retries: 2, RetryBackoff: time.Millisecond,
+		OnRetry: func(node, source string, err error) { retried = append(retried, source+":"+node+": "+err.Error()) }}
+	if _, err := s.Cadvisor(context.Background(), "node-a", nil); err != nil {
+		t.Fatalf("Cadvisor after a 502: %v", err)
+	}
+	if requests != 2 || len(retried) != 1 || retried[0] != "cadvisor:node-a: status 502" {
+		t.Errorf("%d requests, retries %q; want 2 requests and one retry after the 502", requests, retried)
+	}
+
+	// A 404 is not going away; neither is a failure with retries off.
+	for _, tc := range []struct {
+		status, retries, want int
+	}{
+		{http.StatusNotFound, 2, 1},
+		{http.StatusServiceUnavailable, 0, 1},
+		{http.StatusServiceUnavailable, 2, 3},
+	} {
+		statuses, requests, retried = []int{tc.status}, 0, nil
+		s.Retries = tc.retries
+		_, err := s.Cadvisor(context.Background(), "node-a", nil)
+		var status *StatusError
+		if !errors.As(err, &status) || status.Code != tc.status {
+			t.Errorf("status %d: err = %v, want a StatusError", tc.status, err)
+		}
+		if requests != tc.want {
+			t.Errorf("status %d with %d retries: %d requests, want %d", tc.status, tc.retries, requests, tc.want)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	for attempt, base := range []time.Duration{100, 200, 400} {
+		for i := 0; i < 100; i++ {
+			if d := Backoff(100, attempt); d < base/2 || d > base {
+				t.Fatalf("Backoff(100, %d) = %v, want within [%v, %v]", attempt, d, base/2, base)
+			}
+		}
+	}
+	if d := Backoff(0, 3); d != 0 {
+		t.Errorf("Backoff(0, 3) = %v, want 0", d)
+	}
+}
@@ -0,0 +1,133 @@
+package collector
+
+import (
+	"encoding/json"
+)
+
+// The subset of the kubelet Summary API (/stats/summary, stats/v1alpha1)
+// that ParseSummary reads. Counters the kubelet has not collected yet are
+// absent, hence the pointers.
+type summary struct {
+	Node summaryNode  `json:"node"`
+	Pods []summaryPod `json:"pods"`
+}
+
+type summaryNode struct {
+	CPU     *summaryCPU     `json:"cpu"`
+	Memory  *summaryMemory  `json:"memory"`
+	Network *summaryNetwork `json:"network"`
+	Fs      *summaryFs      `json:"fs"`
+}
+
+type summaryPod struct {
+	PodRef struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+	} `json:"podRef"`
+	Containers []summaryContainer `json:"containers"`
+	CPU        *summaryCPU        `json:"cpu"`
+	Memory     *summaryMemory     `json:"memory"`
+}
+
+type summaryContainer struct {
+	Name   string         `json:"name"`
+	CPU    *summaryCPU    `json:"cpu"`
+	Memory *summaryMemory `json:"memory"`
+}
+
+type summaryCPU struct {
+	UsageCoreNanoSeconds *uint64 `json:"usageCoreNanoSeconds"`
+}
+
+type summaryMemory struct {
+	WorkingSetBytes *uint64 `json:"workingSetBytes"`
+}
+
+type summaryNetwork struct {
+	Interfaces []struct {
+		RxErrors *uint64 `json:"rxErrors"`
+		TxErrors *uint64 `json:"txErrors"`
+	} `json:"interfaces"`
+}
+
+type summaryFs struct {
+	CapacityBytes *uint64 `json:"capacityBytes"`
+	UsedBytes     *uint64 `json:"usedBytes"`
+}
+
+// coreSeconds returns the cumulative CPU time of c in core-seconds.
+func (c *summaryCPU) coreSeconds() float64 {
+	if c == nil {
+		return 0
+	}
+	return float64(uintValue(c.UsageCoreNanoSeconds)) / 1e9
+}
+
+// workingSet returns the memory working set of m in bytes.
+func (m *summaryMemory) workingSet() float64 {
+	if m == nil {
+		return 0
+	}
+	return float64(uintValue(m.WorkingSetBytes))
+}
+
+func uintValue(p *uint64) uint64 {
+	if p == nil {
+		return 0
+	}
+	return *p
+}
+
+// ParseSummary builds a NodeSample from a kubelet Summary API response,
+// for nodes whose cAdvisor endpoint is restricted. CPU and Mem are the
+// node totals, Net sums the node's interface error counters (the Summary
+// API has no drop counters) and Fs is the node's root filesystem. Usage
+// and Containers come from the pods' container and pod-level stats, so
+// the namespace and container breakdowns work as with cAdvisor; the
+// exposition cost fields stay empty.
+func ParseSummary(body []byte) (NodeSample, error) {
+	var s summary
+	if err := json.Unmarshal(body, &s); err != nil {
+		return NodeSample{}, err
+	}
+	sample := NodeSample{
+		CPU:        s.Node.CPU.coreSeconds(),
+		Mem:        s.Node.Memory.workingSet(),
+		Usage:      make(map[string]NamespaceUsage),
+		Containers: make(map[ContainerRef]NamespaceUsage),
+	}
+	if n := s.Node.Network; n != nil {
+		for _, iface := range n.Interfaces {
+			sample.Net.RxErrors += float64(uintValue(iface.RxErrors))
+			sample.Net.TxErrors += float64(uintValue(iface.TxErrors))
+		}
+	}
+	if fs := s.Node.Fs; fs != nil {
+		sample.Fs = NodeFs{CapacityBytes: float64(uintValue(fs.CapacityBytes)), UsedBytes: float64(uintValue(fs.UsedBytes))}
+	}
+	for _, p := range s.Pods {
+		ns := p.PodRef.Namespace
+		if ns == "" {
+			continue
+		}
+		u := sample.Usage[ns]
+		for _, c := range p.Containers {
+			cpu, mem := c.CPU.coreSeconds(), c.Memory.workingSet()
+			u.add(true, true, cpu)
+			u.add(false, true, mem)
+			ref := ContainerRef{Namespace: ns, Pod: p.PodRef.Name, Name: c.Name}
+			cu := sample.Containers[ref]
+			cu.add(true, true, cpu)
+			cu.add(false, true, mem)
+			sample.Containers[ref] = cu
+		}
+		cpu, mem := p.CPU.coreSeconds(), p.Memory.workingSet()
+		u.add(true, false, cpu)
+		u.add(false, false, mem)
+		sample.Containers[ContainerRef{Namespace: ns, Pod: p.PodRef.Name}] = NamespaceUsage{PodCPU: cpu, PodMem: mem}
+		if u != (NamespaceUsage{}) {
+			sample.Usage[ns] = u
+		}
+	}
+	return sample, nil
+}
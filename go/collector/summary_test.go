@@ -0,0 +1,67 @@
+package collector
+
+import (
+	"reflect"
+	"testing"
+)
+
+const summaryJSON = `{
+  "node": {
+    "nodeName": "node-a",
+    "cpu": {"usageNanoCores": 250000000, "usageCoreNanoSeconds": 120000000000},
+    "memory": {"workingSetBytes": 2000000000},
+    "network": {"interfaces": [
+      {"name": "eth0", "rxErrors": 3, "txErrors": 1},
+      {"name": "eth1", "rxErrors": 2}
+    ]},
+    "fs": {"capacityBytes": 100000000000, "usedBytes": 40000000000}
+  },
+  "pods": [
+    {
+      "podRef": {"name": "web-1", "namespace": "shop"},
+      "containers": [
+        {"name": "app", "cpu": {"usageCoreNanoSeconds": 30000000000}, "memory": {"workingSetBytes": 300000000}},
+        {"name": "istio-proxy", "cpu": {"usageCoreNanoSeconds": 5000000000}, "memory": {"workingSetBytes": 50000000}}
+      ],
+      "cpu": {"usageCoreNanoSeconds": 36000000000},
+      "memory": {"workingSetBytes": 360000000}
+    },
+    {
+      "podRef": {"name": "starting", "namespace": "shop"},
+      "containers": [{"name": "app"}]
+    }
+  ]
+}`
+
+func TestParseSummary(t *testing.T) {
+	s, err := ParseSummary([]byte(summaryJSON))
+	if err != nil {
+		t.Fatalf("ParseSummary: %v", err)
+	}
+	if s.CPU != 120 || s.Mem != 2e9 {
+		t.Errorf("CPU, Mem = %g, %g; want 120, 2e9", s.CPU, s.Mem)
+	}
+	if s.Net != (NetCounters{RxErrors: 5, TxErrors: 1}) {
+		t.Errorf("Net = %+v", s.Net)
+	}
+	if s.Fs != (NodeFs{CapacityBytes: 1e11, UsedBytes: 4e10}) {
+		t.Errorf("Fs = %+v", s.Fs)
+	}
+	if want := (NamespaceUsage{CPU: 35, Mem: 3.5e8, PodCPU: 36, PodMem: 3.6e8}); s.Usage["shop"] != want {
+		t.Errorf("Usage[shop] = %+v, want %+v", s.Usage["shop"], want)
+	}
+	want := map[ContainerRef]NamespaceUsage{
+		{"shop", "web-1", "app"}:         {CPU: 30, Mem: 3e8},
+		{"shop", "web-1", "istio-proxy"}: {CPU: 5, Mem: 5e7},
+		{"shop", "web-1", ""}:            {PodCPU: 36, PodMem: 3.6e8},
+		{"shop", "starting", "app"}:      {},
+		{"shop", "starting", ""}:         {},
+	}
+	if !reflect.DeepEqual(s.Containers, want) {
+		t.Errorf("Containers = %+v, want %+v", s.Containers, want)
+	}
+
+	if _, err := ParseSummary([]byte("container_cpu_usage_seconds_total 1\n")); err == nil {
+		t.Error("ParseSummary accepted a text exposition")
+	}
+}
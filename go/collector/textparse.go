@@ -0,0 +1,295 @@
+package collector
+
+import (
+	"bytes"
+	"strconv"
+	"unsafe"
+)
+
+// cAdvisor families summed into NodeSample.CPU and NodeSample.Mem.
+const (
+	CPUUsageMetric      = "container_cpu_usage_seconds_total"
+	MemWorkingSetMetric = "container_memory_working_set_bytes"
+)
+
+var (
+	rootCgroupPair  = []byte(RootCgroupLabel)
+	namespacePrefix = []byte(`namespace="`)
+	containerPrefix = []byte(`container="`)
+	idPrefix        = []byte(`id="`)
+	podPrefix       = []byte(`pod="`)
+)
+
+// TextParser extracts a NodeSample from a text exposition in one pass over
+// the raw bytes. Lines are never converted to strings, so allocations grow
+// with the distinct metric families, namespaces and containers (once each)
+// and the passthrough matches, not with the number of lines.
+type TextParser struct {
+	cpuMetric, memMetric string
+	rules                Rules
+}
+
+// NewTextParser returns a parser for the cAdvisor CPU and memory families
+// that also collects series selected by rules.
+func NewTextParser(rules Rules) *TextParser {
+	return &TextParser{cpuMetric: CPUUsageMetric, memMetric: MemWorkingSetMetric, rules: rules}
+}
+
+// Parse extracts a NodeSample from a text exposition.
+func (p *TextParser) Parse(body []byte) (NodeSample, error) {
+	var sample NodeSample
+	familyIndex := make(map[string]int)
+	var familyCounts []int
+	nsIndex := make(map[string]int)
+	var nsCosts []NamespaceCost
+	var nsUsage []NamespaceUsage
+	containers := containerTable{index: make(map[string]int)}
+	for len(body) > 0 {
+		var line []byte
+		if i := bytes.IndexByte(body, '\n'); i >= 0 {
+			line, body = body[:i], body[i+1:]
+		} else {
+			line, body = body, nil
+		}
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+		name, lbls, value, ok := splitSampleLine(line)
+		if !ok {
+			continue
+		}
+
+		// Map lookups keyed by string(name) do not allocate; inserts do, so
+		// counts live in a slice and the map is only written per new family.
+		if i, seen := familyIndex[string(name)]; seen {
+			familyCounts[i]++
+		} else {
+			familyIndex[string(name)] = len(familyCounts)
+			familyCounts = append(familyCounts, 1)
+		}
+
+		nsi := -1
+		ns := labelValue(lbls, namespacePrefix)
+		if len(ns) > 0 {
+			i, seen := nsIndex[string(ns)]
+			if !seen {
+				i = len(nsCosts)
+				nsIndex[string(ns)] = i
+				nsCosts = append(nsCosts, NamespaceCost{})
+				nsUsage = append(nsUsage, NamespaceUsage{})
+			}
+			nsCosts[i].Series++
+			nsCosts[i].Bytes += len(line) + 1
+			nsi = i
+		}
+
+		// Comparisons against string(name) do not allocate; a switch does.
+		cpu := string(name) == p.cpuMetric
+		if cpu || string(name) == p.memMetric {
+			v := parseValue(value)
+			if cpu {
+				sample.CPU += v
+			} else {
+				sample.Mem += v
+			}
+			if container := inContainer(lbls); nsi >= 0 && (container || inPodCgroup(lbls)) {
+				nsUsage[nsi].add(cpu, container, v)
+				if pod := labelValue(lbls, podPrefix); len(pod) > 0 {
+					containers.at(ns, pod, labelValue(lbls, containerPrefix)).add(cpu, container, v)
+				}
+			}
+		}
+		if field, ok := networkCounterMetrics[string(name)]; ok && hasLabelPair(lbls, rootCgroupPair) {
+			*field(&sample.Net) += parseValue(value)
+		}
+		if len(p.rules) > 0 && p.rules.Wants(string(name)) {
+			n, l, v, err := parseSampleLine(string(line))
+			if err == nil && p.rules.Match(n, l) {
+				sample.Raw = append(sample.Raw, RawSeries{Name: n, Labels: l, Value: v})
+			}
+		}
+	}
+	sample.Families = make(map[string]int, len(familyIndex))
+	for name, i := range familyIndex {
+		sample.Families[name] = familyCounts[i]
+	}
+	sample.Namespaces = make(map[string]NamespaceCost, len(nsIndex))
+	sample.Usage = make(map[string]NamespaceUsage)
+	for ns, i := range nsIndex {
+		sample.Namespaces[ns] = nsCosts[i]
+		if nsUsage[i] != (NamespaceUsage{}) {
+			sample.Usage[ns] = nsUsage[i]
+		}
+	}
+	sample.Containers = make(map[ContainerRef]NamespaceUsage, len(containers.refs))
+	for i, ref := range containers.refs {
+		sample.Containers[ref] = containers.usage[i]
+	}
+	return sample, nil
+}
+
+// containerTable accumulates usage per container. The lookup key is built
+// in a reused buffer, so only a container's first series allocates, once:
+// the ContainerRef fields are substrings of the key.
+type containerTable struct {
+	index map[string]int
+	refs  []ContainerRef
+	usage []NamespaceUsage
+	key   []byte
+}
+
+// at returns the usage of the container named by the ns, pod and
+// container label values. The pointer is valid until the next call.
+func (t *containerTable) at(ns, pod, container []byte) *NamespaceUsage {
+	t.key = append(append(append(append(append(t.key[:0], ns...), '/'), pod...), '/'), container...)
+	i, seen := t.index[string(t.key)]
+	if !seen {
+		i = len(t.refs)
+		key := string(t.key)
+		t.index[key] = i
+		podStart := len(ns) + 1
+		nameStart := podStart + len(pod) + 1
+		t.refs = append(t.refs, ContainerRef{Namespace: key[:len(ns)], Pod: key[podStart : nameStart-1], Name: key[nameStart:]})
+		t.usage = append(t.usage, NamespaceUsage{})
+	}
+	return &t.usage[i]
+}
+
+// splitSampleLine splits `name{labels} value [timestamp]` into its parts
+// without copying. lbls excludes the braces and is empty when absent.
+func splitSampleLine(line []byte) (name, lbls, value []byte, ok bool) {
+	end := bytes.IndexAny(line, "{ ")
+	if end <= 0 {
+		return nil, nil, nil, false
+	}
+	name, rest := line[:end], line[end:]
+	if rest[0] == '{' {
+		end := labelsEnd(rest)
+		if end < 0 {
+			return nil, nil, nil, false
+		}
+		lbls, rest = rest[1:end], rest[end+1:]
+	}
+	rest = bytes.TrimLeft(rest, " ")
+	if i := bytes.IndexByte(rest, ' '); i >= 0 {
+		rest = rest[:i]
+	}
+	if len(rest) == 0 {
+		return nil, nil, nil, false
+	}
+	return name, lbls, rest, true
+}
+
+// labelsEnd returns the index of the '}' closing the label set that starts
+// at s[0], skipping braces inside quoted values.
+func labelsEnd(s []byte) int {
+	inQuote := false
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			inQuote = !inQuote
+		case '}':
+			if !inQuote {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// hasLabelPair reports whether the label set contains pair (e.g. id="/") as
+// a whole label, not as the suffix of a longer name.
+func hasLabelPair(lbls, pair []byte) bool {
+	return labelIndex(lbls, pair) >= 0
+}
+
+// inContainer is isContainer on the container label of lbls, without
+// converting the value to a string.
+func inContainer(lbls []byte) bool {
+	c := labelValue(lbls, containerPrefix)
+	return len(c) > 0 && string(c) != "POD"
+}
+
+// inPodCgroup reports whether lbls is a pod-level cgroup series: no
+// container label value and an id that isPodCgroup accepts.
+func inPodCgroup(lbls []byte) bool {
+	if len(labelValue(lbls, containerPrefix)) > 0 {
+		return false
+	}
+	id := labelValue(lbls, idPrefix)
+	return len(id) > 0 && isPodCgroup(unsafe.String(&id[0], len(id)))
+}
+
+// labelValue returns the value of the label whose `name="` prefix is given,
+// or nil. A value with escapes is unquoted into a copy; any other is
+// returned in place.
+func labelValue(lbls, prefix []byte) []byte {
+	i := labelIndex(lbls, prefix)
+	if i < 0 {
+		return nil
+	}
+	quoted := lbls[i+len(prefix)-1:]
+	end := quotedEnd(quoted)
+	if end < 0 {
+		return nil
+	}
+	v := quoted[1:end]
+	if bytes.IndexByte(v, '\\') < 0 {
+		return v
+	}
+	u, _, err := unquoteLabelValue(string(quoted[:end+1]))
+	if err != nil {
+		return nil
+	}
+	return []byte(u)
+}
+
+// labelIndex returns the offset of s in lbls where it starts a label, or -1.
+// Quoted values are skipped, so a value that contains s does not match.
+func labelIndex(lbls, s []byte) int {
+	for off := 0; off < len(lbls); {
+		if bytes.HasPrefix(lbls[off:], s) {
+			return off
+		}
+		q := bytes.IndexByte(lbls[off:], '"')
+		if q < 0 {
+			return -1
+		}
+		end := quotedEnd(lbls[off+q:])
+		if end < 0 {
+			return -1
+		}
+		off += q + end + 1
+		for off < len(lbls) && (lbls[off] == ',' || lbls[off] == ' ') {
+			off++
+		}
+	}
+	return -1
+}
+
+// quotedEnd returns the index of the '"' closing the quoted value that
+// starts at s[0], skipping escaped characters, or -1.
+func quotedEnd(s []byte) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+// parseValue parses a sample value, returning 0 when malformed like
+// ParsePrometheusValue. The string view avoids a copy; ParseFloat does not
+// retain its argument beyond the returned error, which is discarded.
+func parseValue(b []byte) float64 {
+	v, err := strconv.ParseFloat(unsafe.String(&b[0], len(b)), 64)
+	if err != nil {
+		return 0
+	}
+	return v
+}
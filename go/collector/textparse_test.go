@@ -0,0 +1,118 @@
+package collector
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSplitSampleLine(t *testing.T) {
+	tests := []struct {
+		line, name, lbls, value string
+		ok                      bool
+	}{
+		{`up 1`, "up", "", "1", true},
+		{`m{a="x"} 2.5 1700000000000`, "m", `a="x"`, "2.5", true},
+		{`m{a="}"} 3`, "m", `a="}"`, "3", true},
+		{`m{a="q\"}"} 4`, "m", `a="q\"}"`, "4", true},
+		{`m{a="x"}`, "", "", "", false},
+		{`m{a="x" 1`, "", "", "", false},
+		{`novalue`, "", "", "", false},
+	}
+	for _, tt := range tests {
+		name, lbls, value, ok := splitSampleLine([]byte(tt.line))
+		if ok != tt.ok || string(name) != tt.name || string(lbls) != tt.lbls || string(value) != tt.value {
+			t.Errorf("splitSampleLine(%q) = %q %q %q %v, want %q %q %q %v", tt.line, name, lbls, value, ok, tt.name, tt.lbls, tt.value, tt.ok)
+		}
+	}
+}
+
+func TestTextParserIgnoresTimestamp(t *testing.T) {
+	body := []byte("container_cpu_usage_seconds_total{id=\"/\"} 1.5 1700000000000\n")
+	sample, err := NewTextParser(nil).Parse(body)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if sample.CPU != 1.5 {
+		t.Errorf("cpu = %v, want 1.5", sample.CPU)
+	}
+}
+
+func TestTextParserEscapedLabels(t *testing.T) {
+	body := []byte(`container_cpu_usage_seconds_total{container="side\\car",image="x\",pod=\"fake",namespace="shop",pod="web-\"0\""} 2` + "\n")
+	sample, err := NewTextParser(nil).Parse(body)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	ref := ContainerRef{Namespace: "shop", Pod: `web-"0"`, Name: `side\car`}
+	if got := sample.Containers[ref]; got.CPU != 2 || len(sample.Containers) != 1 {
+		t.Errorf("containers = %+v, want CPU 2 for %+v", sample.Containers, ref)
+	}
+}
+
+func TestTextParserAllocsPerContainer(t *testing.T) {
+	// Repeating the samples of the same containers adds lines but no
+	// allocations.
+	p := NewTextParser(nil)
+	short := benchmarkBody(50)
+	long := []byte(strings.Repeat(string(short), 20))
+	allocs := func(body []byte) float64 {
+		return testing.AllocsPerRun(10, func() {
+			if _, err := p.Parse(body); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+	if a, b := allocs(short), allocs(long); b != a {
+		t.Errorf("allocations = %v for 20x the lines, want the same %v", b, a)
+	}
+	sample, _ := p.Parse(short)
+	if ref := (ContainerRef{Namespace: "ns", Pod: "pod-7", Name: "c7"}); sample.Containers[ref] == (NamespaceUsage{}) {
+		t.Errorf("containers = %v, want usage for %+v", sample.Containers, ref)
+	}
+}
+
+// benchmarkBody mimics a dense node: many containers, each exposing the
+// usual cAdvisor families.
+func benchmarkBody(containers int) []byte {
+	var b strings.Builder
+	families := []string{
+		"container_cpu_usage_seconds_total",
+		"container_memory_working_set_bytes",
+		"container_network_receive_errors_total",
+		"container_fs_reads_total",
+		"container_spec_memory_limit_bytes",
+	}
+	for _, f := range families {
+		fmt.Fprintf(&b, "# HELP %s help\n# TYPE %s counter\n", f, f)
+		for i := 0; i < containers; i++ {
+			fmt.Fprintf(&b, "%s{container=\"c%d\",id=\"/kubepods/pod%d\",image=\"registry/app:1.2.3\",namespace=\"ns\",pod=\"pod-%d\"} %d.5 1700000000000\n", f, i, i, i, i)
+		}
+	}
+	return []byte(b.String())
+}
+
+func BenchmarkTextParser(b *testing.B) {
+	body := benchmarkBody(2000)
+	p := NewTextParser(nil)
+	b.SetBytes(int64(len(body)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := p.Parse(body); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseContainerMetrics(b *testing.B) {
+	body := benchmarkBody(2000)
+	b.SetBytes(int64(len(body)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := ParseContainerMetrics(strings.NewReader(string(body)), CPUUsageMetric, MemWorkingSetMetric); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
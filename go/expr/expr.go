@@ -0,0 +1,375 @@
+// Package expr evaluates the small expressions users hook into a cycle,
+// such as a predicate that drops pods or a formula that scores nodes. The
+// syntax is a subset of Go expressions: number and string literals, true
+// and false, variables, ! - + * / == != < <= > >= && ||, label lookups
+// like labels["app"] and the functions of funcs. Expressions are type
+// checked when compiled against the variables a hook point declares, so a
+// compiled Program cannot fail at evaluation.
+package expr
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Type is the type of a variable or expression.
+type Type int
+
+const (
+	Bool Type = iota + 1
+	Number
+	String
+	Labels // map[string]string, indexed with a string
+)
+
+func (t Type) String() string {
+	switch t {
+	case Bool:
+		return "bool"
+	case Number:
+		return "number"
+	case String:
+		return "string"
+	case Labels:
+		return "labels"
+	}
+	return "invalid"
+}
+
+// Vars declares the variables of a hook point and their types.
+type Vars map[string]Type
+
+// Env holds the values of the variables for one evaluation: bool, float64,
+// string or map[string]string as declared. A variable that is missing
+// evaluates to the zero value of its type.
+type Env map[string]any
+
+type eval func(Env) any
+
+// Program is a compiled expression.
+type Program struct {
+	src  string
+	typ  Type
+	eval eval
+}
+
+// Compile parses src and checks it against vars.
+func Compile(src string, vars Vars) (*Program, error) {
+	e, err := parser.ParseExpr(src)
+	if err != nil {
+		return nil, err
+	}
+	c := &compiler{vars: vars}
+	fn, typ, err := c.compile(e)
+	if err != nil {
+		return nil, err
+	}
+	return &Program{src: src, typ: typ, eval: fn}, nil
+}
+
+// MustCompile is Compile for expressions known to be valid; it panics on
+// error.
+func MustCompile(src string, vars Vars) *Program {
+	p, err := Compile(src, vars)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+func (p *Program) String() string { return p.src }
+
+// Type returns the type of the expression's result.
+func (p *Program) Type() Type { return p.typ }
+
+// Eval returns the result for env, of the type Type reports.
+func (p *Program) Eval(env Env) any { return p.eval(env) }
+
+type compiler struct {
+	vars Vars
+}
+
+func errorf(n ast.Node, format string, args ...any) error {
+	return fmt.Errorf("col %d: %s", n.Pos(), fmt.Sprintf(format, args...))
+}
+
+func (c *compiler) compile(e ast.Expr) (eval, Type, error) {
+	switch e := e.(type) {
+	case *ast.ParenExpr:
+		return c.compile(e.X)
+	case *ast.BasicLit:
+		return compileLiteral(e)
+	case *ast.Ident:
+		return c.compileIdent(e)
+	case *ast.UnaryExpr:
+		return c.compileUnary(e)
+	case *ast.BinaryExpr:
+		return c.compileBinary(e)
+	case *ast.IndexExpr:
+		x, xt, err := c.compile(e.X)
+		if err != nil {
+			return nil, 0, err
+		}
+		k, kt, err := c.compile(e.Index)
+		if err != nil {
+			return nil, 0, err
+		}
+		if xt != Labels || kt != String {
+			return nil, 0, errorf(e, "cannot index %s with %s", xt, kt)
+		}
+		return func(env Env) any { return x(env).(map[string]string)[k(env).(string)] }, String, nil
+	case *ast.CallExpr:
+		return c.compileCall(e)
+	}
+	return nil, 0, errorf(e, "unsupported expression %T", e)
+}
+
+func compileLiteral(e *ast.BasicLit) (eval, Type, error) {
+	switch e.Kind {
+	case token.INT:
+		n, err := strconv.ParseInt(e.Value, 0, 64)
+		if err != nil {
+			return nil, 0, errorf(e, "%v", err)
+		}
+		v := float64(n)
+		return func(Env) any { return v }, Number, nil
+	case token.FLOAT:
+		v, err := strconv.ParseFloat(e.Value, 64)
+		if err != nil {
+			return nil, 0, errorf(e, "%v", err)
+		}
+		return func(Env) any { return v }, Number, nil
+	case token.STRING:
+		v, err := strconv.Unquote(e.Value)
+		if err != nil {
+			return nil, 0, errorf(e, "%v", err)
+		}
+		return func(Env) any { return v }, String, nil
+	}
+	return nil, 0, errorf(e, "unsupported literal %s", e.Value)
+}
+
+func (c *compiler) compileIdent(e *ast.Ident) (eval, Type, error) {
+	switch e.Name {
+	case "true", "false":
+		v := e.Name == "true"
+		return func(Env) any { return v }, Bool, nil
+	}
+	typ, ok := c.vars[e.Name]
+	if !ok {
+		return nil, 0, errorf(e, "unknown variable %s", e.Name)
+	}
+	zero := zeroValue(typ)
+	name := e.Name
+	return func(env Env) any {
+		if v, ok := env[name]; ok {
+			return v
+		}
+		return zero
+	}, typ, nil
+}
+
+func zeroValue(t Type) any {
+	switch t {
+	case Bool:
+		return false
+	case Number:
+		return 0.0
+	case String:
+		return ""
+	}
+	return map[string]string(nil)
+}
+
+func (c *compiler) compileUnary(e *ast.UnaryExpr) (eval, Type, error) {
+	x, xt, err := c.compile(e.X)
+	if err != nil {
+		return nil, 0, err
+	}
+	switch {
+	case e.Op == token.NOT && xt == Bool:
+		return func(env Env) any { return !x(env).(bool) }, Bool, nil
+	case e.Op == token.SUB && xt == Number:
+		return func(env Env) any { return -x(env).(float64) }, Number, nil
+	}
+	return nil, 0, errorf(e, "invalid operation %s%s", e.Op, xt)
+}
+
+func (c *compiler) compileBinary(e *ast.BinaryExpr) (eval, Type, error) {
+	x, xt, err := c.compile(e.X)
+	if err != nil {
+		return nil, 0, err
+	}
+	y, yt, err := c.compile(e.Y)
+	if err != nil {
+		return nil, 0, err
+	}
+	mismatch := errorf(e, "invalid operation %s %s %s", xt, e.Op, yt)
+	if xt != yt || xt == Labels {
+		return nil, 0, mismatch
+	}
+	switch e.Op {
+	case token.LAND, token.LOR:
+		if xt != Bool {
+			return nil, 0, mismatch
+		}
+		if e.Op == token.LAND {
+			return func(env Env) any { return x(env).(bool) && y(env).(bool) }, Bool, nil
+		}
+		return func(env Env) any { return x(env).(bool) || y(env).(bool) }, Bool, nil
+	case token.EQL:
+		return func(env Env) any { return x(env) == y(env) }, Bool, nil
+	case token.NEQ:
+		return func(env Env) any { return x(env) != y(env) }, Bool, nil
+	case token.LSS, token.LEQ, token.GTR, token.GEQ:
+		var cmp func(env Env) int
+		switch xt {
+		case Number:
+			cmp = func(env Env) int { return compareNumbers(x(env).(float64), y(env).(float64)) }
+		case String:
+			cmp = func(env Env) int { return strings.Compare(x(env).(string), y(env).(string)) }
+		default:
+			return nil, 0, mismatch
+		}
+		op := e.Op
+		return func(env Env) any {
+			r := cmp(env)
+			switch op {
+			case token.LSS:
+				return r < 0
+			case token.LEQ:
+				return r <= 0
+			case token.GTR:
+				return r > 0
+			}
+			return r >= 0
+		}, Bool, nil
+	case token.ADD:
+		switch xt {
+		case Number:
+			return func(env Env) any { return x(env).(float64) + y(env).(float64) }, Number, nil
+		case String:
+			return func(env Env) any { return x(env).(string) + y(env).(string) }, String, nil
+		}
+	case token.SUB, token.MUL, token.QUO:
+		if xt != Number {
+			return nil, 0, mismatch
+		}
+		switch e.Op {
+		case token.SUB:
+			return func(env Env) any { return x(env).(float64) - y(env).(float64) }, Number, nil
+		case token.MUL:
+			return func(env Env) any { return x(env).(float64) * y(env).(float64) }, Number, nil
+		}
+		// Division by zero gives 0 rather than an infinity, so a ratio of
+		// a node without the resource stays exportable.
+		return func(env Env) any {
+			d := y(env).(float64)
+			if d == 0 {
+				return 0.0
+			}
+			return x(env).(float64) / d
+		}, Number, nil
+	}
+	return nil, 0, mismatch
+}
+
+// compareNumbers orders NaN like a value below every number, so
+// comparisons stay total.
+func compareNumbers(a, b float64) int {
+	switch {
+	case a == b || math.IsNaN(a) && math.IsNaN(b):
+		return 0
+	case a < b || math.IsNaN(a):
+		return -1
+	}
+	return 1
+}
+
+// funcs are the functions an expression can call, with their argument and
+// result types.
+var funcs = map[string]struct {
+	args   []Type
+	result Type
+	fn     func(args []any) any
+}{
+	"contains":  {[]Type{String, String}, Bool, func(a []any) any { return strings.Contains(a[0].(string), a[1].(string)) }},
+	"hasPrefix": {[]Type{String, String}, Bool, func(a []any) any { return strings.HasPrefix(a[0].(string), a[1].(string)) }},
+	"hasSuffix": {[]Type{String, String}, Bool, func(a []any) any { return strings.HasSuffix(a[0].(string), a[1].(string)) }},
+	"has": {[]Type{Labels, String}, Bool, func(a []any) any {
+		_, ok := a[0].(map[string]string)[a[1].(string)]
+		return ok
+	}},
+	"min": {[]Type{Number, Number}, Number, func(a []any) any { return math.Min(a[0].(float64), a[1].(float64)) }},
+	"max": {[]Type{Number, Number}, Number, func(a []any) any { return math.Max(a[0].(float64), a[1].(float64)) }},
+}
+
+func (c *compiler) compileCall(e *ast.CallExpr) (eval, Type, error) {
+	id, ok := e.Fun.(*ast.Ident)
+	if !ok {
+		return nil, 0, errorf(e, "unsupported call")
+	}
+	if id.Name == "matches" {
+		return c.compileMatches(e)
+	}
+	f, ok := funcs[id.Name]
+	if !ok {
+		return nil, 0, errorf(e, "unknown function %s", id.Name)
+	}
+	if len(e.Args) != len(f.args) || e.Ellipsis.IsValid() {
+		return nil, 0, errorf(e, "%s takes %d arguments", id.Name, len(f.args))
+	}
+	args := make([]eval, len(e.Args))
+	for i, a := range e.Args {
+		fn, typ, err := c.compile(a)
+		if err != nil {
+			return nil, 0, err
+		}
+		if typ != f.args[i] {
+			return nil, 0, errorf(a, "argument %d of %s is %s, want %s", i+1, id.Name, typ, f.args[i])
+		}
+		args[i] = fn
+	}
+	return func(env Env) any {
+		vals := make([]any, len(args))
+		for i, a := range args {
+			vals[i] = a(env)
+		}
+		return f.fn(vals)
+	}, f.result, nil
+}
+
+// compileMatches compiles matches(s, "re"), which reports whether the
+// regular expression re matches all of s. re must be a literal so it is
+// compiled once.
+func (c *compiler) compileMatches(e *ast.CallExpr) (eval, Type, error) {
+	if len(e.Args) != 2 {
+		return nil, 0, errorf(e, "matches takes 2 arguments")
+	}
+	s, st, err := c.compile(e.Args[0])
+	if err != nil {
+		return nil, 0, err
+	}
+	if st != String {
+		return nil, 0, errorf(e.Args[0], "argument 1 of matches is %s, want string", st)
+	}
+	lit, ok := e.Args[1].(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return nil, 0, errorf(e.Args[1], "argument 2 of matches must be a string literal")
+	}
+	pattern, err := strconv.Unquote(lit.Value)
+	if err != nil {
+		return nil, 0, errorf(lit, "%v", err)
+	}
+	re, err := regexp.Compile("^(?:" + pattern + ")$")
+	if err != nil {
+		return nil, 0, errorf(lit, "%v", err)
+	}
+	return func(env Env) any { return re.MatchString(s(env).(string)) }, Bool, nil
+}
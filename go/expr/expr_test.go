@@ -0,0 +1,88 @@
+package expr
+
+import (
+	"strings"
+	"testing"
+)
+
+var testVars = Vars{
+	"namespace": String,
+	"cpu":       Number,
+	"alloc":     Number,
+	"cordoned":  Bool,
+	"labels":    Labels,
+}
+
+func TestEval(t *testing.T) {
+	env := Env{
+		"namespace": "ci-42",
+		"cpu":       3.0,
+		"alloc":     4.0,
+		"cordoned":  false,
+		"labels":    map[string]string{"team": "data", "tier": ""},
+	}
+	tests := []struct {
+		src  string
+		want any
+	}{
+		{`cpu / alloc`, 0.75},
+		{`cpu / 0`, 0.0},
+		{`-cpu + 2 * alloc - 1`, 4.0},
+		{`max(cpu / alloc, 0.9)`, 0.9},
+		{`min(cpu, 1_000)`, 3.0},
+		{`0x10 + 1.5e1`, 31.0},
+		{`namespace + "/" + labels["team"]`, "ci-42/data"},
+		{`labels["missing"]`, ""},
+		{`hasPrefix(namespace, "ci-") && labels["team"] == "data"`, true},
+		{`contains(namespace, "42") || cordoned`, true},
+		{`hasSuffix(namespace, "-1")`, false},
+		{`!cordoned && cpu >= 3 && cpu < alloc`, true},
+		{`namespace <= "ci"`, false},
+		{`matches(namespace, "ci-[0-9]+")`, true},
+		{`matches(namespace, "ci")`, false},
+		{`has(labels, "tier") && !has(labels, "owner")`, true},
+		{`(cpu != 3) == false`, true},
+		{`undeclared_in_env == ""`, true},
+	}
+	vars := Vars{"undeclared_in_env": String}
+	for k, v := range testVars {
+		vars[k] = v
+	}
+	for _, tt := range tests {
+		p, err := Compile(tt.src, vars)
+		if err != nil {
+			t.Errorf("Compile(%s): %v", tt.src, err)
+			continue
+		}
+		if got := p.Eval(env); got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.src, got, tt.want)
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct{ src, want string }{
+		{`cpu +`, "expected operand"},
+		{`memory > 1`, "unknown variable memory"},
+		{`cpu + namespace`, "invalid operation number + string"},
+		{`namespace - "x"`, "invalid operation string - string"},
+		{`cpu && cordoned`, "invalid operation number && bool"},
+		{`labels == labels`, "invalid operation labels == labels"},
+		{`!cpu`, "invalid operation !number"},
+		{`namespace[0]`, "cannot index string with number"},
+		{`lower(namespace)`, "unknown function lower"},
+		{`max(cpu)`, "max takes 2 arguments"},
+		{`contains(namespace, 1)`, "argument 2 of contains is number, want string"},
+		{`matches(namespace, labels["re"])`, "must be a string literal"},
+		{`matches(namespace, "(")`, "missing closing )"},
+		{`'c'`, "unsupported literal"},
+		{`cpu.value`, "unsupported expression"},
+		{`strings.Contains(namespace, "a")`, "unsupported call"},
+	}
+	for _, tt := range tests {
+		_, err := Compile(tt.src, testVars)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Compile(%s) error = %v, want it to contain %q", tt.src, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+// Package golden compares Prometheus text expositions with golden files, so
+// that a change to a metric's name, labels, help, type or values fails a
+// test instead of slipping silently into a release.
+package golden
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+// Tolerance relaxes the comparison of the metric families whose name
+// matches Metric, a path.Match pattern.
+type Tolerance struct {
+	Metric string
+	Abs    float64 // allowed absolute difference
+	Rel    float64 // allowed difference relative to the golden value
+	Ignore bool    // skip the families entirely
+}
+
+func (t Tolerance) allows(got, want float64) bool {
+	d := math.Abs(got - want)
+	return d <= t.Abs || d <= t.Rel*math.Abs(want)
+}
+
+// ParseTolerances reads tolerance rules, one per line:
+//
+//	k8s_node_network_*_per_second rel=0.001
+//	k8s_node_cpu_usage_cores abs=0.01
+//	k8s_ai_exporter_cycle_* ignore
+//
+// Blank lines and lines starting with '#' are skipped. The first rule
+// matching a family applies to it.
+func ParseTolerances(r io.Reader) ([]Tolerance, error) {
+	var tols []Tolerance
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		fields := strings.Fields(sc.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		t := Tolerance{Metric: fields[0]}
+		if _, err := path.Match(t.Metric, ""); err != nil {
+			return nil, fmt.Errorf("line %d: bad pattern %q: %w", n, t.Metric, err)
+		}
+		for _, f := range fields[1:] {
+			if f == "ignore" {
+				t.Ignore = true
+				continue
+			}
+			key, val, _ := strings.Cut(f, "=")
+			v, err := strconv.ParseFloat(val, 64)
+			if err != nil || v < 0 || (key != "abs" && key != "rel") {
+				return nil, fmt.Errorf("line %d: want abs=<x>, rel=<x> or ignore, got %q", n, f)
+			}
+			if key == "abs" {
+				t.Abs = v
+			} else {
+				t.Rel = v
+			}
+		}
+		tols = append(tols, t)
+	}
+	return tols, sc.Err()
+}
+
+// Write gathers g and writes its text exposition to w.
+func Write(w io.Writer, g prometheus.Gatherer) error {
+	families, err := g.Gather()
+	if err != nil {
+		return err
+	}
+	for _, mf := range families {
+		if _, err := expfmt.MetricFamilyToText(w, mf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Diff compares the exposition got with the golden exposition want and
+// describes every difference the tolerances do not allow, sorted: families
+// and series that appeared or disappeared, changed help or type, and
+// values out of tolerance. Without a matching tolerance values must be
+// equal.
+func Diff(got, want io.Reader, tolerances []Tolerance) ([]string, error) {
+	var p expfmt.TextParser
+	gotFams, err := p.TextToMetricFamilies(got)
+	if err != nil {
+		return nil, fmt.Errorf("parse output: %w", err)
+	}
+	wantFams, err := p.TextToMetricFamilies(want)
+	if err != nil {
+		return nil, fmt.Errorf("parse golden file: %w", err)
+	}
+
+	names := make(map[string]bool)
+	for name := range gotFams {
+		names[name] = true
+	}
+	for name := range wantFams {
+		names[name] = true
+	}
+	var diffs []string
+	for name := range names {
+		tol, ok := match(tolerances, name)
+		if ok && tol.Ignore {
+			continue
+		}
+		g, w := gotFams[name], wantFams[name]
+		switch {
+		case w == nil:
+			diffs = append(diffs, fmt.Sprintf("%s: new metric family", name))
+			continue
+		case g == nil:
+			diffs = append(diffs, fmt.Sprintf("%s: metric family removed", name))
+			continue
+		}
+		if g.GetType() != w.GetType() {
+			diffs = append(diffs, fmt.Sprintf("%s: type %s, want %s", name, g.GetType(), w.GetType()))
+		}
+		if g.GetHelp() != w.GetHelp() {
+			diffs = append(diffs, fmt.Sprintf("%s: help %q, want %q", name, g.GetHelp(), w.GetHelp()))
+		}
+		gotValues, wantValues := flatten(g), flatten(w)
+		for series, wv := range wantValues {
+			gv, found := gotValues[series]
+			switch {
+			case !found:
+				diffs = append(diffs, fmt.Sprintf("%s: series removed", series))
+			case gv == wv || (math.IsNaN(gv) && math.IsNaN(wv)):
+			case ok && tol.allows(gv, wv):
+			default:
+				diffs = append(diffs, fmt.Sprintf("%s = %g, want %g", series, gv, wv))
+			}
+		}
+		for series := range gotValues {
+			if _, found := wantValues[series]; !found {
+				diffs = append(diffs, fmt.Sprintf("%s: new series", series))
+			}
+		}
+	}
+	sort.Strings(diffs)
+	return diffs, nil
+}
+
+func match(tolerances []Tolerance, name string) (Tolerance, bool) {
+	for _, t := range tolerances {
+		if ok, _ := path.Match(t.Metric, name); ok {
+			return t, true
+		}
+	}
+	return Tolerance{}, false
+}
+
+// flatten returns the samples of mf keyed by their series as written in
+// the exposition, e.g. `x_bucket{le="1",node="a"}`.
+func flatten(mf *dto.MetricFamily) map[string]float64 {
+	out := make(map[string]float64)
+	name := mf.GetName()
+	for _, m := range mf.GetMetric() {
+		labels := make([]string, 0, len(m.GetLabel())+1)
+		for _, l := range m.GetLabel() {
+			labels = append(labels, fmt.Sprintf("%s=%q", l.GetName(), l.GetValue()))
+		}
+		key := func(suffix string, extra ...string) string {
+			ls := append(append([]string(nil), labels...), extra...)
+			sort.Strings(ls)
+			return name + suffix + "{" + strings.Join(ls, ",") + "}"
+		}
+		switch mf.GetType() {
+		case dto.MetricType_COUNTER:
+			out[key("")] = m.GetCounter().GetValue()
+		case dto.MetricType_GAUGE:
+			out[key("")] = m.GetGauge().GetValue()
+		case dto.MetricType_SUMMARY:
+			s := m.GetSummary()
+			out[key("_sum")] = s.GetSampleSum()
+			out[key("_count")] = float64(s.GetSampleCount())
+			for _, q := range s.GetQuantile() {
+				out[key("", fmt.Sprintf("quantile=%q", fmt.Sprint(q.GetQuantile())))] = q.GetValue()
+			}
+		case dto.MetricType_HISTOGRAM:
+			h := m.GetHistogram()
+			out[key("_sum")] = h.GetSampleSum()
+			out[key("_count")] = float64(h.GetSampleCount())
+			for _, b := range h.GetBucket() {
+				out[key("_bucket", fmt.Sprintf("le=%q", fmt.Sprint(b.GetUpperBound())))] = float64(b.GetCumulativeCount())
+			}
+		default:
+			out[key("")] = m.GetUntyped().GetValue()
+		}
+	}
+	return out
+}
@@ -0,0 +1,103 @@
+package golden
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const goldenExposition = `# HELP k8s_node_cpu_usage_cores CPU per node.
+# TYPE k8s_node_cpu_usage_cores gauge
+k8s_node_cpu_usage_cores{node="a"} 1.5
+k8s_node_cpu_usage_cores{node="b"} 2
+# HELP k8s_ai_exporter_cycle_seconds Cycle time.
+# TYPE k8s_ai_exporter_cycle_seconds gauge
+k8s_ai_exporter_cycle_seconds{cycle="main"} 0.2
+# HELP k8s_namespace_cpu_core_seconds_total Core seconds.
+# TYPE k8s_namespace_cpu_core_seconds_total counter
+k8s_namespace_cpu_core_seconds_total{namespace="shop"} 60
+`
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name       string
+		got        string
+		tolerances []Tolerance
+		want       []string
+	}{
+		{name: "identical", got: goldenExposition},
+		{
+			name: "value and series changes",
+			got: strings.NewReplacer(
+				`{node="a"} 1.5`, `{node="a"} 1.6`,
+				`{node="b"} 2`, `{node="c"} 2`,
+				`{cycle="main"} 0.2`, `{cycle="main"} 0.3`,
+			).Replace(goldenExposition),
+			tolerances: []Tolerance{{Metric: "k8s_ai_exporter_cycle_*", Ignore: true}},
+			want: []string{
+				`k8s_node_cpu_usage_cores{node="a"} = 1.6, want 1.5`,
+				`k8s_node_cpu_usage_cores{node="b"}: series removed`,
+				`k8s_node_cpu_usage_cores{node="c"}: new series`,
+			},
+		},
+		{
+			name:       "within tolerance",
+			got:        strings.Replace(goldenExposition, `{node="a"} 1.5`, `{node="a"} 1.509`, 1),
+			tolerances: []Tolerance{{Metric: "k8s_node_*", Abs: 0.01}},
+		},
+		{
+			name:       "relative tolerance",
+			got:        strings.Replace(goldenExposition, "} 60", "} 60.5", 1),
+			tolerances: []Tolerance{{Metric: "k8s_namespace_*", Rel: 0.001}},
+			want:       []string{`k8s_namespace_cpu_core_seconds_total{namespace="shop"} = 60.5, want 60`},
+		},
+		{
+			name: "renamed family and changed type",
+			got: strings.NewReplacer(
+				"k8s_namespace_cpu_core_seconds_total", "k8s_namespace_cpu_seconds_total",
+				"# TYPE k8s_node_cpu_usage_cores gauge", "# TYPE k8s_node_cpu_usage_cores untyped",
+			).Replace(goldenExposition),
+			want: []string{
+				"k8s_namespace_cpu_core_seconds_total: metric family removed",
+				"k8s_namespace_cpu_seconds_total: new metric family",
+				"k8s_node_cpu_usage_cores: type UNTYPED, want GAUGE",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Diff(strings.NewReader(tt.got), strings.NewReader(goldenExposition), tt.tolerances)
+			if err != nil {
+				t.Fatalf("Diff: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Diff =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestParseTolerances(t *testing.T) {
+	got, err := ParseTolerances(strings.NewReader(`
+# network rates depend on float rounding
+k8s_node_network_* rel=0.001
+k8s_node_cpu_usage_cores abs=0.01 rel=0.02
+k8s_ai_exporter_cycle_* ignore
+`))
+	if err != nil {
+		t.Fatalf("ParseTolerances: %v", err)
+	}
+	want := []Tolerance{
+		{Metric: "k8s_node_network_*", Rel: 0.001},
+		{Metric: "k8s_node_cpu_usage_cores", Abs: 0.01, Rel: 0.02},
+		{Metric: "k8s_ai_exporter_cycle_*", Ignore: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseTolerances = %+v, want %+v", got, want)
+	}
+	for _, bad := range []string{"x abs", "x abs=-1", "x tol=1", "[ abs=1"} {
+		if _, err := ParseTolerances(strings.NewReader(bad)); err == nil {
+			t.Errorf("ParseTolerances(%q): expected an error", bad)
+		}
+	}
+}
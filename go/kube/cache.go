@@ -0,0 +1,152 @@
+package kube
+
+import (
+	"fmt"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// NodeChanges describes which nodes had pod or label changes since
+// pod-derived collectors last ran. All forces a full recomputation.
+type NodeChanges struct {
+	All   bool
+	Nodes map[string]bool
+}
+
+// AllNodesChanged forces a full recomputation.
+var AllNodesChanged = NodeChanges{All: true}
+
+// Affects reports whether node must be recomputed.
+func (c NodeChanges) Affects(node string) bool { return c.All || c.Nodes[node] }
+
+// Empty reports whether nothing changed.
+func (c NodeChanges) Empty() bool { return !c.All && len(c.Nodes) == 0 }
+
+// Cache serves nodes and pods from shared informers instead of
+// listing them every cycle, and tracks dirty nodes from watch events.
+type Cache struct {
+	nodes corelisters.NodeLister
+	pods  corelisters.PodLister
+
+	mu      sync.Mutex
+	changes NodeChanges
+}
+
+// NewCache starts node and pod informers and waits for their caches to
+// sync. The informers run until stop is closed.
+func NewCache(clientset kubernetes.Interface, stop <-chan struct{}) (*Cache, error) {
+	factory := informers.NewSharedInformerFactory(clientset, 0)
+	podInformer := factory.Core().V1().Pods()
+	nodeInformer := factory.Core().V1().Nodes()
+	c := &Cache{
+		nodes:   nodeInformer.Lister(),
+		pods:    podInformer.Lister(),
+		changes: AllNodesChanged,
+	}
+
+	if _, err := podInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: c.podChanged,
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			c.podChanged(oldObj)
+			c.podChanged(newObj)
+		},
+		DeleteFunc: c.podChanged,
+	}); err != nil {
+		return nil, err
+	}
+	if _, err := nodeInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(interface{}) { c.markAll() },
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			// Only label changes move nodes between topology domains or
+			// change what selectors they satisfy; status updates do not.
+			oldNode, ok1 := oldObj.(*corev1.Node)
+			newNode, ok2 := newObj.(*corev1.Node)
+			if !ok1 || !ok2 || !labels.Equals(oldNode.Labels, newNode.Labels) {
+				c.markAll()
+			}
+		},
+		DeleteFunc: func(interface{}) { c.markAll() },
+	}); err != nil {
+		return nil, err
+	}
+
+	factory.Start(stop)
+	for typ, ok := range factory.WaitForCacheSync(stop) {
+		if !ok {
+			return nil, fmt.Errorf("informer cache for %v did not sync", typ)
+		}
+	}
+	return c, nil
+}
+
+func (c *Cache) podChanged(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	pod, ok := obj.(*corev1.Pod)
+	if !ok || pod.Spec.NodeName == "" {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.changes.All {
+		return
+	}
+	if c.changes.Nodes == nil {
+		c.changes.Nodes = make(map[string]bool)
+	}
+	c.changes.Nodes[pod.Spec.NodeName] = true
+}
+
+func (c *Cache) markAll() {
+	c.mu.Lock()
+	c.changes = AllNodesChanged
+	c.mu.Unlock()
+}
+
+// TakeChanges returns the changes accumulated since the last call and
+// resets them.
+func (c *Cache) TakeChanges() NodeChanges {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := c.changes
+	c.changes = NodeChanges{}
+	return ch
+}
+
+// Node returns a copy of the cached node named name.
+func (c *Cache) Node(name string) (*corev1.Node, error) {
+	n, err := c.nodes.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	node := *n
+	return &node, nil
+}
+
+// List returns copies of all cached nodes and pods.
+func (c *Cache) List() ([]corev1.Node, []corev1.Pod, error) {
+	nodePtrs, err := c.nodes.List(labels.Everything())
+	if err != nil {
+		return nil, nil, err
+	}
+	podPtrs, err := c.pods.List(labels.Everything())
+	if err != nil {
+		return nil, nil, err
+	}
+	nodes := make([]corev1.Node, len(nodePtrs))
+	for i, n := range nodePtrs {
+		nodes[i] = *n
+	}
+	pods := make([]corev1.Pod, len(podPtrs))
+	for i, p := range podPtrs {
+		pods[i] = *p
+	}
+	return nodes, pods, nil
+}
@@ -0,0 +1,5 @@
+// Package kube provides the cluster-side inputs of a collection cycle:
+// client configuration, the API server proxy and direct kubelet clients, node
+// and active pod listing (optionally served from informers), and workload
+// identity.
+package kube
@@ -0,0 +1,292 @@
+package kube
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/your-org/k8s-ai-exporter/tlspolicy"
+)
+
+// Config returns the in-cluster config, falling back to $KUBECONFIG or
+// ~/.kube/config when running outside a cluster.
+func Config() (*rest.Config, error) {
+	cfg, err := rest.InClusterConfig()
+	if err == nil {
+		return cfg, nil
+	}
+	kubeconfig := os.Getenv("KUBECONFIG")
+	if kubeconfig == "" {
+		home, _ := os.UserHomeDir()
+		kubeconfig = home + "/.kube/config"
+	}
+	return clientcmd.BuildConfigFromFlags("", kubeconfig)
+}
+
+// Client rate limits of DevConfig, below client-go's defaults of 5 QPS and
+// a burst of 10: a developer's exporter shares the API server with
+// everything else in the cluster.
+const (
+	devQPS   = 2
+	devBurst = 4
+)
+
+// DevConfig returns the config of the kubeconfig context named context, or
+// of the current context when it is empty, for running the exporter from a
+// workstation. In-cluster credentials are never used and client rate
+// limits are lowered. It also returns the name of the context used.
+func DevConfig(context string) (*rest.Config, string, error) {
+	cc := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		clientcmd.NewDefaultClientConfigLoadingRules(),
+		&clientcmd.ConfigOverrides{CurrentContext: context},
+	)
+	if context == "" {
+		raw, err := cc.RawConfig()
+		if err != nil {
+			return nil, "", err
+		}
+		context = raw.CurrentContext
+	}
+	cfg, err := cc.ClientConfig()
+	if err != nil {
+		return nil, "", err
+	}
+	cfg.QPS, cfg.Burst = devQPS, devBurst
+	return cfg, context, nil
+}
+
+// WithTLSPolicy makes cfg's connections to the API server follow p. client-go
+// has no setting for the TLS version or cipher suites, so the TLS config it
+// would build is moved into a custom transport; client-go still adds
+// authentication on top of it.
+func WithTLSPolicy(cfg *rest.Config, p tlspolicy.Policy) error {
+	tlsCfg, err := rest.TLSConfigFor(cfg)
+	if err != nil {
+		return err
+	}
+	if tlsCfg == nil {
+		tlsCfg = &tls.Config{}
+	}
+	p.Apply(tlsCfg)
+	cfg.Transport = &http.Transport{
+		Proxy:               http.ProxyFromEnvironment,
+		TLSClientConfig:     tlsCfg,
+		TLSHandshakeTimeout: 10 * time.Second,
+		IdleConnTimeout:     90 * time.Second,
+		MaxIdleConnsPerHost: 25,
+		ForceAttemptHTTP2:   true,
+	}
+	// A custom transport must not be combined with TLS options.
+	cfg.TLSClientConfig = rest.TLSClientConfig{}
+	return nil
+}
+
+// ProxyClient returns an HTTP client authenticated against the API server
+// and the base URL for proxy requests.
+func ProxyClient(cfg *rest.Config) (*http.Client, string, error) {
+	transport, err := rest.TransportFor(cfg)
+	if err != nil {
+		return nil, "", err
+	}
+	baseURL := strings.TrimSuffix(cfg.Host, "/")
+	return &http.Client{Transport: transport, Timeout: 15 * time.Second}, baseURL, nil
+}
+
+// ParsePhases parses a comma-separated list of pod phases, e.g.
+// "Succeeded,Failed", into a set.
+func ParsePhases(csv string) map[corev1.PodPhase]bool {
+	phases := make(map[corev1.PodPhase]bool)
+	for _, p := range strings.Split(csv, ",") {
+		phase := corev1.PodPhase(strings.TrimSpace(p))
+		if phase != "" {
+			phases[phase] = true
+		}
+	}
+	return phases
+}
+
+// Lister lists nodes and active pods, from Cache when it is set and from
+// the API server otherwise.
+type Lister struct {
+	Clientset kubernetes.Interface
+	Cache     *Cache
+
+	// ExcludePhases are pod phases that do not count as active.
+	ExcludePhases map[corev1.PodPhase]bool
+
+	// Exclude, when set, leaves out the pods it returns true for.
+	Exclude func(*corev1.Pod) bool
+
+	// NodeSelector, when set, limits the nodes to those whose labels
+	// match, and the pods to those bound to one of them.
+	NodeSelector labels.Selector
+
+	// NodeName, when set, limits the nodes to the one of that name and the
+	// pods to those bound to it, e.g. to the node an exporter of a
+	// DaemonSet runs on. The API server filters the lists, so an instance
+	// does not fetch every pod of the cluster.
+	NodeName string
+}
+
+// ControlPlaneLabels are the role labels of control-plane nodes; clusters
+// before Kubernetes 1.24 set the master one.
+var ControlPlaneLabels = []string{"node-role.kubernetes.io/control-plane", "node-role.kubernetes.io/master"}
+
+// WithoutControlPlane narrows sel, nil for every node, to nodes without
+// any of the ControlPlaneLabels.
+func WithoutControlPlane(sel labels.Selector) labels.Selector {
+	if sel == nil {
+		sel = labels.NewSelector()
+	}
+	for _, key := range ControlPlaneLabels {
+		req, err := labels.NewRequirement(key, selection.DoesNotExist, nil)
+		if err != nil {
+			panic(err) // the keys are valid label names
+		}
+		sel = sel.Add(*req)
+	}
+	return sel
+}
+
+// nodeListOptions returns the options of a node list call, with the
+// NodeSelector as label selector.
+func (l *Lister) nodeListOptions() metav1.ListOptions {
+	var opts metav1.ListOptions
+	if l.NodeSelector != nil {
+		opts.LabelSelector = l.NodeSelector.String()
+	}
+	if l.NodeName != "" {
+		opts.FieldSelector = "metadata.name=" + l.NodeName
+	}
+	return opts
+}
+
+// podListOptions returns the options of a pod list call, limited to the
+// pods of NodeName when it is set.
+func (l *Lister) podListOptions() metav1.ListOptions {
+	if l.NodeName == "" {
+		return metav1.ListOptions{}
+	}
+	return metav1.ListOptions{FieldSelector: "spec.nodeName=" + l.NodeName}
+}
+
+// selected reports whether node matches the NodeSelector and NodeName.
+func (l *Lister) selected(node *corev1.Node) bool {
+	if l.NodeName != "" && node.Name != l.NodeName {
+		return false
+	}
+	return l.NodeSelector == nil || l.NodeSelector.Matches(labels.Set(node.Labels))
+}
+
+// filtered reports whether List has to filter nodes itself.
+func (l *Lister) filtered() bool {
+	return l.NodeSelector != nil || l.NodeName != ""
+}
+
+// List returns the selected nodes and the pods bound to one of them whose
+// phase is not excluded.
+func (l *Lister) List(ctx context.Context) ([]corev1.Node, []corev1.Pod, error) {
+	var nodes []corev1.Node
+	var pods []corev1.Pod
+	if l.Cache != nil {
+		var err error
+		nodes, pods, err = l.Cache.List()
+		if err != nil {
+			return nil, nil, err
+		}
+	} else {
+		nodeList, err := l.Clientset.CoreV1().Nodes().List(ctx, l.nodeListOptions())
+		if err != nil {
+			return nil, nil, err
+		}
+		podList, err := l.Clientset.CoreV1().Pods("").List(ctx, l.podListOptions())
+		if err != nil {
+			return nil, nil, err
+		}
+		nodes, pods = nodeList.Items, podList.Items
+	}
+
+	var names map[string]bool
+	if l.filtered() {
+		// The API server has filtered already without a Cache; checking
+		// again is cheap and covers servers that ignore a selector.
+		selected := nodes[:0]
+		names = make(map[string]bool, len(nodes))
+		for i := range nodes {
+			if l.selected(&nodes[i]) {
+				selected = append(selected, nodes[i])
+				names[nodes[i].Name] = true
+			}
+		}
+		nodes = selected
+	}
+	var activePods []corev1.Pod
+	for _, p := range pods {
+		if l.ExcludePhases[p.Status.Phase] {
+			continue
+		}
+		if p.Spec.NodeName == "" {
+			continue
+		}
+		if names != nil && !names[p.Spec.NodeName] {
+			continue
+		}
+		if l.Exclude != nil && l.Exclude(&p) {
+			continue
+		}
+		activePods = append(activePods, p)
+	}
+	return nodes, activePods, nil
+}
+
+// Node returns the node named name. Like the API, it fails with a
+// NotFound error for nodes that do not exist, and so it does for nodes the
+// NodeSelector or NodeName leave out. Without a Cache it lists the node by
+// name rather than getting it, so the list permission the cycles need is
+// enough.
+func (l *Lister) Node(ctx context.Context, name string) (*corev1.Node, error) {
+	if l.Cache != nil {
+		node, err := l.Cache.Node(name)
+		if err == nil && !l.selected(node) {
+			return nil, apierrors.NewNotFound(corev1.Resource("nodes"), name)
+		}
+		return node, err
+	}
+	if l.NodeName != "" && name != l.NodeName {
+		return nil, apierrors.NewNotFound(corev1.Resource("nodes"), name)
+	}
+	opts := l.nodeListOptions()
+	opts.FieldSelector = "metadata.name=" + name
+	nodes, err := l.Clientset.CoreV1().Nodes().List(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	for i := range nodes.Items {
+		if nodes.Items[i].Name == name && l.selected(&nodes.Items[i]) {
+			return &nodes.Items[i], nil
+		}
+	}
+	return nil, apierrors.NewNotFound(corev1.Resource("nodes"), name)
+}
+
+// Changes returns what pod-derived collectors need to recompute:
+// everything without a Cache, otherwise only what changed since the last
+// call.
+func (l *Lister) Changes() NodeChanges {
+	if l.Cache == nil {
+		return AllNodesChanged
+	}
+	return l.Cache.TakeChanges()
+}
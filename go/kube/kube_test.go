@@ -0,0 +1,113 @@
+package kube
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestListerNodeNeedsOnlyList(t *testing.T) {
+	cs := fake.NewSimpleClientset(
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-a"}},
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-b"}},
+	)
+	// The generated ClusterRole grants list but not get on nodes.
+	cs.PrependReactor("get", "nodes", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(corev1.Resource("nodes"), "node-b", nil)
+	})
+	l := &Lister{Clientset: cs}
+	node, err := l.Node(context.Background(), "node-b")
+	if err != nil || node.Name != "node-b" {
+		t.Fatalf("Node(node-b) = %v, %v", node, err)
+	}
+	if _, err := l.Node(context.Background(), "nope"); !apierrors.IsNotFound(err) {
+		t.Errorf("Node(nope) error = %v, want NotFound", err)
+	}
+}
+
+func TestListerNodeSelector(t *testing.T) {
+	cs := fake.NewSimpleClientset(
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "gpu-1", Labels: map[string]string{"pool": "gpu"}}},
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "web-1", Labels: map[string]string{"pool": "web"}}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "train", Namespace: "ml"}, Spec: corev1.PodSpec{NodeName: "gpu-1"}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "nginx", Namespace: "web"}, Spec: corev1.PodSpec{NodeName: "web-1"}},
+	)
+	sel, err := labels.Parse("pool=gpu")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := &Lister{Clientset: cs, NodeSelector: sel}
+	nodes, pods, err := l.List(context.Background())
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(nodes) != 1 || nodes[0].Name != "gpu-1" || len(pods) != 1 || pods[0].Name != "train" {
+		t.Errorf("List = %d nodes, %d pods; want gpu-1 and its pod only", len(nodes), len(pods))
+	}
+	if _, err := l.Node(context.Background(), "web-1"); !apierrors.IsNotFound(err) {
+		t.Errorf("Node(web-1) error = %v, want NotFound for an unselected node", err)
+	}
+	if node, err := l.Node(context.Background(), "gpu-1"); err != nil || node.Name != "gpu-1" {
+		t.Errorf("Node(gpu-1) = %v, %v", node, err)
+	}
+}
+
+func TestWithoutControlPlane(t *testing.T) {
+	cs := fake.NewSimpleClientset(
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "cp-1", Labels: map[string]string{"node-role.kubernetes.io/control-plane": ""}}},
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "master-1", Labels: map[string]string{"node-role.kubernetes.io/master": "", "pool": "gpu"}}},
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "worker-1", Labels: map[string]string{"pool": "gpu"}}},
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "worker-2"}},
+	)
+	gpu, err := labels.Parse("pool=gpu")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		sel  labels.Selector
+		want []string
+	}{
+		{WithoutControlPlane(nil), []string{"worker-1", "worker-2"}},
+		{WithoutControlPlane(gpu), []string{"worker-1"}},
+	} {
+		nodes, _, err := (&Lister{Clientset: cs, NodeSelector: tc.sel}).List(context.Background())
+		if err != nil {
+			t.Fatalf("List: %v", err)
+		}
+		var got []string
+		for _, n := range nodes {
+			got = append(got, n.Name)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: nodes %v, want %v", tc.sel, got, tc.want)
+		}
+	}
+}
+
+func TestListerNodeName(t *testing.T) {
+	cs := fake.NewSimpleClientset(
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-a"}},
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-b"}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web-a", Namespace: "web"}, Spec: corev1.PodSpec{NodeName: "node-a"}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web-b", Namespace: "web"}, Spec: corev1.PodSpec{NodeName: "node-b"}},
+	)
+	l := &Lister{Clientset: cs, NodeName: "node-b"}
+	nodes, pods, err := l.List(context.Background())
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(nodes) != 1 || nodes[0].Name != "node-b" || len(pods) != 1 || pods[0].Name != "web-b" {
+		t.Errorf("List = %d nodes, %d pods; want node-b and its pod only", len(nodes), len(pods))
+	}
+	if _, err := l.Node(context.Background(), "node-a"); !apierrors.IsNotFound(err) {
+		t.Errorf("Node(node-a) error = %v, want NotFound for another node", err)
+	}
+}
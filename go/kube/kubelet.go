@@ -0,0 +1,111 @@
+package kube
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/rest"
+)
+
+// DefaultKubeletPort is the kubelet's authenticated HTTPS port.
+const DefaultKubeletPort = 10250
+
+// DefaultReadOnlyKubeletPort is the kubelet's read-only port, plain HTTP
+// without authentication. It is disabled by default since Kubernetes 1.10
+// but still open on some older and edge clusters.
+const DefaultReadOnlyKubeletPort = 10255
+
+// KubeletConfig returns the config for talking to kubelets directly with
+// cfg's bearer token, i.e. the ServiceAccount token in a cluster. The
+// kubelets' serving certificates are verified against caFile, or against
+// the API server's CA when caFile is empty; insecure skips verification.
+// It must be taken before WithTLSPolicy moves cfg's CA into a transport.
+func KubeletConfig(cfg *rest.Config, caFile string, insecure bool) *rest.Config {
+	kc := &rest.Config{
+		BearerToken:     cfg.BearerToken,
+		BearerTokenFile: cfg.BearerTokenFile,
+		TLSClientConfig: rest.TLSClientConfig{CAFile: cfg.CAFile, CAData: cfg.CAData},
+	}
+	switch {
+	case insecure:
+		// client-go rejects a CA together with Insecure.
+		kc.TLSClientConfig = rest.TLSClientConfig{Insecure: true}
+	case caFile != "":
+		kc.CAFile, kc.CAData = caFile, nil
+	}
+	return kc
+}
+
+// KubeletClient returns an HTTP client for the kubelet config kc.
+func KubeletClient(kc *rest.Config) (*http.Client, error) {
+	transport, err := rest.TransportFor(kc)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{Transport: transport, Timeout: 15 * time.Second}, nil
+}
+
+// ReadOnlyKubeletClient returns an HTTP client for the kubelets'
+// read-only port. It sends no credentials, so the ServiceAccount token
+// never goes over plain HTTP.
+func ReadOnlyKubeletClient() *http.Client {
+	return &http.Client{Timeout: 15 * time.Second}
+}
+
+// KubeletEndpoints resolves node names to the endpoints of their kubelets
+// at the nodes' InternalIP, as of the last Update.
+type KubeletEndpoints struct {
+	Port   int
+	Scheme string // "https" when empty; "http" for the read-only port
+
+	mu    sync.RWMutex
+	hosts map[string]string
+}
+
+// Update replaces the known addresses with those of nodes. It does nothing
+// on a nil receiver.
+func (e *KubeletEndpoints) Update(nodes []corev1.Node) {
+	if e == nil {
+		return
+	}
+	hosts := make(map[string]string, len(nodes))
+	for _, n := range nodes {
+		if ip := InternalIP(n); ip != "" {
+			hosts[n.Name] = ip
+		}
+	}
+	e.mu.Lock()
+	e.hosts = hosts
+	e.mu.Unlock()
+}
+
+// URL returns the URL of path, e.g. "/metrics/cadvisor", on node's kubelet.
+func (e *KubeletEndpoints) URL(node, path string) (string, error) {
+	e.mu.RLock()
+	host, ok := e.hosts[node]
+	e.mu.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("node %s has no InternalIP", node)
+	}
+	scheme := e.Scheme
+	if scheme == "" {
+		scheme = "https"
+	}
+	return scheme + "://" + net.JoinHostPort(host, strconv.Itoa(e.Port)) + path, nil
+}
+
+// InternalIP returns the first InternalIP address of n, or "" if it has
+// none.
+func InternalIP(n corev1.Node) string {
+	for _, a := range n.Status.Addresses {
+		if a.Type == corev1.NodeInternalIP {
+			return a.Address
+		}
+	}
+	return ""
+}
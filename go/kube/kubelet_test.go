@@ -0,0 +1,63 @@
+package kube
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+)
+
+func TestKubeletEndpoints(t *testing.T) {
+	node := func(name string, addrs ...corev1.NodeAddress) corev1.Node {
+		return corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name}, Status: corev1.NodeStatus{Addresses: addrs}}
+	}
+	e := &KubeletEndpoints{Port: DefaultKubeletPort}
+	e.Update([]corev1.Node{
+		node("a", corev1.NodeAddress{Type: corev1.NodeHostName, Address: "a"}, corev1.NodeAddress{Type: corev1.NodeInternalIP, Address: "10.0.0.1"}),
+		node("b", corev1.NodeAddress{Type: corev1.NodeInternalIP, Address: "fd00::2"}),
+		node("c", corev1.NodeAddress{Type: corev1.NodeExternalIP, Address: "203.0.113.3"}),
+	})
+	tests := []struct {
+		node, want string
+		err        bool
+	}{
+		{"a", "https://10.0.0.1:10250/metrics/cadvisor", false},
+		{"b", "https://[fd00::2]:10250/metrics/cadvisor", false},
+		{"c", "", true},
+		{"unknown", "", true},
+	}
+	for _, tt := range tests {
+		got, err := e.URL(tt.node, "/metrics/cadvisor")
+		if got != tt.want || (err != nil) != tt.err {
+			t.Errorf("URL(%s) = %q, %v, want %q (error %v)", tt.node, got, err, tt.want, tt.err)
+		}
+	}
+
+	ro := &KubeletEndpoints{Port: DefaultReadOnlyKubeletPort, Scheme: "http"}
+	ro.Update([]corev1.Node{node("a", corev1.NodeAddress{Type: corev1.NodeInternalIP, Address: "10.0.0.1"})})
+	if got, _ := ro.URL("a", "/metrics/cadvisor"); got != "http://10.0.0.1:10255/metrics/cadvisor" {
+		t.Errorf("read-only URL(a) = %q", got)
+	}
+
+	var none *KubeletEndpoints
+	none.Update(nil)
+}
+
+func TestKubeletConfig(t *testing.T) {
+	cfg := &rest.Config{
+		Host:            "https://10.96.0.1",
+		BearerTokenFile: "/var/run/secrets/kubernetes.io/serviceaccount/token",
+		TLSClientConfig: rest.TLSClientConfig{CAFile: "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt", CertFile: "client.crt"},
+	}
+	kc := KubeletConfig(cfg, "", false)
+	if kc.Host != "" || kc.BearerTokenFile != cfg.BearerTokenFile || kc.CAFile != cfg.CAFile || kc.CertFile != "" {
+		t.Errorf("KubeletConfig = %+v", kc)
+	}
+	if kc := KubeletConfig(cfg, "/etc/kubelet-ca.crt", false); kc.CAFile != "/etc/kubelet-ca.crt" {
+		t.Errorf("KubeletConfig(caFile).CAFile = %q", kc.CAFile)
+	}
+	if kc := KubeletConfig(cfg, "/etc/kubelet-ca.crt", true); !kc.Insecure || kc.CAFile != "" {
+		t.Errorf("KubeletConfig(insecure).TLSClientConfig = %+v", kc.TLSClientConfig)
+	}
+}
@@ -0,0 +1,265 @@
+package kube
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Permission is one cluster-wide API access.
+type Permission struct {
+	Group    string // API group, "" for core
+	Resource string // e.g. "nodes" or "nodes/proxy"
+	// NonResourceURL is a path like "/metrics" that components which
+	// delegate authorization to the API server check; Group and Resource
+	// are then empty.
+	NonResourceURL string
+	Verb           string
+	Reason         string // what needs it, for generated manifests and errors
+}
+
+func (p Permission) String() string {
+	if p.NonResourceURL != "" {
+		return p.Verb + " " + p.NonResourceURL
+	}
+	resource := p.Resource
+	if p.Group != "" {
+		resource += "." + p.Group
+	}
+	return p.Verb + " " + resource
+}
+
+// Features are the exporter settings that decide which permissions it
+// needs.
+type Features struct {
+	Informers     bool
+	NodeProxy     bool // kubelet or cAdvisor scraping through the API server
+	NodeMetrics   bool // kubelet or cAdvisor scraping directly from the kubelets
+	NodeStats     bool // Summary API scraping directly from the kubelets
+	MetricsServer bool // metrics-server fallback for failed node scrapes
+	CustomTargets bool
+	GPU           bool // DCGM exporter scrapes
+	NodeExporter  bool // node_exporter scrapes
+	CoreDNS       bool // CoreDNS scrapes
+	AnnotatedPods bool // prometheus.io annotated pod scrapes
+	ControlPlane  bool // scheduler and controller-manager scrapes
+	ServiceCIDR   bool // Service ClusterIP range utilization
+	BatchQueues   bool // Kueue and Volcano queues
+	NSBudgets     bool // NamespaceBudgets
+}
+
+// RequiredPermissions returns the minimal permissions for f. The exporter
+// never writes to the API; everything here is get, list or watch.
+func RequiredPermissions(f Features) []Permission {
+	verbs := []string{"list"}
+	if f.Informers {
+		verbs = append(verbs, "watch")
+	}
+	var perms []Permission
+	for _, verb := range verbs {
+		perms = append(perms,
+			Permission{Resource: "nodes", Verb: verb, Reason: "node inventory"},
+			Permission{Resource: "pods", Verb: verb, Reason: "pod counts and detail collectors"},
+		)
+	}
+	if f.NodeProxy {
+		perms = append(perms, Permission{Resource: "nodes/proxy", Verb: "get", Reason: "kubelet/cAdvisor scrapes"})
+	}
+	if f.NodeMetrics {
+		// The kubelet authorizes /metrics and /metrics/cadvisor as
+		// nodes/metrics with a SubjectAccessReview.
+		perms = append(perms, Permission{Resource: "nodes/metrics", Verb: "get", Reason: "direct kubelet/cAdvisor scrapes"})
+	}
+	if f.NodeStats {
+		// /stats/summary is authorized as nodes/stats.
+		perms = append(perms, Permission{Resource: "nodes/stats", Verb: "get", Reason: "direct Summary API scrapes"})
+	}
+	if f.MetricsServer {
+		perms = append(perms, Permission{Group: "metrics.k8s.io", Resource: "nodes", Verb: "list", Reason: "metrics-server fallback"})
+	}
+	if f.CustomTargets {
+		perms = append(perms,
+			Permission{Resource: "services", Verb: "list", Reason: "custom target discovery"},
+			Permission{Resource: "pods/proxy", Verb: "get", Reason: "custom target scrapes"},
+			Permission{Group: "discovery.k8s.io", Resource: "endpointslices", Verb: "list", Reason: "custom target discovery"},
+		)
+	}
+	if f.GPU {
+		perms = append(perms, Permission{Resource: "pods/proxy", Verb: "get", Reason: "DCGM exporter scrapes"})
+	}
+	if f.NodeExporter {
+		perms = append(perms, Permission{Resource: "pods/proxy", Verb: "get", Reason: "node_exporter scrapes"})
+	}
+	if f.CoreDNS {
+		perms = append(perms, Permission{Resource: "pods/proxy", Verb: "get", Reason: "CoreDNS scrapes"})
+	}
+	if f.AnnotatedPods {
+		perms = append(perms, Permission{Resource: "pods/proxy", Verb: "get", Reason: "annotated pod scrapes"})
+	}
+	if f.ControlPlane {
+		// The scheduler and controller-manager authorize /metrics with a
+		// SubjectAccessReview; etcd's metrics listener does not check.
+		perms = append(perms, Permission{NonResourceURL: "/metrics", Verb: "get", Reason: "control plane scrapes"})
+	}
+	if f.ServiceCIDR {
+		perms = append(perms, Permission{Resource: "services", Verb: "list", Reason: "service CIDR utilization"})
+	}
+	if f.BatchQueues {
+		perms = append(perms,
+			Permission{Group: "kueue.x-k8s.io", Resource: "workloads", Verb: "list", Reason: "batch queues"},
+			Permission{Group: "scheduling.volcano.sh", Resource: "podgroups", Verb: "list", Reason: "batch queues"},
+		)
+	}
+	if f.NSBudgets {
+		perms = append(perms, Permission{Group: "binbots.io", Resource: "namespacebudgets", Verb: "list", Reason: "namespace budgets"})
+	}
+	return perms
+}
+
+// ReadOnlyProbes are accesses a read-only exporter must not hold: writes,
+// and reading Secrets. Holding any of them means its ServiceAccount is
+// broader than it needs to be.
+var ReadOnlyProbes = []Permission{
+	{Resource: "pods", Verb: "create"},
+	{Resource: "pods", Verb: "delete"},
+	{Resource: "pods/exec", Verb: "create"},
+	{Resource: "nodes", Verb: "patch"},
+	{Resource: "secrets", Verb: "get"},
+}
+
+// WriteClusterRole writes a ClusterRole named name granting perms. Rules
+// share a group and verb set and keep the order of perms; non-resource URLs
+// get rules of their own.
+func WriteClusterRole(w io.Writer, name string, perms []Permission) error {
+	type rule struct {
+		group       string
+		nonResource bool
+		verbs       []string
+		resources   []string
+		reasons     []string
+	}
+	verbs := make(map[[3]string][]string)
+	reasons := make(map[[3]string][]string)
+	var order [][3]string
+	for _, p := range perms {
+		key := [3]string{p.Group, p.Resource, p.NonResourceURL}
+		if _, ok := verbs[key]; !ok {
+			order = append(order, key)
+		}
+		verbs[key] = appendUnique(verbs[key], p.Verb)
+		if p.Reason != "" {
+			reasons[key] = appendUnique(reasons[key], p.Reason)
+		}
+	}
+	var rules []*rule
+	byVerbs := make(map[string]*rule)
+	for _, key := range order {
+		vs := append([]string(nil), verbs[key]...)
+		sort.Strings(vs)
+		nonResource := key[2] != ""
+		id := key[0] + "|" + strings.Join(vs, ",")
+		if nonResource {
+			id = "/|" + strings.Join(vs, ",")
+		}
+		r, ok := byVerbs[id]
+		if !ok {
+			r = &rule{group: key[0], nonResource: nonResource, verbs: vs}
+			byVerbs[id] = r
+			rules = append(rules, r)
+		}
+		if nonResource {
+			r.resources = append(r.resources, key[2])
+		} else {
+			r.resources = append(r.resources, key[1])
+		}
+		for _, reason := range reasons[key] {
+			r.reasons = appendUnique(r.reasons, reason)
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "apiVersion: rbac.authorization.k8s.io/v1\nkind: ClusterRole\nmetadata:\n  name: %s\nrules:\n", name)
+	for _, r := range rules {
+		if len(r.reasons) > 0 {
+			fmt.Fprintf(&b, "  # %s\n", strings.Join(r.reasons, "; "))
+		}
+		if r.nonResource {
+			fmt.Fprintf(&b, "  - nonResourceURLs: %s\n    verbs: %s\n", yamlList(r.resources), yamlList(r.verbs))
+			continue
+		}
+		fmt.Fprintf(&b, "  - apiGroups: [%q]\n    resources: %s\n    verbs: %s\n", r.group, yamlList(r.resources), yamlList(r.verbs))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteClusterRoleBinding writes a ClusterRoleBinding of the ClusterRole
+// name to the ServiceAccount of the same name in namespace.
+func WriteClusterRoleBinding(w io.Writer, name, namespace string) error {
+	_, err := fmt.Fprintf(w, `apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  name: %[1]s
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: ClusterRole
+  name: %[1]s
+subjects:
+  - kind: ServiceAccount
+    name: %[1]s
+    namespace: %[2]s
+`, name, namespace)
+	return err
+}
+
+// AllowedPermissions asks the API server which of perms the exporter's own
+// credentials hold, using SelfSubjectAccessReviews (which need no RBAC).
+func AllowedPermissions(ctx context.Context, clientset kubernetes.Interface, perms []Permission) (allowed, denied []Permission, err error) {
+	for _, p := range perms {
+		var spec authorizationv1.SelfSubjectAccessReviewSpec
+		if p.NonResourceURL != "" {
+			spec.NonResourceAttributes = &authorizationv1.NonResourceAttributes{Verb: p.Verb, Path: p.NonResourceURL}
+		} else {
+			resource, subresource, _ := strings.Cut(p.Resource, "/")
+			spec.ResourceAttributes = &authorizationv1.ResourceAttributes{
+				Verb:        p.Verb,
+				Group:       p.Group,
+				Resource:    resource,
+				Subresource: subresource,
+			}
+		}
+		review, err := clientset.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, &authorizationv1.SelfSubjectAccessReview{Spec: spec}, metav1.CreateOptions{})
+		if err != nil {
+			return nil, nil, fmt.Errorf("access review for %s: %w", p, err)
+		}
+		if review.Status.Allowed {
+			allowed = append(allowed, p)
+		} else {
+			denied = append(denied, p)
+		}
+	}
+	return allowed, denied, nil
+}
+
+func appendUnique(list []string, s string) []string {
+	for _, x := range list {
+		if x == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
+
+func yamlList(items []string) string {
+	quoted := make([]string, len(items))
+	for i, s := range items {
+		quoted[i] = fmt.Sprintf("%q", s)
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
@@ -0,0 +1,67 @@
+package kube
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestRequiredPermissions(t *testing.T) {
+	tests := []struct {
+		f    Features
+		want []string
+	}{
+		{Features{}, []string{"list nodes", "list pods"}},
+		{Features{Informers: true, NodeProxy: true}, []string{"list nodes", "list pods", "watch nodes", "watch pods", "get nodes/proxy"}},
+		{Features{CustomTargets: true}, []string{"list nodes", "list pods", "list services", "get pods/proxy", "list endpointslices.discovery.k8s.io"}},
+		{Features{ServiceCIDR: true}, []string{"list nodes", "list pods", "list services"}},
+		{Features{NodeMetrics: true}, []string{"list nodes", "list pods", "get nodes/metrics"}},
+		{Features{NodeStats: true}, []string{"list nodes", "list pods", "get nodes/stats"}},
+		{Features{MetricsServer: true}, []string{"list nodes", "list pods", "list nodes.metrics.k8s.io"}},
+		{Features{NSBudgets: true}, []string{"list nodes", "list pods", "list namespacebudgets.binbots.io"}},
+		{Features{BatchQueues: true}, []string{"list nodes", "list pods", "list workloads.kueue.x-k8s.io", "list podgroups.scheduling.volcano.sh"}},
+		{Features{GPU: true}, []string{"list nodes", "list pods", "get pods/proxy"}},
+		{Features{NodeExporter: true}, []string{"list nodes", "list pods", "get pods/proxy"}},
+		{Features{CoreDNS: true}, []string{"list nodes", "list pods", "get pods/proxy"}},
+		{Features{AnnotatedPods: true}, []string{"list nodes", "list pods", "get pods/proxy"}},
+		{Features{ControlPlane: true}, []string{"list nodes", "list pods", "get /metrics"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, p := range RequiredPermissions(tt.f) {
+			got = append(got, p.String())
+		}
+		if strings.Join(got, ", ") != strings.Join(tt.want, ", ") {
+			t.Errorf("RequiredPermissions(%+v) = %v, want %v", tt.f, got, tt.want)
+		}
+	}
+}
+
+// The checked-in manifest grants what every collector needs.
+func TestDeployClusterRoleUpToDate(t *testing.T) {
+	want, err := os.ReadFile("../../deploy/clusterrole.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got strings.Builder
+	if err := WriteClusterRole(&got, "k8s-ai-exporter", RequiredPermissions(Features{Informers: true, NodeProxy: true, CustomTargets: true, ServiceCIDR: true})); err != nil {
+		t.Fatal(err)
+	}
+	if got.String() != string(want) {
+		t.Errorf("deploy/clusterrole.yaml is stale; replace it with the ClusterRole printed by `go run ./cmd/exporter gen-rbac -informers -enable-custom-targets -service-cidr=10.96.0.0/12` from go/:\n%s", got.String())
+	}
+}
+
+func TestWriteClusterRoleNonResourceURLs(t *testing.T) {
+	var got strings.Builder
+	if err := WriteClusterRole(&got, "x", RequiredPermissions(Features{ControlPlane: true})); err != nil {
+		t.Fatal(err)
+	}
+	want := `  # control plane scrapes
+  - nonResourceURLs: ["/metrics"]
+    verbs: ["get"]
+`
+	if !strings.HasSuffix(got.String(), want) {
+		t.Errorf("WriteClusterRole =\n%s\nwant it to end with\n%s", got.String(), want)
+	}
+}
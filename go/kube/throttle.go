@@ -0,0 +1,110 @@
+package kube
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+// Kinds of throttling reported by ThrottleMonitor.
+const (
+	// ThrottleServer is a 429 Too Many Requests from the API server, e.g.
+	// from API Priority and Fairness.
+	ThrottleServer = "server"
+	// ThrottleClient is a request held back by client-go's own rate
+	// limiter for longer than ThrottleMonitor.MaxWait.
+	ThrottleClient = "client"
+)
+
+// client-go's rate limits when rest.Config leaves them zero.
+const (
+	defaultQPS   = 5
+	defaultBurst = 10
+)
+
+// ThrottleMonitor notices when the API server or client-go throttles the
+// clients built from a rest.Config.
+type ThrottleMonitor struct {
+	// MaxWait is how long the client-side rate limiter may hold a request
+	// before it counts as throttled.
+	MaxWait time.Duration
+	// OnThrottle, when set, is called for every throttled request.
+	OnThrottle func(kind string)
+
+	count atomic.Int64
+}
+
+// Instrument makes the clients built from cfg afterwards, including
+// ProxyClient, report throttling to m. A rate limiter already set on cfg
+// is left alone.
+func (m *ThrottleMonitor) Instrument(cfg *rest.Config) {
+	cfg.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+		return &throttleTransport{monitor: m, next: rt}
+	})
+	if cfg.RateLimiter != nil {
+		return
+	}
+	qps, burst := cfg.QPS, cfg.Burst
+	if qps == 0 {
+		qps = defaultQPS
+	}
+	if burst == 0 {
+		burst = defaultBurst
+	}
+	cfg.RateLimiter = &waitLimiter{RateLimiter: flowcontrol.NewTokenBucketRateLimiter(qps, burst), monitor: m}
+}
+
+// Throttled reports whether any request was throttled since the previous
+// call.
+func (m *ThrottleMonitor) Throttled() bool {
+	return m.count.Swap(0) > 0
+}
+
+func (m *ThrottleMonitor) throttled(kind string) {
+	m.count.Add(1)
+	if m.OnThrottle != nil {
+		m.OnThrottle(kind)
+	}
+}
+
+type throttleTransport struct {
+	monitor *ThrottleMonitor
+	next    http.RoundTripper
+}
+
+func (t *throttleTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err == nil && resp.StatusCode == http.StatusTooManyRequests {
+		t.monitor.throttled(ThrottleServer)
+	}
+	return resp, err
+}
+
+// waitLimiter times the waits of client-go's rate limiter.
+type waitLimiter struct {
+	flowcontrol.RateLimiter
+	monitor *ThrottleMonitor
+}
+
+func (l *waitLimiter) Accept() {
+	start := time.Now()
+	l.RateLimiter.Accept()
+	l.observe(time.Since(start))
+}
+
+func (l *waitLimiter) Wait(ctx context.Context) error {
+	start := time.Now()
+	err := l.RateLimiter.Wait(ctx)
+	l.observe(time.Since(start))
+	return err
+}
+
+func (l *waitLimiter) observe(waited time.Duration) {
+	if waited > l.monitor.MaxWait {
+		l.monitor.throttled(ThrottleClient)
+	}
+}
@@ -0,0 +1,66 @@
+package kube
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+// slowLimiter is a rate limiter that always holds requests for wait.
+type slowLimiter struct {
+	flowcontrol.RateLimiter
+	wait time.Duration
+}
+
+func (l slowLimiter) Wait(ctx context.Context) error {
+	time.Sleep(l.wait)
+	return nil
+}
+
+func TestThrottleMonitor(t *testing.T) {
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	var kinds []string
+	m := &ThrottleMonitor{MaxWait: 20 * time.Millisecond, OnThrottle: func(kind string) { kinds = append(kinds, kind) }}
+	cfg := &rest.Config{Host: srv.URL}
+	m.Instrument(cfg)
+	if cfg.WrapTransport == nil || cfg.RateLimiter == nil {
+		t.Fatal("Instrument did not wrap the transport and the rate limiter")
+	}
+	client := &http.Client{Transport: cfg.WrapTransport(http.DefaultTransport)}
+	get := func() {
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			t.Fatalf("GET: %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	get()
+	if m.Throttled() {
+		t.Error("Throttled after a 200, want false")
+	}
+	status = http.StatusTooManyRequests
+	get()
+	if !m.Throttled() || m.Throttled() {
+		t.Error("Throttled after a 429 = false, or not reset by the call")
+	}
+
+	l := &waitLimiter{RateLimiter: slowLimiter{wait: 30 * time.Millisecond}, monitor: m}
+	l.Wait(context.Background())
+	if !m.Throttled() {
+		t.Error("Throttled after a long client-side wait = false")
+	}
+	if want := []string{ThrottleServer, ThrottleClient}; len(kinds) != 2 || kinds[0] != want[0] || kinds[1] != want[1] {
+		t.Errorf("OnThrottle kinds = %v, want %v", kinds, want)
+	}
+}
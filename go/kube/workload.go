@@ -0,0 +1,30 @@
+package kube
+
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Workload identifies the controller that owns a pod.
+type Workload struct {
+	Namespace, Kind, Name string
+}
+
+// PodWorkload returns the controller that owns the pod, or the pod itself
+// when it has none. ReplicaSets created by a Deployment are folded into the
+// Deployment by stripping the pod-template-hash suffix.
+func PodWorkload(pod *corev1.Pod) Workload {
+	for _, ref := range pod.OwnerReferences {
+		if ref.Controller == nil || !*ref.Controller {
+			continue
+		}
+		if ref.Kind == "ReplicaSet" {
+			if hash := pod.Labels["pod-template-hash"]; hash != "" && strings.HasSuffix(ref.Name, "-"+hash) {
+				return Workload{pod.Namespace, "Deployment", strings.TrimSuffix(ref.Name, "-"+hash)}
+			}
+		}
+		return Workload{pod.Namespace, ref.Kind, ref.Name}
+	}
+	return Workload{pod.Namespace, "Pod", pod.Name}
+}
@@ -0,0 +1,36 @@
+package kube
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPodWorkload(t *testing.T) {
+	controller := true
+	pod := corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Namespace: "shop",
+		Name:      "web-7d9f8b6c5-x2x4z",
+		Labels:    map[string]string{"pod-template-hash": "7d9f8b6c5"},
+		OwnerReferences: []metav1.OwnerReference{
+			{Kind: "ReplicaSet", Name: "web-7d9f8b6c5", Controller: &controller},
+		},
+	}}
+	want := Workload{"shop", "Deployment", "web"}
+	if got := PodWorkload(&pod); got != want {
+		t.Errorf("PodWorkload = %+v, want %+v", got, want)
+	}
+
+	bare := corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "debug"}}
+	if got := PodWorkload(&bare); got != (Workload{"shop", "Pod", "debug"}) {
+		t.Errorf("PodWorkload(bare) = %+v", got)
+	}
+}
+
+func TestParsePhases(t *testing.T) {
+	got := ParsePhases(" Succeeded, Failed,,")
+	if len(got) != 2 || !got[corev1.PodSucceeded] || !got[corev1.PodFailed] {
+		t.Errorf("ParsePhases = %v", got)
+	}
+}
@@ -0,0 +1,181 @@
+// Package selfcheck audits the privileges the exporter runs with: user,
+// effective capabilities, writable filesystems and network egress. The
+// exporter needs none of root, capabilities or a writable filesystem, so
+// any of them is reported as a finding for pod security audits.
+package selfcheck
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Report is the result of one self-check.
+type Report struct {
+	Time         time.Time      `json:"time"`
+	UID          int            `json:"uid" doc:"Effective user ID; -1 where the platform has none."`
+	GID          int            `json:"gid" doc:"Effective group ID; -1 where the platform has none."`
+	Capabilities []string       `json:"capabilities" doc:"Effective Linux capabilities, e.g. CAP_NET_RAW; empty when all are dropped."`
+	NoNewPrivs   bool           `json:"noNewPrivs" doc:"Whether privilege escalation is disabled (allowPrivilegeEscalation: false)."`
+	Filesystem   []PathResult   `json:"filesystem"`
+	Egress       []EgressResult `json:"egress"`
+	Findings     []string       `json:"findings" doc:"Privileges beyond what the exporter needs; empty for a hardened pod."`
+}
+
+// PathResult reports whether a directory is writable.
+type PathResult struct {
+	Path     string `json:"path"`
+	Writable bool   `json:"writable"`
+	Error    string `json:"error,omitempty" doc:"Why the write failed; a read-only filesystem is expected."`
+}
+
+// EgressResult reports whether a TCP connection to a target succeeded.
+type EgressResult struct {
+	Target    string `json:"target" doc:"host:port"`
+	Reachable bool   `json:"reachable"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Hardened reports whether the process runs without root and without
+// effective capabilities.
+func (r Report) Hardened() bool {
+	return r.UID != 0 && len(r.Capabilities) == 0
+}
+
+// Checker runs self-checks.
+type Checker struct {
+	// Paths are probed for writability, e.g. "/" and os.TempDir().
+	Paths []string
+	// Egress are host:port targets to dial, e.g. the API server (which
+	// must be reachable) and hosts a NetworkPolicy should block.
+	Egress []string
+	// Timeout bounds each dial; default 3s.
+	Timeout time.Duration
+}
+
+// procStatus is read for capabilities; a variable for tests.
+var procStatus = "/proc/self/status"
+
+// Run performs the checks.
+func (c *Checker) Run(ctx context.Context) Report {
+	r := Report{Time: time.Now(), UID: os.Geteuid(), GID: os.Getegid()}
+	if r.UID == 0 {
+		r.Findings = append(r.Findings, "running as root (uid 0)")
+	}
+
+	if f, err := os.Open(procStatus); err != nil {
+		r.Findings = append(r.Findings, fmt.Sprintf("cannot read capabilities: %v", err))
+	} else {
+		status, err := parseStatus(f)
+		f.Close()
+		if err != nil {
+			r.Findings = append(r.Findings, fmt.Sprintf("cannot read capabilities: %v", err))
+		}
+		r.Capabilities = status.capabilities
+		r.NoNewPrivs = status.noNewPrivs
+	}
+	for _, name := range r.Capabilities {
+		r.Findings = append(r.Findings, "effective capability "+name)
+	}
+	if !r.NoNewPrivs {
+		r.Findings = append(r.Findings, "privilege escalation is allowed")
+	}
+
+	for _, path := range c.Paths {
+		res := PathResult{Path: path}
+		f, err := os.CreateTemp(path, ".selfcheck-*")
+		if err == nil {
+			res.Writable = true
+			f.Close()
+			os.Remove(f.Name())
+			r.Findings = append(r.Findings, path+" is writable")
+		} else {
+			res.Error = err.Error()
+		}
+		r.Filesystem = append(r.Filesystem, res)
+	}
+
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = 3 * time.Second
+	}
+	dialer := net.Dialer{Timeout: timeout}
+	for _, target := range c.Egress {
+		res := EgressResult{Target: target}
+		conn, err := dialer.DialContext(ctx, "tcp", target)
+		if err == nil {
+			res.Reachable = true
+			conn.Close()
+		} else {
+			res.Error = err.Error()
+		}
+		r.Egress = append(r.Egress, res)
+	}
+	return r
+}
+
+type status struct {
+	capabilities []string
+	noNewPrivs   bool
+}
+
+// parseStatus reads CapEff and NoNewPrivs from /proc/<pid>/status.
+func parseStatus(r io.Reader) (status, error) {
+	var s status
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		key, value, ok := strings.Cut(sc.Text(), ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch key {
+		case "CapEff":
+			mask, err := strconv.ParseUint(value, 16, 64)
+			if err != nil {
+				return s, fmt.Errorf("CapEff %q: %w", value, err)
+			}
+			s.capabilities = capabilityNames(mask)
+		case "NoNewPrivs":
+			s.noNewPrivs = value == "1"
+		}
+	}
+	return s, sc.Err()
+}
+
+// capabilities are the Linux capability names by bit, from
+// include/uapi/linux/capability.h.
+var capabilities = []string{
+	"CAP_CHOWN", "CAP_DAC_OVERRIDE", "CAP_DAC_READ_SEARCH", "CAP_FOWNER",
+	"CAP_FSETID", "CAP_KILL", "CAP_SETGID", "CAP_SETUID",
+	"CAP_SETPCAP", "CAP_LINUX_IMMUTABLE", "CAP_NET_BIND_SERVICE", "CAP_NET_BROADCAST",
+	"CAP_NET_ADMIN", "CAP_NET_RAW", "CAP_IPC_LOCK", "CAP_IPC_OWNER",
+	"CAP_SYS_MODULE", "CAP_SYS_RAWIO", "CAP_SYS_CHROOT", "CAP_SYS_PTRACE",
+	"CAP_SYS_PACCT", "CAP_SYS_ADMIN", "CAP_SYS_BOOT", "CAP_SYS_NICE",
+	"CAP_SYS_RESOURCE", "CAP_SYS_TIME", "CAP_SYS_TTY_CONFIG", "CAP_MKNOD",
+	"CAP_LEASE", "CAP_AUDIT_WRITE", "CAP_AUDIT_CONTROL", "CAP_SETFCAP",
+	"CAP_MAC_OVERRIDE", "CAP_MAC_ADMIN", "CAP_SYSLOG", "CAP_WAKE_ALARM",
+	"CAP_BLOCK_SUSPEND", "CAP_AUDIT_READ", "CAP_PERFMON", "CAP_BPF",
+	"CAP_CHECKPOINT_RESTORE",
+}
+
+func capabilityNames(mask uint64) []string {
+	var names []string
+	for bit := 0; bit < 64; bit++ {
+		if mask&(1<<bit) == 0 {
+			continue
+		}
+		if bit < len(capabilities) {
+			names = append(names, capabilities[bit])
+		} else {
+			names = append(names, "CAP_"+strconv.Itoa(bit))
+		}
+	}
+	return names
+}
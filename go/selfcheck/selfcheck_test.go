@@ -0,0 +1,66 @@
+package selfcheck
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseStatus(t *testing.T) {
+	s, err := parseStatus(strings.NewReader("Name:\texporter\nCapEff:\t0000000000002400\nNoNewPrivs:\t1\n"))
+	if err != nil {
+		t.Fatalf("parseStatus: %v", err)
+	}
+	if want := []string{"CAP_NET_BIND_SERVICE", "CAP_NET_RAW"}; !reflect.DeepEqual(s.capabilities, want) || !s.noNewPrivs {
+		t.Errorf("parseStatus = %+v, want %v and noNewPrivs", s, want)
+	}
+	if s, _ := parseStatus(strings.NewReader("CapEff:\t0000000000000000\nNoNewPrivs:\t0\n")); len(s.capabilities) != 0 || s.noNewPrivs {
+		t.Errorf("dropped capabilities = %+v", s)
+	}
+	if _, err := parseStatus(strings.NewReader("CapEff:\tzz\n")); err == nil {
+		t.Error("expected error for a malformed CapEff")
+	}
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	procStatus = filepath.Join(dir, "status")
+	defer func() { procStatus = "/proc/self/status" }()
+	if err := os.WriteFile(procStatus, []byte("CapEff:\t0000000000000000\nNoNewPrivs:\t1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed, _ := net.Listen("tcp", "127.0.0.1:0")
+	closedAddr := closed.Addr().String()
+	closed.Close()
+	defer ln.Close()
+
+	c := &Checker{Paths: []string{dir, filepath.Join(dir, "missing")}, Egress: []string{ln.Addr().String(), closedAddr}}
+	r := c.Run(context.Background())
+	if !r.Filesystem[0].Writable || r.Filesystem[1].Writable || r.Filesystem[1].Error == "" {
+		t.Errorf("filesystem = %+v, want %s writable only", r.Filesystem, dir)
+	}
+	if !r.Egress[0].Reachable || r.Egress[1].Reachable {
+		t.Errorf("egress = %+v, want only the listener reachable", r.Egress)
+	}
+	if len(r.Capabilities) != 0 || !r.NoNewPrivs {
+		t.Errorf("capabilities = %v, noNewPrivs = %v", r.Capabilities, r.NoNewPrivs)
+	}
+	found := false
+	for _, f := range r.Findings {
+		found = found || f == dir+" is writable"
+	}
+	if !found {
+		t.Errorf("findings = %v, want %s is writable", r.Findings, dir)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("probe files left behind: %v", entries)
+	}
+}
@@ -0,0 +1,4 @@
+// Package sink publishes collection results. Prometheus exposes them as
+// gauges on one registry for node families and, optionally, a separate one
+// for the per-pod and per-workload (detail) families.
+package sink
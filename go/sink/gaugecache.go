@@ -0,0 +1,66 @@
+package sink
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// gaugeCache memoizes GaugeVec children by label values so hot loops skip
+// the vector's hashing and locking on every update. Children must be
+// removed through the cache (retain) so it never hands out a gauge
+// that is no longer exported.
+type gaugeCache struct {
+	vec *prometheus.GaugeVec
+
+	mu       sync.Mutex
+	children map[string]cachedGauge
+}
+
+type cachedGauge struct {
+	gauge prometheus.Gauge
+	lvs   []string
+}
+
+func newGaugeCache(vec *prometheus.GaugeVec) *gaugeCache {
+	return &gaugeCache{vec: vec, children: make(map[string]cachedGauge)}
+}
+
+func labelKey(lvs []string) string {
+	if len(lvs) == 1 {
+		return lvs[0]
+	}
+	return strings.Join(lvs, "\xff")
+}
+
+// with returns the child for lvs, creating it on first use.
+func (c *gaugeCache) with(lvs ...string) prometheus.Gauge {
+	key := labelKey(lvs)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if child, ok := c.children[key]; ok {
+		return child.gauge
+	}
+	g := c.vec.WithLabelValues(lvs...)
+	c.children[key] = cachedGauge{gauge: g, lvs: append([]string(nil), lvs...)}
+	return g
+}
+
+// retain deletes every child whose label values keep rejects, e.g. series
+// of nodes that left the cluster.
+func (c *gaugeCache) retain(keep func(lvs []string) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, child := range c.children {
+		if !keep(child.lvs) {
+			c.vec.DeleteLabelValues(child.lvs...)
+			delete(c.children, key)
+		}
+	}
+}
+
+// retainNodes keeps only children whose first label value is a known node.
+func (c *gaugeCache) retainNodes(nodes map[string]bool) {
+	c.retain(func(lvs []string) bool { return nodes[lvs[0]] })
+}
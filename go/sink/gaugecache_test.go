@@ -0,0 +1,35 @@
+package sink
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestGaugeCacheRetain(t *testing.T) {
+	c := newGaugeCache(prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "test_gauge"}, []string{"node", "direction"}))
+	c.with("a", "receive").Set(1)
+	c.with("a", "transmit").Set(2)
+	c.with("b", "receive").Set(3)
+	c.with("a", "receive").Set(4)
+	if got := len(c.children); got != 3 {
+		t.Fatalf("children = %d, want 3", got)
+	}
+
+	c.retainNodes(map[string]bool{"a": true})
+	if got := len(c.children); got != 2 {
+		t.Errorf("children after retainNodes = %d, want 2", got)
+	}
+	if _, ok := c.children[labelKey([]string{"b", "receive"})]; ok {
+		t.Errorf("child of deleted node b still cached")
+	}
+}
+
+func TestLabelKey(t *testing.T) {
+	if labelKey([]string{"ab", "c"}) == labelKey([]string{"a", "bc"}) {
+		t.Errorf("labelKey does not separate label values")
+	}
+	if got := labelKey([]string{"node-1"}); got != "node-1" {
+		t.Errorf("labelKey([node-1]) = %q, want %q", got, "node-1")
+	}
+}
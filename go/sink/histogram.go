@@ -0,0 +1,54 @@
+package sink
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// histogramCollector exports the latest set of observations as a constant
+// histogram, so it describes the cluster as last listed rather than
+// accumulating every cycle's observations as a prometheus.Histogram would.
+// Nothing is exported before the first update.
+type histogramCollector struct {
+	desc    *prometheus.Desc
+	buckets []float64
+
+	mu      sync.Mutex
+	values  []float64
+	updated bool
+}
+
+func newHistogramCollector(name, help string, buckets []float64) *histogramCollector {
+	return &histogramCollector{desc: prometheus.NewDesc(name, help, nil, nil), buckets: buckets}
+}
+
+func (c *histogramCollector) update(values []float64) {
+	c.mu.Lock()
+	c.values, c.updated = values, true
+	c.mu.Unlock()
+}
+
+func (c *histogramCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *histogramCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	values, updated := c.values, c.updated
+	c.mu.Unlock()
+	if !updated {
+		return
+	}
+	counts := make(map[float64]uint64, len(c.buckets))
+	var sum float64
+	for _, v := range values {
+		sum += v
+		for _, b := range c.buckets {
+			if v <= b {
+				counts[b]++
+			}
+		}
+	}
+	ch <- prometheus.MustNewConstHistogram(c.desc, uint64(len(values)), sum, counts)
+}
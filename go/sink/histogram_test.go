@@ -0,0 +1,32 @@
+package sink
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestHistogramCollector(t *testing.T) {
+	c := newHistogramCollector("test_pods_per_node", "test", []float64{10, 110})
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(c)
+	if families, err := reg.Gather(); err != nil || len(families) != 0 {
+		t.Fatalf("Gather before the first update = %v, %v; want nothing", families, err)
+	}
+
+	c.update([]float64{3, 10, 40, 110, 120})
+	c.update([]float64{0, 10, 110})
+	families, err := reg.Gather()
+	if err != nil || len(families) != 1 {
+		t.Fatalf("Gather = %v, %v", families, err)
+	}
+	h := families[0].Metric[0].Histogram
+	if h.GetSampleCount() != 3 || h.GetSampleSum() != 120 {
+		t.Errorf("count, sum = %d, %v; want the last update only, 3 and 120", h.GetSampleCount(), h.GetSampleSum())
+	}
+	for i, want := range []uint64{2, 3} {
+		if got := h.Bucket[i].GetCumulativeCount(); got != want {
+			t.Errorf("bucket le=%v = %d, want %d", h.Bucket[i].GetUpperBound(), got, want)
+		}
+	}
+}
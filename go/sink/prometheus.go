@@ -0,0 +1,1660 @@
+package sink
+
+import (
+	"log"
+	"math"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/your-org/k8s-ai-exporter/aggregate"
+	"github.com/your-org/k8s-ai-exporter/alert"
+	"github.com/your-org/k8s-ai-exporter/cloud"
+	"github.com/your-org/k8s-ai-exporter/collector"
+	"github.com/your-org/k8s-ai-exporter/kube"
+)
+
+// Prometheus holds the exporter's metric families. Node families are
+// updated every cycle and series of nodes that left the cluster are
+// removed with RetainNodes; detail families replace their previous
+// content on every Set call.
+type Prometheus struct {
+	nodeCPU        *gaugeCache
+	nodeMem        *gaugeCache
+	nodePods       *gaugeCache
+	workloadPods   *gaugeCache
+	maxPods        *gaugeCache
+	maxPodsRatio   *gaugeCache
+	podDensity     *histogramCollector
+	nodeStale      *gaugeCache
+	lastScrape     *gaugeCache
+	netErrors      *gaugeCache
+	netDrops       *gaugeCache
+	cadvisorSeries *gaugeCache
+	scrapeBytes    *gaugeCache
+	transferred    *gaugeCache
+	circuitOpen    *gaugeCache
+	unreachable    *gaugeCache
+	timedOut       *gaugeCache
+	fsUsed         *gaugeCache
+	fsCapacity     *gaugeCache
+	nodeConditions *gaugeCache
+	costSeries     *gaugeCache
+	costBytes      *gaugeCache
+	costParse      *gaugeCache
+	nodePower      *gaugeCache
+	powerMeasured  *gaugeCache
+	cpuCredits     *gaugeCache
+	customScore    *gaugeCache
+	gpuUtil        *gaugeCache
+	gpuMemUsed     *gaugeCache
+	hostLoad       *gaugeCache
+	hostDiskBusy   *gaugeCache
+	hostNetUtil    *gaugeCache
+	interruption   *gaugeCache
+	interruptAt    *gaugeCache
+	cloudInfo      *gaugeCache
+	scrapeSources  *gaugeCache
+	capacityCPU    *gaugeCache
+	capacityMem    *gaugeCache
+	podIPs         *gaugeCache
+	podIPCapacity  *gaugeCache
+	podCIDRRatio   *gaugeCache
+	rcPods         *gaugeCache
+	infraPods      *gaugeCache
+	infraCPU       *gaugeCache
+	infraMem       *gaugeCache
+	rcCPU          *gaugeCache
+	rcMem          *gaugeCache
+	nodeCPUWeek    *gaugeCache
+	nodeMemWeek    *gaugeCache
+	nsCPUWeek      *gaugeCache
+	nsMemWeek      *gaugeCache
+	burnRate       *gaugeCache
+	alertFiring    *gaugeCache
+	budgetLimit    *gaugeCache
+	budgetRatio    *gaugeCache
+	budgetExceeded *gaugeCache
+
+	serviceIPs        *gaugeCache
+	serviceIPCapacity *gaugeCache
+	serviceCIDRRatio  *gaugeCache
+
+	constraintViolations *gaugeCache
+	zoneSkew             *gaugeCache
+	zoneSkewViolation    *gaugeCache
+	poolFragmentation    *gaugeCache
+	poolStrandedCPU      *gaugeCache
+	poolStrandedMem      *gaugeCache
+	clusterFragmentation *gaugeCache
+	queueWorkloads       *gaugeCache
+	queuePods            *gaugeCache
+	queueCPU             *gaugeCache
+	queueMem             *gaugeCache
+	queueWait            *gaugeCache
+	controlPlaneUp       *gaugeCache
+	schedulingP99        *gaugeCache
+	etcdDBSize           *gaugeCache
+	etcdHasLeader        *gaugeCache
+	etcdLeaderChanges    *prometheus.CounterVec
+	dnsRequests          *gaugeCache
+	dnsServFails         *gaugeCache
+	dnsCacheHits         *gaugeCache
+	dnsPods              *gaugeCache
+	customSeries         *gaugeCache
+	passthrough          *rawCollector
+	execSeries           *rawCollector
+	annotatedSeries      *rawCollector
+
+	scrapeErrors      *prometheus.CounterVec
+	scrapeRetries     *prometheus.CounterVec
+	cycleOverruns     *prometheus.CounterVec
+	faultsInjected    *prometheus.CounterVec
+	notifyErrors      *prometheus.CounterVec
+	sinkHealthy       *prometheus.GaugeVec
+	collectorPanics   *prometheus.CounterVec
+	collectorSeconds  *prometheus.GaugeVec
+	collectorErrors   *prometheus.CounterVec
+	cyclePhaseSeconds *prometheus.GaugeVec
+	cycleSeconds      *prometheus.GaugeVec
+	cycleOverBudget   *prometheus.GaugeVec
+	cycleInterval     *prometheus.GaugeVec
+	stuck             *prometheus.GaugeVec
+	degradationLevel  prometheus.Gauge
+	virtualNodes      prometheus.Gauge
+	apiThrottled      *prometheus.CounterVec
+	maintenance       prometheus.Gauge
+	labelOverflow     *prometheus.GaugeVec
+
+	coreSeconds     *prometheus.CounterVec
+	byteSeconds     *prometheus.CounterVec
+	overheadCore    *prometheus.CounterVec
+	overheadBytes   *prometheus.CounterVec
+	typeCore        *prometheus.CounterVec
+	typeBytes       *prometheus.CounterVec
+	nodeJoules      *prometheus.CounterVec
+	namespaceJoules *prometheus.CounterVec
+	nodeCarbon      *prometheus.CounterVec
+	namespaceCarbon *prometheus.CounterVec
+
+	nodeCollectors   []prometheus.Collector
+	detailCollectors []prometheus.Collector
+}
+
+// NewPrometheus creates the metric families. Nothing is exported until
+// Register is called.
+func NewPrometheus() *Prometheus {
+	p := &Prometheus{
+		nodeCPU: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_node_cpu_usage_cores",
+				Help: "Aggregated CPU usage (cores) per node from kubelet/cAdvisor, averaged since the node's previous scrape.",
+			},
+			[]string{"node"},
+		)),
+		nodeMem: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_node_memory_usage_bytes",
+				Help: "Aggregated memory working set (bytes) per node from kubelet/cAdvisor.",
+			},
+			[]string{"node"},
+		)),
+		nodePods: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_node_active_pods",
+				Help: "Number of non-terminal pods per node.",
+			},
+			[]string{"node"},
+		)),
+		workloadPods: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_node_workload_pods",
+				Help: "Number of non-terminal pods per node without the infrastructure pods of -workload-pods-exclude (DaemonSet and mirror pods by default).",
+			},
+			[]string{"node"},
+		)),
+		maxPods: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_node_max_pods",
+				Help: "Pods the node can run, its allocatable pods (the kubelet's max-pods).",
+			},
+			[]string{"node"},
+		)),
+		maxPodsRatio: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_node_max_pods_utilization_ratio",
+				Help: "Non-terminal pods on the node as a share of its max-pods; new pods cannot be scheduled on the node at 1.",
+			},
+			[]string{"node"},
+		)),
+		podDensity: newHistogramCollector(
+			"k8s_cluster_pods_per_node",
+			"Distribution of non-terminal pods per node over the nodes of the last listing.",
+			aggregate.PodDensityBuckets,
+		),
+		nodeStale: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_node_scrape_stale",
+				Help: "1 when the node's usage is from its last successful scrape because the latest one failed, else 0.",
+			},
+			[]string{"node"},
+		)),
+		lastScrape: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_node_last_scrape_timestamp_seconds",
+				Help: "Unix time of the node's last successful kubelet or cAdvisor scrape.",
+			},
+			[]string{"node"},
+		)),
+		netErrors: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_node_network_errors_per_second",
+				Help: "Network interface errors per second per node and direction (receive/transmit) from cAdvisor.",
+			},
+			[]string{"node", "direction"},
+		)),
+		netDrops: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_node_network_drops_per_second",
+				Help: "Dropped packets per second per node and direction (receive/transmit) from cAdvisor.",
+			},
+			[]string{"node", "direction"},
+		)),
+		cadvisorSeries: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_node_cadvisor_series_count",
+				Help: "Number of series in the node's last cAdvisor exposition.",
+			},
+			[]string{"node"},
+		)),
+		scrapeBytes: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_node_scrape_payload_bytes",
+				Help: "Size of the node's last kubelet/cAdvisor payload after decompression.",
+			},
+			[]string{"node"},
+		)),
+		transferred: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_node_scrape_transferred_bytes",
+				Help: "Bytes transferred for the node's last kubelet/cAdvisor payload; below k8s_node_scrape_payload_bytes when it came gzipped (-scrape-gzip).",
+			},
+			[]string{"node"},
+		)),
+		circuitOpen: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_ai_exporter_target_circuit_open",
+				Help: "1 while the node is skipped after consecutive failed scrapes (-circuit-breaker-failures), else 0.",
+			},
+			[]string{"node"},
+		)),
+		unreachable: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_node_unreachable",
+				Help: "1 when the node was not scraped because its Ready condition is False or Unknown (-skip-unready-nodes), else 0.",
+			},
+			[]string{"node"},
+		)),
+		timedOut: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_node_scrape_timed_out",
+				Help: "1 when the node's last scrape was cancelled or skipped because the cycle hit -scrape-timeout, else 0.",
+			},
+			[]string{"node"},
+		)),
+		fsUsed: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_node_fs_used_bytes",
+				Help: "Used bytes of the node's root filesystem from the kubelet Summary API (-source=summary).",
+			},
+			[]string{"node"},
+		)),
+		fsCapacity: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_node_fs_capacity_bytes",
+				Help: "Capacity of the node's root filesystem in bytes from the kubelet Summary API (-source=summary).",
+			},
+			[]string{"node"},
+		)),
+		nodeConditions: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_node_condition",
+				Help: "1 if the node condition (Ready, MemoryPressure, ...) is True, else 0.",
+			},
+			[]string{"node", "condition"},
+		)),
+		rcPods: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_node_runtimeclass_pods",
+				Help: "Non-terminal pods on the node per RuntimeClass; \"default\" when the pod sets none.",
+			},
+			[]string{"node", "runtime_class"},
+		)),
+		infraPods: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_node_infra_pods",
+				Help: "Infrastructure pods on the node by kind: mirror (static) pods, and DaemonSet pods in -infra-daemonset-namespaces.",
+			},
+			[]string{"node", "kind"},
+		)),
+		infraCPU: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_node_infra_cpu_request_cores",
+				Help: "CPU requested by the node's infrastructure pods by kind, including init containers and pod overhead.",
+			},
+			[]string{"node", "kind"},
+		)),
+		infraMem: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_node_infra_memory_request_bytes",
+				Help: "Memory requested by the node's infrastructure pods by kind, including init containers and pod overhead.",
+			},
+			[]string{"node", "kind"},
+		)),
+		rcCPU: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_node_runtimeclass_cpu_usage_cores",
+				Help: "CPU usage of the node's pods per RuntimeClass, including sandbox and RuntimeClass overhead, averaged since the previous scrape.",
+			},
+			[]string{"node", "runtime_class"},
+		)),
+		rcMem: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_node_runtimeclass_memory_usage_bytes",
+				Help: "Working set of the node's pods per RuntimeClass, including sandbox and RuntimeClass overhead, averaged since the previous scrape.",
+			},
+			[]string{"node", "runtime_class"},
+		)),
+		nodeCPUWeek: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_node_cpu_usage_vs_last_week_ratio",
+				Help: "Node CPU usage divided by its average at the same time of day one week earlier.",
+			},
+			[]string{"node"},
+		)),
+		nodeMemWeek: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_node_memory_usage_vs_last_week_ratio",
+				Help: "Node memory working set divided by its average at the same time of day one week earlier.",
+			},
+			[]string{"node"},
+		)),
+		nsCPUWeek: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_namespace_cpu_usage_vs_last_week_ratio",
+				Help: "Namespace CPU usage divided by its average at the same time of day one week earlier.",
+			},
+			[]string{"namespace"},
+		)),
+		nsMemWeek: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_namespace_memory_usage_vs_last_week_ratio",
+				Help: "Namespace memory working set divided by its average at the same time of day one week earlier.",
+			},
+			[]string{"namespace"},
+		)),
+		costSeries: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_ai_exporter_namespace_cost_series",
+				Help: "Series the namespace's pods added to the last kubelet/cAdvisor scrape, summed over nodes.",
+			},
+			[]string{"namespace"},
+		)),
+		costBytes: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_ai_exporter_namespace_cost_bytes",
+				Help: "Exposition bytes of the namespace's series in the last kubelet/cAdvisor scrape, summed over nodes.",
+			},
+			[]string{"namespace"},
+		)),
+		costParse: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_ai_exporter_namespace_cost_parse_seconds",
+				Help: "Parse time of the last kubelet/cAdvisor scrape charged to the namespace by its share of the bytes, summed over nodes.",
+			},
+			[]string{"namespace"},
+		)),
+		nodePower: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_node_power_watts",
+				Help: "Estimated power draw of the node from its power model and CPU utilization over the last cycle.",
+			},
+			[]string{"node"},
+		)),
+		powerMeasured: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_node_power_measured",
+				Help: "1 if k8s_node_power_watts comes from a node agent's energy counters, 0 if from the power model.",
+			},
+			[]string{"node"},
+		)),
+		cpuCredits: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_node_cpu_credits_remaining",
+				Help: "CPU credit balance of burstable instances, as reported by a node agent.",
+			},
+			[]string{"node"},
+		)),
+		customScore: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_node_custom_score",
+				Help: "Value of the -node-score expression for the node.",
+			},
+			[]string{"node"},
+		)),
+		gpuUtil: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_node_gpu_utilization",
+				Help: "Mean utilization of the node's GPUs from 0 to 1, as reported by the NVIDIA DCGM exporter.",
+			},
+			[]string{"node"},
+		)),
+		gpuMemUsed: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_node_gpu_memory_used_bytes",
+				Help: "GPU framebuffer memory in use on the node, as reported by the NVIDIA DCGM exporter.",
+			},
+			[]string{"node"},
+		)),
+		hostLoad: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_node_load_per_cpu",
+				Help: "Load average of the node over period (1m, 5m, 15m) divided by its CPUs, as reported by node_exporter. Above 1, runnable threads wait for a CPU.",
+			},
+			[]string{"node", "period"},
+		)),
+		hostDiskBusy: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_node_disk_io_utilization",
+				Help: "Share of time the node's busiest block device had IO in progress since the previous node_exporter scrape, from 0 to 1.",
+			},
+			[]string{"node"},
+		)),
+		hostNetUtil: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_node_network_utilization",
+				Help: "Throughput of the node's busiest physical interface over its link speed, in its busier direction, since the previous node_exporter scrape, from 0 to 1.",
+			},
+			[]string{"node"},
+		)),
+		interruption: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_node_interruption_pending",
+				Help: "1 while the cloud provider's instance metadata announces that it will reclaim the node (action: terminate, stop, preempt), 0 otherwise.",
+			},
+			[]string{"node", "action"},
+		)),
+		interruptAt: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_node_interruption_timestamp_seconds",
+				Help: "Unix time at which the cloud provider reclaims the node, when its notice says.",
+			},
+			[]string{"node"},
+		)),
+		cloudInfo: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_node_cloud_info",
+				Help: "Cloud metadata of the node from its providerID and well-known labels; always 1.",
+			},
+			[]string{"node", "provider", "region", "zone", "instance_type", "lifecycle"},
+		)),
+		scrapeSources: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_node_scrape_source_info",
+				Help: "Sources the node's data was scraped from in the last cycle, with the exposition format and the node's kubelet and container runtime versions; always 1.",
+			},
+			[]string{"node", "source", "format", "kubelet_version", "container_runtime"},
+		)),
+		capacityCPU: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_node_capacity_cpu_cores",
+				Help: "CPU capacity of the node (its instance type's vCPUs on cloud nodes).",
+			},
+			[]string{"node"},
+		)),
+		capacityMem: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_node_capacity_memory_bytes",
+				Help: "Memory capacity of the node.",
+			},
+			[]string{"node"},
+		)),
+		podIPs: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_node_pod_ips_allocated",
+				Help: "Pod IPs in use on the node by IP family; host-network pods are not counted.",
+			},
+			[]string{"node", "family"},
+		)),
+		podIPCapacity: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_node_pod_ips_capacity",
+				Help: "Assignable addresses in the node's pod CIDRs by IP family.",
+			},
+			[]string{"node", "family"},
+		)),
+		podCIDRRatio: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_node_pod_cidr_utilization_ratio",
+				Help: "Share of the node's pod CIDR in use; new pods cannot start on the node at 1.",
+			},
+			[]string{"node", "family"},
+		)),
+		burnRate: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_node_saturation_burn_rate",
+				Help: "Rate at which the node burns the error budget of its saturation objective (-saturation-objectives) over the window; 1 spends exactly the budget.",
+			},
+			[]string{"node", "resource", "window"},
+		)),
+		alertFiring: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_node_saturation_alert_firing",
+				Help: "1 while the node's saturation burn rate is at least -saturation-burn-rate over both windows, otherwise 0.",
+			},
+			[]string{"node", "resource"},
+		)),
+		budgetLimit: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_namespace_budget_limit",
+				Help: "Cap of the namespace's NamespaceBudget: cores for cpu, bytes for memory, monthly cost for cost.",
+			},
+			[]string{"namespace", "resource"},
+		)),
+		budgetRatio: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_namespace_budget_used_ratio",
+				Help: "Usage or requests of the namespace divided by its NamespaceBudget cap, by basis; above 1 exceeds the budget.",
+			},
+			[]string{"namespace", "resource", "basis"},
+		)),
+		budgetExceeded: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_namespace_budget_exceeded",
+				Help: "1 while the namespace's usage or requests exceed its NamespaceBudget cap, otherwise 0.",
+			},
+			[]string{"namespace", "resource"},
+		)),
+		serviceIPs: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_service_cidr_ips_allocated",
+				Help: "Service ClusterIPs in use in the range.",
+			},
+			[]string{"cidr"},
+		)),
+		serviceIPCapacity: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_service_cidr_ips_capacity",
+				Help: "Assignable addresses in the Service ClusterIP range.",
+			},
+			[]string{"cidr"},
+		)),
+		serviceCIDRRatio: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_service_cidr_utilization_ratio",
+				Help: "Share of the Service ClusterIP range in use; Service creation fails at 1.",
+			},
+			[]string{"cidr"},
+		)),
+		constraintViolations: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_node_pod_constraint_violations",
+				Help: "Running pods whose required scheduling constraints are no longer satisfied by their current node, by constraint kind.",
+			},
+			[]string{"node", "constraint"},
+		)),
+		zoneSkew: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_workload_zone_skew",
+				Help: "Difference between the most and least populated zone for workloads that declare zone spread or zone anti-affinity.",
+			},
+			[]string{"namespace", "kind", "workload"},
+		)),
+		zoneSkewViolation: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_workload_zone_skew_violation",
+				Help: "1 if the workload's zone skew exceeds the maxSkew of its zone topologySpreadConstraint, else 0.",
+			},
+			[]string{"namespace", "kind", "workload"},
+		)),
+		poolFragmentation: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_pool_fragmentation_ratio",
+				Help: "Share of the pool's free allocatable capacity that no running workload could use, by resource.",
+			},
+			[]string{"pool", "resource"},
+		)),
+		poolStrandedCPU: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_pool_stranded_cpu_cores",
+				Help: "Free allocatable CPU of the pool that no running workload could use, by reason.",
+			},
+			[]string{"pool", "reason"},
+		)),
+		poolStrandedMem: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_pool_stranded_memory_bytes",
+				Help: "Free allocatable memory of the pool that no running workload could use, by reason.",
+			},
+			[]string{"pool", "reason"},
+		)),
+		clusterFragmentation: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_cluster_fragmentation_ratio",
+				Help: "Share of the cluster's free allocatable capacity that no running workload could use, by resource.",
+			},
+			[]string{"resource"},
+		)),
+		queueWorkloads: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_batch_queue_pending_workloads",
+				Help: "Kueue Workloads or Volcano PodGroups waiting for admission in the queue.",
+			},
+			[]string{"scheduler", "namespace", "queue"},
+		)),
+		queuePods: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_batch_queue_pending_pods",
+				Help: "Pods of the workloads waiting for admission in the queue.",
+			},
+			[]string{"scheduler", "namespace", "queue"},
+		)),
+		queueCPU: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_batch_queue_pending_cpu_cores",
+				Help: "CPU requested by the workloads waiting for admission in the queue.",
+			},
+			[]string{"scheduler", "namespace", "queue"},
+		)),
+		queueMem: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_batch_queue_pending_memory_bytes",
+				Help: "Memory requested by the workloads waiting for admission in the queue.",
+			},
+			[]string{"scheduler", "namespace", "queue"},
+		)),
+		queueWait: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_batch_queue_estimated_wait_seconds",
+				Help: "Estimated time until the queue's requests fit, from the cluster's headroom and the queue's recent admission rate. Absent while unknown.",
+			},
+			[]string{"scheduler", "namespace", "queue"},
+		)),
+		controlPlaneUp: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_control_plane_up",
+				Help: "1 when the last scrape of the control plane pod succeeded, 0 when it failed.",
+			},
+			[]string{"component", "pod"},
+		)),
+		schedulingP99: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_scheduler_scheduling_latency_p99_seconds",
+				Help: "99th percentile scheduling attempt latency of the pods the scheduler placed since its previous scrape; absent when it placed none.",
+			},
+			[]string{"pod"},
+		)),
+		etcdDBSize: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_etcd_db_size_bytes",
+				Help: "Physical size of the etcd member's backend database, including free pages.",
+			},
+			[]string{"pod"},
+		)),
+		etcdHasLeader: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_etcd_has_leader",
+				Help: "1 when the etcd member sees a leader, 0 when it does not.",
+			},
+			[]string{"pod"},
+		)),
+		etcdLeaderChanges: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "k8s_etcd_leader_changes_total",
+				Help: "Leader changes the etcd member has seen while the exporter scraped it.",
+			},
+			[]string{"pod"},
+		),
+		dnsRequests: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_dns_requests_per_second",
+				Help: "DNS requests per second served by the cluster's CoreDNS pods since their previous scrape.",
+			},
+			nil,
+		)),
+		dnsServFails: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_dns_servfail_per_second",
+				Help: "SERVFAIL responses per second of the cluster's CoreDNS pods since their previous scrape.",
+			},
+			nil,
+		)),
+		dnsCacheHits: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_dns_cache_hit_ratio",
+				Help: "Share of CoreDNS cache lookups that hit since the previous scrape, from 0 to 1; absent without lookups.",
+			},
+			nil,
+		)),
+		dnsPods: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_dns_pods",
+				Help: "CoreDNS pods the k8s_dns_* rates cover: those scraped in this and the previous detail cycle.",
+			},
+			nil,
+		)),
+		customSeries: newGaugeCache(prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_node_custom_series",
+				Help: "Sum per node of selected series scraped from annotated pods and Services.",
+			},
+			[]string{"node", "job", "metric"},
+		)),
+		passthrough:     &rawCollector{help: "Passthrough of raw kubelet/cAdvisor series."},
+		execSeries:      &rawCollector{help: "Series of an exec collector (-exec-collectors)."},
+		annotatedSeries: &rawCollector{help: "Series of a pod annotated prometheus.io/scrape (-annotated-series)."},
+		scrapeErrors: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "k8s_ai_exporter_scrape_errors_total",
+				Help: "Total scrape errors by target.",
+			},
+			[]string{"target"},
+		),
+		scrapeRetries: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "k8s_ai_exporter_scrape_retries_total",
+				Help: "Total scrapes retried after a transport error, 429 or 5xx, by target (-scrape-retries).",
+			},
+			[]string{"target"},
+		),
+		cycleOverruns: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "k8s_ai_exporter_cycle_overruns_total",
+				Help: "Total cycles whose node scrapes hit -scrape-timeout and were cut short, by cycle.",
+			},
+			[]string{"cycle"},
+		),
+		notifyErrors: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "k8s_ai_exporter_notification_errors_total",
+				Help: "Total alert notifications that could not be delivered, by notifier.",
+			},
+			[]string{"notifier"},
+		),
+		sinkHealthy: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_ai_exporter_sink_healthy",
+				Help: "1 if the last health check of a configured alert receiver succeeded, else 0.",
+			},
+			[]string{"sink"},
+		),
+		faultsInjected: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "k8s_ai_exporter_faults_injected_total",
+				Help: "Total faults injected into scrapes by the hidden -fault.* flags, by kind.",
+			},
+			[]string{"fault"},
+		),
+		collectorPanics: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "k8s_ai_exporter_collector_panics_total",
+				Help: "Total panics recovered in a collector; its results were skipped for that cycle.",
+			},
+			[]string{"collector"},
+		),
+		collectorSeconds: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_ai_exporter_collector_duration_seconds",
+				Help: "Duration of the last run of each registered collector.",
+			},
+			[]string{"collector"},
+		),
+		collectorErrors: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "k8s_ai_exporter_collector_errors_total",
+				Help: "Total runs of each registered collector that returned an error or panicked.",
+			},
+			[]string{"collector"},
+		),
+		cyclePhaseSeconds: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_ai_exporter_cycle_phase_seconds",
+				Help: "Time spent in each phase of the last collection cycle. Proxy fetch and parse are summed over all nodes.",
+			},
+			[]string{"cycle", "phase"},
+		),
+		cycleSeconds: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_ai_exporter_cycle_seconds",
+				Help: "Wall-clock duration of the last collection cycle.",
+			},
+			[]string{"cycle"},
+		),
+		cycleOverBudget: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_ai_exporter_cycle_over_budget",
+				Help: "1 if the last collection cycle took longer than its interval, else 0.",
+			},
+			[]string{"cycle"},
+		),
+		cycleInterval: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_ai_exporter_cycle_interval_seconds",
+				Help: "Interval the cycle waits for from the start of its last run, after -adaptive-interval and throttling stretched it.",
+			},
+			[]string{"cycle"},
+		),
+		stuck: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_ai_exporter_stuck",
+				Help: "1 if the cycle has not completed within -stuck-after-intervals times its interval, else 0.",
+			},
+			[]string{"cycle"},
+		),
+		degradationLevel: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "k8s_ai_exporter_degradation_level",
+			Help: "Back-off level while the API server throttles the exporter: intervals are stretched by 2^level and expensive collectors are skipped above 0.",
+		}),
+		virtualNodes: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "k8s_ai_exporter_virtual_nodes",
+			Help: "Virtual nodes (virtual-kubelet, EKS Fargate) seen in the last cycle; they are not scraped (-skip-virtual-nodes).",
+		}),
+		apiThrottled: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "k8s_ai_exporter_api_throttled_total",
+				Help: "Total API server requests throttled, by kind: server (429 responses) or client (held back by the client-side rate limiter).",
+			},
+			[]string{"kind"},
+		),
+		maintenance: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "k8s_ai_exporter_maintenance_windows_active",
+			Help: "Number of declared maintenance windows in effect; alerts and forecasts are suppressed while it is above 0.",
+		}),
+		labelOverflow: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "k8s_ai_exporter_label_values_overflowed",
+				Help: "Distinct values of a capped label folded into \"other\" in the last cycle's passthrough series (-label-value-caps).",
+			},
+			[]string{"label"},
+		),
+		coreSeconds: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "k8s_namespace_cpu_core_seconds_total",
+				Help: "CPU core-seconds consumed by the namespace's containers, for chargeback with increase().",
+			},
+			[]string{"namespace"},
+		),
+		byteSeconds: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "k8s_namespace_memory_byte_seconds_total",
+				Help: "Working-set byte-seconds of the namespace's containers, for chargeback with increase().",
+			},
+			[]string{"namespace"},
+		),
+		overheadCore: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "k8s_namespace_overhead_cpu_core_seconds_total",
+				Help: "CPU core-seconds of the namespace's pod sandboxes and RuntimeClass overhead, beyond k8s_namespace_cpu_core_seconds_total.",
+			},
+			[]string{"namespace"},
+		),
+		overheadBytes: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "k8s_namespace_overhead_memory_byte_seconds_total",
+				Help: "Working-set byte-seconds of the namespace's pod sandboxes and RuntimeClass overhead, beyond k8s_namespace_memory_byte_seconds_total.",
+			},
+			[]string{"namespace"},
+		),
+		typeCore: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "k8s_namespace_container_cpu_core_seconds_total",
+				Help: "CPU core-seconds of the namespace's containers by container_type (app, init, ephemeral, sidecar).",
+			},
+			[]string{"namespace", "container_type"},
+		),
+		typeBytes: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "k8s_namespace_container_memory_byte_seconds_total",
+				Help: "Working-set byte-seconds of the namespace's containers by container_type (app, init, ephemeral, sidecar).",
+			},
+			[]string{"namespace", "container_type"},
+		),
+		nodeJoules: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "k8s_node_energy_joules_total",
+				Help: "Estimated energy consumed by the node.",
+			},
+			[]string{"node"},
+		),
+		namespaceJoules: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "k8s_namespace_energy_joules_total",
+				Help: "Estimated node energy attributed to the namespace by its share of container CPU time.",
+			},
+			[]string{"namespace"},
+		),
+		nodeCarbon: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "k8s_node_carbon_grams_total",
+				Help: "Estimated grams of CO2 emitted for the node's energy at the configured grid intensity.",
+			},
+			[]string{"node"},
+		),
+		namespaceCarbon: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "k8s_namespace_carbon_grams_total",
+				Help: "Estimated grams of CO2 attributed to the namespace at the configured grid intensity.",
+			},
+			[]string{"namespace"},
+		),
+	}
+	p.nodeCollectors = []prometheus.Collector{
+		p.nodeCPU.vec, p.nodeMem.vec, p.nodePods.vec, p.workloadPods.vec, p.maxPods.vec, p.maxPodsRatio.vec, p.podDensity, p.nodeStale.vec, p.lastScrape.vec,
+		p.netErrors.vec, p.netDrops.vec, p.cadvisorSeries.vec, p.scrapeBytes.vec, p.transferred.vec, p.circuitOpen.vec, p.unreachable.vec, p.timedOut.vec, p.fsUsed.vec, p.fsCapacity.vec, p.nodeConditions.vec,
+		p.costSeries.vec, p.costBytes.vec, p.costParse.vec,
+		p.nodePower.vec, p.powerMeasured.vec, p.cpuCredits.vec, p.customScore.vec, p.gpuUtil.vec, p.gpuMemUsed.vec,
+		p.hostLoad.vec, p.hostDiskBusy.vec, p.hostNetUtil.vec, p.interruption.vec, p.interruptAt.vec,
+		p.cloudInfo.vec, p.scrapeSources.vec, p.capacityCPU.vec, p.capacityMem.vec,
+		p.podIPs.vec, p.podIPCapacity.vec, p.podCIDRRatio.vec,
+		p.rcPods.vec, p.rcCPU.vec, p.rcMem.vec,
+		p.infraPods.vec, p.infraCPU.vec, p.infraMem.vec,
+		p.nodeCPUWeek.vec, p.nodeMemWeek.vec, p.nsCPUWeek.vec, p.nsMemWeek.vec,
+		p.burnRate.vec, p.alertFiring.vec,
+		p.budgetLimit.vec, p.budgetRatio.vec, p.budgetExceeded.vec,
+		p.serviceIPs.vec, p.serviceIPCapacity.vec, p.serviceCIDRRatio.vec,
+		p.scrapeErrors, p.scrapeRetries, p.cycleOverruns, p.faultsInjected, p.collectorPanics, p.notifyErrors, p.sinkHealthy,
+		p.collectorSeconds, p.collectorErrors,
+		p.cyclePhaseSeconds, p.cycleSeconds, p.cycleOverBudget, p.cycleInterval, p.stuck,
+		p.degradationLevel, p.virtualNodes, p.apiThrottled,
+		p.maintenance, p.labelOverflow, p.coreSeconds, p.byteSeconds,
+		p.overheadCore, p.overheadBytes, p.typeCore, p.typeBytes,
+		p.nodeJoules, p.namespaceJoules, p.nodeCarbon, p.namespaceCarbon,
+	}
+	p.detailCollectors = []prometheus.Collector{
+		p.constraintViolations.vec, p.zoneSkew.vec, p.zoneSkewViolation.vec,
+		p.poolFragmentation.vec, p.poolStrandedCPU.vec, p.poolStrandedMem.vec, p.clusterFragmentation.vec,
+		p.queueWorkloads.vec, p.queuePods.vec, p.queueCPU.vec, p.queueMem.vec, p.queueWait.vec,
+		p.controlPlaneUp.vec, p.schedulingP99.vec, p.etcdDBSize.vec, p.etcdHasLeader.vec, p.etcdLeaderChanges,
+		p.dnsRequests.vec, p.dnsServFails.vec, p.dnsCacheHits.vec, p.dnsPods.vec,
+		p.customSeries.vec, p.passthrough, p.execSeries, p.annotatedSeries,
+	}
+	return p
+}
+
+// Register registers the node families on reg and the per-pod and
+// per-workload families on detail. Pass the same registerer twice to serve
+// everything from one endpoint.
+func (p *Prometheus) Register(reg, detail prometheus.Registerer) error {
+	for _, c := range p.nodeCollectors {
+		if err := reg.Register(c); err != nil {
+			return err
+		}
+	}
+	for _, c := range p.detailCollectors {
+		if err := detail.Register(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetNodePods sets the active pod count per node.
+func (p *Prometheus) SetNodePods(counts map[string]float64) {
+	for node, n := range counts {
+		p.nodePods.with(node).Set(n)
+	}
+}
+
+// SetNodeWorkloadPods sets the workload pod count per node.
+func (p *Prometheus) SetNodeWorkloadPods(counts map[string]float64) {
+	for node, n := range counts {
+		p.workloadPods.with(node).Set(n)
+	}
+}
+
+// SetNodeMaxPods sets the max-pods of the nodes in maxPods and, from the
+// active pod counts, their utilization. density has the pod count of every
+// listed node, for the pods-per-node histogram.
+func (p *Prometheus) SetNodeMaxPods(maxPods, counts map[string]float64, density []float64) {
+	for node, max := range maxPods {
+		p.maxPods.with(node).Set(max)
+		p.maxPodsRatio.with(node).Set(counts[node] / max)
+	}
+	p.podDensity.update(density)
+}
+
+// SetScrapeSources replaces the scrape source info series with sources.
+// Nodes that could not be scraped have none.
+func (p *Prometheus) SetScrapeSources(sources []collector.ScrapeSource) {
+	current := make(map[string]bool, len(sources))
+	for _, s := range sources {
+		lvs := []string{s.Node, s.Source, s.Format, s.KubeletVersion, s.ContainerRuntime}
+		p.scrapeSources.with(lvs...).Set(1)
+		current[labelKey(lvs)] = true
+	}
+	p.scrapeSources.retain(func(lvs []string) bool { return current[labelKey(lvs)] })
+}
+
+// SetRuntimeClassPods replaces the pod counts per node and RuntimeClass
+// with counts, so classes no longer running on a node disappear.
+func (p *Prometheus) SetRuntimeClassPods(counts map[string]map[string]float64) {
+	for node, byClass := range counts {
+		for rc, n := range byClass {
+			p.rcPods.with(node, rc).Set(n)
+		}
+	}
+	p.rcPods.retain(func(lvs []string) bool {
+		_, ok := counts[lvs[0]][lvs[1]]
+		return ok
+	})
+}
+
+// SetInfraPods replaces the infrastructure pod families with footprints,
+// keyed by node and kind.
+func (p *Prometheus) SetInfraPods(footprints map[string]map[string]aggregate.InfraFootprint) {
+	for node, byKind := range footprints {
+		for kind, f := range byKind {
+			p.infraPods.with(node, kind).Set(f.Pods)
+			p.infraCPU.with(node, kind).Set(f.CPUCores)
+			p.infraMem.with(node, kind).Set(f.MemoryBytes)
+		}
+	}
+	keep := func(lvs []string) bool {
+		_, ok := footprints[lvs[0]][lvs[1]]
+		return ok
+	}
+	p.infraPods.retain(keep)
+	p.infraCPU.retain(keep)
+	p.infraMem.retain(keep)
+}
+
+// SetRuntimeClassUsage replaces node's usage per RuntimeClass with rates.
+func (p *Prometheus) SetRuntimeClassUsage(node string, rates map[string]aggregate.UsageRate) {
+	for rc, r := range rates {
+		p.rcCPU.with(node, rc).Set(r.CPUCores)
+		p.rcMem.with(node, rc).Set(r.MemoryBytes)
+	}
+	keep := func(lvs []string) bool {
+		_, ok := rates[lvs[1]]
+		return lvs[0] != node || ok
+	}
+	p.rcCPU.retain(keep)
+	p.rcMem.retain(keep)
+}
+
+// SetNodeConditions sets the condition values per node and condition type.
+func (p *Prometheus) SetNodeConditions(conds map[string]map[string]float64) {
+	for node, byType := range conds {
+		for cond, v := range byType {
+			p.nodeConditions.with(node, cond).Set(v)
+		}
+	}
+}
+
+// SetNodeCloudInfo sets the cloud info and capacity families per node. An
+// info series whose labels changed (e.g. a zone relabel) is replaced.
+func (p *Prometheus) SetNodeCloudInfo(infos map[string]aggregate.CloudInfo) {
+	for node, info := range infos {
+		p.cloudInfo.with(cloudInfoLabels(node, info)...).Set(1)
+		p.capacityCPU.with(node).Set(info.CPUCores)
+		p.capacityMem.with(node).Set(info.MemoryBytes)
+	}
+	p.cloudInfo.retain(func(lvs []string) bool {
+		info, ok := infos[lvs[0]]
+		return !ok || labelKey(lvs) == labelKey(cloudInfoLabels(lvs[0], info))
+	})
+}
+
+func cloudInfoLabels(node string, info aggregate.CloudInfo) []string {
+	return []string{node, info.Provider, info.Region, info.Zone, info.InstanceType, info.Lifecycle}
+}
+
+// SetPodCIDRUsage sets the pod IP families per node and IP family.
+func (p *Prometheus) SetPodCIDRUsage(usage map[string]map[string]aggregate.IPUsage) {
+	for node, byFamily := range usage {
+		for family, u := range byFamily {
+			p.podIPs.with(node, family).Set(u.Allocated)
+			p.podIPCapacity.with(node, family).Set(u.Capacity)
+			p.podCIDRRatio.with(node, family).Set(u.Ratio())
+		}
+	}
+}
+
+// SetServiceCIDRUsage replaces the Service ClusterIP range families.
+func (p *Prometheus) SetServiceCIDRUsage(usage map[string]aggregate.IPUsage) {
+	for cidr, u := range usage {
+		p.serviceIPs.with(cidr).Set(u.Allocated)
+		p.serviceIPCapacity.with(cidr).Set(u.Capacity)
+		p.serviceCIDRRatio.with(cidr).Set(u.Ratio())
+	}
+}
+
+// SetNodeUsage sets CPU cores and memory bytes per node.
+func (p *Prometheus) SetNodeUsage(cpu, mem map[string]float64) {
+	for node, v := range cpu {
+		p.nodeCPU.with(node).Set(v)
+	}
+	for node, v := range mem {
+		p.nodeMem.with(node).Set(v)
+	}
+}
+
+// SetNodeFreshness sets when node was last scraped successfully and
+// whether its usage is stale, i.e. from that scrape rather than the
+// current cycle.
+func (p *Prometheus) SetNodeFreshness(node string, last time.Time, stale bool) {
+	v := 0.0
+	if stale {
+		v = 1
+	}
+	p.nodeStale.with(node).Set(v)
+	p.lastScrape.with(node).Set(float64(last.UnixNano()) / 1e9)
+}
+
+// SetCircuitOpen sets whether node's scrape circuit is open.
+func (p *Prometheus) SetCircuitOpen(node string, open bool) {
+	v := 0.0
+	if open {
+		v = 1
+	}
+	p.circuitOpen.with(node).Set(v)
+}
+
+// SetNodeUnreachable sets whether node was skipped as unreachable.
+func (p *Prometheus) SetNodeUnreachable(node string, unreachable bool) {
+	v := 0.0
+	if unreachable {
+		v = 1
+	}
+	p.unreachable.with(node).Set(v)
+}
+
+// SetNodeScrapeTimedOut sets whether node's last scrape was cut short by
+// the cycle's scrape timeout.
+func (p *Prometheus) SetNodeScrapeTimedOut(node string, timedOut bool) {
+	v := 0.0
+	if timedOut {
+		v = 1
+	}
+	p.timedOut.with(node).Set(v)
+}
+
+// CycleOverrun counts a cycle cut short by the scrape timeout.
+func (p *Prometheus) CycleOverrun(cycle string) {
+	p.cycleOverruns.WithLabelValues(cycle).Inc()
+}
+
+// MarkNodeStale flags node's usage as stale without changing its last
+// scrape time, for cycles that could not even list the nodes.
+func (p *Prometheus) MarkNodeStale(node string) {
+	p.nodeStale.with(node).Set(1)
+}
+
+// SetWeekOverWeek replaces the usage ratios against the same time last
+// week with those of nodes and namespaces.
+func (p *Prometheus) SetWeekOverWeek(nodes, namespaces map[string]aggregate.UsageRatio) {
+	for _, f := range []struct {
+		ratios   map[string]aggregate.UsageRatio
+		cpu, mem *gaugeCache
+	}{
+		{nodes, p.nodeCPUWeek, p.nodeMemWeek},
+		{namespaces, p.nsCPUWeek, p.nsMemWeek},
+	} {
+		for key, r := range f.ratios {
+			f.cpu.with(key).Set(r.CPU)
+			f.mem.with(key).Set(r.Memory)
+		}
+		ratios := f.ratios
+		current := func(lvs []string) bool {
+			_, ok := ratios[lvs[0]]
+			return ok
+		}
+		f.cpu.retain(current)
+		f.mem.retain(current)
+	}
+}
+
+// SetSaturationBurnRates replaces the saturation burn rates and alert
+// states with rates.
+func (p *Prometheus) SetSaturationBurnRates(rates []alert.BurnRate) {
+	current := make(map[[2]string]bool, len(rates))
+	for _, r := range rates {
+		current[[2]string{r.Node, r.Resource}] = true
+		p.burnRate.with(r.Node, r.Resource, alert.ShortWindowName).Set(r.Short)
+		p.burnRate.with(r.Node, r.Resource, alert.LongWindowName).Set(r.Long)
+		firing := 0.0
+		if r.Firing {
+			firing = 1
+		}
+		p.alertFiring.with(r.Node, r.Resource).Set(firing)
+	}
+	retain := func(lvs []string) bool { return current[[2]string{lvs[0], lvs[1]}] }
+	p.burnRate.retain(retain)
+	p.alertFiring.retain(retain)
+}
+
+// SetNamespaceBudgets replaces the namespace budget families with uses.
+func (p *Prometheus) SetNamespaceBudgets(uses []alert.BudgetUse) {
+	current := make(map[[2]string]bool, len(uses))
+	for _, u := range uses {
+		current[[2]string{u.Namespace, u.Resource}] = true
+		p.budgetLimit.with(u.Namespace, u.Resource).Set(u.Limit)
+		p.budgetRatio.with(u.Namespace, u.Resource, "usage").Set(u.Usage / u.Limit)
+		p.budgetRatio.with(u.Namespace, u.Resource, "requests").Set(u.Requests / u.Limit)
+		exceeded := 0.0
+		if u.Exceeded() {
+			exceeded = 1
+		}
+		p.budgetExceeded.with(u.Namespace, u.Resource).Set(exceeded)
+	}
+	retain := func(lvs []string) bool { return current[[2]string{lvs[0], lvs[1]}] }
+	p.budgetLimit.retain(retain)
+	p.budgetRatio.retain(retain)
+	p.budgetExceeded.retain(retain)
+}
+
+// SetNetworkRates sets the network error and drop rates of node.
+func (p *Prometheus) SetNetworkRates(node string, r aggregate.NetRates) {
+	p.netErrors.with(node, "receive").Set(r.RxErrors)
+	p.netErrors.with(node, "transmit").Set(r.TxErrors)
+	p.netDrops.with(node, "receive").Set(r.RxDrops)
+	p.netDrops.with(node, "transmit").Set(r.TxDrops)
+}
+
+// SetCadvisorSeries sets the size of node's last cAdvisor exposition.
+func (p *Prometheus) SetCadvisorSeries(node string, n int) {
+	p.cadvisorSeries.with(node).Set(float64(n))
+}
+
+// SetScrapeBytes sets the decompressed size of node's last payload and
+// the bytes transferred for it.
+func (p *Prometheus) SetScrapeBytes(node string, payload int, transferred int64) {
+	p.scrapeBytes.with(node).Set(float64(payload))
+	p.transferred.with(node).Set(float64(transferred))
+}
+
+// SetNodeFs sets the root filesystem usage of node.
+func (p *Prometheus) SetNodeFs(node string, fs collector.NodeFs) {
+	p.fsUsed.with(node).Set(fs.UsedBytes)
+	p.fsCapacity.with(node).Set(fs.CapacityBytes)
+}
+
+// AddNodeEnergy sets node's power draw and adds the interval's
+// energy to the node and namespace counters. Carbon counters are only
+// updated when gramsPerKWh is positive.
+func (p *Prometheus) AddNodeEnergy(node string, e aggregate.NodeEnergy, gramsPerKWh float64) {
+	p.nodePower.with(node).Set(e.Watts)
+	measured := 0.0
+	if e.Measured {
+		measured = 1
+	}
+	p.powerMeasured.with(node).Set(measured)
+	p.nodeJoules.WithLabelValues(node).Add(e.Joules)
+	for ns, j := range e.Namespaces {
+		p.namespaceJoules.WithLabelValues(ns).Add(j)
+	}
+	if gramsPerKWh <= 0 {
+		return
+	}
+	p.nodeCarbon.WithLabelValues(node).Add(aggregate.GramsCO2(e.Joules, gramsPerKWh))
+	for ns, j := range e.Namespaces {
+		p.namespaceCarbon.WithLabelValues(ns).Add(aggregate.GramsCO2(j, gramsPerKWh))
+	}
+}
+
+// SetCPUCredits replaces the CPU credit balances with credits, keyed by
+// node; nodes whose agent stopped reporting lose their series.
+func (p *Prometheus) SetCPUCredits(credits map[string]float64) {
+	for node, v := range credits {
+		p.cpuCredits.with(node).Set(v)
+	}
+	p.cpuCredits.retain(func(lvs []string) bool {
+		_, ok := credits[lvs[0]]
+		return ok
+	})
+}
+
+// SetCustomScores replaces k8s_node_custom_score with scores, keyed by
+// node.
+func (p *Prometheus) SetCustomScores(scores map[string]float64) {
+	for node, v := range scores {
+		p.customScore.with(node).Set(v)
+	}
+	p.customScore.retain(func(lvs []string) bool {
+		_, ok := scores[lvs[0]]
+		return ok
+	})
+}
+
+// SetGPUUsage replaces the GPU families with usage, keyed by node.
+func (p *Prometheus) SetGPUUsage(usage map[string]collector.GPUUsage) {
+	for node, u := range usage {
+		p.gpuUtil.with(node).Set(u.Utilization)
+		p.gpuMemUsed.with(node).Set(u.MemoryUsedBytes)
+	}
+	current := func(lvs []string) bool {
+		_, ok := usage[lvs[0]]
+		return ok
+	}
+	p.gpuUtil.retain(current)
+	p.gpuMemUsed.retain(current)
+}
+
+// SetHostSaturation replaces the node_exporter families with saturation,
+// keyed by node.
+func (p *Prometheus) SetHostSaturation(saturation map[string]aggregate.HostSaturation) {
+	for node, s := range saturation {
+		p.hostLoad.with(node, "1m").Set(s.Load1)
+		p.hostLoad.with(node, "5m").Set(s.Load5)
+		p.hostLoad.with(node, "15m").Set(s.Load15)
+		p.hostDiskBusy.with(node).Set(s.DiskBusy)
+		p.hostNetUtil.with(node).Set(s.NetworkUtilization)
+	}
+	current := func(lvs []string) bool {
+		_, ok := saturation[lvs[0]]
+		return ok
+	}
+	p.hostLoad.retain(current)
+	p.hostDiskBusy.retain(current)
+	p.hostNetUtil.retain(current)
+}
+
+// SetNamespaceCosts replaces the per-namespace scrape cost families with
+// costs.
+func (p *Prometheus) SetNamespaceCosts(costs map[string]aggregate.NamespaceCost) {
+	for ns, c := range costs {
+		p.costSeries.with(ns).Set(c.Series)
+		p.costBytes.with(ns).Set(c.Bytes)
+		p.costParse.with(ns).Set(c.ParseSeconds)
+	}
+	current := func(lvs []string) bool {
+		_, ok := costs[lvs[0]]
+		return ok
+	}
+	p.costSeries.retain(current)
+	p.costBytes.retain(current)
+	p.costParse.retain(current)
+}
+
+// AddNamespaceUsage adds a cycle's usage increases to the billing counters.
+// Like the scrape error counters they are kept for the life of the process,
+// so a namespace that briefly has no containers does not reset its total.
+func (p *Prometheus) AddNamespaceUsage(inc map[string]aggregate.UsageIncrease) {
+	for ns, u := range inc {
+		p.coreSeconds.WithLabelValues(ns).Add(u.CoreSeconds)
+		p.byteSeconds.WithLabelValues(ns).Add(u.ByteSeconds)
+		p.overheadCore.WithLabelValues(ns).Add(u.OverheadCoreSeconds)
+		p.overheadBytes.WithLabelValues(ns).Add(u.OverheadByteSeconds)
+	}
+}
+
+// AddContainerTypeUsage adds a cycle's usage increases per namespace and
+// container type, keyed by aggregate.ContainerTypeKey, to the container
+// type counters.
+func (p *Prometheus) AddContainerTypeUsage(inc map[string]aggregate.UsageIncrease) {
+	for key, u := range inc {
+		ns, typ := aggregate.SplitContainerTypeKey(key)
+		p.typeCore.WithLabelValues(ns, typ).Add(u.CoreSeconds)
+		p.typeBytes.WithLabelValues(ns, typ).Add(u.ByteSeconds)
+	}
+}
+
+// RetainNodes removes the node family series of nodes not in nodes, so
+// nodes that left the cluster stop being exported.
+func (p *Prometheus) RetainNodes(nodes map[string]bool) {
+	for _, c := range []*gaugeCache{p.nodeCPU, p.nodeMem, p.nodePods, p.workloadPods, p.maxPods, p.maxPodsRatio, p.nodeStale, p.lastScrape, p.netErrors, p.netDrops, p.cadvisorSeries, p.scrapeBytes, p.transferred, p.circuitOpen, p.unreachable, p.timedOut, p.fsUsed, p.fsCapacity, p.nodeConditions, p.nodePower, p.powerMeasured, p.cpuCredits, p.customScore, p.gpuUtil, p.gpuMemUsed, p.hostLoad, p.hostDiskBusy, p.hostNetUtil, p.interruption, p.interruptAt,
+		p.cloudInfo, p.capacityCPU, p.capacityMem, p.podIPs, p.podIPCapacity, p.podCIDRRatio, p.rcPods, p.rcCPU, p.rcMem, p.infraPods, p.infraCPU, p.infraMem,
+		p.nodeCPUWeek, p.nodeMemWeek, p.burnRate, p.alertFiring} {
+		c.retainNodes(nodes)
+	}
+}
+
+// SetConstraintViolations replaces the constraint violation counts with
+// counts, keyed by node and constraint kind.
+func (p *Prometheus) SetConstraintViolations(counts map[string]map[string]float64) {
+	current := make(map[string]bool, len(counts))
+	for node, byKind := range counts {
+		current[node] = true
+		for kind, n := range byKind {
+			p.constraintViolations.with(node, kind).Set(n)
+		}
+	}
+	p.constraintViolations.retainNodes(current)
+}
+
+// SetZoneSkews replaces the workload zone skew families with spreads.
+func (p *Prometheus) SetZoneSkews(spreads map[kube.Workload]aggregate.ZoneSpread) {
+	for w, s := range spreads {
+		p.zoneSkew.with(w.Namespace, w.Kind, w.Name).Set(float64(s.Skew))
+		violation := 0.0
+		if s.Violation {
+			violation = 1
+		}
+		p.zoneSkewViolation.with(w.Namespace, w.Kind, w.Name).Set(violation)
+	}
+	current := func(lvs []string) bool {
+		_, ok := spreads[kube.Workload{Namespace: lvs[0], Kind: lvs[1], Name: lvs[2]}]
+		return ok
+	}
+	p.zoneSkew.retain(current)
+	p.zoneSkewViolation.retain(current)
+}
+
+// SetFragmentation replaces the fragmentation families with pools and
+// cluster. Every pool has a stranded series for every reason.
+func (p *Prometheus) SetFragmentation(pools map[string]aggregate.Fragmentation, cluster aggregate.Fragmentation) {
+	for pool, f := range pools {
+		p.poolFragmentation.with(pool, "cpu").Set(f.CPURatio())
+		p.poolFragmentation.with(pool, "memory").Set(f.MemoryRatio())
+		for _, reason := range aggregate.StrandedReasons {
+			p.poolStrandedCPU.with(pool, reason).Set(f.StrandedCPU[reason])
+			p.poolStrandedMem.with(pool, reason).Set(f.StrandedMemory[reason])
+		}
+	}
+	current := func(lvs []string) bool {
+		_, ok := pools[lvs[0]]
+		return ok
+	}
+	p.poolFragmentation.retain(current)
+	p.poolStrandedCPU.retain(current)
+	p.poolStrandedMem.retain(current)
+	p.clusterFragmentation.with("cpu").Set(cluster.CPURatio())
+	p.clusterFragmentation.with("memory").Set(cluster.MemoryRatio())
+}
+
+// SetBatchQueues replaces the batch queue families with queues. A queue
+// whose wait is unknown has no wait series.
+func (p *Prometheus) SetBatchQueues(queues map[aggregate.QueueKey]aggregate.QueueStatus) {
+	for k, s := range queues {
+		p.queueWorkloads.with(k.Scheduler, k.Namespace, k.Queue).Set(float64(s.Workloads))
+		p.queuePods.with(k.Scheduler, k.Namespace, k.Queue).Set(float64(s.Pods))
+		p.queueCPU.with(k.Scheduler, k.Namespace, k.Queue).Set(s.CPUCores)
+		p.queueMem.with(k.Scheduler, k.Namespace, k.Queue).Set(s.MemoryBytes)
+		if s.WaitKnown {
+			p.queueWait.with(k.Scheduler, k.Namespace, k.Queue).Set(s.Wait.Seconds())
+		}
+	}
+	current := func(lvs []string) bool {
+		_, ok := queues[aggregate.QueueKey{Scheduler: lvs[0], Namespace: lvs[1], Queue: lvs[2]}]
+		return ok
+	}
+	p.queueWorkloads.retain(current)
+	p.queuePods.retain(current)
+	p.queueCPU.retain(current)
+	p.queueMem.retain(current)
+	p.queueWait.retain(func(lvs []string) bool {
+		s, ok := queues[aggregate.QueueKey{Scheduler: lvs[0], Namespace: lvs[1], Queue: lvs[2]}]
+		return ok && s.WaitKnown
+	})
+}
+
+// SetInterruption sets the interruption families of node to in.
+func (p *Prometheus) SetInterruption(node string, in cloud.Interruption) {
+	p.interruption.retain(func(lvs []string) bool { return lvs[0] != node || lvs[1] == in.Action })
+	if in.Pending {
+		p.interruption.with(node, in.Action).Set(1)
+	} else {
+		p.interruption.with(node, "").Set(0)
+	}
+	if in.At.IsZero() {
+		p.interruptAt.retain(func(lvs []string) bool { return lvs[0] != node })
+	} else {
+		p.interruptAt.with(node).Set(float64(in.At.Unix()))
+	}
+}
+
+// SetControlPlane replaces the control plane families with status, keyed
+// by pod, and adds the etcd leader changes to their counter.
+func (p *Prometheus) SetControlPlane(status map[string]aggregate.ControlPlaneStatus) {
+	for pod, s := range status {
+		if !s.Up {
+			p.controlPlaneUp.with(s.Component, pod).Set(0)
+			continue
+		}
+		p.controlPlaneUp.with(s.Component, pod).Set(1)
+		switch s.Component {
+		case collector.ComponentScheduler:
+			if !math.IsNaN(s.SchedulingP99) {
+				p.schedulingP99.with(pod).Set(s.SchedulingP99)
+			}
+		case collector.ComponentEtcd:
+			p.etcdDBSize.with(pod).Set(s.EtcdDBSizeBytes)
+			hasLeader := 0.0
+			if s.EtcdHasLeader {
+				hasLeader = 1
+			}
+			p.etcdHasLeader.with(pod).Set(hasLeader)
+			p.etcdLeaderChanges.WithLabelValues(pod).Add(s.EtcdLeaderChanges)
+		}
+	}
+	p.controlPlaneUp.retain(func(lvs []string) bool {
+		s, ok := status[lvs[1]]
+		return ok && s.Component == lvs[0]
+	})
+	p.schedulingP99.retain(func(lvs []string) bool {
+		s, ok := status[lvs[0]]
+		return ok && s.Up && s.Component == collector.ComponentScheduler && !math.IsNaN(s.SchedulingP99)
+	})
+	etcd := func(lvs []string) bool {
+		s, ok := status[lvs[0]]
+		return ok && s.Up && s.Component == collector.ComponentEtcd
+	}
+	p.etcdDBSize.retain(etcd)
+	p.etcdHasLeader.retain(etcd)
+}
+
+// SetDNSStats sets the cluster DNS families to s, or removes them when ok
+// is false.
+func (p *Prometheus) SetDNSStats(s aggregate.DNSStats, ok bool) {
+	if ok {
+		p.dnsRequests.with().Set(s.RequestsPerSecond)
+		p.dnsServFails.with().Set(s.ServFailsPerSecond)
+		p.dnsPods.with().Set(float64(s.Pods))
+	}
+	keep := func([]string) bool { return ok }
+	p.dnsRequests.retain(keep)
+	p.dnsServFails.retain(keep)
+	p.dnsPods.retain(keep)
+	if ok && !math.IsNaN(s.CacheHitRatio) {
+		p.dnsCacheHits.with().Set(s.CacheHitRatio)
+	} else {
+		p.dnsCacheHits.retain(func([]string) bool { return false })
+	}
+}
+
+// SetCustomSeries replaces the custom target series with sums.
+func (p *Prometheus) SetCustomSeries(sums map[collector.CustomSeries]float64) {
+	for k, v := range sums {
+		p.customSeries.with(k.Node, k.Job, k.Metric).Set(v)
+	}
+	p.customSeries.retain(func(lvs []string) bool {
+		_, ok := sums[collector.CustomSeries{Node: lvs[0], Job: lvs[1], Metric: lvs[2]}]
+		return ok
+	})
+}
+
+// SetExecSeries replaces the series of the exec collectors.
+func (p *Prometheus) SetExecSeries(series []collector.RawSeries) {
+	p.execSeries.update(series)
+}
+
+// SetAnnotatedSeries replaces the series of the prometheus.io annotated
+// pods.
+func (p *Prometheus) SetAnnotatedSeries(series []collector.RawSeries) {
+	p.annotatedSeries.update(series)
+}
+
+// SetPassthrough replaces the re-exported raw series.
+func (p *Prometheus) SetPassthrough(series []collector.RawSeries) {
+	p.passthrough.update(series)
+}
+
+// SetLabelOverflow sets the number of label values folded into the
+// overflow bucket per capped label.
+func (p *Prometheus) SetLabelOverflow(overflowed map[string]int) {
+	for label, n := range overflowed {
+		p.labelOverflow.WithLabelValues(label).Set(float64(n))
+	}
+}
+
+// SetMaintenanceWindows sets the number of maintenance windows in effect.
+func (p *Prometheus) SetMaintenanceWindows(n int) {
+	p.maintenance.Set(float64(n))
+}
+
+// ScrapeError counts a failed scrape of target, e.g. "cadvisor:<node>".
+func (p *Prometheus) ScrapeError(target string) {
+	p.scrapeErrors.WithLabelValues(target).Inc()
+}
+
+// ScrapeRetry counts a retried scrape of target.
+func (p *Prometheus) ScrapeRetry(target string) {
+	p.scrapeRetries.WithLabelValues(target).Inc()
+}
+
+// SetDegradationLevel sets the API server back-off level.
+func (p *Prometheus) SetDegradationLevel(level int) {
+	p.degradationLevel.Set(float64(level))
+}
+
+// SetVirtualNodes sets the number of virtual nodes seen.
+func (p *Prometheus) SetVirtualNodes(n int) {
+	p.virtualNodes.Set(float64(n))
+}
+
+// APIThrottled counts a throttled API server request of kind "server" or
+// "client".
+func (p *Prometheus) APIThrottled(kind string) {
+	p.apiThrottled.WithLabelValues(kind).Inc()
+}
+
+// CollectorPanic counts a panic recovered in collector.
+func (p *Prometheus) CollectorPanic(collector string) {
+	p.collectorPanics.WithLabelValues(collector).Inc()
+}
+
+// ObserveCollector records a run of the registered collector that took d
+// and returned err.
+func (p *Prometheus) ObserveCollector(collector string, d time.Duration, err error) {
+	p.collectorSeconds.WithLabelValues(collector).Set(d.Seconds())
+	if err != nil {
+		p.collectorErrors.WithLabelValues(collector).Inc()
+	} else {
+		// Export the series from the first run.
+		p.collectorErrors.WithLabelValues(collector)
+	}
+}
+
+// NotificationError counts an alert notification notifier failed to
+// deliver.
+func (p *Prometheus) NotificationError(notifier string) {
+	p.notifyErrors.WithLabelValues(notifier).Inc()
+}
+
+// SetSinkHealthy records the result of a health check of sink.
+func (p *Prometheus) SetSinkHealthy(sink string, healthy bool) {
+	v := 0.0
+	if healthy {
+		v = 1
+	}
+	p.sinkHealthy.WithLabelValues(sink).Set(v)
+}
+
+// FaultInjected counts a fault injected into a scrape, e.g. "failure".
+func (p *Prometheus) FaultInjected(kind string) {
+	p.faultsInjected.WithLabelValues(kind).Inc()
+}
+
+// ObserveCycle exports the phase breakdown of a finished cycle and flags it
+// when it took longer than interval, since the next tick is then already
+// overdue.
+func (p *Prometheus) ObserveCycle(cycle string, b *collector.Budget, interval time.Duration) {
+	if b == nil {
+		return
+	}
+	total := b.Elapsed()
+	for phase, d := range b.Phases() {
+		p.cyclePhaseSeconds.WithLabelValues(cycle, phase).Set(d.Seconds())
+	}
+	p.cycleSeconds.WithLabelValues(cycle).Set(total.Seconds())
+	over := 0.0
+	if interval > 0 && total > interval {
+		over = 1
+		log.Printf("%s cycle took %s, longer than its %s interval (%s)", cycle, total.Round(time.Millisecond), interval, b.Summary())
+	}
+	p.cycleOverBudget.WithLabelValues(cycle).Set(over)
+}
+
+// SetCycleInterval sets the interval cycle currently runs at.
+func (p *Prometheus) SetCycleInterval(cycle string, interval time.Duration) {
+	p.cycleInterval.WithLabelValues(cycle).Set(interval.Seconds())
+}
+
+// SetStuck flags whether cycle is stuck.
+func (p *Prometheus) SetStuck(cycle string, stuck bool) {
+	v := 0.0
+	if stuck {
+		v = 1
+	}
+	p.stuck.WithLabelValues(cycle).Set(v)
+}
@@ -0,0 +1,71 @@
+package sink
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/your-org/k8s-ai-exporter/collector"
+)
+
+// rawCollector re-exports the latest snapshot of passthrough or exec
+// collector series as untyped metrics, with help as the help of every
+// family. Label names are unified per family so every series of a family
+// has the same dimensions.
+type rawCollector struct {
+	help   string
+	mu     sync.Mutex
+	series []collector.RawSeries
+}
+
+func (c *rawCollector) update(series []collector.RawSeries) {
+	c.mu.Lock()
+	c.series = series
+	c.mu.Unlock()
+}
+
+// Describe sends nothing: the set of families depends on the allowlist and
+// the scraped data, so this is an unchecked collector.
+func (c *rawCollector) Describe(chan<- *prometheus.Desc) {}
+
+func (c *rawCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	series := c.series
+	c.mu.Unlock()
+
+	families := make(map[string][]collector.RawSeries)
+	for _, s := range series {
+		families[s.Name] = append(families[s.Name], s)
+	}
+	for name, fam := range families {
+		keys := familyLabelNames(fam)
+		desc := prometheus.NewDesc(name, c.help, keys, nil)
+		for _, s := range fam {
+			values := make([]string, len(keys))
+			for i, k := range keys {
+				values[i] = s.Labels[k]
+			}
+			m, err := prometheus.NewConstMetric(desc, prometheus.UntypedValue, s.Value, values...)
+			if err != nil {
+				continue
+			}
+			ch <- m
+		}
+	}
+}
+
+func familyLabelNames(fam []collector.RawSeries) []string {
+	set := make(map[string]bool)
+	for _, s := range fam {
+		for k := range s.Labels {
+			set[k] = true
+		}
+	}
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,127 @@
+package timeline
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Window is a declared maintenance window. While one is in effect, alerts
+// and forecasts are suppressed and its samples are left out of baselines,
+// so planned disruption such as chaos drills does not skew them.
+type Window struct {
+	ID     int64     `json:"id,omitempty" doc:"Assigned by the exporter; ignored on create."`
+	Start  time.Time `json:"start" doc:"Start of the window, inclusive."`
+	End    time.Time `json:"end" doc:"End of the window, exclusive."`
+	Reason string    `json:"reason,omitempty" doc:"Why, e.g. chaos drill or node pool upgrade."`
+	Source string    `json:"source,omitempty" doc:"Who declared the window; config for windows from -maintenance-windows."`
+}
+
+// Validate reports whether w can be added.
+func (w Window) Validate() error {
+	if w.Start.IsZero() || w.End.IsZero() {
+		return errors.New("start and end are required")
+	}
+	if !w.End.After(w.Start) {
+		return errors.New("end must be after start")
+	}
+	return nil
+}
+
+// Contains reports whether t falls inside w.
+func (w Window) Contains(t time.Time) bool {
+	return !t.Before(w.Start) && t.Before(w.End)
+}
+
+// Schedule holds maintenance windows ordered by start. It is safe for
+// concurrent use.
+type Schedule struct {
+	mu      sync.RWMutex
+	max     int
+	nextID  int64
+	windows []Window
+}
+
+// NewSchedule returns a schedule that keeps at most max windows, dropping
+// the one that ended first.
+func NewSchedule(max int) *Schedule {
+	return &Schedule{max: max, nextID: 1}
+}
+
+// Add validates w, assigns its ID and inserts it in start order.
+func (s *Schedule) Add(w Window) (Window, error) {
+	if err := w.Validate(); err != nil {
+		return Window{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w.ID = s.nextID
+	s.nextID++
+	s.windows = append(s.windows, w)
+	if s.max > 0 && len(s.windows) > s.max {
+		first := 0
+		for i, x := range s.windows {
+			if x.End.Before(s.windows[first].End) {
+				first = i
+			}
+		}
+		s.windows = append(s.windows[:first], s.windows[first+1:]...)
+	}
+	sort.SliceStable(s.windows, func(i, j int) bool { return s.windows[i].Start.Before(s.windows[j].Start) })
+	return w, nil
+}
+
+// All returns every retained window ordered by start.
+func (s *Schedule) All() []Window {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]Window{}, s.windows...)
+}
+
+// Active returns the windows that contain t.
+func (s *Schedule) Active(t time.Time) []Window {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var out []Window
+	for _, w := range s.windows {
+		if w.Contains(t) {
+			out = append(out, w)
+		}
+	}
+	return out
+}
+
+// ParseWindows parses the -maintenance-windows flag: semicolon-separated
+// "<start>/<end>[=<reason>]" entries with RFC 3339 times, e.g.
+// "2024-05-01T02:00:00Z/2024-05-01T04:00:00Z=chaos drill".
+func ParseWindows(spec string) ([]Window, error) {
+	var out []Window
+	for _, entry := range strings.Split(spec, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		span, reason, _ := strings.Cut(entry, "=")
+		start, end, ok := strings.Cut(span, "/")
+		if !ok {
+			return nil, fmt.Errorf("maintenance window %q: want <start>/<end>", entry)
+		}
+		var w Window
+		var err error
+		if w.Start, err = time.Parse(time.RFC3339, strings.TrimSpace(start)); err != nil {
+			return nil, fmt.Errorf("maintenance window %q: %v", entry, err)
+		}
+		if w.End, err = time.Parse(time.RFC3339, strings.TrimSpace(end)); err != nil {
+			return nil, fmt.Errorf("maintenance window %q: %v", entry, err)
+		}
+		if err := w.Validate(); err != nil {
+			return nil, fmt.Errorf("maintenance window %q: %v", entry, err)
+		}
+		w.Reason = strings.TrimSpace(reason)
+		out = append(out, w)
+	}
+	return out, nil
+}
@@ -0,0 +1,61 @@
+package timeline
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseWindows(t *testing.T) {
+	got, err := ParseWindows("2024-05-01T02:00:00Z/2024-05-01T04:00:00Z=chaos drill; 2024-05-02T00:00:00+02:00/2024-05-02T01:00:00+02:00")
+	if err != nil {
+		t.Fatalf("ParseWindows: %v", err)
+	}
+	if len(got) != 2 || got[0].Reason != "chaos drill" || got[1].Reason != "" ||
+		!got[1].Start.Equal(time.Date(2024, 5, 1, 22, 0, 0, 0, time.UTC)) {
+		t.Errorf("ParseWindows = %+v", got)
+	}
+
+	for _, bad := range []string{
+		"2024-05-01T02:00:00Z",
+		"2024-05-01T02:00:00Z/tomorrow",
+		"2024-05-01T04:00:00Z/2024-05-01T02:00:00Z",
+	} {
+		if _, err := ParseWindows(bad); err == nil {
+			t.Errorf("ParseWindows(%q): expected error", bad)
+		}
+	}
+}
+
+func TestScheduleActiveAndRetention(t *testing.T) {
+	base := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	s := NewSchedule(2)
+	for _, h := range []int{10, 0, 5} {
+		if _, err := s.Add(Window{Start: base.Add(time.Duration(h) * time.Hour), End: base.Add(time.Duration(h+2) * time.Hour)}); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+	}
+
+	all := s.All()
+	if len(all) != 2 || !all[0].Start.Equal(base.Add(5*time.Hour)) || !all[1].Start.Equal(base.Add(10*time.Hour)) {
+		t.Errorf("All = %+v, want the windows at 5h and 10h", all)
+	}
+
+	tests := []struct {
+		at   time.Duration
+		want int
+	}{
+		{1 * time.Hour, 0}, // dropped by retention
+		{5 * time.Hour, 1},
+		{7 * time.Hour, 0}, // end is exclusive
+		{11 * time.Hour, 1},
+	}
+	for _, tt := range tests {
+		if got := s.Active(base.Add(tt.at)); len(got) != tt.want {
+			t.Errorf("Active(%v) = %+v, want %d windows", tt.at, got, tt.want)
+		}
+	}
+
+	if _, err := s.Add(Window{Start: base}); err == nil {
+		t.Error("Add without end: expected error")
+	}
+}
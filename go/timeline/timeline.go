@@ -0,0 +1,99 @@
+// Package timeline keeps recent external events (deploy markers, incident
+// start/end) so usage shifts can be correlated with what happened in the
+// cluster at the time, and the maintenance windows during which alerts and
+// forecasts are suppressed.
+package timeline
+
+import (
+	"errors"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Well-known event types. Other lower_snake_case types are accepted.
+const (
+	TypeDeploy        = "deploy"
+	TypeIncidentStart = "incident_start"
+	TypeIncidentEnd   = "incident_end"
+)
+
+// Event is one external event.
+type Event struct {
+	ID        int64             `json:"id,omitempty" doc:"Assigned by the exporter; ignored on create."`
+	Time      time.Time         `json:"time,omitempty" doc:"When the event happened; defaults to the time it was received."`
+	Type      string            `json:"type" doc:"deploy, incident_start, incident_end or another lower_snake_case type."`
+	Title     string            `json:"title"`
+	Source    string            `json:"source,omitempty" doc:"Sender, e.g. argocd or pagerduty."`
+	Namespace string            `json:"namespace,omitempty" doc:"Namespace the event applies to; empty for cluster-wide events."`
+	Labels    map[string]string `json:"labels,omitempty"`
+}
+
+var typePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// Validate reports whether e can be added.
+func (e Event) Validate() error {
+	if !typePattern.MatchString(e.Type) {
+		return errors.New("type must be lower_snake_case")
+	}
+	if e.Title == "" {
+		return errors.New("title is required")
+	}
+	return nil
+}
+
+// Timeline holds the most recent events ordered by time. It is safe for
+// concurrent use.
+type Timeline struct {
+	mu     sync.RWMutex
+	max    int
+	nextID int64
+	events []Event
+}
+
+// New returns a timeline that keeps at most max events, dropping the oldest.
+func New(max int) *Timeline {
+	return &Timeline{max: max, nextID: 1}
+}
+
+// Add validates e, assigns its ID (and Time when zero, using now) and
+// inserts it in time order.
+func (t *Timeline) Add(e Event, now time.Time) (Event, error) {
+	if err := e.Validate(); err != nil {
+		return Event{}, err
+	}
+	if e.Time.IsZero() {
+		e.Time = now
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	e.ID = t.nextID
+	t.nextID++
+	i := sort.Search(len(t.events), func(i int) bool { return t.events[i].Time.After(e.Time) })
+	t.events = append(t.events, Event{})
+	copy(t.events[i+1:], t.events[i:])
+	t.events[i] = e
+	if t.max > 0 && len(t.events) > t.max {
+		t.events = append(t.events[:0], t.events[len(t.events)-t.max:]...)
+	}
+	return e, nil
+}
+
+// Between returns the events with from <= Time < to, oldest first. A zero
+// from or to leaves that side open.
+func (t *Timeline) Between(from, to time.Time) []Event {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	out := []Event{}
+	for _, e := range t.events {
+		if !from.IsZero() && e.Time.Before(from) {
+			continue
+		}
+		if !to.IsZero() && !e.Time.Before(to) {
+			break
+		}
+		out = append(out, e)
+	}
+	return out
+}
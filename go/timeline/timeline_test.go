@@ -0,0 +1,59 @@
+package timeline
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimelineOrderAndRetention(t *testing.T) {
+	tl := New(3)
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for i, offset := range []int{10, 0, 20, 5} {
+		e := Event{Type: TypeDeploy, Title: "rollout", Time: base.Add(time.Duration(offset) * time.Minute)}
+		got, err := tl.Add(e, base)
+		if err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+		if got.ID != int64(i+1) {
+			t.Errorf("ID = %d, want %d", got.ID, i+1)
+		}
+	}
+
+	got := tl.Between(time.Time{}, time.Time{})
+	var offsets []time.Duration
+	for _, e := range got {
+		offsets = append(offsets, e.Time.Sub(base))
+	}
+	want := []time.Duration{5 * time.Minute, 10 * time.Minute, 20 * time.Minute}
+	if len(offsets) != len(want) {
+		t.Fatalf("events at %v, want %v", offsets, want)
+	}
+	for i := range want {
+		if offsets[i] != want[i] {
+			t.Errorf("events at %v, want %v", offsets, want)
+			break
+		}
+	}
+
+	if got := tl.Between(base.Add(10*time.Minute), base.Add(20*time.Minute)); len(got) != 1 || got[0].Time != base.Add(10*time.Minute) {
+		t.Errorf("Between(10m, 20m) = %+v", got)
+	}
+}
+
+func TestAddDefaultsAndValidation(t *testing.T) {
+	tl := New(0)
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	e, err := tl.Add(Event{Type: TypeIncidentStart, Title: "checkout errors"}, now)
+	if err != nil || !e.Time.Equal(now) {
+		t.Errorf("Add without time = %+v, %v; want time %v", e, err, now)
+	}
+	for _, bad := range []Event{
+		{Type: "Deploy", Title: "x"},
+		{Type: "deploy marker", Title: "x"},
+		{Type: TypeDeploy},
+	} {
+		if _, err := tl.Add(bad, now); err == nil {
+			t.Errorf("Add(%+v): expected error", bad)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+// Package tlspolicy applies a configured TLS minimum version and cipher
+// suite list to the exporter's listeners and its API server client, for
+// environments that mandate a TLS policy.
+package tlspolicy
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Policy is a TLS minimum version and, optionally, the allowed TLS 1.2
+// cipher suites. TLS 1.3 suites are not configurable in Go and are always
+// the standard ones.
+type Policy struct {
+	MinVersion   uint16
+	CipherSuites []uint16 // nil allows Go's defaults
+}
+
+// Default is the policy without -tls-min-version and -tls-cipher-suites:
+// TLS 1.2 or later with Go's default suites.
+var Default = Policy{MinVersion: tls.VersionTLS12}
+
+var versions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// Parse parses a minimum version such as "1.2" (empty means 1.2) and a
+// comma-separated list of cipher suite names as in crypto/tls, e.g.
+// "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256". Suites Go considers insecure
+// are rejected.
+func Parse(minVersion, cipherSuites string) (Policy, error) {
+	p := Policy{MinVersion: tls.VersionTLS12}
+	if minVersion != "" {
+		v, ok := versions[minVersion]
+		if !ok {
+			return Policy{}, fmt.Errorf("unknown TLS version %q, want one of 1.0, 1.1, 1.2, 1.3", minVersion)
+		}
+		p.MinVersion = v
+	}
+	ids := make(map[string]uint16)
+	for _, s := range tls.CipherSuites() {
+		ids[s.Name] = s.ID
+	}
+	for _, name := range strings.Split(cipherSuites, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		id, ok := ids[name]
+		if !ok {
+			return Policy{}, fmt.Errorf("unknown or insecure cipher suite %q", name)
+		}
+		p.CipherSuites = append(p.CipherSuites, id)
+	}
+	return p, nil
+}
+
+// Apply sets the policy on c.
+func (p Policy) Apply(c *tls.Config) {
+	c.MinVersion = p.MinVersion
+	if p.CipherSuites != nil {
+		c.CipherSuites = append([]uint16(nil), p.CipherSuites...)
+	}
+}
+
+// Config returns a new tls.Config with the policy applied.
+func (p Policy) Config() *tls.Config {
+	c := &tls.Config{}
+	p.Apply(c)
+	return c
+}
+
+// HTTPClient returns a client for outbound requests, such as alert
+// webhooks, whose connections follow the policy. It otherwise behaves like
+// http.DefaultClient, including proxy settings from the environment.
+func (p Policy) HTTPClient() *http.Client {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.TLSClientConfig = p.Config()
+	return &http.Client{Transport: t}
+}
@@ -0,0 +1,46 @@
+package tlspolicy
+
+import (
+	"crypto/tls"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	p, err := Parse("1.3", " TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384")
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	want := Policy{MinVersion: tls.VersionTLS13, CipherSuites: []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384}}
+	if !reflect.DeepEqual(p, want) {
+		t.Errorf("Parse = %+v, want %+v", p, want)
+	}
+	if p, _ := Parse("", ""); p.MinVersion != tls.VersionTLS12 || p.CipherSuites != nil {
+		t.Errorf("default policy = %+v, want TLS 1.2 and Go's suites", p)
+	}
+	for _, bad := range [][2]string{{"1.4", ""}, {"", "TLS_RSA_WITH_RC4_128_SHA"}, {"", "NOPE"}} {
+		if _, err := Parse(bad[0], bad[1]); err == nil {
+			t.Errorf("Parse(%q, %q): expected error", bad[0], bad[1])
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	c := &tls.Config{ServerName: "api"}
+	Policy{MinVersion: tls.VersionTLS13}.Apply(c)
+	if c.MinVersion != tls.VersionTLS13 || c.CipherSuites != nil || c.ServerName != "api" {
+		t.Errorf("Apply = %+v", c)
+	}
+}
+
+func TestHTTPClient(t *testing.T) {
+	c := Policy{MinVersion: tls.VersionTLS13}.HTTPClient()
+	tr, ok := c.Transport.(*http.Transport)
+	if !ok || tr.TLSClientConfig == nil || tr.TLSClientConfig.MinVersion != tls.VersionTLS13 {
+		t.Fatalf("transport = %+v, want TLS 1.3 minimum", c.Transport)
+	}
+	if tr == http.DefaultTransport {
+		t.Error("HTTPClient modified the default transport")
+	}
+}
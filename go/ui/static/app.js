@@ -0,0 +1,130 @@
+// Draws the page from /api/v1/heatmap, /api/v1/history and /api/v1/events. Everything is
+// recomputed on every refresh; the history is small enough for that.
+"use strict";
+
+const refreshMillis = 30000;
+const colors = ["#1f77b4", "#ff7f0e", "#2ca02c", "#d62728", "#9467bd"];
+
+async function getJSON(path) {
+  const resp = await fetch(path);
+  if (!resp.ok) {
+    throw new Error(path + ": " + resp.status);
+  }
+  return resp.json();
+}
+
+function el(tag, attrs, text) {
+  const e = document.createElementNS(tag === "svg" || tag === "polyline" ? "http://www.w3.org/2000/svg" : "http://www.w3.org/1999/xhtml", tag);
+  for (const [k, v] of Object.entries(attrs || {})) {
+    e.setAttribute(k, v);
+  }
+  if (text !== undefined) {
+    e.textContent = text;
+  }
+  return e;
+}
+
+// heatmap fills table with one row per node and one cell per timestamp,
+// shaded by utilization of allocatable.
+function heatmap(table, h) {
+  table.replaceChildren();
+  h.nodes.forEach((name, i) => {
+    const row = el("tr");
+    row.append(el("th", {}, name));
+    h.values[i].forEach((v, j) => {
+      const cell = el("td");
+      if (v !== null) {
+        cell.style.background = "rgba(214, 39, 40, " + Math.min(v, 1).toFixed(2) + ")";
+        cell.title = new Date(h.timestamps[j]).toLocaleTimeString() + " " + (v * 100).toFixed(0) + "%";
+      }
+      row.append(cell);
+    });
+    table.append(row);
+  });
+}
+
+// trends draws the CPU usage of the busiest namespaces as lines.
+function trends(svg, legend, snapshots) {
+  svg.replaceChildren();
+  legend.replaceChildren();
+  const total = new Map();
+  for (const s of snapshots) {
+    for (const ns of s.namespaces || []) {
+      total.set(ns.name, (total.get(ns.name) || 0) + ns.cpuCores);
+    }
+  }
+  const names = [...total.keys()].sort((a, b) => total.get(b) - total.get(a)).slice(0, colors.length);
+  let top = 0;
+  for (const s of snapshots) {
+    for (const ns of s.namespaces || []) {
+      if (names.includes(ns.name)) {
+        top = Math.max(top, ns.cpuCores);
+      }
+    }
+  }
+  const width = svg.width.baseVal.value;
+  const height = svg.height.baseVal.value;
+  names.forEach((name, i) => {
+    const points = snapshots.map((s, j) => {
+      const ns = (s.namespaces || []).find((ns) => ns.name === name);
+      const x = snapshots.length > 1 ? (j / (snapshots.length - 1)) * width : 0;
+      const y = height - (top > 0 && ns ? (ns.cpuCores / top) * (height - 4) : 0) - 2;
+      return x.toFixed(1) + "," + y.toFixed(1);
+    });
+    svg.append(el("polyline", { points: points.join(" "), fill: "none", stroke: colors[i], "stroke-width": 2 }));
+    const item = el("span");
+    const swatch = el("i");
+    swatch.style.background = colors[i];
+    item.append(swatch, name);
+    legend.append(item);
+  });
+}
+
+function events(table, list) {
+  table.replaceChildren();
+  if (list.length === 0) {
+    table.append(el("tr", {}, "No events in this window."));
+    return;
+  }
+  for (const e of list.slice().reverse()) {
+    const row = el("tr");
+    row.append(
+      el("td", {}, new Date(e.time).toLocaleString()),
+      el("td", {}, e.type),
+      el("td", {}, e.namespace || ""),
+      el("td", {}, e.title),
+    );
+    table.append(row);
+  }
+}
+
+async function refresh() {
+  const window = document.getElementById("window").value;
+  const status = document.getElementById("status");
+  try {
+    const since = new Date(Date.now() - parseDuration(window)).toISOString();
+    const [cpu, memory, history, list] = await Promise.all([
+      getJSON("/api/v1/heatmap?resource=cpu&window=" + window),
+      getJSON("/api/v1/heatmap?resource=memory&window=" + window),
+      getJSON("/api/v1/history?window=" + window),
+      getJSON("/api/v1/events?since=" + encodeURIComponent(since)),
+    ]);
+    const snapshots = history.snapshots;
+    heatmap(document.getElementById("cpu"), cpu);
+    heatmap(document.getElementById("memory"), memory);
+    trends(document.getElementById("trends"), document.getElementById("trends-legend"), snapshots);
+    events(document.getElementById("events"), list.events);
+    status.textContent = snapshots.length + " snapshots, updated " + new Date().toLocaleTimeString();
+  } catch (err) {
+    status.textContent = "refresh failed: " + err.message;
+  }
+}
+
+function parseDuration(s) {
+  const n = parseFloat(s);
+  return s.endsWith("h") ? n * 3600000 : n * 60000;
+}
+
+document.getElementById("window").addEventListener("change", refresh);
+refresh();
+setInterval(refresh, refreshMillis);
@@ -0,0 +1,23 @@
+// Package ui serves the exporter's read-only web UI: a single page that
+// draws node heatmaps, namespace usage trends and recent events from the
+// JSON API. Its assets are embedded in the binary.
+package ui
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+//go:embed static
+var static embed.FS
+
+// Handler serves the UI's assets, index.html at the root. Mount it with
+// http.StripPrefix; the page calls the API at /api/v1 on the same host.
+func Handler() http.Handler {
+	sub, err := fs.Sub(static, "static")
+	if err != nil {
+		panic(err) // the embedded directory always exists
+	}
+	return http.FileServer(http.FS(sub))
+}
@@ -0,0 +1,22 @@
+package ui
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandler(t *testing.T) {
+	h := Handler()
+	for path, want := range map[string]string{
+		"/":       "<title>k8s-ai-exporter</title>",
+		"/app.js": "/api/v1/heatmap",
+	} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("GET %s = %d, body does not contain %q", path, rec.Code, want)
+		}
+	}
+}
@@ -2,14 +2,45 @@
 """
 AI Agent: pulls metrics from Prometheus, runs trend prediction for CPU/memory,
 and prints optimization suggestions. Safe to run as CronJob in-cluster.
+
+Maintenance windows declared on the exporter are honoured: no forecast is
+made while one is in effect, and samples inside past windows are left out.
+
+With business hours configured, daytime usage weighs more in the mean that
+decides whether a node is underutilized, so idle nights alone do not size
+a node down.
+
+The report can also be POSTed to a webhook on every run and emailed once a
+day over SMTP. The day of the last email is kept in a ConfigMap, so a late
+or failed run still sends it and a retried run does not send it twice.
+With EXPLAIN_CHANGES the last run's statuses are kept in a ConfigMap too, and
+a recommendation that changed since then says why.
 """
+import hashlib
+import hmac
+import html
+import json
 import os
+import re
+import smtplib
+import ssl
 import sys
-from datetime import datetime, timedelta
+import time
+import urllib.error
+import urllib.request
+from dataclasses import dataclass
+from datetime import datetime, time as dtime, timedelta, timezone
+from email.message import EmailMessage
+from string import Template
+from typing import List, Optional
+from zoneinfo import ZoneInfo, ZoneInfoNotFoundError
 
+import numpy as np
 import pandas as pd
 from prometheus_api_client import PrometheusConnect, MetricRangeDataFrame
 
+from binbots_client import APIError, Client, Window
+
 # Optional: use Prophet if installed (heavier dependency)
 try:
     from prophet import Prophet
@@ -22,6 +53,51 @@ LOOKBACK_MINUTES = int(os.getenv("LOOKBACK_MINUTES", "120"))
 FORECAST_MINUTES = int(os.getenv("FORECAST_MINUTES", "30"))
 CPU_QUERY = os.getenv("CPU_QUERY", "k8s_node_cpu_usage_cores")
 MEM_QUERY = os.getenv("MEM_QUERY", "k8s_node_memory_usage_bytes")
+EXPORTER_URL = os.getenv("EXPORTER_URL", "http://k8s-ai-exporter.monitoring.svc:9100")
+REPORT_WEBHOOK_URL = os.getenv("REPORT_WEBHOOK_URL", "")
+REPORT_WEBHOOK_SECRET = os.getenv("REPORT_WEBHOOK_SECRET", "")
+SMTP_HOST = os.getenv("SMTP_HOST", "")
+SMTP_PORT = int(os.getenv("SMTP_PORT", "587"))
+SMTP_USERNAME = os.getenv("SMTP_USERNAME", "")
+SMTP_PASSWORD = os.getenv("SMTP_PASSWORD", "")
+SMTP_STARTTLS = os.getenv("SMTP_STARTTLS", "true").lower() != "false"
+REPORT_EMAIL_FROM = os.getenv("REPORT_EMAIL_FROM", "binbots@localhost")
+REPORT_EMAIL_TO = [a.strip() for a in os.getenv("REPORT_EMAIL_TO", "").split(",") if a.strip()]
+# The email goes out on the first run at or after REPORT_EMAIL_AT (UTC) that
+# finds no email sent for that day in the REPORT_EMAIL_STATE_CONFIGMAP.
+REPORT_EMAIL_AT = os.getenv("REPORT_EMAIL_AT", "07:00")
+REPORT_EMAIL_STATE_CONFIGMAP = os.getenv("REPORT_EMAIL_STATE_CONFIGMAP", "k8s-ai-agent-state")
+# Sizing policies evaluated side by side, e.g. "p95+15%;p99": each sizes a
+# node to a percentile of its lookback plus headroom. Capacities for the
+# savings come from CPU_CAPACITY_QUERY and MEM_CAPACITY_QUERY.
+POLICIES = os.getenv("POLICIES", "")
+CPU_CAPACITY_QUERY = os.getenv("CPU_CAPACITY_QUERY", "k8s_node_capacity_cpu_cores")
+MEM_CAPACITY_QUERY = os.getenv("MEM_CAPACITY_QUERY", "k8s_node_capacity_memory_bytes")
+# With EXPLAIN_CHANGES, each run's statuses and forecasts are kept in the
+# RECOMMENDATION_STATE_CONFIGMAP, and a recommendation whose status differs
+# from the previous run's says why.
+EXPLAIN_CHANGES = os.getenv("EXPLAIN_CHANGES", "false").lower() == "true"
+RECOMMENDATION_STATE_CONFIGMAP = os.getenv("RECOMMENDATION_STATE_CONFIGMAP", "k8s-ai-agent-recommendations")
+# A forecast that moved by more than this fraction since the previous run
+# counts as a reason for a changed recommendation.
+CHANGE_THRESHOLD = 0.1
+# The latest sample of a node is an anomaly when it is at least this many
+# standard deviations from the rest of the lookback.
+ANOMALY_Z_SCORE = float(os.getenv("ANOMALY_Z_SCORE", "3"))
+# Samples inside BUSINESS_HOURS ("HH:MM-HH:MM", Monday to Friday in
+# BUSINESS_HOURS_TZ) count BUSINESS_HOURS_WEIGHT times in the mean that
+# decides whether a node is underutilized. Empty turns the weighting off.
+BUSINESS_HOURS = os.getenv("BUSINESS_HOURS", "")
+BUSINESS_HOURS_TZ = os.getenv("BUSINESS_HOURS_TZ", "UTC")
+BUSINESS_HOURS_WEIGHT = float(os.getenv("BUSINESS_HOURS_WEIGHT", "3"))
+# A node's CPU or memory with less than MIN_HISTORY_MINUTES between its first
+# and last sample gets no recommendation.
+MIN_HISTORY_MINUTES = int(os.getenv("MIN_HISTORY_MINUTES", "60"))
+SERVICE_ACCOUNT_DIR = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+SIGNATURE_HEADER = "X-Binbots-Signature"
+TIMESTAMP_HEADER = "X-Binbots-Timestamp"
+SIGNATURE_TOLERANCE_SECONDS = 300
 
 
 def fetch_timeseries(prom: PrometheusConnect, query: str) -> pd.DataFrame:
@@ -39,6 +115,153 @@ def fetch_timeseries(prom: PrometheusConnect, query: str) -> pd.DataFrame:
     return MetricRangeDataFrame(metric_data)
 
 
+def fetch_maintenance_windows(url: str) -> List[Window]:
+    try:
+        return Client(url).list_maintenance_windows().windows
+    except (APIError, OSError) as e:
+        print(f"Cannot read maintenance windows from {url} ({e}); forecasting without them.")
+        return []
+
+
+def active_window(windows: List[Window], now: pd.Timestamp) -> Optional[Window]:
+    for w in windows:
+        if pd.Timestamp(w.start) <= now < pd.Timestamp(w.end):
+            return w
+    return None
+
+
+def _sample_times(ts: pd.DataFrame) -> pd.DatetimeIndex:
+    return pd.DatetimeIndex(pd.to_datetime(ts["ds"] if "ds" in ts.columns else ts.index, utc=True))
+
+
+def exclude_windows(ts: pd.DataFrame, windows: List[Window]) -> pd.DataFrame:
+    """Drop samples inside maintenance windows so planned disruption does not shape the forecast."""
+    if ts.empty or not windows:
+        return ts
+    times = _sample_times(ts)
+    keep = np.ones(len(ts), dtype=bool)
+    for w in windows:
+        keep &= ~((times >= pd.Timestamp(w.start)) & (times < pd.Timestamp(w.end)))
+    return ts[keep]
+
+
+@dataclass
+class BusinessHours:
+    """Daily hours, Monday to Friday in tz, whose usage counts weight times.
+    end before start spans midnight, e.g. 22:00-06:00 for a night shift."""
+    start: dtime
+    end: dtime
+    tz: ZoneInfo
+    weight: float
+
+    @classmethod
+    def parse(cls, hours: str, tz: str, weight: float) -> "BusinessHours":
+        start, sep, end = hours.partition("-")
+        if not sep:
+            raise ValueError(f"business hours {hours!r} are not HH:MM-HH:MM")
+        if weight < 1:
+            raise ValueError(f"business hours weight {weight} is below 1")
+        try:
+            zone = ZoneInfo(tz)
+        except (ZoneInfoNotFoundError, ValueError):
+            raise ValueError(f"unknown time zone {tz!r}") from None
+        return cls(dtime.fromisoformat(start.strip()), dtime.fromisoformat(end.strip()), zone, weight)
+
+    def contains(self, times: pd.DatetimeIndex) -> np.ndarray:
+        local = times.tz_convert(self.tz)
+        minutes = local.hour * 60 + local.minute
+        start = self.start.hour * 60 + self.start.minute
+        end = self.end.hour * 60 + self.end.minute
+        if start <= end:
+            inside = (minutes >= start) & (minutes < end)
+        else:
+            inside = (minutes >= start) | (minutes < end)
+        return np.asarray(inside & (local.dayofweek < 5))
+
+    def weighted_mean(self, ts: pd.DataFrame) -> float:
+        """Mean of ts with samples inside business hours counted weight times."""
+        weights = np.where(self.contains(_sample_times(ts)), self.weight, 1.0)
+        return float(np.average(ts["value"].astype(float).to_numpy(), weights=weights))
+
+
+POLICY_RE = re.compile(r"p(\d+(?:\.\d+)?)(?:\+(\d+(?:\.\d+)?)%)?")
+
+
+@dataclass
+class Policy:
+    """Sizes a node to the quantile of its samples plus headroom, e.g. p95+15%."""
+    name: str
+    quantile: float
+    headroom: float
+
+    @classmethod
+    def parse(cls, spec: str) -> "Policy":
+        m = POLICY_RE.fullmatch(spec.strip())
+        if not m or not 0 < float(m.group(1)) <= 100:
+            raise ValueError(f"policy {spec!r} is not p<percentile>[+<headroom>%]")
+        return cls(spec.strip(), float(m.group(1)) / 100, float(m.group(2) or 0) / 100)
+
+    def evaluate(self, values: np.ndarray, capacity: Optional[float]) -> dict:
+        """The size this policy gives a node with samples values, the share of
+        samples above it as risk, and the capacity it frees as savings when
+        capacity is known."""
+        size = float(np.quantile(values, self.quantile)) * (1 + self.headroom)
+        out = {"size": size, "risk": float(np.mean(values > size))}
+        if capacity:
+            out["savings"] = capacity - size
+        return out
+
+
+def parse_policies(spec: str) -> List[Policy]:
+    return [Policy.parse(p) for p in spec.split(";") if p.strip()]
+
+
+def summarize_policies(report: dict, policies: List[Policy]) -> dict:
+    """Per policy and resource, the savings summed over the nodes with a
+    known capacity and the mean risk over all evaluated nodes."""
+    summary = {}
+    for policy in policies:
+        per_resource = {}
+        for resource in ("cpu", "memory"):
+            results = [e["policies"][policy.name][resource] for e in report["nodes"]
+                       if resource in e.get("policies", {}).get(policy.name, {})]
+            if results:
+                per_resource[resource] = {
+                    "savings": sum(r.get("savings", 0.0) for r in results),
+                    "risk": sum(r["risk"] for r in results) / len(results),
+                    "nodes": len(results),
+                }
+        summary[policy.name] = per_resource
+    return summary
+
+
+def fetch_capacity(prom: PrometheusConnect, query: str, label: str) -> dict:
+    """Latest value of query per node label; empty when the query fails."""
+    try:
+        result = prom.custom_query(query)
+    except Exception as e:  # the client raises its own error types
+        print(f"Cannot query {query} ({e}); policies are compared without savings.")
+        return {}
+    return {r["metric"].get(label): float(r["value"][1]) for r in result if r["metric"].get(label)}
+
+
+def find_anomaly(ts: pd.DataFrame, z_score: float) -> Optional[dict]:
+    """Compare the latest sample with the rest of the series; return it with
+    the series mean and its z-score when it is at least z_score standard
+    deviations away."""
+    values = ts["value"].astype(float)
+    if len(values) < 5:
+        return None
+    history, latest = values.iloc[:-1], values.iloc[-1]
+    mean, std = history.mean(), history.std()
+    if not std > 0:
+        return None
+    z = (latest - mean) / std
+    if abs(z) < z_score:
+        return None
+    return {"latest": float(latest), "mean": float(mean), "zScore": float(z)}
+
+
 def _to_prophet_df(ts: pd.DataFrame) -> pd.DataFrame:
     """MetricRangeDataFrame uses timestamp index + 'value'; Prophet needs 'ds' and 'y'."""
     if "value" not in ts.columns:
@@ -72,23 +295,403 @@ def forecast_prophet(ts: pd.DataFrame) -> tuple[float, float]:
     return float(forecast["yhat"].max()), float(forecast["yhat"].mean())
 
 
-def recommend_cpu(node: str, max_val: float, mean_val: float) -> str:
+# Status of a forecast: short of capacity, wasting capacity, or fine; or
+# withheld for too little history.
+SCALE_UP, UNDERUTILIZED, OK = "scale-up", "underutilized", "ok"
+INSUFFICIENT_DATA = "insufficient-data"
+
+CPU_RECOMMENDATIONS = {
+    SCALE_UP: "Consider adding CPU (scale up) or moving pods away from this node.",
+    UNDERUTILIZED: "Node is underutilized; consider consolidating pods and scaling down.",
+    OK: "Utilization looks healthy; no change needed.",
+}
+MEM_RECOMMENDATIONS = {
+    SCALE_UP: "High memory usage; consider increasing node size or moving memory-heavy workloads.",
+    UNDERUTILIZED: "Node memory underutilized; consider bin-packing or smaller instance.",
+    OK: "Memory utilization acceptable.",
+}
+
+
+def cpu_status(max_val: float, mean_val: float) -> str:
     if max_val > 0.8:
-        return "Consider adding CPU (scale up) or moving pods away from this node."
+        return SCALE_UP
     if mean_val < 0.2:
-        return "Node is underutilized; consider consolidating pods and scaling down."
-    return "Utilization looks healthy; no change needed."
+        return UNDERUTILIZED
+    return OK
 
 
-def recommend_mem(node: str, max_gb: float, mean_gb: float) -> str:
+def mem_status(max_gb: float, mean_gb: float) -> str:
     if max_gb > 14:
-        return "High memory usage; consider increasing node size or moving memory-heavy workloads."
+        return SCALE_UP
     if mean_gb < 2:
-        return "Node memory underutilized; consider bin-packing or smaller instance."
-    return "Memory utilization acceptable."
+        return UNDERUTILIZED
+    return OK
 
 
-def main():
+def observed_history(ts: pd.DataFrame, lookback_minutes: int) -> tuple[float, float]:
+    """Minutes between the first and last sample of ts, and a confidence in
+    [0, 1]: the share of the lookback's one-minute steps that have a sample,
+    so short and gappy series both score low."""
+    times = _sample_times(ts)
+    observed = (times.max() - times.min()).total_seconds() / 60 if len(times) else 0.0
+    confidence = min(1.0, len(ts) / lookback_minutes) if lookback_minutes > 0 else 0.0
+    return float(observed), round(confidence, 2)
+
+
+def withheld(observed: float) -> str:
+    return f"Only {observed:.0f} of the required {MIN_HISTORY_MINUTES} minutes of history; no recommendation yet."
+
+
+def recommend_cpu(node: str, max_val: float, mean_val: float) -> str:
+    return CPU_RECOMMENDATIONS[cpu_status(max_val, mean_val)]
+
+
+def recommend_mem(node: str, max_gb: float, mean_gb: float) -> str:
+    return MEM_RECOMMENDATIONS[mem_status(max_gb, mean_gb)]
+
+
+RESOURCE_KEYS = {
+    "cpu": ("forecastMaxCores", "forecastMeanCores"),
+    "memory": ("forecastMaxBytes", "forecastMeanBytes"),
+}
+
+
+def settings_fingerprint() -> str:
+    """Hash of the settings that shape recommendations, so changes they
+    caused can be told from changes in usage."""
+    settings = {
+        "lookbackMinutes": LOOKBACK_MINUTES,
+        "forecastMinutes": FORECAST_MINUTES,
+        "minHistoryMinutes": MIN_HISTORY_MINUTES,
+        "businessHours": [BUSINESS_HOURS, BUSINESS_HOURS_TZ, BUSINESS_HOURS_WEIGHT],
+    }
+    return hashlib.sha256(json.dumps(settings, sort_keys=True).encode()).hexdigest()[:16]
+
+
+def _moved(current: float, previous: float, up: str, down: str) -> List[str]:
+    if current > previous * (1 + CHANGE_THRESHOLD):
+        return [up]
+    if current < previous * (1 - CHANGE_THRESHOLD):
+        return [down]
+    return []
+
+
+def change_reasons(resource: str, previous: dict, current: dict, config_changed: bool) -> List[str]:
+    """Why resource's recommendation moved from previous to current: the
+    settings changed, enough history arrived, or the forecast peak or mean
+    moved by more than CHANGE_THRESHOLD. "forecast" when none of these."""
+    max_key, mean_key = RESOURCE_KEYS[resource]
+    reasons = []
+    if config_changed:
+        reasons.append("config-change")
+    if previous.get("status") == INSUFFICIENT_DATA:
+        reasons.append("more-history")
+    reasons += _moved(current[max_key], previous.get(max_key, 0.0), "new-peak", "lower-peak")
+    reasons += _moved(current[mean_key], previous.get(mean_key, 0.0), "usage-rise", "usage-drop")
+    return reasons or ["forecast"]
+
+
+def explain_changes(report: dict, previous: dict, fingerprint: str) -> dict:
+    """Attach a change with its reasons to every recommendation in report
+    whose status differs from the one in previous, and return the state to
+    keep for the next run."""
+    config_changed = bool(previous) and previous.get("fingerprint") != fingerprint
+    before = previous.get("nodes", {})
+    nodes = {}
+    for entry in report["nodes"]:
+        kept = {}
+        for resource, (max_key, mean_key) in RESOURCE_KEYS.items():
+            current = entry.get(resource)
+            if not current:
+                continue
+            kept[resource] = {"status": current["status"], max_key: current[max_key], mean_key: current[mean_key]}
+            prev = before.get(entry["node"], {}).get(resource)
+            if prev and prev["status"] != current["status"]:
+                current["change"] = {
+                    "previousStatus": prev["status"],
+                    "reasons": change_reasons(resource, prev, current, config_changed),
+                }
+        nodes[entry["node"]] = kept
+    return {"fingerprint": fingerprint, "nodes": nodes}
+
+
+def track_changes(report: dict) -> None:
+    """Explain changed recommendations against the previous run's state and
+    store this run's. Failing to reach the state only leaves the changes
+    out."""
+    try:
+        state = RecommendationState.in_cluster(RECOMMENDATION_STATE_CONFIGMAP)
+        current = explain_changes(report, state.load(), settings_fingerprint())
+        if not state.store(current):
+            print("Another run updated the recommendation state first; keeping its version.")
+    except (OSError, KeyError, ValueError) as e:
+        print(f"Cannot track recommendation changes in {RECOMMENDATION_STATE_CONFIGMAP}: {e!r}")
+
+
+def sign_payload(secret: str, timestamp: int, body: bytes) -> str:
+    """HMAC-SHA256 over "<timestamp>.<body>", as sent in the X-Binbots-Signature header."""
+    mac = hmac.new(secret.encode(), str(timestamp).encode() + b"." + body, hashlib.sha256)
+    return "sha256=" + mac.hexdigest()
+
+
+def verify_signature(
+    secret: str, timestamp: str, body: bytes, signature: str, now: Optional[float] = None
+) -> bool:
+    """Receiver-side check of a signed report; rejects timestamps older than five minutes to stop replays."""
+    try:
+        ts = int(timestamp)
+    except ValueError:
+        return False
+    if abs((time.time() if now is None else now) - ts) > SIGNATURE_TOLERANCE_SECONDS:
+        return False
+    return hmac.compare_digest(sign_payload(secret, ts, body), signature)
+
+
+def post_report(url: str, secret: str, report: dict) -> None:
+    """POST the report as JSON, signed when a secret is set."""
+    body = json.dumps(report, sort_keys=True).encode()
+    headers = {"Content-Type": "application/json"}
+    if secret:
+        ts = int(time.time())
+        headers[TIMESTAMP_HEADER] = str(ts)
+        headers[SIGNATURE_HEADER] = sign_payload(secret, ts, body)
+    req = urllib.request.Request(url, data=body, headers=headers, method="POST")
+    with urllib.request.urlopen(req, timeout=10) as resp:
+        resp.read()
+
+
+REPORT_HTML = Template("""<!DOCTYPE html>
+<html>
+<body style="font-family: sans-serif">
+<h2>Binbots capacity report</h2>
+<p>Generated $generated_at for $nodes node(s).</p>
+$maintenance
+<h3>Needs capacity ($scale_up_count)</h3>
+$scale_up
+<h3>Underutilized ($underutilized_count)</h3>
+$underutilized
+<h3>Anomalies ($anomaly_count)</h3>
+$anomalies
+<h3>All nodes</h3>
+<table border="1" cellpadding="4" cellspacing="0" style="border-collapse: collapse">
+<tr><th>Node</th><th>CPU forecast max / mean (cores)</th><th>CPU</th><th>Memory forecast max / mean (GiB)</th><th>Memory</th></tr>
+$rows
+</table>
+</body>
+</html>
+""")
+
+
+def _findings(report: dict, status: str) -> str:
+    items = []
+    for entry in report.get("nodes", []):
+        for resource in ("cpu", "memory"):
+            forecast = entry.get(resource)
+            if forecast and forecast.get("status") == status:
+                items.append(f"<li>{html.escape(entry['node'])} {resource}: {html.escape(forecast['recommendation'])}</li>")
+    return "<ul>" + "".join(items) + "</ul>" if items else "<p>None.</p>"
+
+
+def _anomalies(report: dict) -> str:
+    items = []
+    for a in report.get("anomalies", []):
+        scale, unit = (1024**3, "GiB") if a["resource"] == "memory" else (1, "cores")
+        items.append(
+            f"<li>{html.escape(a['node'])} {a['resource']}: {a['latest'] / scale:.2f} {unit}, "
+            f"{abs(a['zScore']):.1f} standard deviations {'above' if a['zScore'] > 0 else 'below'} "
+            f"its mean of {a['mean'] / scale:.2f}</li>"
+        )
+    return "<ul>" + "".join(items) + "</ul>" if items else "<p>None.</p>"
+
+
+def _forecast_cells(forecast: Optional[dict], max_key: str, mean_key: str, scale: float) -> str:
+    if not forecast:
+        return "<td>-</td><td>not enough data</td>"
+    return (
+        f"<td>{forecast[max_key] / scale:.2f} / {forecast[mean_key] / scale:.2f}</td>"
+        f"<td>{html.escape(forecast['recommendation'])}</td>"
+    )
+
+
+def render_report_html(report: dict) -> str:
+    """Render the report as an HTML email: capacity and waste findings, then every node."""
+    rows = []
+    for entry in report.get("nodes", []):
+        rows.append(
+            f"<tr><td>{html.escape(entry['node'])}</td>"
+            + _forecast_cells(entry.get("cpu"), "forecastMaxCores", "forecastMeanCores", 1)
+            + _forecast_cells(entry.get("memory"), "forecastMaxBytes", "forecastMeanBytes", 1024**3)
+            + "</tr>"
+        )
+    scale_up, underutilized = _findings(report, SCALE_UP), _findings(report, UNDERUTILIZED)
+    anomalies = _anomalies(report)
+    maintenance = ""
+    if report.get("maintenance"):
+        m = report["maintenance"]
+        maintenance = (
+            f"<p><b>Maintenance window in effect until {html.escape(m['end'])} "
+            f"({html.escape(m.get('reason') or 'no reason given')}); no forecasts were made.</b></p>"
+        )
+    return REPORT_HTML.substitute(
+        generated_at=html.escape(report.get("generatedAt", "")),
+        nodes=len(report.get("nodes", [])),
+        maintenance=maintenance,
+        scale_up_count=scale_up.count("<li>"),
+        scale_up=scale_up,
+        underutilized_count=underutilized.count("<li>"),
+        underutilized=underutilized,
+        anomaly_count=anomalies.count("<li>"),
+        anomalies=anomalies,
+        rows="\n".join(rows),
+    )
+
+
+def email_day(now: datetime, at: str) -> str:
+    """The day (YYYY-MM-DD) whose email is due at now (UTC): today from the
+    HH:MM send time at on, yesterday before it."""
+    hour, minute = (int(v) for v in at.split(":"))
+    start = now.replace(hour=hour, minute=minute, second=0, microsecond=0)
+    if now < start:
+        start -= timedelta(days=1)
+    return start.date().isoformat()
+
+
+class ConfigMapState:
+    """A value kept under KEY in a ConfigMap so every CronJob run sees it.
+    Updates carry the resourceVersion that was read, so of two runs racing
+    to update it only one succeeds."""
+
+    KEY = ""
+
+    def __init__(self, name: str, namespace: str, api: str, token: str, cafile: Optional[str] = None):
+        self.name = name
+        self.url = f"{api}/api/v1/namespaces/{namespace}/configmaps"
+        self.token = token
+        self.cafile = cafile
+        self.version: Optional[str] = None  # None until the ConfigMap exists
+
+    @classmethod
+    def in_cluster(cls, name: str) -> "ConfigMapState":
+        with open(f"{SERVICE_ACCOUNT_DIR}/namespace") as f:
+            namespace = f.read().strip()
+        with open(f"{SERVICE_ACCOUNT_DIR}/token") as f:
+            token = f.read().strip()
+        api = f"https://{os.environ['KUBERNETES_SERVICE_HOST']}:{os.environ['KUBERNETES_SERVICE_PORT']}"
+        return cls(name, namespace, api, token, f"{SERVICE_ACCOUNT_DIR}/ca.crt")
+
+    def _request(self, method: str, url: str, body: Optional[dict] = None) -> dict:
+        req = urllib.request.Request(url, method=method, headers={"Authorization": f"Bearer {self.token}"})
+        if body is not None:
+            req.data = json.dumps(body).encode()
+            req.add_header("Content-Type", "application/json")
+        ctx = ssl.create_default_context(cafile=self.cafile) if self.cafile else None
+        with urllib.request.urlopen(req, timeout=10, context=ctx) as resp:
+            return json.loads(resp.read())
+
+    def get(self) -> str:
+        """The value, empty when none was recorded."""
+        try:
+            cm = self._request("GET", f"{self.url}/{self.name}")
+        except urllib.error.HTTPError as e:
+            if e.code != 404:
+                raise
+            self.version = None
+            return ""
+        self.version = cm["metadata"]["resourceVersion"]
+        return (cm.get("data") or {}).get(self.KEY, "")
+
+    def put(self, value: str) -> bool:
+        """Record value; False when another run changed the ConfigMap since
+        it was read."""
+        cm = {"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": self.name}, "data": {self.KEY: value}}
+        try:
+            if self.version is None:
+                cm = self._request("POST", self.url, cm)
+            else:
+                cm["metadata"]["resourceVersion"] = self.version
+                cm = self._request("PUT", f"{self.url}/{self.name}", cm)
+        except urllib.error.HTTPError as e:
+            if e.code == 409:
+                return False
+            raise
+        self.version = cm["metadata"]["resourceVersion"]
+        return True
+
+
+class EmailState(ConfigMapState):
+    """Day of the last report email, so of two runs racing for a day only one
+    can claim it."""
+
+    KEY = "reportEmailLastSent"
+
+    def last_sent(self) -> str:
+        """The day of the last email, empty when none was recorded."""
+        return self.get()
+
+    def record(self, day: str) -> bool:
+        """Record day as sent; False when another run claimed it first."""
+        return self.put(day)
+
+
+class RecommendationState(ConfigMapState):
+    """Each node's statuses and forecasts of the last run, with the
+    fingerprint of the settings that produced them."""
+
+    KEY = "recommendations"
+
+    def load(self) -> dict:
+        value = self.get()
+        return json.loads(value) if value else {}
+
+    def store(self, state: dict) -> bool:
+        return self.put(json.dumps(state, sort_keys=True))
+
+
+def email_once(report: dict, state: EmailState, now: datetime, at: str, send) -> bool:
+    """Call send(report) unless the email for the day due at now was already
+    sent. The day is claimed in state before sending and released again when
+    sending fails, so the next run retries it. Returns whether it sent."""
+    day = email_day(now, at)
+    previous = state.last_sent()
+    if previous >= day:
+        return False
+    if not state.record(day):
+        print(f"Another run is sending the report email of {day}.")
+        return False
+    try:
+        send(report)
+    except OSError:
+        try:
+            state.record(previous)
+        except OSError as e:
+            print(f"Cannot release the report email of {day}; it will not be retried: {e}")
+        raise
+    return True
+
+
+def send_report_email(report: dict, host: str, port: int, sender: str, recipients: List[str],
+                      username: str = "", password: str = "", starttls: bool = True) -> None:
+    """Send the report as an HTML email with the JSON report as plain-text alternative."""
+    msg = EmailMessage()
+    msg["Subject"] = f"Binbots capacity report {report.get('generatedAt', '')[:10]}"
+    msg["From"] = sender
+    msg["To"] = ", ".join(recipients)
+    msg.set_content(json.dumps(report, indent=2, sort_keys=True))
+    msg.add_alternative(render_report_html(report), subtype="html")
+    with smtplib.SMTP(host, port, timeout=10) as smtp:
+        if starttls:
+            smtp.starttls()
+        if username:
+            smtp.login(username, password)
+        smtp.send_message(msg)
+
+
+def forecast_nodes(report: dict, windows: List[Window], business: Optional[BusinessHours] = None,
+                   policies: List[Policy] = ()) -> None:
+    """Forecast every node's CPU and memory into report, and flag anomalies.
+    With business, a node is only underutilized when its business-hours
+    weighted mean is low as well. Each of policies is evaluated on every
+    node and summarized in the report's policies."""
     prom = PrometheusConnect(url=PROM_URL, disable_ssl=True)
 
     cpu_df = fetch_timeseries(prom, CPU_QUERY)
@@ -109,28 +712,146 @@ def main():
         print("No node (or instance) labels found in metrics.")
         sys.exit(1)
 
+    capacity = {}
+    if policies:
+        capacity = {
+            "cpu": fetch_capacity(prom, CPU_CAPACITY_QUERY, label_col),
+            "memory": fetch_capacity(prom, MEM_CAPACITY_QUERY, label_col),
+        }
+
     for node in sorted(nodes):
         print(f"\n--- Node: {node} ---")
+        entry = {"node": node}
+        report["nodes"].append(entry)
         if not cpu_df.empty and label_col in cpu_df.columns:
-            ts = cpu_df[cpu_df[label_col] == node]
+            ts = exclude_windows(cpu_df[cpu_df[label_col] == node], windows)
             if len(ts) >= 5:
                 max_pred, mean_pred = forecast_prophet(ts)
-                rec = recommend_cpu(node, max_pred, mean_pred)
-                print(f"  CPU: forecast_max={max_pred:.2f} cores, forecast_mean={mean_pred:.2f} -> {rec}")
+                sizing_mean = mean_pred
+                if business is not None:
+                    weighted = business.weighted_mean(ts)
+                    sizing_mean = max(mean_pred, weighted)
+                status = cpu_status(max_pred, sizing_mean)
+                rec = recommend_cpu(node, max_pred, sizing_mean)
+                observed, confidence = observed_history(ts, LOOKBACK_MINUTES)
+                if observed < MIN_HISTORY_MINUTES:
+                    status, rec = INSUFFICIENT_DATA, withheld(observed)
+                print(f"  CPU: forecast_max={max_pred:.2f} cores, forecast_mean={mean_pred:.2f}, confidence={confidence:.2f} -> {rec}")
+                entry["cpu"] = {
+                    "forecastMaxCores": max_pred,
+                    "forecastMeanCores": mean_pred,
+                    "observedMinutes": observed,
+                    "confidence": confidence,
+                    "status": status,
+                    "recommendation": rec,
+                }
+                if business is not None:
+                    entry["cpu"]["businessWeightedMeanCores"] = weighted
+                values = ts["value"].astype(float).to_numpy()
+                for policy in policies:
+                    result = policy.evaluate(values, capacity["cpu"].get(node))
+                    entry.setdefault("policies", {}).setdefault(policy.name, {})["cpu"] = result
+                anomaly = find_anomaly(ts, ANOMALY_Z_SCORE)
+                if anomaly:
+                    print(f"  CPU: anomaly, z-score {anomaly['zScore']:.1f}")
+                    report["anomalies"].append({"node": node, "resource": "cpu", **anomaly})
             else:
                 print(f"  CPU: not enough points ({len(ts)}) for trend.")
         if not mem_df.empty and label_col in mem_df.columns:
-            ts = mem_df[mem_df[label_col] == node]
+            ts = exclude_windows(mem_df[mem_df[label_col] == node], windows)
             if len(ts) >= 5:
                 max_pred, mean_pred = forecast_prophet(ts)
+                sizing_mean = mean_pred
+                if business is not None:
+                    weighted = business.weighted_mean(ts)
+                    sizing_mean = max(mean_pred, weighted)
                 max_gb = max_pred / (1024**3)
                 mean_gb = mean_pred / (1024**3)
-                rec = recommend_mem(node, max_gb, mean_gb)
-                print(f"  Memory: forecast_max={max_gb:.2f} GiB, mean={mean_gb:.2f} GiB -> {rec}")
+                sizing_gb = sizing_mean / (1024**3)
+                status = mem_status(max_gb, sizing_gb)
+                rec = recommend_mem(node, max_gb, sizing_gb)
+                observed, confidence = observed_history(ts, LOOKBACK_MINUTES)
+                if observed < MIN_HISTORY_MINUTES:
+                    status, rec = INSUFFICIENT_DATA, withheld(observed)
+                print(f"  Memory: forecast_max={max_gb:.2f} GiB, mean={mean_gb:.2f} GiB, confidence={confidence:.2f} -> {rec}")
+                entry["memory"] = {
+                    "forecastMaxBytes": max_pred,
+                    "forecastMeanBytes": mean_pred,
+                    "observedMinutes": observed,
+                    "confidence": confidence,
+                    "status": status,
+                    "recommendation": rec,
+                }
+                if business is not None:
+                    entry["memory"]["businessWeightedMeanBytes"] = weighted
+                values = ts["value"].astype(float).to_numpy()
+                for policy in policies:
+                    result = policy.evaluate(values, capacity["memory"].get(node))
+                    entry.setdefault("policies", {}).setdefault(policy.name, {})["memory"] = result
+                anomaly = find_anomaly(ts, ANOMALY_Z_SCORE)
+                if anomaly:
+                    print(f"  Memory: anomaly, z-score {anomaly['zScore']:.1f}")
+                    report["anomalies"].append({"node": node, "resource": "memory", **anomaly})
             else:
                 print(f"  Memory: not enough points ({len(ts)}) for trend.")
+    if policies:
+        report["policies"] = summarize_policies(report, policies)
+        for name, resources in report["policies"].items():
+            for resource, r in resources.items():
+                print(f"Policy {name} {resource}: savings={r['savings']:.2f}, risk={r['risk']:.3f} over {r['nodes']} node(s)")
     print()
 
 
+def main():
+    business = None
+    if BUSINESS_HOURS:
+        try:
+            business = BusinessHours.parse(BUSINESS_HOURS, BUSINESS_HOURS_TZ, BUSINESS_HOURS_WEIGHT)
+        except ValueError as e:
+            print(f"Invalid BUSINESS_HOURS settings: {e}")
+            sys.exit(1)
+    if MIN_HISTORY_MINUTES > LOOKBACK_MINUTES:
+        print(f"MIN_HISTORY_MINUTES ({MIN_HISTORY_MINUTES}) exceeds LOOKBACK_MINUTES ({LOOKBACK_MINUTES}); every recommendation is withheld.")
+    try:
+        policies = parse_policies(POLICIES)
+    except ValueError as e:
+        print(f"Invalid POLICIES: {e}")
+        sys.exit(1)
+    windows = fetch_maintenance_windows(EXPORTER_URL)
+    now = pd.Timestamp.now(tz="UTC")
+    report = {"generatedAt": now.isoformat(), "nodes": [], "anomalies": []}
+    window = active_window(windows, now)
+    if window is not None:
+        # The daily email still goes out; it says why it has no forecasts.
+        print(f"Maintenance window in effect until {window.end} ({window.reason or 'no reason given'}); skipping forecasts.")
+        report["maintenance"] = {"end": window.end, "reason": window.reason or ""}
+    else:
+        forecast_nodes(report, windows, business, policies)
+        if EXPLAIN_CHANGES:
+            track_changes(report)
+
+        if REPORT_WEBHOOK_URL:
+            if not REPORT_WEBHOOK_SECRET:
+                print("REPORT_WEBHOOK_SECRET is not set; sending the report unsigned.")
+            try:
+                post_report(REPORT_WEBHOOK_URL, REPORT_WEBHOOK_SECRET, report)
+            except OSError as e:
+                print(f"Cannot send the report to {REPORT_WEBHOOK_URL}: {e}")
+                sys.exit(1)
+
+    if SMTP_HOST and REPORT_EMAIL_TO:
+        def send(r: dict) -> None:
+            send_report_email(r, SMTP_HOST, SMTP_PORT, REPORT_EMAIL_FROM, REPORT_EMAIL_TO,
+                              SMTP_USERNAME, SMTP_PASSWORD, SMTP_STARTTLS)
+
+        try:
+            state = EmailState.in_cluster(REPORT_EMAIL_STATE_CONFIGMAP)
+            if email_once(report, state, datetime.now(timezone.utc), REPORT_EMAIL_AT, send):
+                print(f"Report emailed to {', '.join(REPORT_EMAIL_TO)}.")
+        except (OSError, KeyError) as e:
+            print(f"Cannot email the report via {SMTP_HOST}:{SMTP_PORT}: {e!r}")
+            sys.exit(1)
+
+
 if __name__ == "__main__":
     main()
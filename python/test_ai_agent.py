@@ -1,5 +1,9 @@
 """Tests for the AI agent: recommendations and forecast logic."""
+import http.server
+import json
 import os
+import threading
+import numpy as np
 import pytest
 import pandas as pd
 from datetime import datetime, timedelta
@@ -7,7 +11,29 @@ from datetime import datetime, timedelta
 # Set env before importing ai_agent so PrometheusConnect isn't required for unit tests
 os.environ.setdefault("PROMETHEUS_URL", "http://localhost:9090")
 
+from binbots_client import Window
+import ai_agent
 from ai_agent import (
+    SIGNATURE_HEADER,
+    TIMESTAMP_HEADER,
+    BusinessHours,
+    EmailState,
+    Policy,
+    RecommendationState,
+    active_window,
+    email_day,
+    email_once,
+    exclude_windows,
+    explain_changes,
+    observed_history,
+    parse_policies,
+    summarize_policies,
+    find_anomaly,
+    post_report,
+    render_report_html,
+    send_report_email,
+    sign_payload,
+    verify_signature,
     recommend_cpu,
     recommend_mem,
     forecast_prophet,
@@ -75,3 +101,345 @@ class TestForecastProphet:
         ts = pd.DataFrame({"y": [1.0, 2.0, 3.0, 4.0, 5.0]})
         max_v, mean_v = forecast_prophet(ts)
         assert max_v >= 0 and mean_v >= 0
+
+
+DRILL = Window(start="2024-01-01T00:02:00Z", end="2024-01-01T00:04:00Z", reason="chaos drill")
+
+
+class TestFindAnomaly:
+    def test_spike(self):
+        ts = pd.DataFrame({"value": [1.0, 1.1, 0.9, 1.0, 1.05, 0.95, 4.0]})
+        a = find_anomaly(ts, 3)
+        assert a is not None and a["latest"] == 4.0 and a["zScore"] > 3
+
+    def test_normal_and_flat(self):
+        assert find_anomaly(pd.DataFrame({"value": [1.0, 1.1, 0.9, 1.0, 1.05, 0.95, 1.1]}), 3) is None
+        assert find_anomaly(pd.DataFrame({"value": [2.0] * 6}), 3) is None
+        assert find_anomaly(pd.DataFrame({"value": [1.0, 9.0]}), 3) is None
+
+
+class TestMaintenanceWindows:
+    def test_active_window(self):
+        assert active_window([DRILL], pd.Timestamp("2024-01-01T00:03:00Z")) == DRILL
+        assert active_window([DRILL], pd.Timestamp("2024-01-01T00:04:00Z")) is None
+        assert active_window([], pd.Timestamp("2024-01-01T00:03:00Z")) is None
+
+    def test_exclude_windows(self):
+        ts = pd.DataFrame({"value": [1.0, 2.0, 50.0, 60.0, 5.0]}, index=pd.date_range("2024-01-01", periods=5, freq="min"))
+        out = exclude_windows(ts, [DRILL])
+        assert list(out["value"]) == [1.0, 2.0, 5.0]
+
+    def test_exclude_windows_ds_column(self):
+        ts = pd.DataFrame({"ds": pd.date_range("2024-01-01", periods=5, freq="min", tz="UTC"), "value": range(5)})
+        assert list(exclude_windows(ts, [DRILL])["value"]) == [0, 1, 4]
+        assert exclude_windows(ts, []) is ts
+
+
+class TestBusinessHours:
+    def test_weighted_mean(self):
+        # 2024-01-01 is a Monday; Berlin is UTC+1 in January.
+        hours = BusinessHours.parse("09:00-18:00", "Europe/Berlin", 3)
+        ts = pd.DataFrame(
+            {"value": [0.0, 1.0, 1.0, 0.0]},
+            index=pd.to_datetime(["2024-01-01T07:30Z", "2024-01-01T08:30Z", "2024-01-01T16:30Z", "2024-01-01T17:30Z"]),
+        )
+        assert list(hours.contains(pd.DatetimeIndex(ts.index))) == [False, True, True, False]
+        assert hours.weighted_mean(ts) == pytest.approx(0.75)
+
+    def test_weekend_and_overnight(self):
+        saturday = pd.DatetimeIndex(pd.to_datetime(["2024-01-06T12:00Z"]))
+        assert not BusinessHours.parse("09:00-18:00", "UTC", 2).contains(saturday).any()
+        night = BusinessHours.parse("22:00-06:00", "UTC", 2)
+        times = pd.DatetimeIndex(pd.to_datetime(["2024-01-02T23:00Z", "2024-01-03T05:59Z", "2024-01-03T12:00Z"]))
+        assert list(night.contains(times)) == [True, True, False]
+
+    def test_parse_errors(self):
+        for hours, tz, weight in [("09:00", "UTC", 3), ("09:00-18:00", "Mars/Olympus", 3), ("09:00-18:00", "UTC", 0.5)]:
+            with pytest.raises(ValueError):
+                BusinessHours.parse(hours, tz, weight)
+
+
+class TestObservedHistory:
+    def test_full_and_short(self):
+        full = pd.DataFrame({"value": [1.0] * 120}, index=pd.date_range("2024-01-01", periods=120, freq="min"))
+        assert observed_history(full, 120) == (119.0, 1.0)
+        # Half an hour of data in a two-hour lookback.
+        assert observed_history(full.iloc[:31], 120) == (30.0, 0.26)
+
+    def test_gaps(self):
+        # Two hours apart, but only every fourth minute has a sample.
+        ts = pd.DataFrame({"value": [1.0] * 31}, index=pd.date_range("2024-01-01", periods=31, freq="4min"))
+        assert observed_history(ts, 120) == (120.0, 0.26)
+
+
+class TestReportSigning:
+    def test_sign_and_verify(self):
+        body = b'{"nodes": []}'
+        sig = sign_payload("s3cret", 1700000000, body)
+        assert sig.startswith("sha256=")
+        assert verify_signature("s3cret", "1700000000", body, sig, now=1700000060)
+
+    def test_rejects_tampering_and_replays(self):
+        body = b'{"nodes": []}'
+        sig = sign_payload("s3cret", 1700000000, body)
+        assert not verify_signature("s3cret", "1700000000", b'{"nodes": [1]}', sig, now=1700000000)
+        assert not verify_signature("other", "1700000000", body, sig, now=1700000000)
+        assert not verify_signature("s3cret", "1700000001", body, sig, now=1700000000)
+        assert not verify_signature("s3cret", "1700000000", body, sig, now=1700000000 + 301)
+        assert not verify_signature("s3cret", "yesterday", body, sig)
+
+    def test_post_report_signs(self):
+        received = {}
+
+        class Handler(http.server.BaseHTTPRequestHandler):
+            def do_POST(self):
+                received["headers"] = dict(self.headers)
+                received["body"] = self.rfile.read(int(self.headers["Content-Length"]))
+                self.send_response(204)
+                self.end_headers()
+
+            def log_message(self, *args):
+                pass
+
+        srv = http.server.HTTPServer(("127.0.0.1", 0), Handler)
+        thread = threading.Thread(target=srv.handle_request)
+        thread.start()
+        post_report(f"http://127.0.0.1:{srv.server_port}/hook", "s3cret", {"nodes": [{"node": "a"}]})
+        thread.join()
+        srv.server_close()
+
+        h = received["headers"]
+        assert verify_signature("s3cret", h[TIMESTAMP_HEADER], received["body"], h[SIGNATURE_HEADER])
+        assert json.loads(received["body"]) == {"nodes": [{"node": "a"}]}
+
+
+REPORT = {
+    "generatedAt": "2024-05-01T07:00:00+00:00",
+    "nodes": [
+        {
+            "node": "node-<a>",
+            "cpu": {"forecastMaxCores": 0.9, "forecastMeanCores": 0.7, "status": "scale-up", "recommendation": "Scale up & move pods"},
+            "memory": {"forecastMaxBytes": 8 * 1024**3, "forecastMeanBytes": 1024**3, "status": "underutilized", "recommendation": "Smaller instance"},
+        },
+        {"node": "node-b"},
+    ],
+    "anomalies": [
+        {"node": "node-b", "resource": "memory", "latest": 6 * 1024**3, "mean": 2 * 1024**3, "zScore": 4.5},
+    ],
+}
+
+
+class FakeConfigMaps(http.server.BaseHTTPRequestHandler):
+    """Just enough of the ConfigMap API for ConfigMapState: create, get, and
+    update with a resourceVersion check."""
+
+    store = {}
+
+    def _reply(self, code, body=None):
+        data = json.dumps(body or {}).encode()
+        self.send_response(code)
+        self.send_header("Content-Type", "application/json")
+        self.send_header("Content-Length", str(len(data)))
+        self.end_headers()
+        self.wfile.write(data)
+
+    def _body(self):
+        return json.loads(self.rfile.read(int(self.headers["Content-Length"])))
+
+    def do_GET(self):
+        name = self.path.rsplit("/", 1)[1]
+        if name not in self.store:
+            return self._reply(404)
+        self._reply(200, self.store[name])
+
+    def do_POST(self):
+        cm = self._body()
+        if cm["metadata"]["name"] in self.store:
+            return self._reply(409)
+        cm["metadata"]["resourceVersion"] = "1"
+        self.store[cm["metadata"]["name"]] = cm
+        self._reply(201, cm)
+
+    def do_PUT(self):
+        cm = self._body()
+        current = self.store.get(cm["metadata"]["name"])
+        if current is None or current["metadata"]["resourceVersion"] != cm["metadata"]["resourceVersion"]:
+            return self._reply(409)
+        cm["metadata"]["resourceVersion"] = str(int(current["metadata"]["resourceVersion"]) + 1)
+        self.store[cm["metadata"]["name"]] = cm
+        self._reply(200, cm)
+
+    def log_message(self, *args):
+        pass
+
+
+@pytest.fixture
+def configmaps():
+    FakeConfigMaps.store = {}
+    srv = http.server.HTTPServer(("127.0.0.1", 0), FakeConfigMaps)
+    thread = threading.Thread(target=srv.serve_forever)
+    thread.start()
+    yield f"http://127.0.0.1:{srv.server_port}"
+    srv.shutdown()
+    thread.join()
+    srv.server_close()
+
+
+class TestReportEmail:
+    def test_render_report_html(self):
+        out = render_report_html(REPORT)
+        assert "Needs capacity (1)" in out and "Underutilized (1)" in out
+        assert "node-&lt;a&gt; cpu: Scale up &amp; move pods" in out
+        assert "node-&lt;a&gt; memory: Smaller instance" in out
+        assert "<td>8.00 / 1.00</td>" in out
+        assert "not enough data" in out
+        assert "Anomalies (1)" in out and "node-b memory: 6.00 GiB, 4.5 standard deviations above its mean of 2.00" in out
+        assert "Maintenance window" not in out
+        assert "<a>" not in out
+
+    def test_render_report_html_maintenance(self):
+        out = render_report_html({"generatedAt": "2024-05-01T07:00:00+00:00", "nodes": [], "anomalies": [],
+                                  "maintenance": {"end": "2024-05-01T09:00:00Z", "reason": "<upgrade>"}})
+        assert "Maintenance window in effect until 2024-05-01T09:00:00Z (&lt;upgrade&gt;)" in out
+        assert "Anomalies (0)" in out
+
+    def test_email_day(self):
+        assert email_day(datetime(2024, 5, 1, 7, 0), "07:00") == "2024-05-01"
+        assert email_day(datetime(2024, 5, 1, 23, 59), "07:00") == "2024-05-01"
+        assert email_day(datetime(2024, 5, 2, 6, 59), "07:00") == "2024-05-01"
+
+    def test_email_once(self, configmaps):
+        state = EmailState("k8s-ai-agent-state", "monitoring", configmaps, "token")
+        sent = []
+        # Before the send time yesterday's email is due; none was recorded.
+        assert email_once(REPORT, state, datetime(2024, 5, 1, 6, 50), "07:00", sent.append)
+        # A retried run of the same day sends nothing.
+        assert not email_once(REPORT, state, datetime(2024, 5, 1, 6, 55), "07:00", sent.append)
+        # A run delayed past the usual slot still sends today's.
+        assert email_once(REPORT, state, datetime(2024, 5, 1, 9, 30), "07:00", sent.append)
+        assert not email_once(REPORT, state, datetime(2024, 5, 1, 9, 40), "07:00", sent.append)
+        assert len(sent) == 2
+        assert FakeConfigMaps.store["k8s-ai-agent-state"]["data"] == {EmailState.KEY: "2024-05-01"}
+
+    def test_email_once_failure_is_retried(self, configmaps):
+        state = EmailState("k8s-ai-agent-state", "monitoring", configmaps, "token")
+
+        def fail(report):
+            raise OSError("connection refused")
+
+        with pytest.raises(OSError):
+            email_once(REPORT, state, datetime(2024, 5, 1, 7, 0), "07:00", fail)
+        assert FakeConfigMaps.store["k8s-ai-agent-state"]["data"] == {EmailState.KEY: ""}
+        sent = []
+        assert email_once(REPORT, state, datetime(2024, 5, 1, 7, 10), "07:00", sent.append)
+        assert sent == [REPORT]
+
+    def test_email_state_conflict(self, configmaps):
+        a = EmailState("k8s-ai-agent-state", "monitoring", configmaps, "token")
+        b = EmailState("k8s-ai-agent-state", "monitoring", configmaps, "token")
+        assert a.last_sent() == "" and b.last_sent() == ""
+        assert a.record("2024-05-01")
+        assert not b.record("2024-05-01")
+        assert b.last_sent() == "2024-05-01"
+
+    def test_send_report_email(self, monkeypatch):
+        sent = {}
+
+        class FakeSMTP:
+            def __init__(self, host, port, timeout):
+                sent["server"] = (host, port)
+
+            def __enter__(self):
+                return self
+
+            def __exit__(self, *args):
+                pass
+
+            def starttls(self):
+                sent["starttls"] = True
+
+            def login(self, user, password):
+                sent["login"] = (user, password)
+
+            def send_message(self, msg):
+                sent["msg"] = msg
+
+        monkeypatch.setattr(ai_agent.smtplib, "SMTP", FakeSMTP)
+        send_report_email(REPORT, "smtp.example.com", 587, "binbots@example.com", ["ops@example.com", "cto@example.com"], "user", "pw")
+        msg = sent["msg"]
+        assert sent["server"] == ("smtp.example.com", 587) and sent["starttls"] and sent["login"] == ("user", "pw")
+        assert msg["To"] == "ops@example.com, cto@example.com"
+        assert msg["Subject"] == "Binbots capacity report 2024-05-01"
+        assert "Needs capacity (1)" in msg.get_body(("html",)).get_content()
+        assert json.loads(msg.get_body(("plain",)).get_content()) == REPORT
+
+
+class TestExplainChanges:
+    PREVIOUS = {
+        "fingerprint": "a",
+        "nodes": {"node-a": {
+            "cpu": {"status": "ok", "forecastMaxCores": 0.5, "forecastMeanCores": 0.3},
+            "memory": {"status": "insufficient-data", "forecastMaxBytes": 4e9, "forecastMeanBytes": 3e9},
+        }},
+    }
+
+    def report(self, cpu_status="scale-up"):
+        return {"nodes": [
+            {"node": "node-a",
+             "cpu": {"status": cpu_status, "forecastMaxCores": 0.9, "forecastMeanCores": 0.31},
+             "memory": {"status": "ok", "forecastMaxBytes": 4e9, "forecastMeanBytes": 2e9}},
+            {"node": "node-new", "cpu": {"status": "ok", "forecastMaxCores": 0.5, "forecastMeanCores": 0.3}},
+        ]}
+
+    def test_reasons(self):
+        report = self.report()
+        state = explain_changes(report, self.PREVIOUS, "a")
+        cpu, memory = report["nodes"][0]["cpu"], report["nodes"][0]["memory"]
+        assert cpu["change"] == {"previousStatus": "ok", "reasons": ["new-peak"]}
+        assert memory["change"] == {"previousStatus": "insufficient-data", "reasons": ["more-history", "usage-drop"]}
+        assert "change" not in report["nodes"][1]["cpu"]
+        assert state["fingerprint"] == "a"
+        assert state["nodes"]["node-a"]["cpu"] == {"status": "scale-up", "forecastMaxCores": 0.9, "forecastMeanCores": 0.31}
+        assert set(state["nodes"]) == {"node-a", "node-new"}
+
+    def test_config_change_and_unchanged(self):
+        report = self.report(cpu_status="ok")
+        explain_changes(report, self.PREVIOUS, "b")
+        assert "change" not in report["nodes"][0]["cpu"]
+        assert report["nodes"][0]["memory"]["change"]["reasons"][0] == "config-change"
+        # The first run has nothing to compare with.
+        report = self.report()
+        explain_changes(report, {}, "a")
+        assert "change" not in report["nodes"][0]["cpu"]
+
+    def test_state_round_trip(self, configmaps):
+        state = RecommendationState("k8s-ai-agent-recommendations", "monitoring", configmaps, "token")
+        assert state.load() == {}
+        assert state.store(self.PREVIOUS)
+        assert RecommendationState("k8s-ai-agent-recommendations", "monitoring", configmaps, "token").load() == self.PREVIOUS
+
+
+class TestPolicies:
+    def test_parse(self):
+        assert parse_policies("p95+15%; p99") == [Policy("p95+15%", 0.95, 0.15), Policy("p99", 0.99, 0.0)]
+        assert parse_policies("") == []
+        for bad in ("p0", "p101", "95", "p95+x%"):
+            with pytest.raises(ValueError):
+                Policy.parse(bad)
+
+    def test_evaluate(self):
+        values = np.arange(1, 101, dtype=float)  # 1..100 cores
+        p95 = Policy.parse("p95+10%").evaluate(values, 200.0)
+        assert p95["size"] == pytest.approx(np.quantile(values, 0.95) * 1.1)
+        assert p95["risk"] == 0.0 and p95["savings"] == pytest.approx(200 - p95["size"])
+        p50 = Policy.parse("p50").evaluate(values, None)
+        assert p50["risk"] == 0.5 and "savings" not in p50
+
+    def test_summarize(self):
+        report = {"nodes": [
+            {"node": "a", "policies": {"p99": {"cpu": {"size": 1.0, "risk": 0.1, "savings": 3.0}}}},
+            {"node": "b", "policies": {"p99": {"cpu": {"size": 1.0, "risk": 0.3}}}},
+            {"node": "c"},
+        ]}
+        summary = summarize_policies(report, [Policy.parse("p99")])
+        assert summary == {"p99": {"cpu": {"savings": 3.0, "risk": pytest.approx(0.2), "nodes": 2}}}
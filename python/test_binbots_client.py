@@ -0,0 +1,94 @@
+"""Tests for the generated API client against a stub exporter."""
+import json
+import threading
+from http.server import BaseHTTPRequestHandler, HTTPServer
+from urllib.parse import parse_qs, urlsplit
+
+import pytest
+
+from binbots_client import APIError, Client, Event, NodeSnapshot
+
+SNAPSHOT = {
+    "timestamp": "2024-05-01T12:00:00Z",
+    "nodes": [{"name": "a", "cpuCores": 1.5, "memoryBytes": 1024, "activePods": 3}],
+}
+
+
+TOKEN = "s3cret"
+
+
+class _Handler(BaseHTTPRequestHandler):
+    status = 200
+    events = []
+
+    def do_GET(self):
+        url = urlsplit(self.path)
+        if url.path == "/api/v1/events":
+            since = parse_qs(url.query).get("since", [""])[0]
+            self._send(200, {"events": [e for e in _Handler.events if e["time"] >= since]})
+        elif url.path != "/api/v1/snapshot":
+            self._send(404, {"error": "not found"})
+        elif _Handler.status != 200:
+            self._send(_Handler.status, {"error": "no collection cycle has completed yet"})
+        else:
+            self._send(200, SNAPSHOT)
+
+    def do_POST(self):
+        if self.path != "/api/v1/events":
+            self._send(404, {"error": "not found"})
+        elif self.headers.get("Authorization") != "Bearer " + TOKEN:
+            self._send(401, {"error": "missing or invalid bearer token"})
+        else:
+            event = json.loads(self.rfile.read(int(self.headers["Content-Length"])))
+            event["id"] = len(_Handler.events) + 1
+            _Handler.events.append(event)
+            self._send(201, event)
+
+    def _send(self, status, body):
+        data = json.dumps(body).encode()
+        self.send_response(status)
+        self.send_header("Content-Type", "application/json")
+        self.send_header("Content-Length", str(len(data)))
+        self.end_headers()
+        self.wfile.write(data)
+
+    def log_message(self, *args):
+        pass
+
+
+@pytest.fixture
+def client():
+    server = HTTPServer(("127.0.0.1", 0), _Handler)
+    thread = threading.Thread(target=server.serve_forever, daemon=True)
+    thread.start()
+    yield Client(f"http://127.0.0.1:{server.server_port}/")
+    server.shutdown()
+    _Handler.status = 200
+    _Handler.events = []
+
+
+class TestClient:
+    def test_get_snapshot(self, client):
+        snap = client.get_snapshot()
+        assert snap.timestamp == "2024-05-01T12:00:00Z"
+        assert snap.nodes == [NodeSnapshot(active_pods=3, cpu_cores=1.5, memory_bytes=1024, name="a")]
+
+    def test_error(self, client):
+        _Handler.status = 503
+        with pytest.raises(APIError) as exc:
+            client.get_snapshot()
+        assert exc.value.status == 503
+        assert "no collection cycle" in exc.value.message
+
+    def test_events(self, client):
+        event = Event(type="deploy", title="checkout v42", time="2024-05-01T12:00:00Z", source="argocd")
+        with pytest.raises(APIError) as exc:
+            client.create_event(event)
+        assert exc.value.status == 401
+
+        client.token = TOKEN
+        created = client.create_event(event)
+        assert created.id == 1
+        assert created.source == "argocd"
+        assert client.list_events().events == [created]
+        assert client.list_events(since="2024-05-01T13:00:00Z").events == []